@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	grpcadapter "github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/config"
+)
+
+const calendarTokenUsage = `usage: grpc-task-manager calendar-token <user>
+
+Prints the token of a calendar feed subscription for user, signed with CALENDAR_FEED_SECRET and valid
+for CALENDAR_FEED_TOKEN_TTL. Calendar apps subscribe to the feed of the tasks assigned to user with the
+token as the token query parameter.`
+
+// runCalendarToken implements the calendar-token subcommand, which issues calendar feed tokens without
+// starting the server.
+func runCalendarToken(args []string, appConfig *config.AppConfig) error {
+	if len(args) != 1 {
+		return errors.New(calendarTokenUsage)
+	}
+
+	token, expires, err := grpcadapter.NewCalendarTokens(appConfig.CalendarFeedSecret, appConfig.CalendarFeedTokenTTL).Issue(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+	fmt.Fprintf(os.Stderr, "The token expires at %s\n", expires.UTC().Format(time.RFC3339))
+	return nil
+}
@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/cache"
+	storage "github.com/Sunf1ower113/grpc-task-manager/internal/adapters/db"
+	grpcadapter "github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/memory"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/composites"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/config"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
 	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/redis"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/sqlite"
 	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
+	"os"
+	_ "time/tzdata" // Exports render timestamps in IANA time zones, also on hosts without zoneinfo.
 )
 
 func main() {
@@ -24,33 +35,183 @@ func main() {
 	}
 	defer logger.Sync()
 
-	database, err := postgres.NewDB(
-		appConfig.DBUser,
-		appConfig.DBPassword,
-		appConfig.DBName,
-		appConfig.DBHost,
-		appConfig.DBPort,
-		logger,
-	)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], appConfig, logger); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "calendar-token" {
+		if err := runCalendarToken(os.Args[2:], appConfig); err != nil {
+			log.Fatalf("Issuing calendar feed token failed: %v", err)
+		}
+		return
+	}
+
+	store := composites.Store{Driver: appConfig.StorageDriver}
+	var pool *pgxpool.Pool
+	var replicas []composites.TaskReplica
+	switch appConfig.StorageDriver {
+	case "postgres":
+		store.Postgres, err = postgres.NewDB(appConfig.Postgres(), logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize database", zap.Error(err))
+		}
+		defer store.Postgres.Close()
+	case "sqlite":
+		store.SQLite, err = sqlite.NewDB(appConfig.SQLite(), logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize SQLite database", zap.Error(err))
+		}
+		defer store.SQLite.Close()
+	case "memory":
+		store.Memory = memory.NewStore(logger)
+	default:
+		logger.Fatal("Unknown storage driver", zap.String("driver", appConfig.StorageDriver))
+	}
+
+	if appConfig.StorageDriver == "postgres" {
+		switch appConfig.DBDriver {
+		case "pq":
+		case "pgx":
+			pool, err = postgres.NewPool(appConfig.Postgres(), logger)
+			if err != nil {
+				logger.Fatal("Failed to initialize database connection pool", zap.Error(err))
+			}
+			defer pool.Close()
+		default:
+			logger.Fatal("Unknown database driver", zap.String("driver", appConfig.DBDriver))
+		}
+
+		for i, dsn := range appConfig.DBReplicaDSNs {
+			replicaConfig := appConfig.Postgres().ForReplica(dsn)
+			replicaDB, err := postgres.OpenDB(replicaConfig)
+			if err != nil {
+				logger.Fatal("Failed to initialize read replica", zap.Int("replica", i), zap.Error(err))
+			}
+			defer replicaDB.Close()
+			replica := composites.TaskReplica{DB: replicaDB}
+			if pool != nil {
+				replica.Pool, err = postgres.OpenPool(replicaConfig, logger)
+				if err != nil {
+					logger.Fatal("Failed to initialize read replica connection pool", zap.Int("replica", i), zap.Error(err))
+				}
+				defer replica.Pool.Close()
+			}
+			replicas = append(replicas, replica)
+		}
+	} else if len(appConfig.DBReplicaDSNs) > 0 {
+		logger.Fatal("Read replicas need the postgres storage driver", zap.String("driver", appConfig.StorageDriver))
+	}
+
+	var taskCache cache.Store
+	switch appConfig.CacheBackend {
+	case "none":
+	case "lru":
+		taskCache = cache.NewLRU(appConfig.CacheSize, appConfig.CacheTTL)
+	case "redis":
+		redisClient, err := redis.NewClient(appConfig.Redis(), logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize Redis client", zap.Error(err))
+		}
+		defer redisClient.Close()
+		taskCache = cache.NewRedis(redisClient, appConfig.CacheTTL)
+	default:
+		logger.Fatal("Unknown cache backend", zap.String("backend", appConfig.CacheBackend))
+	}
+
+	projectComposite, err := composites.NewProjectComposite(store, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize project composite", zap.Error(err))
+	}
+
+	customFieldComposite, err := composites.NewCustomFieldComposite(store, projectComposite.Repository, logger)
 	if err != nil {
-		logger.Fatal("Failed to initialize database", zap.Error(err))
+		logger.Fatal("Failed to initialize custom field composite", zap.Error(err))
 	}
-	defer database.Close()
 
-	taskComposite, err := composites.NewTaskComposite(database, logger)
+	taskStore := composites.TaskStore{Pool: pool, Cache: taskCache, ChangesDSN: appConfig.Postgres().DSN(), Replicas: replicas,
+		Routing: storage.ReplicaRouting{
+			MaxLag:         appConfig.DBReplicaMaxLag,
+			ReadYourWrites: appConfig.DBReadYourWritesWindow,
+			CheckInterval:  appConfig.DBReplicaCheckInterval,
+		},
+		TxAttempts: appConfig.DBTxMaxAttempts}
+	if appConfig.CalendarFeedSecret == "" {
+		logger.Warn("CALENDAR_FEED_SECRET is not set, calendar feeds are refused")
+	}
+	calendarTokens := grpcadapter.NewCalendarTokens(appConfig.CalendarFeedSecret, appConfig.CalendarFeedTokenTTL)
+	taskComposite, err := composites.NewTaskComposite(store, taskStore, projectComposite.Repository, customFieldComposite.Repository,
+		appConfig.IDStrategy, appConfig.SearchLanguage, appConfig.BatchMaxSize, calendarTokens, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize task composite", zap.Error(err))
 	}
 
-	startGRPCServer(taskComposite, appConfig, logger)
+	savedViewComposite, err := composites.NewSavedViewComposite(store, projectComposite.Repository, customFieldComposite.Repository,
+		taskComposite.Service, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize saved view composite", zap.Error(err))
+	}
+
+	taskTemplateComposite, err := composites.NewTaskTemplateComposite(store, projectComposite.Repository, taskComposite.Service,
+		taskComposite.Transactor, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize task template composite", zap.Error(err))
+	}
+
+	idempotencyComposite, err := composites.NewIdempotencyComposite(store, appConfig.IdempotencyTTL,
+		appConfig.IdempotencyPurgeInterval, appConfig.RPCTimeout, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize idempotency composite", zap.Error(err))
+	}
+	go idempotencyComposite.Purger.Run(context.Background())
+
+	if taskComposite.Cache != nil {
+		go taskComposite.Cache.ReportStats(context.Background(), appConfig.CacheStatsInterval)
+	}
+	if taskComposite.ChangeListener != nil {
+		go taskComposite.ChangeListener.Run(context.Background())
+	}
+	if taskComposite.Replicas != nil {
+		go taskComposite.Replicas.Run(context.Background())
+	}
+
+	rebalancer := services.NewRankRebalancer(taskComposite.Repository, appConfig.RankMaxLength, appConfig.RankRebalanceInterval, logger)
+	go rebalancer.Run(context.Background())
+
+	partitioner := services.NewTaskPartitioner(taskComposite.Repository, appConfig.PartitionInterval, logger)
+	go partitioner.Run(context.Background())
+
+	if appConfig.ArchiveRetention > 0 {
+		archiver, err := services.NewTaskArchiver(taskComposite.Repository, projectComposite.Repository, appConfig.ArchiveRetention,
+			appConfig.ArchiveInterval, appConfig.ArchiveBatchSize, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize task archiver", zap.Error(err))
+		}
+		go archiver.Run(context.Background())
+	}
+
+	startGRPCServer(taskComposite, projectComposite, customFieldComposite, savedViewComposite, taskTemplateComposite, idempotencyComposite,
+		appConfig, logger)
 }
 
-func startGRPCServer(taskComposite *composites.TaskComposite, cfg *config.AppConfig, logger *zap.Logger) {
+func startGRPCServer(taskComposite *composites.TaskComposite, projectComposite *composites.ProjectComposite,
+	customFieldComposite *composites.CustomFieldComposite, savedViewComposite *composites.SavedViewComposite,
+	taskTemplateComposite *composites.TaskTemplateComposite, idempotencyComposite *composites.IdempotencyComposite, cfg *config.AppConfig, logger *zap.Logger) {
 	logger.Info("Starting the gRPC server...")
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcadapter.NewDeadlineInterceptor(cfg.RPCTimeout, logger), grpcadapter.NewCallerInterceptor(),
+			grpcadapter.NewTimeZoneInterceptor(), idempotencyComposite.Interceptor),
+		grpc.ChainStreamInterceptor(grpcadapter.NewStreamContextInterceptor(logger), grpcadapter.NewStreamCallerInterceptor(),
+			grpcadapter.NewStreamTimeZoneInterceptor()),
+	)
 
 	pb.RegisterTaskManagerServer(grpcServer, taskComposite.Handler)
+	pb.RegisterProjectManagerServer(grpcServer, projectComposite.Handler)
+	pb.RegisterCustomFieldManagerServer(grpcServer, customFieldComposite.Handler)
+	pb.RegisterSavedViewManagerServer(grpcServer, savedViewComposite.Handler)
+	pb.RegisterTaskTemplateManagerServer(grpcServer, taskTemplateComposite.Handler)
 
 	reflection.Register(grpcServer)
 
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/config"
+	"github.com/Sunf1ower113/grpc-task-manager/migrations"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
+	"go.uber.org/zap"
+)
+
+const migrateUsage = `usage: grpc-task-manager migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down N     revert the N most recently applied migrations
+  status     list the migrations and whether they are applied
+  force V    record the schema as being at version V without running any migration`
+
+// runMigrate implements the migrate subcommand, which manages the schema without starting the server.
+func runMigrate(args []string, appConfig *config.AppConfig, logger *zap.Logger) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var number int64
+	switch args[0] {
+	case "up", "status":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down", "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		var err error
+		number, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || number < 0 {
+			return fmt.Errorf("invalid number %q\n%s", args[1], migrateUsage)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+
+	switch appConfig.StorageDriver {
+	case "memory":
+		return errors.New("the memory storage driver has no database to migrate")
+	case "sqlite":
+		return errors.New("the sqlite storage driver migrates its database when the server starts")
+	}
+
+	database, err := postgres.ConnectDB(appConfig.Postgres())
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := postgres.NewMigrator(database, migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations, the schema is at version %d\n", applied, migrator.Latest())
+	case "down":
+		return migrator.Down(ctx, int(number))
+	case "force":
+		return migrator.Force(ctx, number)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+	}
+	return nil
+}
+
+// printMigrationStatus writes one line per migration to stdout.
+func printMigrationStatus(statuses []postgres.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Unknown:
+			state = "applied by a newer release at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		case status.Applied:
+			state = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, state)
+	}
+	w.Flush()
+}
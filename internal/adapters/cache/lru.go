@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// LRU keeps up to size tasks in process memory, evicting the least recently used task when it is full.
+// Tasks expire ttl after they were cached; a zero ttl keeps them until they are evicted.
+type LRU struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu sync.Mutex
+	// order holds the entries from the most to the least recently used.
+	order   *list.List
+	entries map[int64]*list.Element
+}
+
+type lruEntry struct {
+	task    *models.Task
+	expires time.Time
+}
+
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:    max(size, 1),
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[int64]*list.Element),
+	}
+}
+
+func (c *LRU) Get(_ context.Context, id int64) (*models.Task, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+	return cloneTask(entry.task), true, nil
+}
+
+func (c *LRU) Set(_ context.Context, task *models.Task) error {
+	entry := &lruEntry{task: cloneTask(task), expires: c.now().Add(c.ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[task.ID]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[task.ID] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(_ context.Context, ids ...int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		if element, ok := c.entries[id]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+func (c *LRU) Purge(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	clear(c.entries)
+	return nil
+}
+
+// Len returns the number of cached tasks, including expired ones not yet evicted.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).task.ID)
+}
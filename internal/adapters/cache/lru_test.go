@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	lru := NewLRU(2, 0)
+	ctx := context.Background()
+
+	require.NoError(t, lru.Set(ctx, &models.Task{ID: 1}))
+	require.NoError(t, lru.Set(ctx, &models.Task{ID: 2}))
+	_, ok, _ := lru.Get(ctx, 1)
+	require.True(t, ok)
+	require.NoError(t, lru.Set(ctx, &models.Task{ID: 3}))
+
+	_, ok, _ = lru.Get(ctx, 2)
+	assert.False(t, ok, "the least recently used task is evicted")
+	_, ok, _ = lru.Get(ctx, 1)
+	assert.True(t, ok)
+	_, ok, _ = lru.Get(ctx, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, lru.Len())
+
+	require.NoError(t, lru.Set(ctx, &models.Task{ID: 3, Title: "Replaced"}))
+	task, _, _ := lru.Get(ctx, 3)
+	assert.Equal(t, "Replaced", task.Title)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLRU_TTL(t *testing.T) {
+	lru := NewLRU(10, time.Minute)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lru.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, lru.Set(ctx, &models.Task{ID: 1}))
+	now = now.Add(59 * time.Second)
+	_, ok, _ := lru.Get(ctx, 1)
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = lru.Get(ctx, 1)
+	assert.False(t, ok)
+	assert.Zero(t, lru.Len())
+}
+
+func TestLRU_Copies(t *testing.T) {
+	lru := NewLRU(10, 0)
+	ctx := context.Background()
+	task := &models.Task{ID: 1, Labels: []string{"a"}, CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 1}}}
+
+	require.NoError(t, lru.Set(ctx, task))
+	task.Labels[0] = "changed"
+	cached, _, _ := lru.Get(ctx, 1)
+	cached.CustomFields["points"] = models.FieldValue{Type: models.FieldTypeNumber, Number: 2}
+
+	cached, _, _ = lru.Get(ctx, 1)
+	assert.Equal(t, []string{"a"}, cached.Labels)
+	assert.Equal(t, float64(1), cached.CustomFields["points"].Number)
+}
+
+func TestLRU_DeleteAndPurge(t *testing.T) {
+	lru := NewLRU(10, 0)
+	ctx := context.Background()
+	for id := int64(1); id <= 3; id++ {
+		require.NoError(t, lru.Set(ctx, &models.Task{ID: id}))
+	}
+
+	require.NoError(t, lru.Delete(ctx, 1, 4))
+	_, ok, _ := lru.Get(ctx, 1)
+	assert.False(t, ok)
+	assert.Equal(t, 2, lru.Len())
+
+	require.NoError(t, lru.Purge(ctx))
+	assert.Zero(t, lru.Len())
+	require.NoError(t, lru.Set(ctx, &models.Task{ID: 1}))
+	assert.Equal(t, 1, lru.Len())
+}
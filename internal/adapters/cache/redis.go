@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// redisKeyPrefix namespaces the keys of cached tasks, which are followed by the task ID.
+const redisKeyPrefix = "task-manager:task:"
+
+// RedisClient is the part of a Redis client the Redis store uses, as offered by pkg/client/redis.
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+}
+
+// Redis keeps tasks in Redis as JSON, so that all servers of a deployment share one cache that survives
+// restarts. Tasks expire ttl after they were cached; a zero ttl keeps them until they are invalidated.
+type Redis struct {
+	client RedisClient
+	ttl    time.Duration
+}
+
+func NewRedis(client RedisClient, ttl time.Duration) *Redis {
+	return &Redis{client: client, ttl: ttl}
+}
+
+func (c *Redis) Get(ctx context.Context, id int64) (*models.Task, bool, error) {
+	data, ok, err := c.client.Get(ctx, redisKey(id))
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var task models.Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, false, err
+	}
+	return &task, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, task *models.Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, redisKey(task.ID), data, c.ttl)
+}
+
+func (c *Redis) Delete(ctx context.Context, ids ...int64) error {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisKey(id)
+	}
+	return c.client.Del(ctx, keys...)
+}
+
+// Purge deletes the cached tasks page by page, as Redis offers no atomic way to delete keys by prefix.
+func (c *Redis) Purge(ctx context.Context) error {
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, redisKeyPrefix+"*", 1000)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := c.client.Del(ctx, keys...); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func redisKey(id int64) string {
+	return redisKeyPrefix + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis stands in for a Redis server, keeping keys in memory. It records the TTLs it was given
+// rather than expiring keys, and fails every command once err is set.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+	// scanned holds the keys of the scan in progress.
+	scanned []string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (r *fakeRedis) Get(_ context.Context, key string) ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	value, ok := r.values[key]
+	return value, ok, nil
+}
+
+func (r *fakeRedis) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.values[key] = slices.Clone(value)
+	r.ttls[key] = ttl
+	return nil
+}
+
+func (r *fakeRedis) Del(_ context.Context, keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	for _, key := range keys {
+		delete(r.values, key)
+		delete(r.ttls, key)
+	}
+	return nil
+}
+
+// Scan pages through the keys matching when the scan began, in sorted order, using the offset of the next
+// page as cursor. Like Redis, it returns every key that exists during the whole scan.
+func (r *fakeRedis) Scan(_ context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	if cursor == 0 {
+		r.scanned = r.scanned[:0]
+		for key := range r.values {
+			if ok, _ := path.Match(match, key); ok {
+				r.scanned = append(r.scanned, key)
+			}
+		}
+		slices.Sort(r.scanned)
+	}
+	end := min(int(cursor)+int(count), len(r.scanned))
+	var keys []string
+	for _, key := range r.scanned[cursor:end] {
+		if _, ok := r.values[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if end == len(r.scanned) {
+		return keys, 0, nil
+	}
+	return keys, uint64(end), nil
+}
+
+func (r *fakeRedis) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func TestRedis(t *testing.T) {
+	client := newFakeRedis()
+	store := NewRedis(client, time.Minute)
+	ctx := context.Background()
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	task := &models.Task{
+		ID: 7, Key: "OPS-7", Title: "Cached", Labels: []string{"a", "b"}, CreatedAt: created,
+		CustomFields: map[string]models.FieldValue{
+			"points": {Type: models.FieldTypeNumber, Number: 3},
+			"due":    {Type: models.FieldTypeDate, String: "2024-06-01"},
+		},
+	}
+
+	require.NoError(t, store.Set(ctx, task))
+	assert.Equal(t, time.Minute, client.ttls["task-manager:task:7"])
+
+	cached, ok, err := store.Get(ctx, 7)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, task.Title, cached.Title)
+	assert.Equal(t, task.Labels, cached.Labels)
+	assert.Equal(t, task.CustomFields, cached.CustomFields)
+	assert.True(t, created.Equal(cached.CreatedAt))
+
+	require.NoError(t, store.Delete(ctx, 7, 8))
+	_, ok, err = store.Get(ctx, 7)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedis_Purge(t *testing.T) {
+	client := newFakeRedis()
+	store := NewRedis(client, 0)
+	ctx := context.Background()
+	for id := int64(1); id <= 2500; id++ {
+		require.NoError(t, store.Set(ctx, &models.Task{ID: id}))
+	}
+	require.NoError(t, client.Set(ctx, "other", []byte("kept"), 0))
+
+	require.NoError(t, store.Purge(ctx))
+
+	assert.Len(t, client.values, 1)
+	assert.Contains(t, client.values, "other")
+}
+
+func TestRedis_Failure(t *testing.T) {
+	client := newFakeRedis()
+	store := NewRedis(client, time.Minute)
+	failure := errors.New("connection refused")
+	client.fail(failure)
+
+	_, _, err := store.Get(context.Background(), 1)
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorIs(t, store.Set(context.Background(), &models.Task{ID: 1}), failure)
+	assert.ErrorIs(t, store.Purge(context.Background()), failure)
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// Store holds copies of tasks by ID. Implementations are safe for concurrent use and never share
+// the tasks they return with the tasks they were given.
+type Store interface {
+	// Get returns the cached copy of the task and whether there is one.
+	Get(ctx context.Context, id int64) (*models.Task, bool, error)
+	Set(ctx context.Context, task *models.Task) error
+	Delete(ctx context.Context, ids ...int64) error
+	// Purge drops all cached tasks.
+	Purge(ctx context.Context) error
+}
+
+// Stats counts the GetTask calls served from the cache and those that read the underlying repository.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// TaskRepository serves GetTask from a cache in front of another task repository and passes all other
+// calls through. Writes through it drop the cached copies of the tasks they touch, those of a unit of work
+// once it commits; writes made elsewhere, such as by other servers, need to be reported to Invalidate.
+// Reads within a unit of work bypass the cache, as they must see the unit's own writes and must not
+// cache them before they are committed. Cache failures are logged and fall back to the underlying
+// repository.
+type TaskRepository struct {
+	repository.TaskRepository
+	store  Store
+	logger *zap.Logger
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+
+	// mu orders filling the cache against invalidations: a task read from the repository is only cached
+	// if no invalidation happened since the read began, as it might predate the change invalidated.
+	mu         sync.RWMutex
+	generation uint64
+}
+
+func NewTaskRepository(repo repository.TaskRepository, store Store, logger *zap.Logger) *TaskRepository {
+	return &TaskRepository{
+		TaskRepository: repo,
+		store:          store,
+		logger:         logger,
+	}
+}
+
+func (r *TaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	if consistency.InUnitOfWork(ctx) {
+		return r.TaskRepository.GetTask(ctx, id)
+	}
+
+	task, ok, err := r.store.Get(ctx, id)
+	if err != nil {
+		r.logger.Warn("Failed to read task cache", zap.Int64("task_id", id), zap.Error(err))
+	} else if ok {
+		r.hits.Add(1)
+		return task, nil
+	}
+	r.misses.Add(1)
+
+	r.mu.RLock()
+	generation := r.generation
+	r.mu.RUnlock()
+
+	// Tasks are kept until they change again, so they must not come from a replica lagging behind.
+	task, err = r.TaskRepository.GetTask(consistency.WithLatest(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.generation == generation {
+		if err := r.store.Set(ctx, task); err != nil {
+			r.logger.Warn("Failed to fill task cache", zap.Int64("task_id", id), zap.Error(err))
+		}
+	}
+	return task, nil
+}
+
+func (r *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	defer r.written(ctx, task.ID)
+	return r.TaskRepository.UpdateTask(ctx, task)
+}
+
+func (r *TaskRepository) DeleteTask(ctx context.Context, id int64) error {
+	defer r.written(ctx, id)
+	return r.TaskRepository.DeleteTask(ctx, id)
+}
+
+func (r *TaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	defer r.written(ctx, ids...)
+	return r.TaskRepository.UpdateTasks(ctx, tasks)
+}
+
+func (r *TaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	defer r.written(ctx, ids...)
+	return r.TaskRepository.DeleteTasks(ctx, ids)
+}
+
+func (r *TaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	defer r.written(ctx, id)
+	return r.TaskRepository.TransferTask(ctx, id, projectID, status, rank, customFields)
+}
+
+func (r *TaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	defer r.written(ctx, id)
+	return r.TaskRepository.SetTaskParent(ctx, id, parentID)
+}
+
+// DetachSubtasks drops the cached copies of the subtasks it detached, or all cached tasks if it fails
+// without telling which subtasks it detached.
+func (r *TaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	ids, err := r.TaskRepository.DetachSubtasks(ctx, parentIDs)
+	if err != nil || len(ids) > 0 {
+		r.written(ctx, ids...)
+	}
+	return ids, err
+}
+
+func (r *TaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	defer r.written(ctx, id)
+	return r.TaskRepository.MoveTask(ctx, id, status, rank)
+}
+
+// RebalanceColumn rewrites the ranks of the column and then drops all cached tasks. Rebalancing is rare,
+// so this is cheaper than looking up which tasks the column holds.
+func (r *TaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	defer r.written(ctx)
+	return r.TaskRepository.RebalanceColumn(ctx, column, ranks)
+}
+
+// ArchiveTasks drops the cached copies of the tasks it archived, or all cached tasks if it fails without
+// telling which tasks it archived.
+func (r *TaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	ids, err := r.TaskRepository.ArchiveTasks(ctx, column, updatedBefore, limit)
+	if err != nil || len(ids) > 0 {
+		r.written(ctx, ids...)
+	}
+	return ids, err
+}
+
+// written invalidates the tasks a write touched, also when it failed, as a failed write may still have
+// been committed. Within a unit of work it waits for the commit: until then others read the tasks as they
+// were before, and copies cached meanwhile must go.
+func (r *TaskRepository) written(ctx context.Context, ids ...int64) {
+	consistency.AfterCommit(ctx, func() { r.Invalidate(ctx, ids...) })
+}
+
+// Invalidate drops the cached copies of the tasks with the given IDs, or of all tasks when no IDs are given.
+func (r *TaskRepository) Invalidate(ctx context.Context, ids ...int64) {
+	// Dropping the tasks must not fail because the write that changed them ran out of time.
+	ctx = context.WithoutCancel(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+
+	var err error
+	if len(ids) == 0 {
+		err = r.store.Purge(ctx)
+	} else {
+		err = r.store.Delete(ctx, ids...)
+	}
+	if err != nil {
+		r.logger.Error("Failed to invalidate task cache", zap.Int64s("task_ids", ids), zap.Error(err))
+	}
+}
+
+// Stats returns the hit and miss counts since the repository was created.
+func (r *TaskRepository) Stats() Stats {
+	return Stats{Hits: r.hits.Load(), Misses: r.misses.Load()}
+}
+
+// ReportStats logs the hit and miss counts every interval until ctx is cancelled.
+func (r *TaskRepository) ReportStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := r.Stats()
+			r.logger.Info("Task cache statistics", zap.Uint64("hits", stats.Hits), zap.Uint64("misses", stats.Misses))
+		}
+	}
+}
+
+func cloneTask(task *models.Task) *models.Task {
+	clone := *task
+	clone.Labels = slices.Clone(task.Labels)
+	if len(task.CustomFields) == 0 {
+		clone.CustomFields = nil
+	} else {
+		clone.CustomFields = maps.Clone(task.CustomFields)
+	}
+	return &clone
+}
@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/memory"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// projectStore serves the projects the in-memory task repository looks up key prefixes of.
+type projectStore struct {
+	repository.ProjectRepository
+
+	mu       sync.Mutex
+	projects map[int64]*models.Project
+}
+
+func (s *projectStore) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return project, nil
+}
+
+func (s *projectStore) add() *models.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := int64(len(s.projects) + 1)
+	project := &models.Project{ID: id, Key: fmt.Sprintf("C%d", id), Name: "Project"}
+	s.projects[id] = project
+	return project
+}
+
+// countingRepository counts the GetTask calls reaching the repository and those that asked for the latest
+// data, and runs beforeReturn, if set, after reading a task.
+type countingRepository struct {
+	repository.TaskRepository
+
+	mu           sync.Mutex
+	gets         int
+	latestGets   int
+	beforeReturn func()
+}
+
+func (r *countingRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	r.mu.Lock()
+	r.gets++
+	if consistency.Latest(ctx) {
+		r.latestGets++
+	}
+	beforeReturn := r.beforeReturn
+	r.mu.Unlock()
+
+	task, err := r.TaskRepository.GetTask(ctx, id)
+	if beforeReturn != nil {
+		beforeReturn()
+	}
+	return task, err
+}
+
+func (r *countingRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gets
+}
+
+func newFixture(store Store) (*TaskRepository, *countingRepository, *projectStore) {
+	projects := &projectStore{projects: make(map[int64]*models.Project)}
+	underlying := &countingRepository{TaskRepository: memory.NewTaskRepository(projects, zap.NewNop())}
+	return NewTaskRepository(underlying, store, zap.NewNop()), underlying, projects
+}
+
+func TestTaskRepository_Conformance(t *testing.T) {
+	stores := map[string]func() Store{
+		"lru":   func() Store { return NewLRU(100, 0) },
+		"redis": func() Store { return NewRedis(newFakeRedis(), 0) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Fixture {
+				repo, underlying, projects := newFixture(newStore())
+				return repositorytest.Fixture{
+					Repository: repo,
+					NewProject: func(t *testing.T) (int64, string) {
+						project := projects.add()
+						return project.ID, project.Key
+					},
+					Transactor: underlying.TaskRepository.(*memory.TaskRepository),
+				}
+			})
+		})
+	}
+}
+
+func TestTaskRepository_HitsAndMisses(t *testing.T) {
+	repo, underlying, _ := newFixture(NewLRU(100, 0))
+	ctx := context.Background()
+	task, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", Title: "Hot", Status: "open", Rank: "m"})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		got, err := repo.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Hot", got.Title)
+	}
+	_, err = repo.GetTask(ctx, task.ID+1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.Equal(t, 2, underlying.count())
+	assert.Equal(t, 2, underlying.latestGets, "tasks to cache are read from the primary")
+	assert.Equal(t, Stats{Hits: 2, Misses: 2}, repo.Stats())
+}
+
+func TestTaskRepository_WritesInvalidate(t *testing.T) {
+	repo, underlying, projects := newFixture(NewLRU(100, 0))
+	ctx := context.Background()
+	project := projects.add()
+	task, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Title: "Before", Status: "open", Rank: "m"})
+	require.NoError(t, err)
+
+	writes := []struct {
+		name  string
+		write func(t *testing.T)
+		check func(t *testing.T, task *models.Task)
+	}{
+		{"UpdateTask", func(t *testing.T) {
+			changed := *task
+			changed.Title = "Updated"
+			_, err := repo.UpdateTask(ctx, &changed)
+			require.NoError(t, err)
+		}, func(t *testing.T, task *models.Task) { assert.Equal(t, "Updated", task.Title) }},
+		{"UpdateTasks", func(t *testing.T) {
+			changed := *task
+			changed.Title = "Batch updated"
+			_, err := repo.UpdateTasks(ctx, []*models.Task{&changed})
+			require.NoError(t, err)
+		}, func(t *testing.T, task *models.Task) { assert.Equal(t, "Batch updated", task.Title) }},
+		{"MoveTask", func(t *testing.T) {
+			_, err := repo.MoveTask(ctx, task.ID, "done", "n")
+			require.NoError(t, err)
+		}, func(t *testing.T, task *models.Task) { assert.Equal(t, "done", task.Status) }},
+		{"RebalanceColumn", func(t *testing.T) {
+			err := repo.RebalanceColumn(ctx, models.Column{ProjectID: project.ID, Status: "done"}, func(count int) []string {
+				return []string{"b"}
+			})
+			require.NoError(t, err)
+		}, func(t *testing.T, task *models.Task) { assert.Equal(t, "b", task.Rank) }},
+		{"TransferTask", func(t *testing.T) {
+			_, err := repo.TransferTask(ctx, task.ID, projects.add().ID, "open", "m", nil)
+			require.NoError(t, err)
+		}, func(t *testing.T, task *models.Task) { assert.Equal(t, "C2-1", task.Key) }},
+	}
+	for _, w := range writes {
+		t.Run(w.name, func(t *testing.T) {
+			_, err := repo.GetTask(ctx, task.ID)
+			require.NoError(t, err)
+			before := underlying.count()
+
+			w.write(t)
+			got, err := repo.GetTask(ctx, task.ID)
+			require.NoError(t, err)
+			w.check(t, got)
+			assert.Equal(t, before+1, underlying.count())
+		})
+	}
+
+	t.Run("DeleteTask", func(t *testing.T) {
+		_, err := repo.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteTask(ctx, task.ID))
+		_, err = repo.GetTask(ctx, task.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("DeleteTasks", func(t *testing.T) {
+		other, err := repo.CreateTask(ctx, &models.Task{ExternalID: "b", Title: "Other", Status: "open", Rank: "m"})
+		require.NoError(t, err)
+		_, err = repo.GetTask(ctx, other.ID)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteTasks(ctx, []int64{other.ID}))
+		_, err = repo.GetTask(ctx, other.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestTaskRepository_Invalidate(t *testing.T) {
+	lru := NewLRU(100, 0)
+	repo, underlying, _ := newFixture(lru)
+	ctx := context.Background()
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		task, err := repo.CreateTask(ctx, &models.Task{ExternalID: fmt.Sprint(i), Title: "Cached", Status: "open", Rank: "m"})
+		require.NoError(t, err)
+		_, err = repo.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		ids = append(ids, task.ID)
+	}
+
+	// A change made elsewhere is only seen once it is reported.
+	changed := &models.Task{ID: ids[0], Title: "Changed elsewhere", Status: "open", Rank: "m"}
+	_, err := underlying.TaskRepository.UpdateTask(ctx, changed)
+	require.NoError(t, err)
+	got, err := repo.GetTask(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Cached", got.Title)
+
+	repo.Invalidate(ctx, ids[0])
+	got, err = repo.GetTask(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Changed elsewhere", got.Title)
+	assert.Equal(t, 3, lru.Len())
+
+	repo.Invalidate(ctx)
+	assert.Zero(t, lru.Len())
+}
+
+func TestTaskRepository_InvalidateDuringRead(t *testing.T) {
+	lru := NewLRU(100, 0)
+	repo, underlying, _ := newFixture(lru)
+	ctx := context.Background()
+	task, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", Title: "Before", Status: "open", Rank: "m"})
+	require.NoError(t, err)
+
+	// The task changes after it was read but before it would be cached, so the copy read is stale.
+	underlying.beforeReturn = func() { repo.Invalidate(ctx, task.ID) }
+	_, err = repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Zero(t, lru.Len())
+
+	underlying.beforeReturn = nil
+	_, err = repo.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, lru.Len())
+}
+
+func TestTaskRepository_StoreFailure(t *testing.T) {
+	client := newFakeRedis()
+	repo, underlying, _ := newFixture(NewRedis(client, 0))
+	ctx := context.Background()
+	task, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", Title: "Uncached", Status: "open", Rank: "m"})
+	require.NoError(t, err)
+	client.fail(errors.New("connection refused"))
+
+	for i := 0; i < 2; i++ {
+		got, err := repo.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Uncached", got.Title)
+	}
+	assert.Equal(t, 2, underlying.count())
+	assert.Equal(t, Stats{Misses: 2}, repo.Stats())
+
+	task.Title = "Updated"
+	_, err = repo.UpdateTask(ctx, task)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// encodeCustomFields stores custom field values as a JSON object of plain numbers and strings.
+func encodeCustomFields(values map[string]models.FieldValue) ([]byte, error) {
+	raw := make(map[string]any, len(values))
+	for name, value := range values {
+		raw[name] = value.Raw()
+	}
+	return json.Marshal(raw)
+}
+
+// decodeCustomFields reads the JSONB custom_fields column. JSON numbers become number values and
+// everything else string values; the service refines the types from the field definitions.
+func decodeCustomFields(data []byte) (map[string]models.FieldValue, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]models.FieldValue, len(raw))
+	for name, data := range raw {
+		value, err := decodeFieldValue(data)
+		if err != nil {
+			return nil, fmt.Errorf("custom field %q: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+func decodeFieldValue(data []byte) (models.FieldValue, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw any
+	if err := decoder.Decode(&raw); err != nil {
+		return models.FieldValue{}, err
+	}
+
+	switch raw := raw.(type) {
+	case json.Number:
+		number, err := raw.Float64()
+		if err != nil {
+			return models.FieldValue{}, err
+		}
+		return models.FieldValue{Type: models.FieldTypeNumber, Number: number}, nil
+	case string:
+		return models.FieldValue{Type: models.FieldTypeString, String: raw}, nil
+	default:
+		return models.FieldValue{}, fmt.Errorf("unsupported custom field value %s", data)
+	}
+}
+
+// customFieldExpr returns the SQL expression for the custom field whose name is bound to parameter n.
+// Number fields are compared numerically and all other fields byte-wise as text, which keeps
+// YYYY-MM-DD dates in calendar order.
+func customFieldExpr(fieldType string, n int) string {
+	if fieldType == models.FieldTypeNumber {
+		return fmt.Sprintf("(CASE WHEN jsonb_typeof(custom_fields -> $%d::text) = 'number' THEN (custom_fields ->> $%d::text)::double precision END)", n, n)
+	}
+	return fmt.Sprintf(`(custom_fields ->> $%d::text) COLLATE "C"`, n)
+}
+
+// fieldOperators are the comparison operators custom field conditions may use.
+var fieldOperators = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// customFieldCondition builds the SQL condition for one custom field condition, appending its arguments.
+// Equality uses JSONB containment so that it can be served by the GIN index on custom_fields.
+func customFieldCondition(condition models.FieldCondition, args []any) (string, []any, error) {
+	if !fieldOperators[condition.Operator] {
+		return "", nil, fmt.Errorf("unsupported custom field operator %q", condition.Operator)
+	}
+	if condition.Operator == "=" {
+		args = append(args, condition.Field, condition.Value.Raw())
+		cast := "text"
+		if condition.Type == models.FieldTypeNumber {
+			cast = "double precision"
+		}
+		return fmt.Sprintf("custom_fields @> jsonb_build_object($%d::text, $%d::%s)", len(args)-1, len(args), cast), args, nil
+	}
+
+	args = append(args, condition.Field)
+	expr := customFieldExpr(condition.Type, len(args))
+	args = append(args, condition.Value.Raw())
+	return fmt.Sprintf("%s %s $%d", expr, condition.Operator, len(args)), args, nil
+}
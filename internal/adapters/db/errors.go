@@ -0,0 +1,66 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// isUniqueViolation reports whether err was caused by a unique constraint, with any of the drivers.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isServerFailure reports whether err means that the database server or the connection to it failed, or
+// that a replica cancelled the query to replay changes, rather than that the query itself was refused.
+func isServerFailure(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr) {
+		return true
+	}
+	code := sqlState(err)
+	// Connection exceptions, insufficient resources, operator intervention and serialization failures,
+	// which include conflicts with recovery.
+	return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "53") || strings.HasPrefix(code, "57") || code == "40001"
+}
+
+// isTransactionConflict reports whether err means that the database aborted a transaction for a conflict
+// with concurrent transactions, which a new attempt may well not run into: a serialization failure or a
+// deadlock in Postgres, or an SQLite database that stayed locked for longer than the busy timeout.
+func isTransactionConflict(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+	}
+	code := sqlState(err)
+	return code == "40001" || code == "40P01"
+}
+
+// sqlState returns the SQLSTATE code of a Postgres error of either driver, or "" for other errors.
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
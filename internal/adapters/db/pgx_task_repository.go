@@ -0,0 +1,896 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// PgxTaskRepository stores tasks like PostgresTaskRepository, running the same queries through a pgx
+// connection pool instead of database/sql. It reports missing rows as sql.ErrNoRows, as the services expect.
+type PgxTaskRepository struct {
+	pool   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewPgxTaskRepository(pool *pgxpool.Pool, logger *zap.Logger) *PgxTaskRepository {
+	return &PgxTaskRepository{pool: pool, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see pgxConnOf.
+func (r *PgxTaskRepository) conn(ctx context.Context) pgxConn {
+	return pgxConnOf(ctx, r.pool)
+}
+
+func (r *PgxTaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	customFields, err := encodeCustomFields(task.CustomFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	key, err := nextTaskKey(ctx, pgxQueryRow(tx), task.ProjectID)
+	if err != nil {
+		r.logger.Error("Failed to allocate task key", zap.Error(err))
+		return nil, err
+	}
+
+	err = tx.QueryRow(ctx, insertTaskQuery,
+		task.ExternalID, nullableID(task.ProjectID), key, task.Title, task.Description, task.Status, task.Assignee, stringArray(task.Labels),
+		task.Rank, customFields, nullableID(task.ParentID),
+	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create task", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit task creation", zap.Error(err))
+		return nil, err
+	}
+	task.Key = key.String
+
+	return task, nil
+}
+
+func (r *PgxTaskRepository) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	query, args, err := listTasksQuery(filter)
+	if err != nil {
+		r.logger.Error("Invalid task filter", zap.Error(err))
+		return nil, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		task, err := scanListedPgxTask(rows, filter)
+		if err != nil {
+			r.logger.Error("Failed to scan task", zap.Error(err))
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// ExportTasks reads the tasks matching filter through a server-side cursor, exportFetchSize rows at a time,
+// and passes them to each in ListTasks order. It stops at the first error each returns.
+func (r *PgxTaskRepository) ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error {
+	query, args, err := listTasksQuery(filter)
+	if err != nil {
+		r.logger.Error("Invalid task filter", zap.Error(err))
+		return err
+	}
+
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DECLARE task_export NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		r.logger.Error("Failed to open export cursor", zap.Error(err))
+		return err
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM task_export`, exportFetchSize)
+	for {
+		fetched, err := r.fetchTasks(ctx, tx, fetch, filter, each)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// fetchTasks runs one FETCH of an export cursor over the tasks matching filter, passing the tasks to each,
+// and returns how many it read.
+func (r *PgxTaskRepository) fetchTasks(ctx context.Context, tx pgx.Tx, fetch string, filter models.TaskFilter, each func(*models.Task) error) (int, error) {
+	rows, err := tx.Query(ctx, fetch)
+	if err != nil {
+		r.logger.Error("Failed to fetch tasks", zap.Error(err))
+		return 0, err
+	}
+	defer rows.Close()
+
+	var fetched int
+	for rows.Next() {
+		task, err := scanListedPgxTask(rows, filter)
+		if err != nil {
+			r.logger.Error("Failed to scan task", zap.Error(err))
+			return 0, err
+		}
+		fetched++
+		if err := each(task); err != nil {
+			return 0, err
+		}
+	}
+	return fetched, rows.Err()
+}
+
+func (r *PgxTaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	task, err := scanPgxTask(r.conn(ctx).QueryRow(ctx, getTaskQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PgxTaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	customFields, err := encodeCustomFields(task.CustomFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
+
+	err = scanUpdatedTask(r.conn(ctx).QueryRow(ctx, updateTaskQuery,
+		task.Title, task.Description, task.Status, task.Assignee, stringArray(task.Labels), task.Rank, customFields, task.ID,
+	), task)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to update task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PgxTaskRepository) DeleteTask(ctx context.Context, id int64) error {
+	tag, err := r.conn(ctx).Exec(ctx, deleteTaskQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to delete task", zap.Error(err))
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// CreateTasks allocates the keys of all tasks and inserts them with multi-row inserts in one transaction.
+func (r *PgxTaskRepository) CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	return r.createTasks(ctx, tasks, nil)
+}
+
+// CreateImportedTasks creates tasks like CreateTasks and links them to their source IDs. A source ID
+// imported meanwhile by a concurrent import fails the whole transaction with a unique violation.
+func (r *PgxTaskRepository) CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error) {
+	if len(sourceIDs) != len(tasks) {
+		return nil, fmt.Errorf("got %d source IDs for %d tasks", len(sourceIDs), len(tasks))
+	}
+
+	return r.createTasks(ctx, tasks, func(tx pgx.Tx) error {
+		taskIDs := make([]int64, len(tasks))
+		for i, task := range tasks {
+			taskIDs[i] = task.ID
+		}
+		_, err := tx.Exec(ctx, recordImportsQuery, source, sourceIDs, taskIDs)
+		if err != nil {
+			r.logger.Error("Failed to record imported tasks", zap.Error(err))
+		}
+		return err
+	})
+}
+
+// ImportedSourceIDs looks up which of the source IDs were imported before.
+func (r *PgxTaskRepository) ImportedSourceIDs(ctx context.Context, source string, sourceIDs []string) ([]string, error) {
+	rows, err := r.conn(ctx).Query(ctx, importedSourceIDsQuery, source, sourceIDs)
+	if err != nil {
+		r.logger.Error("Failed to look up imported tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imported []string
+	for rows.Next() {
+		var sourceID string
+		if err := rows.Scan(&sourceID); err != nil {
+			r.logger.Error("Failed to scan imported task", zap.Error(err))
+			return nil, err
+		}
+		imported = append(imported, sourceID)
+	}
+	return imported, rows.Err()
+}
+
+// createTasks inserts tasks in one transaction, running afterInsert, if set, in the same transaction
+// once the tasks have their IDs.
+func (r *PgxTaskRepository) createTasks(ctx context.Context, tasks []*models.Task, afterInsert func(tx pgx.Tx) error) ([]*models.Task, error) {
+	customFields := make([][]byte, len(tasks))
+	for i, task := range tasks {
+		var err error
+		if customFields[i], err = encodeCustomFields(task.CustomFields); err != nil {
+			r.logger.Error("Failed to encode custom fields", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	keys, err := nextTaskKeys(ctx, pgxQueryRow(tx), tasks)
+	if err != nil {
+		r.logger.Error("Failed to allocate task keys", zap.Error(err))
+		return nil, err
+	}
+
+	for start := 0; start < len(tasks); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(tasks))
+		query, args := insertTasksQuery(tasks[start:end], keys[start:end], customFields[start:end])
+		if err := r.insertTaskRows(ctx, tx, query, args, tasks[start:end]); err != nil {
+			r.logger.Error("Failed to create tasks", zap.Error(err))
+			return nil, err
+		}
+	}
+	if afterInsert != nil {
+		if err := afterInsert(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit task creation", zap.Error(err))
+		return nil, err
+	}
+	for i, task := range tasks {
+		task.Key = keys[i].String
+	}
+
+	return tasks, nil
+}
+
+// insertTaskRows runs a multi-row insert and assigns the returned IDs and timestamps to the tasks by
+// external ID, as Postgres does not promise to return the rows in the order of the VALUES list.
+func (r *PgxTaskRepository) insertTaskRows(ctx context.Context, tx pgx.Tx, query string, args []any, tasks []*models.Task) error {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]*models.Task, len(tasks))
+	for rows.Next() {
+		var row models.Task
+		if err := rows.Scan(&row.ID, &row.ExternalID, &row.CreatedAt, &row.UpdatedAt); err != nil {
+			return err
+		}
+		inserted[row.ExternalID] = &row
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return assignInsertedRows(tasks, inserted)
+}
+
+// UpdateTasks sends the updates of UpdateTask for all tasks as one batch inside a transaction,
+// saving a round trip per task.
+func (r *PgxTaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	customFields := make([][]byte, len(tasks))
+	for i, task := range tasks {
+		var err error
+		if customFields[i], err = encodeCustomFields(task.CustomFields); err != nil {
+			r.logger.Error("Failed to encode custom fields", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for i, task := range tasks {
+		batch.Queue(updateTaskQuery,
+			task.Title, task.Description, task.Status, task.Assignee, stringArray(task.Labels), task.Rank, customFields[i], task.ID)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for _, task := range tasks {
+		if err := scanUpdatedTask(results.QueryRow(), task); err != nil {
+			results.Close()
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, sql.ErrNoRows
+			}
+			r.logger.Error("Failed to update task", zap.Int64("id", task.ID), zap.Error(err))
+			return nil, err
+		}
+	}
+	if err := results.Close(); err != nil {
+		r.logger.Error("Failed to update tasks", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit task updates", zap.Error(err))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *PgxTaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, deleteTasksQuery, ids)
+	if err != nil {
+		r.logger.Error("Failed to delete tasks", zap.Error(err))
+		return err
+	}
+	if tag.RowsAffected() != int64(len(ids)) {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit task deletion", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PgxTaskRepository) GetTaskIDByKey(ctx context.Context, key string) (int64, error) {
+	var id int64
+	err := r.conn(ctx).QueryRow(ctx, taskIDByKeyQuery, key).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to resolve task key", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *PgxTaskRepository) GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error) {
+	var id int64
+	err := r.conn(ctx).QueryRow(ctx, taskIDByExternalIDQuery, externalID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to resolve task external ID", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *PgxTaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	encodedFields, err := encodeCustomFields(customFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var oldKey pgtype.Text
+	err = tx.QueryRow(ctx, lockTaskKeyQuery, id).Scan(&oldKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to lock task for transfer", zap.Error(err))
+		return nil, err
+	}
+
+	if oldKey.Status == pgtype.Present {
+		_, err = tx.Exec(ctx, keepTaskKeyQuery, oldKey.String, id)
+		if err != nil {
+			r.logger.Error("Failed to keep old task key", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	newKey, err := nextTaskKey(ctx, pgxQueryRow(tx), projectID)
+	if err != nil {
+		r.logger.Error("Failed to allocate task key", zap.Error(err))
+		return nil, err
+	}
+
+	task, err := scanPgxTask(tx.QueryRow(ctx, transferTaskQuery,
+		nullableID(projectID), newKey, status, rank, encodedFields, id))
+	if err != nil {
+		r.logger.Error("Failed to transfer task", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit task transfer", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PgxTaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	task, err := scanPgxTask(r.conn(ctx).QueryRow(ctx, setTaskParentQuery, nullableID(parentID), id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to set task parent", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PgxTaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	rows, err := r.conn(ctx).Query(ctx, detachSubtasksQuery, parentIDs)
+	if err != nil {
+		r.logger.Error("Failed to detach subtasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PgxTaskRepository) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	taskIDs, relatedTaskIDs, types := relationArrays(relations)
+	if _, err := r.conn(ctx).Exec(ctx, createTaskRelationsQuery, taskIDs, relatedTaskIDs, types); err != nil {
+		r.logger.Error("Failed to create task relations", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PgxTaskRepository) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	rows, err := r.conn(ctx).Query(ctx, listTaskRelationsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task relations", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []models.TaskRelation
+	for rows.Next() {
+		var relation models.TaskRelation
+		if err := rows.Scan(&relation.TaskID, &relation.RelatedTaskID, &relation.Type); err != nil {
+			r.logger.Error("Failed to scan task relation", zap.Error(err))
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+func (r *PgxTaskRepository) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	tag, err := r.conn(ctx).Exec(ctx, deleteTaskRelationQuery, relation.TaskID, relation.RelatedTaskID, relation.Type)
+	if err != nil {
+		r.logger.Error("Failed to delete task relation", zap.Error(err))
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PgxTaskRepository) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error {
+	texts, done := checklistArrays(items)
+	if _, err := r.conn(ctx).Exec(ctx, setTaskChecklistQuery, id, texts, done); err != nil {
+		r.logger.Error("Failed to set task checklist", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PgxTaskRepository) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	rows, err := r.conn(ctx).Query(ctx, getTaskChecklistQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to get task checklist", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		var item models.ChecklistItem
+		if err := rows.Scan(&item.Text, &item.Done); err != nil {
+			r.logger.Error("Failed to scan checklist item", zap.Error(err))
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *PgxTaskRepository) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	err := r.conn(ctx).QueryRow(ctx, createTaskCommentQuery, comment.TaskID, comment.Author, comment.Body).Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create task comment", zap.Error(err))
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *PgxTaskRepository) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	rows, err := r.conn(ctx).Query(ctx, listTaskCommentsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task comments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.TaskComment
+	for rows.Next() {
+		var comment models.TaskComment
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan task comment", zap.Error(err))
+			return nil, err
+		}
+		comments = append(comments, &comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *PgxTaskRepository) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	err := r.conn(ctx).QueryRow(ctx, createTaskAttachmentQuery, attachment.TaskID, attachment.Name, attachment.URL, attachment.ContentType,
+		attachment.Size).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create task attachment", zap.Error(err))
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (r *PgxTaskRepository) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	rows, err := r.conn(ctx).Query(ctx, listTaskAttachmentsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task attachments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.TaskAttachment
+	for rows.Next() {
+		var attachment models.TaskAttachment
+		if err := rows.Scan(&attachment.ID, &attachment.TaskID, &attachment.Name, &attachment.URL, &attachment.ContentType, &attachment.Size,
+			&attachment.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan task attachment", zap.Error(err))
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *PgxTaskRepository) CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error {
+	_, err := r.conn(ctx).Exec(ctx, copyTaskContentQuery, fromID, toID, content.Checklist, content.Comments, content.Attachments)
+	if err != nil {
+		r.logger.Error("Failed to copy task content", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PgxTaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	task, err := scanPgxTask(r.conn(ctx).QueryRow(ctx, moveTaskQuery, status, rank, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to move task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PgxTaskRepository) RankBefore(ctx context.Context, column models.Column, rank string) (string, error) {
+	var before string
+	err := r.conn(ctx).QueryRow(ctx, rankBeforeQuery, nullableID(column.ProjectID), column.Status, rank).Scan(&before)
+	if err != nil {
+		r.logger.Error("Failed to fetch preceding rank", zap.Error(err))
+		return "", err
+	}
+
+	return before, nil
+}
+
+func (r *PgxTaskRepository) RankAfter(ctx context.Context, column models.Column, rank string) (string, error) {
+	var after string
+	err := r.conn(ctx).QueryRow(ctx, rankAfterQuery, nullableID(column.ProjectID), column.Status, rank).Scan(&after)
+	if err != nil {
+		r.logger.Error("Failed to fetch following rank", zap.Error(err))
+		return "", err
+	}
+
+	return after, nil
+}
+
+func (r *PgxTaskRepository) ColumnsWithLongRanks(ctx context.Context, maxLength int) ([]models.Column, error) {
+	rows, err := r.conn(ctx).Query(ctx, columnsWithLongRanksQuery, maxLength)
+	if err != nil {
+		r.logger.Error("Failed to list columns with long ranks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.Column
+	for rows.Next() {
+		var column models.Column
+		var projectID pgtype.Int8
+		if err := rows.Scan(&projectID, &column.Status); err != nil {
+			r.logger.Error("Failed to scan column", zap.Error(err))
+			return nil, err
+		}
+		column.ProjectID = projectID.Int
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+func (r *PgxTaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin rebalance transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, lockColumnQuery, nullableID(column.ProjectID), column.Status)
+	if err != nil {
+		r.logger.Error("Failed to lock column", zap.Error(err))
+		return err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to read column", zap.Error(err))
+		return err
+	}
+
+	newRanks := ranks(len(ids))
+	if len(newRanks) != len(ids) {
+		return fmt.Errorf("rank generator returned %d keys for %d tasks", len(newRanks), len(ids))
+	}
+	for i, id := range ids {
+		if _, err := tx.Exec(ctx, setRankQuery, newRanks[i], id); err != nil {
+			r.logger.Error("Failed to rewrite rank", zap.Int64("id", id), zap.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ArchiveTasks moves the tasks like PostgresTaskRepository.ArchiveTasks.
+func (r *PgxTaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	tx, err := beginPgxTx(ctx, r.pool, pgx.TxOptions{})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, archiveCandidatesQuery, nullableID(column.ProjectID), column.Status, updatedBefore, limit)
+	if err != nil {
+		r.logger.Error("Failed to select tasks to archive", zap.Error(err))
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to select tasks to archive", zap.Error(err))
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(ctx, archivePartitionsQuery, ids); err != nil {
+		r.logger.Error("Failed to create archive partitions", zap.Error(err))
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, archiveTaskRecordsQuery, ids); err != nil {
+		r.logger.Error("Failed to archive task records", zap.Error(err))
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, archiveTasksQuery, ids); err != nil {
+		r.logger.Error("Failed to archive tasks", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit task archiving", zap.Error(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// CreateTaskPartitions creates the partitions like PostgresTaskRepository.CreateTaskPartitions.
+func (r *PgxTaskRepository) CreateTaskPartitions(ctx context.Context, until time.Time) error {
+	if _, err := r.conn(ctx).Exec(ctx, createTaskPartitionsQuery, until); err != nil {
+		r.logger.Error("Failed to create task partitions", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PgxTaskRepository) GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	task, err := scanArchivedPgxTask(r.conn(ctx).QueryRow(ctx, getArchivedTaskQuery, archivedTaskRef(ref)...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch archived task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// pgxQueryRow runs queries in a pgx transaction.
+func pgxQueryRow(tx pgx.Tx) queryRowFunc {
+	return func(ctx context.Context, query string, args ...any) rowScanner {
+		return tx.QueryRow(ctx, query, args...)
+	}
+}
+
+// scanPgxTask reads a row selecting taskColumns, followed by any extra columns, like scanTask. pgx hands
+// sql.Scanner implementations such as pq.StringArray the binary wire format, so nullable and array columns
+// scan into pgx types here.
+func scanPgxTask(row rowScanner, extra ...any) (*models.Task, error) {
+	var task models.Task
+	var projectID, parentID pgtype.Int8
+	var key pgtype.Text
+	var labels []string
+	var customFields []byte
+	dest := []any{&task.ID, &task.ExternalID, &projectID, &key, &task.Title, &task.Description, &task.Status, &task.Assignee, &labels,
+		&task.Rank, &customFields, &task.CreatedAt, &task.UpdatedAt, &parentID}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if task.CustomFields, err = decodeCustomFields(customFields); err != nil {
+		return nil, err
+	}
+	task.ProjectID = projectID.Int
+	task.ParentID = parentID.Int
+	task.Key = key.String
+	task.Labels = labels
+	return &task, nil
+}
+
+// scanListedPgxTask reads a row of listTasksQuery for filter.
+func scanListedPgxTask(row rowScanner, filter models.TaskFilter) (*models.Task, error) {
+	if filter.IncludeArchived {
+		return scanArchivedPgxTask(row)
+	}
+	return scanPgxTask(row)
+}
+
+// scanArchivedPgxTask reads a row selecting archivedTaskColumns.
+func scanArchivedPgxTask(row rowScanner) (*models.Task, error) {
+	var archivedAt pgtype.Timestamptz
+	task, err := scanPgxTask(row, &archivedAt)
+	if err != nil {
+		return nil, err
+	}
+	if archivedAt.Status == pgtype.Present {
+		task.ArchivedAt = archivedAt.Time
+	}
+	return task, nil
+}
+
+// scanUpdatedTask reads the columns returned by updateTaskQuery into task.
+func scanUpdatedTask(row rowScanner, task *models.Task) error {
+	var projectID, parentID pgtype.Int8
+	var key pgtype.Text
+	if err := row.Scan(&task.ExternalID, &projectID, &key, &task.CreatedAt, &task.UpdatedAt, &parentID); err != nil {
+		return err
+	}
+	task.ProjectID = projectID.Int
+	task.ParentID = parentID.Int
+	task.Key = key.String
+	return nil
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository/repositorytest"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// The tests and benchmarks below need a real database, as pgx cannot be mocked like database/sql.
+// They run against the database named by the libpq variables PGHOST, PGPORT, PGUSER, PGPASSWORD
+// and PGDATABASE, and are skipped when PGHOST is unset.
+
+// liveRepositories connects both drivers to the test database and returns their task repositories
+// along with a project to create tasks in.
+func liveRepositories(tb testing.TB) (map[string]repository.TaskRepository, *models.Project) {
+	tb.Helper()
+	db, pool := liveDatabase(tb)
+	logger := zap.NewNop()
+
+	return map[string]repository.TaskRepository{
+		"pq":  NewPostgresTaskRepository(db, logger),
+		"pgx": NewPgxTaskRepository(pool, logger),
+	}, newLiveProject(tb, db)
+}
+
+// liveConfig returns the settings of the test database, skipping the test when PGHOST is unset.
+func liveConfig(tb testing.TB) postgres.Config {
+	tb.Helper()
+	host := os.Getenv("PGHOST")
+	if host == "" {
+		tb.Skip("PGHOST is not set")
+	}
+
+	cfg := postgres.Config{
+		User:                   os.Getenv("PGUSER"),
+		Password:               os.Getenv("PGPASSWORD"),
+		DBName:                 os.Getenv("PGDATABASE"),
+		Host:                   host,
+		Port:                   os.Getenv("PGPORT"),
+		SSLMode:                "disable",
+		MaxConns:               10,
+		StatementCacheCapacity: 512,
+		ConnectAttempts:        1,
+	}
+	if cfg.Port == "" {
+		cfg.Port = "5432"
+	}
+	return cfg
+}
+
+// liveDatabase connects both drivers to the test database, closing them when the test ends.
+func liveDatabase(tb testing.TB) (*sql.DB, *pgxpool.Pool) {
+	tb.Helper()
+	cfg := liveConfig(tb)
+	logger := zap.NewNop()
+
+	db, err := postgres.NewDB(cfg, logger)
+	require.NoError(tb, err)
+	pool, err := postgres.NewPool(cfg, logger)
+	require.NoError(tb, err)
+
+	tb.Cleanup(func() {
+		pool.Close()
+		db.Close()
+	})
+	return db, pool
+}
+
+// newLiveProject creates a project with a unique key, deleting it along with its tasks when the test ends.
+func newLiveProject(tb testing.TB, db *sql.DB) *models.Project {
+	tb.Helper()
+	project, err := NewPostgresProjectRepository(db, zap.NewNop()).CreateProject(context.Background(), &models.Project{
+		Key:  "B" + strings.ToUpper(strconv.FormatInt(time.Now().UnixNano()%1e9, 36)),
+		Name: "Driver comparison",
+	})
+	require.NoError(tb, err)
+
+	tb.Cleanup(func() {
+		db.Exec(`DELETE FROM tasks WHERE project_id = $1`, project.ID)
+		db.Exec(`DELETE FROM projects WHERE id = $1`, project.ID)
+	})
+	return project
+}
+
+// newLiveTask returns a task of project with a unique external ID.
+func newLiveTask(project *models.Project, title string) *models.Task {
+	return &models.Task{
+		ExternalID:   fmt.Sprintf("%s-%d", project.Key, time.Now().UnixNano()),
+		ProjectID:    project.ID,
+		Title:        title,
+		Description:  "Compare the drivers",
+		Status:       "open",
+		Labels:       []string{"bench"},
+		Rank:         "m",
+		CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 3}},
+	}
+}
+
+func TestTaskRepositoryDrivers(t *testing.T) {
+	repos, project := liveRepositories(t)
+	ctx := context.Background()
+
+	for driver, repo := range repos {
+		t.Run(driver, func(t *testing.T) {
+			created, err := repo.CreateTask(ctx, newLiveTask(project, "Created with "+driver))
+			require.NoError(t, err)
+			assert.NotZero(t, created.ID)
+			assert.NotEmpty(t, created.Key)
+
+			task, err := repo.GetTask(ctx, created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, created.Key, task.Key)
+			assert.Equal(t, project.ID, task.ProjectID)
+			assert.Equal(t, []string{"bench"}, task.Labels)
+			assert.Equal(t, float64(3), task.CustomFields["points"].Number)
+
+			id, err := repo.GetTaskIDByKey(ctx, created.Key)
+			require.NoError(t, err)
+			assert.Equal(t, created.ID, id)
+
+			task.Title = "Updated with " + driver
+			task.Labels = nil
+			updated, err := repo.UpdateTasks(ctx, []*models.Task{task})
+			require.NoError(t, err)
+			assert.Equal(t, created.Key, updated[0].Key)
+
+			tasks, err := repo.ListTasks(ctx, models.TaskFilter{ProjectID: project.ID, Status: "open"})
+			require.NoError(t, err)
+			assert.NotEmpty(t, tasks)
+
+			require.NoError(t, repo.DeleteTask(ctx, created.ID))
+			_, err = repo.GetTask(ctx, created.ID)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+			assert.ErrorIs(t, repo.DeleteTask(ctx, created.ID), sql.ErrNoRows)
+			assert.ErrorIs(t, repo.DeleteTasks(ctx, []int64{created.ID}), sql.ErrNoRows)
+		})
+	}
+}
+
+func TestTaskRepositoryConformance(t *testing.T) {
+	db, pool := liveDatabase(t)
+	logger := zap.NewNop()
+	drivers := map[string]struct {
+		repo       repository.TaskRepository
+		transactor repository.Transactor
+	}{
+		"pq":  {NewPostgresTaskRepository(db, logger), NewSQLTransactor(db, &sql.TxOptions{Isolation: sql.LevelSerializable}, 3, logger)},
+		"pgx": {NewPgxTaskRepository(pool, logger), NewPgxTransactor(pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, 3, logger)},
+	}
+
+	for driver, d := range drivers {
+		t.Run(driver, func(t *testing.T) {
+			repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Fixture {
+				return repositorytest.Fixture{
+					Repository: d.repo,
+					NewProject: func(t *testing.T) (int64, string) {
+						project := newLiveProject(t, db)
+						return project.ID, project.Key
+					},
+					Transactor: d.transactor,
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkTaskRepositoryCRUD creates, reads, updates and deletes one task per iteration.
+func BenchmarkTaskRepositoryCRUD(b *testing.B) {
+	repos, project := liveRepositories(b)
+	ctx := context.Background()
+
+	for _, driver := range []string{"pq", "pgx"} {
+		repo := repos[driver]
+		b.Run(driver, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				task, err := repo.CreateTask(ctx, newLiveTask(project, "CRUD"))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if task, err = repo.GetTask(ctx, task.ID); err != nil {
+					b.Fatal(err)
+				}
+				task.Status = "done"
+				if _, err := repo.UpdateTask(ctx, task); err != nil {
+					b.Fatal(err)
+				}
+				if err := repo.DeleteTask(ctx, task.ID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTaskRepositoryList lists the first page of a project holding 1000 tasks.
+func BenchmarkTaskRepositoryList(b *testing.B) {
+	repos, project := liveRepositories(b)
+	ctx := context.Background()
+
+	tasks := make([]*models.Task, 1000)
+	for i := range tasks {
+		tasks[i] = newLiveTask(project, "List")
+		tasks[i].ExternalID += "-" + strconv.Itoa(i)
+	}
+	if _, err := repos["pq"].CreateTasks(ctx, tasks); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, driver := range []string{"pq", "pgx"} {
+		repo := repos[driver]
+		for _, limit := range []int{50, 1000} {
+			b.Run(fmt.Sprintf("%s/limit=%d", driver, limit), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.ListTasks(ctx, models.TaskFilter{ProjectID: project.ID, Limit: limit}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const customFieldColumns = `id, project_id, name, type, required, options, min_value, max_value, max_length, created_at, updated_at`
+
+type PostgresCustomFieldRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresCustomFieldRepository(db *sql.DB, logger *zap.Logger) *PostgresCustomFieldRepository {
+	return &PostgresCustomFieldRepository{db: db, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *PostgresCustomFieldRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *PostgresCustomFieldRepository) CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	query := `
+		INSERT INTO custom_fields (project_id, name, type, required, options, min_value, max_value, max_length, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id;
+	`
+
+	now := time.Now()
+	field.CreatedAt = now
+	field.UpdatedAt = now
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		field.ProjectID, field.Name, field.Type, field.Required, stringArray(field.Options), field.Min, field.Max,
+		field.MaxLength, field.CreatedAt, field.UpdatedAt,
+	).Scan(&field.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to create custom field", zap.Error(err))
+		return nil, err
+	}
+
+	return field, nil
+}
+
+func (r *PostgresCustomFieldRepository) ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error) {
+	query := `SELECT ` + customFieldColumns + ` FROM custom_fields WHERE project_id = $1 ORDER BY name`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, projectID)
+	if err != nil {
+		r.logger.Error("Failed to list custom fields", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []*models.CustomField
+	for rows.Next() {
+		field, err := scanCustomField(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan custom field", zap.Error(err))
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, rows.Err()
+}
+
+func (r *PostgresCustomFieldRepository) GetCustomField(ctx context.Context, id int64) (*models.CustomField, error) {
+	query := `SELECT ` + customFieldColumns + ` FROM custom_fields WHERE id = $1`
+
+	field, err := scanCustomField(r.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch custom field", zap.Error(err))
+		return nil, err
+	}
+
+	return field, nil
+}
+
+func (r *PostgresCustomFieldRepository) UpdateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	query := `
+		UPDATE custom_fields
+		SET type = $1, required = $2, options = $3, min_value = $4, max_value = $5, max_length = $6, updated_at = $7
+		WHERE id = $8
+		RETURNING created_at;
+	`
+
+	field.UpdatedAt = time.Now()
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		field.Type, field.Required, stringArray(field.Options), field.Min, field.Max, field.MaxLength, field.UpdatedAt, field.ID,
+	).Scan(&field.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to update custom field", zap.Error(err))
+		return nil, err
+	}
+
+	return field, nil
+}
+
+func (r *PostgresCustomFieldRepository) DeleteCustomField(ctx context.Context, id int64) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var projectID int64
+	var name string
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM custom_fields WHERE id = $1 RETURNING project_id, name`, id,
+	).Scan(&projectID, &name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		r.logger.Error("Failed to delete custom field", zap.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE tasks SET custom_fields = custom_fields - $1::text WHERE project_id = $2 AND custom_fields ? $1::text`,
+		name, projectID)
+	if err != nil {
+		r.logger.Error("Failed to remove custom field values", zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit custom field deletion", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresCustomFieldRepository) ListFieldValues(ctx context.Context, projectID int64, name string) ([]models.FieldValueRef, error) {
+	query := `SELECT id, key, custom_fields -> $2::text FROM tasks WHERE project_id = $1 ORDER BY id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, projectID, name)
+	if err != nil {
+		r.logger.Error("Failed to list custom field values", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []models.FieldValueRef
+	for rows.Next() {
+		var ref models.FieldValueRef
+		var key sql.NullString
+		var raw []byte
+		if err := rows.Scan(&ref.TaskID, &key, &raw); err != nil {
+			r.logger.Error("Failed to scan custom field value", zap.Error(err))
+			return nil, err
+		}
+		ref.TaskKey = key.String
+		if raw != nil {
+			value, err := decodeFieldValue(raw)
+			if err != nil {
+				r.logger.Error("Failed to decode custom field value", zap.Error(err))
+				return nil, err
+			}
+			ref.Value = &value
+		}
+		values = append(values, ref)
+	}
+
+	return values, rows.Err()
+}
+
+func scanCustomField(row rowScanner) (*models.CustomField, error) {
+	var field models.CustomField
+	var options pq.StringArray
+	var minValue, maxValue sql.NullFloat64
+	err := row.Scan(&field.ID, &field.ProjectID, &field.Name, &field.Type, &field.Required, &options,
+		&minValue, &maxValue, &field.MaxLength, &field.CreatedAt, &field.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	field.Options = options
+	if minValue.Valid {
+		field.Min = &minValue.Float64
+	}
+	if maxValue.Valid {
+		field.Max = &maxValue.Float64
+	}
+	return &field, nil
+}
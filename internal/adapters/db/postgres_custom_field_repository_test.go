@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var customFieldRowColumns = []string{"id", "project_id", "name", "type", "required", "options", "min_value", "max_value", "max_length", "created_at", "updated_at"}
+
+func TestPostgresCustomFieldRepository_CreateCustomField(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCustomFieldRepository(db, logger)
+	maxPoints := 13.0
+	field := &models.CustomField{ProjectID: 3, Name: "points", Type: models.FieldTypeNumber, Max: &maxPoints}
+
+	mock.ExpectQuery("INSERT INTO custom_fields").
+		WithArgs(3, "points", "number", false, sqlmock.AnyArg(), nil, &maxPoints, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	createdField, err := repo.CreateCustomField(context.Background(), field)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), createdField.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCustomFieldRepository_CreateCustomField_NameTaken(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCustomFieldRepository(db, logger)
+
+	mock.ExpectQuery("INSERT INTO custom_fields").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	_, err := repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: 3, Name: "points", Type: models.FieldTypeNumber})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCustomFieldRepository_ListCustomFields(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCustomFieldRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM custom_fields WHERE project_id = \\$1 ORDER BY name").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(customFieldRowColumns).
+			AddRow(1, 3, "env", "enum", true, "{staging,prod}", nil, nil, 0, time.Now(), time.Now()).
+			AddRow(2, 3, "points", "number", false, "{}", 0, 13, 0, time.Now(), time.Now()))
+
+	fields, err := repo.ListCustomFields(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Len(t, fields, 2)
+	assert.Equal(t, []string{"staging", "prod"}, fields[0].Options)
+	assert.Nil(t, fields[0].Min)
+	assert.Equal(t, 13.0, *fields[1].Max)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCustomFieldRepository_DeleteCustomField(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCustomFieldRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM custom_fields WHERE id = \\$1 RETURNING project_id, name").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"project_id", "name"}).AddRow(3, "points"))
+	mock.ExpectExec("UPDATE tasks SET custom_fields = custom_fields - \\$1::text").
+		WithArgs("points", 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err := repo.DeleteCustomField(context.Background(), 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCustomFieldRepository_ListFieldValues(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresCustomFieldRepository(db, logger)
+
+	mock.ExpectQuery("SELECT id, key, custom_fields -> \\$2::text FROM tasks WHERE project_id = \\$1").
+		WithArgs(3, "points").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "key", "value"}).
+			AddRow(1, "OPS-1", "5").
+			AddRow(2, "OPS-2", nil))
+
+	values, err := repo.ListFieldValues(context.Background(), 3, "points")
+	assert.NoError(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, "OPS-1", values[0].TaskKey)
+	assert.Equal(t, &models.FieldValue{Type: models.FieldTypeNumber, Number: 5}, values[0].Value)
+	assert.Nil(t, values[1].Value)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
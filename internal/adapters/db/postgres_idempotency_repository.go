@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap"
+)
+
+// claimAttempts bounds how often ClaimKey retries when the record holding a key disappears between
+// the failed claim and reading the record.
+const claimAttempts = 3
+
+type PostgresIdempotencyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresIdempotencyRepository(db *sql.DB, logger *zap.Logger) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db, logger: logger}
+}
+
+// ClaimKey relies on the primary key of idempotency_keys, so that of several replicas receiving the
+// same key at once exactly one claims it.
+func (r *PostgresIdempotencyRepository) ClaimKey(ctx context.Context, record *models.IdempotencyRecord,
+	staleBefore time.Time) (*models.IdempotencyRecord, error) {
+	claim := `
+		INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, response_type = NULL, response = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+			OR (idempotency_keys.response_type IS NULL AND idempotency_keys.created_at < $5)
+		RETURNING created_at;
+	`
+	holder := `
+		SELECT key, request_hash, COALESCE(response_type, ''), response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+
+	for attempt := 0; attempt < claimAttempts; attempt++ {
+		var createdAt time.Time
+		err := r.db.QueryRowContext(ctx, claim,
+			record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt, sql.NullTime{Time: staleBefore, Valid: !staleBefore.IsZero()},
+		).Scan(&createdAt)
+		if err == nil {
+			record.CreatedAt = createdAt
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Error("Failed to claim idempotency key", zap.Error(err))
+			return nil, err
+		}
+
+		var existing models.IdempotencyRecord
+		err = r.db.QueryRowContext(ctx, holder, record.Key).Scan(&existing.Key, &existing.RequestHash,
+			&existing.ResponseType, &existing.Response, &existing.CreatedAt, &existing.ExpiresAt)
+		if err == nil {
+			return &existing, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Error("Failed to fetch idempotency key", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("idempotency key changed hands too often to be claimed")
+}
+
+// CompleteKey and ReleaseKey recognize the claim by its creation time and request, which a later claim of
+// the same key does not share.
+func (r *PostgresIdempotencyRepository) CompleteKey(ctx context.Context, claim *models.IdempotencyRecord, responseType string,
+	response []byte) error {
+	query := `
+		UPDATE idempotency_keys SET response_type = $1, response = $2
+		WHERE key = $3 AND created_at = $4 AND request_hash = $5 AND response_type IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, responseType, response, claim.Key, claim.CreatedAt, claim.RequestHash)
+	if err != nil {
+		r.logger.Error("Failed to complete idempotency key", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) ReleaseKey(ctx context.Context, claim *models.IdempotencyRecord) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND created_at = $2 AND request_hash = $3 AND response_type IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, claim.Key, claim.CreatedAt, claim.RequestHash)
+	if err != nil {
+		r.logger.Error("Failed to release idempotency key", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		r.logger.Error("Failed to delete expired idempotency keys", zap.Error(err))
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
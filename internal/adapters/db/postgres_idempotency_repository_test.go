@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresIdempotencyRepository_ClaimKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	record := &models.IdempotencyRecord{Key: "retry-1", RequestHash: []byte("hash"), CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	staleBefore := now.Add(-time.Minute)
+
+	t.Run("Free key is claimed", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresIdempotencyRepository(db, logger)
+
+		mock.ExpectQuery("INSERT INTO idempotency_keys .* ON CONFLICT \\(key\\) DO UPDATE").
+			WithArgs("retry-1", []byte("hash"), now, now.Add(time.Hour), staleBefore).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now.Truncate(time.Microsecond)))
+
+		claim := *record
+		existing, err := repo.ClaimKey(ctx, &claim, staleBefore)
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+		assert.Equal(t, now.Truncate(time.Microsecond), claim.CreatedAt)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Held key returns its record", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresIdempotencyRepository(db, logger)
+
+		mock.ExpectQuery("INSERT INTO idempotency_keys").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT key, request_hash, COALESCE\\(response_type, ''\\), response, created_at, expires_at FROM idempotency_keys").
+			WithArgs("retry-1").
+			WillReturnRows(sqlmock.NewRows([]string{"key", "request_hash", "response_type", "response", "created_at", "expires_at"}).
+				AddRow("retry-1", []byte("hash"), "taskmanager.TaskResponse", []byte("resp"), now, now.Add(time.Hour)))
+
+		existing, err := repo.ClaimKey(ctx, record, staleBefore)
+		assert.NoError(t, err)
+		assert.True(t, existing.Completed())
+		assert.Equal(t, []byte("resp"), existing.Response)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Key released meanwhile is claimed again", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresIdempotencyRepository(db, logger)
+
+		mock.ExpectQuery("INSERT INTO idempotency_keys").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT key, request_hash").WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("INSERT INTO idempotency_keys").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+
+		existing, err := repo.ClaimKey(ctx, record, staleBefore)
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Claims in progress are kept without a stale time", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresIdempotencyRepository(db, logger)
+
+		mock.ExpectQuery("INSERT INTO idempotency_keys").
+			WithArgs("retry-1", []byte("hash"), now, now.Add(time.Hour), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+
+		existing, err := repo.ClaimKey(ctx, record, time.Time{})
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresIdempotencyRepository_CompleteKey(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresIdempotencyRepository(db, logger)
+	claim := &models.IdempotencyRecord{Key: "retry-1", RequestHash: []byte("hash"), CreatedAt: time.Now()}
+
+	mock.ExpectExec("UPDATE idempotency_keys SET response_type = \\$1, response = \\$2 "+
+		"WHERE key = \\$3 AND created_at = \\$4 AND request_hash = \\$5 AND response_type IS NULL").
+		WithArgs("taskmanager.TaskResponse", []byte("resp"), "retry-1", claim.CreatedAt, []byte("hash")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.CompleteKey(context.Background(), claim, "taskmanager.TaskResponse", []byte("resp"))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresIdempotencyRepository_ReleaseKey(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresIdempotencyRepository(db, logger)
+	claim := &models.IdempotencyRecord{Key: "retry-1", RequestHash: []byte("hash"), CreatedAt: time.Now()}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE key = \\$1 AND created_at = \\$2 AND request_hash = \\$3 AND response_type IS NULL").
+		WithArgs("retry-1", claim.CreatedAt, []byte("hash")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.ReleaseKey(context.Background(), claim)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresIdempotencyRepository_DeleteExpiredKeys(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresIdempotencyRepository(db, logger)
+	now := time.Now()
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= \\$1").
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	deleted, err := repo.DeleteExpiredKeys(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const projectColumns = `id, key, name, description, workflow, default_assignee, allowed_labels, archived, created_at, updated_at`
+
+// projectHasTasksQuery reports whether a project holds any task, live or archived. The SQLite project
+// repository runs it as well.
+const projectHasTasksQuery = `
+	SELECT EXISTS (SELECT 1 FROM tasks WHERE project_id = $1)
+		OR EXISTS (SELECT 1 FROM tasks_archive WHERE project_id = $1)
+`
+
+type PostgresProjectRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresProjectRepository(db *sql.DB, logger *zap.Logger) *PostgresProjectRepository {
+	return &PostgresProjectRepository{db: db, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *PostgresProjectRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *PostgresProjectRepository) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	query := `
+		INSERT INTO projects (key, name, description, workflow, default_assignee, allowed_labels, archived, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id;
+	`
+
+	now := time.Now()
+	project.CreatedAt = now
+	project.UpdatedAt = now
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		project.Key, project.Name, project.Description, stringArray(project.Workflow), project.DefaultAssignee,
+		stringArray(project.AllowedLabels), project.Archived, project.CreatedAt, project.UpdatedAt,
+	).Scan(&project.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to create project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (r *PostgresProjectRepository) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects ORDER BY id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to list projects", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []*models.Project
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan project", zap.Error(err))
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
+
+func (r *PostgresProjectRepository) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	project, err := scanProject(r.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (r *PostgresProjectRepository) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	query := `
+		UPDATE projects
+		SET name = $1, description = $2, workflow = $3, default_assignee = $4, allowed_labels = $5,
+		    archived = $6, updated_at = $7
+		WHERE id = $8
+		RETURNING key, created_at;
+	`
+
+	project.UpdatedAt = time.Now()
+
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		project.Name, project.Description, stringArray(project.Workflow), project.DefaultAssignee,
+		stringArray(project.AllowedLabels), project.Archived, project.UpdatedAt, project.ID,
+	).Scan(&project.Key, &project.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to update project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// DeleteProject locks the project before looking for its tasks. Creating, transferring or archiving a task
+// locks the project as well, through the foreign keys of tasks and tasks_archive, so tasks created or
+// archived meanwhile are either seen or wait for the deletion and then fail.
+func (r *PostgresProjectRepository) DeleteProject(ctx context.Context, id int64) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM projects WHERE id = $1 FOR UPDATE`, id).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.ErrNoRows
+		}
+		r.logger.Error("Failed to lock project", zap.Error(err))
+		return err
+	}
+
+	var hasTasks bool
+	err = tx.QueryRowContext(ctx, projectHasTasksQuery, id).Scan(&hasTasks)
+	if err != nil {
+		r.logger.Error("Failed to check project tasks", zap.Error(err))
+		return err
+	}
+	if hasTasks {
+		return repository.ErrNotEmpty
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id); err != nil {
+		r.logger.Error("Failed to delete project", zap.Error(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanProject reads a row selected with projectColumns.
+func scanProject(row rowScanner) (*models.Project, error) {
+	var project models.Project
+	var workflow, allowedLabels pq.StringArray
+	err := row.Scan(&project.ID, &project.Key, &project.Name, &project.Description, &workflow, &project.DefaultAssignee,
+		&allowedLabels, &project.Archived, &project.CreatedAt, &project.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	project.Workflow = workflow
+	project.AllowedLabels = allowedLabels
+	return &project, nil
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var projectRowColumns = []string{"id", "key", "name", "description", "workflow", "default_assignee", "allowed_labels", "archived", "created_at", "updated_at"}
+
+func TestPostgresProjectRepository_CreateProject(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresProjectRepository(db, logger)
+	project := &models.Project{
+		Key:      "OPS",
+		Name:     "Ops",
+		Workflow: []string{"open", "done"},
+	}
+
+	mock.ExpectQuery("INSERT INTO projects").
+		WithArgs(project.Key, project.Name, "", sqlmock.AnyArg(), "", sqlmock.AnyArg(), false, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	createdProject, err := repo.CreateProject(context.Background(), project)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), createdProject.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProjectRepository_CreateProject_KeyTaken(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresProjectRepository(db, logger)
+
+	mock.ExpectQuery("INSERT INTO projects").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	_, err := repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProjectRepository_GetProject(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresProjectRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM projects WHERE id =").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(projectRowColumns).
+			AddRow(1, "OPS", "Ops", "", "{open,done}", "alice", "{bug}", true, time.Now(), time.Now()))
+
+	project, err := repo.GetProject(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"open", "done"}, project.Workflow)
+	assert.Equal(t, []string{"bug"}, project.AllowedLabels)
+	assert.True(t, project.Archived)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProjectRepository_UpdateProject(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresProjectRepository(db, logger)
+	project := &models.Project{ID: 1, Name: "Operations", Archived: true}
+
+	mock.ExpectQuery("UPDATE projects SET").
+		WithArgs(project.Name, "", sqlmock.AnyArg(), "", sqlmock.AnyArg(), true, sqlmock.AnyArg(), project.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "created_at"}).AddRow("OPS", time.Now()))
+
+	updatedProject, err := repo.UpdateProject(context.Background(), project)
+	assert.NoError(t, err)
+	assert.Equal(t, project.ID, updatedProject.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProjectRepository_DeleteProject(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresProjectRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM projects WHERE id = (.+) FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT EXISTS (.+) tasks_archive").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("DELETE FROM projects WHERE id =").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.DeleteProject(context.Background(), 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProjectRepository_DeleteProject_WithTasks(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresProjectRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM projects WHERE id = (.+) FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT EXISTS (.+) tasks_archive").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err := repo.DeleteProject(context.Background(), 1)
+	assert.ErrorIs(t, err, repository.ErrNotEmpty)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const savedViewColumns = `id, owner, name, project_id, filter, order_by, order_descending, columns, shared, created_at, updated_at`
+
+type PostgresSavedViewRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresSavedViewRepository(db *sql.DB, logger *zap.Logger) *PostgresSavedViewRepository {
+	return &PostgresSavedViewRepository{db: db, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *PostgresSavedViewRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *PostgresSavedViewRepository) CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	query := `
+		INSERT INTO saved_views (owner, name, project_id, filter, order_by, order_descending, columns, shared, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id;
+	`
+
+	now := time.Now()
+	view.CreatedAt = now
+	view.UpdatedAt = now
+
+	orderBy, descending := viewOrder(view.OrderBy)
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		view.Owner, view.Name, nullableID(view.ProjectID), view.Filter, orderBy, descending, stringArray(view.Columns),
+		view.Shared, view.CreatedAt, view.UpdatedAt,
+	).Scan(&view.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to create saved view", zap.Error(err))
+		return nil, err
+	}
+
+	return view, nil
+}
+
+func (r *PostgresSavedViewRepository) GetSavedView(ctx context.Context, id int64) (*models.SavedView, error) {
+	query := `SELECT ` + savedViewColumns + ` FROM saved_views WHERE id = $1`
+
+	view, err := scanSavedView(r.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch saved view", zap.Error(err))
+		return nil, err
+	}
+
+	return view, nil
+}
+
+func (r *PostgresSavedViewRepository) ListSavedViews(ctx context.Context, owner string, projectID int64) ([]*models.SavedView, error) {
+	query := `SELECT ` + savedViewColumns + ` FROM saved_views WHERE (owner = $1 OR shared)`
+	args := []any{owner}
+	if projectID != 0 {
+		args = append(args, projectID)
+		query += fmt.Sprintf(" AND project_id = $%d", len(args))
+	}
+	query += ` ORDER BY name, id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list saved views", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []*models.SavedView
+	for rows.Next() {
+		view, err := scanSavedView(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan saved view", zap.Error(err))
+			return nil, err
+		}
+		views = append(views, view)
+	}
+
+	return views, rows.Err()
+}
+
+func (r *PostgresSavedViewRepository) UpdateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	query := `
+		UPDATE saved_views
+		SET name = $1, project_id = $2, filter = $3, order_by = $4, order_descending = $5, columns = $6, shared = $7, updated_at = $8
+		WHERE id = $9
+		RETURNING owner, created_at;
+	`
+
+	view.UpdatedAt = time.Now()
+
+	orderBy, descending := viewOrder(view.OrderBy)
+	err := r.conn(ctx).QueryRowContext(ctx, query,
+		view.Name, nullableID(view.ProjectID), view.Filter, orderBy, descending, stringArray(view.Columns), view.Shared,
+		view.UpdatedAt, view.ID,
+	).Scan(&view.Owner, &view.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to update saved view", zap.Error(err))
+		return nil, err
+	}
+
+	return view, nil
+}
+
+func (r *PostgresSavedViewRepository) DeleteSavedView(ctx context.Context, id int64) error {
+	res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM saved_views WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete saved view", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get affected rows", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// viewOrder splits the order of a view into its order_by and order_descending columns.
+func viewOrder(order *models.FieldOrder) (sql.NullString, bool) {
+	if order == nil {
+		return sql.NullString{}, false
+	}
+	return sql.NullString{String: order.Field, Valid: true}, order.Descending
+}
+
+func scanSavedView(row rowScanner) (*models.SavedView, error) {
+	var view models.SavedView
+	var projectID sql.NullInt64
+	var orderBy sql.NullString
+	var descending bool
+	var columns pq.StringArray
+	err := row.Scan(&view.ID, &view.Owner, &view.Name, &projectID, &view.Filter, &orderBy, &descending, &columns,
+		&view.Shared, &view.CreatedAt, &view.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	view.ProjectID = projectID.Int64
+	view.Columns = columns
+	if orderBy.Valid {
+		view.OrderBy = &models.FieldOrder{Field: orderBy.String, Descending: descending}
+	}
+	return &view, nil
+}
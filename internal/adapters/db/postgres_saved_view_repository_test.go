@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var savedViewRowColumns = []string{"id", "owner", "name", "project_id", "filter", "order_by", "order_descending", "columns", "shared", "created_at", "updated_at"}
+
+func TestPostgresSavedViewRepository_CreateSavedView(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresSavedViewRepository(db, logger)
+	view := &models.SavedView{Owner: "alice", Name: "Big bugs", ProjectID: 3, Filter: `labels:bug`,
+		OrderBy: &models.FieldOrder{Field: "points", Descending: true}, Columns: []string{"key", "title"}}
+
+	mock.ExpectQuery("INSERT INTO saved_views").
+		WithArgs("alice", "Big bugs", 3, `labels:bug`, "points", true, sqlmock.AnyArg(), false, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	createdView, err := repo.CreateSavedView(context.Background(), view)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), createdView.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSavedViewRepository_CreateSavedView_NameTaken(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresSavedViewRepository(db, logger)
+
+	mock.ExpectQuery("INSERT INTO saved_views").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	_, err := repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "Big bugs"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSavedViewRepository_ListSavedViews(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresSavedViewRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM saved_views WHERE \\(owner = \\$1 OR shared\\) AND project_id = \\$2 ORDER BY name, id").
+		WithArgs("alice", 3).
+		WillReturnRows(sqlmock.NewRows(savedViewRowColumns).
+			AddRow(1, "alice", "Big bugs", 3, `labels:bug`, "points", true, "{key,title}", false, time.Now(), time.Now()).
+			AddRow(2, "bob", "Open", nil, `status = open`, nil, false, "{}", true, time.Now(), time.Now()))
+
+	views, err := repo.ListSavedViews(context.Background(), "alice", 3)
+	assert.NoError(t, err)
+	assert.Len(t, views, 2)
+	assert.Equal(t, &models.FieldOrder{Field: "points", Descending: true}, views[0].OrderBy)
+	assert.Equal(t, []string{"key", "title"}, views[0].Columns)
+	assert.Nil(t, views[1].OrderBy)
+	assert.Equal(t, int64(0), views[1].ProjectID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSavedViewRepository_UpdateSavedView(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresSavedViewRepository(db, logger)
+	view := &models.SavedView{ID: 1, Name: "Open", Filter: `status = open`, Shared: true}
+
+	mock.ExpectQuery("UPDATE saved_views").
+		WithArgs("Open", nil, `status = open`, nil, false, sqlmock.AnyArg(), true, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"owner", "created_at"}).AddRow("alice", time.Now()))
+
+	updatedView, err := repo.UpdateSavedView(context.Background(), view)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", updatedView.Owner)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSavedViewRepository_DeleteSavedView_NotFound(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresSavedViewRepository(db, logger)
+
+	mock.ExpectExec("DELETE FROM saved_views WHERE id = \\$1").
+		WithArgs(9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.DeleteSavedView(context.Background(), 9), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
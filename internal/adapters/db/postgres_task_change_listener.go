@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// taskChangesChannel is the channel the tasks table notifies of updated and deleted tasks, see migration 0012.
+const taskChangesChannel = "task_changes"
+
+// PostgresTaskChangeListener listens for the notifications Postgres sends when any server changes or
+// deletes a task, and reports the IDs of those tasks.
+type PostgresTaskChangeListener struct {
+	dsn     string
+	changed func(ctx context.Context, ids ...int64)
+	logger  *zap.Logger
+}
+
+// NewPostgresTaskChangeListener reports changed tasks to changed. It calls changed without IDs after
+// reconnecting to the database, as the notifications sent while it was disconnected are lost.
+func NewPostgresTaskChangeListener(dsn string, changed func(ctx context.Context, ids ...int64), logger *zap.Logger) *PostgresTaskChangeListener {
+	return &PostgresTaskChangeListener{
+		dsn:     dsn,
+		changed: changed,
+		logger:  logger,
+	}
+}
+
+// Run listens on its own connection until ctx is cancelled, reconnecting whenever the connection is lost.
+func (l *PostgresTaskChangeListener) Run(ctx context.Context) {
+	listener := pq.NewListener(l.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			l.logger.Warn("Task change listener lost its database connection", zap.Error(err))
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(taskChangesChannel); err != nil {
+		l.logger.Error("Failed to listen for task changes", zap.Error(err))
+		return
+	}
+	l.logger.Info("Listening for task changes", zap.String("channel", taskChangesChannel))
+	// Tasks cached before the listener was connected may have changed unnoticed.
+	l.changed(ctx)
+
+	// Pinging an idle connection notices a dead one, which is then reconnected.
+	ticker := time.NewTicker(90 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.logger.Info("Stopping task change listener")
+			return
+		case notification := <-listener.Notify:
+			if notification == nil {
+				l.logger.Info("Task change listener reconnected")
+				l.changed(ctx)
+				continue
+			}
+			id, err := strconv.ParseInt(notification.Extra, 10, 64)
+			if err != nil {
+				l.logger.Warn("Ignoring malformed task change notification", zap.String("payload", notification.Extra))
+				continue
+			}
+			l.changed(ctx, id)
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPostgresTaskChangeListener(t *testing.T) {
+	cfg := liveConfig(t)
+	db, _ := liveDatabase(t)
+	project := newLiveProject(t, db)
+	repo := NewPostgresTaskRepository(db, zap.NewNop())
+	ctx := context.Background()
+
+	changes := make(chan []int64, 100)
+	listener := NewPostgresTaskChangeListener(cfg.DSN(), func(_ context.Context, ids ...int64) {
+		changes <- ids
+	}, zap.NewNop())
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go listener.Run(listenCtx)
+
+	// waitFor returns once id is reported; other tests may change tasks meanwhile.
+	waitFor := func(t *testing.T, want func(ids []int64) bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case ids := <-changes:
+				if want(ids) {
+					return
+				}
+			case <-timeout:
+				t.Fatal("no matching task change was reported")
+			}
+		}
+	}
+
+	// Once connected, the listener reports that all tasks may have changed.
+	waitFor(t, func(ids []int64) bool { return len(ids) == 0 })
+
+	task, err := repo.CreateTask(ctx, newLiveTask(project, "Watched"))
+	require.NoError(t, err)
+	task.Title = "Changed"
+	_, err = repo.UpdateTask(ctx, task)
+	require.NoError(t, err)
+	waitFor(t, func(ids []int64) bool { return assert.ObjectsAreEqual([]int64{task.ID}, ids) })
+
+	require.NoError(t, repo.DeleteTask(ctx, task.ID))
+	waitFor(t, func(ids []int64) bool { return assert.ObjectsAreEqual([]int64{task.ID}, ids) })
+}
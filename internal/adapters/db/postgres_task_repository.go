@@ -4,13 +4,189 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+const taskColumns = `id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, created_at, updated_at, parent_id`
+
+// archivedTaskColumns are the columns of archived tasks: taskColumns and the time the task was archived.
+const archivedTaskColumns = taskColumns + `, archived_at`
+
+// tasksWithArchive stands in for the tasks table in the queries of reads that include archived tasks.
+// Live tasks have no archiving time.
+const tasksWithArchive = `(SELECT ` + taskColumns + `, NULL AS archived_at FROM tasks UNION ALL SELECT ` + archivedTaskColumns + ` FROM tasks_archive) tasks`
+
+// insertBatchSize caps the rows of one multi-row insert, keeping its 11 parameters per task well below
+// the 65535 parameters Postgres accepts per statement.
+const insertBatchSize = 1000
+
+// exportFetchSize is the number of rows ExportTasks reads from its cursor at a time.
+const exportFetchSize = 500
+
+// The queries below are shared by the database/sql and the pgx task repositories. The SQLite task
+// repository runs those that SQLite understands as well.
+//
+// Postgres sets the timestamps of tasks to the start of the writing transaction, so that they come from
+// one clock whichever server writes, and all tasks written together carry the same time.
+const (
+	insertTaskQuery = `
+		INSERT INTO tasks (external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, parent_id,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, now(), now())
+		RETURNING id, created_at, updated_at;
+	`
+	getTaskQuery    = `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1`
+	updateTaskQuery = `
+		UPDATE tasks
+		SET title = $1, description = $2, status = $3, assignee = $4, labels = $5, rank = $6, custom_fields = $7, updated_at = now()
+		WHERE id = $8
+		RETURNING external_id, project_id, key, created_at, updated_at, parent_id;
+	`
+	deleteTaskQuery    = `DELETE FROM tasks WHERE id = $1`
+	deleteTasksQuery   = `DELETE FROM tasks WHERE id = ANY($1)`
+	recordImportsQuery = `
+		INSERT INTO task_imports (source, source_id, task_id)
+		SELECT $1, source_id, task_id FROM unnest($2::text[], $3::bigint[]) AS imported (source_id, task_id)
+	`
+	importedSourceIDsQuery = `
+		SELECT source_id FROM task_imports WHERE source = $1 AND source_id = ANY($2)
+		UNION ALL
+		SELECT source_id FROM task_imports_archive WHERE source = $1 AND source_id = ANY($2)
+	`
+	taskIDByKeyQuery = `
+		SELECT id FROM (
+			SELECT id, 0 AS priority FROM tasks WHERE key = $1
+			UNION ALL
+			SELECT task_id, 1 AS priority FROM task_key_aliases WHERE key = $1
+		) found
+		ORDER BY priority
+		LIMIT 1
+	`
+	taskIDByExternalIDQuery = `SELECT id FROM tasks WHERE external_id = $1`
+	lockTaskKeyQuery        = `SELECT key FROM tasks WHERE id = $1 FOR UPDATE`
+	keepTaskKeyQuery        = `INSERT INTO task_key_aliases (key, task_id) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET task_id = EXCLUDED.task_id`
+	transferTaskQuery       = `
+		UPDATE tasks
+		SET project_id = $1, key = $2, status = $3, rank = $4, custom_fields = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING ` + taskColumns + `;
+	`
+	moveTaskQuery = `
+		UPDATE tasks
+		SET status = $1, rank = $2, updated_at = now()
+		WHERE id = $3
+		RETURNING ` + taskColumns + `;
+	`
+	rankBeforeQuery = `
+		SELECT COALESCE(MAX(rank), '') FROM tasks
+		WHERE project_id IS NOT DISTINCT FROM $1 AND status = $2 AND ($3 = '' OR rank < $3)
+	`
+	rankAfterQuery = `
+		SELECT COALESCE(MIN(rank), '') FROM tasks
+		WHERE project_id IS NOT DISTINCT FROM $1 AND status = $2 AND rank > $3
+	`
+	columnsWithLongRanksQuery = `SELECT DISTINCT project_id, status FROM tasks WHERE length(rank) > $1`
+	lockColumnQuery           = `
+		SELECT id FROM tasks
+		WHERE project_id IS NOT DISTINCT FROM $1 AND status = $2
+		ORDER BY rank, external_id
+		FOR UPDATE
+	`
+	setRankQuery           = `UPDATE tasks SET rank = $1 WHERE id = $2`
+	archiveCandidatesQuery = `
+		SELECT id FROM tasks
+		WHERE project_id IS NOT DISTINCT FROM $1 AND status = $2 AND updated_at < $3
+		ORDER BY updated_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	`
+	archivePartitionsQuery = `
+		SELECT create_task_partition('tasks_archive', month) FROM (
+			SELECT DISTINCT date_trunc('month', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS month
+			FROM tasks
+			WHERE id = ANY($1)
+		) months
+	`
+	// Partitions hold the months of UTC, so the months are counted in UTC as well.
+	createTaskPartitionsQuery = `
+		SELECT create_task_partition('tasks', month AT TIME ZONE 'UTC')
+		FROM generate_series(date_trunc('month', now() AT TIME ZONE 'UTC'), $1::timestamptz AT TIME ZONE 'UTC', interval '1 month') AS month
+	`
+	// The records are copied before the tasks are deleted, which deletes the originals.
+	archiveTaskRecordsQuery = `
+		WITH aliases AS (
+			INSERT INTO task_key_aliases_archive (key, task_id)
+			SELECT key, task_id FROM task_key_aliases WHERE task_id = ANY($1)
+		)
+		INSERT INTO task_imports_archive (source, source_id, task_id, imported_at)
+		SELECT source, source_id, task_id, imported_at FROM task_imports WHERE task_id = ANY($1)
+	`
+	archiveTasksQuery = `
+		WITH moved AS (DELETE FROM tasks WHERE id = ANY($1) RETURNING ` + taskColumns + `)
+		INSERT INTO tasks_archive (` + archivedTaskColumns + `)
+		SELECT ` + taskColumns + `, now() FROM moved
+	`
+	// Unset parts of the task reference are passed as "" and 0, which no archived task has. Keys the task
+	// had in projects it was transferred out of resolve through its archived key aliases.
+	getArchivedTaskQuery = `
+		SELECT ` + archivedTaskColumns + ` FROM tasks_archive
+		WHERE external_id = $1 OR key = $2 OR id = $3 OR id = (SELECT task_id FROM task_key_aliases_archive WHERE key = $2)
+		LIMIT 1
+	`
+	setTaskParentQuery  = `UPDATE tasks SET parent_id = $1, updated_at = now() WHERE id = $2 RETURNING ` + taskColumns
+	detachSubtasksQuery = `UPDATE tasks SET parent_id = NULL, updated_at = now() WHERE parent_id = ANY($1) RETURNING id`
+	// Relations are listed from both ends.
+	listTaskRelationsQuery = `
+		SELECT task_id, related_task_id, type FROM task_relations
+		WHERE task_id = $1 OR related_task_id = $1
+		ORDER BY task_id, related_task_id, type
+	`
+	createTaskRelationsQuery = `
+		INSERT INTO task_relations (task_id, related_task_id, type)
+		SELECT * FROM unnest($1::bigint[], $2::bigint[], $3::text[])
+		ON CONFLICT DO NOTHING
+	`
+	deleteTaskRelationQuery = `DELETE FROM task_relations WHERE task_id = $1 AND related_task_id = $2 AND type = $3`
+	// The new items do not see the old ones being deleted, as both parts of the statement see the same snapshot.
+	setTaskChecklistQuery = `
+		WITH cleared AS (DELETE FROM task_checklist_items WHERE task_id = $1)
+		INSERT INTO task_checklist_items (task_id, position, text, done)
+		SELECT $1, position, text, done FROM unnest($2::text[], $3::boolean[]) WITH ORDINALITY AS items (text, done, position)
+	`
+	getTaskChecklistQuery     = `SELECT text, done FROM task_checklist_items WHERE task_id = $1 ORDER BY position`
+	createTaskCommentQuery    = `INSERT INTO task_comments (task_id, author, body) VALUES ($1, $2, $3) RETURNING id, created_at`
+	listTaskCommentsQuery     = `SELECT id, task_id, author, body, created_at FROM task_comments WHERE task_id = $1 ORDER BY id`
+	createTaskAttachmentQuery = `
+		INSERT INTO task_attachments (task_id, name, url, content_type, size) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	listTaskAttachmentsQuery = `
+		SELECT id, task_id, name, url, content_type, size, created_at FROM task_attachments
+		WHERE task_id = $1
+		ORDER BY id
+	`
+	// The parts of the content that are not selected copy no rows.
+	copyTaskContentQuery = `
+		WITH checklist AS (
+			INSERT INTO task_checklist_items (task_id, position, text, done)
+			SELECT $2, position, text, done FROM task_checklist_items WHERE task_id = $1 AND $3
+		), comments AS (
+			INSERT INTO task_comments (task_id, author, body, created_at)
+			SELECT $2, author, body, created_at FROM task_comments WHERE task_id = $1 AND $4 ORDER BY id
+		)
+		INSERT INTO task_attachments (task_id, name, url, content_type, size, created_at)
+		SELECT $2, name, url, content_type, size, created_at FROM task_attachments WHERE task_id = $1 AND $5 ORDER BY id
+	`
+)
+
 type PostgresTaskRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
@@ -20,30 +196,59 @@ func NewPostgresTaskRepository(db *sql.DB, logger *zap.Logger) *PostgresTaskRepo
 	return &PostgresTaskRepository{db: db, logger: logger}
 }
 
-func (r *PostgresTaskRepository) CreateTask(task *models.Task) (*models.Task, error) {
-	query := `
-		INSERT INTO tasks (title, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id;
-	`
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *PostgresTaskRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+// CreateTask allocates the task key and inserts the task in one transaction,
+// so a failed insert gives the key back and project keys stay gap-free.
+func (r *PostgresTaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	customFields, err := encodeCustomFields(task.CustomFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	now := time.Now()
-	task.CreatedAt = now
-	task.UpdatedAt = now
+	key, err := nextTaskKey(ctx, sqlQueryRow(tx), task.ProjectID)
+	if err != nil {
+		r.logger.Error("Failed to allocate task key", zap.Error(err))
+		return nil, err
+	}
 
-	err := r.db.QueryRowContext(context.Background(), query, task.Title, task.Description, task.CreatedAt, task.UpdatedAt).Scan(&task.ID)
+	err = tx.QueryRowContext(ctx, insertTaskQuery,
+		task.ExternalID, nullableID(task.ProjectID), key, task.Title, task.Description, task.Status, task.Assignee, stringArray(task.Labels),
+		task.Rank, customFields, nullableID(task.ParentID),
+	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
 	if err != nil {
 		r.logger.Error("Failed to create task", zap.Error(err))
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task creation", zap.Error(err))
+		return nil, err
+	}
+	task.Key = key.String
+
 	return task, nil
 }
 
-func (r *PostgresTaskRepository) ListTasks() ([]*models.Task, error) {
-	query := `SELECT id, title, description, created_at, updated_at FROM tasks`
+func (r *PostgresTaskRepository) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	query, args, err := listTasksQuery(filter)
+	if err != nil {
+		r.logger.Error("Invalid task filter", zap.Error(err))
+		return nil, err
+	}
 
-	rows, err := r.db.QueryContext(context.Background(), query)
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("Failed to list tasks", zap.Error(err))
 		return nil, err
@@ -52,22 +257,158 @@ func (r *PostgresTaskRepository) ListTasks() ([]*models.Task, error) {
 
 	var tasks []*models.Task
 	for rows.Next() {
-		var task models.Task
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.CreatedAt, &task.UpdatedAt); err != nil {
+		task, err := scanListedTask(rows, filter)
+		if err != nil {
 			r.logger.Error("Failed to scan task", zap.Error(err))
 			return nil, err
 		}
-		tasks = append(tasks, &task)
+		tasks = append(tasks, task)
 	}
 
 	return tasks, nil
 }
 
-func (r *PostgresTaskRepository) GetTask(id int64) (*models.Task, error) {
-	query := `SELECT id, title, description, created_at, updated_at FROM tasks WHERE id = $1`
+// listTasksQuery builds the query selecting the tasks matching filter in ListTasks order. Filters that
+// include archived tasks select archivedTaskColumns from the live and the archived tasks.
+func listTasksQuery(filter models.TaskFilter) (string, []any, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks`
+	if filter.IncludeArchived {
+		query = `SELECT ` + archivedTaskColumns + ` FROM ` + tasksWithArchive
+	}
+	var conditions []string
+	var args []any
+	if filter.ProjectID != 0 {
+		args = append(args, filter.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	for _, condition := range filter.CustomFields {
+		var sqlCondition string
+		var err error
+		sqlCondition, args, err = customFieldCondition(condition, args)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, sqlCondition)
+	}
+	if filter.Where != nil {
+		var sqlCondition string
+		var err error
+		sqlCondition, args, err = filterCondition(filter.Where, args)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, sqlCondition)
+	}
 
-	var task models.Task
-	err := r.db.QueryRowContext(context.Background(), query, id).Scan(&task.ID, &task.Title, &task.Description, &task.CreatedAt, &task.UpdatedAt)
+	// Tasks sort by the custom field, if any, with tasks lacking it last, then by rank and external ID.
+	// The page cursor continues right after the last task of the previous page in that order.
+	orderBy := `rank, external_id`
+	var sortExpr string
+	if filter.OrderBy != nil {
+		args = append(args, filter.OrderBy.Field)
+		sortExpr = customFieldExpr(filter.OrderBy.Type, len(args))
+		direction := "ASC"
+		if filter.OrderBy.Descending {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf("%s %s NULLS LAST, %s", sortExpr, direction, orderBy)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Rank, filter.After.ExternalID)
+		tieBreak := fmt.Sprintf("(rank, external_id) > ($%d, $%d)", len(args)-1, len(args))
+		switch {
+		case sortExpr == "":
+			conditions = append(conditions, tieBreak)
+		case filter.After.SortValue == nil:
+			conditions = append(conditions, fmt.Sprintf("(%s IS NULL AND %s)", sortExpr, tieBreak))
+		default:
+			args = append(args, filter.After.SortValue)
+			comparison := ">"
+			if filter.OrderBy.Descending {
+				comparison = "<"
+			}
+			conditions = append(conditions, fmt.Sprintf("(%s %s $%d OR %s IS NULL OR (%s = $%d AND %s))",
+				sortExpr, comparison, len(args), sortExpr, sortExpr, len(args), tieBreak))
+		}
+	}
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY ` + orderBy
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return query, args, nil
+}
+
+// ExportTasks reads the tasks matching filter through a server-side cursor, exportFetchSize rows at a time,
+// and passes them to each in ListTasks order. It stops at the first error each returns.
+func (r *PostgresTaskRepository) ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error {
+	query, args, err := listTasksQuery(filter)
+	if err != nil {
+		r.logger.Error("Invalid task filter", zap.Error(err))
+		return err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DECLARE task_export NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		r.logger.Error("Failed to open export cursor", zap.Error(err))
+		return err
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM task_export`, exportFetchSize)
+	for {
+		fetched, err := r.fetchTasks(ctx, tx, fetch, filter, each)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
+// fetchTasks runs one FETCH of an export cursor over the tasks matching filter, passing the tasks to each,
+// and returns how many it read.
+func (r *PostgresTaskRepository) fetchTasks(ctx context.Context, tx *sqlTx, fetch string, filter models.TaskFilter, each func(*models.Task) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		r.logger.Error("Failed to fetch tasks", zap.Error(err))
+		return 0, err
+	}
+	defer rows.Close()
+
+	var fetched int
+	for rows.Next() {
+		task, err := scanListedTask(rows, filter)
+		if err != nil {
+			r.logger.Error("Failed to scan task", zap.Error(err))
+			return 0, err
+		}
+		fetched++
+		if err := each(task); err != nil {
+			return 0, err
+		}
+	}
+	return fetched, rows.Err()
+}
+
+func (r *PostgresTaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	task, err := scanTask(r.conn(ctx).QueryRowContext(ctx, getTaskQuery, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, sql.ErrNoRows
@@ -76,21 +417,21 @@ func (r *PostgresTaskRepository) GetTask(id int64) (*models.Task, error) {
 		return nil, err
 	}
 
-	return &task, nil
+	return task, nil
 }
 
-func (r *PostgresTaskRepository) UpdateTask(task *models.Task) (*models.Task, error) {
-	query := `
-		UPDATE tasks
-		SET title = $1, description = $2, updated_at = $3
-		WHERE id = $4
-		RETURNING created_at;
-	`
-
-	task.UpdatedAt = time.Now()
+func (r *PostgresTaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	customFields, err := encodeCustomFields(task.CustomFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
 
-	err := r.db.QueryRowContext(context.Background(), query, task.Title, task.Description, task.UpdatedAt, task.ID).
-		Scan(&task.CreatedAt)
+	var projectID, parentID sql.NullInt64
+	var key sql.NullString
+	err = r.conn(ctx).QueryRowContext(ctx, updateTaskQuery,
+		task.Title, task.Description, task.Status, task.Assignee, stringArray(task.Labels), task.Rank, customFields, task.ID,
+	).Scan(&task.ExternalID, &projectID, &key, &task.CreatedAt, &task.UpdatedAt, &parentID)
 	if err != nil {
 		log.Println(err)
 		if errors.Is(err, sql.ErrNoRows) {
@@ -99,14 +440,15 @@ func (r *PostgresTaskRepository) UpdateTask(task *models.Task) (*models.Task, er
 		r.logger.Error("Failed to update task", zap.Error(err))
 		return nil, err
 	}
+	task.ProjectID = projectID.Int64
+	task.ParentID = parentID.Int64
+	task.Key = key.String
 
 	return task, nil
 }
 
-func (r *PostgresTaskRepository) DeleteTask(id int64) error {
-	query := `DELETE FROM tasks WHERE id = $1`
-
-	res, err := r.db.ExecContext(context.Background(), query, id)
+func (r *PostgresTaskRepository) DeleteTask(ctx context.Context, id int64) error {
+	res, err := r.conn(ctx).ExecContext(ctx, deleteTaskQuery, id)
 	if err != nil {
 		r.logger.Error("Failed to delete task", zap.Error(err))
 		return err
@@ -124,3 +466,863 @@ func (r *PostgresTaskRepository) DeleteTask(id int64) error {
 
 	return nil
 }
+
+// CreateTasks allocates the keys of all tasks and inserts them with multi-row inserts in one transaction.
+func (r *PostgresTaskRepository) CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	return r.createTasks(ctx, tasks, nil)
+}
+
+// CreateImportedTasks creates tasks like CreateTasks and links them to their source IDs. A source ID
+// imported meanwhile by a concurrent import fails the whole transaction with a unique violation.
+func (r *PostgresTaskRepository) CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error) {
+	if len(sourceIDs) != len(tasks) {
+		return nil, fmt.Errorf("got %d source IDs for %d tasks", len(sourceIDs), len(tasks))
+	}
+
+	return r.createTasks(ctx, tasks, func(tx *sqlTx) error {
+		taskIDs := make([]int64, len(tasks))
+		for i, task := range tasks {
+			taskIDs[i] = task.ID
+		}
+		_, err := tx.ExecContext(ctx, recordImportsQuery, source, pq.StringArray(sourceIDs), pq.Int64Array(taskIDs))
+		if err != nil {
+			r.logger.Error("Failed to record imported tasks", zap.Error(err))
+		}
+		return err
+	})
+}
+
+// ImportedSourceIDs looks up which of the source IDs were imported before.
+func (r *PostgresTaskRepository) ImportedSourceIDs(ctx context.Context, source string, sourceIDs []string) ([]string, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, importedSourceIDsQuery, source, pq.StringArray(sourceIDs))
+	if err != nil {
+		r.logger.Error("Failed to look up imported tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imported []string
+	for rows.Next() {
+		var sourceID string
+		if err := rows.Scan(&sourceID); err != nil {
+			r.logger.Error("Failed to scan imported task", zap.Error(err))
+			return nil, err
+		}
+		imported = append(imported, sourceID)
+	}
+	return imported, rows.Err()
+}
+
+// createTasks inserts tasks in one transaction, running afterInsert, if set, in the same transaction
+// once the tasks have their IDs.
+func (r *PostgresTaskRepository) createTasks(ctx context.Context, tasks []*models.Task, afterInsert func(tx *sqlTx) error) ([]*models.Task, error) {
+	customFields := make([][]byte, len(tasks))
+	for i, task := range tasks {
+		var err error
+		if customFields[i], err = encodeCustomFields(task.CustomFields); err != nil {
+			r.logger.Error("Failed to encode custom fields", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	keys, err := nextTaskKeys(ctx, sqlQueryRow(tx), tasks)
+	if err != nil {
+		r.logger.Error("Failed to allocate task keys", zap.Error(err))
+		return nil, err
+	}
+
+	for start := 0; start < len(tasks); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(tasks))
+		query, args := insertTasksQuery(tasks[start:end], keys[start:end], customFields[start:end])
+		if err := r.insertTaskRows(ctx, tx, query, args, tasks[start:end]); err != nil {
+			r.logger.Error("Failed to create tasks", zap.Error(err))
+			return nil, err
+		}
+	}
+	if afterInsert != nil {
+		if err := afterInsert(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task creation", zap.Error(err))
+		return nil, err
+	}
+	for i, task := range tasks {
+		task.Key = keys[i].String
+	}
+
+	return tasks, nil
+}
+
+// insertTaskRows runs a multi-row insert and assigns the returned IDs and timestamps to the tasks by
+// external ID, as Postgres does not promise to return the rows in the order of the VALUES list.
+func (r *PostgresTaskRepository) insertTaskRows(ctx context.Context, tx *sqlTx, query string, args []any, tasks []*models.Task) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]*models.Task, len(tasks))
+	for rows.Next() {
+		var row models.Task
+		if err := rows.Scan(&row.ID, &row.ExternalID, &row.CreatedAt, &row.UpdatedAt); err != nil {
+			return err
+		}
+		inserted[row.ExternalID] = &row
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return assignInsertedRows(tasks, inserted)
+}
+
+// UpdateTasks runs the update of UpdateTask for every task inside one transaction.
+func (r *PostgresTaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	customFields := make([][]byte, len(tasks))
+	for i, task := range tasks {
+		var err error
+		if customFields[i], err = encodeCustomFields(task.CustomFields); err != nil {
+			r.logger.Error("Failed to encode custom fields", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, updateTaskQuery)
+	if err != nil {
+		r.logger.Error("Failed to prepare task update", zap.Error(err))
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for i, task := range tasks {
+		var projectID, parentID sql.NullInt64
+		var key sql.NullString
+		err := stmt.QueryRowContext(ctx,
+			task.Title, task.Description, task.Status, task.Assignee, stringArray(task.Labels), task.Rank, customFields[i], task.ID,
+		).Scan(&task.ExternalID, &projectID, &key, &task.CreatedAt, &task.UpdatedAt, &parentID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, sql.ErrNoRows
+			}
+			r.logger.Error("Failed to update task", zap.Int64("id", task.ID), zap.Error(err))
+			return nil, err
+		}
+		task.ProjectID = projectID.Int64
+		task.ParentID = parentID.Int64
+		task.Key = key.String
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task updates", zap.Error(err))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *PostgresTaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, deleteTasksQuery, pq.Int64Array(ids))
+	if err != nil {
+		r.logger.Error("Failed to delete tasks", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+	if rowsAffected != int64(len(ids)) {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task deletion", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresTaskRepository) GetTaskIDByKey(ctx context.Context, key string) (int64, error) {
+	var id int64
+	err := r.conn(ctx).QueryRowContext(ctx, taskIDByKeyQuery, key).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to resolve task key", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *PostgresTaskRepository) GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error) {
+	var id int64
+	err := r.conn(ctx).QueryRowContext(ctx, taskIDByExternalIDQuery, externalID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to resolve task external ID", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *PostgresTaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	encodedFields, err := encodeCustomFields(customFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldKey sql.NullString
+	err = tx.QueryRowContext(ctx, lockTaskKeyQuery, id).Scan(&oldKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to lock task for transfer", zap.Error(err))
+		return nil, err
+	}
+
+	if oldKey.Valid {
+		_, err = tx.ExecContext(ctx, keepTaskKeyQuery, oldKey.String, id)
+		if err != nil {
+			r.logger.Error("Failed to keep old task key", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	newKey, err := nextTaskKey(ctx, sqlQueryRow(tx), projectID)
+	if err != nil {
+		r.logger.Error("Failed to allocate task key", zap.Error(err))
+		return nil, err
+	}
+
+	task, err := scanTask(tx.QueryRowContext(ctx, transferTaskQuery,
+		nullableID(projectID), newKey, status, rank, encodedFields, id))
+	if err != nil {
+		r.logger.Error("Failed to transfer task", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task transfer", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PostgresTaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	task, err := scanTask(r.conn(ctx).QueryRowContext(ctx, setTaskParentQuery, nullableID(parentID), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to set task parent", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PostgresTaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, detachSubtasksQuery, pq.Int64Array(parentIDs))
+	if err != nil {
+		r.logger.Error("Failed to detach subtasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *PostgresTaskRepository) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	taskIDs, relatedTaskIDs, types := relationArrays(relations)
+	_, err := r.conn(ctx).ExecContext(ctx, createTaskRelationsQuery, pq.Int64Array(taskIDs), pq.Int64Array(relatedTaskIDs), pq.StringArray(types))
+	if err != nil {
+		r.logger.Error("Failed to create task relations", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, listTaskRelationsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task relations", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []models.TaskRelation
+	for rows.Next() {
+		var relation models.TaskRelation
+		if err := rows.Scan(&relation.TaskID, &relation.RelatedTaskID, &relation.Type); err != nil {
+			r.logger.Error("Failed to scan task relation", zap.Error(err))
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+func (r *PostgresTaskRepository) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	res, err := r.conn(ctx).ExecContext(ctx, deleteTaskRelationQuery, relation.TaskID, relation.RelatedTaskID, relation.Type)
+	if err != nil {
+		r.logger.Error("Failed to delete task relation", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresTaskRepository) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error {
+	texts, done := checklistArrays(items)
+	if _, err := r.conn(ctx).ExecContext(ctx, setTaskChecklistQuery, id, stringArray(texts), pq.BoolArray(done)); err != nil {
+		r.logger.Error("Failed to set task checklist", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, getTaskChecklistQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to get task checklist", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		var item models.ChecklistItem
+		if err := rows.Scan(&item.Text, &item.Done); err != nil {
+			r.logger.Error("Failed to scan checklist item", zap.Error(err))
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *PostgresTaskRepository) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	err := r.conn(ctx).QueryRowContext(ctx, createTaskCommentQuery, comment.TaskID, comment.Author, comment.Body).Scan(&comment.ID, &comment.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create task comment", zap.Error(err))
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *PostgresTaskRepository) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, listTaskCommentsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task comments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.TaskComment
+	for rows.Next() {
+		var comment models.TaskComment
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan task comment", zap.Error(err))
+			return nil, err
+		}
+		comments = append(comments, &comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *PostgresTaskRepository) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	err := r.conn(ctx).QueryRowContext(ctx, createTaskAttachmentQuery, attachment.TaskID, attachment.Name, attachment.URL, attachment.ContentType,
+		attachment.Size).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create task attachment", zap.Error(err))
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (r *PostgresTaskRepository) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, listTaskAttachmentsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task attachments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.TaskAttachment
+	for rows.Next() {
+		var attachment models.TaskAttachment
+		if err := rows.Scan(&attachment.ID, &attachment.TaskID, &attachment.Name, &attachment.URL, &attachment.ContentType, &attachment.Size,
+			&attachment.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan task attachment", zap.Error(err))
+			return nil, err
+		}
+		attachments = append(attachments, &attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *PostgresTaskRepository) CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error {
+	_, err := r.conn(ctx).ExecContext(ctx, copyTaskContentQuery, fromID, toID, content.Checklist, content.Comments, content.Attachments)
+	if err != nil {
+		r.logger.Error("Failed to copy task content", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	task, err := scanTask(r.conn(ctx).QueryRowContext(ctx, moveTaskQuery, status, rank, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to move task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *PostgresTaskRepository) RankBefore(ctx context.Context, column models.Column, rank string) (string, error) {
+	var before string
+	err := r.conn(ctx).QueryRowContext(ctx, rankBeforeQuery, nullableID(column.ProjectID), column.Status, rank).Scan(&before)
+	if err != nil {
+		r.logger.Error("Failed to fetch preceding rank", zap.Error(err))
+		return "", err
+	}
+
+	return before, nil
+}
+
+func (r *PostgresTaskRepository) RankAfter(ctx context.Context, column models.Column, rank string) (string, error) {
+	var after string
+	err := r.conn(ctx).QueryRowContext(ctx, rankAfterQuery, nullableID(column.ProjectID), column.Status, rank).Scan(&after)
+	if err != nil {
+		r.logger.Error("Failed to fetch following rank", zap.Error(err))
+		return "", err
+	}
+
+	return after, nil
+}
+
+func (r *PostgresTaskRepository) ColumnsWithLongRanks(ctx context.Context, maxLength int) ([]models.Column, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, columnsWithLongRanksQuery, maxLength)
+	if err != nil {
+		r.logger.Error("Failed to list columns with long ranks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.Column
+	for rows.Next() {
+		var column models.Column
+		var projectID sql.NullInt64
+		if err := rows.Scan(&projectID, &column.Status); err != nil {
+			r.logger.Error("Failed to scan column", zap.Error(err))
+			return nil, err
+		}
+		column.ProjectID = projectID.Int64
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+func (r *PostgresTaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin rebalance transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, lockColumnQuery, nullableID(column.ProjectID), column.Status)
+	if err != nil {
+		r.logger.Error("Failed to lock column", zap.Error(err))
+		return err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to read column", zap.Error(err))
+		return err
+	}
+
+	newRanks := ranks(len(ids))
+	if len(newRanks) != len(ids) {
+		return fmt.Errorf("rank generator returned %d keys for %d tasks", len(newRanks), len(ids))
+	}
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, setRankQuery, newRanks[i], id); err != nil {
+			r.logger.Error("Failed to rewrite rank", zap.Int64("id", id), zap.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows as well as by pgx.Row and pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// ArchiveTasks moves the tasks with their key aliases and import records in one transaction, creating the
+// archive partitions of the months they were created in first. Tasks locked by concurrent writes are
+// skipped and left for a later call.
+func (r *PostgresTaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, archiveCandidatesQuery, nullableID(column.ProjectID), column.Status, updatedBefore, limit)
+	if err != nil {
+		r.logger.Error("Failed to select tasks to archive", zap.Error(err))
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to select tasks to archive", zap.Error(err))
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, archivePartitionsQuery, pq.Int64Array(ids)); err != nil {
+		r.logger.Error("Failed to create archive partitions", zap.Error(err))
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, archiveTaskRecordsQuery, pq.Int64Array(ids)); err != nil {
+		r.logger.Error("Failed to archive task records", zap.Error(err))
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, archiveTasksQuery, pq.Int64Array(ids)); err != nil {
+		r.logger.Error("Failed to archive tasks", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task archiving", zap.Error(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// CreateTaskPartitions creates the missing partitions of tasks for the months from the current one through
+// that of until, see create_task_partition in the migrations.
+func (r *PostgresTaskRepository) CreateTaskPartitions(ctx context.Context, until time.Time) error {
+	if _, err := r.conn(ctx).ExecContext(ctx, createTaskPartitionsQuery, until); err != nil {
+		r.logger.Error("Failed to create task partitions", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	task, err := scanArchivedTask(r.conn(ctx).QueryRowContext(ctx, getArchivedTaskQuery, archivedTaskRef(ref)...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch archived task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// queryRowFunc runs a query expected to return at most one row, letting helpers such as nextTaskKeys
+// run inside the transactions of either driver.
+type queryRowFunc func(ctx context.Context, query string, args ...any) rowScanner
+
+// sqlQueryRow runs queries in a database/sql transaction.
+func sqlQueryRow(tx sqlConn) queryRowFunc {
+	return func(ctx context.Context, query string, args ...any) rowScanner {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+}
+
+// scanTask reads a row selecting taskColumns, followed by any extra columns scanned into extra.
+func scanTask(row rowScanner, extra ...any) (*models.Task, error) {
+	var task models.Task
+	var projectID, parentID sql.NullInt64
+	var key sql.NullString
+	var labels pq.StringArray
+	var customFields []byte
+	dest := []any{&task.ID, &task.ExternalID, &projectID, &key, &task.Title, &task.Description, &task.Status, &task.Assignee, &labels,
+		&task.Rank, &customFields, &task.CreatedAt, &task.UpdatedAt, &parentID}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if task.CustomFields, err = decodeCustomFields(customFields); err != nil {
+		return nil, err
+	}
+	task.ProjectID = projectID.Int64
+	task.ParentID = parentID.Int64
+	task.Key = key.String
+	task.Labels = labels
+	return &task, nil
+}
+
+// scanListedTask reads a row of listTasksQuery for filter.
+func scanListedTask(row rowScanner, filter models.TaskFilter) (*models.Task, error) {
+	if filter.IncludeArchived {
+		return scanArchivedTask(row)
+	}
+	return scanTask(row)
+}
+
+// scanArchivedTask reads a row selecting archivedTaskColumns.
+func scanArchivedTask(row rowScanner) (*models.Task, error) {
+	var archivedAt sql.NullTime
+	task, err := scanTask(row, &archivedAt)
+	if err != nil {
+		return nil, err
+	}
+	task.ArchivedAt = archivedAt.Time
+	return task, nil
+}
+
+// archivedTaskRef returns the arguments of getArchivedTaskQuery for ref, keeping only the part of ref
+// that identifies the task.
+func archivedTaskRef(ref models.TaskRef) []any {
+	switch {
+	case ref.ExternalID != "":
+		return []any{ref.ExternalID, "", 0}
+	case ref.Key != "":
+		return []any{"", ref.Key, 0}
+	}
+	return []any{"", "", ref.ID}
+}
+
+// nextTaskKey takes the next task number of a project inside tx. The project row stays locked
+// until tx ends, so concurrent creations in the same project get consecutive numbers, and a
+// rolled back transaction returns its number. Tasks outside of any project get a NULL key.
+func nextTaskKey(ctx context.Context, queryRow queryRowFunc, projectID int64) (sql.NullString, error) {
+	if projectID == 0 {
+		return sql.NullString{}, nil
+	}
+
+	query := `
+		UPDATE projects
+		SET task_counter = task_counter + 1
+		WHERE id = $1
+		RETURNING key || '-' || task_counter;
+	`
+
+	var key string
+	if err := queryRow(ctx, query, projectID).Scan(&key); err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: key, Valid: true}, nil
+}
+
+// nextTaskKeys takes the next task numbers for a batch of tasks inside tx, one counter update per
+// project. Projects are locked in ID order so that concurrent batches cannot deadlock, and the tasks
+// of a project get consecutive numbers in the order of the batch.
+func nextTaskKeys(ctx context.Context, queryRow queryRowFunc, tasks []*models.Task) ([]sql.NullString, error) {
+	counts := make(map[int64]int64)
+	for _, task := range tasks {
+		if task.ProjectID != 0 {
+			counts[task.ProjectID]++
+		}
+	}
+	projectIDs := make([]int64, 0, len(counts))
+	for projectID := range counts {
+		projectIDs = append(projectIDs, projectID)
+	}
+	slices.Sort(projectIDs)
+
+	query := `
+		UPDATE projects
+		SET task_counter = task_counter + $2
+		WHERE id = $1
+		RETURNING key, task_counter;
+	`
+
+	prefixes := make(map[int64]string, len(projectIDs))
+	next := make(map[int64]int64, len(projectIDs))
+	for _, projectID := range projectIDs {
+		var prefix string
+		var counter int64
+		if err := queryRow(ctx, query, projectID, counts[projectID]).Scan(&prefix, &counter); err != nil {
+			return nil, err
+		}
+		prefixes[projectID] = prefix
+		next[projectID] = counter - counts[projectID] + 1
+	}
+
+	keys := make([]sql.NullString, len(tasks))
+	for i, task := range tasks {
+		if task.ProjectID == 0 {
+			continue
+		}
+		keys[i] = sql.NullString{String: fmt.Sprintf("%s-%d", prefixes[task.ProjectID], next[task.ProjectID]), Valid: true}
+		next[task.ProjectID]++
+	}
+	return keys, nil
+}
+
+// insertTasksQuery builds the multi-row insert of tasks with their keys and encoded custom fields,
+// returning the ID, external ID and timestamps of every inserted row.
+func insertTasksQuery(tasks []*models.Task, keys []sql.NullString, customFields [][]byte) (string, []any) {
+	values := make([]string, 0, len(tasks))
+	args := make([]any, 0, 11*len(tasks))
+	for i, task := range tasks {
+		values = append(values, placeholders(len(args), 11, "now()", "now()"))
+		args = append(args, task.ExternalID, nullableID(task.ProjectID), keys[i], task.Title, task.Description, task.Status,
+			task.Assignee, stringArray(task.Labels), task.Rank, customFields[i], nullableID(task.ParentID))
+	}
+
+	query := `
+		INSERT INTO tasks (external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, parent_id,
+			created_at, updated_at)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id, external_id, created_at, updated_at;
+	`
+	return query, args
+}
+
+// relationArrays splits relations into the arrays createTaskRelationsQuery unnests.
+func relationArrays(relations []models.TaskRelation) ([]int64, []int64, []string) {
+	taskIDs := make([]int64, len(relations))
+	relatedTaskIDs := make([]int64, len(relations))
+	types := make([]string, len(relations))
+	for i, relation := range relations {
+		taskIDs[i], relatedTaskIDs[i], types[i] = relation.TaskID, relation.RelatedTaskID, relation.Type
+	}
+	return taskIDs, relatedTaskIDs, types
+}
+
+// checklistArrays splits a checklist into the arrays setTaskChecklistQuery unnests.
+func checklistArrays(items []models.ChecklistItem) ([]string, []bool) {
+	texts := make([]string, len(items))
+	done := make([]bool, len(items))
+	for i, item := range items {
+		texts[i], done[i] = item.Text, item.Done
+	}
+	return texts, done
+}
+
+// assignInsertedRows copies the IDs and timestamps of the inserted rows, keyed by external ID, to tasks.
+func assignInsertedRows(tasks []*models.Task, inserted map[string]*models.Task) error {
+	for _, task := range tasks {
+		row, ok := inserted[task.ExternalID]
+		if !ok {
+			return fmt.Errorf("no ID returned for task %s", task.ExternalID)
+		}
+		task.ID, task.CreatedAt, task.UpdatedAt = row.ID, row.CreatedAt, row.UpdatedAt
+	}
+	return nil
+}
+
+// placeholders returns the parenthesised parameters of one row of a multi-row insert, numbered after
+// the first offset parameters and followed by the given SQL expressions.
+func placeholders(offset, count int, expressions ...string) string {
+	params := make([]string, count, count+len(expressions))
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", offset+i+1)
+	}
+	params = append(params, expressions...)
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// stringArray encodes values as a Postgres text array, storing nil slices as empty arrays
+// so that NOT NULL array columns can be written directly from the domain models.
+func stringArray(values []string) pq.StringArray {
+	if values == nil {
+		return pq.StringArray{}
+	}
+	return values
+}
+
+// nullableID maps the zero ID used by the domain models to a NULL foreign key.
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -1,16 +1,22 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
+var taskRowColumns = []string{"id", "external_id", "project_id", "key", "title", "description", "status", "assignee", "labels", "rank", "custom_fields", "created_at", "updated_at", "parent_id"}
+
 func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *zap.Logger) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -27,20 +33,67 @@ func TestPostgresTaskRepository_CreateTask(t *testing.T) {
 
 	repo := NewPostgresTaskRepository(db, logger)
 	task := &models.Task{
+		ExternalID:  "01KDVDNA000000000000000001",
 		Title:       "Test Task",
 		Description: "This is a test task",
 	}
 
-	now := time.Now()
-	mock.ExpectQuery("INSERT INTO tasks").
-		WithArgs(task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	createdAt := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO tasks (.+) VALUES (.+), now\\(\\), now\\(\\)\\) RETURNING id, created_at, updated_at").
+		WithArgs(task.ExternalID, nil, nil, task.Title, task.Description, "", "", sqlmock.AnyArg(), "", []byte("{}"), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, createdAt, createdAt))
+	mock.ExpectCommit()
 
-	createdTask, err := repo.CreateTask(task)
+	createdTask, err := repo.CreateTask(context.Background(), task)
 	assert.NoError(t, err)
 	assert.NotNil(t, createdTask)
 	assert.Equal(t, int64(1), createdTask.ID)
-	assert.WithinDuration(t, now, createdTask.CreatedAt, time.Second)
+	assert.Equal(t, createdAt, createdTask.CreatedAt, "the database sets the timestamps")
+	assert.Equal(t, createdAt, createdTask.UpdatedAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_CreateTask_AllocatesKey(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	task := &models.Task{ProjectID: 3, Title: "Test Task"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE projects SET task_counter = task_counter \\+ 1").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("OPS-7"))
+	mock.ExpectQuery("INSERT INTO tasks").
+		WithArgs(task.ExternalID, 3, "OPS-7", task.Title, "", "", "", sqlmock.AnyArg(), "", []byte("{}"), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, time.Now(), time.Now()))
+	mock.ExpectCommit()
+
+	createdTask, err := repo.CreateTask(context.Background(), task)
+	assert.NoError(t, err)
+	assert.Equal(t, "OPS-7", createdTask.Key)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_CreateTask_RollsBackKey(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	task := &models.Task{ProjectID: 3, Title: "Test Task"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE projects SET task_counter").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("OPS-7"))
+	mock.ExpectQuery("INSERT INTO tasks").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	_, err := repo.CreateTask(context.Background(), task)
+	assert.Error(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -51,14 +104,249 @@ func TestPostgresTaskRepository_ListTasks(t *testing.T) {
 
 	repo := NewPostgresTaskRepository(db, logger)
 
-	mock.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM tasks").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
-			AddRow(1, "Test Task", "This is a test task", time.Now(), time.Now()).
-			AddRow(2, "Another Task", "This is another test task", time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, created_at, updated_at, parent_id FROM tasks ORDER BY rank, external_id").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(1, "01KDVDNA000000000000000001", nil, nil, "Test Task", "This is a test task", "open", "", "{}", "i", "{}", time.Now(), time.Now(), nil).
+			AddRow(2, "01KDVDNA000000000000000002", 3, "OPS-2", "Another Task", "This is another test task", "done", "alice", "{bug,urgent}", "i", "{}", time.Now(), time.Now(), nil))
 
-	tasks, err := repo.ListTasks()
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{})
 	assert.NoError(t, err)
 	assert.Len(t, tasks, 2)
+	assert.Equal(t, int64(0), tasks[0].ProjectID)
+	assert.Equal(t, int64(3), tasks[1].ProjectID)
+	assert.Equal(t, []string{"bug", "urgent"}, tasks[1].Labels)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ListTasks_ByProject(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE project_id = \\$1 AND status = \\$2 ORDER BY rank, external_id").
+		WithArgs(3, "done").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(2, "01KDVDNA000000000000000002", 3, "OPS-2", "Another Task", "This is another test task", "done", "alice", "{}", "i", "{}", time.Now(), time.Now(), 1))
+
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{ProjectID: 3, Status: "done"})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ListTasks_Cancelled(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(1, "01KDVDNA000000000000000001", nil, nil, "Test Task", "", "open", "", "{}", "i", "{}", time.Now(), time.Now(), nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	tasks, err := repo.ListTasks(ctx, models.TaskFilter{})
+	assert.Error(t, err)
+	assert.Nil(t, tasks)
+	assert.Less(t, time.Since(start), time.Second, "the query should be aborted when the deadline passes")
+}
+
+func TestPostgresTaskRepository_ExportTasks(t *testing.T) {
+	t.Run("Reads the cursor until it runs dry", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresTaskRepository(db, logger)
+
+		full := sqlmock.NewRows(taskRowColumns)
+		for i := 1; i <= exportFetchSize; i++ {
+			full.AddRow(i, "01KDVDNA000000000000000001", 3, fmt.Sprintf("OPS-%d", i), "Task", "", "open", "", "{}", "i", "{}", time.Now(), time.Now(), nil)
+		}
+		mock.ExpectBegin()
+		mock.ExpectExec("DECLARE task_export NO SCROLL CURSOR FOR SELECT (.+) FROM tasks WHERE project_id = \\$1 ORDER BY rank, external_id").
+			WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM task_export").WillReturnRows(full)
+		mock.ExpectQuery("FETCH FORWARD 500 FROM task_export").
+			WillReturnRows(sqlmock.NewRows(taskRowColumns).
+				AddRow(501, "01KDVDNA000000000000000002", 3, "OPS-501", "Last", "", "open", "", "{}", "j", "{}", time.Now(), time.Now(), nil))
+		mock.ExpectCommit()
+
+		var exported int
+		err := repo.ExportTasks(context.Background(), models.TaskFilter{ProjectID: 3}, func(task *models.Task) error {
+			exported++
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, exportFetchSize+1, exported)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stops at the first error of the consumer", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresTaskRepository(db, logger)
+		stop := errors.New("client went away")
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DECLARE task_export").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM task_export").
+			WillReturnRows(sqlmock.NewRows(taskRowColumns).
+				AddRow(1, "01KDVDNA000000000000000001", nil, nil, "First", "", "open", "", "{}", "i", "{}", time.Now(), time.Now(), nil).
+				AddRow(2, "01KDVDNA000000000000000002", nil, nil, "Second", "", "open", "", "{}", "j", "{}", time.Now(), time.Now(), nil))
+		mock.ExpectRollback()
+
+		var exported int
+		err := repo.ExportTasks(context.Background(), models.TaskFilter{}, func(task *models.Task) error {
+			exported++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, exported)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Aborts the running fetch when the client goes away", func(t *testing.T) {
+		db, mock, logger := setupMockDB(t)
+		defer db.Close()
+
+		repo := NewPostgresTaskRepository(db, logger)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DECLARE task_export").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM task_export").
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows(taskRowColumns).
+				AddRow(1, "01KDVDNA000000000000000001", nil, nil, "First", "", "open", "", "{}", "i", "{}", time.Now(), time.Now(), nil))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		var exported int
+		start := time.Now()
+		err := repo.ExportTasks(ctx, models.TaskFilter{}, func(task *models.Task) error {
+			exported++
+			return nil
+		})
+		assert.Error(t, err)
+		assert.Zero(t, exported)
+		assert.Less(t, time.Since(start), time.Second, "the fetch should be aborted when the context is cancelled")
+	})
+}
+
+func TestPostgresTaskRepository_ListTasks_Filter(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	where := &models.FilterExpr{Logic: models.FilterAnd, Operands: []*models.FilterExpr{
+		{Field: "status", Type: models.FilterTypeString, Operator: "=", Value: "open"},
+		{Field: "created_at", Type: models.FilterTypeTimestamp, Operator: ">", Value: since},
+		{Field: "title", Type: models.FilterTypeString, Operator: ":", Value: "100%_done"},
+		{Logic: models.FilterNot, Operands: []*models.FilterExpr{
+			{Logic: models.FilterOr, Operands: []*models.FilterExpr{
+				{Field: "labels", Type: models.FilterTypeList, Operator: ":", Value: "bug"},
+				{Field: "key", Type: models.FilterTypeString, Operator: "!=", Value: "OPS-1"},
+			}},
+		}},
+		{Field: "points", Custom: true, Type: models.FieldTypeNumber, Operator: ":"},
+	}}
+
+	mock.ExpectQuery(`SELECT (.+) FROM tasks WHERE project_id = \$1 AND \(status = \$2 AND created_at > \$3 AND title ILIKE \$4 ESCAPE '\\' AND `+
+		`NOT COALESCE\(\(\$5 = ANY\(labels\) OR key IS DISTINCT FROM \$6\), false\) AND custom_fields \? \$7::text\) ORDER BY rank, external_id`).
+		WithArgs(3, "open", since, `%100\%\_done%`, "bug", "OPS-1", "points").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns))
+
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{ProjectID: 3, Where: where})
+	assert.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ListTasks_Page(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE project_id = \\$1 AND \\(rank, external_id\\) > \\(\\$2, \\$3\\) ORDER BY rank, external_id LIMIT \\$4").
+		WithArgs(3, "i", "01KDVDNA000000000000000001", 2).
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(2, "01KDVDNA000000000000000002", 3, "OPS-2", "Another Task", "This is another test task", "done", "alice", "{}", "k", "{}", time.Now(), time.Now(), nil))
+
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{
+		ProjectID: 3,
+		Limit:     2,
+		After:     &models.TaskCursor{Rank: "i", ExternalID: "01KDVDNA000000000000000001"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ListTasks_CustomFields(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE project_id = \\$1 "+
+		"AND custom_fields @> jsonb_build_object\\(\\$2::text, \\$3::text\\) "+
+		"AND \\(CASE WHEN (.+) END\\) >= \\$5 "+
+		"ORDER BY \\(CASE WHEN (.+) END\\) DESC NULLS LAST, rank, external_id").
+		WithArgs(3, "env", "prod", "points", 3.0, "points").
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(2, "01KDVDNA000000000000000002", 3, "OPS-2", "Another Task", "", "open", "", "{}", "i", `{"env": "prod", "points": 5}`, time.Now(), time.Now(), nil))
+
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{
+		ProjectID: 3,
+		CustomFields: []models.FieldCondition{
+			{Field: "env", Type: models.FieldTypeEnum, Operator: "=", Value: models.FieldValue{Type: models.FieldTypeEnum, String: "prod"}},
+			{Field: "points", Type: models.FieldTypeNumber, Operator: ">=", Value: models.FieldValue{Type: models.FieldTypeNumber, Number: 3}},
+		},
+		OrderBy: &models.FieldOrder{Field: "points", Type: models.FieldTypeNumber, Descending: true},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, models.FieldValue{Type: models.FieldTypeString, String: "prod"}, tasks[0].CustomFields["env"])
+	assert.Equal(t, models.FieldValue{Type: models.FieldTypeNumber, Number: 5}, tasks[0].CustomFields["points"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ListTasks_CustomFieldPage(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE project_id = \\$1 "+
+		"AND \\(\\(custom_fields ->> \\$2::text\\) COLLATE \"C\" > \\$5 OR (.+) IS NULL OR \\((.+) = \\$5 AND \\(rank, external_id\\) > \\(\\$3, \\$4\\)\\)\\) "+
+		"ORDER BY (.+) ASC NULLS LAST, rank, external_id LIMIT \\$6").
+		WithArgs(3, "due", "i", "01KDVDNA000000000000000001", "2026-05-01", 2).
+		WillReturnRows(sqlmock.NewRows(taskRowColumns))
+
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{
+		ProjectID: 3,
+		OrderBy:   &models.FieldOrder{Field: "due", Type: models.FieldTypeDate},
+		Limit:     2,
+		After:     &models.TaskCursor{SortValue: "2026-05-01", Rank: "i", ExternalID: "01KDVDNA000000000000000001"},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, tasks)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -69,12 +357,12 @@ func TestPostgresTaskRepository_GetTask(t *testing.T) {
 
 	repo := NewPostgresTaskRepository(db, logger)
 
-	mock.ExpectQuery("SELECT id, title, description, created_at, updated_at FROM tasks WHERE id =").
+	mock.ExpectQuery("SELECT (.+) FROM tasks WHERE id =").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at", "updated_at"}).
-			AddRow(1, "Test Task", "This is a test task", time.Now(), time.Now()))
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(1, "01KDVDNA000000000000000001", nil, nil, "Test Task", "This is a test task", "open", "", "{}", "i", "{}", time.Now(), time.Now(), nil))
 
-	task, err := repo.GetTask(1)
+	task, err := repo.GetTask(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, task)
 	assert.Equal(t, int64(1), task.ID)
@@ -93,14 +381,17 @@ func TestPostgresTaskRepository_UpdateTask(t *testing.T) {
 		Description: "Updated Description",
 	}
 
-	mock.ExpectQuery("UPDATE tasks SET").
-		WithArgs(task.Title, task.Description, sqlmock.AnyArg(), task.ID).
-		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	updatedAt := time.Date(2026, 5, 2, 14, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE tasks SET (.+) updated_at = now\\(\\) WHERE id = \\$8").
+		WithArgs(task.Title, task.Description, task.Status, task.Assignee, sqlmock.AnyArg(), task.Rank, []byte("{}"), task.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"external_id", "project_id", "key", "created_at", "updated_at", "parent_id"}).
+			AddRow("01KDVDNA000000000000000001", nil, nil, updatedAt.Add(-time.Hour), updatedAt, nil))
 
-	updatedTask, err := repo.UpdateTask(task)
+	updatedTask, err := repo.UpdateTask(context.Background(), task)
 	assert.NoError(t, err)
 	assert.NotNil(t, updatedTask)
 	assert.Equal(t, task.ID, updatedTask.ID)
+	assert.Equal(t, updatedAt, updatedTask.UpdatedAt)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -115,8 +406,478 @@ func TestPostgresTaskRepository_DeleteTask(t *testing.T) {
 		WithArgs(1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err := repo.DeleteTask(1)
+	err := repo.DeleteTask(context.Background(), 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_CreateTasks(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	tasks := []*models.Task{
+		{ExternalID: "01KDVDNA000000000000000001", ProjectID: 5, Title: "First"},
+		{ExternalID: "01KDVDNA000000000000000002", Title: "Loose"},
+		{ExternalID: "01KDVDNA000000000000000003", ProjectID: 3, ParentID: 9, Title: "Second"},
+		{ExternalID: "01KDVDNA000000000000000004", ProjectID: 5, Title: "Third"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE projects SET task_counter = task_counter \\+ \\$2").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "task_counter"}).AddRow("WEB", 1))
+	mock.ExpectQuery("UPDATE projects SET task_counter = task_counter \\+ \\$2").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "task_counter"}).AddRow("OPS", 8))
+	createdAt := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO tasks .* VALUES \\(\\$1, .*\\$11, now\\(\\), now\\(\\)\\), \\(\\$12, .*\\$44, now\\(\\), now\\(\\)\\) "+
+		"RETURNING id, external_id, created_at, updated_at").
+		WithArgs(
+			tasks[0].ExternalID, 5, "OPS-7", "First", "", "", "", sqlmock.AnyArg(), "", []byte("{}"), nil,
+			tasks[1].ExternalID, nil, nil, "Loose", "", "", "", sqlmock.AnyArg(), "", []byte("{}"), nil,
+			tasks[2].ExternalID, 3, "WEB-1", "Second", "", "", "", sqlmock.AnyArg(), "", []byte("{}"), 9,
+			tasks[3].ExternalID, 5, "OPS-8", "Third", "", "", "", sqlmock.AnyArg(), "", []byte("{}"), nil,
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "external_id", "created_at", "updated_at"}).
+			AddRow(12, tasks[1].ExternalID, createdAt, createdAt).AddRow(11, tasks[0].ExternalID, createdAt, createdAt).
+			AddRow(14, tasks[3].ExternalID, createdAt, createdAt).AddRow(13, tasks[2].ExternalID, createdAt, createdAt))
+	mock.ExpectCommit()
+
+	createdTasks, err := repo.CreateTasks(context.Background(), tasks)
 	assert.NoError(t, err)
+	assert.Equal(t, []int64{11, 12, 13, 14}, []int64{createdTasks[0].ID, createdTasks[1].ID, createdTasks[2].ID, createdTasks[3].ID})
+	assert.Equal(t, []string{"OPS-7", "", "WEB-1", "OPS-8"}, []string{createdTasks[0].Key, createdTasks[1].Key, createdTasks[2].Key, createdTasks[3].Key})
+	assert.Equal(t, createdAt, createdTasks[3].CreatedAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_CreateImportedTasks(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	tasks := []*models.Task{
+		{ExternalID: "01KDVDNA000000000000000001", Title: "First"},
+		{ExternalID: "01KDVDNA000000000000000002", Title: "Second"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO tasks .* RETURNING id, external_id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "external_id", "created_at", "updated_at"}).
+			AddRow(21, tasks[0].ExternalID, time.Now(), time.Now()).AddRow(22, tasks[1].ExternalID, time.Now(), time.Now()))
+	mock.ExpectExec("INSERT INTO task_imports \\(source, source_id, task_id\\)").
+		WithArgs("jira", pq.StringArray{"PROJ-1", "PROJ-2"}, pq.Int64Array{21, 22}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	createdTasks, err := repo.CreateImportedTasks(context.Background(), "jira", tasks, []string{"PROJ-1", "PROJ-2"})
+	assert.NoError(t, err)
+	assert.Len(t, createdTasks, 2)
+
+	_, err = repo.CreateImportedTasks(context.Background(), "jira", tasks, []string{"PROJ-1"})
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ImportedSourceIDs(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT source_id FROM task_imports WHERE source = \\$1 AND source_id = ANY\\(\\$2\\)").
+		WithArgs("github", pq.StringArray{"1", "2", "3"}).
+		WillReturnRows(sqlmock.NewRows([]string{"source_id"}).AddRow("1").AddRow("3"))
+
+	imported, err := repo.ImportedSourceIDs(context.Background(), "github", []string{"1", "2", "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, imported)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_UpdateTasks(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	tasks := []*models.Task{{ID: 1, Title: "First"}, {ID: 2, Title: "Second"}}
+
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare("UPDATE tasks SET")
+	prepared.ExpectQuery().
+		WithArgs("First", "", "", "", sqlmock.AnyArg(), "", []byte("{}"), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"external_id", "project_id", "key", "created_at", "updated_at", "parent_id"}).
+			AddRow("01KDVDNA000000000000000001", 3, "OPS-1", time.Now(), time.Now(), nil))
+	prepared.ExpectQuery().
+		WithArgs("Second", "", "", "", sqlmock.AnyArg(), "", []byte("{}"), 2).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := repo.UpdateTasks(context.Background(), tasks)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_DeleteTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		wantErr  error
+	}{
+		{name: "All tasks deleted", affected: 2},
+		{name: "Missing task rolls back", affected: 1, wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, logger := setupMockDB(t)
+			defer db.Close()
+
+			repo := NewPostgresTaskRepository(db, logger)
+
+			mock.ExpectBegin()
+			mock.ExpectExec("DELETE FROM tasks WHERE id = ANY").
+				WithArgs(sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			if tt.wantErr == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := repo.DeleteTasks(context.Background(), []int64{1, 2})
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresTaskRepository_GetTaskIDByKey(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT id FROM (.+) task_key_aliases").
+		WithArgs("OPS-7").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	id, err := repo.GetTaskIDByKey(context.Background(), "OPS-7")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), id)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_GetTaskIDByExternalID(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT id FROM tasks WHERE external_id = \\$1").
+		WithArgs("01KDVDNA000000000000000009").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetTaskIDByExternalID(context.Background(), "01KDVDNA000000000000000009")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_TransferTask(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT key FROM tasks WHERE id = (.+) FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("OPS-7"))
+	mock.ExpectExec("INSERT INTO task_key_aliases").
+		WithArgs("OPS-7", 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE projects SET task_counter").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("DEV-2"))
+	mock.ExpectQuery("UPDATE tasks SET project_id").
+		WithArgs(5, "DEV-2", "open", "i", []byte(`{"points":3}`), 4).
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(4, "01KDVDNA000000000000000004", 5, "DEV-2", "Test Task", "", "open", "", "{}", "i", `{"points": 3}`, time.Now(), time.Now(), nil))
+	mock.ExpectCommit()
+
+	task, err := repo.TransferTask(context.Background(), 4, 5, "open", "i", map[string]models.FieldValue{
+		"points": {Type: models.FieldTypeNumber, Number: 3},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "DEV-2", task.Key)
+	assert.Equal(t, int64(5), task.ProjectID)
+	assert.Equal(t, models.FieldValue{Type: models.FieldTypeNumber, Number: 3}, task.CustomFields["points"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_SetTaskParent(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("UPDATE tasks SET parent_id = \\$1, updated_at = now\\(\\) WHERE id = \\$2 RETURNING").
+		WithArgs(2, 4).
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(4, "01KDVDNA000000000000000004", 5, "DEV-4", "Test Task", "", "open", "", "{}", "i", "{}", time.Now(), time.Now(), 2))
+	mock.ExpectQuery("UPDATE tasks SET parent_id = NULL").
+		WithArgs(pq.Int64Array{2}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(6))
+
+	task, err := repo.SetTaskParent(context.Background(), 4, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), task.ParentID)
+
+	ids, err := repo.DetachSubtasks(context.Background(), []int64{2})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4, 6}, ids)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_TaskRelations(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	relations := []models.TaskRelation{
+		{TaskID: 1, RelatedTaskID: 2, Type: models.RelationBlocks},
+		{TaskID: 3, RelatedTaskID: 1, Type: models.RelationDuplicates},
+	}
+
+	mock.ExpectExec("INSERT INTO task_relations (.+) SELECT \\* FROM unnest(.+) ON CONFLICT DO NOTHING").
+		WithArgs(pq.Int64Array{1, 3}, pq.Int64Array{2, 1}, pq.StringArray{"blocks", "duplicates"}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT task_id, related_task_id, type FROM task_relations WHERE task_id = \\$1 OR related_task_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "related_task_id", "type"}).AddRow(1, 2, "blocks").AddRow(3, 1, "duplicates"))
+	mock.ExpectExec("DELETE FROM task_relations").
+		WithArgs(1, 2, "blocks").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.CreateTaskRelations(context.Background(), relations))
+	listed, err := repo.ListTaskRelations(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, relations, listed)
+	assert.ErrorIs(t, repo.DeleteTaskRelation(context.Background(), relations[0]), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_TaskContent(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	checklist := []models.ChecklistItem{{Text: "Write"}, {Text: "Review", Done: true}}
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("WITH cleared AS \\(DELETE FROM task_checklist_items WHERE task_id = \\$1\\) INSERT INTO task_checklist_items").
+		WithArgs(1, pq.StringArray{"Write", "Review"}, pq.BoolArray{false, true}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT text, done FROM task_checklist_items WHERE task_id = \\$1 ORDER BY position").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"text", "done"}).AddRow("Write", false).AddRow("Review", true))
+	mock.ExpectQuery("INSERT INTO task_comments (.+) RETURNING id, created_at").
+		WithArgs(1, "ada", "Looks good").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, created))
+	mock.ExpectQuery("INSERT INTO task_attachments (.+) RETURNING id, created_at").
+		WithArgs(1, "plan.pdf", "https://files.example.com/plan.pdf", "application/pdf", 2048).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, created))
+	mock.ExpectExec("WITH checklist AS \\( INSERT INTO task_checklist_items (.+) INSERT INTO task_attachments").
+		WithArgs(1, 2, true, false, true).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	assert.NoError(t, repo.SetTaskChecklist(context.Background(), 1, checklist))
+	items, err := repo.GetTaskChecklist(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, checklist, items)
+	comment, err := repo.CreateTaskComment(context.Background(), &models.TaskComment{TaskID: 1, Author: "ada", Body: "Looks good"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.TaskComment{ID: 7, TaskID: 1, Author: "ada", Body: "Looks good", CreatedAt: created}, comment)
+	attachment, err := repo.CreateTaskAttachment(context.Background(), &models.TaskAttachment{
+		TaskID: 1, Name: "plan.pdf", URL: "https://files.example.com/plan.pdf", ContentType: "application/pdf", Size: 2048,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), attachment.ID)
+	assert.NoError(t, repo.CopyTaskContent(context.Background(), 1, 2, models.TaskContent{Checklist: true, Attachments: true}))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_MoveTask(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("UPDATE tasks SET status = (.+), rank = (.+) WHERE id = (.+) RETURNING").
+		WithArgs("done", "x", 1).
+		WillReturnRows(sqlmock.NewRows(taskRowColumns).
+			AddRow(1, "01KDVDNA000000000000000001", nil, nil, "Test Task", "This is a test task", "done", "", "{}", "x", "{}", time.Now(), time.Now(), nil))
+
+	task, err := repo.MoveTask(context.Background(), 1, "done", "x")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", task.Rank)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_RankBefore(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(rank\\), ''\\) FROM tasks").
+		WithArgs(2, "open", "").
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow("m"))
+
+	rank, err := repo.RankBefore(context.Background(), models.Column{ProjectID: 2, Status: "open"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "m", rank)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_RebalanceColumn(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM tasks (.+) FOR UPDATE").
+		WithArgs(nil, "open").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(3))
+	mock.ExpectExec("UPDATE tasks SET rank").WithArgs("c", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE tasks SET rank").WithArgs("o", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.RebalanceColumn(context.Background(), models.Column{Status: "open"}, func(count int) []string {
+		assert.Equal(t, 2, count)
+		return []string{"c", "o"}
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ArchiveTasks(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	updatedBefore := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM tasks (.+) FOR UPDATE SKIP LOCKED").
+		WithArgs(int64(2), "done", updatedBefore, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5).AddRow(3))
+	mock.ExpectExec(`SELECT create_task_partition\('tasks_archive'`).WithArgs(pq.Int64Array{5, 3}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO task_key_aliases_archive (.+) INSERT INTO task_imports_archive").WithArgs(pq.Int64Array{5, 3}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM tasks (.+) INSERT INTO tasks_archive").WithArgs(pq.Int64Array{5, 3}).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	ids, err := repo.ArchiveTasks(context.Background(), models.Column{ProjectID: 2, Status: "done"}, updatedBefore, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{5, 3}, ids)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_CreateTaskPartitions(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	until := time.Now().AddDate(0, 1, 0)
+
+	mock.ExpectExec(`SELECT create_task_partition\('tasks', (.+) FROM generate_series`).WithArgs(until).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, repo.CreateTaskPartitions(context.Background(), until))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ArchiveTasks_None(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM tasks (.+) FOR UPDATE SKIP LOCKED").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	ids, err := repo.ArchiveTasks(context.Background(), models.Column{Status: "done"}, time.Now(), 100)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_ListTasks_IncludeArchived(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+	archivedAt := time.Now()
+
+	mock.ExpectQuery(`SELECT (.+), archived_at FROM \(SELECT (.+) FROM tasks UNION ALL SELECT (.+) FROM tasks_archive\) tasks WHERE project_id = \$1`).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows(append(taskRowColumns, "archived_at")).
+			AddRow(1, "01KDVDNA000000000000000001", 2, "OPS-1", "Old Task", "", "done", "", "{}", "a", "{}", time.Now(), time.Now(), nil, archivedAt).
+			AddRow(2, "01KDVDNA000000000000000002", 2, "OPS-2", "Live Task", "", "open", "", "{}", "b", "{}", time.Now(), time.Now(), nil, nil))
+
+	tasks, err := repo.ListTasks(context.Background(), models.TaskFilter{ProjectID: 2, IncludeArchived: true})
+	assert.NoError(t, err)
+	if assert.Len(t, tasks, 2) {
+		assert.True(t, tasks[0].ArchivedAt.Equal(archivedAt))
+		assert.True(t, tasks[1].ArchivedAt.IsZero())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskRepository_GetArchivedTask(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM tasks_archive WHERE").
+		WithArgs("", "OPS-1", 0).
+		WillReturnRows(sqlmock.NewRows(append(taskRowColumns, "archived_at")).
+			AddRow(1, "01KDVDNA000000000000000001", 2, "OPS-1", "Old Task", "", "done", "", "{}", "a", "{}", time.Now(), time.Now(), nil, time.Now()))
+	mock.ExpectQuery("SELECT (.+) FROM tasks_archive WHERE").
+		WithArgs("", "", 9).
+		WillReturnError(sql.ErrNoRows)
+
+	task, err := repo.GetArchivedTask(context.Background(), models.TaskRef{Key: "OPS-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), task.ID)
+	assert.False(t, task.ArchivedAt.IsZero())
+
+	_, err = repo.GetArchivedTask(context.Background(), models.TaskRef{ID: 9})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Options of ts_headline: titles are highlighted whole, descriptions are cut down to the fragments
+// around the matching words.
+const (
+	titleHeadlineOptions       = `HighlightAll=true, StartSel=<mark>, StopSel=</mark>`
+	descriptionHeadlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+// searchWordPattern splits prefix terms into the words to_tsquery accepts.
+var searchWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// PostgresTaskSearcher searches the tsvector column that Postgres generates from task titles and
+// descriptions. Titles weigh more than descriptions in the ranking.
+type PostgresTaskSearcher struct {
+	db       *sql.DB
+	language string
+	logger   *zap.Logger
+}
+
+// NewPostgresTaskSearcher returns a searcher that indexes and queries tasks with the given text
+// search configuration, such as "english" or "simple".
+func NewPostgresTaskSearcher(db *sql.DB, language string, logger *zap.Logger) *PostgresTaskSearcher {
+	return &PostgresTaskSearcher{db: db, language: language, logger: logger}
+}
+
+// ApplyLanguage makes the searcher's text search configuration the one new tasks are indexed with
+// and reindexes the tasks indexed with another one. It does nothing if the language is already in use.
+func (s *PostgresTaskSearcher) ApplyLanguage() error {
+	var exists bool
+	err := s.db.QueryRowContext(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = $1)`, s.language,
+	).Scan(&exists)
+	if err != nil {
+		s.logger.Error("Failed to look up text search configuration", zap.Error(err))
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("unknown text search configuration %q", s.language)
+	}
+
+	var current sql.NullString
+	err = s.db.QueryRowContext(context.Background(),
+		`SELECT column_default FROM information_schema.columns WHERE table_name = 'tasks' AND column_name = 'search_language'`,
+	).Scan(&current)
+	if err != nil {
+		s.logger.Error("Failed to look up search language", zap.Error(err))
+		return err
+	}
+	defaultExpr := pq.QuoteLiteral(s.language) + "::regconfig"
+	if current.String == defaultExpr {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(context.Background(), `ALTER TABLE tasks ALTER COLUMN search_language SET DEFAULT `+defaultExpr); err != nil {
+		s.logger.Error("Failed to change search language", zap.Error(err))
+		return err
+	}
+	res, err := tx.ExecContext(context.Background(),
+		`UPDATE tasks SET search_language = $1::regconfig WHERE search_language <> $1::regconfig`, s.language)
+	if err != nil {
+		s.logger.Error("Failed to reindex tasks", zap.Error(err))
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit search language change", zap.Error(err))
+		return err
+	}
+
+	reindexed, _ := res.RowsAffected()
+	s.logger.Info("Changed search language", zap.String("language", s.language), zap.Int64("reindexed_tasks", reindexed))
+	return nil
+}
+
+// SearchTasks returns the tasks matching all terms of the search, ranked by how well they match.
+func (s *PostgresTaskSearcher) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	args := []any{s.language}
+	var queries []string
+	for _, term := range search.Terms {
+		query, ok := termQuery(term, len(args)+1)
+		if !ok {
+			continue
+		}
+		args = append(args, termText(term))
+		queries = append(queries, query)
+	}
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	conditions := []string{`search_vector @@ q`, `search_language = $1::regconfig`}
+	if search.ProjectID != 0 {
+		args = append(args, search.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	var pageCondition string
+	if search.After != nil {
+		args = append(args, search.After.Score, search.After.ExternalID)
+		pageCondition = fmt.Sprintf(` WHERE score < $%d::real OR (score = $%d::real AND external_id > $%d)`,
+			len(args)-1, len(args)-1, len(args))
+	}
+
+	// Snippets are only generated for the returned page, as ts_headline works on the raw text.
+	query := `
+		SELECT ` + taskColumns + `, score,
+			ts_headline($1::regconfig, title, q, '` + titleHeadlineOptions + `'),
+			ts_headline($1::regconfig, description, q, '` + descriptionHeadlineOptions + `')
+		FROM (
+			SELECT tasks.*, q, ts_rank_cd(search_vector, q, 32) AS score
+			FROM tasks, (SELECT ` + strings.Join(queries, " && ") + ` AS q) query
+			WHERE ` + strings.Join(conditions, " AND ") + `
+		) hits` + pageCondition + `
+		ORDER BY score DESC, external_id`
+	if search.Limit > 0 {
+		args = append(args, search.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("Failed to search tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*models.SearchHit
+	for rows.Next() {
+		var hit models.SearchHit
+		hit.Task, err = scanTask(rows, &hit.Score, &hit.TitleSnippet, &hit.DescriptionSnippet)
+		if err != nil {
+			s.logger.Error("Failed to scan search hit", zap.Error(err))
+			return nil, err
+		}
+		hits = append(hits, &hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// termQuery returns the tsquery expression for a search term whose text is bound to parameter n.
+// It reports false for prefix terms without any word, which to_tsquery would reject.
+func termQuery(term models.SearchTerm, n int) (string, bool) {
+	var query string
+	switch {
+	case term.Prefix:
+		if termText(term) == "" {
+			return "", false
+		}
+		query = fmt.Sprintf("to_tsquery($1::regconfig, $%d)", n)
+	case term.Phrase:
+		query = fmt.Sprintf("phraseto_tsquery($1::regconfig, $%d)", n)
+	default:
+		query = fmt.Sprintf("plainto_tsquery($1::regconfig, $%d)", n)
+	}
+	if term.Exclude {
+		query = "!!" + query
+	}
+	return query, true
+}
+
+// termText returns the text bound for a search term. Prefix terms are turned into tsquery syntax
+// here, quoting each word so that no user input is interpreted as an operator.
+func termText(term models.SearchTerm) string {
+	if !term.Prefix {
+		return term.Text
+	}
+	words := searchWordPattern.FindAllString(term.Text, -1)
+	if len(words) == 0 {
+		return ""
+	}
+	for i, word := range words {
+		words[i] = "'" + word + "'"
+	}
+	return strings.Join(words, " <-> ") + ":*"
+}
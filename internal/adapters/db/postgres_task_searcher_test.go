@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresTaskSearcher_SearchTasks(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	searcher := NewPostgresTaskSearcher(db, "english", logger)
+	search := models.TaskSearch{
+		Terms: []models.SearchTerm{
+			{Text: "release notes", Phrase: true},
+			{Text: "migr", Prefix: true},
+			{Text: "draft", Exclude: true},
+		},
+		ProjectID: 3,
+		Limit:     11,
+		After:     &models.SearchCursor{Score: 0.5, ExternalID: "01KDVDNA000000000000000001"},
+	}
+
+	now := time.Now()
+	columns := append(taskRowColumns, "score", "title_snippet", "description_snippet")
+	mock.ExpectQuery(`phraseto_tsquery\(\$1::regconfig, \$2\) && to_tsquery\(\$1::regconfig, \$3\) && !!plainto_tsquery\(\$1::regconfig, \$4\).*`+
+		`project_id = \$5.*WHERE score < \$6::real OR \(score = \$6::real AND external_id > \$7\).*ORDER BY score DESC, external_id LIMIT \$8`).
+		WithArgs("english", "release notes", "'migr':*", "draft", int64(3), 0.5, "01KDVDNA000000000000000001", 11).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			2, "01KDVDNA000000000000000002", 3, "OPS-2", "Migrate release notes", "", "open", "", "{}", "m", []byte("{}"), now, now, nil,
+			0.25, "<mark>Migrate</mark> <mark>release</mark> <mark>notes</mark>", "",
+		))
+
+	hits, err := searcher.SearchTasks(context.Background(), search)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, int64(2), hits[0].Task.ID)
+	assert.Equal(t, 0.25, hits[0].Score)
+	assert.Equal(t, "<mark>Migrate</mark> <mark>release</mark> <mark>notes</mark>", hits[0].TitleSnippet)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskSearcher_ApplyLanguage(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	searcher := NewPostgresTaskSearcher(db, "simple", logger)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM pg_ts_config").
+		WithArgs("simple").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT column_default FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows([]string{"column_default"}).AddRow("'english'::regconfig"))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE tasks ALTER COLUMN search_language SET DEFAULT 'simple'::regconfig").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE tasks SET search_language = \\$1::regconfig").
+		WithArgs("simple").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	assert.NoError(t, searcher.ApplyLanguage())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskSearcher_ApplyLanguage_Unknown(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	searcher := NewPostgresTaskSearcher(db, "klingon", logger)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM pg_ts_config").
+		WithArgs("klingon").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	assert.Error(t, searcher.ApplyLanguage())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// taskTemplateColumns select a template joined with one of its versions; the version was made when the
+// template was last updated.
+const taskTemplateColumns = `t.id, t.project_id, t.name, v.version, v.description, v.variables, v.tasks, t.created_at, v.created_at`
+
+type PostgresTaskTemplateRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPostgresTaskTemplateRepository(db *sql.DB, logger *zap.Logger) *PostgresTaskTemplateRepository {
+	return &PostgresTaskTemplateRepository{db: db, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *PostgresTaskTemplateRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *PostgresTaskTemplateRepository) CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	tasks, err := encodeBlueprints(template.Tasks)
+	if err != nil {
+		r.logger.Error("Failed to encode task blueprints", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	template.Version = 1
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO task_templates (project_id, name, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;
+	`, template.ProjectID, template.Name, template.Version, template.CreatedAt, template.UpdatedAt).Scan(&template.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to create task template", zap.Error(err))
+		return nil, err
+	}
+	if err := insertTemplateVersion(ctx, tx, template, tasks); err != nil {
+		r.logger.Error("Failed to store task template version", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+	return template, nil
+}
+
+func (r *PostgresTaskTemplateRepository) GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error) {
+	query := `
+		SELECT ` + taskTemplateColumns + `
+		FROM task_templates t
+		JOIN task_template_versions v ON v.template_id = t.id AND v.version = COALESCE(NULLIF($2, 0), t.version)
+		WHERE t.id = $1
+	`
+
+	template, err := scanTaskTemplate(r.conn(ctx).QueryRowContext(ctx, query, id, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch task template", zap.Error(err))
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (r *PostgresTaskTemplateRepository) ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error) {
+	query := `
+		SELECT ` + taskTemplateColumns + `
+		FROM task_templates t
+		JOIN task_template_versions v ON v.template_id = t.id AND v.version = t.version
+		WHERE t.project_id = $1
+		ORDER BY t.name, t.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, projectID)
+	if err != nil {
+		r.logger.Error("Failed to list task templates", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.TaskTemplate
+	for rows.Next() {
+		template, err := scanTaskTemplate(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan task template", zap.Error(err))
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *PostgresTaskTemplateRepository) UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	tasks, err := encodeBlueprints(template.Tasks)
+	if err != nil {
+		r.logger.Error("Failed to encode task blueprints", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	template.UpdatedAt = time.Now()
+	err = tx.QueryRowContext(ctx, `
+		UPDATE task_templates
+		SET name = $1, version = version + 1, updated_at = $2
+		WHERE id = $3
+		RETURNING project_id, version, created_at;
+	`, template.Name, template.UpdatedAt, template.ID).Scan(&template.ProjectID, &template.Version, &template.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to update task template", zap.Error(err))
+		return nil, err
+	}
+	if err := insertTemplateVersion(ctx, tx, template, tasks); err != nil {
+		r.logger.Error("Failed to store task template version", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+	return template, nil
+}
+
+func (r *PostgresTaskTemplateRepository) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM task_templates WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete task template", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get affected rows", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func insertTemplateVersion(ctx context.Context, tx *sqlTx, template *models.TaskTemplate, tasks []byte) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO task_template_versions (template_id, version, description, variables, tasks, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`, template.ID, template.Version, template.Description, stringArray(template.Variables), tasks, template.UpdatedAt)
+	return err
+}
+
+// blueprintRecord is the stored form of a task blueprint. Custom field values are stored like those of tasks.
+type blueprintRecord struct {
+	Ref          string                     `json:"ref,omitempty"`
+	Title        string                     `json:"title"`
+	Description  string                     `json:"description,omitempty"`
+	Status       string                     `json:"status,omitempty"`
+	Assignee     string                     `json:"assignee,omitempty"`
+	Labels       []string                   `json:"labels,omitempty"`
+	CustomFields json.RawMessage            `json:"custom_fields,omitempty"`
+	Subtasks     []blueprintRecord          `json:"subtasks,omitempty"`
+	Relations    []models.BlueprintRelation `json:"relations,omitempty"`
+}
+
+func encodeBlueprints(blueprints []models.TaskBlueprint) ([]byte, error) {
+	records, err := blueprintRecords(blueprints)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(records)
+}
+
+// blueprintRecords returns the stored form of blueprints and their subtasks.
+func blueprintRecords(blueprints []models.TaskBlueprint) ([]blueprintRecord, error) {
+	records := make([]blueprintRecord, len(blueprints))
+	for i, blueprint := range blueprints {
+		records[i] = blueprintRecord{
+			Ref:         blueprint.Ref,
+			Title:       blueprint.Title,
+			Description: blueprint.Description,
+			Status:      blueprint.Status,
+			Assignee:    blueprint.Assignee,
+			Labels:      blueprint.Labels,
+			Relations:   blueprint.Relations,
+		}
+		var err error
+		if len(blueprint.CustomFields) > 0 {
+			if records[i].CustomFields, err = encodeCustomFields(blueprint.CustomFields); err != nil {
+				return nil, err
+			}
+		}
+		if len(blueprint.Subtasks) > 0 {
+			if records[i].Subtasks, err = blueprintRecords(blueprint.Subtasks); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return records, nil
+}
+
+func decodeBlueprints(data []byte) ([]models.TaskBlueprint, error) {
+	var records []blueprintRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return recordBlueprints(records)
+}
+
+// recordBlueprints returns the blueprints stored as records, with their subtasks.
+func recordBlueprints(records []blueprintRecord) ([]models.TaskBlueprint, error) {
+	blueprints := make([]models.TaskBlueprint, len(records))
+	for i, record := range records {
+		customFields, err := decodeCustomFields(record.CustomFields)
+		if err != nil {
+			return nil, err
+		}
+		blueprints[i] = models.TaskBlueprint{
+			Ref:          record.Ref,
+			Title:        record.Title,
+			Description:  record.Description,
+			Status:       record.Status,
+			Assignee:     record.Assignee,
+			Labels:       record.Labels,
+			CustomFields: customFields,
+			Relations:    record.Relations,
+		}
+		if len(record.Subtasks) > 0 {
+			if blueprints[i].Subtasks, err = recordBlueprints(record.Subtasks); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return blueprints, nil
+}
+
+func scanTaskTemplate(row rowScanner) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	var variables pq.StringArray
+	var tasks []byte
+	err := row.Scan(&template.ID, &template.ProjectID, &template.Name, &template.Version, &template.Description, &variables,
+		&tasks, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	template.Variables = variables
+	if template.Tasks, err = decodeBlueprints(tasks); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var taskTemplateRowColumns = []string{"id", "project_id", "name", "version", "description", "variables", "tasks", "created_at", "updated_at"}
+
+func TestPostgresTaskTemplateRepository_CreateTaskTemplate(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskTemplateRepository(db, logger)
+	template := &models.TaskTemplate{ProjectID: 3, Name: "Onboarding", Variables: []string{"name"}, Tasks: []models.TaskBlueprint{
+		{Title: "Welcome {{name}}", Description: "Say hello", CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 2}}},
+	}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO task_templates").
+		WithArgs(3, "Onboarding", 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectExec("INSERT INTO task_template_versions").
+		WithArgs(5, 1, "", sqlmock.AnyArg(), []byte(`[{"title":"Welcome {{name}}","description":"Say hello","custom_fields":{"points":2}}]`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	createdTemplate, err := repo.CreateTaskTemplate(context.Background(), template)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), createdTemplate.ID)
+	assert.Equal(t, 1, createdTemplate.Version)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskTemplateRepository_CreateTaskTemplate_NameTaken(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskTemplateRepository(db, logger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO task_templates").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err := repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: 3, Name: "Onboarding"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskTemplateRepository_GetTaskTemplate(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskTemplateRepository(db, logger)
+
+	mock.ExpectQuery("SELECT (.+) FROM task_templates t JOIN task_template_versions v ON v.template_id = t.id AND v.version = COALESCE\\(NULLIF\\(\\$2, 0\\), t.version\\)").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows(taskTemplateRowColumns).
+			AddRow(5, 3, "Onboarding", 1, "", "{name}", `[{"title":"Welcome {{name}}","labels":["new"],"custom_fields":{"points":2}}]`,
+				time.Now(), time.Now()))
+
+	template, err := repo.GetTaskTemplate(context.Background(), 5, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name"}, template.Variables)
+	assert.Equal(t, []models.TaskBlueprint{{Title: "Welcome {{name}}", Labels: []string{"new"},
+		CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 2}}}}, template.Tasks)
+
+	mock.ExpectQuery("SELECT (.+) FROM task_templates").
+		WithArgs(5, 9).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.GetTaskTemplate(context.Background(), 5, 9)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskTemplateRepository_UpdateTaskTemplate(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskTemplateRepository(db, logger)
+	template := &models.TaskTemplate{ID: 5, Name: "Onboarding", Tasks: []models.TaskBlueprint{{Title: "Welcome"}}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE task_templates SET name = \\$1, version = version \\+ 1").
+		WithArgs("Onboarding", sqlmock.AnyArg(), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"project_id", "version", "created_at"}).AddRow(3, 2, time.Now()))
+	mock.ExpectExec("INSERT INTO task_template_versions").
+		WithArgs(5, 2, "", sqlmock.AnyArg(), []byte(`[{"title":"Welcome"}]`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	updatedTemplate, err := repo.UpdateTaskTemplate(context.Background(), template)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updatedTemplate.Version)
+	assert.Equal(t, int64(3), updatedTemplate.ProjectID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresTaskTemplateRepository_DeleteTaskTemplate_NotFound(t *testing.T) {
+	db, mock, logger := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPostgresTaskTemplateRepository(db, logger)
+
+	mock.ExpectExec("DELETE FROM task_templates WHERE id = \\$1").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.DeleteTaskTemplate(context.Background(), 5)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"go.uber.org/zap"
+)
+
+// replicaLagQuery measures how far a replica is behind its primary. A replica that streams WAL from the
+// primary and replayed all it received is up to date however long ago the last transaction was, and a server
+// that is not replicating at all has no lag. A replica that lost its upstream has replayed all it received
+// as well but falls behind from then on, so it has no known lag: the query returns NULL for it.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN NOT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') THEN NULL
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END;`
+
+// ReplicaRouting configures how reads are spread over read replicas.
+type ReplicaRouting struct {
+	// MaxLag is how far a replica may fall behind the primary before reads avoid it.
+	MaxLag time.Duration
+	// ReadYourWrites is how long after a write the reads of the same caller go to the primary, so that
+	// they see the write even if the replicas have not replayed it yet.
+	ReadYourWrites time.Duration
+	// CheckInterval is how often the health and lag of the replicas are checked.
+	CheckInterval time.Duration
+}
+
+// ReplicaSet decides which read replica, if any, serves a read. Replicas serve reads once a check found
+// them reachable and no further behind than MaxLag, and reads fall back to the primary while none does.
+// It is safe for concurrent use.
+type ReplicaSet struct {
+	replicas []*replica
+	routing  ReplicaRouting
+	logger   *zap.Logger
+	now      func() time.Time
+
+	// next spreads reads over the healthy replicas in turn.
+	next atomic.Uint64
+
+	mu sync.Mutex
+	// writes holds when each caller last wrote, for the callers that wrote within the ReadYourWrites window.
+	writes map[string]time.Time
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// NewReplicaSet routes reads over the replicas whose databases are dbs. The replicas are considered
+// unhealthy until the first check, which Run performs right away.
+func NewReplicaSet(dbs []*sql.DB, routing ReplicaRouting, logger *zap.Logger) *ReplicaSet {
+	replicas := make([]*replica, len(dbs))
+	for i, db := range dbs {
+		replicas[i] = &replica{db: db}
+	}
+	return &ReplicaSet{
+		replicas: replicas,
+		routing:  routing,
+		logger:   logger,
+		now:      time.Now,
+		writes:   make(map[string]time.Time),
+	}
+}
+
+// Run checks the replicas every CheckInterval until ctx is cancelled.
+func (s *ReplicaSet) Run(ctx context.Context) {
+	s.logger.Info("Starting replica health checks", zap.Int("replicas", len(s.replicas)),
+		zap.Duration("interval", s.routing.CheckInterval), zap.Duration("max_lag", s.routing.MaxLag))
+
+	ticker := time.NewTicker(s.routing.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.CheckOnce(ctx)
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping replica health checks")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckOnce measures the lag of every replica and lets only those within MaxLag serve reads.
+// It also forgets the writes that no longer affect routing.
+func (s *ReplicaSet) CheckOnce(ctx context.Context) {
+	for i, replica := range s.replicas {
+		ctx, cancel := context.WithTimeout(ctx, s.routing.CheckInterval)
+		var seconds sql.NullFloat64
+		err := replica.db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds)
+		cancel()
+		lag := time.Duration(seconds.Float64 * float64(time.Second))
+
+		healthy := err == nil && seconds.Valid && lag <= s.routing.MaxLag
+		if healthy == replica.healthy.Swap(healthy) {
+			continue
+		}
+		switch {
+		case healthy:
+			s.logger.Info("Read replica serves reads", zap.Int("replica", i), zap.Duration("lag", lag))
+		case err != nil:
+			s.logger.Warn("Read replica is unreachable, reading from the primary instead", zap.Int("replica", i), zap.Error(err))
+		case !seconds.Valid:
+			s.logger.Warn("Read replica does not stream from the primary, reading from the primary instead", zap.Int("replica", i))
+		default:
+			s.logger.Warn("Read replica lags behind, reading from the primary instead", zap.Int("replica", i), zap.Duration("lag", lag))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for caller, wrote := range s.writes {
+		if s.now().Sub(wrote) >= s.routing.ReadYourWrites {
+			delete(s.writes, caller)
+		}
+	}
+}
+
+// Wrote records that the caller of ctx just wrote, so that its reads go to the primary for a while.
+func (s *ReplicaSet) Wrote(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes[consistency.Caller(ctx)] = s.now()
+}
+
+// Pick returns the index of the replica to read from under ctx, or -1 to read from the primary.
+func (s *ReplicaSet) Pick(ctx context.Context) int {
+	if consistency.Latest(ctx) || s.wroteRecently(ctx) {
+		return -1
+	}
+	start := int(s.next.Add(1) % uint64(len(s.replicas)))
+	for i := range s.replicas {
+		candidate := (start + i) % len(s.replicas)
+		if s.replicas[candidate].healthy.Load() {
+			return candidate
+		}
+	}
+	return -1
+}
+
+// Failed reports whether err, returned by a read from replica i, means that the replica failed rather than
+// the read, and stops reading from the replica until the next check finds it healthy again if so.
+func (s *ReplicaSet) Failed(ctx context.Context, i int, err error) bool {
+	if err == nil || ctx.Err() != nil || !isServerFailure(err) {
+		return false
+	}
+	if s.replicas[i].healthy.Swap(false) {
+		s.logger.Warn("Read from replica failed, reading from the primary instead", zap.Int("replica", i), zap.Error(err))
+	}
+	return true
+}
+
+func (s *ReplicaSet) wroteRecently(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wrote, ok := s.writes[consistency.Caller(ctx)]
+	return ok && s.now().Sub(wrote) < s.routing.ReadYourWrites
+}
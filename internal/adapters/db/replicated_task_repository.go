@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+)
+
+// ReplicatedTaskRepository sends the reads of tasks to read replicas as its ReplicaSet picks them, and
+// everything else to the primary. Rank and import lookups, which writes depend on, always read the
+// primary. A read that fails because its replica failed is retried on the primary.
+type ReplicatedTaskRepository struct {
+	repository.TaskRepository
+	replicas []repository.TaskRepository
+	set      *ReplicaSet
+}
+
+// NewReplicatedTaskRepository reads from replicas, which hold a task repository per replica of set in
+// the same order.
+func NewReplicatedTaskRepository(primary repository.TaskRepository, replicas []repository.TaskRepository, set *ReplicaSet) *ReplicatedTaskRepository {
+	return &ReplicatedTaskRepository{
+		TaskRepository: primary,
+		replicas:       replicas,
+		set:            set,
+	}
+}
+
+func (r *ReplicatedTaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	var task *models.Task
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		task, err = repo.GetTask(ctx, id)
+		return err
+	})
+	return task, err
+}
+
+func (r *ReplicatedTaskRepository) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		tasks, err = repo.ListTasks(ctx, filter)
+		return err
+	})
+	return tasks, err
+}
+
+// ExportTasks only falls back to the primary if the replica fails before passing on any task, as each
+// must not see a task twice.
+func (r *ReplicatedTaskRepository) ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error {
+	i := r.set.Pick(ctx)
+	if i < 0 {
+		return r.TaskRepository.ExportTasks(ctx, filter, each)
+	}
+	exported := false
+	err := r.replicas[i].ExportTasks(ctx, filter, func(task *models.Task) error {
+		exported = true
+		return each(task)
+	})
+	if !exported && r.set.Failed(ctx, i, err) {
+		return r.TaskRepository.ExportTasks(ctx, filter, each)
+	}
+	return err
+}
+
+func (r *ReplicatedTaskRepository) GetTaskIDByKey(ctx context.Context, key string) (int64, error) {
+	var id int64
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		id, err = repo.GetTaskIDByKey(ctx, key)
+		return err
+	})
+	return id, err
+}
+
+func (r *ReplicatedTaskRepository) GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error) {
+	var id int64
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		id, err = repo.GetTaskIDByExternalID(ctx, externalID)
+		return err
+	})
+	return id, err
+}
+
+func (r *ReplicatedTaskRepository) GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	var task *models.Task
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		task, err = repo.GetArchivedTask(ctx, ref)
+		return err
+	})
+	return task, err
+}
+
+func (r *ReplicatedTaskRepository) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	var relations []models.TaskRelation
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		relations, err = repo.ListTaskRelations(ctx, id)
+		return err
+	})
+	return relations, err
+}
+
+func (r *ReplicatedTaskRepository) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		items, err = repo.GetTaskChecklist(ctx, id)
+		return err
+	})
+	return items, err
+}
+
+func (r *ReplicatedTaskRepository) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	var comments []*models.TaskComment
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		comments, err = repo.ListTaskComments(ctx, id)
+		return err
+	})
+	return comments, err
+}
+
+func (r *ReplicatedTaskRepository) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	var attachments []*models.TaskAttachment
+	err := r.read(ctx, func(repo repository.TaskRepository) error {
+		var err error
+		attachments, err = repo.ListTaskAttachments(ctx, id)
+		return err
+	})
+	return attachments, err
+}
+
+func (r *ReplicatedTaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CreateTask(ctx, task)
+}
+
+func (r *ReplicatedTaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.UpdateTask(ctx, task)
+}
+
+func (r *ReplicatedTaskRepository) DeleteTask(ctx context.Context, id int64) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.DeleteTask(ctx, id)
+}
+
+func (r *ReplicatedTaskRepository) CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CreateTasks(ctx, tasks)
+}
+
+func (r *ReplicatedTaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.UpdateTasks(ctx, tasks)
+}
+
+func (r *ReplicatedTaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.DeleteTasks(ctx, ids)
+}
+
+func (r *ReplicatedTaskRepository) CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CreateImportedTasks(ctx, source, tasks, sourceIDs)
+}
+
+func (r *ReplicatedTaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.TransferTask(ctx, id, projectID, status, rank, customFields)
+}
+
+func (r *ReplicatedTaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.SetTaskParent(ctx, id, parentID)
+}
+
+func (r *ReplicatedTaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.DetachSubtasks(ctx, parentIDs)
+}
+
+func (r *ReplicatedTaskRepository) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CreateTaskRelations(ctx, relations)
+}
+
+func (r *ReplicatedTaskRepository) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.DeleteTaskRelation(ctx, relation)
+}
+
+func (r *ReplicatedTaskRepository) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.SetTaskChecklist(ctx, id, items)
+}
+
+func (r *ReplicatedTaskRepository) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CreateTaskComment(ctx, comment)
+}
+
+func (r *ReplicatedTaskRepository) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CreateTaskAttachment(ctx, attachment)
+}
+
+func (r *ReplicatedTaskRepository) CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.CopyTaskContent(ctx, fromID, toID, content)
+}
+
+func (r *ReplicatedTaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.MoveTask(ctx, id, status, rank)
+}
+
+func (r *ReplicatedTaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.RebalanceColumn(ctx, column, ranks)
+}
+
+func (r *ReplicatedTaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	defer r.set.Wrote(ctx)
+	return r.TaskRepository.ArchiveTasks(ctx, column, updatedBefore, limit)
+}
+
+// read runs query against the replica the set picks, or against the primary if it picks none or the
+// replica fails.
+func (r *ReplicatedTaskRepository) read(ctx context.Context, query func(repo repository.TaskRepository) error) error {
+	if i := r.set.Pick(ctx); i >= 0 {
+		err := query(r.replicas[i])
+		if !r.set.Failed(ctx, i, err) {
+			return err
+		}
+	}
+	return query(r.TaskRepository)
+}
+
+// ReplicatedTaskSearcher sends searches to read replicas like ReplicatedTaskRepository sends reads.
+type ReplicatedTaskSearcher struct {
+	primary  repository.TaskSearcher
+	replicas []repository.TaskSearcher
+	set      *ReplicaSet
+}
+
+// NewReplicatedTaskSearcher searches replicas, which hold a searcher per replica of set in the same order.
+func NewReplicatedTaskSearcher(primary repository.TaskSearcher, replicas []repository.TaskSearcher, set *ReplicaSet) *ReplicatedTaskSearcher {
+	return &ReplicatedTaskSearcher{
+		primary:  primary,
+		replicas: replicas,
+		set:      set,
+	}
+}
+
+func (s *ReplicatedTaskSearcher) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	if i := s.set.Pick(ctx); i >= 0 {
+		hits, err := s.replicas[i].SearchTasks(ctx, search)
+		if !s.set.Failed(ctx, i, err) {
+			return hits, err
+		}
+	}
+	return s.primary.SearchTasks(ctx, search)
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// namedRepository answers reads with tasks titled by its name, or with err when set, and counts writes.
+type namedRepository struct {
+	repository.TaskRepository
+	name   string
+	err    error
+	writes int
+}
+
+func (r *namedRepository) GetTask(_ context.Context, id int64) (*models.Task, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.Task{ID: id, Title: r.name}, nil
+}
+
+func (r *namedRepository) ExportTasks(_ context.Context, _ models.TaskFilter, each func(*models.Task) error) error {
+	if err := each(&models.Task{ID: 1, Title: r.name}); err != nil {
+		return err
+	}
+	return r.err
+}
+
+func (r *namedRepository) UpdateTask(_ context.Context, task *models.Task) (*models.Task, error) {
+	r.writes++
+	return task, nil
+}
+
+func (r *namedRepository) SearchTasks(_ context.Context, _ models.TaskSearch) ([]*models.SearchHit, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []*models.SearchHit{{Task: &models.Task{Title: r.name}}}, nil
+}
+
+// newReplicaSet returns a set of replicas with the given lags in seconds, nil for replicas that do not
+// stream from the primary, or errors for unreachable ones, as measured by a first check.
+func newReplicaSet(t *testing.T, routing ReplicaRouting, lags ...any) (*ReplicaSet, []sqlmock.Sqlmock) {
+	t.Helper()
+	var dbs []*sql.DB
+	var mocks []sqlmock.Sqlmock
+	for _, lag := range lags {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		expectLag(mock, lag)
+		dbs = append(dbs, db)
+		mocks = append(mocks, mock)
+	}
+
+	set := NewReplicaSet(dbs, routing, zap.NewNop())
+	set.CheckOnce(context.Background())
+	for _, mock := range mocks {
+		require.NoError(t, mock.ExpectationsWereMet())
+	}
+	return set, mocks
+}
+
+func expectLag(mock sqlmock.Sqlmock, lag any) {
+	query := mock.ExpectQuery("SELECT CASE WHEN NOT pg_is_in_recovery()")
+	if err, ok := lag.(error); ok {
+		query.WillReturnError(err)
+	} else {
+		query.WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(lag))
+	}
+}
+
+func newReplicatedRepository(set *ReplicaSet, replicas int) (*ReplicatedTaskRepository, *namedRepository, []*namedRepository) {
+	primary := &namedRepository{name: "primary"}
+	var named []*namedRepository
+	var repos []repository.TaskRepository
+	for i := 0; i < replicas; i++ {
+		replica := &namedRepository{name: fmt.Sprintf("replica %d", i)}
+		named = append(named, replica)
+		repos = append(repos, replica)
+	}
+	return NewReplicatedTaskRepository(primary, repos, set), primary, named
+}
+
+var testRouting = ReplicaRouting{MaxLag: 5 * time.Second, ReadYourWrites: 10 * time.Second, CheckInterval: time.Second}
+
+func readFrom(t *testing.T, repo repository.TaskRepository, ctx context.Context) string {
+	t.Helper()
+	task, err := repo.GetTask(ctx, 1)
+	require.NoError(t, err)
+	return task.Title
+}
+
+func TestReplicatedTaskRepository_SpreadsReads(t *testing.T) {
+	set, _ := newReplicaSet(t, testRouting, 0.0, 1.5)
+	repo, _, _ := newReplicatedRepository(set, 2)
+
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[readFrom(t, repo, context.Background())]++
+	}
+	assert.Equal(t, map[string]int{"replica 0": 2, "replica 1": 2}, seen)
+}
+
+func TestReplicatedTaskRepository_AvoidsUnhealthyReplicas(t *testing.T) {
+	set, mocks := newReplicaSet(t, testRouting, 30.0, errors.New("connection refused"))
+	repo, _, _ := newReplicatedRepository(set, 2)
+	ctx := context.Background()
+
+	assert.Equal(t, "primary", readFrom(t, repo, ctx), "lagging and unreachable replicas serve no reads")
+
+	expectLag(mocks[0], 2.0)
+	expectLag(mocks[1], errors.New("connection refused"))
+	set.CheckOnce(ctx)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "replica 0", readFrom(t, repo, ctx))
+	}
+}
+
+func TestReplicatedTaskRepository_AvoidsReplicasWithoutUpstream(t *testing.T) {
+	set, mocks := newReplicaSet(t, testRouting, 0.0)
+	repo, _, _ := newReplicatedRepository(set, 1)
+	ctx := context.Background()
+	assert.Equal(t, "replica 0", readFrom(t, repo, ctx))
+
+	expectLag(mocks[0], nil)
+	set.CheckOnce(ctx)
+	assert.Equal(t, "primary", readFrom(t, repo, ctx), "a replica that lost the primary falls behind unnoticed")
+}
+
+func TestReplicatedTaskRepository_ReadYourWrites(t *testing.T) {
+	set, _ := newReplicaSet(t, testRouting, 0.0)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	set.now = func() time.Time { return now }
+	repo, primary, _ := newReplicatedRepository(set, 1)
+	alice := consistency.WithCaller(context.Background(), "alice")
+	bob := consistency.WithCaller(context.Background(), "bob")
+
+	_, err := repo.UpdateTask(alice, &models.Task{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.writes)
+
+	assert.Equal(t, "primary", readFrom(t, repo, alice), "the writer reads its write")
+	assert.Equal(t, "replica 0", readFrom(t, repo, bob))
+	assert.Equal(t, "primary", readFrom(t, repo, consistency.WithLatest(bob)))
+
+	now = now.Add(testRouting.ReadYourWrites)
+	assert.Equal(t, "replica 0", readFrom(t, repo, alice), "the window has passed")
+}
+
+func TestReplicatedTaskRepository_FallsBackOnFailure(t *testing.T) {
+	set, mocks := newReplicaSet(t, testRouting, 0.0)
+	repo, _, replicas := newReplicatedRepository(set, 1)
+	ctx := context.Background()
+
+	replicas[0].err = sql.ErrNoRows
+	_, err := repo.GetTask(ctx, 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "a missing task is not a failure of the replica")
+
+	replicas[0].err = &pq.Error{Code: "42703", Message: "column does not exist"}
+	_, err = repo.GetTask(ctx, 1)
+	assert.Error(t, err, "a refused query is not retried")
+
+	replicas[0].err = &pq.Error{Code: "40001", Message: "canceling statement due to conflict with recovery"}
+	assert.Equal(t, "primary", readFrom(t, repo, ctx))
+
+	replicas[0].err = nil
+	assert.Equal(t, "primary", readFrom(t, repo, ctx), "the failed replica waits for the next check")
+	expectLag(mocks[0], 0.0)
+	set.CheckOnce(ctx)
+	assert.Equal(t, "replica 0", readFrom(t, repo, ctx))
+}
+
+func TestReplicatedTaskRepository_ExportTasks(t *testing.T) {
+	export := func(repo repository.TaskRepository) ([]string, error) {
+		var titles []string
+		err := repo.ExportTasks(context.Background(), models.TaskFilter{}, func(task *models.Task) error {
+			titles = append(titles, task.Title)
+			return nil
+		})
+		return titles, err
+	}
+
+	set, _ := newReplicaSet(t, testRouting, 0.0)
+	repo, _, replicas := newReplicatedRepository(set, 1)
+	titles, err := export(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"replica 0"}, titles)
+
+	// Tasks already passed on cannot be taken back, so a replica failing midway fails the export.
+	replicas[0].err = driver.ErrBadConn
+	titles, err = export(repo)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, []string{"replica 0"}, titles)
+}
+
+func TestReplicatedTaskSearcher(t *testing.T) {
+	set, _ := newReplicaSet(t, testRouting, 0.0)
+	replica := &namedRepository{name: "replica 0"}
+	searcher := NewReplicatedTaskSearcher(&namedRepository{name: "primary"}, []repository.TaskSearcher{replica}, set)
+	ctx := context.Background()
+
+	hits, err := searcher.SearchTasks(ctx, models.TaskSearch{Query: "report"})
+	require.NoError(t, err)
+	assert.Equal(t, "replica 0", hits[0].Task.Title)
+
+	replica.err = driver.ErrBadConn
+	hits, err = searcher.SearchTasks(ctx, models.TaskSearch{Query: "report"})
+	require.NoError(t, err)
+	assert.Equal(t, "primary", hits[0].Task.Title)
+}
+
+func TestReplicaSet_ForgetsOldWrites(t *testing.T) {
+	set, mocks := newReplicaSet(t, testRouting, 0.0)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	set.now = func() time.Time { return now }
+
+	set.Wrote(consistency.WithCaller(context.Background(), "alice"))
+	now = now.Add(time.Minute)
+	set.Wrote(consistency.WithCaller(context.Background(), "bob"))
+	expectLag(mocks[0], 0.0)
+	set.CheckOnce(context.Background())
+
+	assert.Equal(t, []string{"bob"}, func() []string {
+		var callers []string
+		for caller := range set.writes {
+			callers = append(callers, caller)
+		}
+		return callers
+	}())
+}
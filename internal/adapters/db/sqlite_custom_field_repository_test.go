@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteCustomFieldRepository(t *testing.T) {
+	db := openSQLite(t)
+	projects := NewSQLiteProjectRepository(db, zap.NewNop())
+	repo := NewSQLiteCustomFieldRepository(db, zap.NewNop())
+	project, err := projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	require.NoError(t, err)
+	maxValue := 10.0
+
+	field, err := repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeNumber, Max: &maxValue})
+	require.NoError(t, err)
+	_, err = repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeString})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	_, err = repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "area", Type: models.FieldTypeEnum, Options: []string{"api", "ui"}})
+	require.NoError(t, err)
+
+	updated, err := repo.UpdateCustomField(context.Background(), &models.CustomField{ID: field.ID, Type: models.FieldTypeNumber, Required: true})
+	require.NoError(t, err)
+	assert.True(t, updated.CreatedAt.Equal(field.CreatedAt))
+	_, err = repo.UpdateCustomField(context.Background(), &models.CustomField{ID: 99})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := repo.GetCustomField(context.Background(), field.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Required)
+	assert.Nil(t, got.Max)
+
+	fields, err := repo.ListCustomFields(context.Background(), project.ID)
+	require.NoError(t, err)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "area", fields[0].Name)
+	assert.Equal(t, []string{"api", "ui"}, fields[0].Options)
+	assert.Equal(t, "estimate", fields[1].Name)
+}
+
+func TestSQLiteCustomFieldRepository_Values(t *testing.T) {
+	db := openSQLite(t)
+	projects := NewSQLiteProjectRepository(db, zap.NewNop())
+	repo := NewSQLiteCustomFieldRepository(db, zap.NewNop())
+	tasks := NewSQLiteTaskRepository(db, projects, zap.NewNop())
+	ctx := context.Background()
+
+	project, err := projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	require.NoError(t, err)
+	field, err := repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeNumber})
+	require.NoError(t, err)
+	estimated, err := tasks.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Status: "open",
+		CustomFields: map[string]models.FieldValue{
+			"estimate": {Type: models.FieldTypeNumber, Number: 3},
+			"area":     {Type: models.FieldTypeString, String: "api"},
+		}})
+	require.NoError(t, err)
+	unestimated, err := tasks.CreateTask(ctx, &models.Task{ExternalID: "b", ProjectID: project.ID, Status: "open"})
+	require.NoError(t, err)
+
+	values, err := repo.ListFieldValues(context.Background(), project.ID, "estimate")
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	assert.Equal(t, estimated.ID, values[0].TaskID)
+	assert.Equal(t, "OPS-1", values[0].TaskKey)
+	require.NotNil(t, values[0].Value)
+	assert.Equal(t, 3.0, values[0].Value.Number)
+	assert.Equal(t, unestimated.ID, values[1].TaskID)
+	assert.Nil(t, values[1].Value)
+
+	require.NoError(t, repo.DeleteCustomField(context.Background(), field.ID))
+	assert.ErrorIs(t, repo.DeleteCustomField(context.Background(), field.ID), sql.ErrNoRows)
+	got, err := tasks.GetTask(ctx, estimated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]models.FieldValue{"area": {Type: models.FieldTypeString, String: "api"}}, got.CustomFields,
+		"deleting a field removes only its values")
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap"
+)
+
+// SQLiteIdempotencyRepository stores idempotency records in the SQLite database of the SQLite task store.
+type SQLiteIdempotencyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteIdempotencyRepository(db *sql.DB, logger *zap.Logger) *SQLiteIdempotencyRepository {
+	return &SQLiteIdempotencyRepository{db: db, logger: logger}
+}
+
+// ClaimKey relies on the primary key of idempotency_keys like the Postgres idempotency repository.
+func (r *SQLiteIdempotencyRepository) ClaimKey(ctx context.Context, record *models.IdempotencyRecord,
+	staleBefore time.Time) (*models.IdempotencyRecord, error) {
+	claim := `
+		INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = excluded.request_hash, response_type = NULL, response = NULL,
+			created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= excluded.created_at
+			OR (idempotency_keys.response_type IS NULL AND idempotency_keys.created_at < $5)
+		RETURNING created_at;
+	`
+	holder := `
+		SELECT key, request_hash, COALESCE(response_type, ''), response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+
+	var stale sql.NullInt64
+	if !staleBefore.IsZero() {
+		stale = sql.NullInt64{Int64: staleBefore.UnixNano(), Valid: true}
+	}
+
+	for attempt := 0; attempt < claimAttempts; attempt++ {
+		var createdAt int64
+		err := r.db.QueryRowContext(ctx, claim,
+			record.Key, record.RequestHash, record.CreatedAt.UnixNano(), record.ExpiresAt.UnixNano(), stale,
+		).Scan(&createdAt)
+		if err == nil {
+			record.CreatedAt = time.Unix(0, createdAt)
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Error("Failed to claim idempotency key", zap.Error(err))
+			return nil, err
+		}
+
+		var existing models.IdempotencyRecord
+		var existingCreatedAt, existingExpiresAt int64
+		err = r.db.QueryRowContext(ctx, holder, record.Key).Scan(&existing.Key, &existing.RequestHash,
+			&existing.ResponseType, &existing.Response, &existingCreatedAt, &existingExpiresAt)
+		if err == nil {
+			existing.CreatedAt = time.Unix(0, existingCreatedAt)
+			existing.ExpiresAt = time.Unix(0, existingExpiresAt)
+			return &existing, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.logger.Error("Failed to fetch idempotency key", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("idempotency key changed hands too often to be claimed")
+}
+
+func (r *SQLiteIdempotencyRepository) CompleteKey(ctx context.Context, claim *models.IdempotencyRecord, responseType string,
+	response []byte) error {
+	query := `
+		UPDATE idempotency_keys SET response_type = $1, response = $2
+		WHERE key = $3 AND created_at = $4 AND request_hash = $5 AND response_type IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, responseType, response, claim.Key, claim.CreatedAt.UnixNano(), claim.RequestHash)
+	if err != nil {
+		r.logger.Error("Failed to complete idempotency key", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *SQLiteIdempotencyRepository) ReleaseKey(ctx context.Context, claim *models.IdempotencyRecord) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND created_at = $2 AND request_hash = $3 AND response_type IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, claim.Key, claim.CreatedAt.UnixNano(), claim.RequestHash)
+	if err != nil {
+		r.logger.Error("Failed to release idempotency key", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteIdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now.UnixNano())
+	if err != nil {
+		r.logger.Error("Failed to delete expired idempotency keys", zap.Error(err))
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteIdempotencyRepository(t *testing.T) {
+	repo := NewSQLiteIdempotencyRepository(openSQLite(t), zap.NewNop())
+	ctx := context.Background()
+	now := time.Now()
+	claim := func(createdAt time.Time) *models.IdempotencyRecord {
+		return &models.IdempotencyRecord{Key: "k", RequestHash: []byte("hash"), CreatedAt: createdAt, ExpiresAt: createdAt.Add(time.Hour)}
+	}
+
+	first := claim(now)
+	holder, err := repo.ClaimKey(ctx, first, time.Time{})
+	require.NoError(t, err)
+	assert.Nil(t, holder)
+
+	holder, err = repo.ClaimKey(ctx, claim(now.Add(time.Minute)), now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, holder, "fresh claims are held")
+	assert.False(t, holder.Completed())
+	assert.True(t, holder.CreatedAt.Equal(first.CreatedAt))
+
+	taken := claim(now.Add(2 * time.Minute))
+	holder, err = repo.ClaimKey(ctx, taken, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, holder, "stale claims are taken over")
+	assert.ErrorIs(t, repo.CompleteKey(ctx, first, "Task", []byte("response")), sql.ErrNoRows)
+	require.NoError(t, repo.ReleaseKey(ctx, first))
+
+	require.NoError(t, repo.CompleteKey(ctx, taken, "Task", []byte("response")))
+	holder, err = repo.ClaimKey(ctx, claim(now.Add(3*time.Minute)), now.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, holder, "completed records are never stale")
+	assert.Equal(t, "Task", holder.ResponseType)
+	assert.Equal(t, []byte("response"), holder.Response)
+	require.NoError(t, repo.ReleaseKey(ctx, taken))
+
+	deleted, err := repo.DeleteExpiredKeys(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	deleted, err = repo.DeleteExpiredKeys(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	holder, err = repo.ClaimKey(ctx, claim(now), time.Time{})
+	require.NoError(t, err)
+	assert.Nil(t, holder, "expired keys can be claimed again")
+}
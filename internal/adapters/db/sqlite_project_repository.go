@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// SQLiteProjectRepository stores projects in the SQLite database of the SQLite task store.
+type SQLiteProjectRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteProjectRepository(db *sql.DB, logger *zap.Logger) *SQLiteProjectRepository {
+	return &SQLiteProjectRepository{db: db, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *SQLiteProjectRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *SQLiteProjectRepository) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	query := `
+		INSERT INTO projects (key, name, description, workflow, default_assignee, allowed_labels, archived, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id;
+	`
+
+	workflow, allowedLabels, err := encodeSQLiteProjectLists(project)
+	if err != nil {
+		r.logger.Error("Failed to encode project", zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	project.CreatedAt = now
+	project.UpdatedAt = now
+
+	err = r.conn(ctx).QueryRowContext(ctx, query,
+		project.Key, project.Name, project.Description, workflow, project.DefaultAssignee, allowedLabels, project.Archived,
+		now.UnixNano(), now.UnixNano(),
+	).Scan(&project.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to create project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (r *SQLiteProjectRepository) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects ORDER BY id`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to list projects", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []*models.Project
+	for rows.Next() {
+		project, err := scanSQLiteProject(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan project", zap.Error(err))
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (r *SQLiteProjectRepository) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	project, err := scanSQLiteProject(r.conn(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (r *SQLiteProjectRepository) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	query := `
+		UPDATE projects
+		SET name = $1, description = $2, workflow = $3, default_assignee = $4, allowed_labels = $5,
+		    archived = $6, updated_at = $7
+		WHERE id = $8
+		RETURNING key, created_at;
+	`
+
+	workflow, allowedLabels, err := encodeSQLiteProjectLists(project)
+	if err != nil {
+		r.logger.Error("Failed to encode project", zap.Error(err))
+		return nil, err
+	}
+
+	project.UpdatedAt = time.Now()
+
+	var createdAt int64
+	err = r.conn(ctx).QueryRowContext(ctx, query,
+		project.Name, project.Description, workflow, project.DefaultAssignee, allowedLabels, project.Archived,
+		project.UpdatedAt.UnixNano(), project.ID,
+	).Scan(&project.Key, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to update project", zap.Error(err))
+		return nil, err
+	}
+	project.CreatedAt = time.Unix(0, createdAt)
+
+	return project, nil
+}
+
+// DeleteProject deletes the custom fields, saved views and task templates of the project with it. The
+// transaction holds the write lock of the database from its start, so no task is created in the project
+// between the check for tasks and the deletion.
+func (r *SQLiteProjectRepository) DeleteProject(ctx context.Context, id int64) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var hasTasks bool
+	err = tx.QueryRowContext(ctx, projectHasTasksQuery, id).Scan(&hasTasks)
+	if err != nil {
+		r.logger.Error("Failed to check project tasks", zap.Error(err))
+		return err
+	}
+	if hasTasks {
+		return repository.ErrNotEmpty
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete project", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// encodeSQLiteProjectLists encodes the workflow and allowed labels of a project for SQLite.
+func encodeSQLiteProjectLists(project *models.Project) (workflow, allowedLabels string, err error) {
+	if workflow, err = encodeSQLiteStrings(project.Workflow); err != nil {
+		return "", "", err
+	}
+	if allowedLabels, err = encodeSQLiteStrings(project.AllowedLabels); err != nil {
+		return "", "", err
+	}
+	return workflow, allowedLabels, nil
+}
+
+// scanSQLiteProject reads a row selected with projectColumns from the SQLite schema.
+func scanSQLiteProject(row rowScanner) (*models.Project, error) {
+	var project models.Project
+	var workflow, allowedLabels string
+	var createdAt, updatedAt int64
+	err := row.Scan(&project.ID, &project.Key, &project.Name, &project.Description, &workflow, &project.DefaultAssignee,
+		&allowedLabels, &project.Archived, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if project.Workflow, err = decodeSQLiteStrings(workflow); err != nil {
+		return nil, err
+	}
+	if project.AllowedLabels, err = decodeSQLiteStrings(allowedLabels); err != nil {
+		return nil, err
+	}
+	project.CreatedAt = time.Unix(0, createdAt)
+	project.UpdatedAt = time.Unix(0, updatedAt)
+	return &project, nil
+}
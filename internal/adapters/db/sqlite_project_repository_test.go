@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteProjectRepository(t *testing.T) {
+	db := openSQLite(t)
+	repo := NewSQLiteProjectRepository(db, zap.NewNop())
+
+	project, err := repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops", Workflow: []string{"open", "done"}})
+	require.NoError(t, err)
+	_, err = repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Other"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	project.Name = "Operations"
+	project.AllowedLabels = []string{"bug"}
+	project.Archived = true
+	_, err = repo.UpdateProject(context.Background(), project)
+	require.NoError(t, err)
+	_, err = repo.UpdateProject(context.Background(), &models.Project{ID: 99})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := repo.GetProject(context.Background(), project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "OPS", got.Key)
+	assert.Equal(t, "Operations", got.Name)
+	assert.Equal(t, []string{"open", "done"}, got.Workflow)
+	assert.Equal(t, []string{"bug"}, got.AllowedLabels)
+	assert.True(t, got.Archived)
+	assert.True(t, got.CreatedAt.Equal(project.CreatedAt))
+
+	projects, err := repo.ListProjects(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, projects, 1)
+}
+
+func TestSQLiteProjectRepository_UnitOfWork(t *testing.T) {
+	db := openSQLite(t)
+	repo := NewSQLiteProjectRepository(db, zap.NewNop())
+	fields := NewSQLiteCustomFieldRepository(db, zap.NewNop())
+	tasks := NewSQLiteTaskRepository(db, repo, zap.NewNop())
+	transactor := NewSQLTransactor(db, nil, 1, zap.NewNop())
+
+	// The write lock of the unit of work is only shared by calls that run in its transaction.
+	failed := errors.New("failed")
+	err := transactor.InTx(context.Background(), func(ctx context.Context) error {
+		project, err := repo.CreateProject(ctx, &models.Project{Key: "OPS", Name: "Ops"})
+		require.NoError(t, err)
+		_, err = fields.CreateCustomField(ctx, &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeNumber})
+		require.NoError(t, err)
+		task, err := tasks.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Status: "open"})
+		require.NoError(t, err)
+		assert.Equal(t, "OPS-1", task.Key)
+		return failed
+	})
+	require.ErrorIs(t, err, failed)
+
+	projects, err := repo.ListProjects(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestSQLiteProjectRepository_DeleteProject(t *testing.T) {
+	db := openSQLite(t)
+	repo := NewSQLiteProjectRepository(db, zap.NewNop())
+	fields := NewSQLiteCustomFieldRepository(db, zap.NewNop())
+	views := NewSQLiteSavedViewRepository(db, zap.NewNop())
+
+	project, err := repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	require.NoError(t, err)
+	field, err := fields.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeNumber})
+	require.NoError(t, err)
+	view, err := views.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "mine", ProjectID: project.ID})
+	require.NoError(t, err)
+
+	tasks := NewSQLiteTaskRepository(db, repo, zap.NewNop())
+	task, err := tasks.CreateTask(context.Background(), &models.Task{ExternalID: "a", ProjectID: project.ID, Status: "open"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.DeleteProject(context.Background(), project.ID), repository.ErrNotEmpty)
+	require.NoError(t, tasks.DeleteTask(context.Background(), task.ID))
+
+	require.NoError(t, repo.DeleteProject(context.Background(), project.ID))
+	assert.ErrorIs(t, repo.DeleteProject(context.Background(), project.ID), sql.ErrNoRows)
+	_, err = fields.GetCustomField(context.Background(), field.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "custom fields go away with their project")
+	_, err = views.GetSavedView(context.Background(), view.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "saved views go away with their project")
+}
+
+func TestSQLiteProjectRepository_DeleteProject_ArchivedTasks(t *testing.T) {
+	db := openSQLite(t)
+	repo := NewSQLiteProjectRepository(db, zap.NewNop())
+	tasks := NewSQLiteTaskRepository(db, repo, zap.NewNop())
+
+	project, err := repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	require.NoError(t, err)
+	_, err = tasks.CreateTask(context.Background(), &models.Task{ExternalID: "a", ProjectID: project.ID, Status: "done"})
+	require.NoError(t, err)
+
+	ids, err := tasks.ArchiveTasks(context.Background(), models.Column{ProjectID: project.ID, Status: "done"}, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.ErrorIs(t, repo.DeleteProject(context.Background(), project.ID), repository.ErrNotEmpty)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteSavedViewRepository(t *testing.T) {
+	db := openSQLite(t)
+	projects := NewSQLiteProjectRepository(db, zap.NewNop())
+	repo := NewSQLiteSavedViewRepository(db, zap.NewNop())
+	project, err := projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	require.NoError(t, err)
+
+	view, err := repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "open", ProjectID: project.ID, Filter: `status = "open"`,
+		OrderBy: &models.FieldOrder{Field: "created_at", Descending: true}, Columns: []string{"key", "title"}})
+	require.NoError(t, err)
+	_, err = repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "open"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	shared, err := repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "bob", Name: "all", Shared: true})
+	require.NoError(t, err)
+	_, err = repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "bob", Name: "private"})
+	require.NoError(t, err)
+
+	got, err := repo.GetSavedView(context.Background(), view.ID)
+	require.NoError(t, err)
+	assert.Equal(t, project.ID, got.ProjectID)
+	assert.Equal(t, &models.FieldOrder{Field: "created_at", Descending: true}, got.OrderBy)
+	assert.Equal(t, []string{"key", "title"}, got.Columns)
+
+	views, err := repo.ListSavedViews(context.Background(), "alice", 0)
+	require.NoError(t, err)
+	require.Len(t, views, 2, "the own and the shared views")
+	assert.Equal(t, shared.ID, views[0].ID)
+	views, err = repo.ListSavedViews(context.Background(), "alice", project.ID)
+	require.NoError(t, err)
+	assert.Len(t, views, 1)
+
+	updated, err := repo.UpdateSavedView(context.Background(), &models.SavedView{ID: view.ID, Owner: "mallory", Name: "mine"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", updated.Owner)
+	assert.True(t, updated.CreatedAt.Equal(view.CreatedAt))
+	_, err = repo.UpdateSavedView(context.Background(), &models.SavedView{ID: shared.ID, Name: "private"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	_, err = repo.UpdateSavedView(context.Background(), &models.SavedView{ID: 99})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, repo.DeleteSavedView(context.Background(), view.ID))
+	assert.ErrorIs(t, repo.DeleteSavedView(context.Background(), view.ID), sql.ErrNoRows)
+}
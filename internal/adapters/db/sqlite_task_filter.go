@@ -0,0 +1,211 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// The functions below compile task filters for the SQLite schema like their Postgres counterparts do,
+// with labels and custom fields stored as JSON. SQLite compares text byte-wise, like the "C" collation,
+// and its LIKE ignores case, though only for ASCII letters.
+
+// sqliteListTasksQuery builds the query selecting the tasks matching filter in ListTasks order, from the
+// live and the archived tasks if filter includes archived tasks.
+func sqliteListTasksQuery(filter models.TaskFilter) (string, []any, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks`
+	if filter.IncludeArchived {
+		query = `SELECT ` + archivedTaskColumns + ` FROM ` + tasksWithArchive
+	}
+	var conditions []string
+	var args []any
+	if filter.ProjectID != 0 {
+		args = append(args, filter.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	for _, condition := range filter.CustomFields {
+		var sqlCondition string
+		var err error
+		sqlCondition, args, err = sqliteCustomFieldCondition(condition, args)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, sqlCondition)
+	}
+	if filter.Where != nil {
+		var sqlCondition string
+		var err error
+		sqlCondition, args, err = sqliteFilterCondition(filter.Where, args)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, sqlCondition)
+	}
+
+	orderBy := `rank, external_id`
+	var sortExpr string
+	if filter.OrderBy != nil {
+		args = append(args, filter.OrderBy.Field)
+		sortExpr = sqliteCustomFieldExpr(filter.OrderBy.Type, len(args))
+		direction := "ASC"
+		if filter.OrderBy.Descending {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf("%s %s NULLS LAST, %s", sortExpr, direction, orderBy)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Rank, filter.After.ExternalID)
+		tieBreak := fmt.Sprintf("(rank, external_id) > ($%d, $%d)", len(args)-1, len(args))
+		switch {
+		case sortExpr == "":
+			conditions = append(conditions, tieBreak)
+		case filter.After.SortValue == nil:
+			conditions = append(conditions, fmt.Sprintf("(%s IS NULL AND %s)", sortExpr, tieBreak))
+		default:
+			args = append(args, filter.After.SortValue)
+			comparison := ">"
+			if filter.OrderBy.Descending {
+				comparison = "<"
+			}
+			conditions = append(conditions, fmt.Sprintf("(%s %s $%d OR %s IS NULL OR (%s = $%d AND %s))",
+				sortExpr, comparison, len(args), sortExpr, sortExpr, len(args), tieBreak))
+		}
+	}
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY ` + orderBy
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return query, args, nil
+}
+
+// sqliteFilterCondition compiles a checked filter expression like filterCondition.
+func sqliteFilterCondition(expr *models.FilterExpr, args []any) (string, []any, error) {
+	switch expr.Logic {
+	case models.FilterAnd, models.FilterOr:
+		parts := make([]string, 0, len(expr.Operands))
+		for _, operand := range expr.Operands {
+			var part string
+			var err error
+			part, args, err = sqliteFilterCondition(operand, args)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, " "+expr.Logic+" ") + ")", args, nil
+	case models.FilterNot:
+		if len(expr.Operands) != 1 {
+			return "", nil, fmt.Errorf("NOT takes one operand, got %d", len(expr.Operands))
+		}
+		operand, args, err := sqliteFilterCondition(expr.Operands[0], args)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("NOT COALESCE(%s, false)", operand), args, nil
+	case "":
+	default:
+		return "", nil, fmt.Errorf("unsupported filter logic %q", expr.Logic)
+	}
+
+	if expr.Custom {
+		return sqliteCustomFieldFilterCondition(expr, args)
+	}
+
+	column, ok := filterColumns[expr.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported filter field %q", expr.Field)
+	}
+	value := expr.Value
+	if timestamp, ok := value.(time.Time); ok {
+		value = timestamp.UnixNano()
+	}
+	switch {
+	case expr.Operator == ":" && expr.Value == nil:
+		switch expr.Type {
+		case models.FilterTypeList:
+			return fmt.Sprintf("json_array_length(%s) > 0", column), args, nil
+		case models.FilterTypeString:
+			return fmt.Sprintf("COALESCE(%s, '') <> ''", column), args, nil
+		default:
+			return fmt.Sprintf("%s IS NOT NULL", column), args, nil
+		}
+	case expr.Operator == ":" && expr.Type == models.FilterTypeList:
+		args = append(args, value)
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE value = $%d)", column, len(args)), args, nil
+	case expr.Operator == ":":
+		text, ok := value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("\":\" on %s needs a text value", expr.Field)
+		}
+		args = append(args, "%"+likeEscaper.Replace(text)+"%")
+		return fmt.Sprintf(`%s LIKE $%d ESCAPE '\'`, column, len(args)), args, nil
+	case expr.Operator == "!=":
+		// Tasks without a key or project differ from any given one.
+		args = append(args, value)
+		return fmt.Sprintf("%s IS NOT $%d", column, len(args)), args, nil
+	case fieldOperators[expr.Operator]:
+		args = append(args, value)
+		return fmt.Sprintf("%s %s $%d", column, expr.Operator, len(args)), args, nil
+	}
+	return "", nil, fmt.Errorf("unsupported filter operator %q", expr.Operator)
+}
+
+// sqliteCustomFieldFilterCondition compiles a restriction on a custom field like customFieldFilterCondition.
+func sqliteCustomFieldFilterCondition(expr *models.FilterExpr, args []any) (string, []any, error) {
+	if expr.Operator == ":" && expr.Value == nil {
+		args = append(args, expr.Field)
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(custom_fields) WHERE key = $%d)", len(args)), args, nil
+	}
+
+	value, ok := expr.Value.(models.FieldValue)
+	if !ok {
+		return "", nil, fmt.Errorf("custom field %s needs a field value", expr.Field)
+	}
+	if expr.Operator == ":" {
+		args = append(args, expr.Field, "%"+likeEscaper.Replace(value.String)+"%")
+		return fmt.Sprintf(`%s LIKE $%d ESCAPE '\'`, sqliteCustomFieldExpr(models.FieldTypeString, len(args)-1), len(args)), args, nil
+	}
+	return sqliteCustomFieldCondition(models.FieldCondition{Field: expr.Field, Type: expr.Type, Operator: expr.Operator, Value: value}, args)
+}
+
+// sqliteCustomFieldExpr returns the SQL expression for the custom field whose name is bound to parameter n,
+// typed like customFieldExpr: numbers for number fields and the text of the value for all other fields.
+func sqliteCustomFieldExpr(fieldType string, n int) string {
+	if fieldType == models.FieldTypeNumber {
+		return fmt.Sprintf("(SELECT value FROM json_each(custom_fields) WHERE key = $%d AND type IN ('integer', 'real'))", n)
+	}
+	return fmt.Sprintf("(SELECT CAST(value AS TEXT) FROM json_each(custom_fields) WHERE key = $%d)", n)
+}
+
+// sqliteCustomFieldCondition builds the SQL condition for one custom field condition like customFieldCondition.
+// Equality only holds for a stored value of the same JSON type, as with containment in Postgres.
+func sqliteCustomFieldCondition(condition models.FieldCondition, args []any) (string, []any, error) {
+	if !fieldOperators[condition.Operator] {
+		return "", nil, fmt.Errorf("unsupported custom field operator %q", condition.Operator)
+	}
+	if condition.Operator == "=" {
+		args = append(args, condition.Field, condition.Value.Raw())
+		types := "'text'"
+		if condition.Type == models.FieldTypeNumber {
+			types = "'integer', 'real'"
+		}
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(custom_fields) WHERE key = $%d AND type IN (%s) AND value = $%d)",
+			len(args)-1, types, len(args)), args, nil
+	}
+
+	args = append(args, condition.Field)
+	expr := sqliteCustomFieldExpr(condition.Type, len(args))
+	args = append(args, condition.Value.Raw())
+	return fmt.Sprintf("%s %s $%d", expr, condition.Operator, len(args)), args, nil
+}
@@ -0,0 +1,1056 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// The queries below are used by the SQLite task repository only, as the shared ones rely on Postgres
+// arrays, row locks or the key counters of the projects table.
+const (
+	sqliteInsertTaskQuery = `
+		INSERT INTO tasks (external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, parent_id,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id;
+	`
+	sqliteNextTaskNumberQuery = `
+		INSERT INTO task_counters (project_id, counter) VALUES ($1, $2)
+		ON CONFLICT (project_id) DO UPDATE SET counter = counter + excluded.counter
+		RETURNING counter;
+	`
+	sqliteDeleteTasksQuery  = `DELETE FROM tasks WHERE id IN (SELECT value FROM json_each($1))`
+	sqliteRecordImportQuery = `INSERT INTO task_imports (source, source_id, task_id, imported_at) VALUES ($1, $2, $3, $4)`
+	sqliteImportedIDsQuery  = `
+		SELECT source_id FROM task_imports WHERE source = $1 AND source_id IN (SELECT value FROM json_each($2))
+		UNION ALL
+		SELECT source_id FROM task_imports_archive WHERE source = $1 AND source_id IN (SELECT value FROM json_each($2))
+	`
+	sqliteTaskKeyQuery = `SELECT key FROM tasks WHERE id = $1`
+	// The database of the SQLite store runs within the server and shares its clock, so the timestamps
+	// of tasks are passed in as Unix nanoseconds rather than taken by the database as in Postgres.
+	sqliteUpdateTaskQuery = `
+		UPDATE tasks
+		SET title = $1, description = $2, status = $3, assignee = $4, labels = $5, rank = $6, custom_fields = $7, updated_at = $8
+		WHERE id = $9
+		RETURNING external_id, project_id, key, created_at, parent_id;
+	`
+	sqliteTransferTaskQuery = `
+		UPDATE tasks
+		SET project_id = $1, key = $2, status = $3, rank = $4, custom_fields = $5, updated_at = $6
+		WHERE id = $7
+		RETURNING ` + taskColumns + `;
+	`
+	sqliteMoveTaskQuery = `
+		UPDATE tasks
+		SET status = $1, rank = $2, updated_at = $3
+		WHERE id = $4
+		RETURNING ` + taskColumns + `;
+	`
+	sqliteColumnTaskIDsQuery = `
+		SELECT id FROM tasks
+		WHERE project_id IS NOT DISTINCT FROM $1 AND status = $2
+		ORDER BY rank, external_id
+	`
+	sqliteArchiveCandidatesQuery = `
+		SELECT id FROM tasks
+		WHERE project_id IS NOT DISTINCT FROM $1 AND status = $2 AND updated_at < $3
+		ORDER BY updated_at
+		LIMIT $4
+	`
+	sqliteArchiveTasksQuery = `
+		INSERT INTO tasks_archive (` + archivedTaskColumns + `)
+		SELECT ` + taskColumns + `, $1 FROM tasks WHERE id IN (SELECT value FROM json_each($2))
+	`
+	sqliteArchiveKeyAliasesQuery = `
+		INSERT INTO task_key_aliases_archive (key, task_id)
+		SELECT key, task_id FROM task_key_aliases WHERE task_id IN (SELECT value FROM json_each($1))
+	`
+	sqliteArchiveImportsQuery = `
+		INSERT INTO task_imports_archive (source, source_id, task_id, imported_at)
+		SELECT source, source_id, task_id, imported_at FROM task_imports WHERE task_id IN (SELECT value FROM json_each($1))
+	`
+	sqliteSetTaskParentQuery  = `UPDATE tasks SET parent_id = $1, updated_at = $2 WHERE id = $3 RETURNING ` + taskColumns
+	sqliteDetachSubtasksQuery = `
+		UPDATE tasks SET parent_id = NULL, updated_at = $1
+		WHERE parent_id IN (SELECT value FROM json_each($2))
+		RETURNING id
+	`
+	sqliteCreateTaskRelationQuery = `
+		INSERT INTO task_relations (task_id, related_task_id, type) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+	sqliteClearTaskChecklistQuery   = `DELETE FROM task_checklist_items WHERE task_id = $1`
+	sqliteInsertChecklistItemQuery  = `INSERT INTO task_checklist_items (task_id, position, text, done) VALUES ($1, $2, $3, $4)`
+	sqliteCreateTaskCommentQuery    = `INSERT INTO task_comments (task_id, author, body, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	sqliteCreateTaskAttachmentQuery = `
+		INSERT INTO task_attachments (task_id, name, url, content_type, size, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	sqliteCopyTaskChecklistQuery = `
+		INSERT INTO task_checklist_items (task_id, position, text, done)
+		SELECT $2, position, text, done FROM task_checklist_items WHERE task_id = $1
+	`
+	sqliteCopyTaskCommentsQuery = `
+		INSERT INTO task_comments (task_id, author, body, created_at)
+		SELECT $2, author, body, created_at FROM task_comments WHERE task_id = $1 ORDER BY id
+	`
+	sqliteCopyTaskAttachmentsQuery = `
+		INSERT INTO task_attachments (task_id, name, url, content_type, size, created_at)
+		SELECT $2, name, url, content_type, size, created_at FROM task_attachments WHERE task_id = $1 ORDER BY id
+	`
+)
+
+// SQLiteTaskRepository stores tasks in an SQLite database migrated with the SQLite migrations, for
+// deployments without Postgres. It asks projects for the key prefixes of projects, while the task numbers
+// of every project are counted in the SQLite database.
+//
+// Writes run in transactions that take the database's write lock when they begin, see sqlite.Config,
+// so concurrent writers queue up for the busy timeout rather than failing on lock upgrades.
+type SQLiteTaskRepository struct {
+	db       *sql.DB
+	projects repository.ProjectRepository
+	logger   *zap.Logger
+}
+
+func NewSQLiteTaskRepository(db *sql.DB, projects repository.ProjectRepository, logger *zap.Logger) *SQLiteTaskRepository {
+	return &SQLiteTaskRepository{db: db, projects: projects, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *SQLiteTaskRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *SQLiteTaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	tasks, err := r.createTasks(ctx, []*models.Task{task}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return tasks[0], nil
+}
+
+func (r *SQLiteTaskRepository) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	var tasks []*models.Task
+	err := r.ExportTasks(ctx, filter, func(task *models.Task) error {
+		tasks = append(tasks, task)
+		return nil
+	})
+	return tasks, err
+}
+
+// ExportTasks passes the tasks matching filter to each while stepping through the result rows, so the
+// tasks are never all held in memory. Thanks to write-ahead logging, each may write meanwhile.
+func (r *SQLiteTaskRepository) ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error {
+	query, args, err := sqliteListTasksQuery(filter)
+	if err != nil {
+		r.logger.Error("Invalid task filter", zap.Error(err))
+		return err
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list tasks", zap.Error(err))
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task, err := scanListedSQLiteTask(rows, filter)
+		if err != nil {
+			r.logger.Error("Failed to scan task", zap.Error(err))
+			return err
+		}
+		if err := each(task); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *SQLiteTaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	task, err := scanSQLiteTask(r.conn(ctx).QueryRowContext(ctx, getTaskQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *SQLiteTaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	tasks, err := r.UpdateTasks(ctx, []*models.Task{task})
+	if err != nil {
+		return nil, err
+	}
+	return tasks[0], nil
+}
+
+func (r *SQLiteTaskRepository) DeleteTask(ctx context.Context, id int64) error {
+	return r.DeleteTasks(ctx, []int64{id})
+}
+
+func (r *SQLiteTaskRepository) CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	return r.createTasks(ctx, tasks, nil)
+}
+
+// CreateImportedTasks creates tasks like CreateTasks and links them to their source IDs. A source ID
+// imported before fails the whole transaction with a unique violation.
+func (r *SQLiteTaskRepository) CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error) {
+	if len(sourceIDs) != len(tasks) {
+		return nil, fmt.Errorf("got %d source IDs for %d tasks", len(sourceIDs), len(tasks))
+	}
+
+	return r.createTasks(ctx, tasks, func(tx *sqlTx) error {
+		now := time.Now().UnixNano()
+		for i, task := range tasks {
+			if _, err := tx.ExecContext(ctx, sqliteRecordImportQuery, source, sourceIDs[i], task.ID, now); err != nil {
+				r.logger.Error("Failed to record imported tasks", zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLiteTaskRepository) ImportedSourceIDs(ctx context.Context, source string, sourceIDs []string) ([]string, error) {
+	encoded, err := json.Marshal(sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, sqliteImportedIDsQuery, source, string(encoded))
+	if err != nil {
+		r.logger.Error("Failed to look up imported tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imported []string
+	for rows.Next() {
+		var sourceID string
+		if err := rows.Scan(&sourceID); err != nil {
+			r.logger.Error("Failed to scan imported task", zap.Error(err))
+			return nil, err
+		}
+		imported = append(imported, sourceID)
+	}
+	return imported, rows.Err()
+}
+
+// createTasks inserts tasks in one transaction, running afterInsert, if set, in the same transaction
+// once the tasks have their IDs. The key prefixes of the projects are looked up before it begins.
+func (r *SQLiteTaskRepository) createTasks(ctx context.Context, tasks []*models.Task, afterInsert func(tx *sqlTx) error) ([]*models.Task, error) {
+	customFields := make([]string, len(tasks))
+	labels := make([]string, len(tasks))
+	for i, task := range tasks {
+		var err error
+		if customFields[i], labels[i], err = encodeSQLiteColumns(task); err != nil {
+			r.logger.Error("Failed to encode task", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	prefixes, err := r.keyPrefixes(ctx, tasks)
+	if err != nil {
+		r.logger.Error("Failed to allocate task keys", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	keys, err := sqliteTaskKeys(ctx, tx, tasks, prefixes)
+	if err != nil {
+		r.logger.Error("Failed to allocate task keys", zap.Error(err))
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, sqliteInsertTaskQuery)
+	if err != nil {
+		r.logger.Error("Failed to prepare task creation", zap.Error(err))
+		return nil, err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for i, task := range tasks {
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		err := stmt.QueryRowContext(ctx,
+			task.ExternalID, nullableID(task.ProjectID), keys[i], task.Title, task.Description, task.Status, task.Assignee, labels[i],
+			task.Rank, customFields[i], nullableID(task.ParentID), now.UnixNano(), now.UnixNano(),
+		).Scan(&task.ID)
+		if err != nil {
+			r.logger.Error("Failed to create task", zap.Error(err))
+			return nil, err
+		}
+	}
+	if afterInsert != nil {
+		if err := afterInsert(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task creation", zap.Error(err))
+		return nil, err
+	}
+	for i, task := range tasks {
+		task.Key = keys[i].String
+	}
+
+	return tasks, nil
+}
+
+// UpdateTasks applies the updates of all tasks in one transaction.
+func (r *SQLiteTaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	customFields := make([]string, len(tasks))
+	labels := make([]string, len(tasks))
+	for i, task := range tasks {
+		var err error
+		if customFields[i], labels[i], err = encodeSQLiteColumns(task); err != nil {
+			r.logger.Error("Failed to encode task", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, sqliteUpdateTaskQuery)
+	if err != nil {
+		r.logger.Error("Failed to prepare task update", zap.Error(err))
+		return nil, err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for i, task := range tasks {
+		task.UpdatedAt = now
+		var projectID, parentID sql.NullInt64
+		var key sql.NullString
+		var createdAt int64
+		err := stmt.QueryRowContext(ctx,
+			task.Title, task.Description, task.Status, task.Assignee, labels[i], task.Rank, customFields[i], now.UnixNano(), task.ID,
+		).Scan(&task.ExternalID, &projectID, &key, &createdAt, &parentID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, sql.ErrNoRows
+			}
+			r.logger.Error("Failed to update task", zap.Int64("id", task.ID), zap.Error(err))
+			return nil, err
+		}
+		task.ProjectID = projectID.Int64
+		task.ParentID = parentID.Int64
+		task.Key = key.String
+		task.CreatedAt = time.Unix(0, createdAt)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task updates", zap.Error(err))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (r *SQLiteTaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, sqliteDeleteTasksQuery, string(encoded))
+	if err != nil {
+		r.logger.Error("Failed to delete tasks", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+	if rowsAffected != int64(len(ids)) {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task deletion", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteTaskRepository) GetTaskIDByKey(ctx context.Context, key string) (int64, error) {
+	var id int64
+	err := r.conn(ctx).QueryRowContext(ctx, taskIDByKeyQuery, key).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to resolve task key", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *SQLiteTaskRepository) GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error) {
+	var id int64
+	err := r.conn(ctx).QueryRowContext(ctx, taskIDByExternalIDQuery, externalID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to resolve task external ID", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *SQLiteTaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	encodedFields, err := encodeCustomFields(customFields)
+	if err != nil {
+		r.logger.Error("Failed to encode custom fields", zap.Error(err))
+		return nil, err
+	}
+	task := &models.Task{ProjectID: projectID}
+	prefixes, err := r.keyPrefixes(ctx, []*models.Task{task})
+	if err != nil {
+		r.logger.Error("Failed to allocate task key", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldKey sql.NullString
+	if err := tx.QueryRowContext(ctx, sqliteTaskKeyQuery, id).Scan(&oldKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to read task for transfer", zap.Error(err))
+		return nil, err
+	}
+
+	if oldKey.Valid {
+		if _, err := tx.ExecContext(ctx, keepTaskKeyQuery, oldKey.String, id); err != nil {
+			r.logger.Error("Failed to keep old task key", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	keys, err := sqliteTaskKeys(ctx, tx, []*models.Task{task}, prefixes)
+	if err != nil {
+		r.logger.Error("Failed to allocate task key", zap.Error(err))
+		return nil, err
+	}
+
+	task, err = scanSQLiteTask(tx.QueryRowContext(ctx, sqliteTransferTaskQuery,
+		nullableID(projectID), keys[0], status, rank, string(encodedFields), time.Now().UnixNano(), id))
+	if err != nil {
+		r.logger.Error("Failed to transfer task", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task transfer", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *SQLiteTaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	task, err := scanSQLiteTask(r.conn(ctx).QueryRowContext(ctx, sqliteMoveTaskQuery, status, rank, time.Now().UnixNano(), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to move task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *SQLiteTaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	task, err := scanSQLiteTask(r.conn(ctx).QueryRowContext(ctx, sqliteSetTaskParentQuery, nullableID(parentID), time.Now().UnixNano(), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to set task parent", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (r *SQLiteTaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	encoded, err := json.Marshal(parentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, sqliteDetachSubtasksQuery, time.Now().UnixNano(), string(encoded))
+	if err != nil {
+		r.logger.Error("Failed to detach subtasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateTaskRelations inserts the relations one by one in a transaction.
+func (r *SQLiteTaskRepository) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, relation := range relations {
+		if _, err := tx.ExecContext(ctx, sqliteCreateTaskRelationQuery, relation.TaskID, relation.RelatedTaskID, relation.Type); err != nil {
+			r.logger.Error("Failed to create task relation", zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task relations", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteTaskRepository) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, listTaskRelationsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task relations", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []models.TaskRelation
+	for rows.Next() {
+		var relation models.TaskRelation
+		if err := rows.Scan(&relation.TaskID, &relation.RelatedTaskID, &relation.Type); err != nil {
+			r.logger.Error("Failed to scan task relation", zap.Error(err))
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+	return relations, rows.Err()
+}
+
+func (r *SQLiteTaskRepository) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	res, err := r.conn(ctx).ExecContext(ctx, deleteTaskRelationQuery, relation.TaskID, relation.RelatedTaskID, relation.Type)
+	if err != nil {
+		r.logger.Error("Failed to delete task relation", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected count", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SetTaskChecklist deletes the old items and inserts the new ones one by one in a transaction.
+func (r *SQLiteTaskRepository) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqliteClearTaskChecklistQuery, id); err != nil {
+		r.logger.Error("Failed to clear task checklist", zap.Error(err))
+		return err
+	}
+	for i, item := range items {
+		if _, err := tx.ExecContext(ctx, sqliteInsertChecklistItemQuery, id, i+1, item.Text, item.Done); err != nil {
+			r.logger.Error("Failed to insert checklist item", zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task checklist", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteTaskRepository) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, getTaskChecklistQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to get task checklist", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		var item models.ChecklistItem
+		if err := rows.Scan(&item.Text, &item.Done); err != nil {
+			r.logger.Error("Failed to scan checklist item", zap.Error(err))
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *SQLiteTaskRepository) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	now := time.Now()
+	err := r.conn(ctx).QueryRowContext(ctx, sqliteCreateTaskCommentQuery, comment.TaskID, comment.Author, comment.Body, now.UnixNano()).Scan(&comment.ID)
+	if err != nil {
+		r.logger.Error("Failed to create task comment", zap.Error(err))
+		return nil, err
+	}
+	comment.CreatedAt = now
+	return comment, nil
+}
+
+func (r *SQLiteTaskRepository) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, listTaskCommentsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task comments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []*models.TaskComment
+	for rows.Next() {
+		var comment models.TaskComment
+		var createdAt int64
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.Author, &comment.Body, &createdAt); err != nil {
+			r.logger.Error("Failed to scan task comment", zap.Error(err))
+			return nil, err
+		}
+		comment.CreatedAt = time.Unix(0, createdAt)
+		comments = append(comments, &comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *SQLiteTaskRepository) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	now := time.Now()
+	err := r.conn(ctx).QueryRowContext(ctx, sqliteCreateTaskAttachmentQuery, attachment.TaskID, attachment.Name, attachment.URL, attachment.ContentType,
+		attachment.Size, now.UnixNano()).Scan(&attachment.ID)
+	if err != nil {
+		r.logger.Error("Failed to create task attachment", zap.Error(err))
+		return nil, err
+	}
+	attachment.CreatedAt = now
+	return attachment, nil
+}
+
+func (r *SQLiteTaskRepository) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, listTaskAttachmentsQuery, id)
+	if err != nil {
+		r.logger.Error("Failed to list task attachments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*models.TaskAttachment
+	for rows.Next() {
+		var attachment models.TaskAttachment
+		var createdAt int64
+		if err := rows.Scan(&attachment.ID, &attachment.TaskID, &attachment.Name, &attachment.URL, &attachment.ContentType, &attachment.Size,
+			&createdAt); err != nil {
+			r.logger.Error("Failed to scan task attachment", zap.Error(err))
+			return nil, err
+		}
+		attachment.CreatedAt = time.Unix(0, createdAt)
+		attachments = append(attachments, &attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// CopyTaskContent copies each selected part of the content by a statement of its own in a transaction.
+func (r *SQLiteTaskRepository) CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, part := range []struct {
+		copy  bool
+		query string
+	}{
+		{content.Checklist, sqliteCopyTaskChecklistQuery},
+		{content.Comments, sqliteCopyTaskCommentsQuery},
+		{content.Attachments, sqliteCopyTaskAttachmentsQuery},
+	} {
+		if !part.copy {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, part.query, fromID, toID); err != nil {
+			r.logger.Error("Failed to copy task content", zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task content", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteTaskRepository) RankBefore(ctx context.Context, column models.Column, rank string) (string, error) {
+	var before string
+	err := r.conn(ctx).QueryRowContext(ctx, rankBeforeQuery, nullableID(column.ProjectID), column.Status, rank).Scan(&before)
+	if err != nil {
+		r.logger.Error("Failed to fetch preceding rank", zap.Error(err))
+		return "", err
+	}
+
+	return before, nil
+}
+
+func (r *SQLiteTaskRepository) RankAfter(ctx context.Context, column models.Column, rank string) (string, error) {
+	var after string
+	err := r.conn(ctx).QueryRowContext(ctx, rankAfterQuery, nullableID(column.ProjectID), column.Status, rank).Scan(&after)
+	if err != nil {
+		r.logger.Error("Failed to fetch following rank", zap.Error(err))
+		return "", err
+	}
+
+	return after, nil
+}
+
+func (r *SQLiteTaskRepository) ColumnsWithLongRanks(ctx context.Context, maxLength int) ([]models.Column, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, columnsWithLongRanksQuery, maxLength)
+	if err != nil {
+		r.logger.Error("Failed to list columns with long ranks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.Column
+	for rows.Next() {
+		var column models.Column
+		var projectID sql.NullInt64
+		if err := rows.Scan(&projectID, &column.Status); err != nil {
+			r.logger.Error("Failed to scan column", zap.Error(err))
+			return nil, err
+		}
+		column.ProjectID = projectID.Int64
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+func (r *SQLiteTaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin rebalance transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, sqliteColumnTaskIDsQuery, nullableID(column.ProjectID), column.Status)
+	if err != nil {
+		r.logger.Error("Failed to read column", zap.Error(err))
+		return err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to read column", zap.Error(err))
+		return err
+	}
+
+	newRanks := ranks(len(ids))
+	if len(newRanks) != len(ids) {
+		return fmt.Errorf("rank generator returned %d keys for %d tasks", len(newRanks), len(ids))
+	}
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, setRankQuery, newRanks[i], id); err != nil {
+			r.logger.Error("Failed to rewrite rank", zap.Int64("id", id), zap.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ArchiveTasks copies the tasks with their key aliases and import records to the archive and deletes them
+// in one transaction, which holds the write lock of the database throughout.
+func (r *SQLiteTaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, sqliteArchiveCandidatesQuery, nullableID(column.ProjectID), column.Status, updatedBefore.UnixNano(), limit)
+	if err != nil {
+		r.logger.Error("Failed to select tasks to archive", zap.Error(err))
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan task id", zap.Error(err))
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to select tasks to archive", zap.Error(err))
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, sqliteArchiveTasksQuery, time.Now().UnixNano(), string(encoded)); err != nil {
+		r.logger.Error("Failed to archive tasks", zap.Error(err))
+		return nil, err
+	}
+	for _, query := range []string{sqliteArchiveKeyAliasesQuery, sqliteArchiveImportsQuery} {
+		if _, err := tx.ExecContext(ctx, query, string(encoded)); err != nil {
+			r.logger.Error("Failed to archive task records", zap.Error(err))
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, sqliteDeleteTasksQuery, string(encoded)); err != nil {
+		r.logger.Error("Failed to delete archived tasks", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit task archiving", zap.Error(err))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// CreateTaskPartitions does nothing, as SQLite has no table partitioning.
+func (r *SQLiteTaskRepository) CreateTaskPartitions(ctx context.Context, until time.Time) error {
+	return nil
+}
+
+func (r *SQLiteTaskRepository) GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	task, err := scanArchivedSQLiteTask(r.conn(ctx).QueryRowContext(ctx, getArchivedTaskQuery, archivedTaskRef(ref)...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch archived task", zap.Error(err))
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// keyPrefixes looks up the key prefixes of the projects of tasks.
+func (r *SQLiteTaskRepository) keyPrefixes(ctx context.Context, tasks []*models.Task) (map[int64]string, error) {
+	prefixes := make(map[int64]string)
+	for _, task := range tasks {
+		if _, ok := prefixes[task.ProjectID]; ok || task.ProjectID == 0 {
+			continue
+		}
+		project, err := r.projects.GetProject(ctx, task.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		prefixes[task.ProjectID] = project.Key
+	}
+	return prefixes, nil
+}
+
+// sqliteTaskKeys takes the next task numbers for a batch of tasks inside tx like nextTaskKeys, counting
+// them in the task_counters table. A rolled back transaction gives its numbers back.
+func sqliteTaskKeys(ctx context.Context, tx *sqlTx, tasks []*models.Task, prefixes map[int64]string) ([]sql.NullString, error) {
+	counts := make(map[int64]int64)
+	for _, task := range tasks {
+		if task.ProjectID != 0 {
+			counts[task.ProjectID]++
+		}
+	}
+	projectIDs := make([]int64, 0, len(counts))
+	for projectID := range counts {
+		projectIDs = append(projectIDs, projectID)
+	}
+	slices.Sort(projectIDs)
+
+	next := make(map[int64]int64, len(projectIDs))
+	for _, projectID := range projectIDs {
+		var counter int64
+		if err := tx.QueryRowContext(ctx, sqliteNextTaskNumberQuery, projectID, counts[projectID]).Scan(&counter); err != nil {
+			return nil, err
+		}
+		next[projectID] = counter - counts[projectID] + 1
+	}
+
+	keys := make([]sql.NullString, len(tasks))
+	for i, task := range tasks {
+		if task.ProjectID == 0 {
+			continue
+		}
+		keys[i] = sql.NullString{String: fmt.Sprintf("%s-%d", prefixes[task.ProjectID], next[task.ProjectID]), Valid: true}
+		next[task.ProjectID]++
+	}
+	return keys, nil
+}
+
+// encodeSQLiteColumns encodes the custom fields and labels of a task as the JSON text stored in SQLite.
+// They are passed as strings, as SQLite takes JSON given as a blob for its binary JSONB format.
+func encodeSQLiteColumns(task *models.Task) (customFields, labels string, err error) {
+	encodedFields, err := encodeCustomFields(task.CustomFields)
+	if err != nil {
+		return "", "", err
+	}
+	encodedLabels, err := json.Marshal(stringArray(task.Labels))
+	if err != nil {
+		return "", "", err
+	}
+	return string(encodedFields), string(encodedLabels), nil
+}
+
+// encodeSQLiteStrings encodes a list as the JSON array text stored in SQLite, see encodeSQLiteColumns.
+func encodeSQLiteStrings(values []string) (string, error) {
+	encoded, err := json.Marshal(stringArray(values))
+	return string(encoded), err
+}
+
+// decodeSQLiteStrings reads a list stored by encodeSQLiteStrings.
+func decodeSQLiteStrings(encoded string) ([]string, error) {
+	var values []string
+	err := json.Unmarshal([]byte(encoded), &values)
+	return values, err
+}
+
+// scanSQLiteTask reads a row selecting taskColumns from the SQLite schema, followed by any extra columns
+// scanned into extra.
+func scanSQLiteTask(row rowScanner, extra ...any) (*models.Task, error) {
+	var task models.Task
+	var projectID, parentID sql.NullInt64
+	var key sql.NullString
+	var labels, customFields string
+	var createdAt, updatedAt int64
+	dest := []any{&task.ID, &task.ExternalID, &projectID, &key, &task.Title, &task.Description, &task.Status, &task.Assignee, &labels,
+		&task.Rank, &customFields, &createdAt, &updatedAt, &parentID}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if task.CustomFields, err = decodeCustomFields([]byte(customFields)); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(labels), &task.Labels); err != nil {
+		return nil, err
+	}
+	task.ProjectID = projectID.Int64
+	task.ParentID = parentID.Int64
+	task.Key = key.String
+	task.CreatedAt = time.Unix(0, createdAt)
+	task.UpdatedAt = time.Unix(0, updatedAt)
+	return &task, nil
+}
+
+// scanListedSQLiteTask reads a row of sqliteListTasksQuery for filter.
+func scanListedSQLiteTask(row rowScanner, filter models.TaskFilter) (*models.Task, error) {
+	if filter.IncludeArchived {
+		return scanArchivedSQLiteTask(row)
+	}
+	return scanSQLiteTask(row)
+}
+
+// scanArchivedSQLiteTask reads a row selecting archivedTaskColumns from the SQLite schema.
+func scanArchivedSQLiteTask(row rowScanner) (*models.Task, error) {
+	var archivedAt sql.NullInt64
+	task, err := scanSQLiteTask(row, &archivedAt)
+	if err != nil {
+		return nil, err
+	}
+	if archivedAt.Valid {
+		task.ArchivedAt = time.Unix(0, archivedAt.Int64)
+	}
+	return task, nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository/repositorytest"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// projectKeys serves the key prefixes the SQLite task repository asks the project repository for.
+type projectKeys struct {
+	repository.ProjectRepository
+
+	mu   sync.Mutex
+	keys map[int64]string
+}
+
+func (p *projectKeys) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &models.Project{ID: id, Key: key}, nil
+}
+
+func (p *projectKeys) add() (int64, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := int64(len(p.keys) + 1)
+	p.keys[id] = fmt.Sprintf("S%d", id)
+	return id, p.keys[id]
+}
+
+// openSQLite opens a migrated SQLite database in a temporary directory.
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sqlite.NewDB(sqlite.Config{Path: filepath.Join(t.TempDir(), "tasks.db"), BusyTimeout: 5 * time.Second}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// setupSQLite returns a task repository on a migrated SQLite database in a temporary directory.
+func setupSQLite(t *testing.T) (*SQLiteTaskRepository, *projectKeys) {
+	t.Helper()
+	projects := &projectKeys{keys: make(map[int64]string)}
+	return NewSQLiteTaskRepository(openSQLite(t), projects, zap.NewNop()), projects
+}
+
+func TestSQLiteTaskRepository_Conformance(t *testing.T) {
+	repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Fixture {
+		repo, projects := setupSQLite(t)
+		return repositorytest.Fixture{
+			Repository: repo,
+			NewProject: func(t *testing.T) (int64, string) { return projects.add() },
+			Transactor: NewSQLTransactor(repo.db, nil, 3, zap.NewNop()),
+		}
+	})
+}
+
+func TestSQLiteTaskRepository_ConcurrentWrites(t *testing.T) {
+	repo, projects := setupSQLite(t)
+	projectID, projectKey := projects.add()
+	ctx := context.Background()
+
+	// Writers wait for each other within the busy timeout rather than failing with SQLITE_BUSY.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			task, err := repo.CreateTask(ctx, &models.Task{ExternalID: fmt.Sprint(i), ProjectID: projectID, Status: "open"})
+			if !assert.NoError(t, err) {
+				return
+			}
+			task.Title = "Updated"
+			_, err = repo.UpdateTask(ctx, task)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	tasks, err := repo.ListTasks(ctx, models.TaskFilter{ProjectID: projectID})
+	require.NoError(t, err)
+	keys := make(map[string]bool)
+	for _, task := range tasks {
+		keys[task.Key] = true
+	}
+	assert.Len(t, keys, 20)
+	assert.True(t, keys[projectKey+"-20"])
+}
+
+func TestSQLiteTaskRepository_TimestampFilter(t *testing.T) {
+	repo, projects := setupSQLite(t)
+	projectID, _ := projects.add()
+	ctx := context.Background()
+
+	task, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: projectID, Status: "open"})
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		operator string
+		want     int
+	}{{">=", 1}, {"<", 0}} {
+		where := &models.FilterExpr{Field: "created_at", Type: models.FilterTypeTimestamp, Operator: tt.operator, Value: task.CreatedAt.UTC()}
+		tasks, err := repo.ListTasks(ctx, models.TaskFilter{ProjectID: projectID, Where: where})
+		require.NoError(t, err)
+		assert.Len(t, tasks, tt.want, tt.operator)
+	}
+}
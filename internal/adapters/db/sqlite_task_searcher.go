@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap"
+)
+
+// sqliteTokenizers are the FTS5 tokenizers standing in for the Postgres text search configurations the
+// SQLite searcher supports.
+var sqliteTokenizers = map[string]string{
+	"english": "porter unicode61",
+	"simple":  "unicode61",
+}
+
+// SQLiteTaskSearcher searches the tasks_fts index that SQLite keeps of task titles and descriptions.
+// Like the Postgres searcher, titles weigh more than descriptions in the ranking.
+type SQLiteTaskSearcher struct {
+	db       *sql.DB
+	language string
+	logger   *zap.Logger
+}
+
+// NewSQLiteTaskSearcher returns a searcher that indexes and queries tasks with the tokenizer standing in
+// for the given text search configuration, "english" or "simple".
+func NewSQLiteTaskSearcher(db *sql.DB, language string, logger *zap.Logger) *SQLiteTaskSearcher {
+	return &SQLiteTaskSearcher{db: db, language: language, logger: logger}
+}
+
+// ApplyLanguage rebuilds the index with the tokenizer of the searcher's language if it was built with
+// another one. It does nothing if the language is already in use.
+func (s *SQLiteTaskSearcher) ApplyLanguage() error {
+	tokenizer, ok := sqliteTokenizers[s.language]
+	if !ok {
+		return fmt.Errorf("unsupported search language %q", s.language)
+	}
+	tokenize := "tokenize='" + tokenizer + "'"
+
+	var current string
+	err := s.db.QueryRowContext(context.Background(),
+		`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts'`,
+	).Scan(&current)
+	if err != nil {
+		s.logger.Error("Failed to look up search index", zap.Error(err))
+		return err
+	}
+	if strings.Contains(current, tokenize) {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range []string{
+		`DROP TABLE tasks_fts`,
+		`CREATE VIRTUAL TABLE tasks_fts USING fts5(title, description, content='tasks', content_rowid='id', ` + tokenize + `)`,
+		`INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild')`,
+	} {
+		if _, err := tx.ExecContext(context.Background(), statement); err != nil {
+			s.logger.Error("Failed to rebuild search index", zap.Error(err))
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit search language change", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Changed search language", zap.String("language", s.language))
+	return nil
+}
+
+// SearchTasks returns the tasks matching all terms of the search, ranked by how well they match.
+func (s *SQLiteTaskSearcher) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	match := matchExpression(search.Terms)
+	if match == "" {
+		return nil, nil
+	}
+
+	args := []any{match}
+	conditions := []string{`tasks_fts MATCH $1`}
+	if search.ProjectID != 0 {
+		args = append(args, search.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("tasks.project_id = $%d", len(args)))
+	}
+	var pageCondition string
+	if search.After != nil {
+		args = append(args, search.After.Score, search.After.ExternalID)
+		pageCondition = fmt.Sprintf(` WHERE score < $%d OR (score = $%d AND external_id > $%d)`,
+			len(args)-1, len(args)-1, len(args))
+	}
+
+	// bm25 ranks better matches lower, so scores are negated to sort like those of Postgres.
+	query := `
+		SELECT ` + taskColumns + `, score, title_snippet, description_snippet
+		FROM (
+			SELECT tasks.*, -bm25(tasks_fts, 1.0, 0.4) AS score,
+				highlight(tasks_fts, 0, '<mark>', '</mark>') AS title_snippet,
+				snippet(tasks_fts, 1, '<mark>', '</mark>', ' … ', 35) AS description_snippet
+			FROM tasks_fts JOIN tasks ON tasks.id = tasks_fts.rowid
+			WHERE ` + strings.Join(conditions, " AND ") + `
+		) hits` + pageCondition + `
+		ORDER BY score DESC, external_id`
+	if search.Limit > 0 {
+		args = append(args, search.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("Failed to search tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []*models.SearchHit
+	for rows.Next() {
+		var hit models.SearchHit
+		hit.Task, err = scanSQLiteTask(rows, &hit.Score, &hit.TitleSnippet, &hit.DescriptionSnippet)
+		if err != nil {
+			s.logger.Error("Failed to scan search hit", zap.Error(err))
+			return nil, err
+		}
+		hits = append(hits, &hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// matchExpression builds the FTS5 query for the terms out of their quoted words, so that no user input
+// is interpreted as an operator. It returns "" if no term that is not excluded has any word.
+func matchExpression(terms []models.SearchTerm) string {
+	var wanted, excluded []string
+	for _, term := range terms {
+		words := searchWordPattern.FindAllString(term.Text, -1)
+		if len(words) == 0 {
+			continue
+		}
+		var query string
+		switch {
+		case term.Prefix:
+			query = `"` + strings.Join(words, " ") + `"*`
+		case term.Phrase:
+			query = `"` + strings.Join(words, " ") + `"`
+		default:
+			query = `"` + strings.Join(words, `" AND "`) + `"`
+		}
+		if term.Exclude {
+			excluded = append(excluded, "("+query+")")
+		} else {
+			wanted = append(wanted, "("+query+")")
+		}
+	}
+	if len(wanted) == 0 {
+		return ""
+	}
+	query := "(" + strings.Join(wanted, " AND ") + ")"
+	for _, term := range excluded {
+		query += " NOT " + term
+	}
+	return query
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupSQLiteSearch returns a searcher in language over a few tasks of project 1 and one task without project.
+func setupSQLiteSearch(t *testing.T, language string) (*SQLiteTaskSearcher, *SQLiteTaskRepository) {
+	t.Helper()
+	repo, projects := setupSQLite(t)
+	projectID, _ := projects.add()
+	for _, task := range []*models.Task{
+		{ExternalID: "a", ProjectID: projectID, Title: "Fix login page", Description: "The login form crashes."},
+		{ExternalID: "b", ProjectID: projectID, Title: "Update docs", Description: "Describe how the login works."},
+		{ExternalID: "c", Title: "Login audit", Description: "Check the logs of the page."},
+		{ExternalID: "d", ProjectID: projectID, Title: "Page login", Description: "Unrelated."},
+	} {
+		task.Status = "open"
+		_, err := repo.CreateTask(context.Background(), task)
+		require.NoError(t, err)
+	}
+
+	searcher := NewSQLiteTaskSearcher(repo.db, language, zap.NewNop())
+	require.NoError(t, searcher.ApplyLanguage())
+	return searcher, repo
+}
+
+func searchExternalIDs(t *testing.T, searcher *SQLiteTaskSearcher, search models.TaskSearch) []string {
+	t.Helper()
+	hits, err := searcher.SearchTasks(context.Background(), search)
+	require.NoError(t, err)
+	var externalIDs []string
+	for _, hit := range hits {
+		externalIDs = append(externalIDs, hit.Task.ExternalID)
+	}
+	return externalIDs
+}
+
+func TestSQLiteTaskSearcher_SearchTasks(t *testing.T) {
+	searcher, repo := setupSQLiteSearch(t, "english")
+	search := func(projectID int64, terms ...models.SearchTerm) []string {
+		t.Helper()
+		return searchExternalIDs(t, searcher, models.TaskSearch{Terms: terms, ProjectID: projectID})
+	}
+
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, search(0, models.SearchTerm{Text: "LOGIN"}))
+	assert.Equal(t, "b", search(0, models.SearchTerm{Text: "login"})[3], "title matches rank first")
+	assert.ElementsMatch(t, []string{"a", "d"}, search(1, models.SearchTerm{Text: "login"}, models.SearchTerm{Text: "page"}))
+	assert.Equal(t, []string{"a"}, search(0, models.SearchTerm{Text: "login page", Phrase: true}))
+	assert.Equal(t, []string{"c"}, search(0, models.SearchTerm{Text: "the lo", Prefix: true}, models.SearchTerm{Text: "audit"}))
+	assert.Equal(t, []string{"b"}, search(1, models.SearchTerm{Text: "login"}, models.SearchTerm{Text: "page", Exclude: true}))
+	assert.Equal(t, []string{"a"}, search(0, models.SearchTerm{Text: "crashing"}), "english words are stemmed")
+	assert.Empty(t, search(0, models.SearchTerm{Text: `crash" OR "docs`}), "operators are searched as words")
+	assert.Empty(t, search(0, models.SearchTerm{Text: "!!"}, models.SearchTerm{Text: "audit", Exclude: true}), "there is no word to look for")
+
+	hits, err := searcher.SearchTasks(context.Background(), models.TaskSearch{Terms: []models.SearchTerm{{Text: "form"}}})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "Fix login page", hits[0].TitleSnippet)
+	assert.Equal(t, "The login <mark>form</mark> crashes.", hits[0].DescriptionSnippet)
+
+	hits, err = searcher.SearchTasks(context.Background(), models.TaskSearch{Terms: []models.SearchTerm{{Text: "login"}}, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.GreaterOrEqual(t, hits[0].Score, hits[1].Score)
+	last := hits[1]
+	rest := searchExternalIDs(t, searcher, models.TaskSearch{Terms: []models.SearchTerm{{Text: "login"}},
+		After: &models.SearchCursor{Score: last.Score, ExternalID: last.Task.ExternalID}})
+	assert.Len(t, rest, 2)
+	assert.NotContains(t, rest, hits[0].Task.ExternalID)
+	assert.NotContains(t, rest, last.Task.ExternalID)
+
+	id, err := repo.GetTaskIDByExternalID(context.Background(), "b")
+	require.NoError(t, err)
+	task, err := repo.GetTask(context.Background(), id)
+	require.NoError(t, err)
+	task.Description = "Nothing to see."
+	_, err = repo.UpdateTask(context.Background(), task)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "c", "d"}, search(0, models.SearchTerm{Text: "login"}), "updates are reindexed")
+}
+
+func TestSQLiteTaskSearcher_ApplyLanguage(t *testing.T) {
+	searcher, _ := setupSQLiteSearch(t, "simple")
+	assert.Empty(t, searchExternalIDs(t, searcher, models.TaskSearch{Terms: []models.SearchTerm{{Text: "crashing"}}}),
+		"simple words are not stemmed")
+	assert.Equal(t, []string{"a"}, searchExternalIDs(t, searcher, models.TaskSearch{Terms: []models.SearchTerm{{Text: "crashes"}}}))
+	require.NoError(t, searcher.ApplyLanguage(), "applying the language in use does nothing")
+
+	english := NewSQLiteTaskSearcher(searcher.db, "english", zap.NewNop())
+	require.NoError(t, english.ApplyLanguage())
+	assert.Equal(t, []string{"a"}, searchExternalIDs(t, english, models.TaskSearch{Terms: []models.SearchTerm{{Text: "crashing"}}}))
+
+	assert.Error(t, NewSQLiteTaskSearcher(searcher.db, "klingon", zap.NewNop()).ApplyLanguage())
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// SQLiteTaskTemplateRepository stores task templates and their versions in the SQLite database of the
+// SQLite task store.
+type SQLiteTaskTemplateRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSQLiteTaskTemplateRepository(db *sql.DB, logger *zap.Logger) *SQLiteTaskTemplateRepository {
+	return &SQLiteTaskTemplateRepository{db: db, logger: logger}
+}
+
+// conn returns what the statements of a method called with ctx run on, see sqlConnOf.
+func (r *SQLiteTaskTemplateRepository) conn(ctx context.Context) sqlConn {
+	return sqlConnOf(ctx, r.db)
+}
+
+func (r *SQLiteTaskTemplateRepository) CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	tasks, err := encodeBlueprints(template.Tasks)
+	if err != nil {
+		r.logger.Error("Failed to encode task blueprints", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	template.Version = 1
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO task_templates (project_id, name, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;
+	`, template.ProjectID, template.Name, template.Version, now.UnixNano(), now.UnixNano()).Scan(&template.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to create task template", zap.Error(err))
+		return nil, err
+	}
+	if err := insertSQLiteTemplateVersion(ctx, tx, template, tasks); err != nil {
+		r.logger.Error("Failed to store task template version", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+	return template, nil
+}
+
+func (r *SQLiteTaskTemplateRepository) GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error) {
+	query := `
+		SELECT ` + taskTemplateColumns + `
+		FROM task_templates t
+		JOIN task_template_versions v ON v.template_id = t.id AND v.version = COALESCE(NULLIF($2, 0), t.version)
+		WHERE t.id = $1
+	`
+
+	template, err := scanSQLiteTaskTemplate(r.conn(ctx).QueryRowContext(ctx, query, id, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		r.logger.Error("Failed to fetch task template", zap.Error(err))
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (r *SQLiteTaskTemplateRepository) ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error) {
+	query := `
+		SELECT ` + taskTemplateColumns + `
+		FROM task_templates t
+		JOIN task_template_versions v ON v.template_id = t.id AND v.version = t.version
+		WHERE t.project_id = $1
+		ORDER BY t.name, t.id
+	`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, projectID)
+	if err != nil {
+		r.logger.Error("Failed to list task templates", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.TaskTemplate
+	for rows.Next() {
+		template, err := scanSQLiteTaskTemplate(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan task template", zap.Error(err))
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *SQLiteTaskTemplateRepository) UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	tasks, err := encodeBlueprints(template.Tasks)
+	if err != nil {
+		r.logger.Error("Failed to encode task blueprints", zap.Error(err))
+		return nil, err
+	}
+
+	tx, err := beginSQLTx(ctx, r.db, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	template.UpdatedAt = time.Now()
+	var createdAt int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE task_templates
+		SET name = $1, version = version + 1, updated_at = $2
+		WHERE id = $3
+		RETURNING project_id, version, created_at;
+	`, template.Name, template.UpdatedAt.UnixNano(), template.ID).Scan(&template.ProjectID, &template.Version, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		r.logger.Error("Failed to update task template", zap.Error(err))
+		return nil, err
+	}
+	template.CreatedAt = time.Unix(0, createdAt)
+	if err := insertSQLiteTemplateVersion(ctx, tx, template, tasks); err != nil {
+		r.logger.Error("Failed to store task template version", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+	return template, nil
+}
+
+func (r *SQLiteTaskTemplateRepository) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	res, err := r.conn(ctx).ExecContext(ctx, `DELETE FROM task_templates WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete task template", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get affected rows", zap.Error(err))
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func insertSQLiteTemplateVersion(ctx context.Context, tx *sqlTx, template *models.TaskTemplate, tasks []byte) error {
+	variables, err := encodeSQLiteStrings(template.Variables)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_template_versions (template_id, version, description, variables, tasks, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`, template.ID, template.Version, template.Description, variables, string(tasks), template.UpdatedAt.UnixNano())
+	return err
+}
+
+func scanSQLiteTaskTemplate(row rowScanner) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	var variables, tasks string
+	var createdAt, updatedAt int64
+	err := row.Scan(&template.ID, &template.ProjectID, &template.Name, &template.Version, &template.Description, &variables,
+		&tasks, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if template.Variables, err = decodeSQLiteStrings(variables); err != nil {
+		return nil, err
+	}
+	if template.Tasks, err = decodeBlueprints([]byte(tasks)); err != nil {
+		return nil, err
+	}
+	template.CreatedAt = time.Unix(0, createdAt)
+	template.UpdatedAt = time.Unix(0, updatedAt)
+	return &template, nil
+}
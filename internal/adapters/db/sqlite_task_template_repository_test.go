@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteTaskTemplateRepository(t *testing.T) {
+	db := openSQLite(t)
+	projects := NewSQLiteProjectRepository(db, zap.NewNop())
+	repo := NewSQLiteTaskTemplateRepository(db, zap.NewNop())
+	project, err := projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Ops"})
+	require.NoError(t, err)
+
+	template, err := repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: project.ID, Name: "Onboarding", Variables: []string{"name"},
+		Tasks: []models.TaskBlueprint{{Title: "Welcome {{name}}", Labels: []string{"hr"},
+			CustomFields: map[string]models.FieldValue{"estimate": {Type: models.FieldTypeNumber, Number: 2}}}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, template.Version)
+	_, err = repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: project.ID, Name: "Onboarding"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	other, err := repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: project.ID, Name: "Audit"})
+	require.NoError(t, err)
+
+	updated, err := repo.UpdateTaskTemplate(context.Background(), &models.TaskTemplate{ID: template.ID, Name: "Offboarding",
+		Tasks: []models.TaskBlueprint{{Title: "Goodbye"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, project.ID, updated.ProjectID)
+	assert.True(t, updated.CreatedAt.Equal(template.CreatedAt))
+	_, err = repo.UpdateTaskTemplate(context.Background(), &models.TaskTemplate{ID: other.ID, Name: "Offboarding"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	first, err := repo.GetTaskTemplate(context.Background(), template.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Offboarding", first.Name, "versions carry the current name")
+	assert.Equal(t, []string{"name"}, first.Variables)
+	assert.Equal(t, []string{"hr"}, first.Tasks[0].Labels)
+	assert.Equal(t, 2.0, first.Tasks[0].CustomFields["estimate"].Number)
+	current, err := repo.GetTaskTemplate(context.Background(), template.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Version)
+	assert.Equal(t, "Goodbye", current.Tasks[0].Title)
+	_, err = repo.GetTaskTemplate(context.Background(), template.ID, 3)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	templates, err := repo.ListTaskTemplates(context.Background(), project.ID)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "Audit", templates[0].Name)
+	assert.Equal(t, 2, templates[1].Version)
+
+	require.NoError(t, repo.DeleteTaskTemplate(context.Background(), template.ID))
+	assert.ErrorIs(t, repo.DeleteTaskTemplate(context.Background(), template.ID), sql.ErrNoRows)
+	_, err = repo.GetTaskTemplate(context.Background(), template.ID, 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
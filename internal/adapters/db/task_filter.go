@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// filterColumns maps the task fields filters can refer to onto their columns. Field names never
+// reach the SQL otherwise; values are always bound as parameters.
+var filterColumns = map[string]string{
+	"id":          "id",
+	"external_id": "external_id",
+	"project_id":  "project_id",
+	"parent_id":   "parent_id",
+	"key":         "key",
+	"title":       "title",
+	"description": "description",
+	"status":      "status",
+	"assignee":    "assignee",
+	"labels":      "labels",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterCondition compiles a checked filter expression into an SQL condition, appending its arguments.
+// NOT treats unknown values, such as comparisons with a missing custom field, as false, so that
+// negating a restriction matches exactly the tasks the restriction does not.
+func filterCondition(expr *models.FilterExpr, args []any) (string, []any, error) {
+	switch expr.Logic {
+	case models.FilterAnd, models.FilterOr:
+		parts := make([]string, 0, len(expr.Operands))
+		for _, operand := range expr.Operands {
+			var part string
+			var err error
+			part, args, err = filterCondition(operand, args)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, part)
+		}
+		return "(" + strings.Join(parts, " "+expr.Logic+" ") + ")", args, nil
+	case models.FilterNot:
+		if len(expr.Operands) != 1 {
+			return "", nil, fmt.Errorf("NOT takes one operand, got %d", len(expr.Operands))
+		}
+		operand, args, err := filterCondition(expr.Operands[0], args)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("NOT COALESCE(%s, false)", operand), args, nil
+	case "":
+	default:
+		return "", nil, fmt.Errorf("unsupported filter logic %q", expr.Logic)
+	}
+
+	if expr.Custom {
+		return customFieldFilterCondition(expr, args)
+	}
+
+	column, ok := filterColumns[expr.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported filter field %q", expr.Field)
+	}
+	switch {
+	case expr.Operator == ":" && expr.Value == nil:
+		switch expr.Type {
+		case models.FilterTypeList:
+			return fmt.Sprintf("cardinality(%s) > 0", column), args, nil
+		case models.FilterTypeString:
+			return fmt.Sprintf("COALESCE(%s, '') <> ''", column), args, nil
+		default:
+			return fmt.Sprintf("%s IS NOT NULL", column), args, nil
+		}
+	case expr.Operator == ":" && expr.Type == models.FilterTypeList:
+		args = append(args, expr.Value)
+		return fmt.Sprintf("$%d = ANY(%s)", len(args), column), args, nil
+	case expr.Operator == ":":
+		text, ok := expr.Value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("\":\" on %s needs a text value", expr.Field)
+		}
+		args = append(args, "%"+likeEscaper.Replace(text)+"%")
+		return fmt.Sprintf(`%s ILIKE $%d ESCAPE '\'`, column, len(args)), args, nil
+	case expr.Operator == "!=":
+		// Tasks without a key or project differ from any given one.
+		args = append(args, expr.Value)
+		return fmt.Sprintf("%s IS DISTINCT FROM $%d", column, len(args)), args, nil
+	case fieldOperators[expr.Operator]:
+		args = append(args, expr.Value)
+		return fmt.Sprintf("%s %s $%d", column, expr.Operator, len(args)), args, nil
+	}
+	return "", nil, fmt.Errorf("unsupported filter operator %q", expr.Operator)
+}
+
+// customFieldFilterCondition compiles a restriction on a custom field. Comparisons share the SQL of
+// custom field conditions, so a task without a value for the field never satisfies them.
+func customFieldFilterCondition(expr *models.FilterExpr, args []any) (string, []any, error) {
+	if expr.Operator == ":" && expr.Value == nil {
+		args = append(args, expr.Field)
+		return fmt.Sprintf("custom_fields ? $%d::text", len(args)), args, nil
+	}
+
+	value, ok := expr.Value.(models.FieldValue)
+	if !ok {
+		return "", nil, fmt.Errorf("custom field %s needs a field value", expr.Field)
+	}
+	if expr.Operator == ":" {
+		args = append(args, expr.Field, "%"+likeEscaper.Replace(value.String)+"%")
+		return fmt.Sprintf(`(custom_fields ->> $%d::text) ILIKE $%d ESCAPE '\'`, len(args)-1, len(args)), args, nil
+	}
+	return customFieldCondition(models.FieldCondition{Field: expr.Field, Type: expr.Type, Operator: expr.Operator, Value: value}, args)
+}
@@ -0,0 +1,280 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// txRetryDelay is how long a unit of work aborted for a conflict waits before its second attempt. The delay
+// doubles for every further attempt and is jittered, so that units of work conflicting with each other part ways.
+const txRetryDelay = 20 * time.Millisecond
+
+// sqlUnit is the database/sql transaction of a unit of work, carried by the contexts of its repository calls.
+type sqlUnit struct {
+	db *sql.DB
+	tx *sql.Tx
+	// savepoints numbers the savepoints of the unit of work, keeping their names apart.
+	savepoints atomic.Int64
+}
+
+type sqlUnitKey struct{}
+
+// SQLTransactor runs units of work in transactions of a database/sql database, for the Postgres and SQLite
+// task repositories on that database.
+type SQLTransactor struct {
+	db          *sql.DB
+	opts        *sql.TxOptions
+	maxAttempts int
+	logger      *zap.Logger
+}
+
+// NewSQLTransactor begins the transactions of units of work with opts and runs a unit of work up to
+// maxAttempts times when it conflicts with concurrent transactions.
+func NewSQLTransactor(db *sql.DB, opts *sql.TxOptions, maxAttempts int, logger *zap.Logger) *SQLTransactor {
+	return &SQLTransactor{
+		db:          db,
+		opts:        opts,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+func (t *SQLTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if sqlUnitOf(ctx, t.db) != nil {
+		tx, err := beginSQLTx(ctx, t.db, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	return retryConflicts(ctx, t.maxAttempts, t.logger, func() error {
+		tx, err := t.db.BeginTx(ctx, t.opts)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		unitCtx, unit := consistency.WithUnitOfWork(ctx)
+		if err := fn(context.WithValue(unitCtx, sqlUnitKey{}, &sqlUnit{db: t.db, tx: tx})); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		unit.Committed()
+		return nil
+	})
+}
+
+// sqlUnitOf returns the unit of work of ctx if it runs on db, or nil.
+func sqlUnitOf(ctx context.Context, db *sql.DB) *sqlUnit {
+	unit, _ := ctx.Value(sqlUnitKey{}).(*sqlUnit)
+	if unit == nil || unit.db != db {
+		return nil
+	}
+	return unit
+}
+
+// sqlConn is implemented by *sql.DB and *sql.Tx.
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// sqlConnOf returns what the statements of a repository method on db run on: the transaction of the unit
+// of work of ctx if it runs on db, and db itself otherwise.
+func sqlConnOf(ctx context.Context, db *sql.DB) sqlConn {
+	if unit := sqlUnitOf(ctx, db); unit != nil {
+		return unit.tx
+	}
+	return db
+}
+
+// sqlTx is the transaction of a repository method on a database/sql database, see beginSQLTx. Like
+// *sql.Tx it may be rolled back after it committed, which does nothing.
+type sqlTx struct {
+	*sql.Tx
+	ctx       context.Context
+	savepoint string
+	done      bool
+}
+
+// beginSQLTx begins the transaction of a repository method on db. Within a unit of work on db that is
+// a savepoint of the unit's transaction, so that the method undoes its own changes if it fails without
+// undoing those of the unit of work; opts then do not apply.
+func beginSQLTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*sqlTx, error) {
+	unit := sqlUnitOf(ctx, db)
+	if unit == nil {
+		tx, err := db.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlTx{Tx: tx}, nil
+	}
+
+	savepoint := fmt.Sprintf("unit_of_work_%d", unit.savepoints.Add(1))
+	if _, err := unit.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+	return &sqlTx{Tx: unit.tx, ctx: ctx, savepoint: savepoint}, nil
+}
+
+func (tx *sqlTx) Commit() error {
+	if tx.savepoint == "" {
+		return tx.Tx.Commit()
+	}
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	_, err := tx.ExecContext(tx.ctx, "RELEASE SAVEPOINT "+tx.savepoint)
+	return err
+}
+
+func (tx *sqlTx) Rollback() error {
+	if tx.savepoint == "" {
+		return tx.Tx.Rollback()
+	}
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	if _, err := tx.ExecContext(tx.ctx, "ROLLBACK TO SAVEPOINT "+tx.savepoint); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(tx.ctx, "RELEASE SAVEPOINT "+tx.savepoint)
+	return err
+}
+
+// pgxUnit is the pgx transaction of a unit of work, carried by the contexts of its repository calls.
+type pgxUnit struct {
+	pool *pgxpool.Pool
+	tx   pgx.Tx
+}
+
+type pgxUnitKey struct{}
+
+// PgxTransactor runs units of work in transactions of a pgx pool, for the pgx task repository on that pool.
+type PgxTransactor struct {
+	pool        *pgxpool.Pool
+	opts        pgx.TxOptions
+	maxAttempts int
+	logger      *zap.Logger
+}
+
+// NewPgxTransactor begins the transactions of units of work with opts and runs a unit of work up to
+// maxAttempts times when it conflicts with concurrent transactions.
+func NewPgxTransactor(pool *pgxpool.Pool, opts pgx.TxOptions, maxAttempts int, logger *zap.Logger) *PgxTransactor {
+	return &PgxTransactor{
+		pool:        pool,
+		opts:        opts,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+func (t *PgxTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if pgxUnitOf(ctx, t.pool) != nil {
+		tx, err := beginPgxTx(ctx, t.pool, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	return retryConflicts(ctx, t.maxAttempts, t.logger, func() error {
+		tx, err := t.pool.BeginTx(ctx, t.opts)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		unitCtx, unit := consistency.WithUnitOfWork(ctx)
+		if err := fn(context.WithValue(unitCtx, pgxUnitKey{}, &pgxUnit{pool: t.pool, tx: tx})); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		unit.Committed()
+		return nil
+	})
+}
+
+// pgxUnitOf returns the unit of work of ctx if it runs on pool, or nil.
+func pgxUnitOf(ctx context.Context, pool *pgxpool.Pool) *pgxUnit {
+	unit, _ := ctx.Value(pgxUnitKey{}).(*pgxUnit)
+	if unit == nil || unit.pool != pool {
+		return nil
+	}
+	return unit
+}
+
+// pgxConn is implemented by *pgxpool.Pool and pgx.Tx.
+type pgxConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// pgxConnOf returns what the statements of a repository method on pool run on, like sqlConnOf.
+func pgxConnOf(ctx context.Context, pool *pgxpool.Pool) pgxConn {
+	if unit := pgxUnitOf(ctx, pool); unit != nil {
+		return unit.tx
+	}
+	return pool
+}
+
+// beginPgxTx begins the transaction of a repository method on pool like beginSQLTx. pgx runs transactions
+// begun in a transaction in savepoints.
+func beginPgxTx(ctx context.Context, pool *pgxpool.Pool, opts pgx.TxOptions) (pgx.Tx, error) {
+	if unit := pgxUnitOf(ctx, pool); unit != nil {
+		return unit.tx.Begin(ctx)
+	}
+	return pool.BeginTx(ctx, opts)
+}
+
+// retryConflicts runs attempt until it succeeds, fails for another reason than a conflict with concurrent
+// transactions, or has run maxAttempts times, waiting a little longer after every conflict.
+func retryConflicts(ctx context.Context, maxAttempts int, logger *zap.Logger, attempt func() error) error {
+	delay := txRetryDelay
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i >= maxAttempts || !isTransactionConflict(err) {
+			return err
+		}
+		logger.Warn("Unit of work conflicted with a concurrent transaction, retrying", zap.Int("attempt", i), zap.Error(err))
+
+		timer := time.NewTimer(delay/2 + rand.N(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
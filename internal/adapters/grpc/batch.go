@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// batchResponse collects the results of the items of a batch request in request order. Items the
+// handler rejects get their status right away; the others are passed on to the service, whose
+// results fill them in the order they were passed. Timestamps are rendered in location.
+type batchResponse struct {
+	results  []*pb.BatchTaskResult
+	passed   []int
+	location *time.Location
+}
+
+func newBatchResponse(size int, location *time.Location) *batchResponse {
+	return &batchResponse{results: make([]*pb.BatchTaskResult, size), location: location}
+}
+
+// reject records the status of an item the handler did not pass on.
+func (b *batchResponse) reject(index int, err error) {
+	st := status.Convert(err)
+	b.results[index] = &pb.BatchTaskResult{Code: int32(st.Code()), Message: st.Message()}
+}
+
+// pass records that the item at index is passed on to the service.
+func (b *batchResponse) pass(index int) {
+	b.passed = append(b.passed, index)
+}
+
+// requestIndex returns the request index of the item the service knows by index.
+func (b *batchResponse) requestIndex(index int) int {
+	if index < 0 || index >= len(b.passed) {
+		return index
+	}
+	return b.passed[index]
+}
+
+// response fills in the results of the service and returns the response.
+func (b *batchResponse) response(results []services.BatchResult) *pb.BatchTasksResponse {
+	for i, result := range results {
+		index := b.requestIndex(i)
+		if result.Err != nil {
+			b.reject(index, taskError(result.Err, "Failed to process task"))
+			continue
+		}
+		b.results[index] = &pb.BatchTaskResult{Code: int32(codes.OK)}
+		if result.Task != nil {
+			b.results[index].Task = toTaskResponse(result.Task, b.location)
+		}
+	}
+	return &pb.BatchTasksResponse{Results: b.results}
+}
+
+// batchItemError turns the status of an item into the status of the all-or-nothing batch it failed,
+// keeping its code and naming the item by its position in the request.
+func batchItemError(index int, err error) error {
+	st := status.Convert(err)
+	return status.Errorf(st.Code(), "Item %d: %s", index, st.Message())
+}
@@ -0,0 +1,231 @@
+package grpc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+const (
+	// calendarContentType is the media type of iCalendar feeds.
+	calendarContentType = "text/calendar; charset=utf-8"
+	// calendarUIDDomain makes task UIDs globally unique, as RFC 5545 recommends.
+	calendarUIDDomain = "grpc-task-manager"
+	// defaultDueField is the date custom field due dates are read from when a request names none.
+	defaultDueField = "due"
+	// calendarLineLength is the number of octets after which iCalendar content lines are folded.
+	calendarLineLength = 75
+)
+
+// calendarTokenContext separates the signatures of calendar feed tokens from other uses of the secret.
+const calendarTokenContext = "calendar-feed\x00"
+
+// CalendarTokens issues and checks the tokens that authenticate calendar feed subscriptions. Calendar apps
+// cannot send headers, so the token goes into the subscription URL. A token names the user it was issued
+// to and when it expires, and is signed with the server's secret, so that it needs no storage. A token
+// stops working when it expires, and changing the secret revokes all tokens at once.
+type CalendarTokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewCalendarTokens signs tokens with secret, issuing tokens that expire after ttl. Without a secret no
+// token is valid.
+func NewCalendarTokens(secret string, ttl time.Duration) *CalendarTokens {
+	return &CalendarTokens{secret: []byte(secret), ttl: ttl, now: time.Now}
+}
+
+// Enabled reports whether the tokens have a secret, without which feeds are not served.
+func (t *CalendarTokens) Enabled() bool {
+	return len(t.secret) > 0
+}
+
+// Issue returns a token of user and when it expires.
+func (t *CalendarTokens) Issue(user string) (string, time.Time, error) {
+	if !t.Enabled() {
+		return "", time.Time{}, errors.New("calendar feed tokens need a secret")
+	}
+	if t.ttl <= 0 {
+		return "", time.Time{}, errors.New("calendar feed tokens need a positive lifetime")
+	}
+	user = strings.TrimSpace(user)
+	if user == "" || len(user) > MaxLength {
+		return "", time.Time{}, fmt.Errorf("calendar feed tokens need a user of at most %d characters", MaxLength)
+	}
+	expires := t.now().Add(t.ttl).Truncate(time.Second)
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(user)) + "." + expiry + "." +
+		base64.RawURLEncoding.EncodeToString(t.sign(user, expiry)), expires, nil
+}
+
+// Verify returns the user a valid token was issued to. Expired tokens are not valid.
+func (t *CalendarTokens) Verify(token string) (string, bool) {
+	if !t.Enabled() {
+		return "", false
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	user, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, t.sign(string(user), parts[1])) {
+		return "", false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || !t.now().Before(time.Unix(expires, 0)) {
+		return "", false
+	}
+	return string(user), true
+}
+
+func (t *CalendarTokens) sign(user, expiry string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(calendarTokenContext + user + "\x00" + expiry))
+	return mac.Sum(nil)
+}
+
+// calendarEscaper escapes the characters with a meaning in iCalendar text values.
+var calendarEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// calendarWriter renders tasks with a due date as the entries of an iCalendar feed and sends them in
+// chunks of about exportChunkSize, like an export.
+type calendarWriter struct {
+	component string
+	dueField  string
+	send      func(*httpbody.HttpBody) error
+	buf       bytes.Buffer
+	started   bool
+}
+
+func newCalendarWriter(component, dueField string, send func(*httpbody.HttpBody) error) *calendarWriter {
+	return &calendarWriter{component: component, dueField: dueField, send: send}
+}
+
+// WriteTask renders one task, skipping tasks without a date in the due field.
+func (w *calendarWriter) WriteTask(task *models.Task) error {
+	value, ok := task.CustomFields[w.dueField]
+	if !ok || value.Type != models.FieldTypeDate {
+		return nil
+	}
+	due, err := time.Parse("2006-01-02", value.String)
+	if err != nil {
+		return nil
+	}
+	if !w.started {
+		w.writeHeader()
+	}
+
+	summary := task.Title
+	if task.Key != "" {
+		summary = task.Key + ": " + task.Title
+	}
+	name := "VTODO"
+	if w.component == "event" {
+		name = "VEVENT"
+	}
+	w.writeLine("BEGIN:" + name)
+	w.writeLine("UID:" + task.ExternalID + "@" + calendarUIDDomain)
+	w.writeLine("DTSTAMP:" + task.UpdatedAt.UTC().Format("20060102T150405Z"))
+	w.writeLine("LAST-MODIFIED:" + task.UpdatedAt.UTC().Format("20060102T150405Z"))
+	w.writeLine("SUMMARY:" + calendarEscaper.Replace(summary))
+	if task.Description != "" {
+		w.writeLine("DESCRIPTION:" + calendarEscaper.Replace(task.Description))
+	}
+	if name == "VEVENT" {
+		w.writeLine("DTSTART;VALUE=DATE:" + due.Format("20060102"))
+		w.writeLine("DTEND;VALUE=DATE:" + due.AddDate(0, 0, 1).Format("20060102"))
+		w.writeLine("TRANSP:TRANSPARENT")
+	} else {
+		w.writeLine("DUE;VALUE=DATE:" + due.Format("20060102"))
+	}
+	if len(task.Labels) > 0 {
+		labels := make([]string, len(task.Labels))
+		for i, label := range task.Labels {
+			labels[i] = calendarEscaper.Replace(label)
+		}
+		w.writeLine("CATEGORIES:" + strings.Join(labels, ","))
+	}
+	w.writeLine("END:" + name)
+
+	if w.buf.Len() >= exportChunkSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close ends the calendar and sends what is left of it. A feed without tasks is an empty calendar.
+func (w *calendarWriter) Close() error {
+	if !w.started {
+		w.writeHeader()
+	}
+	w.writeLine("END:VCALENDAR")
+	return w.flush()
+}
+
+func (w *calendarWriter) writeHeader() {
+	w.started = true
+	w.writeLine("BEGIN:VCALENDAR")
+	w.writeLine("VERSION:2.0")
+	w.writeLine("PRODID:-//" + calendarUIDDomain + "//Tasks//EN")
+	w.writeLine("CALSCALE:GREGORIAN")
+	w.writeLine("X-WR-CALNAME:Tasks")
+}
+
+// writeLine writes a content line, folding it into lines of at most calendarLineLength octets
+// without splitting UTF-8 sequences.
+func (w *calendarWriter) writeLine(line string) {
+	limit := calendarLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards their length.
+		limit = calendarLineLength - 1
+	}
+	w.buf.WriteString(line + "\r\n")
+}
+
+func (w *calendarWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	chunk := &httpbody.HttpBody{ContentType: calendarContentType, Data: bytes.Clone(w.buf.Bytes())}
+	w.buf.Reset()
+	return w.send(chunk)
+}
+
+// calendarFilter builds the filter expression selecting the tasks of a calendar feed, which are those of
+// the assignee. Within a project the tasks without a due date are left out by the query already.
+func calendarFilter(projectID int64, assignee, label, dueField string) string {
+	restrictions := []string{"assignee = " + quoteFilterValue(assignee)}
+	if label != "" {
+		restrictions = append(restrictions, "labels:"+quoteFilterValue(label))
+	}
+	if projectID != 0 {
+		restrictions = append(restrictions, customFieldColumnPrefix+dueField+":*")
+	}
+	return strings.Join(restrictions, " AND ")
+}
+
+// quoteFilterValue quotes a value for a filter expression.
+func quoteFilterValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,192 @@
+package grpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func calendarTasks() []*models.Task {
+	updated := time.Date(2026, 3, 1, 23, 30, 0, 0, time.FixedZone("CET", 3600))
+	return []*models.Task{
+		{ExternalID: "01KDVDNA000000000000000001", Key: "OPS-1", Title: "Renew certificates; all of them", Description: "Staging, then prod\nThen tell support",
+			Labels: []string{"ops", "on call"}, UpdatedAt: updated,
+			CustomFields: map[string]models.FieldValue{"due": {Type: models.FieldTypeDate, String: "2026-03-15"}}},
+		{ExternalID: "01KDVDNA000000000000000002", Key: "OPS-2", Title: "No due date", UpdatedAt: updated},
+		{ExternalID: "01KDVDNA000000000000000003", Key: "DEV-1", Title: "Due is text elsewhere", UpdatedAt: updated,
+			CustomFields: map[string]models.FieldValue{"due": {Type: models.FieldTypeString, String: "2026-03-16"}}},
+	}
+}
+
+// calendarToken returns a token of ada valid for the calendar tokens of setupHandler.
+func calendarToken(t *testing.T) string {
+	token, _, err := NewCalendarTokens("test-secret", time.Hour).Issue("ada")
+	require.NoError(t, err)
+	return token
+}
+
+func TestTaskHandler_GetCalendarFeed(t *testing.T) {
+	t.Run("To-dos", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		filter := models.TaskFilter{ProjectID: 1, Expression: `assignee = "ada" AND labels:"on \"call\"" AND custom_fields.due:*`}
+		mockService.On("ExportTasks", models.TaskExport{Filter: filter}).Return(calendarTasks(), nil)
+
+		stream := &exportStream{}
+		err := handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{ProjectId: 1, Assignee: " ada ", Label: `on "call"`, Token: calendarToken(t)}, stream)
+		require.NoError(t, err)
+		require.Equal(t, "text/calendar; charset=utf-8", stream.chunks[0].ContentType)
+		require.Equal(t, strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:-//grpc-task-manager//Tasks//EN",
+			"CALSCALE:GREGORIAN",
+			"X-WR-CALNAME:Tasks",
+			"BEGIN:VTODO",
+			"UID:01KDVDNA000000000000000001@grpc-task-manager",
+			"DTSTAMP:20260301T223000Z",
+			"LAST-MODIFIED:20260301T223000Z",
+			`SUMMARY:OPS-1: Renew certificates\; all of them`,
+			`DESCRIPTION:Staging\, then prod\nThen tell support`,
+			"DUE;VALUE=DATE:20260315",
+			"CATEGORIES:ops,on call",
+			"END:VTODO",
+			"END:VCALENDAR",
+			"",
+		}, "\r\n"), stream.body())
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("ExportTasks", models.TaskExport{Filter: models.TaskFilter{Expression: `assignee = "ada"`}}).Return(calendarTasks()[:1], nil)
+
+		stream := &exportStream{}
+		require.NoError(t, handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{Component: "Event", Token: calendarToken(t)}, stream))
+		body := stream.body()
+		require.Contains(t, body, "BEGIN:VEVENT\r\n")
+		require.Contains(t, body, "DTSTART;VALUE=DATE:20260315\r\nDTEND;VALUE=DATE:20260316\r\n")
+		require.NotContains(t, body, "DUE")
+	})
+
+	t.Run("Long lines are folded", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		task := calendarTasks()[0]
+		task.Description = strings.Repeat("é", 100)
+		mockService.On("ExportTasks", models.TaskExport{Filter: models.TaskFilter{Expression: `assignee = "ada"`}}).Return([]*models.Task{task}, nil)
+
+		stream := &exportStream{}
+		require.NoError(t, handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{Token: calendarToken(t)}, stream))
+		for _, line := range strings.Split(stream.body(), "\r\n") {
+			require.LessOrEqual(t, len(line), 75)
+		}
+		require.Contains(t, strings.ReplaceAll(stream.body(), "\r\n ", ""), "DESCRIPTION:"+task.Description)
+	})
+
+	t.Run("Empty calendar", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("ExportTasks", models.TaskExport{Filter: models.TaskFilter{Expression: `assignee = "ada" AND custom_fields.deadline:*`, ProjectID: 2}}).
+			Return(nil, nil)
+
+		stream := &exportStream{}
+		require.NoError(t, handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{ProjectId: 2, DueField: "deadline", Token: calendarToken(t)}, stream))
+		require.True(t, strings.HasSuffix(stream.body(), "X-WR-CALNAME:Tasks\r\nEND:VCALENDAR\r\n"))
+	})
+
+	t.Run("Unknown due field", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("ExportTasks", models.TaskExport{Filter: models.TaskFilter{ProjectID: 1, Expression: `assignee = "ada" AND custom_fields.due:*`}}).
+			Return(nil, services.ErrUnknownCustomField)
+
+		err := handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{ProjectId: 1, Token: calendarToken(t)}, &exportStream{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	for _, req := range []*pb.GetCalendarFeedRequest{
+		{ProjectId: -1},
+		{DueField: "Due Date"},
+		{Component: "journal"},
+	} {
+		t.Run("Invalid request", func(t *testing.T) {
+			mockService, handler := setupHandler()
+			req.Token = calendarToken(t)
+			err := handler.GetCalendarFeed(req, &exportStream{})
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			mockService.AssertNotCalled(t, "ExportTasks")
+		})
+	}
+
+	t.Run("Tasks of another user", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		err := handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{Assignee: "bob", Token: calendarToken(t)}, &exportStream{})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		mockService.AssertNotCalled(t, "ExportTasks")
+	})
+
+	t.Run("Server without a secret", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		handler.calendarTokens = NewCalendarTokens("", time.Hour)
+		err := handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{Token: calendarToken(t)}, &exportStream{})
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+		mockService.AssertNotCalled(t, "ExportTasks")
+	})
+
+	otherToken, _, err := NewCalendarTokens("other-secret", time.Hour).Issue("ada")
+	require.NoError(t, err)
+	parts := strings.Split(calendarToken(t), ".")
+	expired := NewCalendarTokens("test-secret", time.Hour)
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	expiredToken, _, err := expired.Issue("ada")
+	require.NoError(t, err)
+	for name, token := range map[string]string{
+		"Missing token":                  "",
+		"Malformed token":                "not-a-token",
+		"Token of another secret":        otherToken,
+		"Token with a changed user":      "Ym9i." + parts[1] + "." + parts[2],
+		"Token with a changed expiry":    parts[0] + ".99999999999." + parts[2],
+		"Token with a signature missing": parts[0] + "." + parts[1],
+		"Expired token":                  expiredToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockService, handler := setupHandler()
+			err := handler.GetCalendarFeed(&pb.GetCalendarFeedRequest{ProjectId: 1, Token: token}, &exportStream{})
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+			mockService.AssertNotCalled(t, "ExportTasks")
+		})
+	}
+}
+
+func TestCalendarTokens(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tokens := NewCalendarTokens("test-secret", 24*time.Hour)
+	tokens.now = func() time.Time { return now }
+
+	token, expires, err := tokens.Issue(" ada ")
+	require.NoError(t, err)
+	require.Equal(t, now.Add(24*time.Hour), expires)
+	user, ok := tokens.Verify(token)
+	require.True(t, ok)
+	require.Equal(t, "ada", user)
+
+	now = expires.Add(-time.Second)
+	_, ok = tokens.Verify(token)
+	require.True(t, ok)
+	now = expires
+	_, ok = tokens.Verify(token)
+	require.False(t, ok, "tokens stop working when they expire")
+
+	_, _, err = tokens.Issue("")
+	require.Error(t, err)
+	_, _, err = NewCalendarTokens("test-secret", 0).Issue("ada")
+	require.Error(t, err)
+
+	_, _, err = NewCalendarTokens("", time.Hour).Issue("ada")
+	require.Error(t, err)
+	_, ok = NewCalendarTokens("", time.Hour).Verify(token)
+	require.False(t, ok)
+}
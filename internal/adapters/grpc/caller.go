@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// callerIDHeader is the metadata header by which clients identify themselves, such as with a session ID,
+// so that they read their own writes across connections.
+const callerIDHeader = "caller-id"
+
+// NewCallerInterceptor tags the context of unary RPCs with their caller, see callerOf.
+func NewCallerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(consistency.WithCaller(ctx, callerOf(ctx)), req)
+	}
+}
+
+// NewStreamCallerInterceptor tags the context of streaming RPCs with their caller like NewCallerInterceptor.
+func NewStreamCallerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		return handler(srv, &callerStream{ServerStream: stream, ctx: consistency.WithCaller(ctx, callerOf(ctx))})
+	}
+}
+
+// callerOf identifies the client of an RPC by its caller-id header or, without one, by the address of its
+// connection. Clients behind a proxy such as the HTTP gateway share the proxy's connection and thus count
+// as one caller unless they send the header.
+func callerOf(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get(callerIDHeader) {
+			if value = strings.TrimSpace(value); value != "" && len(value) <= MaxLength {
+				return "id:" + value
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "addr:" + p.Addr.String()
+	}
+	return ""
+}
+
+// callerStream is a server stream with the context of its RPC replaced.
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
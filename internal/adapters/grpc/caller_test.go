@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestCallerInterceptor(t *testing.T) {
+	interceptor := NewCallerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/taskmanager.TaskManager/GetTask"}
+	connected := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 41000}})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"Header", metadata.NewIncomingContext(connected, metadata.Pairs(callerIDHeader, " session-1 ")), "id:session-1"},
+		{"Connection", connected, "addr:10.0.0.7:41000"},
+		{"Blank header", metadata.NewIncomingContext(connected, metadata.Pairs(callerIDHeader, " ")), "addr:10.0.0.7:41000"},
+		{"Unknown", context.Background(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var caller string
+			_, err := interceptor(tt.ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				caller = consistency.Caller(ctx)
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, caller)
+		})
+	}
+}
+
+func TestStreamCallerInterceptor(t *testing.T) {
+	interceptor := NewStreamCallerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/taskmanager.TaskManager/ExportTasks"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(callerIDHeader, "session-2"))
+
+	var caller string
+	err := interceptor(nil, &contextStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+		caller = consistency.Caller(stream.Context())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id:session-2", caller)
+}
@@ -0,0 +1,280 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxReportedConflicts limits the conflicting tasks listed in an error message.
+const maxReportedConflicts = 10
+
+// CustomFieldHandler implements the gRPC CustomFieldManagerServer interface and handles all custom field gRPC requests.
+type CustomFieldHandler struct {
+	pb.UnimplementedCustomFieldManagerServer
+	service services.CustomFieldService
+	logger  *zap.Logger
+}
+
+// NewCustomFieldHandler initializes a new CustomFieldHandler instance.
+func NewCustomFieldHandler(service services.CustomFieldService, logger *zap.Logger) pb.CustomFieldManagerServer {
+	return &CustomFieldHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateCustomField handles the gRPC request to define a new custom field for a project.
+func (h *CustomFieldHandler) CreateCustomField(ctx context.Context, req *pb.CreateCustomFieldRequest) (*pb.CustomFieldResponse, error) {
+	h.logger.Info("Received CreateCustomField request", zap.Int64("project_id", req.ProjectId), zap.String("name", req.Name))
+
+	if err := trimAndValidateCreateCustomFieldRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateCustomField", zap.Error(err))
+		return nil, err
+	}
+
+	field := &models.CustomField{
+		ProjectID: req.ProjectId,
+		Name:      req.Name,
+		Type:      req.Type,
+		Required:  req.Required,
+		Options:   req.Options,
+		Min:       req.Min,
+		Max:       req.Max,
+		MaxLength: int(req.MaxLength),
+	}
+
+	createdField, conflicts, err := h.service.CreateCustomField(ctx, field)
+	if err != nil {
+		if errors.Is(err, services.ErrCustomFieldNameTaken) {
+			h.logger.Warn("Custom field name is already taken", zap.String("name", req.Name))
+			return nil, status.Error(codes.AlreadyExists, "Custom field name is already taken")
+		}
+		if st := customFieldError(err, conflicts); st != nil {
+			h.logger.Warn("Custom field rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to create custom field", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to create custom field")
+	}
+
+	return toCustomFieldResponse(createdField), nil
+}
+
+// ListCustomFields handles the gRPC request to list the custom fields of a project.
+func (h *CustomFieldHandler) ListCustomFields(ctx context.Context, req *pb.ListCustomFieldsRequest) (*pb.ListCustomFieldsResponse, error) {
+	h.logger.Info("Received ListCustomFields request", zap.Int64("project_id", req.ProjectId))
+
+	if err := validateProjectID(req.ProjectId); err != nil {
+		h.logger.Warn("Validation failed for ListCustomFields", zap.Error(err))
+		return nil, err
+	}
+
+	fields, err := h.service.ListCustomFields(ctx, req.ProjectId)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found", zap.Int64("project_id", req.ProjectId))
+			return nil, status.Error(codes.NotFound, "Project not found")
+		}
+		h.logger.Error("Failed to list custom fields", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list custom fields")
+	}
+
+	var fieldResponses []*pb.CustomFieldResponse
+	for _, field := range fields {
+		fieldResponses = append(fieldResponses, toCustomFieldResponse(field))
+	}
+
+	return &pb.ListCustomFieldsResponse{Fields: fieldResponses}, nil
+}
+
+// UpdateCustomField handles the gRPC request to change a custom field definition.
+func (h *CustomFieldHandler) UpdateCustomField(ctx context.Context, req *pb.UpdateCustomFieldRequest) (*pb.UpdateCustomFieldResponse, error) {
+	h.logger.Info("Received UpdateCustomField request", zap.Int64("project_id", req.ProjectId), zap.Int64("id", req.Id),
+		zap.Bool("validate_only", req.ValidateOnly))
+
+	if err := trimAndValidateUpdateCustomFieldRequest(req); err != nil {
+		h.logger.Warn("Validation failed for UpdateCustomField", zap.Error(err))
+		return nil, err
+	}
+
+	field := &models.CustomField{
+		ID:        req.Id,
+		ProjectID: req.ProjectId,
+		Type:      req.Type,
+		Required:  req.Required,
+		Options:   req.Options,
+		Min:       req.Min,
+		Max:       req.Max,
+		MaxLength: int(req.MaxLength),
+	}
+
+	updatedField, conflicts, err := h.service.UpdateCustomField(ctx, field, req.ValidateOnly)
+	if err != nil {
+		if errors.Is(err, services.ErrCustomFieldNotFound) {
+			h.logger.Warn("Custom field not found for update", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.NotFound, "Custom field not found")
+		}
+		if st := customFieldError(err, conflicts); st != nil {
+			h.logger.Warn("Custom field update rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to update custom field", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to update custom field")
+	}
+
+	response := &pb.UpdateCustomFieldResponse{Field: toCustomFieldResponse(updatedField)}
+	for _, conflict := range conflicts {
+		response.Conflicts = append(response.Conflicts, &pb.CustomFieldConflict{
+			TaskId:  conflict.TaskID,
+			TaskKey: conflict.TaskKey,
+			Reason:  conflict.Reason,
+		})
+	}
+
+	return response, nil
+}
+
+// DeleteCustomField handles the gRPC request to delete a custom field and its values.
+func (h *CustomFieldHandler) DeleteCustomField(ctx context.Context, req *pb.DeleteCustomFieldRequest) (*pb.DeleteCustomFieldResponse, error) {
+	h.logger.Info("Received DeleteCustomField request", zap.Int64("project_id", req.ProjectId), zap.Int64("id", req.Id))
+
+	if err := validateCustomFieldRef(req.ProjectId, req.Id); err != nil {
+		h.logger.Warn("Validation failed for DeleteCustomField", zap.Error(err))
+		return nil, err
+	}
+
+	err := h.service.DeleteCustomField(ctx, req.ProjectId, req.Id)
+	if err != nil {
+		if errors.Is(err, services.ErrCustomFieldNotFound) {
+			h.logger.Warn("Custom field not found for deletion", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.NotFound, "Custom field not found")
+		}
+		if st := customFieldError(err, nil); st != nil {
+			h.logger.Warn("Custom field deletion rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to delete custom field", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to delete custom field")
+	}
+
+	return &pb.DeleteCustomFieldResponse{Success: true}, nil
+}
+
+// customFieldError maps the rules enforced by the custom field service to gRPC status errors,
+// listing the first conflicting tasks. It returns nil for any other error.
+func customFieldError(err error, conflicts []models.FieldConflict) error {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound):
+		return status.Error(codes.NotFound, "Project not found")
+	case errors.Is(err, services.ErrProjectArchived):
+		return status.Error(codes.FailedPrecondition, "Project is archived and its fields are read-only")
+	case errors.Is(err, services.ErrInvalidCustomField):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrCustomFieldConflicts):
+		var reasons []string
+		for i, conflict := range conflicts {
+			if i == maxReportedConflicts {
+				reasons = append(reasons, fmt.Sprintf("and %d more", len(conflicts)-i))
+				break
+			}
+			task := conflict.TaskKey
+			if task == "" {
+				task = fmt.Sprintf("task %d", conflict.TaskID)
+			}
+			reasons = append(reasons, task+": "+conflict.Reason)
+		}
+		return status.Errorf(codes.FailedPrecondition, "%d existing tasks conflict with the custom field: %s",
+			len(conflicts), strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// toCustomFieldResponse converts a custom field model into its gRPC representation.
+func toCustomFieldResponse(field *models.CustomField) *pb.CustomFieldResponse {
+	return &pb.CustomFieldResponse{
+		Id:        field.ID,
+		ProjectId: field.ProjectID,
+		Name:      field.Name,
+		Type:      field.Type,
+		Required:  field.Required,
+		Options:   field.Options,
+		Min:       field.Min,
+		Max:       field.Max,
+		MaxLength: int32(field.MaxLength),
+		CreatedAt: field.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: field.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// toCustomFieldValues converts the custom field values of a task into their gRPC representation.
+func toCustomFieldValues(values map[string]models.FieldValue) map[string]*pb.CustomFieldValue {
+	if len(values) == 0 {
+		return nil
+	}
+	converted := make(map[string]*pb.CustomFieldValue, len(values))
+	for name, value := range values {
+		converted[name] = toCustomFieldValue(value)
+	}
+	return converted
+}
+
+func toCustomFieldValue(value models.FieldValue) *pb.CustomFieldValue {
+	switch value.Type {
+	case models.FieldTypeNumber:
+		return &pb.CustomFieldValue{Value: &pb.CustomFieldValue_NumberValue{NumberValue: value.Number}}
+	case models.FieldTypeEnum:
+		return &pb.CustomFieldValue{Value: &pb.CustomFieldValue_EnumValue{EnumValue: value.String}}
+	case models.FieldTypeDate:
+		return &pb.CustomFieldValue{Value: &pb.CustomFieldValue_DateValue{DateValue: value.String}}
+	case models.FieldTypeUser:
+		return &pb.CustomFieldValue{Value: &pb.CustomFieldValue_UserValue{UserValue: value.String}}
+	default:
+		return &pb.CustomFieldValue{Value: &pb.CustomFieldValue_StringValue{StringValue: value.String}}
+	}
+}
+
+// fromCustomFieldValues converts custom field values of a request into models. A nil map stays nil.
+func fromCustomFieldValues(values map[string]*pb.CustomFieldValue) map[string]models.FieldValue {
+	if values == nil {
+		return nil
+	}
+	converted := make(map[string]models.FieldValue, len(values))
+	for name, value := range values {
+		converted[name] = fromCustomFieldValue(value)
+	}
+	return converted
+}
+
+// fromCustomFieldValue converts a gRPC custom field value; a value with nothing set becomes the zero FieldValue.
+func fromCustomFieldValue(value *pb.CustomFieldValue) models.FieldValue {
+	switch v := value.GetValue().(type) {
+	case *pb.CustomFieldValue_StringValue:
+		return models.FieldValue{Type: models.FieldTypeString, String: v.StringValue}
+	case *pb.CustomFieldValue_NumberValue:
+		return models.FieldValue{Type: models.FieldTypeNumber, Number: v.NumberValue}
+	case *pb.CustomFieldValue_EnumValue:
+		return models.FieldValue{Type: models.FieldTypeEnum, String: v.EnumValue}
+	case *pb.CustomFieldValue_DateValue:
+		return models.FieldValue{Type: models.FieldTypeDate, String: v.DateValue}
+	case *pb.CustomFieldValue_UserValue:
+		return models.FieldValue{Type: models.FieldTypeUser, String: v.UserValue}
+	}
+	return models.FieldValue{}
+}
+
+// formatOrderBy returns the canonical spelling of a custom field order.
+func formatOrderBy(order *models.FieldOrder) string {
+	if order.Descending {
+		return order.Field + " desc"
+	}
+	return order.Field
+}
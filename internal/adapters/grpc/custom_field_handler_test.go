@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockCustomFieldService struct {
+	mock.Mock
+}
+
+func (m *MockCustomFieldService) CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, []models.FieldConflict, error) {
+	args := m.Called(field)
+	return args.Get(0).(*models.CustomField), args.Get(1).([]models.FieldConflict), args.Error(2)
+}
+
+func (m *MockCustomFieldService) ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error) {
+	args := m.Called(projectID)
+	return args.Get(0).([]*models.CustomField), args.Error(1)
+}
+
+func (m *MockCustomFieldService) UpdateCustomField(ctx context.Context, field *models.CustomField, validateOnly bool) (*models.CustomField, []models.FieldConflict, error) {
+	args := m.Called(field, validateOnly)
+	return args.Get(0).(*models.CustomField), args.Get(1).([]models.FieldConflict), args.Error(2)
+}
+
+func (m *MockCustomFieldService) DeleteCustomField(ctx context.Context, projectID, id int64) error {
+	args := m.Called(projectID, id)
+	return args.Error(0)
+}
+
+func setupCustomFieldHandler() (*MockCustomFieldService, *CustomFieldHandler) {
+	mockService := new(MockCustomFieldService)
+	logger, _ := zap.NewDevelopment()
+	handler := &CustomFieldHandler{
+		service: mockService,
+		logger:  logger,
+	}
+	return mockService, handler
+}
+
+func TestCustomFieldHandler_CreateCustomField(t *testing.T) {
+	mockService, handler := setupCustomFieldHandler()
+
+	maxPoints := 13.0
+	mockField := &models.CustomField{ProjectID: 1, Name: "points", Type: "number", Options: []string{}, Max: &maxPoints}
+	mockResponse := &models.CustomField{ID: 1, ProjectID: 1, Name: "points", Type: "number", Max: &maxPoints}
+
+	mockService.On("CreateCustomField", mockField).Return(mockResponse, []models.FieldConflict(nil), nil)
+
+	resp, err := handler.CreateCustomField(context.Background(), &pb.CreateCustomFieldRequest{
+		ProjectId: 1,
+		Name:      " points ",
+		Type:      "Number",
+		Max:       &maxPoints,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.Id)
+	require.Equal(t, 13.0, resp.GetMax())
+	require.Nil(t, resp.Min)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCustomFieldHandler_CreateCustomField_InvalidName(t *testing.T) {
+	_, handler := setupCustomFieldHandler()
+
+	_, err := handler.CreateCustomField(context.Background(), &pb.CreateCustomFieldRequest{ProjectId: 1, Name: "Story Points", Type: "number"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCustomFieldHandler_CreateCustomField_Conflicts(t *testing.T) {
+	mockService, handler := setupCustomFieldHandler()
+
+	conflicts := []models.FieldConflict{{TaskID: 3, TaskKey: "OPS-3", Reason: "value is required"}}
+	mockService.On("CreateCustomField", mock.Anything).Return((*models.CustomField)(nil), conflicts, services.ErrCustomFieldConflicts)
+
+	_, err := handler.CreateCustomField(context.Background(), &pb.CreateCustomFieldRequest{ProjectId: 1, Name: "points", Type: "number", Required: true})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "OPS-3: value is required")
+}
+
+func TestCustomFieldHandler_UpdateCustomField_ValidateOnly(t *testing.T) {
+	mockService, handler := setupCustomFieldHandler()
+
+	maxPoints := 5.0
+	mockField := &models.CustomField{ID: 1, ProjectID: 1, Type: "number", Options: []string{}, Max: &maxPoints}
+	conflicts := []models.FieldConflict{{TaskID: 1, TaskKey: "OPS-1", Reason: "invalid custom field value: points must be at most 5"}}
+	mockService.On("UpdateCustomField", mockField, true).
+		Return(&models.CustomField{ID: 1, ProjectID: 1, Name: "points", Type: "number", Max: &maxPoints}, conflicts, nil)
+
+	resp, err := handler.UpdateCustomField(context.Background(), &pb.UpdateCustomFieldRequest{
+		ProjectId:    1,
+		Id:           1,
+		Type:         "number",
+		Max:          &maxPoints,
+		ValidateOnly: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "points", resp.Field.Name)
+	require.Len(t, resp.Conflicts, 1)
+	require.Equal(t, "OPS-1", resp.Conflicts[0].TaskKey)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestCustomFieldHandler_DeleteCustomField_NotFound(t *testing.T) {
+	mockService, handler := setupCustomFieldHandler()
+
+	mockService.On("DeleteCustomField", int64(1), int64(9)).Return(services.ErrCustomFieldNotFound)
+
+	_, err := handler.DeleteCustomField(context.Background(), &pb.DeleteCustomFieldRequest{ProjectId: 1, Id: 9})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewDeadlineInterceptor bounds every unary RPC by timeout, keeping an earlier deadline set by the client.
+// A zero timeout leaves RPCs unbounded. RPCs that fail because their deadline passed or their client went
+// away return DEADLINE_EXCEEDED or CANCELLED instead of the internal error the handler reported.
+func NewDeadlineInterceptor(timeout time.Duration, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if st := contextError(ctx, err); st != nil {
+				logger.Warn("Request ended before it completed", zap.String("method", info.FullMethod), zap.Error(err))
+				return nil, st
+			}
+		}
+		return resp, err
+	}
+}
+
+// NewStreamContextInterceptor maps the errors of streaming RPCs like NewDeadlineInterceptor does for unary
+// ones. Streams get no default deadline, as exports and imports may take as long as their files are big.
+func NewStreamContextInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, stream)
+		if err != nil {
+			if st := contextError(stream.Context(), err); st != nil {
+				logger.Warn("Stream ended before it completed", zap.String("method", info.FullMethod), zap.Error(err))
+				return st
+			}
+		}
+		return err
+	}
+}
+
+// contextError returns the DEADLINE_EXCEEDED or CANCELLED status for an error caused by the end of ctx,
+// and nil for any other error. Definite answers such as NOT_FOUND are kept even when ctx ended meanwhile.
+func contextError(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+	if ctx.Err() == nil {
+		return nil
+	}
+	if code := status.Code(err); code != codes.Internal && code != codes.Unknown {
+		return nil
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// contextStream is a server stream whose only behaviour is its context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	info := &grpc.UnaryServerInfo{FullMethod: "/taskmanager.TaskManager/ListTasks"}
+
+	// slowHandler waits for its context to end, like a query aborted by the database, and reports
+	// the failure the way the task handlers do.
+	slowHandler := func(ctx context.Context, req any) (any, error) {
+		select {
+		case <-ctx.Done():
+			return nil, status.Error(codes.Internal, "Failed to list tasks")
+		case <-time.After(time.Second):
+			return "done", nil
+		}
+	}
+
+	t.Run("Aborts requests after the default deadline", func(t *testing.T) {
+		interceptor := NewDeadlineInterceptor(20*time.Millisecond, logger)
+
+		start := time.Now()
+		_, err := interceptor(context.Background(), nil, info, slowHandler)
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Keeps an earlier deadline of the client", func(t *testing.T) {
+		interceptor := NewDeadlineInterceptor(time.Hour, logger)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			require.Less(t, time.Until(deadline), time.Minute)
+			return slowHandler(ctx, req)
+		})
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("Zero disables the deadline", func(t *testing.T) {
+		interceptor := NewDeadlineInterceptor(0, logger)
+
+		resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			_, ok := ctx.Deadline()
+			require.False(t, ok)
+			return "done", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "done", resp)
+	})
+
+	t.Run("Maps a cancelled request", func(t *testing.T) {
+		interceptor := NewDeadlineInterceptor(time.Hour, logger)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		_, err := interceptor(ctx, nil, info, slowHandler)
+		require.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("Maps raw context errors", func(t *testing.T) {
+		interceptor := NewDeadlineInterceptor(time.Hour, logger)
+
+		_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, context.DeadlineExceeded
+		})
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("Keeps definite answers", func(t *testing.T) {
+		interceptor := NewDeadlineInterceptor(time.Hour, logger)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.NotFound, "Task not found")
+		})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestStreamContextInterceptor(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	info := &grpc.StreamServerInfo{FullMethod: "/taskmanager.TaskManager/ExportTasks", IsServerStream: true}
+	interceptor := NewStreamContextInterceptor(logger)
+
+	t.Run("Maps a stream whose client went away", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := interceptor(nil, &contextStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+			_, ok := stream.Context().Deadline()
+			require.False(t, ok, "streams get no default deadline")
+			return status.Error(codes.Internal, "Failed to export tasks")
+		})
+		require.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("Passes other errors through", func(t *testing.T) {
+		err := interceptor(nil, &contextStream{ctx: context.Background()}, info, func(srv any, stream grpc.ServerStream) error {
+			return status.Error(codes.Internal, "Failed to export tasks")
+		})
+		require.Equal(t, codes.Internal, status.Code(err))
+	})
+}
@@ -0,0 +1,209 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+)
+
+// exportChunkSize is the amount of encoded tasks collected before they are sent as one chunk.
+const exportChunkSize = 32 * 1024
+
+// customFieldColumnPrefix introduces a custom field in the columns of an export, as in custom_fields.points.
+const customFieldColumnPrefix = "custom_fields."
+
+// defaultExportColumns are exported when a request names no columns.
+var defaultExportColumns = []string{"key", "title", "status", "assignee", "labels", "created_at", "updated_at"}
+
+// exportFormat describes a file format tasks can be exported in.
+type exportFormat struct {
+	contentType string
+	extension   string
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":      {contentType: "text/csv; charset=utf-8", extension: "csv"},
+	"jsonl":    {contentType: "application/x-ndjson", extension: "jsonl"},
+	"markdown": {contentType: "text/markdown; charset=utf-8", extension: "md"},
+}
+
+// markdownEscaper keeps cell text from breaking out of its Markdown table cell.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// exportWriter encodes tasks in an export format and sends them in chunks of about exportChunkSize,
+// so that an export never holds more than a chunk in memory.
+type exportWriter struct {
+	format   string
+	columns  []string
+	location *time.Location
+	send     func(*httpbody.HttpBody) error
+	buf      bytes.Buffer
+	csv      *csv.Writer
+	started  bool
+}
+
+func newExportWriter(format string, columns []string, location *time.Location, send func(*httpbody.HttpBody) error) *exportWriter {
+	w := &exportWriter{format: format, columns: columns, location: location, send: send}
+	w.csv = csv.NewWriter(&w.buf)
+	return w
+}
+
+// WriteTask encodes one task, sending the collected chunk once it is large enough.
+func (w *exportWriter) WriteTask(task *models.Task) error {
+	if !w.started {
+		w.writeHeader()
+	}
+
+	values := make([]any, len(w.columns))
+	for i, column := range w.columns {
+		values[i] = exportValue(task, column, w.location)
+	}
+	if err := w.writeRow(values); err != nil {
+		return err
+	}
+
+	if w.buf.Len() >= exportChunkSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close sends what is left of the export. An export without tasks still gets its header.
+func (w *exportWriter) Close() error {
+	if !w.started {
+		w.writeHeader()
+	}
+	return w.flush()
+}
+
+func (w *exportWriter) writeHeader() {
+	w.started = true
+	switch w.format {
+	case "csv":
+		w.csv.Write(w.columns)
+		w.csv.Flush()
+	case "markdown":
+		w.buf.WriteString("|")
+		for _, column := range w.columns {
+			w.buf.WriteString(" " + markdownEscaper.Replace(column) + " |")
+		}
+		w.buf.WriteString("\n|")
+		for range w.columns {
+			w.buf.WriteString(" --- |")
+		}
+		w.buf.WriteString("\n")
+	}
+}
+
+func (w *exportWriter) writeRow(values []any) error {
+	switch w.format {
+	case "jsonl":
+		// Objects are written field by field to keep the columns in the requested order.
+		w.buf.WriteString("{")
+		for i, column := range w.columns {
+			if i > 0 {
+				w.buf.WriteString(",")
+			}
+			name, _ := json.Marshal(column)
+			value, err := json.Marshal(values[i])
+			if err != nil {
+				return err
+			}
+			w.buf.Write(name)
+			w.buf.WriteString(":")
+			w.buf.Write(value)
+		}
+		w.buf.WriteString("}\n")
+	case "markdown":
+		w.buf.WriteString("|")
+		for _, value := range values {
+			w.buf.WriteString(" " + markdownEscaper.Replace(exportText(value)) + " |")
+		}
+		w.buf.WriteString("\n")
+	default:
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = exportText(value)
+		}
+		if err := w.csv.Write(record); err != nil {
+			return err
+		}
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+func (w *exportWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	// The chunk gets its own copy, as the buffer is reused for the next one.
+	chunk := &httpbody.HttpBody{ContentType: exportFormats[w.format].contentType, Data: bytes.Clone(w.buf.Bytes())}
+	w.buf.Reset()
+	return w.send(chunk)
+}
+
+// exportValue returns the value of a task column: a string, an int64, a float64 for number custom
+// fields, a list of labels or nil for a custom field without a value. Timestamps are rendered in location.
+func exportValue(task *models.Task, column string, location *time.Location) any {
+	if name, ok := strings.CutPrefix(column, customFieldColumnPrefix); ok {
+		value, ok := task.CustomFields[name]
+		if !ok {
+			return nil
+		}
+		return value.Raw()
+	}
+
+	switch column {
+	case "id":
+		return task.ID
+	case "key":
+		return task.Key
+	case "external_id":
+		return task.ExternalID
+	case "project_id":
+		return task.ProjectID
+	case "title":
+		return task.Title
+	case "description":
+		return task.Description
+	case "status":
+		return task.Status
+	case "assignee":
+		return task.Assignee
+	case "labels":
+		if task.Labels == nil {
+			return []string{}
+		}
+		return task.Labels
+	case "rank":
+		return task.Rank
+	case "created_at":
+		return task.CreatedAt.In(location).Format(time.RFC3339)
+	case "updated_at":
+		return task.UpdatedAt.In(location).Format(time.RFC3339)
+	}
+	return nil
+}
+
+// exportText renders a column value as the text of a CSV or Markdown cell. Labels are separated by commas.
+func exportText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// exportStream collects the chunks and headers an export sends.
+type exportStream struct {
+	grpc.ServerStream
+	chunks []*httpbody.HttpBody
+	header metadata.MD
+}
+
+func (s *exportStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *exportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *exportStream) Send(chunk *httpbody.HttpBody) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func (s *exportStream) body() string {
+	var body strings.Builder
+	for _, chunk := range s.chunks {
+		body.Write(chunk.Data)
+	}
+	return body.String()
+}
+
+func exportedTasks() []*models.Task {
+	created := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	return []*models.Task{
+		{ID: 1, Key: "OPS-1", Title: "Fix | pipe", Status: "open", Labels: []string{"bug", "ui"}, CreatedAt: created,
+			CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 2.5}}},
+		{ID: 2, Key: "OPS-2", Title: "Say \"hi\",\nthen leave", Status: "done", CreatedAt: created},
+	}
+}
+
+func TestTaskHandler_ExportTasks(t *testing.T) {
+	columns := []string{"key", "title", "labels", "custom_fields.points", "created_at"}
+	export := models.TaskExport{Filter: models.TaskFilter{ProjectID: 1}, Columns: columns}
+
+	tests := []struct {
+		format      string
+		contentType string
+		want        string
+	}{
+		{format: "", contentType: "text/csv; charset=utf-8", want: "key,title,labels,custom_fields.points,created_at\n" +
+			"OPS-1,Fix | pipe,\"bug, ui\",2.5,2026-03-02T00:30:00+01:00\n" +
+			"OPS-2,\"Say \"\"hi\"\",\nthen leave\",,,2026-03-02T00:30:00+01:00\n"},
+		{format: "JSONL", contentType: "application/x-ndjson", want: `{"key":"OPS-1","title":"Fix | pipe","labels":["bug","ui"],"custom_fields.points":2.5,"created_at":"2026-03-02T00:30:00+01:00"}` + "\n" +
+			`{"key":"OPS-2","title":"Say \"hi\",\nthen leave","labels":[],"custom_fields.points":null,"created_at":"2026-03-02T00:30:00+01:00"}` + "\n"},
+		{format: "markdown", contentType: "text/markdown; charset=utf-8", want: "| key | title | labels | custom_fields.points | created_at |\n" +
+			"| --- | --- | --- | --- | --- |\n" +
+			"| OPS-1 | Fix \\| pipe | bug, ui | 2.5 | 2026-03-02T00:30:00+01:00 |\n" +
+			"| OPS-2 | Say \"hi\",<br>then leave |  |  | 2026-03-02T00:30:00+01:00 |\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Format "+tt.format, func(t *testing.T) {
+			mockService, handler := setupHandler()
+			mockService.On("ExportTasks", export).Return(exportedTasks(), nil)
+			stream := &exportStream{}
+
+			err := handler.ExportTasks(&pb.ExportTasksRequest{ProjectId: 1, Format: tt.format, Columns: columns, TimeZone: "Europe/Berlin"}, stream)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, stream.body())
+			require.Equal(t, tt.contentType, stream.chunks[0].ContentType)
+		})
+	}
+
+	t.Run("Default columns and an empty export", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("ExportTasks", models.TaskExport{Columns: defaultExportColumns}).Return(nil, nil)
+		stream := &exportStream{}
+
+		err := handler.ExportTasks(&pb.ExportTasksRequest{}, stream)
+		require.NoError(t, err)
+		require.Equal(t, "key,title,status,assignee,labels,created_at,updated_at\n", stream.body())
+		require.Equal(t, []string{`attachment; filename="tasks.csv"`}, stream.header.Get("content-disposition"))
+	})
+
+	t.Run("Large exports are sent in chunks", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		var tasks []*models.Task
+		for i := 0; i < 1000; i++ {
+			tasks = append(tasks, &models.Task{ID: int64(i), Title: strings.Repeat("x", 100)})
+		}
+		mockService.On("ExportTasks", models.TaskExport{Columns: []string{"title"}}).Return(tasks, nil)
+		stream := &exportStream{}
+
+		err := handler.ExportTasks(&pb.ExportTasksRequest{Columns: []string{"title"}}, stream)
+		require.NoError(t, err)
+		require.Greater(t, len(stream.chunks), 1)
+		require.Equal(t, 1001, strings.Count(stream.body(), "\n"))
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		_, handler := setupHandler()
+		for _, req := range []*pb.ExportTasksRequest{
+			{Format: "xlsx"},
+			{TimeZone: "Mars/Olympus"},
+			{Columns: []string{"key", "key"}},
+		} {
+			err := handler.ExportTasks(req, &exportStream{})
+			require.Equal(t, codes.InvalidArgument, status.Code(err), req.String())
+		}
+	})
+
+	t.Run("Unknown column", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("ExportTasks", models.TaskExport{Columns: []string{"owner"}}).Return(nil, services.ErrUnknownColumn)
+
+		err := handler.ExportTasks(&pb.ExportTasksRequest{Columns: []string{"owner"}}, &exportStream{})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,227 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	// idempotencyKeyHeader is the metadata header carrying the idempotency key of a request.
+	idempotencyKeyHeader = "idempotency-key"
+	// idempotencyKeyField is the request field carrying the idempotency key of a request.
+	idempotencyKeyField = "idempotency_key"
+	// idempotentReplayHeader marks responses replayed for a repeated idempotency key.
+	idempotentReplayHeader = "idempotent-replayed"
+)
+
+// idempotentRequest is implemented by the requests of the mutating RPCs that accept an idempotency key.
+type idempotentRequest interface {
+	proto.Message
+	GetIdempotencyKey() string
+}
+
+// NewIdempotencyInterceptor makes the RPCs whose requests have an idempotency_key field safe to retry.
+// A request carrying a key, in that field or in the idempotency-key header, is processed once; repeating
+// it returns the stored response of the first call, with its timestamps rendered in the time zone of the
+// repeated request. Keys belong to their caller, see idempotencyScope, so that callers choosing the same
+// key do not get each other's responses. Failed requests are not remembered and may be retried.
+func NewIdempotencyInterceptor(service services.IdempotencyService, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		request, ok := req.(idempotentRequest)
+		if !ok {
+			return handler(ctx, req)
+		}
+		key, err := idempotencyKey(ctx, request)
+		if err != nil {
+			logger.Warn("Invalid idempotency key", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, err
+		}
+		if key == "" {
+			return handler(ctx, req)
+		}
+
+		hash, err := requestHash(info.FullMethod, request)
+		if err != nil {
+			logger.Error("Failed to hash request", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, status.Error(codes.Internal, "Failed to process idempotency key")
+		}
+
+		record, err := service.Begin(ctx, scopedIdempotencyKey(idempotencyScope(ctx), key), hash)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyReused):
+				return nil, status.Error(codes.AlreadyExists, "Idempotency key was already used for a different request")
+			case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+				return nil, status.Error(codes.Aborted, "A request with the same idempotency key is still in progress")
+			}
+			return nil, status.Error(codes.Internal, "Failed to process idempotency key")
+		}
+		if record.Completed() {
+			return replayResponse(ctx, record, timeZoneOf(ctx), logger)
+		}
+
+		// The claim is settled even when the request failed for its deadline or was cancelled.
+		settleCtx := context.WithoutCancel(ctx)
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if releaseErr := service.Release(settleCtx, record); releaseErr != nil {
+				logger.Error("Failed to release idempotency key", zap.String("key", key), zap.Error(releaseErr))
+			}
+			return nil, err
+		}
+
+		// The change is applied either way; a response that cannot be stored only makes retries apply it again.
+		if message, ok := resp.(proto.Message); ok {
+			data, err := proto.Marshal(message)
+			if err == nil {
+				err = service.Complete(settleCtx, record, string(message.ProtoReflect().Descriptor().FullName()), data)
+			}
+			if err != nil {
+				logger.Error("Failed to store response for idempotency key", zap.String("key", key), zap.Error(err))
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// idempotencyKey returns the idempotency key of a request, taken from the request field or the header.
+// Both may be given as long as they agree.
+func idempotencyKey(ctx context.Context, request idempotentRequest) (string, error) {
+	key := strings.TrimSpace(request.GetIdempotencyKey())
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get(idempotencyKeyHeader) {
+			value = strings.TrimSpace(value)
+			if key != "" && value != "" && value != key {
+				return "", status.Error(codes.InvalidArgument, "Idempotency key header and field differ")
+			}
+			if value != "" {
+				key = value
+			}
+		}
+	}
+	if len(key) > MaxLength {
+		return "", status.Errorf(codes.InvalidArgument, "Idempotency key exceeds maximum length of %d characters", MaxLength)
+	}
+	return key, nil
+}
+
+// idempotencyScope returns the caller a request's idempotency key belongs to: the caller-id header or,
+// without one, the host of the connection. Unlike callerOf it leaves out the port, as retries often come
+// over a new connection. Requests of unknown callers share one scope.
+func idempotencyScope(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get(callerIDHeader) {
+			if value = strings.TrimSpace(value); value != "" && len(value) <= MaxLength {
+				return "id:" + value
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "host:" + host
+	}
+	return ""
+}
+
+// scopedIdempotencyKey returns the key under which the idempotency key of a caller is stored. The length
+// of the scope keeps scopes and keys containing the separator apart.
+func scopedIdempotencyKey(scope, key string) string {
+	if scope == "" {
+		return key
+	}
+	return fmt.Sprintf("%d:%s:%s", len(scope), scope, key)
+}
+
+// requestHash identifies a request by its method and content, leaving out the idempotency key itself.
+func requestHash(method string, request proto.Message) ([]byte, error) {
+	clone := proto.Clone(request).ProtoReflect()
+	if field := clone.Descriptor().Fields().ByName(idempotencyKeyField); field != nil {
+		clone.Clear(field)
+	}
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(clone.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write(data)
+	return hash.Sum(nil), nil
+}
+
+// replayResponse decodes the response stored for a repeated request, rendering its string timestamps in
+// location and marking it as replayed in the response header.
+func replayResponse(ctx context.Context, record *models.IdempotencyRecord, location *time.Location, logger *zap.Logger) (any, error) {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(record.ResponseType))
+	if err != nil {
+		logger.Error("Unknown type of stored response", zap.String("type", record.ResponseType), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to replay response")
+	}
+	message := messageType.New().Interface()
+	if err := proto.Unmarshal(record.Response, message); err != nil {
+		logger.Error("Failed to decode stored response", zap.String("key", record.Key), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to replay response")
+	}
+	renderTimestamps(message.ProtoReflect(), location)
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayHeader, "true")); err != nil {
+		logger.Warn("Failed to mark replayed response", zap.Error(err))
+	}
+	return message, nil
+}
+
+// renderTimestamps renders the string timestamps of the tasks in message, at any depth, in location. They
+// are derived from the Timestamp fields, which carry no zone.
+func renderTimestamps(message protoreflect.Message, location *time.Location) {
+	if task, ok := message.Interface().(*pb.TaskResponse); ok {
+		if task.CreateTime != nil {
+			task.CreatedAt = formatTimestamp(task.CreateTime.AsTime(), location)
+		}
+		if task.UpdateTime != nil {
+			task.UpdatedAt = formatTimestamp(task.UpdateTime.AsTime(), location)
+		}
+		return
+	}
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsMap():
+			if field.MapValue().Message() != nil {
+				value.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+					renderTimestamps(value.Message(), location)
+					return true
+				})
+			}
+		case field.Message() == nil:
+		case field.IsList():
+			for i := 0; i < value.List().Len(); i++ {
+				renderTimestamps(value.List().Get(i).Message(), location)
+			}
+		default:
+			renderTimestamps(value.Message(), location)
+		}
+		return true
+	})
+}
@@ -0,0 +1,196 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyService) Begin(ctx context.Context, key string, requestHash []byte) (*models.IdempotencyRecord, error) {
+	args := m.Called(key, requestHash)
+	record, _ := args.Get(0).(*models.IdempotencyRecord)
+	return record, args.Error(1)
+}
+
+func (m *MockIdempotencyService) Complete(ctx context.Context, claim *models.IdempotencyRecord, responseType string, response []byte) error {
+	args := m.Called(claim, responseType, response)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyService) Release(ctx context.Context, claim *models.IdempotencyRecord) error {
+	args := m.Called(claim)
+	return args.Error(0)
+}
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	info := &grpc.UnaryServerInfo{FullMethod: "/taskmanager.TaskManager/CreateTask"}
+	created := &pb.TaskResponse{Id: 7, Title: "Task"}
+	createdBytes, err := proto.Marshal(created)
+	require.NoError(t, err)
+
+	newHandler := func(calls *int, err error) grpc.UnaryHandler {
+		return func(ctx context.Context, req any) (any, error) {
+			*calls++
+			if err != nil {
+				return nil, err
+			}
+			return created, nil
+		}
+	}
+
+	t.Run("Requests without a key pass through", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		resp, err := interceptor(context.Background(), &pb.CreateTaskRequest{Title: "Task"}, info, newHandler(&calls, nil))
+		require.NoError(t, err)
+		require.Equal(t, created, resp)
+		require.Equal(t, 1, calls)
+		mockService.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything)
+	})
+
+	t.Run("First request stores its response", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		claim := &models.IdempotencyRecord{Key: "retry-1"}
+		mockService.On("Begin", "retry-1", mock.Anything).Return(claim, nil)
+		mockService.On("Complete", claim, "taskmanager.TaskResponse", createdBytes).Return(nil)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyHeader, "retry-1"))
+		resp, err := interceptor(ctx, &pb.CreateTaskRequest{Title: "Task"}, info, newHandler(&calls, nil))
+		require.NoError(t, err)
+		require.Equal(t, created, resp)
+		require.Equal(t, 1, calls)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Repeated request replays the stored response", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		mockService.On("Begin", "retry-1", mock.Anything).
+			Return(&models.IdempotencyRecord{Key: "retry-1", ResponseType: "taskmanager.TaskResponse", Response: createdBytes}, nil)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		resp, err := interceptor(context.Background(), &pb.CreateTaskRequest{Title: "Task", IdempotencyKey: "retry-1"}, info, newHandler(&calls, nil))
+		require.NoError(t, err)
+		require.True(t, proto.Equal(created, resp.(proto.Message)))
+		require.Equal(t, 0, calls)
+	})
+
+	t.Run("Replays render timestamps in the time zone of the repeated request", func(t *testing.T) {
+		createTime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		stored, err := proto.Marshal(&pb.BatchTasksResponse{Results: []*pb.BatchTaskResult{{Task: toTaskResponse(
+			&models.Task{ID: 7, CreatedAt: createTime, UpdatedAt: createTime}, tokyo)}}})
+		require.NoError(t, err)
+		mockService := new(MockIdempotencyService)
+		mockService.On("Begin", "retry-1", mock.Anything).
+			Return(&models.IdempotencyRecord{Key: "retry-1", ResponseType: "taskmanager.BatchTasksResponse", Response: stored}, nil)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		ctx := context.WithValue(context.Background(), timeZoneKey{}, berlin)
+		resp, err := interceptor(ctx, &pb.CreateTaskRequest{Title: "Task", IdempotencyKey: "retry-1"}, info, newHandler(&calls, nil))
+		require.NoError(t, err)
+		task := resp.(*pb.BatchTasksResponse).Results[0].Task
+		require.Equal(t, "2026-03-01T13:00:00+01:00", task.CreatedAt)
+		require.Equal(t, "2026-03-01T13:00:00+01:00", task.UpdatedAt)
+		require.True(t, task.CreateTime.AsTime().Equal(createTime))
+	})
+
+	t.Run("Keys are kept apart by caller", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		mockService.On("Begin", "8:id:alice:retry-1", mock.Anything).Return(&models.IdempotencyRecord{Key: "8:id:alice:retry-1"}, nil)
+		mockService.On("Begin", "6:id:bob:retry-1", mock.Anything).
+			Return(&models.IdempotencyRecord{Key: "6:id:bob:retry-1", ResponseType: "taskmanager.TaskResponse", Response: createdBytes}, nil)
+		mockService.On("Complete", mock.Anything, "taskmanager.TaskResponse", createdBytes).Return(nil)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		for _, caller := range []string{"alice", "bob"} {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(callerIDHeader, caller))
+			_, err := interceptor(ctx, &pb.CreateTaskRequest{Title: "Task", IdempotencyKey: "retry-1"}, info, newHandler(&calls, nil))
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, calls, "only the request of bob, who used the key before, is replayed")
+		mockService.AssertExpectations(t)
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000}})
+		require.Equal(t, "host:10.0.0.1", idempotencyScope(ctx), "retries over a new connection keep their scope")
+	})
+
+	t.Run("Key reused with a different request", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		mockService.On("Begin", "retry-1", mock.Anything).Return(nil, services.ErrIdempotencyKeyReused)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		_, err := interceptor(context.Background(), &pb.CreateTaskRequest{Title: "Other", IdempotencyKey: "retry-1"}, info, newHandler(&calls, nil))
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+		require.Equal(t, 0, calls)
+	})
+
+	t.Run("Failed request releases its key", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		claim := &models.IdempotencyRecord{Key: "retry-1"}
+		mockService.On("Begin", "retry-1", mock.Anything).Return(claim, nil)
+		mockService.On("Release", claim).Return(nil)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		_, err := interceptor(context.Background(), &pb.CreateTaskRequest{Title: "Task", IdempotencyKey: "retry-1"}, info,
+			newHandler(&calls, status.Error(codes.InvalidArgument, "Description cannot be empty")))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Header and field must agree", func(t *testing.T) {
+		mockService := new(MockIdempotencyService)
+		interceptor := NewIdempotencyInterceptor(mockService, logger)
+		var calls int
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyHeader, "retry-2"))
+		_, err := interceptor(ctx, &pb.CreateTaskRequest{Title: "Task", IdempotencyKey: "retry-1"}, info, newHandler(&calls, nil))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, 0, calls)
+	})
+}
+
+func TestRequestHash(t *testing.T) {
+	withKey, err := requestHash("/taskmanager.TaskManager/CreateTask", &pb.CreateTaskRequest{Title: "Task", IdempotencyKey: "retry-1"})
+	require.NoError(t, err)
+	withoutKey, err := requestHash("/taskmanager.TaskManager/CreateTask", &pb.CreateTaskRequest{Title: "Task"})
+	require.NoError(t, err)
+	otherTitle, err := requestHash("/taskmanager.TaskManager/CreateTask", &pb.CreateTaskRequest{Title: "Other"})
+	require.NoError(t, err)
+	otherMethod, err := requestHash("/taskmanager.TaskManager/UpdateTask", &pb.CreateTaskRequest{Title: "Task"})
+	require.NoError(t, err)
+
+	require.Equal(t, withKey, withoutKey)
+	require.NotEqual(t, withKey, otherTitle)
+	require.NotEqual(t, withKey, otherMethod)
+}
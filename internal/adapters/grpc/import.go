@@ -0,0 +1,381 @@
+package grpc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Task fields records can be mapped to, besides custom_fields.<name>.
+var importTargets = []string{"title", "description", "status", "assignee", "labels", "source_id"}
+
+// importFormats holds the default field mapping of each import format. csv and jsonl also map the
+// fields named like a task field to that field.
+var importFormats = map[string]map[string]string{
+	"csv":   {"id": "source_id"},
+	"jsonl": {"id": "source_id"},
+	// Statuses of other trackers rarely match a project workflow, so they are only imported when mapped.
+	"github": {
+		"number":         "source_id",
+		"title":          "title",
+		"body":           "description",
+		"assignee.login": "assignee",
+		"labels.name":    "labels",
+	},
+	"jira": {
+		"key":                "source_id",
+		"fields.summary":     "title",
+		"fields.description": "description",
+		// Descriptions in the Atlassian document format of the v3 API contribute the text of their paragraphs.
+		"fields.description.content.content.text": "description",
+		"fields.assignee.displayName":             "assignee",
+		"fields.labels":                           "labels",
+	},
+}
+
+// importRecord holds the values of a record by field name. Nested JSON fields are named with dots and
+// arrays contribute a value per element, so the labels of a GitHub issue are all under labels.name.
+// Empty values are left out.
+type importRecord map[string][]string
+
+// importReader reads the data of an import stream as one file.
+type importReader struct {
+	stream grpc.ClientStreamingServer[pb.ImportTasksRequest, pb.ImportTasksResponse]
+	buf    []byte
+	// err is the error that ended the stream; io.EOF when the client finished sending.
+	err error
+}
+
+func (r *importReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		req, err := r.stream.Recv()
+		if err != nil {
+			r.err = err
+			return 0, err
+		}
+		if req.Options != nil {
+			r.err = status.Error(codes.InvalidArgument, "Import options must only be sent with the first message")
+			return 0, r.err
+		}
+		r.buf = req.Data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// broken returns the error that broke the stream, or nil if it was read to the end or not at all.
+func (r *importReader) broken() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// readImport parses a file in an import format and calls each for every record with its 1-based row.
+// Records that cannot be read are passed with an error; errors of each end the import and are returned
+// as they are, while a file that cannot be parsed any further yields an InvalidArgument error.
+func readImport(format string, r io.Reader, each func(row int, record importRecord, err error) error) error {
+	switch format {
+	case "csv":
+		return readCSVImport(r, each)
+	case "jsonl":
+		return readJSONLinesImport(r, each)
+	case "jira":
+		return readJiraImport(r, each)
+	}
+	return readJSONArrayImport(json.NewDecoder(r), each)
+}
+
+func readCSVImport(r io.Reader, each func(row int, record importRecord, err error) error) error {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return invalidImport(err)
+	}
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	for row := 1; ; row++ {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			err = fmt.Errorf("Row has %d fields but the header has %d", len(cells), len(header))
+			if err := each(row, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return invalidImport(err)
+		}
+		record := make(importRecord, len(cells))
+		for i, cell := range cells {
+			if cell = strings.TrimSpace(cell); cell != "" {
+				record[header[i]] = append(record[header[i]], cell)
+			}
+		}
+		if err := each(row, record, nil); err != nil {
+			return err
+		}
+	}
+}
+
+func readJSONLinesImport(r io.Reader, each func(row int, record importRecord, err error) error) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	for row := 1; ; row++ {
+		var value any
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return invalidImport(err)
+		}
+		record, err := jsonRecord(value)
+		if err := each(row, record, err); err != nil {
+			return err
+		}
+	}
+}
+
+// readJiraImport reads a Jira search result, whose issues are under "issues", or just its issues array.
+func readJiraImport(r io.Reader, each func(row int, record importRecord, err error) error) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return invalidImport(err)
+	}
+	if token == json.Delim('[') {
+		return readJSONArrayElements(decoder, each)
+	}
+	if token != json.Delim('{') {
+		return status.Error(codes.InvalidArgument, "Invalid file: expected a Jira search result or an array of issues")
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return invalidImport(err)
+		}
+		if key != "issues" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return invalidImport(err)
+			}
+			continue
+		}
+		return readJSONArrayImport(decoder, each)
+	}
+	return nil
+}
+
+// readJSONArrayImport reads an array of records one element at a time.
+func readJSONArrayImport(decoder *json.Decoder, each func(row int, record importRecord, err error) error) error {
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return invalidImport(err)
+	}
+	if token != json.Delim('[') {
+		return status.Error(codes.InvalidArgument, "Invalid file: expected an array of records")
+	}
+	return readJSONArrayElements(decoder, each)
+}
+
+func readJSONArrayElements(decoder *json.Decoder, each func(row int, record importRecord, err error) error) error {
+	for row := 1; decoder.More(); row++ {
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return invalidImport(err)
+		}
+		record, err := jsonRecord(value)
+		if err := each(row, record, err); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return invalidImport(err)
+	}
+	return nil
+}
+
+// jsonRecord flattens a decoded JSON object into a record.
+func jsonRecord(value any) (importRecord, error) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("Record is not a JSON object")
+	}
+	record := make(importRecord)
+	for key, item := range object {
+		flattenJSON(record, key, item)
+	}
+	return record, nil
+}
+
+func flattenJSON(record importRecord, name string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			flattenJSON(record, name+"."+key, item)
+		}
+	case []any:
+		for _, item := range v {
+			flattenJSON(record, name, item)
+		}
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			record[name] = append(record[name], v)
+		}
+	case json.Number:
+		record[name] = append(record[name], v.String())
+	case bool:
+		record[name] = append(record[name], strconv.FormatBool(v))
+	}
+}
+
+func invalidImport(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return status.Error(codes.InvalidArgument, "Invalid file: unexpected end of data")
+	}
+	return status.Errorf(codes.InvalidArgument, "Invalid file: %v", err)
+}
+
+// importMapping maps the fields of records to task fields.
+type importMapping struct {
+	fields map[string]string
+	// byName maps the fields named like a task field to that field.
+	byName bool
+}
+
+// newImportMapping combines the defaults of a format with the mapping of a request, which wins.
+func newImportMapping(format string, mapping map[string]string) importMapping {
+	fields := make(map[string]string, len(importFormats[format])+len(mapping))
+	for field, target := range importFormats[format] {
+		fields[field] = target
+	}
+	for field, target := range mapping {
+		fields[field] = target
+	}
+	return importMapping{fields: fields, byName: format == "csv" || format == "jsonl"}
+}
+
+func (m importMapping) target(field string) string {
+	if target, ok := m.fields[field]; ok {
+		return target
+	}
+	if m.byName && (slices.Contains(importTargets, field) || strings.HasPrefix(field, customFieldColumnPrefix)) {
+		return field
+	}
+	return ""
+}
+
+// row maps a record to a task of the project and validates it like CreateTask. Fields map in name order;
+// descriptions join the values mapped to them, labels collect them and other task fields take the first.
+// Labels given as text are separated by commas.
+func (m importMapping) row(projectID int64, row int, record importRecord, err error) models.ImportRow {
+	if err != nil {
+		return models.ImportRow{Row: row, Err: err}
+	}
+
+	req := &pb.CreateTaskRequest{ProjectId: projectID}
+	var sourceID string
+	var description []string
+	names := make([]string, 0, len(record))
+	for name := range record {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		values := record[name]
+		target := m.target(name)
+		switch target {
+		case "":
+		case "source_id":
+			sourceID = firstNonEmpty(sourceID, values[0])
+		case "title":
+			req.Title = firstNonEmpty(req.Title, values[0])
+		case "description":
+			description = append(description, values...)
+		case "status":
+			req.Status = firstNonEmpty(req.Status, values[0])
+		case "assignee":
+			req.Assignee = firstNonEmpty(req.Assignee, values[0])
+		case "labels":
+			for _, value := range values {
+				for _, label := range strings.Split(value, ",") {
+					if label = strings.TrimSpace(label); label != "" && !slices.Contains(req.Labels, label) {
+						req.Labels = append(req.Labels, label)
+					}
+				}
+			}
+		default:
+			field := strings.TrimPrefix(target, customFieldColumnPrefix)
+			if req.CustomFields == nil {
+				req.CustomFields = make(map[string]*pb.CustomFieldValue)
+			}
+			if _, ok := req.CustomFields[field]; !ok {
+				req.CustomFields[field] = &pb.CustomFieldValue{Value: &pb.CustomFieldValue_StringValue{StringValue: values[0]}}
+			}
+		}
+	}
+	req.Description = strings.Join(description, "\n")
+
+	sourceID = strings.TrimSpace(sourceID)
+	if len(sourceID) > MaxLength {
+		return models.ImportRow{Row: row, Err: errors.New("Source ID exceeds maximum length of 255 characters")}
+	}
+	if err := trimAndValidateCreateTaskRequest(req); err != nil {
+		return models.ImportRow{Row: row, SourceID: sourceID, Err: errors.New(status.Convert(err).Message())}
+	}
+	return models.ImportRow{Row: row, SourceID: sourceID, Task: fromCreateTaskRequest(req)}
+}
+
+func firstNonEmpty(current, value string) string {
+	if current != "" {
+		return current
+	}
+	return value
+}
+
+// toImportTasksResponse converts the summary of an import into its gRPC representation.
+func toImportTasksResponse(summary *models.ImportSummary, dryRun bool) *pb.ImportTasksResponse {
+	resp := &pb.ImportTasksResponse{
+		Created: int32(summary.Created),
+		Skipped: int32(summary.Skipped),
+		Failed:  int32(summary.Failed),
+		DryRun:  dryRun,
+	}
+	for _, rowErr := range summary.Errors {
+		resp.Errors = append(resp.Errors, &pb.ImportRowError{Row: int32(rowErr.Row), SourceId: rowErr.SourceID, Message: rowErr.Message})
+	}
+	return resp
+}
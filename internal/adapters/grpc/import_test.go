@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// importStream replays the messages of an import and keeps the response.
+type importStream struct {
+	grpc.ServerStream
+	requests []*pb.ImportTasksRequest
+	err      error
+	resp     *pb.ImportTasksResponse
+}
+
+// newImportStream sends the options with the first chunk of data.
+func newImportStream(options *pb.ImportOptions, chunks ...string) *importStream {
+	stream := &importStream{}
+	for i, chunk := range chunks {
+		req := &pb.ImportTasksRequest{Data: []byte(chunk)}
+		if i == 0 {
+			req.Options = options
+		}
+		stream.requests = append(stream.requests, req)
+	}
+	return stream
+}
+
+func (s *importStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *importStream) Recv() (*pb.ImportTasksRequest, error) {
+	if len(s.requests) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *importStream) SendAndClose(resp *pb.ImportTasksResponse) error {
+	s.resp = resp
+	return nil
+}
+
+// recordingImporter keeps the rows of an import and counts them like the task service.
+type recordingImporter struct {
+	rows []models.ImportRow
+	err  error
+}
+
+func (i *recordingImporter) Add(row models.ImportRow) error {
+	i.rows = append(i.rows, row)
+	return i.err
+}
+
+func (i *recordingImporter) Finish() (*models.ImportSummary, error) {
+	summary := &models.ImportSummary{}
+	for _, row := range i.rows {
+		if row.Err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, models.ImportRowError{Row: row.Row, SourceID: row.SourceID, Message: row.Err.Error()})
+			continue
+		}
+		summary.Created++
+	}
+	return summary, nil
+}
+
+func TestTaskHandler_ImportTasks(t *testing.T) {
+	taskImport := models.TaskImport{ProjectID: 1, Source: "csv"}
+
+	t.Run("CSV split across chunks", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		importer := &recordingImporter{}
+		mockService.On("NewTaskImport", taskImport).Return(importer, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1},
+			"\ufeffid,title,description,labels,custom_fields.points\n1,Fix login,Users can't sign in,\"bug, ui\",3\n",
+			"2,,No title,,\n3,Too,many,fields,here,x\n4,Write do", "cs,Describe the API,,\n")
+		require.NoError(t, handler.ImportTasks(stream))
+		require.Equal(t, int32(2), stream.resp.Created)
+		require.Equal(t, int32(2), stream.resp.Failed)
+
+		require.Len(t, importer.rows, 4)
+		first := importer.rows[0]
+		require.Equal(t, "1", first.SourceID)
+		require.Equal(t, &models.Task{
+			ProjectID:    1,
+			Title:        "Fix login",
+			Description:  "Users can't sign in",
+			Labels:       []string{"bug", "ui"},
+			CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeString, String: "3"}},
+		}, first.Task)
+		require.EqualError(t, importer.rows[1].Err, "Title cannot be empty")
+		require.Equal(t, "2", importer.rows[1].SourceID)
+		require.EqualError(t, importer.rows[2].Err, "Row has 6 fields but the header has 5")
+		require.Equal(t, "Write docs", importer.rows[3].Task.Title)
+		require.Equal(t, 4, importer.rows[3].Row)
+	})
+
+	t.Run("JSON Lines with nested custom fields", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		importer := &recordingImporter{}
+		mockService.On("NewTaskImport", models.TaskImport{ProjectID: 1, Source: "jsonl", DryRun: true}).Return(importer, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1, Format: "jsonl", DryRun: true},
+			`{"source_id": "a-1", "title": "One", "description": "First", "labels": ["x", "y"], "custom_fields": {"points": 2}}`+"\n"+
+				`[1, 2]`+"\n")
+		require.NoError(t, handler.ImportTasks(stream))
+		require.True(t, stream.resp.DryRun)
+
+		require.Len(t, importer.rows, 2)
+		require.Equal(t, "a-1", importer.rows[0].SourceID)
+		require.Equal(t, []string{"x", "y"}, importer.rows[0].Task.Labels)
+		require.Equal(t, "2", importer.rows[0].Task.CustomFields["points"].String)
+		require.EqualError(t, importer.rows[1].Err, "Record is not a JSON object")
+	})
+
+	t.Run("GitHub issues with a mapped state", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		importer := &recordingImporter{}
+		mockService.On("NewTaskImport", models.TaskImport{ProjectID: 1, Source: "github.com/acme/app"}).Return(importer, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1, Format: "GitHub", Source: "github.com/acme/app",
+			FieldMapping: map[string]string{"state": "status", "milestone.title": "custom_fields.milestone"}},
+			`[{"number": 42, "title": "Crash on start", "body": "Stack trace attached", "state": "open",`,
+			` "assignee": {"login": "octocat"}, "labels": [{"name": "bug"}, {"name": "p1"}], "milestone": {"title": "v2"}}]`)
+		require.NoError(t, handler.ImportTasks(stream))
+
+		require.Len(t, importer.rows, 1)
+		require.Equal(t, models.ImportRow{Row: 1, SourceID: "42", Task: &models.Task{
+			ProjectID:    1,
+			Title:        "Crash on start",
+			Description:  "Stack trace attached",
+			Status:       "open",
+			Assignee:     "octocat",
+			Labels:       []string{"bug", "p1"},
+			CustomFields: map[string]models.FieldValue{"milestone": {Type: models.FieldTypeString, String: "v2"}},
+		}}, importer.rows[0])
+	})
+
+	t.Run("Jira search result", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		importer := &recordingImporter{}
+		mockService.On("NewTaskImport", models.TaskImport{ProjectID: 1, Source: "jira"}).Return(importer, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1, Format: "jira", FieldMapping: map[string]string{"fields.labels": ""}},
+			`{"startAt": 0, "total": 1, "issues": [{"key": "PROJ-7", "fields": {"summary": "Export fails",`+
+				` "description": {"type": "doc", "content": [{"content": [{"text": "First line"}]}, {"content": [{"text": "Second line"}]}]},`+
+				` "assignee": {"displayName": "Ada"}, "labels": ["backend"]}}]}`)
+		require.NoError(t, handler.ImportTasks(stream))
+
+		require.Len(t, importer.rows, 1)
+		task := importer.rows[0].Task
+		require.Equal(t, "PROJ-7", importer.rows[0].SourceID)
+		require.Equal(t, "Export fails", task.Title)
+		require.Equal(t, "First line\nSecond line", task.Description)
+		require.Equal(t, "Ada", task.Assignee)
+		require.Empty(t, task.Labels)
+	})
+
+	t.Run("Reports row errors", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		importer := &recordingImporter{}
+		mockService.On("NewTaskImport", taskImport).Return(importer, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1}, "id,title\n7,No description\n")
+		require.NoError(t, handler.ImportTasks(stream))
+		require.Equal(t, []*pb.ImportRowError{{Row: 1, SourceId: "7", Message: "Description cannot be empty"}}, stream.resp.Errors)
+	})
+
+	for _, tc := range []struct {
+		name    string
+		stream  *importStream
+		code    codes.Code
+		message string
+	}{
+		{name: "Missing options", stream: &importStream{requests: []*pb.ImportTasksRequest{{Data: []byte("id\n")}}},
+			code: codes.InvalidArgument, message: "Import options are required in the first message"},
+		{name: "Empty stream", stream: &importStream{}, code: codes.InvalidArgument, message: "Import options are required in the first message"},
+		{name: "Unknown format", stream: newImportStream(&pb.ImportOptions{ProjectId: 1, Format: "xml"}, ""),
+			code: codes.InvalidArgument, message: "Format must be one of csv, jsonl, github and jira"},
+		{name: "Unknown target", stream: newImportStream(&pb.ImportOptions{ProjectId: 1, FieldMapping: map[string]string{"owner": "reporter"}}, ""),
+			code: codes.InvalidArgument, message: `Field mapping maps "owner" to unknown task field "reporter"`},
+		{name: "Invalid project", stream: newImportStream(&pb.ImportOptions{}, ""), code: codes.InvalidArgument, message: "ID must be greater than 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService, handler := setupHandler()
+			err := handler.ImportTasks(tc.stream)
+			require.Equal(t, tc.code, status.Code(err))
+			require.Equal(t, tc.message, status.Convert(err).Message())
+			mockService.AssertNotCalled(t, "NewTaskImport", taskImport)
+		})
+	}
+
+	t.Run("Options sent twice", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("NewTaskImport", taskImport).Return(&recordingImporter{}, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1}, "id,title,description\n")
+		stream.requests = append(stream.requests, &pb.ImportTasksRequest{Options: &pb.ImportOptions{ProjectId: 1}})
+		err := handler.ImportTasks(stream)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Malformed JSON", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("NewTaskImport", models.TaskImport{ProjectID: 1, Source: "github"}).Return(&recordingImporter{}, nil)
+
+		err := handler.ImportTasks(newImportStream(&pb.ImportOptions{ProjectId: 1, Format: "github"}, `[{"number": 1,`))
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, "Invalid file: unexpected end of data", status.Convert(err).Message())
+	})
+
+	t.Run("Broken stream", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("NewTaskImport", taskImport).Return(&recordingImporter{}, nil)
+
+		stream := newImportStream(&pb.ImportOptions{ProjectId: 1}, "id,title,description\n1,Half")
+		stream.err = status.Error(codes.Canceled, "context canceled")
+		err := handler.ImportTasks(stream)
+		require.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("Archived project", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("NewTaskImport", taskImport).Return(nil, services.ErrProjectArchived)
+
+		err := handler.ImportTasks(newImportStream(&pb.ImportOptions{ProjectId: 1}, "id,title\n"))
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("Storage failure", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("NewTaskImport", taskImport).Return(&recordingImporter{err: errors.New("connection reset")}, nil)
+
+		err := handler.ImportTasks(newImportStream(&pb.ImportOptions{ProjectId: 1}, "id,title,description\n1,One,First\n"))
+		require.Equal(t, codes.Internal, status.Code(err))
+	})
+}
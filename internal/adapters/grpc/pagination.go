@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/ids"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pageToken is the position after which the next ListTasks page starts. It holds the external ID
+// rather than the numeric ID, so tokens stay meaningful across deployments that share task data.
+// When tasks are sorted by a custom field, the token also records the order and the task's value.
+type pageToken struct {
+	Order      string `json:"o,omitempty"`
+	SortValue  any    `json:"v,omitempty"`
+	Rank       string `json:"r"`
+	ExternalID string `json:"x"`
+}
+
+// encodePageToken returns an opaque token for the page following task in the given order.
+func encodePageToken(task *models.Task, order *models.FieldOrder) string {
+	token := pageToken{Rank: task.Rank, ExternalID: task.ExternalID}
+	if order != nil {
+		token.Order = formatOrderBy(order)
+		if value, ok := task.CustomFields[order.Field]; ok {
+			token.SortValue = value.Raw()
+		}
+	}
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageToken parses a token produced by encodePageToken for the same order.
+// An empty token means the first page.
+func decodePageToken(token string, order *models.FieldOrder) (*models.TaskCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+	var decoded pageToken
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+	if _, ok := ids.Normalize(decoded.ExternalID); !ok {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+	if order == nil && decoded.Order != "" || order != nil && decoded.Order != formatOrderBy(order) {
+		return nil, status.Error(codes.InvalidArgument, "Page token was issued for a different order")
+	}
+	switch decoded.SortValue.(type) {
+	case nil, float64, string:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+
+	return &models.TaskCursor{SortValue: decoded.SortValue, Rank: decoded.Rank, ExternalID: decoded.ExternalID}, nil
+}
+
+// searchPageToken is the position after which the next SearchTasks page starts.
+type searchPageToken struct {
+	Score      float64 `json:"s"`
+	ExternalID string  `json:"x"`
+}
+
+// encodeSearchPageToken returns an opaque token for the search results following hit.
+func encodeSearchPageToken(hit *models.SearchHit) string {
+	data, _ := json.Marshal(searchPageToken{Score: hit.Score, ExternalID: hit.Task.ExternalID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSearchPageToken parses a token produced by encodeSearchPageToken. An empty token means the first page.
+func decodeSearchPageToken(token string) (*models.SearchCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+	var decoded searchPageToken
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+	if _, ok := ids.Normalize(decoded.ExternalID); !ok {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page token")
+	}
+
+	return &models.SearchCursor{Score: decoded.Score, ExternalID: decoded.ExternalID}, nil
+}
@@ -0,0 +1,209 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProjectHandler implements the gRPC ProjectManagerServer interface and handles all project-related gRPC requests.
+type ProjectHandler struct {
+	pb.UnimplementedProjectManagerServer
+	service services.ProjectService
+	logger  *zap.Logger
+}
+
+// NewProjectHandler initializes a new ProjectHandler instance.
+func NewProjectHandler(service services.ProjectService, logger *zap.Logger) pb.ProjectManagerServer {
+	return &ProjectHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateProject handles the gRPC request to create a new project.
+func (h *ProjectHandler) CreateProject(ctx context.Context, req *pb.CreateProjectRequest) (*pb.ProjectResponse, error) {
+	h.logger.Info("Received CreateProject request", zap.String("name", req.Name))
+
+	if err := trimAndValidateCreateProjectRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateProject", zap.Error(err))
+		return nil, err
+	}
+
+	project := &models.Project{
+		Key:             req.Key,
+		Name:            req.Name,
+		Description:     req.Description,
+		Workflow:        req.Workflow,
+		DefaultAssignee: req.DefaultAssignee,
+		AllowedLabels:   req.AllowedLabels,
+	}
+
+	createdProject, err := h.service.CreateProject(ctx, project)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectKeyTaken) {
+			h.logger.Warn("Project key is already taken", zap.String("key", req.Key))
+			return nil, status.Error(codes.AlreadyExists, "Project key is already taken")
+		}
+		h.logger.Error("Failed to create project", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to create project")
+	}
+
+	return toProjectResponse(createdProject), nil
+}
+
+// ListProjects handles the gRPC request to list all projects.
+func (h *ProjectHandler) ListProjects(ctx context.Context, req *pb.ListProjectsRequest) (*pb.ListProjectsResponse, error) {
+	h.logger.Info("Received ListProjects request")
+
+	projects, err := h.service.ListProjects(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list projects", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list projects")
+	}
+
+	var projectResponses []*pb.ProjectResponse
+	for _, project := range projects {
+		projectResponses = append(projectResponses, toProjectResponse(project))
+	}
+
+	return &pb.ListProjectsResponse{Projects: projectResponses}, nil
+}
+
+// GetProject handles the gRPC request to retrieve a specific project by its ID.
+func (h *ProjectHandler) GetProject(ctx context.Context, req *pb.GetProjectRequest) (*pb.ProjectResponse, error) {
+	h.logger.Info("Received GetProject request", zap.Int64("id", req.Id))
+
+	if err := validateProjectID(req.Id); err != nil {
+		h.logger.Warn("Validation failed for GetProject", zap.Error(err))
+		return nil, err
+	}
+
+	project, err := h.service.GetProject(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.NotFound, "Project not found")
+		}
+		h.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to fetch project")
+	}
+
+	return toProjectResponse(project), nil
+}
+
+// UpdateProject handles the gRPC request to update an existing project and its settings.
+func (h *ProjectHandler) UpdateProject(ctx context.Context, req *pb.UpdateProjectRequest) (*pb.ProjectResponse, error) {
+	h.logger.Info("Received UpdateProject request", zap.Int64("id", req.Id))
+
+	if err := trimAndValidateUpdateProjectRequest(req); err != nil {
+		h.logger.Warn("Validation failed for UpdateProject", zap.Error(err))
+		return nil, err
+	}
+
+	project := &models.Project{
+		ID:              req.Id,
+		Name:            req.Name,
+		Description:     req.Description,
+		Workflow:        req.Workflow,
+		DefaultAssignee: req.DefaultAssignee,
+		AllowedLabels:   req.AllowedLabels,
+	}
+
+	updatedProject, err := h.service.UpdateProject(ctx, project)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found for update", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.NotFound, "Project not found for update")
+		}
+		if errors.Is(err, services.ErrProjectArchived) {
+			h.logger.Warn("Project is archived", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.FailedPrecondition, "Project is archived")
+		}
+		h.logger.Error("Failed to update project", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to update project")
+	}
+
+	return toProjectResponse(updatedProject), nil
+}
+
+// ArchiveProject handles the gRPC request to archive a project, making its tasks read-only.
+func (h *ProjectHandler) ArchiveProject(ctx context.Context, req *pb.ArchiveProjectRequest) (*pb.ProjectResponse, error) {
+	h.logger.Info("Received ArchiveProject request", zap.Int64("id", req.Id))
+
+	return h.setArchived(ctx, req.Id, true)
+}
+
+// UnarchiveProject handles the gRPC request to make an archived project writable again.
+func (h *ProjectHandler) UnarchiveProject(ctx context.Context, req *pb.UnarchiveProjectRequest) (*pb.ProjectResponse, error) {
+	h.logger.Info("Received UnarchiveProject request", zap.Int64("id", req.Id))
+
+	return h.setArchived(ctx, req.Id, false)
+}
+
+func (h *ProjectHandler) setArchived(ctx context.Context, id int64, archived bool) (*pb.ProjectResponse, error) {
+	if err := validateProjectID(id); err != nil {
+		h.logger.Warn("Validation failed for project archive request", zap.Error(err))
+		return nil, err
+	}
+
+	project, err := h.service.SetProjectArchived(ctx, id, archived)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found", zap.Int64("id", id))
+			return nil, status.Error(codes.NotFound, "Project not found")
+		}
+		h.logger.Error("Failed to change project archive state", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to update project")
+	}
+
+	return toProjectResponse(project), nil
+}
+
+// DeleteProject handles the gRPC request to delete an empty project by its ID.
+func (h *ProjectHandler) DeleteProject(ctx context.Context, req *pb.DeleteProjectRequest) (*pb.DeleteProjectResponse, error) {
+	h.logger.Info("Received DeleteProject request", zap.Int64("id", req.Id))
+
+	if err := validateProjectID(req.Id); err != nil {
+		h.logger.Warn("Validation failed for DeleteProject", zap.Error(err))
+		return nil, err
+	}
+
+	err := h.service.DeleteProject(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found for deletion", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.NotFound, "Project not found for deletion")
+		}
+		if errors.Is(err, services.ErrProjectNotEmpty) {
+			h.logger.Warn("Project still has tasks", zap.Int64("id", req.Id))
+			return nil, status.Error(codes.FailedPrecondition, "Project still has tasks")
+		}
+		h.logger.Error("Failed to delete project", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to delete project")
+	}
+
+	return &pb.DeleteProjectResponse{Success: true}, nil
+}
+
+// toProjectResponse converts a project model into its gRPC representation.
+func toProjectResponse(project *models.Project) *pb.ProjectResponse {
+	return &pb.ProjectResponse{
+		Id:              project.ID,
+		Key:             project.Key,
+		Name:            project.Name,
+		Description:     project.Description,
+		Workflow:        project.Workflow,
+		DefaultAssignee: project.DefaultAssignee,
+		AllowedLabels:   project.AllowedLabels,
+		Archived:        project.Archived,
+		CreatedAt:       project.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       project.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockProjectService struct {
+	mock.Mock
+}
+
+func (m *MockProjectService) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	args := m.Called(project)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	args := m.Called(project)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) SetProjectArchived(ctx context.Context, id int64, archived bool) (*models.Project, error) {
+	args := m.Called(id, archived)
+	return args.Get(0).(*models.Project), args.Error(1)
+}
+
+func (m *MockProjectService) DeleteProject(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func setupProjectHandler() (*MockProjectService, *ProjectHandler) {
+	mockService := new(MockProjectService)
+	logger, _ := zap.NewDevelopment()
+	handler := &ProjectHandler{
+		service: mockService,
+		logger:  logger,
+	}
+	return mockService, handler
+}
+
+func TestProjectHandler_CreateProject(t *testing.T) {
+	mockService, handler := setupProjectHandler()
+
+	mockProject := &models.Project{
+		Key:           "OPS",
+		Name:          "Ops",
+		Workflow:      []string{"open", "done"},
+		AllowedLabels: []string{"bug"},
+	}
+
+	mockResponse := &models.Project{
+		ID:            1,
+		Key:           "OPS",
+		Name:          "Ops",
+		Workflow:      []string{"open", "done"},
+		AllowedLabels: []string{"bug"},
+	}
+
+	mockService.On("CreateProject", mockProject).Return(mockResponse, nil)
+
+	req := &pb.CreateProjectRequest{
+		Key:           " ops",
+		Name:          " Ops ",
+		Workflow:      []string{"open", " done"},
+		AllowedLabels: []string{"bug"},
+	}
+
+	resp, err := handler.CreateProject(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.Id)
+	require.Equal(t, "OPS", resp.Key)
+	require.Equal(t, []string{"open", "done"}, resp.Workflow)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestProjectHandler_CreateProject_DuplicateStatus(t *testing.T) {
+	_, handler := setupProjectHandler()
+
+	req := &pb.CreateProjectRequest{
+		Key:      "OPS",
+		Name:     "Ops",
+		Workflow: []string{"open", "open"},
+	}
+
+	_, err := handler.CreateProject(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestProjectHandler_CreateProject_KeyTaken(t *testing.T) {
+	mockService, handler := setupProjectHandler()
+
+	mockService.On("CreateProject", mock.Anything).Return((*models.Project)(nil), services.ErrProjectKeyTaken)
+
+	_, err := handler.CreateProject(context.Background(), &pb.CreateProjectRequest{Key: "OPS", Name: "Ops"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestProjectHandler_CreateProject_InvalidKey(t *testing.T) {
+	_, handler := setupProjectHandler()
+
+	_, err := handler.CreateProject(context.Background(), &pb.CreateProjectRequest{Key: "1-OPS", Name: "Ops"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestProjectHandler_ArchiveProject(t *testing.T) {
+	mockService, handler := setupProjectHandler()
+
+	mockService.On("SetProjectArchived", int64(1), true).Return(&models.Project{ID: 1, Archived: true}, nil)
+
+	resp, err := handler.ArchiveProject(context.Background(), &pb.ArchiveProjectRequest{Id: 1})
+	require.NoError(t, err)
+	require.True(t, resp.Archived)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestProjectHandler_DeleteProject_NotEmpty(t *testing.T) {
+	mockService, handler := setupProjectHandler()
+
+	mockService.On("DeleteProject", int64(1)).Return(services.ErrProjectNotEmpty)
+
+	_, err := handler.DeleteProject(context.Background(), &pb.DeleteProjectRequest{Id: 1})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
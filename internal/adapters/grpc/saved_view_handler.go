@@ -0,0 +1,287 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SavedViewHandler implements the gRPC SavedViewManagerServer interface and handles all saved view gRPC requests.
+type SavedViewHandler struct {
+	pb.UnimplementedSavedViewManagerServer
+	service services.SavedViewService
+	logger  *zap.Logger
+}
+
+// NewSavedViewHandler initializes a new SavedViewHandler instance.
+func NewSavedViewHandler(service services.SavedViewService, logger *zap.Logger) pb.SavedViewManagerServer {
+	return &SavedViewHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateSavedView handles the gRPC request to save a view for a user.
+func (h *SavedViewHandler) CreateSavedView(ctx context.Context, req *pb.CreateSavedViewRequest) (*pb.SavedViewResponse, error) {
+	h.logger.Info("Received CreateSavedView request", zap.String("owner", req.Owner), zap.String("name", req.Name))
+
+	if err := trimAndValidateCreateSavedViewRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateSavedView", zap.Error(err))
+		return nil, err
+	}
+	orderBy, err := parseOrderBy(req.OrderBy)
+	if err != nil {
+		h.logger.Warn("Invalid order", zap.Error(err))
+		return nil, err
+	}
+
+	view := &models.SavedView{
+		Owner:     req.Owner,
+		Name:      req.Name,
+		ProjectID: req.ProjectId,
+		Filter:    req.Filter,
+		OrderBy:   orderBy,
+		Columns:   req.Columns,
+		Shared:    req.Shared,
+	}
+
+	createdView, err := h.service.CreateSavedView(ctx, view)
+	if err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved view rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to create saved view", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to create saved view")
+	}
+
+	return toSavedViewResponse(createdView), nil
+}
+
+// ListSavedViews handles the gRPC request to list the views a user can see.
+func (h *SavedViewHandler) ListSavedViews(ctx context.Context, req *pb.ListSavedViewsRequest) (*pb.ListSavedViewsResponse, error) {
+	h.logger.Info("Received ListSavedViews request", zap.String("user", req.User), zap.Int64("project_id", req.ProjectId))
+
+	req.User = strings.TrimSpace(req.User)
+	if err := validateUser(req.User, "User"); err != nil {
+		h.logger.Warn("Validation failed for ListSavedViews", zap.Error(err))
+		return nil, err
+	}
+	if req.ProjectId < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+
+	views, err := h.service.ListSavedViews(ctx, req.User, req.ProjectId)
+	if err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved views unavailable", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to list saved views", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list saved views")
+	}
+
+	var viewResponses []*pb.SavedViewResponse
+	for _, view := range views {
+		viewResponses = append(viewResponses, toSavedViewResponse(view))
+	}
+
+	return &pb.ListSavedViewsResponse{Views: viewResponses}, nil
+}
+
+// GetSavedView handles the gRPC request to retrieve a saved view by its ID.
+func (h *SavedViewHandler) GetSavedView(ctx context.Context, req *pb.GetSavedViewRequest) (*pb.SavedViewResponse, error) {
+	h.logger.Info("Received GetSavedView request", zap.Int64("id", req.Id), zap.String("user", req.User))
+
+	req.User = strings.TrimSpace(req.User)
+	if err := validateSavedViewRef(req.Id, req.User); err != nil {
+		h.logger.Warn("Validation failed for GetSavedView", zap.Error(err))
+		return nil, err
+	}
+
+	view, err := h.service.GetSavedView(ctx, req.Id, req.User)
+	if err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved view unavailable", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to fetch saved view", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to fetch saved view")
+	}
+
+	return toSavedViewResponse(view), nil
+}
+
+// UpdateSavedView handles the gRPC request to replace the definition of a saved view.
+func (h *SavedViewHandler) UpdateSavedView(ctx context.Context, req *pb.UpdateSavedViewRequest) (*pb.SavedViewResponse, error) {
+	h.logger.Info("Received UpdateSavedView request", zap.Int64("id", req.Id), zap.String("user", req.User))
+
+	if err := trimAndValidateUpdateSavedViewRequest(req); err != nil {
+		h.logger.Warn("Validation failed for UpdateSavedView", zap.Error(err))
+		return nil, err
+	}
+	orderBy, err := parseOrderBy(req.OrderBy)
+	if err != nil {
+		h.logger.Warn("Invalid order", zap.Error(err))
+		return nil, err
+	}
+
+	view := &models.SavedView{
+		ID:        req.Id,
+		Name:      req.Name,
+		ProjectID: req.ProjectId,
+		Filter:    req.Filter,
+		OrderBy:   orderBy,
+		Columns:   req.Columns,
+		Shared:    req.Shared,
+	}
+
+	updatedView, err := h.service.UpdateSavedView(ctx, view, req.User)
+	if err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved view update rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to update saved view", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to update saved view")
+	}
+
+	return toSavedViewResponse(updatedView), nil
+}
+
+// DeleteSavedView handles the gRPC request to delete a saved view.
+func (h *SavedViewHandler) DeleteSavedView(ctx context.Context, req *pb.DeleteSavedViewRequest) (*pb.DeleteSavedViewResponse, error) {
+	h.logger.Info("Received DeleteSavedView request", zap.Int64("id", req.Id), zap.String("user", req.User))
+
+	req.User = strings.TrimSpace(req.User)
+	if err := validateSavedViewRef(req.Id, req.User); err != nil {
+		h.logger.Warn("Validation failed for DeleteSavedView", zap.Error(err))
+		return nil, err
+	}
+
+	if err := h.service.DeleteSavedView(ctx, req.Id, req.User); err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved view deletion rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to delete saved view", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to delete saved view")
+	}
+
+	return &pb.DeleteSavedViewResponse{Success: true}, nil
+}
+
+// ExecuteView handles the gRPC request to list a page of the tasks of a saved view.
+func (h *SavedViewHandler) ExecuteView(ctx context.Context, req *pb.ExecuteViewRequest) (*pb.ExecuteViewResponse, error) {
+	h.logger.Info("Received ExecuteView request", zap.Int64("id", req.Id), zap.String("user", req.User))
+
+	req.User = strings.TrimSpace(req.User)
+	req.PageToken = strings.TrimSpace(req.PageToken)
+	if err := validateSavedViewRef(req.Id, req.User); err != nil {
+		h.logger.Warn("Validation failed for ExecuteView", zap.Error(err))
+		return nil, err
+	}
+	if req.PageSize < 0 || req.PageSize > MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "Page size must be between 0 and %d", MaxPageSize)
+	}
+	if req.PageToken != "" && req.PageSize == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Page token requires a page size")
+	}
+
+	view, err := h.service.GetSavedView(ctx, req.Id, req.User)
+	if err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved view unavailable", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to fetch saved view", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to fetch saved view")
+	}
+
+	// A token of a page listed before the view's order changed no longer applies.
+	after, err := decodePageToken(req.PageToken, view.OrderBy)
+	if err != nil {
+		h.logger.Warn("Invalid page token", zap.Error(err))
+		return nil, err
+	}
+	var limit int
+	if req.PageSize > 0 {
+		// One extra task tells whether another page follows.
+		limit = int(req.PageSize) + 1
+	}
+
+	tasks, err := h.service.ExecuteView(ctx, view, limit, after)
+	if err != nil {
+		if st := savedViewError(err); st != nil {
+			h.logger.Warn("Saved view cannot be executed", zap.Error(err))
+			return nil, st
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Saved view cannot be executed", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to execute saved view", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to execute saved view")
+	}
+
+	var nextPageToken string
+	if req.PageSize > 0 && len(tasks) > int(req.PageSize) {
+		tasks = tasks[:req.PageSize]
+		nextPageToken = encodePageToken(tasks[len(tasks)-1], view.OrderBy)
+	}
+
+	response := &pb.ExecuteViewResponse{View: toSavedViewResponse(view), NextPageToken: nextPageToken}
+	for _, task := range tasks {
+		response.Tasks = append(response.Tasks, toTaskResponse(task, timeZoneOf(ctx)))
+	}
+
+	return response, nil
+}
+
+// savedViewError maps the errors of the saved view service to gRPC status errors. It returns nil for any other error.
+func savedViewError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrSavedViewNotFound):
+		return status.Error(codes.NotFound, "Saved view not found")
+	case errors.Is(err, services.ErrProjectNotFound):
+		return status.Error(codes.NotFound, "Project not found")
+	case errors.Is(err, services.ErrSavedViewNameTaken):
+		return status.Error(codes.AlreadyExists, "Saved view name is already taken")
+	case errors.Is(err, services.ErrSavedViewForbidden):
+		return status.Error(codes.PermissionDenied, "Only the owner can change a saved view")
+	case errors.Is(err, services.ErrInvalidSavedView):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrSavedViewBroken):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return nil
+}
+
+// toSavedViewResponse converts a saved view model into its gRPC representation.
+func toSavedViewResponse(view *models.SavedView) *pb.SavedViewResponse {
+	response := &pb.SavedViewResponse{
+		Id:        view.ID,
+		Owner:     view.Owner,
+		Name:      view.Name,
+		ProjectId: view.ProjectID,
+		Filter:    view.Filter,
+		Columns:   view.Columns,
+		Shared:    view.Shared,
+		CreatedAt: view.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: view.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if view.OrderBy != nil {
+		response.OrderBy = formatOrderBy(view.OrderBy)
+	}
+	for _, problem := range view.Problems {
+		response.Problems = append(response.Problems, &pb.ViewProblem{Part: problem.Part, Message: problem.Message})
+	}
+	return response
+}
@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockSavedViewService struct {
+	mock.Mock
+}
+
+func (m *MockSavedViewService) CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	args := m.Called(view)
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) GetSavedView(ctx context.Context, id int64, user string) (*models.SavedView, error) {
+	args := m.Called(id, user)
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) ListSavedViews(ctx context.Context, user string, projectID int64) ([]*models.SavedView, error) {
+	args := m.Called(user, projectID)
+	return args.Get(0).([]*models.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) UpdateSavedView(ctx context.Context, view *models.SavedView, user string) (*models.SavedView, error) {
+	args := m.Called(view, user)
+	return args.Get(0).(*models.SavedView), args.Error(1)
+}
+
+func (m *MockSavedViewService) DeleteSavedView(ctx context.Context, id int64, user string) error {
+	args := m.Called(id, user)
+	return args.Error(0)
+}
+
+func (m *MockSavedViewService) ExecuteView(ctx context.Context, view *models.SavedView, limit int, after *models.TaskCursor) ([]*models.Task, error) {
+	args := m.Called(view, limit, after)
+	return args.Get(0).([]*models.Task), args.Error(1)
+}
+
+func setupSavedViewHandler() (*MockSavedViewService, *SavedViewHandler) {
+	mockService := new(MockSavedViewService)
+	logger, _ := zap.NewDevelopment()
+	handler := &SavedViewHandler{
+		service: mockService,
+		logger:  logger,
+	}
+	return mockService, handler
+}
+
+func TestSavedViewHandler_CreateSavedView(t *testing.T) {
+	mockService, handler := setupSavedViewHandler()
+
+	view := &models.SavedView{Owner: "alice", Name: "Big bugs", ProjectID: 1, Filter: `labels:bug`,
+		OrderBy: &models.FieldOrder{Field: "points", Descending: true}, Columns: []string{"key", "title"}}
+	created := *view
+	created.ID = 1
+	mockService.On("CreateSavedView", view).Return(&created, nil)
+
+	resp, err := handler.CreateSavedView(context.Background(), &pb.CreateSavedViewRequest{
+		Owner:     " alice ",
+		Name:      "Big bugs",
+		ProjectId: 1,
+		Filter:    ` labels:bug `,
+		OrderBy:   "points DESC",
+		Columns:   []string{"key", " title "},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.Id)
+	require.Equal(t, "points desc", resp.OrderBy)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSavedViewHandler_CreateSavedView_Invalid(t *testing.T) {
+	mockService, handler := setupSavedViewHandler()
+
+	_, err := handler.CreateSavedView(context.Background(), &pb.CreateSavedViewRequest{Name: "No owner"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = handler.CreateSavedView(context.Background(), &pb.CreateSavedViewRequest{Owner: "alice", Name: "V", Columns: []string{"key", "key"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.On("CreateSavedView", mock.Anything).
+		Return((*models.SavedView)(nil), fmt.Errorf("%w: filter: unknown field", services.ErrInvalidSavedView))
+	_, err = handler.CreateSavedView(context.Background(), &pb.CreateSavedViewRequest{Owner: "alice", Name: "V", Filter: "titel:x"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSavedViewHandler_UpdateSavedView_Forbidden(t *testing.T) {
+	mockService, handler := setupSavedViewHandler()
+
+	mockService.On("UpdateSavedView", &models.SavedView{ID: 2, Name: "Mine", Columns: []string{}}, "alice").
+		Return((*models.SavedView)(nil), services.ErrSavedViewForbidden)
+
+	_, err := handler.UpdateSavedView(context.Background(), &pb.UpdateSavedViewRequest{Id: 2, User: "alice", Name: "Mine"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSavedViewHandler_ExecuteView(t *testing.T) {
+	mockService, handler := setupSavedViewHandler()
+
+	view := &models.SavedView{ID: 1, Owner: "alice", Name: "Big bugs", ProjectID: 1,
+		Problems: []models.ViewProblem{{Part: models.ViewPartColumns, Message: `unknown custom field "env"`}}}
+	first := &models.Task{ID: 1, ExternalID: "01KDVDNA000000000000000001", Rank: "i"}
+	second := &models.Task{ID: 2, ExternalID: "01KDVDNA000000000000000002", Rank: "k"}
+
+	mockService.On("GetSavedView", int64(1), "bob").Return(view, nil)
+	mockService.On("ExecuteView", view, 2, (*models.TaskCursor)(nil)).Return([]*models.Task{first, second}, nil)
+
+	resp, err := handler.ExecuteView(context.Background(), &pb.ExecuteViewRequest{Id: 1, User: "bob", PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 1)
+	require.NotEmpty(t, resp.NextPageToken)
+	require.Len(t, resp.View.Problems, 1)
+	require.Equal(t, "columns", resp.View.Problems[0].Part)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSavedViewHandler_ExecuteView_Broken(t *testing.T) {
+	mockService, handler := setupSavedViewHandler()
+
+	view := &models.SavedView{ID: 1, Owner: "alice"}
+	mockService.On("GetSavedView", int64(1), "alice").Return(view, nil)
+	mockService.On("ExecuteView", view, 0, (*models.TaskCursor)(nil)).
+		Return([]*models.Task(nil), fmt.Errorf(`%w: order_by: unknown custom field "points"`, services.ErrSavedViewBroken))
+
+	_, err := handler.ExecuteView(context.Background(), &pb.ExecuteViewRequest{Id: 1, User: "alice"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "order_by")
+
+	mockService.AssertExpectations(t)
+}
@@ -3,26 +3,39 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
 	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"io"
+	"strings"
+	"time"
 )
 
 // TaskHandler implements the gRPC TaskManagerServer interface and handles all task-related gRPC requests.
 type TaskHandler struct {
 	pb.UnimplementedTaskManagerServer
 	service services.TaskService
-	logger  *zap.Logger
+	// batchMaxSize is the largest number of items a batch request may carry.
+	batchMaxSize int
+	// calendarTokens checks the tokens of calendar feed subscriptions.
+	calendarTokens *CalendarTokens
+	logger         *zap.Logger
 }
 
 // NewTaskHandler initializes a new TaskHandler instance.
-func NewTaskHandler(service services.TaskService, logger *zap.Logger) pb.TaskManagerServer {
+func NewTaskHandler(service services.TaskService, batchMaxSize int, calendarTokens *CalendarTokens, logger *zap.Logger) pb.TaskManagerServer {
 	return &TaskHandler{
-		service: service,
-		logger:  logger,
+		service:        service,
+		batchMaxSize:   batchMaxSize,
+		calendarTokens: calendarTokens,
+		logger:         logger,
 	}
 }
 
@@ -35,60 +48,164 @@ func (h *TaskHandler) CreateTask(ctx context.Context, req *pb.CreateTaskRequest)
 		return nil, err
 	}
 
-	task := &models.Task{
-		Title:       req.Title,
-		Description: req.Description,
-	}
-
-	createdTask, err := h.service.CreateTask(task)
+	createdTask, err := h.service.CreateTask(ctx, fromCreateTaskRequest(req))
 	if err != nil {
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Task rejected by project rules", zap.Error(err))
+			return nil, st
+		}
 		h.logger.Error("Failed to create task", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to create task")
 	}
 
-	return &pb.TaskResponse{
-		Id:          createdTask.ID,
-		Title:       createdTask.Title,
-		Description: createdTask.Description,
-		CreatedAt:   createdTask.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   createdTask.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return toTaskResponse(createdTask, timeZoneOf(ctx)), nil
 }
 
 // ListTasks handles the gRPC request to list all tasks.
 func (h *TaskHandler) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
-	h.logger.Info("Received ListTasks request")
+	h.logger.Info("Received ListTasks request", zap.Int64("project_id", req.ProjectId))
+
+	if err := trimAndValidateListTasksRequest(req); err != nil {
+		h.logger.Warn("Validation failed for ListTasks", zap.Error(err))
+		return nil, err
+	}
+
+	orderBy, err := parseOrderBy(req.OrderBy)
+	if err != nil {
+		h.logger.Warn("Invalid order", zap.Error(err))
+		return nil, err
+	}
+	after, err := decodePageToken(req.PageToken, orderBy)
+	if err != nil {
+		h.logger.Warn("Invalid page token", zap.Error(err))
+		return nil, err
+	}
 
-	tasks, err := h.service.ListTasks()
+	filter := models.TaskFilter{
+		ProjectID:       req.ProjectId,
+		Status:          req.Status,
+		Expression:      req.Filter,
+		OrderBy:         orderBy,
+		After:           after,
+		IncludeArchived: req.IncludeArchived,
+	}
+	for _, condition := range req.CustomFieldFilters {
+		filter.CustomFields = append(filter.CustomFields, models.FieldCondition{
+			Field:    condition.Field,
+			Operator: condition.Operator,
+			Value:    fromCustomFieldValue(condition.Value),
+		})
+	}
+	if req.PageSize > 0 {
+		// One extra task tells whether another page follows.
+		filter.Limit = int(req.PageSize) + 1
+	}
+
+	tasks, err := h.service.ListTasks(ctx, filter)
 	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found", zap.Int64("project_id", req.ProjectId))
+			return nil, status.Error(codes.NotFound, "Project not found")
+		}
+		if errors.Is(err, services.ErrInvalidFilter) {
+			h.logger.Warn("Invalid filter", zap.Error(err))
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Invalid custom field filter", zap.Error(err))
+			return nil, st
+		}
 		h.logger.Error("Failed to list tasks", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to list tasks")
 	}
 
+	var nextPageToken string
+	if req.PageSize > 0 && len(tasks) > int(req.PageSize) {
+		tasks = tasks[:req.PageSize]
+		nextPageToken = encodePageToken(tasks[len(tasks)-1], orderBy)
+	}
+
 	var taskResponses []*pb.TaskResponse
 	for _, task := range tasks {
-		taskResponses = append(taskResponses, &pb.TaskResponse{
-			Id:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		taskResponses = append(taskResponses, toTaskResponse(task, timeZoneOf(ctx)))
+	}
+
+	return &pb.ListTasksResponse{Tasks: taskResponses, NextPageToken: nextPageToken}, nil
+}
+
+// SearchTasks handles the gRPC request to find tasks by the words of their title and description.
+func (h *TaskHandler) SearchTasks(ctx context.Context, req *pb.SearchTasksRequest) (*pb.SearchTasksResponse, error) {
+	h.logger.Info("Received SearchTasks request", zap.String("query", req.Query), zap.Int64("project_id", req.ProjectId))
+
+	if err := trimAndValidateSearchTasksRequest(req); err != nil {
+		h.logger.Warn("Validation failed for SearchTasks", zap.Error(err))
+		return nil, err
+	}
+
+	after, err := decodeSearchPageToken(req.PageToken)
+	if err != nil {
+		h.logger.Warn("Invalid page token", zap.Error(err))
+		return nil, err
+	}
+
+	// One extra result tells whether another page follows.
+	search := models.TaskSearch{Query: req.Query, ProjectID: req.ProjectId, Limit: int(req.PageSize) + 1, After: after}
+
+	hits, err := h.service.SearchTasks(ctx, search)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSearchQuery) {
+			h.logger.Warn("Invalid search query", zap.Error(err))
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found", zap.Int64("project_id", req.ProjectId))
+			return nil, status.Error(codes.NotFound, "Project not found")
+		}
+		h.logger.Error("Failed to search tasks", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to search tasks")
+	}
+
+	var nextPageToken string
+	if len(hits) > int(req.PageSize) {
+		hits = hits[:req.PageSize]
+		nextPageToken = encodeSearchPageToken(hits[len(hits)-1])
+	}
+
+	var results []*pb.SearchResult
+	for _, hit := range hits {
+		results = append(results, &pb.SearchResult{
+			Task:               toTaskResponse(hit.Task, timeZoneOf(ctx)),
+			Score:              hit.Score,
+			TitleSnippet:       hit.TitleSnippet,
+			DescriptionSnippet: hit.DescriptionSnippet,
 		})
 	}
 
-	return &pb.ListTasksResponse{Tasks: taskResponses}, nil
+	return &pb.SearchTasksResponse{Results: results, NextPageToken: nextPageToken}, nil
 }
 
 // GetTask handles the gRPC request to retrieve a specific task by its ID.
 func (h *TaskHandler) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.TaskResponse, error) {
-	h.logger.Info("Received GetTask request", zap.Int64("id", req.Id))
+	h.logger.Info("Received GetTask request", zap.Int64("id", req.Id), zap.String("key", req.Key),
+		zap.String("external_id", req.ExternalId))
 
 	if err := trimAndValidateGetTaskRequest(req); err != nil {
 		h.logger.Warn("Validation failed for GetTask", zap.Error(err))
 		return nil, err
 	}
 
-	task, err := h.service.GetTask(req.Id)
+	var task *models.Task
+	var err error
+	if req.IncludeArchived {
+		task, err = h.service.GetTaskIncludingArchived(ctx, models.TaskRef{ID: req.Id, Key: req.Key, ExternalID: req.ExternalId})
+	} else {
+		var id int64
+		if id, err = h.resolveTaskID(ctx, req.Id, req.Key, req.ExternalId); err != nil {
+			return nil, err
+		}
+		req.Id = id
+		task, err = h.service.GetTask(ctx, req.Id)
+	}
 	if err != nil {
 		if errors.Is(err, services.ErrTaskNotFound) {
 			h.logger.Warn("Task not found", zap.Int64("id", req.Id))
@@ -97,68 +214,859 @@ func (h *TaskHandler) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.
 		h.logger.Error("Failed to fetch task", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to fetch task")
 	}
+	if req.ProjectId != 0 && task.ProjectID != req.ProjectId {
+		h.logger.Warn("Task not found in project", zap.Int64("id", req.Id), zap.Int64("project_id", req.ProjectId))
+		return nil, status.Error(codes.NotFound, "Task not found")
+	}
 
-	return &pb.TaskResponse{
-		Id:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return toTaskResponse(task, timeZoneOf(ctx)), nil
 }
 
 // UpdateTask handles the gRPC request to update an existing task.
 func (h *TaskHandler) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.TaskResponse, error) {
-	h.logger.Info("Received UpdateTask request", zap.Int64("id", req.Id))
+	h.logger.Info("Received UpdateTask request", zap.Int64("id", req.Id), zap.String("key", req.Key),
+		zap.String("external_id", req.ExternalId))
 
 	if err := trimAndValidateUpdateTaskRequest(req); err != nil {
 		h.logger.Warn("Validation failed for UpdateTask", zap.Error(err))
 		return nil, err
 	}
 
-	task := &models.Task{
-		ID:          req.Id,
-		Title:       req.Title,
-		Description: req.Description,
+	id, err := h.resolveTaskID(ctx, req.Id, req.Key, req.ExternalId)
+	if err != nil {
+		return nil, err
 	}
+	req.Id = id
 
-	updatedTask, err := h.service.UpdateTask(task)
+	updatedTask, err := h.service.UpdateTask(ctx, fromUpdateTaskRequest(req))
 	if err != nil {
 		if errors.Is(err, services.ErrTaskNotFound) {
 			h.logger.Warn("Task not found for update", zap.Int64("id", req.Id))
 			return nil, status.Error(codes.NotFound, "Task not found for update")
 		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Task update rejected by project rules", zap.Error(err))
+			return nil, st
+		}
 		h.logger.Error("Failed to update task", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to update task")
 	}
 
-	return &pb.TaskResponse{
-		Id:          updatedTask.ID,
-		Title:       updatedTask.Title,
-		Description: updatedTask.Description,
-		CreatedAt:   updatedTask.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   updatedTask.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return toTaskResponse(updatedTask, timeZoneOf(ctx)), nil
 }
 
 // DeleteTask handles the gRPC request to delete a task by its ID.
 func (h *TaskHandler) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*pb.DeleteTaskResponse, error) {
-	h.logger.Info("Received DeleteTask request", zap.Int64("id", req.Id))
+	h.logger.Info("Received DeleteTask request", zap.Int64("id", req.Id), zap.String("key", req.Key),
+		zap.String("external_id", req.ExternalId))
 
 	if err := trimAndValidateDeleteTaskRequest(req); err != nil {
 		h.logger.Warn("Validation failed for DeleteTask", zap.Error(err))
 		return nil, err
 	}
 
-	err := h.service.DeleteTask(req.Id)
+	id, err := h.resolveTaskID(ctx, req.Id, req.Key, req.ExternalId)
+	if err != nil {
+		return nil, err
+	}
+	req.Id = id
+
+	if req.ProjectId != 0 {
+		task, err := h.service.GetTask(ctx, req.Id)
+		if err == nil && task.ProjectID != req.ProjectId {
+			err = services.ErrTaskNotFound
+		}
+		if errors.Is(err, services.ErrTaskNotFound) {
+			h.logger.Warn("Task not found in project for deletion", zap.Int64("id", req.Id), zap.Int64("project_id", req.ProjectId))
+			return nil, status.Error(codes.NotFound, "Task not found for deletion")
+		}
+		if err != nil {
+			h.logger.Error("Failed to fetch task for deletion", zap.Error(err))
+			return nil, status.Error(codes.Internal, "Failed to delete task")
+		}
+	}
+
+	err = h.service.DeleteTask(ctx, req.Id)
 	if err != nil {
 		if errors.Is(err, services.ErrTaskNotFound) {
 			h.logger.Warn("Task not found for deletion", zap.Int64("id", req.Id))
 			return nil, status.Error(codes.NotFound, "Task not found for deletion")
 		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Task deletion rejected by project rules", zap.Error(err))
+			return nil, st
+		}
 		h.logger.Error("Failed to delete task", zap.Error(err))
 		return nil, status.Error(codes.Internal, "Failed to delete task")
 	}
 
 	return &pb.DeleteTaskResponse{Success: true}, nil
 }
+
+// MoveTask handles the gRPC request to reorder a task on the board.
+func (h *TaskHandler) MoveTask(ctx context.Context, req *pb.MoveTaskRequest) (*pb.TaskResponse, error) {
+	h.logger.Info("Received MoveTask request", zap.Int64("id", req.Id), zap.String("external_id", req.ExternalId))
+
+	if err := trimAndValidateMoveTaskRequest(req); err != nil {
+		h.logger.Warn("Validation failed for MoveTask", zap.Error(err))
+		return nil, err
+	}
+
+	move := models.TaskMove{
+		ProjectID: req.ProjectId,
+		Status:    req.Status,
+	}
+	var err error
+	if move.TaskID, err = h.resolveTaskID(ctx, req.Id, "", req.ExternalId); err != nil {
+		return nil, err
+	}
+	if move.AfterID, err = h.resolveTaskID(ctx, req.AfterTaskId, "", req.AfterTaskExternalId); err != nil {
+		return nil, err
+	}
+	if move.BeforeID, err = h.resolveTaskID(ctx, req.BeforeTaskId, "", req.BeforeTaskExternalId); err != nil {
+		return nil, err
+	}
+
+	movedTask, err := h.service.MoveTask(ctx, move)
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			h.logger.Warn("Task not found for move", zap.Int64("id", move.TaskID))
+			return nil, status.Error(codes.NotFound, "Task not found for move")
+		}
+		if errors.Is(err, services.ErrInvalidMove) {
+			h.logger.Warn("Invalid task move", zap.Error(err))
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Task move rejected by project rules", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to move task", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to move task")
+	}
+
+	return toTaskResponse(movedTask, timeZoneOf(ctx)), nil
+}
+
+// TransferTask handles the gRPC request to move a task to another project.
+func (h *TaskHandler) TransferTask(ctx context.Context, req *pb.TransferTaskRequest) (*pb.TaskResponse, error) {
+	h.logger.Info("Received TransferTask request", zap.Int64("id", req.Id), zap.String("key", req.Key),
+		zap.Int64("target_project_id", req.TargetProjectId))
+
+	if err := trimAndValidateTransferTaskRequest(req); err != nil {
+		h.logger.Warn("Validation failed for TransferTask", zap.Error(err))
+		return nil, err
+	}
+
+	id, err := h.resolveTaskID(ctx, req.Id, req.Key, req.ExternalId)
+	if err != nil {
+		return nil, err
+	}
+
+	transferredTask, err := h.service.TransferTask(ctx, models.TaskTransfer{TaskID: id, ProjectID: req.TargetProjectId, Subtree: req.IncludeSubtree})
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			h.logger.Warn("Task not found for transfer", zap.Int64("id", id))
+			return nil, status.Error(codes.NotFound, "Task not found for transfer")
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Task transfer rejected by project rules", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to transfer task", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to transfer task")
+	}
+
+	return toTaskResponse(transferredTask, timeZoneOf(ctx)), nil
+}
+
+// CloneTask handles the gRPC request to create a copy of a task.
+func (h *TaskHandler) CloneTask(ctx context.Context, req *pb.CloneTaskRequest) (*pb.TaskResponse, error) {
+	h.logger.Info("Received CloneTask request", zap.Int64("id", req.Id), zap.String("key", req.Key))
+
+	if err := trimAndValidateCloneTaskRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CloneTask", zap.Error(err))
+		return nil, err
+	}
+
+	id, err := h.resolveTaskID(ctx, req.Id, req.Key, req.ExternalId)
+	if err != nil {
+		return nil, err
+	}
+
+	clonedTask, err := h.service.CloneTask(ctx, models.TaskClone{
+		TaskID:       id,
+		ProjectID:    req.TargetProjectId,
+		Title:        req.Title,
+		Labels:       req.IncludeLabels,
+		CustomFields: req.IncludeCustomFields,
+		Subtasks:     req.IncludeSubtasks,
+		Checklist:    req.IncludeChecklist,
+		Comments:     req.IncludeComments,
+		Attachments:  req.IncludeAttachments,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			h.logger.Warn("Task not found for cloning", zap.Int64("id", id))
+			return nil, status.Error(codes.NotFound, "Task not found")
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Task clone rejected by project rules", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to clone task", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to clone task")
+	}
+
+	return toTaskResponse(clonedTask, timeZoneOf(ctx)), nil
+}
+
+// CreateTaskRelations handles the gRPC request to relate a task to other tasks.
+func (h *TaskHandler) CreateTaskRelations(ctx context.Context, req *pb.CreateTaskRelationsRequest) (*pb.TaskRelationsResponse, error) {
+	h.logger.Info("Received CreateTaskRelations request", zap.Int64("task_id", req.TaskId), zap.Int("count", len(req.Relations)))
+
+	if err := trimAndValidateCreateTaskRelationsRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateTaskRelations", zap.Error(err))
+		return nil, err
+	}
+
+	if err := h.service.CreateTaskRelations(ctx, fromTaskRelations(req.Relations)); err != nil {
+		h.logger.Warn("Failed to create task relations", zap.Error(err))
+		return nil, taskError(err, "Failed to create task relations")
+	}
+
+	return h.ListTaskRelations(ctx, &pb.ListTaskRelationsRequest{TaskId: req.TaskId})
+}
+
+// ListTaskRelations handles the gRPC request to list the relations of a task.
+func (h *TaskHandler) ListTaskRelations(ctx context.Context, req *pb.ListTaskRelationsRequest) (*pb.TaskRelationsResponse, error) {
+	h.logger.Info("Received ListTaskRelations request", zap.Int64("task_id", req.TaskId))
+
+	if req.TaskId < MinId {
+		return nil, status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+
+	relations, err := h.service.ListTaskRelations(ctx, req.TaskId)
+	if err != nil {
+		h.logger.Warn("Failed to list task relations", zap.Error(err))
+		return nil, taskError(err, "Failed to list task relations")
+	}
+
+	return &pb.TaskRelationsResponse{Relations: toTaskRelations(relations)}, nil
+}
+
+// DeleteTaskRelation handles the gRPC request to delete a relation of a task.
+func (h *TaskHandler) DeleteTaskRelation(ctx context.Context, req *pb.DeleteTaskRelationRequest) (*pb.DeleteTaskRelationResponse, error) {
+	h.logger.Info("Received DeleteTaskRelation request", zap.Int64("task_id", req.TaskId), zap.Int64("related_task_id", req.RelatedTaskId),
+		zap.String("type", req.Type))
+
+	if err := trimAndValidateDeleteTaskRelationRequest(req); err != nil {
+		h.logger.Warn("Validation failed for DeleteTaskRelation", zap.Error(err))
+		return nil, err
+	}
+
+	err := h.service.DeleteTaskRelation(ctx, models.TaskRelation{TaskID: req.TaskId, RelatedTaskID: req.RelatedTaskId, Type: req.Type})
+	if err != nil {
+		h.logger.Warn("Failed to delete task relation", zap.Error(err))
+		return nil, taskError(err, "Failed to delete task relation")
+	}
+
+	return &pb.DeleteTaskRelationResponse{Success: true}, nil
+}
+
+// SetTaskChecklist handles the gRPC request to replace the checklist of a task.
+func (h *TaskHandler) SetTaskChecklist(ctx context.Context, req *pb.SetTaskChecklistRequest) (*pb.TaskChecklistResponse, error) {
+	h.logger.Info("Received SetTaskChecklist request", zap.Int64("task_id", req.TaskId), zap.Int("count", len(req.Items)))
+
+	if err := trimAndValidateSetTaskChecklistRequest(req); err != nil {
+		h.logger.Warn("Validation failed for SetTaskChecklist", zap.Error(err))
+		return nil, err
+	}
+
+	items := make([]models.ChecklistItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.ChecklistItem{Text: item.Text, Done: item.Done}
+	}
+	checklist, err := h.service.SetTaskChecklist(ctx, req.TaskId, items)
+	if err != nil {
+		h.logger.Warn("Failed to set task checklist", zap.Error(err))
+		return nil, taskError(err, "Failed to set task checklist")
+	}
+
+	return &pb.TaskChecklistResponse{Items: toChecklistItems(checklist)}, nil
+}
+
+// GetTaskChecklist handles the gRPC request to get the checklist of a task.
+func (h *TaskHandler) GetTaskChecklist(ctx context.Context, req *pb.GetTaskChecklistRequest) (*pb.TaskChecklistResponse, error) {
+	h.logger.Info("Received GetTaskChecklist request", zap.Int64("task_id", req.TaskId))
+
+	if req.TaskId < MinId {
+		return nil, status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+
+	checklist, err := h.service.GetTaskChecklist(ctx, req.TaskId)
+	if err != nil {
+		h.logger.Warn("Failed to get task checklist", zap.Error(err))
+		return nil, taskError(err, "Failed to get task checklist")
+	}
+
+	return &pb.TaskChecklistResponse{Items: toChecklistItems(checklist)}, nil
+}
+
+// CreateTaskComment handles the gRPC request to comment on a task.
+func (h *TaskHandler) CreateTaskComment(ctx context.Context, req *pb.CreateTaskCommentRequest) (*pb.TaskComment, error) {
+	h.logger.Info("Received CreateTaskComment request", zap.Int64("task_id", req.TaskId))
+
+	if err := trimAndValidateCreateTaskCommentRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateTaskComment", zap.Error(err))
+		return nil, err
+	}
+
+	comment, err := h.service.CreateTaskComment(ctx, &models.TaskComment{TaskID: req.TaskId, Author: req.Author, Body: req.Body})
+	if err != nil {
+		h.logger.Warn("Failed to create task comment", zap.Error(err))
+		return nil, taskError(err, "Failed to create task comment")
+	}
+
+	return toTaskComment(comment), nil
+}
+
+// ListTaskComments handles the gRPC request to list the comments of a task.
+func (h *TaskHandler) ListTaskComments(ctx context.Context, req *pb.ListTaskCommentsRequest) (*pb.ListTaskCommentsResponse, error) {
+	h.logger.Info("Received ListTaskComments request", zap.Int64("task_id", req.TaskId))
+
+	if req.TaskId < MinId {
+		return nil, status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+
+	comments, err := h.service.ListTaskComments(ctx, req.TaskId)
+	if err != nil {
+		h.logger.Warn("Failed to list task comments", zap.Error(err))
+		return nil, taskError(err, "Failed to list task comments")
+	}
+
+	response := &pb.ListTaskCommentsResponse{Comments: make([]*pb.TaskComment, len(comments))}
+	for i, comment := range comments {
+		response.Comments[i] = toTaskComment(comment)
+	}
+	return response, nil
+}
+
+// CreateTaskAttachment handles the gRPC request to attach a file to a task.
+func (h *TaskHandler) CreateTaskAttachment(ctx context.Context, req *pb.CreateTaskAttachmentRequest) (*pb.TaskAttachment, error) {
+	h.logger.Info("Received CreateTaskAttachment request", zap.Int64("task_id", req.TaskId), zap.String("name", req.Name))
+
+	if err := trimAndValidateCreateTaskAttachmentRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateTaskAttachment", zap.Error(err))
+		return nil, err
+	}
+
+	attachment, err := h.service.CreateTaskAttachment(ctx, &models.TaskAttachment{
+		TaskID:      req.TaskId,
+		Name:        req.Name,
+		URL:         req.Url,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+	})
+	if err != nil {
+		h.logger.Warn("Failed to create task attachment", zap.Error(err))
+		return nil, taskError(err, "Failed to create task attachment")
+	}
+
+	return toTaskAttachment(attachment), nil
+}
+
+// ListTaskAttachments handles the gRPC request to list the attachments of a task.
+func (h *TaskHandler) ListTaskAttachments(ctx context.Context, req *pb.ListTaskAttachmentsRequest) (*pb.ListTaskAttachmentsResponse, error) {
+	h.logger.Info("Received ListTaskAttachments request", zap.Int64("task_id", req.TaskId))
+
+	if req.TaskId < MinId {
+		return nil, status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+
+	attachments, err := h.service.ListTaskAttachments(ctx, req.TaskId)
+	if err != nil {
+		h.logger.Warn("Failed to list task attachments", zap.Error(err))
+		return nil, taskError(err, "Failed to list task attachments")
+	}
+
+	response := &pb.ListTaskAttachmentsResponse{Attachments: make([]*pb.TaskAttachment, len(attachments))}
+	for i, attachment := range attachments {
+		response.Attachments[i] = toTaskAttachment(attachment)
+	}
+	return response, nil
+}
+
+// ExportTasks handles the gRPC request to stream the tasks matching a filter as a CSV, JSON Lines or Markdown file.
+// Errors after the first chunk end the stream, leaving the client with a partial file and the error status.
+func (h *TaskHandler) ExportTasks(req *pb.ExportTasksRequest, stream grpc.ServerStreamingServer[httpbody.HttpBody]) error {
+	h.logger.Info("Received ExportTasks request", zap.Int64("project_id", req.ProjectId), zap.String("format", req.Format))
+
+	if err := trimAndValidateExportTasksRequest(req); err != nil {
+		h.logger.Warn("Validation failed for ExportTasks", zap.Error(err))
+		return err
+	}
+
+	orderBy, err := parseOrderBy(req.OrderBy)
+	if err != nil {
+		h.logger.Warn("Invalid order", zap.Error(err))
+		return err
+	}
+	location := timeZoneOf(stream.Context())
+	if req.TimeZone != "" {
+		if location, err = parseTimeZone(req.TimeZone); err != nil {
+			h.logger.Warn("Invalid time zone", zap.String("time_zone", req.TimeZone), zap.Error(err))
+			return err
+		}
+	}
+	columns := req.Columns
+	if len(columns) == 0 {
+		columns = defaultExportColumns
+	}
+
+	disposition := fmt.Sprintf(`attachment; filename="tasks.%s"`, exportFormats[req.Format].extension)
+	if err := stream.SetHeader(metadata.Pairs("content-disposition", disposition)); err != nil {
+		h.logger.Warn("Failed to set export headers", zap.Error(err))
+	}
+
+	writer := newExportWriter(req.Format, columns, location, stream.Send)
+	export := models.TaskExport{
+		Filter:  models.TaskFilter{ProjectID: req.ProjectId, Status: req.Status, Expression: req.Filter, OrderBy: orderBy},
+		Columns: columns,
+	}
+	err = h.service.ExportTasks(stream.Context(), export, writer.WriteTask)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProjectNotFound):
+			h.logger.Warn("Project not found", zap.Int64("project_id", req.ProjectId))
+			return status.Error(codes.NotFound, "Project not found")
+		case errors.Is(err, services.ErrInvalidFilter), errors.Is(err, services.ErrUnknownColumn):
+			h.logger.Warn("Invalid export", zap.Error(err))
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Invalid custom field in export", zap.Error(err))
+			return st
+		}
+		if st, ok := status.FromError(err); ok {
+			// Sending failed, typically because the client went away.
+			h.logger.Warn("Export stream ended", zap.Error(err))
+			return st.Err()
+		}
+		h.logger.Error("Failed to export tasks", zap.Error(err))
+		return status.Error(codes.Internal, "Failed to export tasks")
+	}
+
+	return nil
+}
+
+// GetCalendarFeed handles the gRPC request to render the tasks with a due date as an iCalendar feed.
+func (h *TaskHandler) GetCalendarFeed(req *pb.GetCalendarFeedRequest, stream grpc.ServerStreamingServer[httpbody.HttpBody]) error {
+	if !h.calendarTokens.Enabled() {
+		h.logger.Warn("Rejected GetCalendarFeed request, no calendar feed secret is set")
+		return status.Error(codes.FailedPrecondition, "Calendar feeds are not enabled on this server")
+	}
+	user, ok := h.calendarTokens.Verify(strings.TrimSpace(req.Token))
+	if !ok {
+		h.logger.Warn("Rejected GetCalendarFeed request without a valid token", zap.Bool("token_given", req.Token != ""))
+		return status.Error(codes.Unauthenticated, "A valid calendar feed token is required")
+	}
+	h.logger.Info("Received GetCalendarFeed request", zap.String("user", user), zap.Int64("project_id", req.ProjectId),
+		zap.String("due_field", req.DueField))
+
+	if err := trimAndValidateGetCalendarFeedRequest(req); err != nil {
+		h.logger.Warn("Validation failed for GetCalendarFeed", zap.Error(err))
+		return err
+	}
+
+	// A token only opens the feed of its own tasks.
+	if req.Assignee != "" && req.Assignee != user {
+		h.logger.Warn("Rejected GetCalendarFeed request for another user's tasks", zap.String("user", user), zap.String("assignee", req.Assignee))
+		return status.Error(codes.PermissionDenied, "Calendar feed tokens only give access to the tasks of their user")
+	}
+
+	writer := newCalendarWriter(req.Component, req.DueField, stream.Send)
+	filter := models.TaskFilter{ProjectID: req.ProjectId, Expression: calendarFilter(req.ProjectId, user, req.Label, req.DueField)}
+	err := h.service.ExportTasks(stream.Context(), models.TaskExport{Filter: filter}, writer.WriteTask)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrProjectNotFound) {
+			h.logger.Warn("Project not found", zap.Int64("project_id", req.ProjectId))
+			return status.Error(codes.NotFound, "Project not found")
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Invalid due field", zap.Error(err))
+			return st
+		}
+		if st, ok := status.FromError(err); ok {
+			h.logger.Warn("Calendar stream ended", zap.Error(err))
+			return st.Err()
+		}
+		h.logger.Error("Failed to render calendar feed", zap.Error(err))
+		return status.Error(codes.Internal, "Failed to render calendar feed")
+	}
+
+	return nil
+}
+
+// ImportTasks handles the gRPC request to import tasks from a file streamed in chunks.
+func (h *TaskHandler) ImportTasks(stream grpc.ClientStreamingServer[pb.ImportTasksRequest, pb.ImportTasksResponse]) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		first, err = &pb.ImportTasksRequest{}, nil
+	}
+	if err != nil {
+		h.logger.Warn("Import stream ended", zap.Error(err))
+		return err
+	}
+	options := first.Options
+	h.logger.Info("Received ImportTasks request", zap.Int64("project_id", options.GetProjectId()), zap.String("format", options.GetFormat()))
+
+	if err := trimAndValidateImportOptions(options); err != nil {
+		h.logger.Warn("Validation failed for ImportTasks", zap.Error(err))
+		return err
+	}
+
+	importer, err := h.service.NewTaskImport(stream.Context(), models.TaskImport{ProjectID: options.ProjectId, Source: options.Source, DryRun: options.DryRun})
+	if err != nil {
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Import rejected by project rules", zap.Error(err))
+			return st
+		}
+		h.logger.Error("Failed to start import", zap.Error(err))
+		return status.Error(codes.Internal, "Failed to import tasks")
+	}
+
+	reader := &importReader{stream: stream, buf: first.Data}
+	mapping := newImportMapping(options.Format, options.FieldMapping)
+	err = readImport(options.Format, reader, func(row int, record importRecord, err error) error {
+		return importer.Add(mapping.row(options.ProjectId, row, record, err))
+	})
+	var summary *models.ImportSummary
+	if err == nil {
+		summary, err = importer.Finish()
+	}
+	if err != nil {
+		if broken := reader.broken(); broken != nil {
+			h.logger.Warn("Import stream ended", zap.Error(broken))
+			return broken
+		}
+		if st := projectRuleError(err); st != nil {
+			h.logger.Warn("Import rejected by project rules", zap.Error(err))
+			return st
+		}
+		if st, ok := status.FromError(err); ok {
+			h.logger.Warn("Invalid import file", zap.Error(err))
+			return st.Err()
+		}
+		h.logger.Error("Failed to import tasks", zap.Error(err))
+		return status.Error(codes.Internal, "Failed to import tasks")
+	}
+
+	return stream.SendAndClose(toImportTasksResponse(summary, options.DryRun))
+}
+
+// BatchCreateTasks handles the gRPC request to create several tasks at once.
+func (h *TaskHandler) BatchCreateTasks(ctx context.Context, req *pb.BatchCreateTasksRequest) (*pb.BatchTasksResponse, error) {
+	h.logger.Info("Received BatchCreateTasks request", zap.Int("count", len(req.Requests)), zap.Bool("best_effort", req.BestEffort))
+
+	if err := validateBatchSize(len(req.Requests), h.batchMaxSize); err != nil {
+		h.logger.Warn("Validation failed for BatchCreateTasks", zap.Error(err))
+		return nil, err
+	}
+
+	batch := newBatchResponse(len(req.Requests), timeZoneOf(ctx))
+	var tasks []*models.Task
+	for i, itemReq := range req.Requests {
+		err := trimAndValidateCreateTaskRequest(itemReq)
+		if err == nil {
+			err = validateBatchItemKey(itemReq.IdempotencyKey)
+		}
+		if err != nil {
+			if !req.BestEffort {
+				h.logger.Warn("Validation failed for BatchCreateTasks", zap.Int("item", i), zap.Error(err))
+				return nil, batchItemError(i, err)
+			}
+			batch.reject(i, err)
+			continue
+		}
+		batch.pass(i)
+		tasks = append(tasks, fromCreateTaskRequest(itemReq))
+	}
+	if len(tasks) == 0 {
+		return batch.response(nil), nil
+	}
+
+	results, err := h.service.BatchCreateTasks(ctx, tasks, req.BestEffort)
+	if err != nil {
+		return nil, h.batchError(batch, err, "Failed to create tasks")
+	}
+
+	return batch.response(results), nil
+}
+
+// BatchUpdateTasks handles the gRPC request to update several tasks at once.
+func (h *TaskHandler) BatchUpdateTasks(ctx context.Context, req *pb.BatchUpdateTasksRequest) (*pb.BatchTasksResponse, error) {
+	h.logger.Info("Received BatchUpdateTasks request", zap.Int("count", len(req.Requests)), zap.Bool("best_effort", req.BestEffort))
+
+	if err := validateBatchSize(len(req.Requests), h.batchMaxSize); err != nil {
+		h.logger.Warn("Validation failed for BatchUpdateTasks", zap.Error(err))
+		return nil, err
+	}
+
+	batch := newBatchResponse(len(req.Requests), timeZoneOf(ctx))
+	var tasks []*models.Task
+	for i, itemReq := range req.Requests {
+		err := trimAndValidateUpdateTaskRequest(itemReq)
+		if err == nil {
+			err = validateBatchItemKey(itemReq.IdempotencyKey)
+		}
+		if err == nil {
+			itemReq.Id, err = h.resolveTaskID(ctx, itemReq.Id, itemReq.Key, itemReq.ExternalId)
+		}
+		if err != nil {
+			if !req.BestEffort {
+				h.logger.Warn("Invalid item in BatchUpdateTasks", zap.Int("item", i), zap.Error(err))
+				return nil, batchItemError(i, err)
+			}
+			batch.reject(i, err)
+			continue
+		}
+		batch.pass(i)
+		tasks = append(tasks, fromUpdateTaskRequest(itemReq))
+	}
+	if len(tasks) == 0 {
+		return batch.response(nil), nil
+	}
+
+	results, err := h.service.BatchUpdateTasks(ctx, tasks, req.BestEffort)
+	if err != nil {
+		return nil, h.batchError(batch, err, "Failed to update tasks")
+	}
+
+	return batch.response(results), nil
+}
+
+// BatchDeleteTasks handles the gRPC request to delete several tasks at once.
+func (h *TaskHandler) BatchDeleteTasks(ctx context.Context, req *pb.BatchDeleteTasksRequest) (*pb.BatchTasksResponse, error) {
+	h.logger.Info("Received BatchDeleteTasks request", zap.Int("count", len(req.Requests)), zap.Bool("best_effort", req.BestEffort))
+
+	if err := validateBatchSize(len(req.Requests), h.batchMaxSize); err != nil {
+		h.logger.Warn("Validation failed for BatchDeleteTasks", zap.Error(err))
+		return nil, err
+	}
+
+	batch := newBatchResponse(len(req.Requests), timeZoneOf(ctx))
+	var tasks []*models.Task
+	for i, itemReq := range req.Requests {
+		err := trimAndValidateDeleteTaskRequest(itemReq)
+		if err == nil {
+			err = validateBatchItemKey(itemReq.IdempotencyKey)
+		}
+		if err == nil {
+			itemReq.Id, err = h.resolveTaskID(ctx, itemReq.Id, itemReq.Key, itemReq.ExternalId)
+		}
+		if err != nil {
+			if !req.BestEffort {
+				h.logger.Warn("Invalid item in BatchDeleteTasks", zap.Int("item", i), zap.Error(err))
+				return nil, batchItemError(i, err)
+			}
+			batch.reject(i, err)
+			continue
+		}
+		batch.pass(i)
+		tasks = append(tasks, &models.Task{ID: itemReq.Id, ProjectID: itemReq.ProjectId})
+	}
+	if len(tasks) == 0 {
+		return batch.response(nil), nil
+	}
+
+	results, err := h.service.BatchDeleteTasks(ctx, tasks, req.BestEffort)
+	if err != nil {
+		return nil, h.batchError(batch, err, "Failed to delete tasks")
+	}
+
+	return batch.response(results), nil
+}
+
+// batchError maps the error of an all-or-nothing batch to a gRPC status error, naming the failed item if any.
+func (h *TaskHandler) batchError(batch *batchResponse, err error, message string) error {
+	var itemErr *services.BatchItemError
+	if errors.As(err, &itemErr) {
+		index := batch.requestIndex(itemErr.Index)
+		h.logger.Warn("Batch rejected", zap.Int("item", index), zap.Error(itemErr.Err))
+		return batchItemError(index, taskError(itemErr.Err, message))
+	}
+	if errors.Is(err, services.ErrTaskNotFound) {
+		h.logger.Warn("Task of batch not found", zap.Error(err))
+		return status.Error(codes.NotFound, "Task not found")
+	}
+	h.logger.Error(message, zap.Error(err))
+	return status.Error(codes.Internal, message)
+}
+
+// resolveTaskID returns the ID of a task referenced by ID, key or external ID.
+func (h *TaskHandler) resolveTaskID(ctx context.Context, id int64, key, externalID string) (int64, error) {
+	if key == "" && externalID == "" {
+		return id, nil
+	}
+
+	resolved, err := h.service.ResolveTask(ctx, models.TaskRef{Key: key, ExternalID: externalID})
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			h.logger.Warn("Task reference not found", zap.String("key", key), zap.String("external_id", externalID))
+			return 0, status.Error(codes.NotFound, "Task not found")
+		}
+		h.logger.Error("Failed to resolve task reference", zap.Error(err))
+		return 0, status.Error(codes.Internal, "Failed to resolve task reference")
+	}
+
+	return resolved, nil
+}
+
+// fromCreateTaskRequest converts a validated CreateTaskRequest into a task model.
+func fromCreateTaskRequest(req *pb.CreateTaskRequest) *models.Task {
+	return &models.Task{
+		ProjectID:    req.ProjectId,
+		ParentID:     req.ParentId,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       req.Status,
+		Assignee:     req.Assignee,
+		Labels:       req.Labels,
+		CustomFields: fromCustomFieldValues(req.CustomFields),
+	}
+}
+
+// fromUpdateTaskRequest converts a validated UpdateTaskRequest with a resolved ID into a task model.
+func fromUpdateTaskRequest(req *pb.UpdateTaskRequest) *models.Task {
+	task := &models.Task{
+		ID:           req.Id,
+		ProjectID:    req.ProjectId,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       req.Status,
+		Assignee:     req.Assignee,
+		CustomFields: fromCustomFieldValues(req.CustomFields),
+	}
+	if req.Labels != nil {
+		task.Labels = append([]string{}, req.Labels.Values...)
+	}
+	return task
+}
+
+// toTaskResponse converts a task model into its gRPC representation, rendering the string timestamps
+// in location.
+func toTaskResponse(task *models.Task, location *time.Location) *pb.TaskResponse {
+	return &pb.TaskResponse{
+		Id:           task.ID,
+		ExternalId:   task.ExternalID,
+		ProjectId:    task.ProjectID,
+		ParentId:     task.ParentID,
+		Key:          task.Key,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		Assignee:     task.Assignee,
+		Labels:       task.Labels,
+		Rank:         task.Rank,
+		CustomFields: toCustomFieldValues(task.CustomFields),
+		CreatedAt:    formatTimestamp(task.CreatedAt, location),
+		UpdatedAt:    formatTimestamp(task.UpdatedAt, location),
+		CreateTime:   toTimestamp(task.CreatedAt),
+		UpdateTime:   toTimestamp(task.UpdatedAt),
+		ArchiveTime:  toTimestamp(task.ArchivedAt),
+	}
+}
+
+// fromTaskRelations converts validated gRPC task relations into their model representation.
+func fromTaskRelations(relations []*pb.TaskRelation) []models.TaskRelation {
+	result := make([]models.TaskRelation, len(relations))
+	for i, relation := range relations {
+		result[i] = models.TaskRelation{TaskID: relation.TaskId, RelatedTaskID: relation.RelatedTaskId, Type: relation.Type}
+	}
+	return result
+}
+
+// toTaskRelations converts task relation models into their gRPC representation.
+func toTaskRelations(relations []models.TaskRelation) []*pb.TaskRelation {
+	result := make([]*pb.TaskRelation, len(relations))
+	for i, relation := range relations {
+		result[i] = &pb.TaskRelation{TaskId: relation.TaskID, RelatedTaskId: relation.RelatedTaskID, Type: relation.Type}
+	}
+	return result
+}
+
+// toChecklistItems converts checklist items into their gRPC representation.
+func toChecklistItems(items []models.ChecklistItem) []*pb.ChecklistItem {
+	result := make([]*pb.ChecklistItem, len(items))
+	for i, item := range items {
+		result[i] = &pb.ChecklistItem{Text: item.Text, Done: item.Done}
+	}
+	return result
+}
+
+// toTaskComment converts a task comment model into its gRPC representation.
+func toTaskComment(comment *models.TaskComment) *pb.TaskComment {
+	return &pb.TaskComment{
+		Id:         comment.ID,
+		TaskId:     comment.TaskID,
+		Author:     comment.Author,
+		Body:       comment.Body,
+		CreateTime: toTimestamp(comment.CreatedAt),
+	}
+}
+
+// toTaskAttachment converts a task attachment model into its gRPC representation.
+func toTaskAttachment(attachment *models.TaskAttachment) *pb.TaskAttachment {
+	return &pb.TaskAttachment{
+		Id:          attachment.ID,
+		TaskId:      attachment.TaskID,
+		Name:        attachment.Name,
+		Url:         attachment.URL,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreateTime:  toTimestamp(attachment.CreatedAt),
+	}
+}
+
+// taskError maps the error of a single task operation to a gRPC status error. Status errors pass through;
+// unexpected errors become Internal with the given message.
+func taskError(err error, message string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		return status.Error(codes.NotFound, "Task not found")
+	case errors.Is(err, services.ErrRelationNotFound):
+		return status.Error(codes.NotFound, "Task relation not found")
+	case errors.Is(err, services.ErrInvalidBatch), errors.Is(err, services.ErrInvalidRelation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if st := projectRuleError(err); st != nil {
+		return st
+	}
+	return status.Error(codes.Internal, message)
+}
+
+// projectRuleError maps the project rules enforced by the task service to gRPC status errors.
+// It returns nil for any other error.
+func projectRuleError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound):
+		return status.Error(codes.NotFound, "Project not found")
+	case errors.Is(err, services.ErrProjectArchived):
+		return status.Error(codes.FailedPrecondition, "Project is archived and its tasks are read-only")
+	case errors.Is(err, services.ErrInvalidStatus), errors.Is(err, services.ErrLabelNotAllowed),
+		errors.Is(err, services.ErrUnknownCustomField), errors.Is(err, services.ErrInvalidFieldValue),
+		errors.Is(err, services.ErrInvalidParent):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
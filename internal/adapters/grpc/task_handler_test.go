@@ -2,50 +2,174 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
 	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) CreateTask(task *models.Task) (*models.Task, error) {
+func (m *MockService) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
 	args := m.Called(task)
 	return args.Get(0).(*models.Task), args.Error(1)
 }
 
-func (m *MockService) ListTasks() ([]*models.Task, error) {
-	args := m.Called()
+func (m *MockService) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]*models.Task), args.Error(1)
 }
 
-func (m *MockService) GetTask(id int64) (*models.Task, error) {
+func (m *MockService) GetTask(ctx context.Context, id int64) (*models.Task, error) {
 	args := m.Called(id)
 	return args.Get(0).(*models.Task), args.Error(1)
 }
 
-func (m *MockService) UpdateTask(task *models.Task) (*models.Task, error) {
+func (m *MockService) GetTaskIncludingArchived(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	args := m.Called(ref)
+	task, _ := args.Get(0).(*models.Task)
+	return task, args.Error(1)
+}
+
+func (m *MockService) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
 	args := m.Called(task)
 	return args.Get(0).(*models.Task), args.Error(1)
 }
 
-func (m *MockService) DeleteTask(id int64) error {
+func (m *MockService) DeleteTask(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockService) MoveTask(ctx context.Context, move models.TaskMove) (*models.Task, error) {
+	args := m.Called(move)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockService) TransferTask(ctx context.Context, transfer models.TaskTransfer) (*models.Task, error) {
+	args := m.Called(transfer)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockService) CloneTask(ctx context.Context, clone models.TaskClone) (*models.Task, error) {
+	args := m.Called(clone)
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockService) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	args := m.Called(relations)
+	return args.Error(0)
+}
+
+func (m *MockService) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
 	args := m.Called(id)
+	return args.Get(0).([]models.TaskRelation), args.Error(1)
+}
+
+func (m *MockService) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	args := m.Called(relation)
 	return args.Error(0)
 }
 
+func (m *MockService) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) ([]models.ChecklistItem, error) {
+	args := m.Called(id, items)
+	checklist, _ := args.Get(0).([]models.ChecklistItem)
+	return checklist, args.Error(1)
+}
+
+func (m *MockService) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	args := m.Called(id)
+	checklist, _ := args.Get(0).([]models.ChecklistItem)
+	return checklist, args.Error(1)
+}
+
+func (m *MockService) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	args := m.Called(comment)
+	created, _ := args.Get(0).(*models.TaskComment)
+	return created, args.Error(1)
+}
+
+func (m *MockService) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	args := m.Called(id)
+	comments, _ := args.Get(0).([]*models.TaskComment)
+	return comments, args.Error(1)
+}
+
+func (m *MockService) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	args := m.Called(attachment)
+	created, _ := args.Get(0).(*models.TaskAttachment)
+	return created, args.Error(1)
+}
+
+func (m *MockService) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	args := m.Called(id)
+	attachments, _ := args.Get(0).([]*models.TaskAttachment)
+	return attachments, args.Error(1)
+}
+
+func (m *MockService) ResolveTask(ctx context.Context, ref models.TaskRef) (int64, error) {
+	args := m.Called(ref)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockService) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	args := m.Called(search)
+	return args.Get(0).([]*models.SearchHit), args.Error(1)
+}
+
+func (m *MockService) BatchCreateTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]services.BatchResult, error) {
+	args := m.Called(tasks, bestEffort)
+	results, _ := args.Get(0).([]services.BatchResult)
+	return results, args.Error(1)
+}
+
+func (m *MockService) BatchUpdateTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]services.BatchResult, error) {
+	args := m.Called(tasks, bestEffort)
+	results, _ := args.Get(0).([]services.BatchResult)
+	return results, args.Error(1)
+}
+
+func (m *MockService) BatchDeleteTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]services.BatchResult, error) {
+	args := m.Called(tasks, bestEffort)
+	results, _ := args.Get(0).([]services.BatchResult)
+	return results, args.Error(1)
+}
+
+func (m *MockService) ExportTasks(ctx context.Context, export models.TaskExport, each func(*models.Task) error) error {
+	args := m.Called(export)
+	tasks, _ := args.Get(0).([]*models.Task)
+	for _, task := range tasks {
+		if err := each(task); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockService) NewTaskImport(ctx context.Context, taskImport models.TaskImport) (services.TaskImporter, error) {
+	args := m.Called(taskImport)
+	importer, _ := args.Get(0).(services.TaskImporter)
+	return importer, args.Error(1)
+}
+
 func setupHandler() (*MockService, *TaskHandler) {
 	mockService := new(MockService)
 	logger, _ := zap.NewDevelopment()
 	handler := &TaskHandler{
-		service: mockService,
-		logger:  logger,
+		service:        mockService,
+		batchMaxSize:   3,
+		calendarTokens: NewCalendarTokens("test-secret", time.Hour),
+		logger:         logger,
 	}
 	return mockService, handler
 }
@@ -56,6 +180,7 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 	mockTask := &models.Task{
 		Title:       "Test Task",
 		Description: "Description for test task",
+		Labels:      []string{},
 	}
 
 	mockResponse := &models.Task{
@@ -96,7 +221,7 @@ func TestTaskHandler_ListTasks(t *testing.T) {
 		},
 	}
 
-	mockService.On("ListTasks").Return(mockTasks, nil)
+	mockService.On("ListTasks", models.TaskFilter{}).Return(mockTasks, nil)
 
 	req := &pb.ListTasksRequest{}
 
@@ -172,3 +297,702 @@ func TestTaskHandler_DeleteTask(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestTaskHandler_CreateTask_ArchivedProject(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("CreateTask", mock.Anything).Return((*models.Task)(nil), services.ErrProjectArchived)
+
+	req := &pb.CreateTaskRequest{
+		ProjectId:   1,
+		Title:       "Test Task",
+		Description: "Description for test task",
+	}
+
+	_, err := handler.CreateTask(context.Background(), req)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_GetTask_OtherProject(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockTask := &models.Task{
+		ID:          1,
+		ProjectID:   2,
+		Title:       "Test Task",
+		Description: "Test Description",
+	}
+
+	mockService.On("GetTask", int64(1)).Return(mockTask, nil)
+
+	req := &pb.GetTaskRequest{Id: 1, ProjectId: 3}
+
+	_, err := handler.GetTask(context.Background(), req)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_GetTask_IncludeArchived(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	archivedAt := time.Date(2026, 9, 1, 3, 0, 0, 0, time.UTC)
+	mockTask := &models.Task{ID: 1, ProjectID: 2, Key: "OPS-1", Title: "Old Task", ArchivedAt: archivedAt}
+	mockService.On("GetTaskIncludingArchived", models.TaskRef{Key: "OPS-1"}).Return(mockTask, nil)
+	mockService.On("GetTaskIncludingArchived", models.TaskRef{Key: "OPS-9"}).Return(nil, services.ErrTaskNotFound)
+
+	resp, err := handler.GetTask(context.Background(), &pb.GetTaskRequest{Key: "OPS-1", IncludeArchived: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.Id)
+	require.True(t, resp.ArchiveTime.AsTime().Equal(archivedAt))
+
+	_, err = handler.GetTask(context.Background(), &pb.GetTaskRequest{Key: "OPS-1", ProjectId: 3, IncludeArchived: true})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = handler.GetTask(context.Background(), &pb.GetTaskRequest{Key: "OPS-9", IncludeArchived: true})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_ListTasks_IncludeArchived(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("ListTasks", models.TaskFilter{ProjectID: 2, IncludeArchived: true}).Return([]*models.Task{
+		{ID: 1, ProjectID: 2, Title: "Live Task"},
+		{ID: 2, ProjectID: 2, Title: "Old Task", ArchivedAt: time.Now()},
+	}, nil)
+
+	resp, err := handler.ListTasks(context.Background(), &pb.ListTasksRequest{ProjectId: 2, IncludeArchived: true})
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 2)
+	require.Nil(t, resp.Tasks[0].ArchiveTime)
+	require.NotNil(t, resp.Tasks[1].ArchiveTime)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_ListTasks_ByProject(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("ListTasks", models.TaskFilter{ProjectID: 2}).Return([]*models.Task{}, services.ErrProjectNotFound)
+
+	_, err := handler.ListTasks(context.Background(), &pb.ListTasksRequest{ProjectId: 2})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_ListTasks_Filter(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	filterErr := &services.FilterError{Position: 19, Message: `unknown field "titel"`}
+	mockService.On("ListTasks", models.TaskFilter{Expression: `status = open AND titel:"x"`}).Return([]*models.Task{}, filterErr)
+
+	_, err := handler.ListTasks(context.Background(), &pb.ListTasksRequest{Filter: ` status = open AND titel:"x" `})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "position 19")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_MoveTask(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	move := models.TaskMove{TaskID: 1, Status: "done", AfterID: 2, BeforeID: 3}
+	mockService.On("MoveTask", move).Return(&models.Task{ID: 1, Status: "done", Rank: "h"}, nil)
+
+	req := &pb.MoveTaskRequest{Id: 1, Status: " done ", AfterTaskId: 2, BeforeTaskId: 3}
+
+	resp, err := handler.MoveTask(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "done", resp.Status)
+	require.Equal(t, "h", resp.Rank)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_MoveTask_InvalidMove(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("MoveTask", mock.Anything).Return((*models.Task)(nil), services.ErrInvalidMove)
+
+	_, err := handler.MoveTask(context.Background(), &pb.MoveTaskRequest{Id: 1, AfterTaskId: 3, BeforeTaskId: 2})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_GetTask_ByKey(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("ResolveTask", models.TaskRef{Key: "OPS-7"}).Return(int64(4), nil)
+	mockService.On("GetTask", int64(4)).Return(&models.Task{ID: 4, Key: "OPS-7"}, nil)
+
+	resp, err := handler.GetTask(context.Background(), &pb.GetTaskRequest{Key: " ops-7 "})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), resp.Id)
+	require.Equal(t, "OPS-7", resp.Key)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_GetTask_IDAndKey(t *testing.T) {
+	_, handler := setupHandler()
+
+	_, err := handler.GetTask(context.Background(), &pb.GetTaskRequest{Id: 4, Key: "OPS-7"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTaskHandler_DeleteTask_UnknownKey(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("ResolveTask", models.TaskRef{Key: "OPS-7"}).Return(int64(0), services.ErrTaskNotFound)
+
+	_, err := handler.DeleteTask(context.Background(), &pb.DeleteTaskRequest{Key: "OPS-7"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_TransferTask(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("ResolveTask", models.TaskRef{Key: "OPS-7"}).Return(int64(4), nil)
+	mockService.On("TransferTask", models.TaskTransfer{TaskID: 4, ProjectID: 2, Subtree: true}).
+		Return(&models.Task{ID: 4, ProjectID: 2, Key: "DEV-1"}, nil)
+
+	resp, err := handler.TransferTask(context.Background(), &pb.TransferTaskRequest{Key: "OPS-7", TargetProjectId: 2, IncludeSubtree: true})
+	require.NoError(t, err)
+	require.Equal(t, "DEV-1", resp.Key)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_TransferTask_ArchivedTarget(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("TransferTask", models.TaskTransfer{TaskID: 4, ProjectID: 2}).Return((*models.Task)(nil), services.ErrProjectArchived)
+
+	_, err := handler.TransferTask(context.Background(), &pb.TransferTaskRequest{Id: 4, TargetProjectId: 2})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CloneTask(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	target := int64(0)
+	mockService.On("ResolveTask", models.TaskRef{Key: "OPS-7"}).Return(int64(4), nil)
+	mockService.On("CloneTask", models.TaskClone{TaskID: 4, ProjectID: &target, Title: "Copy", Labels: true}).
+		Return(&models.Task{ID: 9, Title: "Copy"}, nil)
+
+	resp, err := handler.CloneTask(context.Background(), &pb.CloneTaskRequest{Key: "ops-7", TargetProjectId: &target, Title: " Copy ", IncludeLabels: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(9), resp.Id)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CloneTask_Options(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("CloneTask", models.TaskClone{TaskID: 4, CustomFields: true, Subtasks: true, Checklist: true, Comments: true, Attachments: true}).
+		Return(&models.Task{ID: 9}, nil)
+
+	_, err := handler.CloneTask(context.Background(), &pb.CloneTaskRequest{Id: 4, IncludeCustomFields: true, IncludeSubtasks: true,
+		IncludeChecklist: true, IncludeComments: true, IncludeAttachments: true})
+	require.NoError(t, err)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CloneTask_LabelNotAllowed(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("CloneTask", models.TaskClone{TaskID: 4, Labels: true}).
+		Return((*models.Task)(nil), fmt.Errorf("%w: %q", services.ErrLabelNotAllowed, "bug"))
+
+	_, err := handler.CloneTask(context.Background(), &pb.CloneTaskRequest{Id: 4, IncludeLabels: true})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CreateTask_InvalidParent(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("CreateTask", &models.Task{ProjectID: 2, ParentID: 4, Title: "Sub", Description: "D", Labels: []string{}}).
+		Return((*models.Task)(nil), fmt.Errorf("%w: task 4 is in another project", services.ErrInvalidParent))
+
+	_, err := handler.CreateTask(context.Background(), &pb.CreateTaskRequest{ProjectId: 2, ParentId: 4, Title: "Sub", Description: "D"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = handler.CreateTask(context.Background(), &pb.CreateTaskRequest{ParentId: -1, Title: "Sub", Description: "D"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CreateTaskRelations(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	relations := []models.TaskRelation{{TaskID: 4, RelatedTaskID: 5, Type: "blocks"}, {TaskID: 4, RelatedTaskID: 6, Type: "relates_to"}}
+	mockService.On("CreateTaskRelations", relations).Return(nil)
+	mockService.On("ListTaskRelations", int64(4)).Return(append(relations, models.TaskRelation{TaskID: 7, RelatedTaskID: 4, Type: "duplicates"}), nil)
+
+	resp, err := handler.CreateTaskRelations(context.Background(), &pb.CreateTaskRelationsRequest{TaskId: 4, Relations: []*pb.TaskRelation{
+		{RelatedTaskId: 5, Type: " blocks"}, {TaskId: 4, RelatedTaskId: 6, Type: "relates_to"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Relations, 3)
+	require.Equal(t, int64(7), resp.Relations[2].TaskId)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CreateTaskRelations_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.CreateTaskRelationsRequest
+		err      error
+		wantCode codes.Code
+	}{
+		{name: "No relations", req: &pb.CreateTaskRelationsRequest{TaskId: 4}, wantCode: codes.InvalidArgument},
+		{name: "Other task", req: &pb.CreateTaskRelationsRequest{TaskId: 4, Relations: []*pb.TaskRelation{{TaskId: 3, RelatedTaskId: 5, Type: "blocks"}}},
+			wantCode: codes.InvalidArgument},
+		{name: "No type", req: &pb.CreateTaskRelationsRequest{TaskId: 4, Relations: []*pb.TaskRelation{{RelatedTaskId: 5}}},
+			wantCode: codes.InvalidArgument},
+		{name: "Unknown type", req: &pb.CreateTaskRelationsRequest{TaskId: 4, Relations: []*pb.TaskRelation{{RelatedTaskId: 5, Type: "parent"}}},
+			err: fmt.Errorf("%w: unknown type %q", services.ErrInvalidRelation, "parent"), wantCode: codes.InvalidArgument},
+		{name: "Missing task", req: &pb.CreateTaskRelationsRequest{TaskId: 4, Relations: []*pb.TaskRelation{{RelatedTaskId: 5, Type: "blocks"}}},
+			err: services.ErrTaskNotFound, wantCode: codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService, handler := setupHandler()
+			mockService.On("CreateTaskRelations", mock.Anything).Return(tt.err)
+
+			_, err := handler.CreateTaskRelations(context.Background(), tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if tt.err == nil {
+				mockService.AssertNotCalled(t, "CreateTaskRelations", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_DeleteTaskRelation(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	relation := models.TaskRelation{TaskID: 4, RelatedTaskID: 5, Type: "blocks"}
+	mockService.On("DeleteTaskRelation", relation).Return(nil).Once()
+	mockService.On("DeleteTaskRelation", relation).Return(services.ErrRelationNotFound).Once()
+
+	resp, err := handler.DeleteTaskRelation(context.Background(), &pb.DeleteTaskRelationRequest{TaskId: 4, RelatedTaskId: 5, Type: "blocks "})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	_, err = handler.DeleteTaskRelation(context.Background(), &pb.DeleteTaskRelationRequest{TaskId: 4, RelatedTaskId: 5, Type: "blocks"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_SetTaskChecklist(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	items := []models.ChecklistItem{{Text: "Plan", Done: true}, {Text: "Do"}}
+	mockService.On("SetTaskChecklist", int64(4), items).Return(items, nil).Once()
+	mockService.On("SetTaskChecklist", int64(5), []models.ChecklistItem{}).Return(nil, services.ErrProjectArchived).Once()
+
+	resp, err := handler.SetTaskChecklist(context.Background(), &pb.SetTaskChecklistRequest{TaskId: 4, Items: []*pb.ChecklistItem{
+		{Text: " Plan ", Done: true}, {Text: "Do"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 2)
+	require.Equal(t, "Plan", resp.Items[0].Text)
+
+	_, err = handler.SetTaskChecklist(context.Background(), &pb.SetTaskChecklistRequest{TaskId: 5})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = handler.SetTaskChecklist(context.Background(), &pb.SetTaskChecklistRequest{TaskId: 4, Items: []*pb.ChecklistItem{{Text: " "}}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_TaskComments(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	comment := &models.TaskComment{ID: 1, TaskID: 4, Author: "ada", Body: "Done soon", CreatedAt: created}
+	mockService.On("CreateTaskComment", &models.TaskComment{TaskID: 4, Author: "ada", Body: "Done soon"}).Return(comment, nil)
+	mockService.On("ListTaskComments", int64(4)).Return([]*models.TaskComment{comment}, nil)
+	mockService.On("ListTaskComments", int64(5)).Return(nil, services.ErrTaskNotFound)
+
+	resp, err := handler.CreateTaskComment(context.Background(), &pb.CreateTaskCommentRequest{TaskId: 4, Author: "ada ", Body: " Done soon"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.Id)
+	require.Equal(t, created, resp.CreateTime.AsTime())
+
+	list, err := handler.ListTaskComments(context.Background(), &pb.ListTaskCommentsRequest{TaskId: 4})
+	require.NoError(t, err)
+	require.Len(t, list.Comments, 1)
+
+	_, err = handler.ListTaskComments(context.Background(), &pb.ListTaskCommentsRequest{TaskId: 5})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = handler.CreateTaskComment(context.Background(), &pb.CreateTaskCommentRequest{TaskId: 4, Body: " "})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CreateTaskAttachment(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.CreateTaskAttachmentRequest
+		wantCode codes.Code
+	}{
+		{name: "Valid", req: &pb.CreateTaskAttachmentRequest{TaskId: 4, Name: "plan.pdf", Url: "https://files.example.com/plan.pdf", Size: 10},
+			wantCode: codes.OK},
+		{name: "No name", req: &pb.CreateTaskAttachmentRequest{TaskId: 4, Url: "https://files.example.com/plan.pdf"}, wantCode: codes.InvalidArgument},
+		{name: "Relative URL", req: &pb.CreateTaskAttachmentRequest{TaskId: 4, Name: "plan.pdf", Url: "/plan.pdf"}, wantCode: codes.InvalidArgument},
+		{name: "Other scheme", req: &pb.CreateTaskAttachmentRequest{TaskId: 4, Name: "plan.pdf", Url: "file:///plan.pdf"},
+			wantCode: codes.InvalidArgument},
+		{name: "Negative size", req: &pb.CreateTaskAttachmentRequest{TaskId: 4, Name: "plan.pdf", Url: "https://files.example.com/plan.pdf", Size: -1},
+			wantCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService, handler := setupHandler()
+			mockService.On("CreateTaskAttachment", mock.Anything).Return(&models.TaskAttachment{ID: 1, TaskID: 4, Name: "plan.pdf"}, nil)
+
+			resp, err := handler.CreateTaskAttachment(context.Background(), tt.req)
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				require.Equal(t, int64(1), resp.Id)
+			} else {
+				mockService.AssertNotCalled(t, "CreateTaskAttachment", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_ListTasks_Pages(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	first := &models.Task{ID: 1, ExternalID: "01KDVDNA000000000000000001", Rank: "i"}
+	second := &models.Task{ID: 2, ExternalID: "01KDVDNA000000000000000002", Rank: "k"}
+	third := &models.Task{ID: 3, ExternalID: "01KDVDNA000000000000000003", Rank: "m"}
+
+	mockService.On("ListTasks", models.TaskFilter{Limit: 3}).Return([]*models.Task{first, second, third}, nil)
+	mockService.On("ListTasks", models.TaskFilter{Limit: 3, After: &models.TaskCursor{Rank: "k", ExternalID: second.ExternalID}}).
+		Return([]*models.Task{third}, nil)
+
+	resp, err := handler.ListTasks(context.Background(), &pb.ListTasksRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 2)
+	require.Equal(t, second.ExternalID, resp.Tasks[1].ExternalId)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	resp, err = handler.ListTasks(context.Background(), &pb.ListTasksRequest{PageSize: 2, PageToken: resp.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 1)
+	require.Empty(t, resp.NextPageToken)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_ListTasks_InvalidPageToken(t *testing.T) {
+	_, handler := setupHandler()
+
+	_, err := handler.ListTasks(context.Background(), &pb.ListTasksRequest{PageSize: 2, PageToken: "not-a-token"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTaskHandler_GetTask_ByExternalID(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	externalID := "019b76da-a800-7000-8000-000000000004"
+	mockService.On("ResolveTask", models.TaskRef{ExternalID: externalID}).Return(int64(4), nil)
+	mockService.On("GetTask", int64(4)).Return(&models.Task{ID: 4, ExternalID: externalID}, nil)
+
+	resp, err := handler.GetTask(context.Background(), &pb.GetTaskRequest{ExternalId: "019B76DA-A800-7000-8000-000000000004"})
+	require.NoError(t, err)
+	require.Equal(t, externalID, resp.ExternalId)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_GetTask_InvalidExternalID(t *testing.T) {
+	_, handler := setupHandler()
+
+	_, err := handler.GetTask(context.Background(), &pb.GetTaskRequest{ExternalId: "42"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTaskHandler_MoveTask_ByExternalIDs(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("ResolveTask", models.TaskRef{ExternalID: "01KDVDNA000000000000000001"}).Return(int64(1), nil)
+	mockService.On("ResolveTask", models.TaskRef{ExternalID: "01KDVDNA000000000000000002"}).Return(int64(2), nil)
+	mockService.On("MoveTask", models.TaskMove{TaskID: 1, AfterID: 2, BeforeID: 3}).Return(&models.Task{ID: 1, Rank: "j"}, nil)
+
+	req := &pb.MoveTaskRequest{
+		ExternalId:          "01kdvdna000000000000000001",
+		AfterTaskExternalId: "01KDVDNA000000000000000002",
+		BeforeTaskId:        3,
+	}
+
+	_, err := handler.MoveTask(context.Background(), req)
+	require.NoError(t, err)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_CreateTask_CustomFields(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	customFields := map[string]models.FieldValue{
+		"points": {Type: models.FieldTypeNumber, Number: 5},
+		"env":    {Type: models.FieldTypeEnum, String: "prod"},
+	}
+	mockService.On("CreateTask", &models.Task{
+		ProjectID:    1,
+		Title:        "Test Task",
+		Description:  "Description",
+		Labels:       []string{},
+		CustomFields: customFields,
+	}).Return(&models.Task{ID: 1, CustomFields: customFields}, nil)
+
+	resp, err := handler.CreateTask(context.Background(), &pb.CreateTaskRequest{
+		ProjectId:   1,
+		Title:       "Test Task",
+		Description: "Description",
+		CustomFields: map[string]*pb.CustomFieldValue{
+			"points": {Value: &pb.CustomFieldValue_NumberValue{NumberValue: 5}},
+			"env":    {Value: &pb.CustomFieldValue_EnumValue{EnumValue: "prod"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 5.0, resp.CustomFields["points"].GetNumberValue())
+	require.Equal(t, "prod", resp.CustomFields["env"].GetEnumValue())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_UpdateTask_InvalidFieldValue(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	mockService.On("UpdateTask", mock.Anything).Return((*models.Task)(nil), fmt.Errorf("%w: points must be at most 13", services.ErrInvalidFieldValue))
+
+	_, err := handler.UpdateTask(context.Background(), &pb.UpdateTaskRequest{
+		Id:          1,
+		Title:       "Test Task",
+		Description: "Description",
+		CustomFields: map[string]*pb.CustomFieldValue{
+			"points": {Value: &pb.CustomFieldValue_NumberValue{NumberValue: 21}},
+		},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTaskHandler_ListTasks_CustomFieldOrderPages(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	order := &models.FieldOrder{Field: "points", Descending: true}
+	condition := models.FieldCondition{Field: "env", Operator: "=", Value: models.FieldValue{Type: models.FieldTypeEnum, String: "prod"}}
+	first := &models.Task{ID: 1, ExternalID: "01KDVDNA000000000000000001", Rank: "i",
+		CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 8}}}
+	second := &models.Task{ID: 2, ExternalID: "01KDVDNA000000000000000002", Rank: "k"}
+
+	mockService.On("ListTasks", models.TaskFilter{ProjectID: 1, CustomFields: []models.FieldCondition{condition}, OrderBy: order, Limit: 2}).
+		Return([]*models.Task{first, second}, nil)
+	mockService.On("ListTasks", models.TaskFilter{ProjectID: 1, CustomFields: []models.FieldCondition{condition}, OrderBy: order, Limit: 2,
+		After: &models.TaskCursor{SortValue: 8.0, Rank: "i", ExternalID: first.ExternalID}}).
+		Return([]*models.Task{second}, nil)
+
+	req := &pb.ListTasksRequest{
+		ProjectId: 1,
+		PageSize:  1,
+		OrderBy:   " Points DESC ",
+		CustomFieldFilters: []*pb.CustomFieldFilter{
+			{Field: "env", Operator: "=", Value: &pb.CustomFieldValue{Value: &pb.CustomFieldValue_EnumValue{EnumValue: "prod"}}},
+		},
+	}
+	resp, err := handler.ListTasks(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 1)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	req.PageToken = resp.NextPageToken
+	resp, err = handler.ListTasks(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Tasks, 1)
+	require.Empty(t, resp.NextPageToken)
+
+	req.OrderBy = "points"
+	_, err = handler.ListTasks(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_ListTasks_InvalidCustomFieldFilter(t *testing.T) {
+	_, handler := setupHandler()
+
+	tests := []*pb.ListTasksRequest{
+		{ProjectId: 1, OrderBy: "points sideways"},
+		{ProjectId: 1, CustomFieldFilters: []*pb.CustomFieldFilter{{Field: "points", Operator: "~"}}},
+		{ProjectId: 1, CustomFieldFilters: []*pb.CustomFieldFilter{{Field: "points", Operator: ">"}}},
+	}
+	for _, req := range tests {
+		_, err := handler.ListTasks(context.Background(), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestTaskHandler_SearchTasks(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	first := &models.SearchHit{
+		Task:         &models.Task{ID: 1, ExternalID: "01KDVDNA000000000000000001", Title: "Deploy API"},
+		Score:        0.5,
+		TitleSnippet: "<mark>Deploy</mark> API",
+	}
+	second := &models.SearchHit{Task: &models.Task{ID: 2, ExternalID: "01KDVDNA000000000000000002"}, Score: 0.25}
+
+	mockService.On("SearchTasks", models.TaskSearch{Query: "deploy*", ProjectID: 1, Limit: 2}).
+		Return([]*models.SearchHit{first, second}, nil)
+	mockService.On("SearchTasks", models.TaskSearch{Query: "deploy*", ProjectID: 1, Limit: 2,
+		After: &models.SearchCursor{Score: 0.5, ExternalID: first.Task.ExternalID}}).
+		Return([]*models.SearchHit{second}, nil)
+
+	resp, err := handler.SearchTasks(context.Background(), &pb.SearchTasksRequest{Query: " deploy* ", ProjectId: 1, PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, "<mark>Deploy</mark> API", resp.Results[0].TitleSnippet)
+	require.Equal(t, int64(1), resp.Results[0].Task.Id)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	resp, err = handler.SearchTasks(context.Background(), &pb.SearchTasksRequest{Query: "deploy*", ProjectId: 1, PageSize: 1, PageToken: resp.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	require.Empty(t, resp.NextPageToken)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_SearchTasks_InvalidQuery(t *testing.T) {
+	mockService, handler := setupHandler()
+
+	_, err := handler.SearchTasks(context.Background(), &pb.SearchTasksRequest{Query: "   "})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.On("SearchTasks", models.TaskSearch{Query: "-draft", Limit: DefaultSearchPageSize + 1}).
+		Return([]*models.SearchHit(nil), fmt.Errorf("%w: the query needs at least one word to look for", services.ErrInvalidSearchQuery))
+
+	_, err = handler.SearchTasks(context.Background(), &pb.SearchTasksRequest{Query: "-draft"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskHandler_BatchCreateTasks(t *testing.T) {
+	t.Run("Batch too large", func(t *testing.T) {
+		_, handler := setupHandler()
+		req := &pb.BatchCreateTasksRequest{Requests: make([]*pb.CreateTaskRequest, 4)}
+
+		_, err := handler.BatchCreateTasks(context.Background(), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("All or nothing fails on an invalid item", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		req := &pb.BatchCreateTasksRequest{Requests: []*pb.CreateTaskRequest{
+			{Title: "Task", Description: "Description"},
+			{Title: " ", Description: "Description"},
+		}}
+
+		_, err := handler.BatchCreateTasks(context.Background(), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, "Item 1: Title cannot be empty", status.Convert(err).Message())
+		mockService.AssertNotCalled(t, "BatchCreateTasks", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Items cannot carry their own idempotency key", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		req := &pb.BatchCreateTasksRequest{Requests: []*pb.CreateTaskRequest{
+			{Title: "Task", Description: "Description", IdempotencyKey: "retry-1"},
+		}}
+
+		_, err := handler.BatchCreateTasks(context.Background(), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Contains(t, status.Convert(err).Message(), "Item 0: Idempotency keys apply to the whole batch")
+		mockService.AssertNotCalled(t, "BatchCreateTasks", mock.Anything, mock.Anything)
+	})
+
+	t.Run("All or nothing names the item the service rejected", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("BatchCreateTasks", mock.Anything, false).
+			Return(nil, &services.BatchItemError{Index: 1, Err: fmt.Errorf("%w: %q", services.ErrLabelNotAllowed, "feature")})
+		req := &pb.BatchCreateTasksRequest{Requests: []*pb.CreateTaskRequest{
+			{Title: "Task", Description: "Description"},
+			{Title: "Task", Description: "Description", Labels: []string{"feature"}},
+		}}
+
+		_, err := handler.BatchCreateTasks(context.Background(), req)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Contains(t, status.Convert(err).Message(), "Item 1: ")
+	})
+
+	t.Run("Best effort reports every item", func(t *testing.T) {
+		mockService, handler := setupHandler()
+		mockService.On("BatchCreateTasks", []*models.Task{
+			{Title: "First", Description: "Description", Labels: []string{}},
+			{Title: "Third", Description: "Description", Labels: []string{}},
+		}, true).Return([]services.BatchResult{
+			{Task: &models.Task{ID: 1, Title: "First"}},
+			{Err: services.ErrProjectArchived},
+		}, nil)
+		req := &pb.BatchCreateTasksRequest{BestEffort: true, Requests: []*pb.CreateTaskRequest{
+			{Title: "First", Description: "Description"},
+			{Title: "", Description: "Description"},
+			{Title: "Third", Description: "Description"},
+		}}
+
+		resp, err := handler.BatchCreateTasks(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 3)
+		require.Equal(t, int32(codes.OK), resp.Results[0].Code)
+		require.Equal(t, int64(1), resp.Results[0].Task.Id)
+		require.Equal(t, int32(codes.InvalidArgument), resp.Results[1].Code)
+		require.Equal(t, int32(codes.FailedPrecondition), resp.Results[2].Code)
+		require.Nil(t, resp.Results[2].Task)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestTaskHandler_BatchDeleteTasks(t *testing.T) {
+	mockService, handler := setupHandler()
+	mockService.On("ResolveTask", models.TaskRef{Key: "OPS-9"}).Return(int64(0), services.ErrTaskNotFound)
+	mockService.On("BatchDeleteTasks", []*models.Task{{ID: 1}, {ID: 2, ProjectID: 3}}, true).
+		Return([]services.BatchResult{{}, {Err: services.ErrTaskNotFound}}, nil)
+	req := &pb.BatchDeleteTasksRequest{BestEffort: true, Requests: []*pb.DeleteTaskRequest{
+		{Id: 1},
+		{Key: "OPS-9"},
+		{Id: 2, ProjectId: 3},
+	}}
+
+	resp, err := handler.BatchDeleteTasks(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	require.Equal(t, int32(codes.NotFound), resp.Results[1].Code)
+	require.Equal(t, int32(codes.NotFound), resp.Results[2].Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,268 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TaskTemplateHandler implements the gRPC TaskTemplateManagerServer interface and handles all task template gRPC requests.
+type TaskTemplateHandler struct {
+	pb.UnimplementedTaskTemplateManagerServer
+	service services.TaskTemplateService
+	logger  *zap.Logger
+}
+
+// NewTaskTemplateHandler initializes a new TaskTemplateHandler instance.
+func NewTaskTemplateHandler(service services.TaskTemplateService, logger *zap.Logger) pb.TaskTemplateManagerServer {
+	return &TaskTemplateHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateTaskTemplate handles the gRPC request to create a template in a project.
+func (h *TaskTemplateHandler) CreateTaskTemplate(ctx context.Context, req *pb.CreateTaskTemplateRequest) (*pb.TaskTemplateResponse, error) {
+	h.logger.Info("Received CreateTaskTemplate request", zap.Int64("project_id", req.ProjectId), zap.String("name", req.Name))
+
+	if err := trimAndValidateCreateTaskTemplateRequest(req); err != nil {
+		h.logger.Warn("Validation failed for CreateTaskTemplate", zap.Error(err))
+		return nil, err
+	}
+
+	template := &models.TaskTemplate{
+		ProjectID:   req.ProjectId,
+		Name:        req.Name,
+		Description: req.Description,
+		Variables:   req.Variables,
+		Tasks:       fromTaskBlueprints(req.Tasks),
+	}
+
+	createdTemplate, err := h.service.CreateTaskTemplate(ctx, template)
+	if err != nil {
+		if st := templateError(err); st != nil {
+			h.logger.Warn("Task template rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to create task template", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to create task template")
+	}
+
+	return toTaskTemplateResponse(createdTemplate), nil
+}
+
+// ListTaskTemplates handles the gRPC request to list the templates of a project.
+func (h *TaskTemplateHandler) ListTaskTemplates(ctx context.Context, req *pb.ListTaskTemplatesRequest) (*pb.ListTaskTemplatesResponse, error) {
+	h.logger.Info("Received ListTaskTemplates request", zap.Int64("project_id", req.ProjectId))
+
+	if err := validateProjectID(req.ProjectId); err != nil {
+		h.logger.Warn("Validation failed for ListTaskTemplates", zap.Error(err))
+		return nil, err
+	}
+
+	templates, err := h.service.ListTaskTemplates(ctx, req.ProjectId)
+	if err != nil {
+		if st := templateError(err); st != nil {
+			h.logger.Warn("Task templates unavailable", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to list task templates", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list task templates")
+	}
+
+	var templateResponses []*pb.TaskTemplateResponse
+	for _, template := range templates {
+		templateResponses = append(templateResponses, toTaskTemplateResponse(template))
+	}
+
+	return &pb.ListTaskTemplatesResponse{Templates: templateResponses}, nil
+}
+
+// GetTaskTemplate handles the gRPC request to retrieve a version of a template.
+func (h *TaskTemplateHandler) GetTaskTemplate(ctx context.Context, req *pb.GetTaskTemplateRequest) (*pb.TaskTemplateResponse, error) {
+	h.logger.Info("Received GetTaskTemplate request", zap.Int64("id", req.Id), zap.Int32("version", req.Version))
+
+	if err := validateTaskTemplateRef(req.Id, req.Version); err != nil {
+		h.logger.Warn("Validation failed for GetTaskTemplate", zap.Error(err))
+		return nil, err
+	}
+
+	template, err := h.service.GetTaskTemplate(ctx, req.Id, int(req.Version))
+	if err != nil {
+		if st := templateError(err); st != nil {
+			h.logger.Warn("Task template unavailable", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to fetch task template", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to fetch task template")
+	}
+
+	return toTaskTemplateResponse(template), nil
+}
+
+// UpdateTaskTemplate handles the gRPC request to store a new version of a template.
+func (h *TaskTemplateHandler) UpdateTaskTemplate(ctx context.Context, req *pb.UpdateTaskTemplateRequest) (*pb.TaskTemplateResponse, error) {
+	h.logger.Info("Received UpdateTaskTemplate request", zap.Int64("id", req.Id))
+
+	if err := trimAndValidateUpdateTaskTemplateRequest(req); err != nil {
+		h.logger.Warn("Validation failed for UpdateTaskTemplate", zap.Error(err))
+		return nil, err
+	}
+
+	template := &models.TaskTemplate{
+		ID:          req.Id,
+		Name:        req.Name,
+		Description: req.Description,
+		Variables:   req.Variables,
+		Tasks:       fromTaskBlueprints(req.Tasks),
+	}
+
+	updatedTemplate, err := h.service.UpdateTaskTemplate(ctx, template)
+	if err != nil {
+		if st := templateError(err); st != nil {
+			h.logger.Warn("Task template update rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to update task template", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to update task template")
+	}
+
+	return toTaskTemplateResponse(updatedTemplate), nil
+}
+
+// DeleteTaskTemplate handles the gRPC request to delete a template with all its versions.
+func (h *TaskTemplateHandler) DeleteTaskTemplate(ctx context.Context, req *pb.DeleteTaskTemplateRequest) (*pb.DeleteTaskTemplateResponse, error) {
+	h.logger.Info("Received DeleteTaskTemplate request", zap.Int64("id", req.Id))
+
+	if err := validateTaskTemplateRef(req.Id, 0); err != nil {
+		h.logger.Warn("Validation failed for DeleteTaskTemplate", zap.Error(err))
+		return nil, err
+	}
+
+	if err := h.service.DeleteTaskTemplate(ctx, req.Id); err != nil {
+		if st := templateError(err); st != nil {
+			h.logger.Warn("Task template deletion rejected", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to delete task template", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to delete task template")
+	}
+
+	return &pb.DeleteTaskTemplateResponse{Success: true}, nil
+}
+
+// InstantiateTemplate handles the gRPC request to create the tasks of a template.
+func (h *TaskTemplateHandler) InstantiateTemplate(ctx context.Context, req *pb.InstantiateTemplateRequest) (*pb.InstantiateTemplateResponse, error) {
+	h.logger.Info("Received InstantiateTemplate request", zap.Int64("id", req.Id), zap.Int32("version", req.Version))
+
+	if err := trimAndValidateInstantiateTemplateRequest(req); err != nil {
+		h.logger.Warn("Validation failed for InstantiateTemplate", zap.Error(err))
+		return nil, err
+	}
+
+	instance, err := h.service.InstantiateTemplate(ctx, req.Id, int(req.Version), req.Variables)
+	if err != nil {
+		var itemErr *services.BatchItemError
+		if errors.As(err, &itemErr) {
+			h.logger.Warn("Template task rejected", zap.Int("task", itemErr.Index), zap.Error(itemErr.Err))
+			st := status.Convert(taskError(itemErr.Err, "Failed to instantiate task template"))
+			return nil, status.Errorf(st.Code(), "Task %d: %s", itemErr.Index, st.Message())
+		}
+		if st := templateError(err); st != nil {
+			h.logger.Warn("Task template cannot be instantiated", zap.Error(err))
+			return nil, st
+		}
+		h.logger.Error("Failed to instantiate task template", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to instantiate task template")
+	}
+
+	response := &pb.InstantiateTemplateResponse{
+		Version:   int32(instance.Template.Version),
+		Relations: toTaskRelations(instance.Relations),
+	}
+	for _, task := range instance.Tasks {
+		response.Tasks = append(response.Tasks, toTaskResponse(task, timeZoneOf(ctx)))
+	}
+
+	return response, nil
+}
+
+// templateError maps the errors of the task template service to gRPC status errors. It returns nil for any other error.
+func templateError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrTaskTemplateNotFound):
+		return status.Error(codes.NotFound, "Task template not found")
+	case errors.Is(err, services.ErrTaskTemplateNameTaken):
+		return status.Error(codes.AlreadyExists, "Task template name is already taken")
+	case errors.Is(err, services.ErrInvalidTaskTemplate), errors.Is(err, services.ErrTemplateVariables):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return projectRuleError(err)
+}
+
+// fromTaskBlueprints converts gRPC task blueprints and their subtasks into their model representation.
+func fromTaskBlueprints(blueprints []*pb.TaskBlueprint) []models.TaskBlueprint {
+	if len(blueprints) == 0 {
+		return nil
+	}
+	result := make([]models.TaskBlueprint, len(blueprints))
+	for i, blueprint := range blueprints {
+		result[i] = models.TaskBlueprint{
+			Ref:          blueprint.Ref,
+			Title:        blueprint.Title,
+			Description:  blueprint.Description,
+			Status:       blueprint.Status,
+			Assignee:     blueprint.Assignee,
+			Labels:       blueprint.Labels,
+			CustomFields: fromCustomFieldValues(blueprint.CustomFields),
+			Subtasks:     fromTaskBlueprints(blueprint.Subtasks),
+		}
+		for _, relation := range blueprint.Relations {
+			result[i].Relations = append(result[i].Relations, models.BlueprintRelation{Type: relation.Type, Ref: relation.Ref})
+		}
+	}
+	return result
+}
+
+// toTaskBlueprints converts task blueprints and their subtasks into their gRPC representation.
+func toTaskBlueprints(blueprints []models.TaskBlueprint) []*pb.TaskBlueprint {
+	var result []*pb.TaskBlueprint
+	for _, blueprint := range blueprints {
+		response := &pb.TaskBlueprint{
+			Ref:          blueprint.Ref,
+			Title:        blueprint.Title,
+			Description:  blueprint.Description,
+			Status:       blueprint.Status,
+			Assignee:     blueprint.Assignee,
+			Labels:       blueprint.Labels,
+			CustomFields: toCustomFieldValues(blueprint.CustomFields),
+			Subtasks:     toTaskBlueprints(blueprint.Subtasks),
+		}
+		for _, relation := range blueprint.Relations {
+			response.Relations = append(response.Relations, &pb.BlueprintRelation{Type: relation.Type, Ref: relation.Ref})
+		}
+		result = append(result, response)
+	}
+	return result
+}
+
+// toTaskTemplateResponse converts a task template model into its gRPC representation.
+func toTaskTemplateResponse(template *models.TaskTemplate) *pb.TaskTemplateResponse {
+	return &pb.TaskTemplateResponse{
+		Id:          template.ID,
+		ProjectId:   template.ProjectID,
+		Name:        template.Name,
+		Version:     int32(template.Version),
+		Description: template.Description,
+		Variables:   template.Variables,
+		CreatedAt:   template.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   template.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Tasks:       toTaskBlueprints(template.Tasks),
+	}
+}
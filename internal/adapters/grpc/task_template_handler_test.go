@@ -0,0 +1,185 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockTaskTemplateService struct {
+	mock.Mock
+}
+
+func (m *MockTaskTemplateService) CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	args := m.Called(template)
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateService) GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error) {
+	args := m.Called(id, version)
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateService) ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error) {
+	args := m.Called(projectID)
+	return args.Get(0).([]*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateService) UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	args := m.Called(template)
+	return args.Get(0).(*models.TaskTemplate), args.Error(1)
+}
+
+func (m *MockTaskTemplateService) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockTaskTemplateService) InstantiateTemplate(ctx context.Context, id int64, version int, values map[string]string) (*services.TemplateInstance, error) {
+	args := m.Called(id, version, values)
+	return args.Get(0).(*services.TemplateInstance), args.Error(1)
+}
+
+func setupTaskTemplateHandler() (*MockTaskTemplateService, *TaskTemplateHandler) {
+	mockService := new(MockTaskTemplateService)
+	logger, _ := zap.NewDevelopment()
+	handler := &TaskTemplateHandler{
+		service: mockService,
+		logger:  logger,
+	}
+	return mockService, handler
+}
+
+func TestTaskTemplateHandler_CreateTaskTemplate(t *testing.T) {
+	mockService, handler := setupTaskTemplateHandler()
+
+	template := &models.TaskTemplate{ProjectID: 1, Name: "Onboarding", Variables: []string{"name"}, Tasks: []models.TaskBlueprint{
+		{Ref: "welcome", Title: "Welcome {{name}}", Description: "Say hello", Labels: []string{"onboarding"},
+			CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 1}},
+			Subtasks: []models.TaskBlueprint{{Title: "Send the handbook", Description: "By mail", Labels: []string{},
+				Relations: []models.BlueprintRelation{{Type: models.RelationBlocks, Ref: "welcome"}}}}},
+	}}
+	created := *template
+	created.ID = 1
+	created.Version = 1
+	mockService.On("CreateTaskTemplate", template).Return(&created, nil)
+
+	resp, err := handler.CreateTaskTemplate(context.Background(), &pb.CreateTaskTemplateRequest{
+		ProjectId: 1,
+		Name:      " Onboarding ",
+		Variables: []string{" name "},
+		Tasks: []*pb.TaskBlueprint{{Ref: " welcome", Title: " Welcome {{name}} ", Description: "Say hello", Labels: []string{"onboarding "},
+			CustomFields: map[string]*pb.CustomFieldValue{"points": {Value: &pb.CustomFieldValue_NumberValue{NumberValue: 1}}},
+			Subtasks: []*pb.TaskBlueprint{{Title: "Send the handbook", Description: "By mail",
+				Relations: []*pb.BlueprintRelation{{Type: "blocks ", Ref: "welcome"}}}}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.Id)
+	require.Equal(t, int32(1), resp.Version)
+	require.Equal(t, "Welcome {{name}}", resp.Tasks[0].Title)
+	require.Equal(t, "welcome", resp.Tasks[0].Ref)
+	require.Equal(t, []*pb.BlueprintRelation{{Type: "blocks", Ref: "welcome"}}, resp.Tasks[0].Subtasks[0].Relations)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskTemplateHandler_CreateTaskTemplate_Invalid(t *testing.T) {
+	blueprint := func() *pb.TaskBlueprint { return &pb.TaskBlueprint{Title: "T", Description: "D"} }
+	subtasks := make([]*pb.TaskBlueprint, MaxTemplateTasks)
+	for i := range subtasks {
+		subtasks[i] = blueprint()
+	}
+	tests := []struct {
+		name    string
+		req     *pb.CreateTaskTemplateRequest
+		wantMsg string
+	}{
+		{name: "No tasks", req: &pb.CreateTaskTemplateRequest{ProjectId: 1, Name: "T"}, wantMsg: "Template must have at least one task"},
+		{name: "Invalid variable", req: &pb.CreateTaskTemplateRequest{ProjectId: 1, Name: "T", Variables: []string{"Team Name"},
+			Tasks: []*pb.TaskBlueprint{blueprint()}}, wantMsg: `Invalid variable name "Team Name"`},
+		{name: "Invalid task", req: &pb.CreateTaskTemplateRequest{ProjectId: 1, Name: "T",
+			Tasks: []*pb.TaskBlueprint{blueprint(), {Title: "No description"}}}, wantMsg: "Task 1: Description cannot be empty"},
+		{name: "Invalid subtask", req: &pb.CreateTaskTemplateRequest{ProjectId: 1, Name: "T",
+			Tasks: []*pb.TaskBlueprint{blueprint(), {Title: "T", Description: "D", Subtasks: []*pb.TaskBlueprint{{Title: " "}}}}},
+			wantMsg: "Task 1.0: Title cannot be empty"},
+		{name: "Relation without ref", req: &pb.CreateTaskTemplateRequest{ProjectId: 1, Name: "T",
+			Tasks: []*pb.TaskBlueprint{{Title: "T", Description: "D", Relations: []*pb.BlueprintRelation{{Type: "blocks"}}}}},
+			wantMsg: "Task 0: Relations need a type and a ref"},
+		{name: "Too many tasks", req: &pb.CreateTaskTemplateRequest{ProjectId: 1, Name: "T",
+			Tasks: []*pb.TaskBlueprint{{Title: "T", Description: "D", Subtasks: subtasks}}},
+			wantMsg: "Template exceeds maximum of 100 tasks"},
+		{name: "No project", req: &pb.CreateTaskTemplateRequest{Name: "T", Tasks: []*pb.TaskBlueprint{blueprint()}},
+			wantMsg: "ID must be greater than 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService, handler := setupTaskTemplateHandler()
+			_, err := handler.CreateTaskTemplate(context.Background(), tt.req)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			require.Equal(t, tt.wantMsg, status.Convert(err).Message())
+			mockService.AssertNotCalled(t, "CreateTaskTemplate")
+		})
+	}
+}
+
+func TestTaskTemplateHandler_GetTaskTemplate_NotFound(t *testing.T) {
+	mockService, handler := setupTaskTemplateHandler()
+	mockService.On("GetTaskTemplate", int64(1), 4).Return((*models.TaskTemplate)(nil), services.ErrTaskTemplateNotFound)
+
+	_, err := handler.GetTaskTemplate(context.Background(), &pb.GetTaskTemplateRequest{Id: 1, Version: 4})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTaskTemplateHandler_InstantiateTemplate(t *testing.T) {
+	t.Run("Creates the tasks", func(t *testing.T) {
+		mockService, handler := setupTaskTemplateHandler()
+		mockService.On("InstantiateTemplate", int64(1), 0, map[string]string{"name": "ada"}).Return(&services.TemplateInstance{
+			Template:  &models.TaskTemplate{ID: 1, Version: 2},
+			Tasks:     []*models.Task{{ID: 7, Key: "OPS-7", Title: "Welcome ada"}, {ID: 8, Key: "OPS-8", Title: "Meet ada", ParentID: 7}},
+			Relations: []models.TaskRelation{{TaskID: 8, RelatedTaskID: 7, Type: models.RelationBlocks}},
+		}, nil)
+
+		resp, err := handler.InstantiateTemplate(context.Background(), &pb.InstantiateTemplateRequest{Id: 1, Variables: map[string]string{"name": " ada "}})
+		require.NoError(t, err)
+		require.Equal(t, int32(2), resp.Version)
+		require.Len(t, resp.Tasks, 2)
+		require.Equal(t, "OPS-8", resp.Tasks[1].Key)
+		require.Equal(t, int64(7), resp.Tasks[1].ParentId)
+		require.Equal(t, []*pb.TaskRelation{{TaskId: 8, RelatedTaskId: 7, Type: "blocks"}}, resp.Relations)
+	})
+
+	t.Run("Names the failed task", func(t *testing.T) {
+		mockService, handler := setupTaskTemplateHandler()
+		itemErr := &services.BatchItemError{Index: 1, Err: fmt.Errorf("%w: %q", services.ErrInvalidStatus, "later")}
+		mockService.On("InstantiateTemplate", int64(1), 0, map[string]string(nil)).Return((*services.TemplateInstance)(nil), itemErr)
+
+		_, err := handler.InstantiateTemplate(context.Background(), &pb.InstantiateTemplateRequest{Id: 1})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		require.Equal(t, `Task 1: status is not part of the project workflow: "later"`, status.Convert(err).Message())
+	})
+
+	t.Run("Variables must match", func(t *testing.T) {
+		mockService, handler := setupTaskTemplateHandler()
+		mockService.On("InstantiateTemplate", int64(1), 0, map[string]string(nil)).Return((*services.TemplateInstance)(nil),
+			fmt.Errorf("%w: missing a value for name", services.ErrTemplateVariables))
+
+		_, err := handler.InstantiateTemplate(context.Background(), &pb.InstantiateTemplateRequest{Id: 1})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Empty variable", func(t *testing.T) {
+		mockService, handler := setupTaskTemplateHandler()
+		_, err := handler.InstantiateTemplate(context.Background(), &pb.InstantiateTemplateRequest{Id: 1, Variables: map[string]string{"name": " "}})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		mockService.AssertNotCalled(t, "InstantiateTemplate")
+	})
+}
@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timeZoneHeader is the metadata header by which clients ask for the string timestamps of tasks to be
+// rendered in an IANA time zone, such as Europe/Berlin, rather than in UTC.
+const timeZoneHeader = "time-zone"
+
+type timeZoneKey struct{}
+
+// NewTimeZoneInterceptor tags the context of unary RPCs with the time zone of their time-zone header,
+// failing RPCs that name an unknown zone with InvalidArgument.
+func NewTimeZoneInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := withTimeZone(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewStreamTimeZoneInterceptor tags the context of streaming RPCs with their time zone like
+// NewTimeZoneInterceptor.
+func NewStreamTimeZoneInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withTimeZone(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &callerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func withTimeZone(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	for _, value := range md.Get(timeZoneHeader) {
+		if value = strings.TrimSpace(value); value != "" {
+			location, err := parseTimeZone(value)
+			if err != nil {
+				return nil, err
+			}
+			return context.WithValue(ctx, timeZoneKey{}, location), nil
+		}
+	}
+	return ctx, nil
+}
+
+// timeZoneOf returns the time zone the client of the RPC asked for, or UTC.
+func timeZoneOf(ctx context.Context) *time.Location {
+	if location, ok := ctx.Value(timeZoneKey{}).(*time.Location); ok {
+		return location
+	}
+	return time.UTC
+}
+
+// formatTimestamp renders t for the string timestamp fields of responses, in RFC 3339 with the offset
+// of location.
+func formatTimestamp(t time.Time, location *time.Location) string {
+	return t.In(location).Format(time.RFC3339)
+}
+
+// toTimestamp converts t for the google.protobuf.Timestamp fields of responses, which carry no zone.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTimeZoneInterceptor(t *testing.T) {
+	interceptor := NewTimeZoneInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/taskmanager.TaskManager/GetTask"}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		want     string
+		wantCode codes.Code
+	}{
+		{"Header", metadata.NewIncomingContext(context.Background(), metadata.Pairs(timeZoneHeader, " Europe/Berlin ")), "Europe/Berlin", codes.OK},
+		{"No header", context.Background(), "UTC", codes.OK},
+		{"Unknown zone", metadata.NewIncomingContext(context.Background(), metadata.Pairs(timeZoneHeader, "Mars/Olympus")), "", codes.InvalidArgument},
+		{"Local", metadata.NewIncomingContext(context.Background(), metadata.Pairs(timeZoneHeader, "Local")), "", codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var zone string
+			_, err := interceptor(tt.ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				zone = timeZoneOf(ctx).String()
+				return nil, nil
+			})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.want, zone)
+		})
+	}
+}
+
+func TestToTaskResponse_Timestamps(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	created := time.Date(2026, 3, 29, 0, 30, 0, 0, time.UTC)
+	task := &models.Task{ID: 1, CreatedAt: created, UpdatedAt: created.Add(2 * time.Hour)}
+
+	response := toTaskResponse(task, time.UTC)
+	assert.Equal(t, "2026-03-29T00:30:00Z", response.CreatedAt)
+	assert.Equal(t, "2026-03-29T02:30:00Z", response.UpdatedAt)
+
+	// The string fields follow the zone across its change to daylight saving time, the timestamps do not
+	// depend on it.
+	response = toTaskResponse(task, berlin)
+	assert.Equal(t, "2026-03-29T01:30:00+01:00", response.CreatedAt)
+	assert.Equal(t, "2026-03-29T04:30:00+02:00", response.UpdatedAt)
+	assert.True(t, response.CreateTime.AsTime().Equal(created))
+	assert.True(t, response.UpdateTime.AsTime().Equal(task.UpdatedAt))
+}
@@ -1,18 +1,43 @@
 package grpc
 
 import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/ids"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
 	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 const (
-	MaxLength = 255 // Maximum length for string fields like Title.
-	MinId     = 1   // Minimum valid ID value.
+	MaxLength             = 255   // Maximum length for string fields like Title.
+	MinId                 = 1     // Minimum valid ID value.
+	MaxPageSize           = 1000  // Maximum number of tasks in a ListTasks page.
+	DefaultSearchPageSize = 20    // Number of SearchTasks results when no page size is given.
+	MaxSearchQueryLength  = 1000  // Maximum length of a SearchTasks query.
+	MaxFilterLength       = 2000  // Maximum length of a ListTasks filter.
+	MaxTemplateTasks      = 100   // Maximum number of tasks in a task template, subtasks included.
+	MaxTaskRelations      = 100   // Maximum number of relations created at once.
+	MaxChecklistItems     = 100   // Maximum number of items in a checklist.
+	MaxCommentLength      = 10000 // Maximum length of a comment.
+	MaxURLLength          = 2048  // Maximum length of an attachment URL.
 )
 
+var (
+	projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)             // Project key such as OPS.
+	taskKeyPattern    = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}-[1-9][0-9]*$`) // Task key such as OPS-123.
+	fieldNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)           // Custom field name such as story_points.
+)
+
+// fieldOperators are the comparison operators of custom field filters.
+var fieldOperators = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
 // trimAndValidateCreateTaskRequest validates and trims a CreateTaskRequest.
 // Ensures Title and Description are not empty and Title does not exceed MaxLength.
 func trimAndValidateCreateTaskRequest(req *pb.CreateTaskRequest) error {
@@ -28,7 +53,20 @@ func trimAndValidateCreateTaskRequest(req *pb.CreateTaskRequest) error {
 	if req.Description == "" {
 		return status.Error(codes.InvalidArgument, "Description cannot be empty")
 	}
-	return nil
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if req.ParentId < 0 {
+		return status.Error(codes.InvalidArgument, "Parent ID cannot be negative")
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	req.Assignee = strings.TrimSpace(req.Assignee)
+	labels, err := trimAndValidateNames(req.Labels, "Label")
+	if err != nil {
+		return err
+	}
+	req.Labels = labels
+	return validateCustomFieldValues(req.CustomFields)
 }
 
 // trimAndValidateUpdateTaskRequest validates and trims an UpdateTaskRequest.
@@ -37,8 +75,8 @@ func trimAndValidateUpdateTaskRequest(req *pb.UpdateTaskRequest) error {
 	req.Title = strings.TrimSpace(req.Title)
 	req.Description = strings.TrimSpace(req.Description)
 
-	if req.Id < MinId {
-		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	if err := trimAndValidateTaskRef(req.Id, &req.Key, &req.ExternalId); err != nil {
+		return err
 	}
 	if req.Title == "" {
 		return status.Error(codes.InvalidArgument, "Title cannot be empty")
@@ -49,14 +87,29 @@ func trimAndValidateUpdateTaskRequest(req *pb.UpdateTaskRequest) error {
 	if req.Description == "" {
 		return status.Error(codes.InvalidArgument, "Description cannot be empty")
 	}
-	return nil
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	req.Status = strings.TrimSpace(req.Status)
+	req.Assignee = strings.TrimSpace(req.Assignee)
+	if req.Labels != nil {
+		labels, err := trimAndValidateNames(req.Labels.Values, "Label")
+		if err != nil {
+			return err
+		}
+		req.Labels.Values = labels
+	}
+	return validateCustomFieldValues(req.CustomFields)
 }
 
 // trimAndValidateGetTaskRequest validates a GetTaskRequest.
 // Ensures ID is valid.
 func trimAndValidateGetTaskRequest(req *pb.GetTaskRequest) error {
-	if req.Id < MinId {
-		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	if err := trimAndValidateTaskRef(req.Id, &req.Key, &req.ExternalId); err != nil {
+		return err
+	}
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
 	}
 	return nil
 }
@@ -64,8 +117,780 @@ func trimAndValidateGetTaskRequest(req *pb.GetTaskRequest) error {
 // trimAndValidateDeleteTaskRequest validates a DeleteTaskRequest.
 // Ensures ID is valid.
 func trimAndValidateDeleteTaskRequest(req *pb.DeleteTaskRequest) error {
+	if err := trimAndValidateTaskRef(req.Id, &req.Key, &req.ExternalId); err != nil {
+		return err
+	}
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	return nil
+}
+
+// validateBatchSize ensures a batch request carries at least one and at most maxSize items.
+func validateBatchSize(size, maxSize int) error {
+	if size == 0 {
+		return status.Error(codes.InvalidArgument, "Batch cannot be empty")
+	}
+	if size > maxSize {
+		return status.Errorf(codes.InvalidArgument, "Batch exceeds maximum size of %d items", maxSize)
+	}
+	return nil
+}
+
+// validateBatchItemKey ensures an item of a batch carries no idempotency key, as keys apply to whole batches.
+func validateBatchItemKey(key string) error {
+	if strings.TrimSpace(key) != "" {
+		return status.Error(codes.InvalidArgument, "Idempotency keys apply to the whole batch, not to its items")
+	}
+	return nil
+}
+
+// trimAndValidateListTasksRequest validates a ListTasksRequest.
+// Ensures the optional project ID is not negative and the page size is within bounds.
+func trimAndValidateListTasksRequest(req *pb.ListTasksRequest) error {
+	req.Status = strings.TrimSpace(req.Status)
+	req.PageToken = strings.TrimSpace(req.PageToken)
+	req.Filter = strings.TrimSpace(req.Filter)
+
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if req.PageSize < 0 || req.PageSize > MaxPageSize {
+		return status.Errorf(codes.InvalidArgument, "Page size must be between 0 and %d", MaxPageSize)
+	}
+	if len(req.Filter) > MaxFilterLength {
+		return status.Errorf(codes.InvalidArgument, "Filter exceeds maximum length of %d characters", MaxFilterLength)
+	}
+	if req.PageToken != "" && req.PageSize == 0 {
+		return status.Error(codes.InvalidArgument, "Page token requires a page size")
+	}
+	for _, filter := range req.CustomFieldFilters {
+		filter.Field = strings.TrimSpace(filter.Field)
+		filter.Operator = strings.TrimSpace(filter.Operator)
+		if !fieldNamePattern.MatchString(filter.Field) {
+			return status.Error(codes.InvalidArgument, "Custom field filter must name a field")
+		}
+		if !fieldOperators[filter.Operator] {
+			return status.Error(codes.InvalidArgument, "Custom field filter operator must be one of =, !=, <, <=, > and >=")
+		}
+		if filter.GetValue().GetValue() == nil {
+			return status.Errorf(codes.InvalidArgument, "Custom field filter on %s needs a value", filter.Field)
+		}
+	}
+	return nil
+}
+
+// trimAndValidateExportTasksRequest validates and trims an ExportTasksRequest.
+// Ensures the format is known, defaulting it to csv, the filter is not too long and the columns are distinct.
+func trimAndValidateExportTasksRequest(req *pb.ExportTasksRequest) error {
+	req.Status = strings.TrimSpace(req.Status)
+	req.Filter = strings.TrimSpace(req.Filter)
+	req.Format = strings.ToLower(strings.TrimSpace(req.Format))
+	req.TimeZone = strings.TrimSpace(req.TimeZone)
+
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if len(req.Filter) > MaxFilterLength {
+		return status.Errorf(codes.InvalidArgument, "Filter exceeds maximum length of %d characters", MaxFilterLength)
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if _, ok := exportFormats[req.Format]; !ok {
+		return status.Error(codes.InvalidArgument, "Format must be one of csv, jsonl and markdown")
+	}
+	columns, err := trimAndValidateNames(req.Columns, "Column")
+	if err != nil {
+		return err
+	}
+	req.Columns = columns
+	return nil
+}
+
+// trimAndValidateGetCalendarFeedRequest validates and trims a GetCalendarFeedRequest, defaulting the due
+// field to defaultDueField and the component to todo.
+func trimAndValidateGetCalendarFeedRequest(req *pb.GetCalendarFeedRequest) error {
+	req.Assignee = strings.TrimSpace(req.Assignee)
+	req.Label = strings.TrimSpace(req.Label)
+	req.DueField = strings.TrimSpace(req.DueField)
+	req.Component = strings.ToLower(strings.TrimSpace(req.Component))
+
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if len(req.Assignee) > MaxLength || len(req.Label) > MaxLength {
+		return status.Error(codes.InvalidArgument, "Assignee and label cannot exceed 255 characters")
+	}
+	if req.DueField == "" {
+		req.DueField = defaultDueField
+	}
+	if !fieldNamePattern.MatchString(req.DueField) {
+		return status.Errorf(codes.InvalidArgument, "Invalid custom field name %q", req.DueField)
+	}
+	if req.Component == "" {
+		req.Component = "todo"
+	}
+	if req.Component != "todo" && req.Component != "event" {
+		return status.Error(codes.InvalidArgument, "Component must be todo or event")
+	}
+	return nil
+}
+
+// trimAndValidateImportOptions validates and trims the options of an import, defaulting the format to csv
+// and the source to the format. Ensures the project ID is valid and the field mapping only names task fields.
+func trimAndValidateImportOptions(options *pb.ImportOptions) error {
+	if options == nil {
+		return status.Error(codes.InvalidArgument, "Import options are required in the first message")
+	}
+	options.Format = strings.ToLower(strings.TrimSpace(options.Format))
+	options.Source = strings.TrimSpace(options.Source)
+
+	if err := validateProjectID(options.ProjectId); err != nil {
+		return err
+	}
+	if options.Format == "" {
+		options.Format = "csv"
+	}
+	if _, ok := importFormats[options.Format]; !ok {
+		return status.Error(codes.InvalidArgument, "Format must be one of csv, jsonl, github and jira")
+	}
+	if options.Source == "" {
+		options.Source = options.Format
+	}
+	if len(options.Source) > MaxLength {
+		return status.Error(codes.InvalidArgument, "Source exceeds maximum length of 255 characters")
+	}
+	for field, target := range options.FieldMapping {
+		if strings.TrimSpace(field) == "" {
+			return status.Error(codes.InvalidArgument, "Field mapping cannot map an empty field")
+		}
+		name, isCustomField := strings.CutPrefix(target, customFieldColumnPrefix)
+		if target != "" && !slices.Contains(importTargets, target) && !(isCustomField && fieldNamePattern.MatchString(name)) {
+			return status.Errorf(codes.InvalidArgument, "Field mapping maps %q to unknown task field %q", field, target)
+		}
+	}
+	return nil
+}
+
+// parseTimeZone loads an IANA time zone such as Europe/Berlin. An empty name stands for UTC.
+func parseTimeZone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, status.Error(codes.InvalidArgument, "Time zone must be an IANA time zone such as Europe/Berlin")
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Unknown time zone %q", name)
+	}
+	return location, nil
+}
+
+// trimAndValidateSearchTasksRequest validates and trims a SearchTasksRequest.
+// Ensures the query is not empty or too long and the page size is within bounds, defaulting it to DefaultSearchPageSize.
+func trimAndValidateSearchTasksRequest(req *pb.SearchTasksRequest) error {
+	req.Query = strings.TrimSpace(req.Query)
+	req.PageToken = strings.TrimSpace(req.PageToken)
+
+	if req.Query == "" {
+		return status.Error(codes.InvalidArgument, "Query cannot be empty")
+	}
+	if len(req.Query) > MaxSearchQueryLength {
+		return status.Errorf(codes.InvalidArgument, "Query exceeds maximum length of %d characters", MaxSearchQueryLength)
+	}
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if req.PageSize < 0 || req.PageSize > MaxPageSize {
+		return status.Errorf(codes.InvalidArgument, "Page size must be between 0 and %d", MaxPageSize)
+	}
+	if req.PageSize == 0 {
+		req.PageSize = DefaultSearchPageSize
+	}
+	return nil
+}
+
+// parseOrderBy parses the order_by of a ListTasksRequest, "<field>" or "<field> asc|desc".
+// An empty order_by keeps the board order and returns nil.
+func parseOrderBy(orderBy string) (*models.FieldOrder, error) {
+	parts := strings.Fields(strings.ToLower(orderBy))
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	if len(parts) > 2 || !fieldNamePattern.MatchString(parts[0]) || len(parts) == 2 && parts[1] != "asc" && parts[1] != "desc" {
+		return nil, status.Error(codes.InvalidArgument, `Order must be a custom field name optionally followed by "asc" or "desc"`)
+	}
+	return &models.FieldOrder{Field: parts[0], Descending: len(parts) == 2 && parts[1] == "desc"}, nil
+}
+
+// trimAndValidateMoveTaskRequest validates and trims a MoveTaskRequest.
+// Ensures the task reference is valid and each neighbour is given at most once, by a non-negative ID or an external ID.
+func trimAndValidateMoveTaskRequest(req *pb.MoveTaskRequest) error {
+	req.Status = strings.TrimSpace(req.Status)
+
+	var key string
+	if err := trimAndValidateTaskRef(req.Id, &key, &req.ExternalId); err != nil {
+		return err
+	}
+	if req.ProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if req.AfterTaskId < 0 || req.BeforeTaskId < 0 {
+		return status.Error(codes.InvalidArgument, "Neighbour task IDs cannot be negative")
+	}
+	if err := trimAndValidateExternalID(&req.AfterTaskExternalId); err != nil {
+		return err
+	}
+	if err := trimAndValidateExternalID(&req.BeforeTaskExternalId); err != nil {
+		return err
+	}
+	if (req.AfterTaskId != 0 && req.AfterTaskExternalId != "") || (req.BeforeTaskId != 0 && req.BeforeTaskExternalId != "") {
+		return status.Error(codes.InvalidArgument, "Specify each neighbour either by ID or by external ID, not both")
+	}
+	return nil
+}
+
+// trimAndValidateTransferTaskRequest validates a TransferTaskRequest.
+// Ensures the task reference is valid and the target project ID is not negative.
+func trimAndValidateTransferTaskRequest(req *pb.TransferTaskRequest) error {
+	if err := trimAndValidateTaskRef(req.Id, &req.Key, &req.ExternalId); err != nil {
+		return err
+	}
+	if req.TargetProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Target project ID cannot be negative")
+	}
+	return nil
+}
+
+// trimAndValidateCreateTaskRelationsRequest validates and trims a CreateTaskRelationsRequest.
+// Ensures the task ID is valid and every relation has a type and a valid related task, filling in the task ID.
+func trimAndValidateCreateTaskRelationsRequest(req *pb.CreateTaskRelationsRequest) error {
+	if req.TaskId < MinId {
+		return status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+	if len(req.Relations) == 0 {
+		return status.Error(codes.InvalidArgument, "Relations cannot be empty")
+	}
+	if len(req.Relations) > MaxTaskRelations {
+		return status.Errorf(codes.InvalidArgument, "Relations exceed maximum of %d", MaxTaskRelations)
+	}
+	for i, relation := range req.Relations {
+		if relation.TaskId != 0 && relation.TaskId != req.TaskId {
+			return status.Errorf(codes.InvalidArgument, "Relation %d: Task ID must be the ID of the task or empty", i)
+		}
+		relation.TaskId = req.TaskId
+		if err := trimAndValidateTaskRelation(relation); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Relation %d: %s", i, status.Convert(err).Message())
+		}
+	}
+	return nil
+}
+
+// trimAndValidateDeleteTaskRelationRequest validates and trims a DeleteTaskRelationRequest.
+func trimAndValidateDeleteTaskRelationRequest(req *pb.DeleteTaskRelationRequest) error {
+	if req.TaskId < MinId {
+		return status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+	relation := &pb.TaskRelation{TaskId: req.TaskId, RelatedTaskId: req.RelatedTaskId, Type: req.Type}
+	if err := trimAndValidateTaskRelation(relation); err != nil {
+		return err
+	}
+	req.Type = relation.Type
+	return nil
+}
+
+// trimAndValidateTaskRelation checks the related task and the type of a relation. Whether the type is
+// known is up to the service.
+func trimAndValidateTaskRelation(relation *pb.TaskRelation) error {
+	relation.Type = strings.TrimSpace(relation.Type)
+	if relation.RelatedTaskId < MinId {
+		return status.Error(codes.InvalidArgument, "Related task ID must be greater than 0")
+	}
+	if relation.Type == "" {
+		return status.Error(codes.InvalidArgument, "Type cannot be empty")
+	}
+	return nil
+}
+
+// trimAndValidateSetTaskChecklistRequest validates and trims a SetTaskChecklistRequest.
+// Ensures the task ID is valid and every item has a text that does not exceed MaxLength.
+func trimAndValidateSetTaskChecklistRequest(req *pb.SetTaskChecklistRequest) error {
+	if req.TaskId < MinId {
+		return status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+	if len(req.Items) > MaxChecklistItems {
+		return status.Errorf(codes.InvalidArgument, "Items exceed maximum of %d", MaxChecklistItems)
+	}
+	for i, item := range req.Items {
+		item.Text = strings.TrimSpace(item.Text)
+		if item.Text == "" {
+			return status.Errorf(codes.InvalidArgument, "Item %d: Text cannot be empty", i)
+		}
+		if len(item.Text) > MaxLength {
+			return status.Errorf(codes.InvalidArgument, "Item %d: Text exceeds maximum length of %d characters", i, MaxLength)
+		}
+	}
+	return nil
+}
+
+// trimAndValidateCreateTaskCommentRequest validates and trims a CreateTaskCommentRequest.
+// Ensures the task ID is valid, the author does not exceed MaxLength and the body is not empty.
+func trimAndValidateCreateTaskCommentRequest(req *pb.CreateTaskCommentRequest) error {
+	req.Author = strings.TrimSpace(req.Author)
+	req.Body = strings.TrimSpace(req.Body)
+
+	if req.TaskId < MinId {
+		return status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+	if len(req.Author) > MaxLength {
+		return status.Errorf(codes.InvalidArgument, "Author exceeds maximum length of %d characters", MaxLength)
+	}
+	if req.Body == "" {
+		return status.Error(codes.InvalidArgument, "Body cannot be empty")
+	}
+	if len(req.Body) > MaxCommentLength {
+		return status.Errorf(codes.InvalidArgument, "Body exceeds maximum length of %d characters", MaxCommentLength)
+	}
+	return nil
+}
+
+// trimAndValidateCreateTaskAttachmentRequest validates and trims a CreateTaskAttachmentRequest.
+// Ensures the task ID is valid, the attachment has a name and an absolute http or https URL, and its size is not negative.
+func trimAndValidateCreateTaskAttachmentRequest(req *pb.CreateTaskAttachmentRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Url = strings.TrimSpace(req.Url)
+	req.ContentType = strings.TrimSpace(req.ContentType)
+
+	if req.TaskId < MinId {
+		return status.Error(codes.InvalidArgument, "Task ID must be greater than 0")
+	}
+	if req.Name == "" {
+		return status.Error(codes.InvalidArgument, "Name cannot be empty")
+	}
+	if len(req.Name) > MaxLength || len(req.ContentType) > MaxLength {
+		return status.Errorf(codes.InvalidArgument, "Name and content type cannot exceed %d characters", MaxLength)
+	}
+	if len(req.Url) > MaxURLLength {
+		return status.Errorf(codes.InvalidArgument, "URL exceeds maximum length of %d characters", MaxURLLength)
+	}
+	if u, err := url.Parse(req.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return status.Error(codes.InvalidArgument, "URL must be an absolute http or https URL")
+	}
+	if req.Size < 0 {
+		return status.Error(codes.InvalidArgument, "Size cannot be negative")
+	}
+	return nil
+}
+
+// trimAndValidateCloneTaskRequest validates and trims a CloneTaskRequest.
+// Ensures the task reference is valid, the target project ID is not negative and the title does not exceed MaxLength.
+func trimAndValidateCloneTaskRequest(req *pb.CloneTaskRequest) error {
+	req.Title = strings.TrimSpace(req.Title)
+
+	if err := trimAndValidateTaskRef(req.Id, &req.Key, &req.ExternalId); err != nil {
+		return err
+	}
+	if req.TargetProjectId != nil && *req.TargetProjectId < 0 {
+		return status.Error(codes.InvalidArgument, "Target project ID cannot be negative")
+	}
+	if len(req.Title) > MaxLength {
+		return status.Error(codes.InvalidArgument, "Title exceeds maximum length of 255 characters")
+	}
+	return nil
+}
+
+// trimAndValidateTaskRef validates a task reference given as an ID, a key or an external ID.
+// Keys and external IDs are normalized in place; exactly one of the three must be set.
+func trimAndValidateTaskRef(id int64, key, externalID *string) error {
+	*key = strings.ToUpper(strings.TrimSpace(*key))
+	if err := trimAndValidateExternalID(externalID); err != nil {
+		return err
+	}
+
+	set := 0
+	for _, present := range []bool{id != 0, *key != "", *externalID != ""} {
+		if present {
+			set++
+		}
+	}
+	if set > 1 {
+		return status.Error(codes.InvalidArgument, "Specify only one of the task ID, key and external ID")
+	}
+	if *key != "" && !taskKeyPattern.MatchString(*key) {
+		return status.Error(codes.InvalidArgument, "Task key must look like OPS-123")
+	}
+	if set == 0 || id < 0 {
+		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	}
+	return nil
+}
+
+// trimAndValidateExternalID normalizes an optional ULID or UUIDv7 in place.
+func trimAndValidateExternalID(externalID *string) error {
+	*externalID = strings.TrimSpace(*externalID)
+	if *externalID == "" {
+		return nil
+	}
+	normalized, ok := ids.Normalize(*externalID)
+	if !ok {
+		return status.Error(codes.InvalidArgument, "External ID must be a ULID or a UUIDv7")
+	}
+	*externalID = normalized
+	return nil
+}
+
+// validateCustomFieldValues ensures custom field values of a task are keyed by valid field names.
+func validateCustomFieldValues(values map[string]*pb.CustomFieldValue) error {
+	for name := range values {
+		if !fieldNamePattern.MatchString(name) {
+			return status.Errorf(codes.InvalidArgument, "Invalid custom field name %q", name)
+		}
+	}
+	return nil
+}
+
+// trimAndValidateCreateCustomFieldRequest validates and trims a CreateCustomFieldRequest.
+// Ensures the project ID is valid, Name is a valid field name and the options contain no blanks or duplicates.
+func trimAndValidateCreateCustomFieldRequest(req *pb.CreateCustomFieldRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+
+	if err := validateProjectID(req.ProjectId); err != nil {
+		return err
+	}
+	if !fieldNamePattern.MatchString(req.Name) {
+		return status.Error(codes.InvalidArgument, "Name must be up to 63 lower-case letters, digits and underscores starting with a letter")
+	}
+	options, err := trimAndValidateNames(req.Options, "Option")
+	if err != nil {
+		return err
+	}
+	req.Options = options
+	if req.MaxLength < 0 {
+		return status.Error(codes.InvalidArgument, "Maximum length cannot be negative")
+	}
+	return nil
+}
+
+// trimAndValidateUpdateCustomFieldRequest validates and trims an UpdateCustomFieldRequest.
+// Ensures the project and field IDs are valid and applies the same rules as trimAndValidateCreateCustomFieldRequest.
+func trimAndValidateUpdateCustomFieldRequest(req *pb.UpdateCustomFieldRequest) error {
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+
+	if err := validateCustomFieldRef(req.ProjectId, req.Id); err != nil {
+		return err
+	}
+	options, err := trimAndValidateNames(req.Options, "Option")
+	if err != nil {
+		return err
+	}
+	req.Options = options
+	if req.MaxLength < 0 {
+		return status.Error(codes.InvalidArgument, "Maximum length cannot be negative")
+	}
+	return nil
+}
+
+// validateCustomFieldRef ensures the project and field IDs of a custom field request are valid.
+func validateCustomFieldRef(projectID, id int64) error {
+	if projectID < MinId {
+		return status.Error(codes.InvalidArgument, "Project ID must be greater than 0")
+	}
+	if id < MinId {
+		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	}
+	return nil
+}
+
+// trimAndValidateCreateProjectRequest validates and trims a CreateProjectRequest.
+// Ensures Key is well-formed, Name is not empty and does not exceed MaxLength, and the settings lists contain no blanks or duplicates.
+func trimAndValidateCreateProjectRequest(req *pb.CreateProjectRequest) error {
+	req.Key = strings.ToUpper(strings.TrimSpace(req.Key))
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.DefaultAssignee = strings.TrimSpace(req.DefaultAssignee)
+
+	if !projectKeyPattern.MatchString(req.Key) {
+		return status.Error(codes.InvalidArgument, "Key must be 2 to 10 upper-case letters and digits starting with a letter")
+	}
+	if err := validateProjectName(req.Name); err != nil {
+		return err
+	}
+	workflow, err := trimAndValidateNames(req.Workflow, "Workflow status")
+	if err != nil {
+		return err
+	}
+	allowedLabels, err := trimAndValidateNames(req.AllowedLabels, "Allowed label")
+	if err != nil {
+		return err
+	}
+	req.Workflow = workflow
+	req.AllowedLabels = allowedLabels
+	return nil
+}
+
+// trimAndValidateUpdateProjectRequest validates and trims an UpdateProjectRequest.
+// Ensures ID is valid and applies the same rules as trimAndValidateCreateProjectRequest.
+func trimAndValidateUpdateProjectRequest(req *pb.UpdateProjectRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	req.DefaultAssignee = strings.TrimSpace(req.DefaultAssignee)
+
 	if req.Id < MinId {
 		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
 	}
+	if err := validateProjectName(req.Name); err != nil {
+		return err
+	}
+	workflow, err := trimAndValidateNames(req.Workflow, "Workflow status")
+	if err != nil {
+		return err
+	}
+	allowedLabels, err := trimAndValidateNames(req.AllowedLabels, "Allowed label")
+	if err != nil {
+		return err
+	}
+	req.Workflow = workflow
+	req.AllowedLabels = allowedLabels
+	return nil
+}
+
+// validateProjectID ensures a project ID taken from a request is valid.
+func validateProjectID(id int64) error {
+	if id < MinId {
+		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	}
+	return nil
+}
+
+func validateProjectName(name string) error {
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "Name cannot be empty")
+	}
+	if len(name) > MaxLength {
+		return status.Error(codes.InvalidArgument, "Name exceeds maximum length of 255 characters")
+	}
+	return nil
+}
+
+// trimAndValidateNames trims a list of short names such as labels or statuses.
+// Ensures no name is empty, exceeds MaxLength or appears twice.
+func trimAndValidateNames(names []string, kind string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	trimmed := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s cannot be empty", kind)
+		}
+		if len(name) > MaxLength {
+			return nil, status.Errorf(codes.InvalidArgument, "%s exceeds maximum length of 255 characters", kind)
+		}
+		if seen[name] {
+			return nil, status.Errorf(codes.InvalidArgument, "%s %q is listed more than once", kind, name)
+		}
+		seen[name] = true
+		trimmed = append(trimmed, name)
+	}
+	return trimmed, nil
+}
+
+// trimAndValidateCreateSavedViewRequest validates and trims a CreateSavedViewRequest.
+// Ensures the owner and name are set, the filter is not too long and the columns are distinct.
+func trimAndValidateCreateSavedViewRequest(req *pb.CreateSavedViewRequest) error {
+	req.Owner = strings.TrimSpace(req.Owner)
+	req.Name = strings.TrimSpace(req.Name)
+	req.Filter = strings.TrimSpace(req.Filter)
+
+	if err := validateUser(req.Owner, "Owner"); err != nil {
+		return err
+	}
+	columns, err := validateSavedView(req.Name, req.ProjectId, req.Filter, req.Columns)
+	if err != nil {
+		return err
+	}
+	req.Columns = columns
+	return nil
+}
+
+// trimAndValidateUpdateSavedViewRequest validates and trims an UpdateSavedViewRequest.
+// Ensures ID and user are valid and applies the same rules as trimAndValidateCreateSavedViewRequest.
+func trimAndValidateUpdateSavedViewRequest(req *pb.UpdateSavedViewRequest) error {
+	req.User = strings.TrimSpace(req.User)
+	req.Name = strings.TrimSpace(req.Name)
+	req.Filter = strings.TrimSpace(req.Filter)
+
+	if err := validateSavedViewRef(req.Id, req.User); err != nil {
+		return err
+	}
+	columns, err := validateSavedView(req.Name, req.ProjectId, req.Filter, req.Columns)
+	if err != nil {
+		return err
+	}
+	req.Columns = columns
+	return nil
+}
+
+// validateSavedView checks the definition shared by created and updated views and returns the trimmed columns.
+func validateSavedView(name string, projectID int64, filter string, columns []string) ([]string, error) {
+	if err := validateProjectName(name); err != nil {
+		return nil, err
+	}
+	if projectID < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Project ID cannot be negative")
+	}
+	if len(filter) > MaxFilterLength {
+		return nil, status.Errorf(codes.InvalidArgument, "Filter exceeds maximum length of %d characters", MaxFilterLength)
+	}
+	return trimAndValidateNames(columns, "Column")
+}
+
+// validateSavedViewRef checks the ID of a saved view and the user acting on it.
+func validateSavedViewRef(id int64, user string) error {
+	if id < MinId {
+		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	}
+	return validateUser(user, "User")
+}
+
+func validateUser(user, kind string) error {
+	if user == "" {
+		return status.Errorf(codes.InvalidArgument, "%s cannot be empty", kind)
+	}
+	if len(user) > MaxLength {
+		return status.Errorf(codes.InvalidArgument, "%s exceeds maximum length of 255 characters", kind)
+	}
+	return nil
+}
+
+// trimAndValidateCreateTaskTemplateRequest validates and trims a CreateTaskTemplateRequest.
+// Ensures the project ID is valid and applies the rules of validateTaskTemplate.
+func trimAndValidateCreateTaskTemplateRequest(req *pb.CreateTaskTemplateRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+
+	if err := validateProjectID(req.ProjectId); err != nil {
+		return err
+	}
+	variables, err := validateTaskTemplate(req.Name, req.Variables, req.Tasks)
+	if err != nil {
+		return err
+	}
+	req.Variables = variables
+	return nil
+}
+
+// trimAndValidateUpdateTaskTemplateRequest validates and trims an UpdateTaskTemplateRequest.
+// Ensures ID is valid and applies the rules of validateTaskTemplate.
+func trimAndValidateUpdateTaskTemplateRequest(req *pb.UpdateTaskTemplateRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+
+	if err := validateTaskTemplateRef(req.Id, 0); err != nil {
+		return err
+	}
+	variables, err := validateTaskTemplate(req.Name, req.Variables, req.Tasks)
+	if err != nil {
+		return err
+	}
+	req.Variables = variables
+	return nil
+}
+
+// validateTaskTemplate checks the definition shared by created and updated templates and returns the
+// trimmed variables. Blueprints are trimmed in place and must pass the checks of CreateTask.
+func validateTaskTemplate(name string, variables []string, blueprints []*pb.TaskBlueprint) ([]string, error) {
+	if err := validateProjectName(name); err != nil {
+		return nil, err
+	}
+	variables, err := trimAndValidateNames(variables, "Variable")
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range variables {
+		if !fieldNamePattern.MatchString(variable) {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid variable name %q", variable)
+		}
+	}
+	if len(blueprints) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Template must have at least one task")
+	}
+	count := 0
+	if err := trimAndValidateBlueprints(blueprints, "", &count); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// trimAndValidateBlueprints trims the blueprints of a template tree in place, counting them in count, and
+// reports the first invalid one by its path, such as 1.0 for the first subtask of the second task.
+func trimAndValidateBlueprints(blueprints []*pb.TaskBlueprint, parent string, count *int) error {
+	for i, blueprint := range blueprints {
+		path := strconv.Itoa(i)
+		if parent != "" {
+			path = parent + "." + path
+		}
+		if *count++; *count > MaxTemplateTasks {
+			return status.Errorf(codes.InvalidArgument, "Template exceeds maximum of %d tasks", MaxTemplateTasks)
+		}
+
+		req := &pb.CreateTaskRequest{
+			Title:        blueprint.Title,
+			Description:  blueprint.Description,
+			Status:       blueprint.Status,
+			Assignee:     blueprint.Assignee,
+			Labels:       blueprint.Labels,
+			CustomFields: blueprint.CustomFields,
+		}
+		if err := trimAndValidateCreateTaskRequest(req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Task %s: %s", path, status.Convert(err).Message())
+		}
+		blueprint.Title, blueprint.Description, blueprint.Status = req.Title, req.Description, req.Status
+		blueprint.Assignee, blueprint.Labels = req.Assignee, req.Labels
+
+		blueprint.Ref = strings.TrimSpace(blueprint.Ref)
+		if len(blueprint.Ref) > MaxLength {
+			return status.Errorf(codes.InvalidArgument, "Task %s: Ref exceeds maximum length of 255 characters", path)
+		}
+		for _, relation := range blueprint.Relations {
+			relation.Type = strings.TrimSpace(relation.Type)
+			relation.Ref = strings.TrimSpace(relation.Ref)
+			if relation.Type == "" || relation.Ref == "" {
+				return status.Errorf(codes.InvalidArgument, "Task %s: Relations need a type and a ref", path)
+			}
+		}
+		if err := trimAndValidateBlueprints(blueprint.Subtasks, path, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTaskTemplateRef checks the ID of a task template and a version of it, where zero means the current version.
+func validateTaskTemplateRef(id int64, version int32) error {
+	if id < MinId {
+		return status.Error(codes.InvalidArgument, "ID must be greater than 0")
+	}
+	if version < 0 {
+		return status.Error(codes.InvalidArgument, "Version cannot be negative")
+	}
+	return nil
+}
+
+// trimAndValidateInstantiateTemplateRequest validates and trims an InstantiateTemplateRequest.
+// Ensures the template reference is valid and every variable value is set and not too long.
+func trimAndValidateInstantiateTemplateRequest(req *pb.InstantiateTemplateRequest) error {
+	if err := validateTaskTemplateRef(req.Id, req.Version); err != nil {
+		return err
+	}
+	for name, value := range req.Variables {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return status.Errorf(codes.InvalidArgument, "Variable %s cannot be empty", name)
+		}
+		if len(value) > MaxLength {
+			return status.Errorf(codes.InvalidArgument, "Variable %s exceeds maximum length of 255 characters", name)
+		}
+		req.Variables[name] = value
+	}
 	return nil
 }
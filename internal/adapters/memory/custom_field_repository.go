@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+)
+
+// CustomFieldRepository keeps custom field definitions in memory like the Postgres custom field
+// repository. The values of the fields are held by the tasks.
+type CustomFieldRepository struct {
+	tasks *TaskRepository
+
+	mu     sync.RWMutex
+	nextID int64
+	fields map[int64]*models.CustomField
+}
+
+func newCustomFieldRepository(tasks *TaskRepository) *CustomFieldRepository {
+	return &CustomFieldRepository{tasks: tasks, fields: make(map[int64]*models.CustomField)}
+}
+
+func (r *CustomFieldRepository) CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.fields {
+		if stored.ProjectID == field.ProjectID && stored.Name == field.Name {
+			return nil, repository.ErrAlreadyExists
+		}
+	}
+
+	now := time.Now()
+	r.nextID++
+	field.ID = r.nextID
+	field.CreatedAt = now
+	field.UpdatedAt = now
+	r.fields[field.ID] = cloneCustomField(field)
+	return field, nil
+}
+
+func (r *CustomFieldRepository) ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var fields []*models.CustomField
+	for _, field := range r.fields {
+		if field.ProjectID == projectID {
+			fields = append(fields, cloneCustomField(field))
+		}
+	}
+	slices.SortFunc(fields, func(a, b *models.CustomField) int { return strings.Compare(a.Name, b.Name) })
+	return fields, nil
+}
+
+func (r *CustomFieldRepository) GetCustomField(ctx context.Context, id int64) (*models.CustomField, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	field, ok := r.fields[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneCustomField(field), nil
+}
+
+func (r *CustomFieldRepository) UpdateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.fields[field.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	field.ProjectID = stored.ProjectID
+	field.Name = stored.Name
+	field.CreatedAt = stored.CreatedAt
+	field.UpdatedAt = time.Now()
+	r.fields[field.ID] = cloneCustomField(field)
+	return field, nil
+}
+
+// DeleteCustomField removes the definition and the values of the field as one unit of work of the tasks,
+// whose lock is taken before that of the definitions, like units of work of the task service do.
+func (r *CustomFieldRepository) DeleteCustomField(ctx context.Context, id int64) error {
+	return r.tasks.InTx(ctx, func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		field, ok := r.fields[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		delete(r.fields, id)
+		r.tasks.removeFieldValues(ctx, field.ProjectID, field.Name)
+		return nil
+	})
+}
+
+func (r *CustomFieldRepository) ListFieldValues(ctx context.Context, projectID int64, name string) ([]models.FieldValueRef, error) {
+	return r.tasks.fieldValues(ctx, projectID, name), nil
+}
+
+// deleteProject deletes the fields of a deleted project, which has no tasks left to hold values.
+func (r *CustomFieldRepository) deleteProject(projectID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, field := range r.fields {
+		if field.ProjectID == projectID {
+			delete(r.fields, id)
+		}
+	}
+}
+
+func cloneCustomField(field *models.CustomField) *models.CustomField {
+	clone := *field
+	clone.Options = slices.Clone(field.Options)
+	if field.Min != nil {
+		minValue := *field.Min
+		clone.Min = &minValue
+	}
+	if field.Max != nil {
+		maxValue := *field.Max
+		clone.Max = &maxValue
+	}
+	return &clone
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCustomFieldRepository(t *testing.T) {
+	store := NewStore(zap.NewNop())
+	repo := store.CustomFields
+	maxValue := 10.0
+
+	field, err := repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: 1, Name: "estimate", Type: models.FieldTypeNumber, Max: &maxValue})
+	require.NoError(t, err)
+	_, err = repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: 1, Name: "estimate", Type: models.FieldTypeString})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	_, err = repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: 2, Name: "estimate", Type: models.FieldTypeString})
+	assert.NoError(t, err, "names are unique per project")
+	_, err = repo.CreateCustomField(context.Background(), &models.CustomField{ProjectID: 1, Name: "area", Type: models.FieldTypeEnum, Options: []string{"api"}})
+	require.NoError(t, err)
+
+	maxValue = 20
+	got, err := repo.GetCustomField(context.Background(), field.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, *got.Max, "the repository keeps its own copy")
+
+	updated, err := repo.UpdateCustomField(context.Background(), &models.CustomField{ID: field.ID, ProjectID: 5, Name: "renamed", Type: models.FieldTypeNumber, Required: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated.ProjectID)
+	assert.Equal(t, "estimate", updated.Name)
+	assert.True(t, updated.Required)
+	_, err = repo.UpdateCustomField(context.Background(), &models.CustomField{ID: 99})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	fields, err := repo.ListCustomFields(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "area", fields[0].Name)
+	assert.Equal(t, "estimate", fields[1].Name)
+}
+
+func TestCustomFieldRepository_Values(t *testing.T) {
+	store := NewStore(zap.NewNop())
+	ctx := context.Background()
+	project, err := store.Projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+	field, err := store.CustomFields.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeNumber})
+	require.NoError(t, err)
+	estimated, err := store.Tasks.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Status: "open",
+		CustomFields: map[string]models.FieldValue{"estimate": {Type: models.FieldTypeNumber, Number: 3}}})
+	require.NoError(t, err)
+	unestimated, err := store.Tasks.CreateTask(ctx, &models.Task{ExternalID: "b", ProjectID: project.ID, Status: "open"})
+	require.NoError(t, err)
+
+	values, err := store.CustomFields.ListFieldValues(context.Background(), project.ID, "estimate")
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	assert.Equal(t, estimated.ID, values[0].TaskID)
+	assert.Equal(t, "OPS-1", values[0].TaskKey)
+	require.NotNil(t, values[0].Value)
+	assert.Equal(t, 3.0, values[0].Value.Number)
+	assert.Equal(t, unestimated.ID, values[1].TaskID)
+	assert.Nil(t, values[1].Value)
+
+	require.NoError(t, store.CustomFields.DeleteCustomField(context.Background(), field.ID))
+	assert.ErrorIs(t, store.CustomFields.DeleteCustomField(context.Background(), field.ID), sql.ErrNoRows)
+	got, err := store.Tasks.GetTask(ctx, estimated.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.CustomFields, "deleting a field removes its values")
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// IdempotencyRepository keeps idempotency records in memory like the Postgres idempotency repository.
+// It serves the requests of one server only, which is all the memory driver supports.
+type IdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func newIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (r *IdempotencyRepository) ClaimKey(ctx context.Context, record *models.IdempotencyRecord,
+	staleBefore time.Time) (*models.IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.records[record.Key]
+	if ok && existing.ExpiresAt.After(record.CreatedAt) &&
+		(existing.Completed() || staleBefore.IsZero() || !existing.CreatedAt.Before(staleBefore)) {
+		return cloneIdempotencyRecord(existing), nil
+	}
+
+	r.records[record.Key] = cloneIdempotencyRecord(record)
+	return nil, nil
+}
+
+// CompleteKey and ReleaseKey recognize the claim by its creation time and request, which a later claim of
+// the same key does not share.
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, claim *models.IdempotencyRecord, responseType string,
+	response []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.records[claim.Key]
+	if !ok || !holdsKey(existing, claim) {
+		return sql.ErrNoRows
+	}
+	completed := cloneIdempotencyRecord(existing)
+	completed.ResponseType = responseType
+	completed.Response = slices.Clone(response)
+	r.records[claim.Key] = completed
+	return nil
+}
+
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, claim *models.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[claim.Key]; ok && holdsKey(existing, claim) {
+		delete(r.records, claim.Key)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			delete(r.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// holdsKey reports whether record is the pending claim.
+func holdsKey(record, claim *models.IdempotencyRecord) bool {
+	return !record.Completed() && record.CreatedAt.Equal(claim.CreatedAt) && bytes.Equal(record.RequestHash, claim.RequestHash)
+}
+
+func cloneIdempotencyRecord(record *models.IdempotencyRecord) *models.IdempotencyRecord {
+	clone := *record
+	clone.RequestHash = slices.Clone(record.RequestHash)
+	clone.Response = slices.Clone(record.Response)
+	return &clone
+}
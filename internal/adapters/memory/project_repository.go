@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+)
+
+// ProjectRepository keeps projects in memory like the Postgres project repository. Deleting a project
+// deletes its custom fields, saved views and task templates with it, and fails while it still has tasks.
+type ProjectRepository struct {
+	tasks *TaskRepository
+	// dependents delete what belongs to a deleted project.
+	dependents []func(projectID int64)
+
+	mu       sync.RWMutex
+	nextID   int64
+	projects map[int64]*models.Project
+}
+
+func newProjectRepository() *ProjectRepository {
+	return &ProjectRepository{projects: make(map[int64]*models.Project)}
+}
+
+func (r *ProjectRepository) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.projects {
+		if stored.Key == project.Key {
+			return nil, repository.ErrAlreadyExists
+		}
+	}
+
+	now := time.Now()
+	r.nextID++
+	project.ID = r.nextID
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	r.projects[project.ID] = cloneProject(project)
+	return project, nil
+}
+
+func (r *ProjectRepository) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*models.Project
+	for _, project := range r.projects {
+		projects = append(projects, cloneProject(project))
+	}
+	slices.SortFunc(projects, func(a, b *models.Project) int { return cmp.Compare(a.ID, b.ID) })
+	return projects, nil
+}
+
+func (r *ProjectRepository) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneProject(project), nil
+}
+
+func (r *ProjectRepository) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.projects[project.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	project.Key = stored.Key
+	project.CreatedAt = stored.CreatedAt
+	project.UpdatedAt = time.Now()
+	r.projects[project.ID] = cloneProject(project)
+	return project, nil
+}
+
+// DeleteProject checks for tasks while holding off task writes, like the foreign key of the tasks table.
+func (r *ProjectRepository) DeleteProject(ctx context.Context, id int64) error {
+	defer r.tasks.rlock(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.projects[id]; !ok {
+		return sql.ErrNoRows
+	}
+	if r.tasks.hasProjectTasks(id) {
+		return repository.ErrNotEmpty
+	}
+
+	delete(r.projects, id)
+	for _, deleteDependents := range r.dependents {
+		deleteDependents(id)
+	}
+	return nil
+}
+
+func cloneProject(project *models.Project) *models.Project {
+	clone := *project
+	clone.Workflow = slices.Clone(project.Workflow)
+	clone.AllowedLabels = slices.Clone(project.AllowedLabels)
+	return &clone
+}
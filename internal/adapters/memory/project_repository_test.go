@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProjectRepository(t *testing.T) {
+	store := NewStore(zap.NewNop())
+	repo := store.Projects
+
+	project, err := repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Operations", Workflow: []string{"todo", "done"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), project.ID)
+	assert.False(t, project.CreatedAt.IsZero())
+
+	_, err = repo.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Other"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	project.Workflow[0] = "changed"
+	got, err := repo.GetProject(context.Background(), project.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"todo", "done"}, got.Workflow, "the repository keeps its own copy")
+
+	updated, err := repo.UpdateProject(context.Background(), &models.Project{ID: project.ID, Key: "NEW", Name: "Ops"})
+	require.NoError(t, err)
+	assert.Equal(t, "OPS", updated.Key, "keys cannot change")
+	assert.Equal(t, project.CreatedAt, updated.CreatedAt)
+
+	_, err = repo.UpdateProject(context.Background(), &models.Project{ID: 9})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.GetProject(context.Background(), 9)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	other, err := repo.CreateProject(context.Background(), &models.Project{Key: "WEB", Name: "Web"})
+	require.NoError(t, err)
+	projects, err := repo.ListProjects(context.Background())
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	assert.Equal(t, []int64{project.ID, other.ID}, []int64{projects[0].ID, projects[1].ID})
+}
+
+func TestProjectRepository_DeleteProject(t *testing.T) {
+	store := NewStore(zap.NewNop())
+	ctx := context.Background()
+	project, err := store.Projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+	_, err = store.CustomFields.CreateCustomField(context.Background(), &models.CustomField{ProjectID: project.ID, Name: "estimate", Type: models.FieldTypeNumber})
+	require.NoError(t, err)
+	_, err = store.SavedViews.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "Mine", ProjectID: project.ID})
+	require.NoError(t, err)
+	_, err = store.SavedViews.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "Everything"})
+	require.NoError(t, err)
+	_, err = store.TaskTemplates.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: project.ID, Name: "Onboarding"})
+	require.NoError(t, err)
+
+	task, err := store.Tasks.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Title: "Task", Status: "open"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.Projects.DeleteProject(context.Background(), project.ID), repository.ErrNotEmpty)
+
+	require.NoError(t, store.Tasks.DeleteTask(ctx, task.ID))
+	require.NoError(t, store.Projects.DeleteProject(context.Background(), project.ID))
+	assert.ErrorIs(t, store.Projects.DeleteProject(context.Background(), project.ID), sql.ErrNoRows)
+
+	fields, err := store.CustomFields.ListCustomFields(context.Background(), project.ID)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+	views, err := store.SavedViews.ListSavedViews(context.Background(), "alice", 0)
+	require.NoError(t, err)
+	require.Len(t, views, 1, "views of other projects stay")
+	assert.Equal(t, "Everything", views[0].Name)
+	templates, err := store.TaskTemplates.ListTaskTemplates(context.Background(), project.ID)
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+}
+
+func TestProjectRepository_DeleteProject_ArchivedTasks(t *testing.T) {
+	store := NewStore(zap.NewNop())
+	ctx := context.Background()
+	project, err := store.Projects.CreateProject(ctx, &models.Project{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+	_, err = store.Tasks.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Title: "Task", Status: "done"})
+	require.NoError(t, err)
+
+	ids, err := store.Tasks.ArchiveTasks(ctx, models.Column{ProjectID: project.ID, Status: "done"}, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.ErrorIs(t, store.Projects.DeleteProject(ctx, project.ID), repository.ErrNotEmpty)
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+)
+
+// SavedViewRepository keeps saved views in memory like the Postgres saved view repository.
+type SavedViewRepository struct {
+	mu     sync.RWMutex
+	nextID int64
+	views  map[int64]*models.SavedView
+}
+
+func newSavedViewRepository() *SavedViewRepository {
+	return &SavedViewRepository{views: make(map[int64]*models.SavedView)}
+}
+
+func (r *SavedViewRepository) CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(view.Owner, view.Name, 0) {
+		return nil, repository.ErrAlreadyExists
+	}
+
+	now := time.Now()
+	r.nextID++
+	view.ID = r.nextID
+	view.CreatedAt = now
+	view.UpdatedAt = now
+	r.views[view.ID] = cloneSavedView(view)
+	return view, nil
+}
+
+func (r *SavedViewRepository) GetSavedView(ctx context.Context, id int64) (*models.SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	view, ok := r.views[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneSavedView(view), nil
+}
+
+func (r *SavedViewRepository) ListSavedViews(ctx context.Context, owner string, projectID int64) ([]*models.SavedView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var views []*models.SavedView
+	for _, view := range r.views {
+		if (view.Owner == owner || view.Shared) && (projectID == 0 || view.ProjectID == projectID) {
+			views = append(views, cloneSavedView(view))
+		}
+	}
+	slices.SortFunc(views, func(a, b *models.SavedView) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return views, nil
+}
+
+func (r *SavedViewRepository) UpdateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.views[view.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if r.nameTaken(stored.Owner, view.Name, view.ID) {
+		return nil, repository.ErrAlreadyExists
+	}
+	view.Owner = stored.Owner
+	view.CreatedAt = stored.CreatedAt
+	view.UpdatedAt = time.Now()
+	r.views[view.ID] = cloneSavedView(view)
+	return view, nil
+}
+
+func (r *SavedViewRepository) DeleteSavedView(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.views[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.views, id)
+	return nil
+}
+
+// nameTaken reports whether a view other than the one with ID except has the name among the views
+// of owner. The caller holds the lock.
+func (r *SavedViewRepository) nameTaken(owner, name string, except int64) bool {
+	for id, view := range r.views {
+		if id != except && view.Owner == owner && view.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteProject deletes the views of a deleted project.
+func (r *SavedViewRepository) deleteProject(projectID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, view := range r.views {
+		if view.ProjectID == projectID {
+			delete(r.views, id)
+		}
+	}
+}
+
+// cloneSavedView copies a view without its problems, which are not stored.
+func cloneSavedView(view *models.SavedView) *models.SavedView {
+	clone := *view
+	clone.Columns = slices.Clone(view.Columns)
+	clone.Problems = nil
+	if view.OrderBy != nil {
+		orderBy := models.FieldOrder{Field: view.OrderBy.Field, Descending: view.OrderBy.Descending}
+		clone.OrderBy = &orderBy
+	}
+	return &clone
+}
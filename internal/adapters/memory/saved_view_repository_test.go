@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedViewRepository(t *testing.T) {
+	repo := newSavedViewRepository()
+
+	mine, err := repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "Mine", ProjectID: 1, Columns: []string{"key"},
+		OrderBy: &models.FieldOrder{Field: "estimate", Type: models.FieldTypeNumber, Descending: true}})
+	require.NoError(t, err)
+	_, err = repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "Mine"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	shared, err := repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "bob", Name: "Mine", ProjectID: 2, Shared: true})
+	require.NoError(t, err, "names are unique per owner")
+	_, err = repo.CreateSavedView(context.Background(), &models.SavedView{Owner: "bob", Name: "Private"})
+	require.NoError(t, err)
+
+	got, err := repo.GetSavedView(context.Background(), mine.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &models.FieldOrder{Field: "estimate", Descending: true}, got.OrderBy, "like Postgres, the type of the order is not stored")
+
+	views, err := repo.ListSavedViews(context.Background(), "alice", 0)
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.Equal(t, []int64{mine.ID, shared.ID}, []int64{views[0].ID, views[1].ID})
+	views, err = repo.ListSavedViews(context.Background(), "alice", 2)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, shared.ID, views[0].ID)
+
+	_, err = repo.UpdateSavedView(context.Background(), &models.SavedView{ID: mine.ID, Owner: "bob", Name: "Private"})
+	require.NoError(t, err, "the owner cannot change")
+	_, err = repo.UpdateSavedView(context.Background(), &models.SavedView{ID: shared.ID, Name: "Private"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	_, err = repo.UpdateSavedView(context.Background(), &models.SavedView{ID: 99})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, repo.DeleteSavedView(context.Background(), mine.ID))
+	assert.ErrorIs(t, repo.DeleteSavedView(context.Background(), mine.ID), sql.ErrNoRows)
+}
@@ -0,0 +1,38 @@
+package memory
+
+import "go.uber.org/zap"
+
+// Store holds all data of the server in process memory, for tests and local development, so that the
+// server runs without any database. Its repositories behave like the Postgres ones, down to the
+// custom fields, saved views and task templates that go away with their project, and everything is
+// lost when the process exits.
+type Store struct {
+	Projects      *ProjectRepository
+	CustomFields  *CustomFieldRepository
+	SavedViews    *SavedViewRepository
+	TaskTemplates *TaskTemplateRepository
+	Tasks         *TaskRepository
+	Searcher      *TaskSearcher
+	Idempotency   *IdempotencyRepository
+}
+
+func NewStore(logger *zap.Logger) *Store {
+	projects := newProjectRepository()
+	tasks := NewTaskRepository(projects, logger)
+	store := &Store{
+		Projects:      projects,
+		CustomFields:  newCustomFieldRepository(tasks),
+		SavedViews:    newSavedViewRepository(),
+		TaskTemplates: newTaskTemplateRepository(),
+		Tasks:         tasks,
+		Searcher:      newTaskSearcher(tasks),
+		Idempotency:   newIdempotencyRepository(),
+	}
+	projects.tasks = tasks
+	projects.dependents = []func(projectID int64){
+		store.CustomFields.deleteProject,
+		store.SavedViews.deleteProject,
+		store.TaskTemplates.deleteProject,
+	}
+	return store
+}
@@ -0,0 +1,328 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// truth is the result of an SQL condition, which is unknown when it compares a missing value.
+type truth int
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func truthOf(ok bool) truth {
+	if ok {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+// fieldOperators are the comparison operators conditions may use besides ":".
+var fieldOperators = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// matchesFilter reports whether a task passes all parts of filter, evaluating them like the WHERE clause
+// built by the Postgres repository.
+func matchesFilter(task *models.Task, filter models.TaskFilter) (bool, error) {
+	if filter.ProjectID != 0 && task.ProjectID != filter.ProjectID {
+		return false, nil
+	}
+	if filter.Status != "" && task.Status != filter.Status {
+		return false, nil
+	}
+	for _, condition := range filter.CustomFields {
+		result, err := customFieldCondition(task, condition)
+		if err != nil || result != truthTrue {
+			return false, err
+		}
+	}
+	if filter.Where != nil {
+		result, err := filterCondition(task, filter.Where)
+		if err != nil || result != truthTrue {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// filterCondition evaluates a checked filter expression with the three-valued logic of SQL. Like the
+// Postgres repository, NOT treats unknown as false.
+func filterCondition(task *models.Task, expr *models.FilterExpr) (truth, error) {
+	switch expr.Logic {
+	case models.FilterAnd, models.FilterOr:
+		result := truthOf(expr.Logic == models.FilterAnd)
+		for _, operand := range expr.Operands {
+			part, err := filterCondition(task, operand)
+			if err != nil {
+				return truthFalse, err
+			}
+			switch {
+			case expr.Logic == models.FilterAnd && part == truthFalse, expr.Logic == models.FilterOr && part == truthTrue:
+				result = part
+			case part == truthUnknown && result != truthOf(expr.Logic == models.FilterOr):
+				result = truthUnknown
+			}
+		}
+		return result, nil
+	case models.FilterNot:
+		if len(expr.Operands) != 1 {
+			return truthFalse, fmt.Errorf("NOT takes one operand, got %d", len(expr.Operands))
+		}
+		operand, err := filterCondition(task, expr.Operands[0])
+		if err != nil {
+			return truthFalse, err
+		}
+		return truthOf(operand != truthTrue), nil
+	case "":
+	default:
+		return truthFalse, fmt.Errorf("unsupported filter logic %q", expr.Logic)
+	}
+
+	if expr.Custom {
+		return customFieldFilterCondition(task, expr)
+	}
+
+	value, present, err := taskField(task, expr.Field)
+	if err != nil {
+		return truthFalse, err
+	}
+	switch {
+	case expr.Operator == ":" && expr.Value == nil:
+		switch expr.Type {
+		case models.FilterTypeList:
+			return truthOf(len(task.Labels) > 0), nil
+		case models.FilterTypeString:
+			return truthOf(present && value != ""), nil
+		default:
+			return truthOf(present), nil
+		}
+	case expr.Operator == ":" && expr.Type == models.FilterTypeList:
+		label, ok := expr.Value.(string)
+		if !ok {
+			return truthFalse, fmt.Errorf("\":\" on %s needs a text value", expr.Field)
+		}
+		return truthOf(slices.Contains(task.Labels, label)), nil
+	case expr.Operator == ":":
+		text, ok := expr.Value.(string)
+		if !ok {
+			return truthFalse, fmt.Errorf("\":\" on %s needs a text value", expr.Field)
+		}
+		if !present {
+			return truthUnknown, nil
+		}
+		return truthOf(containsFold(value.(string), text)), nil
+	case expr.Operator == "!=":
+		// Tasks without a key or project differ from any given one.
+		if !present {
+			return truthTrue, nil
+		}
+		c, err := compareValues(value, expr.Value)
+		return truthOf(c != 0), err
+	case fieldOperators[expr.Operator]:
+		if !present {
+			return truthUnknown, nil
+		}
+		c, err := compareValues(value, expr.Value)
+		return truthOf(compareMatches(expr.Operator, c)), err
+	}
+	return truthFalse, fmt.Errorf("unsupported filter operator %q", expr.Operator)
+}
+
+// customFieldFilterCondition evaluates a restriction on a custom field like customFieldCondition,
+// so a task without a value for the field never satisfies a comparison.
+func customFieldFilterCondition(task *models.Task, expr *models.FilterExpr) (truth, error) {
+	stored, ok := task.CustomFields[expr.Field]
+	if expr.Operator == ":" && expr.Value == nil {
+		return truthOf(ok), nil
+	}
+
+	value, isValue := expr.Value.(models.FieldValue)
+	if !isValue {
+		return truthFalse, fmt.Errorf("custom field %s needs a field value", expr.Field)
+	}
+	if expr.Operator == ":" {
+		if !ok {
+			return truthUnknown, nil
+		}
+		return truthOf(containsFold(fieldText(stored), value.String)), nil
+	}
+	return customFieldCondition(task, models.FieldCondition{Field: expr.Field, Type: expr.Type, Operator: expr.Operator, Value: value})
+}
+
+// customFieldCondition evaluates one custom field condition. Equality holds only for a stored value of
+// the same kind, like JSONB containment; other comparisons are unknown for tasks without a comparable value.
+func customFieldCondition(task *models.Task, condition models.FieldCondition) (truth, error) {
+	if !fieldOperators[condition.Operator] {
+		return truthFalse, fmt.Errorf("unsupported custom field operator %q", condition.Operator)
+	}
+	stored, ok := task.CustomFields[condition.Field]
+	if condition.Operator == "=" {
+		if !ok {
+			return truthFalse, nil
+		}
+		if condition.Type == models.FieldTypeNumber {
+			return truthOf(stored.Type == models.FieldTypeNumber && stored.Number == condition.Value.Number), nil
+		}
+		return truthOf(stored.Type != models.FieldTypeNumber && stored.String == condition.Value.String), nil
+	}
+
+	value, present := customFieldValue(task, condition.Field, condition.Type)
+	if !present {
+		return truthUnknown, nil
+	}
+	c, err := compareValues(value, condition.Value.Raw())
+	return truthOf(compareMatches(condition.Operator, c)), err
+}
+
+// customFieldValue returns the value a custom field is compared and sorted by: numbers for number
+// fields, ignoring values of other kinds, and the text of the value for all other fields.
+func customFieldValue(task *models.Task, field, fieldType string) (any, bool) {
+	stored, ok := task.CustomFields[field]
+	if !ok {
+		return nil, false
+	}
+	if fieldType == models.FieldTypeNumber {
+		return stored.Number, stored.Type == models.FieldTypeNumber
+	}
+	return fieldText(stored), true
+}
+
+// fieldText returns a custom field value as text, formatting numbers like JSON does.
+func fieldText(value models.FieldValue) string {
+	if value.Type == models.FieldTypeNumber {
+		return strconv.FormatFloat(value.Number, 'f', -1, 64)
+	}
+	return value.String
+}
+
+// taskField returns the value of the task field a filter refers to, and whether it is set. Only the
+// key, the project and the parent can be missing.
+func taskField(task *models.Task, field string) (any, bool, error) {
+	switch field {
+	case "id":
+		return task.ID, true, nil
+	case "external_id":
+		return task.ExternalID, true, nil
+	case "project_id":
+		return task.ProjectID, task.ProjectID != 0, nil
+	case "parent_id":
+		return task.ParentID, task.ParentID != 0, nil
+	case "key":
+		return task.Key, task.Key != "", nil
+	case "title":
+		return task.Title, true, nil
+	case "description":
+		return task.Description, true, nil
+	case "status":
+		return task.Status, true, nil
+	case "assignee":
+		return task.Assignee, true, nil
+	case "labels":
+		return task.Labels, true, nil
+	case "created_at":
+		return task.CreatedAt, true, nil
+	case "updated_at":
+		return task.UpdatedAt, true, nil
+	}
+	return nil, false, fmt.Errorf("unsupported filter field %q", field)
+}
+
+// compareValues compares a task value with a filter value of the same kind.
+func compareValues(a, b any) (int, error) {
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return cmp.Compare(a, b), nil
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b), nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return compareStrings(a, b), nil
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
+
+func compareMatches(operator string, c int) bool {
+	switch operator {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compareStrings compares byte-wise, like the "C" collation of the rank and external ID columns.
+func compareStrings(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// containsFold reports whether text contains part, ignoring case like ILIKE.
+func containsFold(text, part string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(part))
+}
+
+// position is the place of a task in the ListTasks order: its OrderBy value, if any, then its rank and external ID.
+type position struct {
+	value      any
+	rank       string
+	externalID string
+}
+
+func sortPosition(task *models.Task, order *models.FieldOrder) position {
+	p := position{rank: task.Rank, externalID: task.ExternalID}
+	if order != nil {
+		if value, ok := customFieldValue(task, order.Field, order.Type); ok {
+			p.value = value
+		}
+	}
+	return p
+}
+
+// compareTaskOrder compares two positions in the ListTasks order. Tasks lacking the OrderBy field come
+// last in either direction.
+func compareTaskOrder(order *models.FieldOrder, a, b position) int {
+	if order != nil {
+		switch {
+		case a.value == nil && b.value != nil:
+			return 1
+		case a.value != nil && b.value == nil:
+			return -1
+		case a.value != nil:
+			if c, _ := compareValues(a.value, b.value); c != 0 {
+				if order.Descending {
+					return -c
+				}
+				return c
+			}
+		}
+	}
+	if c := compareStrings(a.rank, b.rank); c != 0 {
+		return c
+	}
+	return compareStrings(a.externalID, b.externalID)
+}
@@ -0,0 +1,1054 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// TaskRepository keeps tasks in memory with the semantics of the Postgres task repository: IDs count up
+// from 1, tasks of a project get consecutive keys, timestamps are set on writes, and missing tasks are
+// reported as sql.ErrNoRows. Projects are looked up in projects for their key prefixes. It is safe for
+// concurrent use, every method applies all of its changes or none, and it runs units of work itself.
+type TaskRepository struct {
+	projects repository.ProjectRepository
+	logger   *zap.Logger
+
+	mu     sync.RWMutex
+	nextID int64
+	tasks  map[int64]*models.Task
+	// counters holds the last task number handed out per project.
+	counters map[int64]int64
+	// aliases maps the keys transferred tasks had in their former projects to the tasks.
+	aliases   map[string]int64
+	imports   map[importedRecord]int64
+	relations map[models.TaskRelation]bool
+	// checklists holds the checklists of the tasks that have one, and comments and attachments hold theirs
+	// by ID.
+	checklists       map[int64][]models.ChecklistItem
+	comments         map[int64]*models.TaskComment
+	attachments      map[int64]*models.TaskAttachment
+	nextCommentID    int64
+	nextAttachmentID int64
+	// archive holds the archived tasks by ID, and archivedAliases and archivedImports their key aliases
+	// and import records.
+	archive         map[int64]*models.Task
+	archivedAliases map[string]int64
+	archivedImports map[importedRecord]int64
+
+	// journaling is set while a unit of work runs; its changes are then recorded in undo, each as the
+	// function reverting it, so that a failing unit of work can be rolled back.
+	journaling bool
+	undo       []func()
+}
+
+type unitKey struct{}
+
+// importedRecord identifies a record of an import source.
+type importedRecord struct {
+	source   string
+	sourceID string
+}
+
+func NewTaskRepository(projects repository.ProjectRepository, logger *zap.Logger) *TaskRepository {
+	return &TaskRepository{
+		projects:        projects,
+		logger:          logger,
+		tasks:           make(map[int64]*models.Task),
+		counters:        make(map[int64]int64),
+		aliases:         make(map[string]int64),
+		imports:         make(map[importedRecord]int64),
+		relations:       make(map[models.TaskRelation]bool),
+		checklists:      make(map[int64][]models.ChecklistItem),
+		comments:        make(map[int64]*models.TaskComment),
+		attachments:     make(map[int64]*models.TaskAttachment),
+		archive:         make(map[int64]*models.Task),
+		archivedAliases: make(map[string]int64),
+		archivedImports: make(map[importedRecord]int64),
+	}
+}
+
+// InTx runs fn as a unit of work, reverting the changes it made if it fails. Units of work run one at a
+// time and hold off all other calls while they run, so fn must call the repository with the context it is
+// given. Nested units of work are undone on their own, like savepoints.
+func (r *TaskRepository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.inUnit(ctx) {
+		return r.undoOnError(ctx, fn)
+	}
+
+	unitCtx, unit := consistency.WithUnitOfWork(context.WithValue(ctx, unitKey{}, r))
+	err := func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.journaling = true
+		defer func() {
+			r.journaling = false
+			r.undo = nil
+		}()
+		return r.undoOnError(unitCtx, fn)
+	}()
+	if err != nil {
+		return err
+	}
+	unit.Committed()
+	return nil
+}
+
+// undoOnError runs fn and reverts the changes it made if it fails. The caller holds the lock.
+func (r *TaskRepository) undoOnError(ctx context.Context, fn func(ctx context.Context) error) error {
+	mark := len(r.undo)
+	err := fn(ctx)
+	if err != nil {
+		for i := len(r.undo) - 1; i >= mark; i-- {
+			r.undo[i]()
+		}
+		r.undo = r.undo[:mark]
+	}
+	return err
+}
+
+// record remembers how to revert a change if a unit of work is running. The caller holds the lock.
+func (r *TaskRepository) record(undo func()) {
+	if r.journaling {
+		r.undo = append(r.undo, undo)
+	}
+}
+
+// setEntry sets an entry of one of the maps of r, recording how to revert it. Stored tasks are never
+// changed in place but replaced, so that restoring an entry restores the task. The caller holds the lock.
+func setEntry[K comparable, V any](r *TaskRepository, m map[K]V, key K, value V) {
+	r.record(restoreEntry(m, key))
+	m[key] = value
+}
+
+// deleteEntry deletes an entry of one of the maps of r like setEntry.
+func deleteEntry[K comparable, V any](r *TaskRepository, m map[K]V, key K) {
+	if _, ok := m[key]; ok {
+		r.record(restoreEntry(m, key))
+		delete(m, key)
+	}
+}
+
+// restoreEntry returns a function that restores an entry of m to its current state.
+func restoreEntry[K comparable, V any](m map[K]V, key K) func() {
+	value, ok := m[key]
+	return func() {
+		if ok {
+			m[key] = value
+		} else {
+			delete(m, key)
+		}
+	}
+}
+
+// inUnit reports whether a call made with ctx is part of a unit of work of the repository.
+func (r *TaskRepository) inUnit(ctx context.Context) bool {
+	unit, _ := ctx.Value(unitKey{}).(*TaskRepository)
+	return unit == r
+}
+
+// lock takes the lock for a write made with ctx and returns its release. Writes that are part of a unit of
+// work run under the lock of the unit of work.
+func (r *TaskRepository) lock(ctx context.Context) (unlock func()) {
+	if r.inUnit(ctx) {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// rlock takes the lock for a read made with ctx like lock.
+func (r *TaskRepository) rlock(ctx context.Context) (unlock func()) {
+	if r.inUnit(ctx) {
+		return func() {}
+	}
+	r.mu.RLock()
+	return r.mu.RUnlock
+}
+
+func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	tasks, err := r.createTasks(ctx, []*models.Task{task}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return tasks[0], nil
+}
+
+func (r *TaskRepository) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	tasks, err := r.listTasks(filter)
+	if err != nil {
+		r.logger.Error("Invalid task filter", zap.Error(err))
+		return nil, err
+	}
+	for i, task := range tasks {
+		tasks[i] = cloneTask(task)
+	}
+	return tasks, nil
+}
+
+// ExportTasks passes the tasks matching filter to each in ListTasks order. The tasks are copied
+// before the first call, so each may use the repository.
+func (r *TaskRepository) ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error {
+	tasks, err := r.ListTasks(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := each(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneTask(task), nil
+}
+
+func (r *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	tasks, err := r.UpdateTasks(ctx, []*models.Task{task})
+	if err != nil {
+		return nil, err
+	}
+	return tasks[0], nil
+}
+
+func (r *TaskRepository) DeleteTask(ctx context.Context, id int64) error {
+	return r.DeleteTasks(ctx, []int64{id})
+}
+
+func (r *TaskRepository) CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	return r.createTasks(ctx, tasks, nil, nil)
+}
+
+// CreateImportedTasks creates tasks like CreateTasks and links them to their source IDs. It fails,
+// creating nothing, if any of the source IDs was imported before.
+func (r *TaskRepository) CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error) {
+	if len(sourceIDs) != len(tasks) {
+		return nil, fmt.Errorf("got %d source IDs for %d tasks", len(sourceIDs), len(tasks))
+	}
+	return r.createTasks(ctx, tasks, &source, sourceIDs)
+}
+
+func (r *TaskRepository) ImportedSourceIDs(ctx context.Context, source string, sourceIDs []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	var imported []string
+	for _, sourceID := range sourceIDs {
+		record := importedRecord{source: source, sourceID: sourceID}
+		if _, ok := r.imports[record]; ok {
+			imported = append(imported, sourceID)
+		} else if _, ok := r.archivedImports[record]; ok {
+			imported = append(imported, sourceID)
+		}
+	}
+	return imported, nil
+}
+
+// createTasks stores tasks, recording their source IDs if source is set. The key prefixes of the
+// projects are looked up before taking the lock, so that a slow project store does not block readers.
+func (r *TaskRepository) createTasks(ctx context.Context, tasks []*models.Task, source *string, sourceIDs []string) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prefixes := make(map[int64]string)
+	for _, task := range tasks {
+		if task.ProjectID == 0 {
+			continue
+		}
+		if _, ok := prefixes[task.ProjectID]; ok {
+			continue
+		}
+		project, err := r.projects.GetProject(ctx, task.ProjectID)
+		if err != nil {
+			r.logger.Error("Failed to allocate task key", zap.Int64("project_id", task.ProjectID), zap.Error(err))
+			return nil, err
+		}
+		prefixes[task.ProjectID] = project.Key
+	}
+
+	defer r.lock(ctx)()
+
+	externalIDs := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		if externalIDs[task.ExternalID] || r.findExternalID(task.ExternalID) != 0 {
+			return nil, fmt.Errorf("task with external ID %s already exists", task.ExternalID)
+		}
+		externalIDs[task.ExternalID] = true
+	}
+	if source != nil {
+		for _, sourceID := range sourceIDs {
+			if _, ok := r.imports[importedRecord{source: *source, sourceID: sourceID}]; ok {
+				return nil, fmt.Errorf("record %s of %s was already imported", sourceID, *source)
+			}
+		}
+	}
+
+	nextID := r.nextID
+	r.record(func() { r.nextID = nextID })
+	now := time.Now()
+	for i, task := range tasks {
+		r.nextID++
+		task.ID = r.nextID
+		task.Key = r.nextKey(task.ProjectID, prefixes[task.ProjectID])
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		setEntry(r, r.tasks, task.ID, storedTask(task))
+		if source != nil {
+			setEntry(r, r.imports, importedRecord{source: *source, sourceID: sourceIDs[i]}, task.ID)
+		}
+	}
+	return tasks, nil
+}
+
+// UpdateTasks applies the updates of all tasks, or none if any of the tasks is gone.
+func (r *TaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	for _, task := range tasks {
+		if _, ok := r.tasks[task.ID]; !ok {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		stored := r.tasks[task.ID]
+		task.UpdatedAt = now
+		task.ExternalID = stored.ExternalID
+		task.ProjectID = stored.ProjectID
+		task.ParentID = stored.ParentID
+		task.Key = stored.Key
+		task.CreatedAt = stored.CreatedAt
+		setEntry(r, r.tasks, task.ID, storedTask(task))
+	}
+	return tasks, nil
+}
+
+// DeleteTasks deletes all tasks along with their records, see deleteRecords, or none if any of them is gone.
+func (r *TaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.lock(ctx)()
+
+	unique := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := r.tasks[id]; ok {
+			unique[id] = true
+		}
+	}
+	if len(unique) != len(ids) {
+		return sql.ErrNoRows
+	}
+
+	for _, id := range ids {
+		deleteEntry(r, r.tasks, id)
+	}
+	r.deleteRecords(unique)
+	return nil
+}
+
+// GetTaskIDByKey resolves the current key of a task, falling back to the keys tasks had before a transfer.
+func (r *TaskRepository) GetTaskIDByKey(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.rlock(ctx)()
+
+	for id, task := range r.tasks {
+		if task.Key == key && key != "" {
+			return id, nil
+		}
+	}
+	if id, ok := r.aliases[key]; ok {
+		return id, nil
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (r *TaskRepository) GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer r.rlock(ctx)()
+
+	if id := r.findExternalID(externalID); id != 0 {
+		return id, nil
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (r *TaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var prefix string
+	if projectID != 0 {
+		project, err := r.projects.GetProject(ctx, projectID)
+		if err != nil {
+			r.logger.Error("Failed to allocate task key", zap.Int64("project_id", projectID), zap.Error(err))
+			return nil, err
+		}
+		prefix = project.Key
+	}
+
+	defer r.lock(ctx)()
+
+	stored, ok := r.tasks[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	task := cloneTask(stored)
+	if task.Key != "" {
+		setEntry(r, r.aliases, task.Key, id)
+	}
+	task.ProjectID = projectID
+	task.Key = r.nextKey(projectID, prefix)
+	task.Status = status
+	task.Rank = rank
+	task.CustomFields = customFields
+	task.UpdatedAt = time.Now()
+	setEntry(r, r.tasks, id, storedTask(task))
+	return cloneTask(r.tasks[id]), nil
+}
+
+func (r *TaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	stored, ok := r.tasks[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	task := cloneTask(stored)
+	task.ParentID = parentID
+	task.UpdatedAt = time.Now()
+	setEntry(r, r.tasks, id, task)
+	return cloneTask(task), nil
+}
+
+func (r *TaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	now := time.Now()
+	var ids []int64
+	for id, stored := range r.tasks {
+		if stored.ParentID == 0 || !slices.Contains(parentIDs, stored.ParentID) {
+			continue
+		}
+		task := cloneTask(stored)
+		task.ParentID = 0
+		task.UpdatedAt = now
+		setEntry(r, r.tasks, id, task)
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// CreateTaskRelations stores the relations, or none if any of their tasks is gone.
+func (r *TaskRepository) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.lock(ctx)()
+
+	for _, relation := range relations {
+		for _, id := range []int64{relation.TaskID, relation.RelatedTaskID} {
+			if _, ok := r.tasks[id]; !ok {
+				return fmt.Errorf("task %d of relation does not exist", id)
+			}
+		}
+	}
+	for _, relation := range relations {
+		setEntry(r, r.relations, relation, true)
+	}
+	return nil
+}
+
+func (r *TaskRepository) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	var relations []models.TaskRelation
+	for relation := range r.relations {
+		if relation.TaskID == id || relation.RelatedTaskID == id {
+			relations = append(relations, relation)
+		}
+	}
+	slices.SortFunc(relations, func(a, b models.TaskRelation) int {
+		return cmp.Or(cmp.Compare(a.TaskID, b.TaskID), cmp.Compare(a.RelatedTaskID, b.RelatedTaskID), cmp.Compare(a.Type, b.Type))
+	})
+	return relations, nil
+}
+
+func (r *TaskRepository) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.lock(ctx)()
+
+	if !r.relations[relation] {
+		return sql.ErrNoRows
+	}
+	deleteEntry(r, r.relations, relation)
+	return nil
+}
+
+func (r *TaskRepository) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.lock(ctx)()
+
+	if _, ok := r.tasks[id]; !ok {
+		return fmt.Errorf("task %d of checklist does not exist", id)
+	}
+	if len(items) == 0 {
+		deleteEntry(r, r.checklists, id)
+	} else {
+		setEntry(r, r.checklists, id, slices.Clone(items))
+	}
+	return nil
+}
+
+func (r *TaskRepository) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	items := slices.Clone(r.checklists[id])
+	if items == nil {
+		items = []models.ChecklistItem{}
+	}
+	return items, nil
+}
+
+func (r *TaskRepository) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	if _, ok := r.tasks[comment.TaskID]; !ok {
+		return nil, fmt.Errorf("task %d of comment does not exist", comment.TaskID)
+	}
+	r.addComment(comment, time.Now())
+	return comment, nil
+}
+
+func (r *TaskRepository) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	return taskEntries(r.comments, func(comment *models.TaskComment) bool { return comment.TaskID == id }), nil
+}
+
+func (r *TaskRepository) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	if _, ok := r.tasks[attachment.TaskID]; !ok {
+		return nil, fmt.Errorf("task %d of attachment does not exist", attachment.TaskID)
+	}
+	r.addAttachment(attachment, time.Now())
+	return attachment, nil
+}
+
+func (r *TaskRepository) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	return taskEntries(r.attachments, func(attachment *models.TaskAttachment) bool { return attachment.TaskID == id }), nil
+}
+
+func (r *TaskRepository) CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.lock(ctx)()
+
+	if _, ok := r.tasks[toID]; !ok {
+		return fmt.Errorf("task %d to copy to does not exist", toID)
+	}
+	if items := r.checklists[fromID]; content.Checklist && len(items) > 0 {
+		setEntry(r, r.checklists, toID, slices.Clone(items))
+	}
+	if content.Comments {
+		for _, comment := range taskEntries(r.comments, func(comment *models.TaskComment) bool { return comment.TaskID == fromID }) {
+			comment.TaskID = toID
+			r.addComment(comment, comment.CreatedAt)
+		}
+	}
+	if content.Attachments {
+		for _, attachment := range taskEntries(r.attachments, func(attachment *models.TaskAttachment) bool { return attachment.TaskID == fromID }) {
+			attachment.TaskID = toID
+			r.addAttachment(attachment, attachment.CreatedAt)
+		}
+	}
+	return nil
+}
+
+// addComment stores a comment made at the given time under the next comment ID. The caller holds the lock.
+func (r *TaskRepository) addComment(comment *models.TaskComment, createdAt time.Time) {
+	nextID := r.nextCommentID
+	r.record(func() { r.nextCommentID = nextID })
+	r.nextCommentID++
+	comment.ID = r.nextCommentID
+	comment.CreatedAt = createdAt
+	stored := *comment
+	setEntry(r, r.comments, comment.ID, &stored)
+}
+
+// addAttachment stores an attachment like addComment.
+func (r *TaskRepository) addAttachment(attachment *models.TaskAttachment, createdAt time.Time) {
+	nextID := r.nextAttachmentID
+	r.record(func() { r.nextAttachmentID = nextID })
+	r.nextAttachmentID++
+	attachment.ID = r.nextAttachmentID
+	attachment.CreatedAt = createdAt
+	stored := *attachment
+	setEntry(r, r.attachments, attachment.ID, &stored)
+}
+
+// taskEntries returns copies of the comments or attachments in m that match, ordered by ID. The caller
+// holds the lock.
+func taskEntries[T any](m map[int64]*T, match func(*T) bool) []*T {
+	var ids []int64
+	for id, entry := range m {
+		if match(entry) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	entries := make([]*T, len(ids))
+	for i, id := range ids {
+		entry := *m[id]
+		entries[i] = &entry
+	}
+	return entries
+}
+
+func (r *TaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	stored, ok := r.tasks[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	task := cloneTask(stored)
+	task.Status = status
+	task.Rank = rank
+	task.UpdatedAt = time.Now()
+	setEntry(r, r.tasks, id, task)
+	return cloneTask(task), nil
+}
+
+func (r *TaskRepository) RankBefore(ctx context.Context, column models.Column, rank string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	defer r.rlock(ctx)()
+
+	var before string
+	for _, task := range r.tasks {
+		if inColumn(task, column) && (rank == "" || task.Rank < rank) && task.Rank > before {
+			before = task.Rank
+		}
+	}
+	return before, nil
+}
+
+func (r *TaskRepository) RankAfter(ctx context.Context, column models.Column, rank string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	defer r.rlock(ctx)()
+
+	var after string
+	for _, task := range r.tasks {
+		if inColumn(task, column) && task.Rank > rank && (after == "" || task.Rank < after) {
+			after = task.Rank
+		}
+	}
+	return after, nil
+}
+
+func (r *TaskRepository) ColumnsWithLongRanks(ctx context.Context, maxLength int) ([]models.Column, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	found := make(map[models.Column]bool)
+	var columns []models.Column
+	for _, task := range r.tasks {
+		column := models.Column{ProjectID: task.ProjectID, Status: task.Status}
+		if utf8.RuneCountInString(task.Rank) > maxLength && !found[column] {
+			found[column] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+func (r *TaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer r.lock(ctx)()
+
+	var tasks []*models.Task
+	for _, task := range r.tasks {
+		if inColumn(task, column) {
+			tasks = append(tasks, task)
+		}
+	}
+	slices.SortFunc(tasks, compareRanks)
+
+	newRanks := ranks(len(tasks))
+	if len(newRanks) != len(tasks) {
+		return fmt.Errorf("rank generator returned %d keys for %d tasks", len(newRanks), len(tasks))
+	}
+	for i, task := range tasks {
+		task = cloneTask(task)
+		task.Rank = newRanks[i]
+		setEntry(r, r.tasks, task.ID, task)
+	}
+	return nil
+}
+
+// ArchiveTasks moves the tasks to the archive along with their key aliases and import records. Their
+// relations, checklists, comments and attachments are deleted.
+func (r *TaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.lock(ctx)()
+
+	var tasks []*models.Task
+	for _, task := range r.tasks {
+		if inColumn(task, column) && task.UpdatedAt.Before(updatedBefore) {
+			tasks = append(tasks, task)
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *models.Task) int {
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	now := time.Now()
+	archived := make(map[int64]bool, len(tasks))
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		task = cloneTask(task)
+		task.ArchivedAt = now
+		setEntry(r, r.archive, task.ID, task)
+		deleteEntry(r, r.tasks, task.ID)
+		archived[task.ID] = true
+		ids[i] = task.ID
+	}
+	for key, id := range r.aliases {
+		if archived[id] {
+			setEntry(r, r.archivedAliases, key, id)
+		}
+	}
+	for record, id := range r.imports {
+		if archived[id] {
+			setEntry(r, r.archivedImports, record, id)
+		}
+	}
+	r.deleteRecords(archived)
+	return ids, nil
+}
+
+// CreateTaskPartitions does nothing, as the tasks are kept in one map.
+func (r *TaskRepository) CreateTaskPartitions(ctx context.Context, until time.Time) error {
+	return nil
+}
+
+func (r *TaskRepository) GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer r.rlock(ctx)()
+
+	for _, task := range r.archive {
+		var ok bool
+		switch {
+		case ref.ExternalID != "":
+			ok = task.ExternalID == ref.ExternalID
+		case ref.Key != "":
+			ok = task.Key == ref.Key || r.archivedAliases[ref.Key] == task.ID
+		default:
+			ok = task.ID == ref.ID
+		}
+		if ok {
+			return cloneTask(task), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// hasProjectTasks reports whether any task, live or archived, belongs to the project. The caller holds
+// the lock.
+func (r *TaskRepository) hasProjectTasks(projectID int64) bool {
+	for _, tasks := range []map[int64]*models.Task{r.tasks, r.archive} {
+		for _, task := range tasks {
+			if task.ProjectID == projectID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldValues returns the values the tasks of a project hold for a custom field, by task ID.
+func (r *TaskRepository) fieldValues(ctx context.Context, projectID int64, name string) []models.FieldValueRef {
+	defer r.rlock(ctx)()
+
+	var values []models.FieldValueRef
+	for _, task := range r.tasks {
+		if task.ProjectID != projectID {
+			continue
+		}
+		ref := models.FieldValueRef{TaskID: task.ID, TaskKey: task.Key}
+		if value, ok := task.CustomFields[name]; ok {
+			ref.Value = &value
+		}
+		values = append(values, ref)
+	}
+	slices.SortFunc(values, func(a, b models.FieldValueRef) int { return cmp.Compare(a.TaskID, b.TaskID) })
+	return values
+}
+
+// removeFieldValues removes the values the tasks of a project hold for a custom field.
+func (r *TaskRepository) removeFieldValues(ctx context.Context, projectID int64, name string) {
+	defer r.lock(ctx)()
+
+	for id, task := range r.tasks {
+		if _, ok := task.CustomFields[name]; ok && task.ProjectID == projectID {
+			task = cloneTask(task)
+			delete(task.CustomFields, name)
+			setEntry(r, r.tasks, id, storedTask(task))
+		}
+	}
+}
+
+// listTasks returns the stored tasks matching filter in ListTasks order. The caller holds the lock.
+func (r *TaskRepository) listTasks(filter models.TaskFilter) ([]*models.Task, error) {
+	stored := []map[int64]*models.Task{r.tasks}
+	if filter.IncludeArchived {
+		stored = append(stored, r.archive)
+	}
+	var tasks []*models.Task
+	for _, byID := range stored {
+		for _, task := range byID {
+			ok, err := matchesFilter(task, filter)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	slices.SortFunc(tasks, func(a, b *models.Task) int {
+		return compareTaskOrder(filter.OrderBy, sortPosition(a, filter.OrderBy), sortPosition(b, filter.OrderBy))
+	})
+	if filter.After != nil {
+		after := position{value: filter.After.SortValue, rank: filter.After.Rank, externalID: filter.After.ExternalID}
+		start, _ := slices.BinarySearchFunc(tasks, after, func(task *models.Task, after position) int {
+			if compareTaskOrder(filter.OrderBy, sortPosition(task, filter.OrderBy), after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		tasks = tasks[start:]
+	}
+	if filter.Limit > 0 && len(tasks) > filter.Limit {
+		tasks = tasks[:filter.Limit]
+	}
+	return tasks, nil
+}
+
+// nextKey hands out the next key of a project with the given prefix, or "" for tasks outside of any project.
+// The caller holds the lock.
+func (r *TaskRepository) nextKey(projectID int64, prefix string) string {
+	if projectID == 0 {
+		return ""
+	}
+	setEntry(r, r.counters, projectID, r.counters[projectID]+1)
+	return fmt.Sprintf("%s-%d", prefix, r.counters[projectID])
+}
+
+// deleteRecords deletes the key aliases, import records, relations, checklists, comments and attachments
+// of the tasks. The caller holds the lock.
+func (r *TaskRepository) deleteRecords(ids map[int64]bool) {
+	for key, id := range r.aliases {
+		if ids[id] {
+			deleteEntry(r, r.aliases, key)
+		}
+	}
+	for record, id := range r.imports {
+		if ids[id] {
+			deleteEntry(r, r.imports, record)
+		}
+	}
+	for relation := range r.relations {
+		if ids[relation.TaskID] || ids[relation.RelatedTaskID] {
+			deleteEntry(r, r.relations, relation)
+		}
+	}
+	for id := range ids {
+		deleteEntry(r, r.checklists, id)
+	}
+	for id, comment := range r.comments {
+		if ids[comment.TaskID] {
+			deleteEntry(r, r.comments, id)
+		}
+	}
+	for id, attachment := range r.attachments {
+		if ids[attachment.TaskID] {
+			deleteEntry(r, r.attachments, id)
+		}
+	}
+}
+
+// findExternalID returns the ID of the task with the external ID, or 0. The caller holds the lock.
+func (r *TaskRepository) findExternalID(externalID string) int64 {
+	for id, task := range r.tasks {
+		if task.ExternalID == externalID {
+			return id
+		}
+	}
+	return 0
+}
+
+func inColumn(task *models.Task, column models.Column) bool {
+	return task.ProjectID == column.ProjectID && task.Status == column.Status
+}
+
+// compareRanks orders the tasks of a column by rank and then by external ID.
+func compareRanks(a, b *models.Task) int {
+	if a.Rank != b.Rank {
+		return compareStrings(a.Rank, b.Rank)
+	}
+	return compareStrings(a.ExternalID, b.ExternalID)
+}
+
+// storedTask returns the copy of task kept by the repository. Like the Postgres columns, it stores
+// missing labels as an empty list and custom field values as numbers or strings.
+func storedTask(task *models.Task) *models.Task {
+	stored := cloneTask(task)
+	if stored.Labels == nil {
+		stored.Labels = []string{}
+	}
+	for name, value := range stored.CustomFields {
+		if value.Type == models.FieldTypeNumber {
+			stored.CustomFields[name] = models.FieldValue{Type: models.FieldTypeNumber, Number: value.Number}
+		} else {
+			stored.CustomFields[name] = models.FieldValue{Type: models.FieldTypeString, String: value.String}
+		}
+	}
+	return stored
+}
+
+// cloneTask deeply copies a task, so that callers and the repository never share labels or custom fields.
+func cloneTask(task *models.Task) *models.Task {
+	clone := *task
+	clone.Labels = slices.Clone(task.Labels)
+	if len(task.CustomFields) == 0 {
+		clone.CustomFields = nil
+	} else {
+		clone.CustomFields = maps.Clone(task.CustomFields)
+	}
+	return &clone
+}
@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// projectStore serves the projects the task repository looks up key prefixes of.
+type projectStore struct {
+	repository.ProjectRepository
+
+	mu       sync.Mutex
+	projects map[int64]*models.Project
+}
+
+func (s *projectStore) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return project, nil
+}
+
+func (s *projectStore) add() *models.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := int64(len(s.projects) + 1)
+	project := &models.Project{ID: id, Key: fmt.Sprintf("P%d", id), Name: "Project"}
+	s.projects[id] = project
+	return project
+}
+
+func newFixture() (*TaskRepository, *projectStore) {
+	projects := &projectStore{projects: make(map[int64]*models.Project)}
+	return NewTaskRepository(projects, zap.NewNop()), projects
+}
+
+func TestTaskRepository_Conformance(t *testing.T) {
+	repositorytest.TestTaskRepository(t, func(t *testing.T) repositorytest.Fixture {
+		repo, projects := newFixture()
+		return repositorytest.Fixture{
+			Repository: repo,
+			NewProject: func(t *testing.T) (int64, string) {
+				project := projects.add()
+				return project.ID, project.Key
+			},
+			Transactor: repo,
+		}
+	})
+}
+
+func TestTaskRepository_UnknownProject(t *testing.T) {
+	repo, _ := newFixture()
+
+	_, err := repo.CreateTask(context.Background(), &models.Task{ExternalID: "a", ProjectID: 7, Title: "Orphan"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestTaskRepository_NoProject(t *testing.T) {
+	repo, projects := newFixture()
+	ctx := context.Background()
+
+	task, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", Title: "Loose", Status: "open", Rank: "m"})
+	require.NoError(t, err)
+	assert.Empty(t, task.Key)
+
+	project := projects.add()
+	moved, err := repo.TransferTask(ctx, task.ID, project.ID, "open", "m", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "P1-1", moved.Key)
+
+	moved, err = repo.TransferTask(ctx, task.ID, 0, "open", "m", nil)
+	require.NoError(t, err)
+	assert.Empty(t, moved.Key)
+	id, err := repo.GetTaskIDByKey(ctx, "P1-1")
+	require.NoError(t, err)
+	assert.Equal(t, task.ID, id)
+}
+
+func TestTaskRepository_Concurrent(t *testing.T) {
+	repo, projects := newFixture()
+	project := projects.add()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			task, err := repo.CreateTask(ctx, &models.Task{ExternalID: fmt.Sprint(i), ProjectID: project.ID, Status: "open"})
+			if !assert.NoError(t, err) {
+				return
+			}
+			task.Title = "Updated"
+			_, err = repo.UpdateTask(ctx, task)
+			assert.NoError(t, err)
+			_, err = repo.ListTasks(ctx, models.TaskFilter{ProjectID: project.ID})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	tasks, err := repo.ListTasks(ctx, models.TaskFilter{ProjectID: project.ID})
+	require.NoError(t, err)
+	keys := make(map[string]bool)
+	for _, task := range tasks {
+		keys[task.Key] = true
+	}
+	assert.Len(t, keys, 20)
+	assert.True(t, keys["P1-20"])
+}
+
+func TestTaskRepository_CanceledContext(t *testing.T) {
+	repo, _ := newFixture()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ListTasks(ctx, models.TaskFilter{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTaskRepository_RollbackRestoresChangedTasks(t *testing.T) {
+	repo, projects := newFixture()
+	project, target := projects.add(), projects.add()
+	ctx := context.Background()
+	moved, err := repo.CreateTask(ctx, &models.Task{ExternalID: "a", ProjectID: project.ID, Status: "open", Rank: "m"})
+	require.NoError(t, err)
+	transferred, err := repo.CreateTask(ctx, &models.Task{ExternalID: "b", ProjectID: project.ID, Status: "open", Rank: "n"})
+	require.NoError(t, err)
+	archived, err := repo.CreateTask(ctx, &models.Task{ExternalID: "c", ProjectID: project.ID, Status: "done", Rank: "m"})
+	require.NoError(t, err)
+
+	errFailed := errors.New("unit of work failed")
+	err = repo.InTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.MoveTask(ctx, moved.ID, "done", "a"); err != nil {
+			return err
+		}
+		if _, err := repo.TransferTask(ctx, transferred.ID, target.ID, "open", "m", nil); err != nil {
+			return err
+		}
+		column := models.Column{ProjectID: project.ID, Status: "done"}
+		if err := repo.RebalanceColumn(ctx, column, func(count int) []string { return []string{"x", "y"}[:count] }); err != nil {
+			return err
+		}
+		if _, err := repo.ArchiveTasks(ctx, column, time.Now().Add(time.Hour), 1); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	require.ErrorIs(t, err, errFailed)
+
+	got, err := repo.GetTask(ctx, moved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "open", got.Status)
+	assert.Equal(t, "m", got.Rank)
+	got, err = repo.GetTask(ctx, transferred.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "P1-2", got.Key)
+	_, err = repo.GetTaskIDByKey(ctx, "P2-1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	got, err = repo.GetTask(ctx, archived.ID)
+	require.NoError(t, err, "the archived task is back")
+	assert.Equal(t, "m", got.Rank)
+	_, err = repo.GetArchivedTask(ctx, models.TaskRef{ID: archived.ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	created, err := repo.CreateTask(ctx, &models.Task{ExternalID: "d", ProjectID: target.ID, Status: "open"})
+	require.NoError(t, err)
+	assert.Equal(t, "P2-1", created.Key, "the key counter of the target project is restored")
+	assert.Equal(t, archived.ID+1, created.ID)
+}
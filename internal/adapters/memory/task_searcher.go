@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// Weights of matches in titles and descriptions, the defaults of ts_rank_cd for the weights Postgres
+// indexes titles and descriptions with.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+// searchWordPattern splits texts into words like the Postgres searcher splits prefix terms.
+var searchWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// TaskSearcher searches the tasks of a memory TaskRepository word by word. It does not stem words, so
+// a word of a query only matches the same word in any letter case, whatever the search language. Like
+// the Postgres searcher, titles weigh more than descriptions in the ranking, but snippets hold the
+// whole description rather than the fragments around the matches.
+type TaskSearcher struct {
+	tasks *TaskRepository
+}
+
+// searchTerm is a search term split into lowercase words.
+type searchTerm struct {
+	words []string
+	// sequence requires the words to appear next to each other, the last one as a prefix if prefix is set.
+	sequence bool
+	prefix   bool
+	exclude  bool
+}
+
+// taskWord is a lowercase word of a task and where it appears in the title or description.
+type taskWord struct {
+	text       string
+	start, end int
+	title      bool
+}
+
+func newTaskSearcher(tasks *TaskRepository) *TaskSearcher {
+	return &TaskSearcher{tasks: tasks}
+}
+
+// SearchTasks returns the tasks matching all terms of the search, ranked by how often their words appear.
+func (s *TaskSearcher) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var terms []searchTerm
+	var wanted bool
+	for _, term := range search.Terms {
+		words := searchWords(term.Text)
+		if len(words) == 0 {
+			continue
+		}
+		terms = append(terms, searchTerm{
+			words:    wordTexts(words),
+			sequence: term.Phrase || term.Prefix,
+			prefix:   term.Prefix,
+			exclude:  term.Exclude,
+		})
+		wanted = wanted || !term.Exclude
+	}
+	if !wanted {
+		return nil, nil
+	}
+
+	hits := s.matchingTasks(search.ProjectID, terms)
+	slices.SortFunc(hits, compareHits)
+	if search.After != nil {
+		after := &models.SearchHit{Score: search.After.Score, Task: &models.Task{ExternalID: search.After.ExternalID}}
+		start, _ := slices.BinarySearchFunc(hits, after, func(hit, after *models.SearchHit) int {
+			if compareHits(hit, after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		hits = hits[start:]
+	}
+	if search.Limit > 0 && len(hits) > search.Limit {
+		hits = hits[:search.Limit]
+	}
+	return hits, nil
+}
+
+// matchingTasks returns the hits among the tasks of the project, or of all projects for zero.
+func (s *TaskSearcher) matchingTasks(projectID int64, terms []searchTerm) []*models.SearchHit {
+	s.tasks.mu.RLock()
+	defer s.tasks.mu.RUnlock()
+
+	var hits []*models.SearchHit
+	for _, task := range s.tasks.tasks {
+		if projectID != 0 && task.ProjectID != projectID {
+			continue
+		}
+		if hit := searchHit(task, terms); hit != nil {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+// searchHit returns the hit of the task if it matches the terms, or nil.
+func searchHit(task *models.Task, terms []searchTerm) *models.SearchHit {
+	titleWords := searchWords(task.Title)
+	for i := range titleWords {
+		titleWords[i].title = true
+	}
+	words := append(titleWords, searchWords(task.Description)...)
+
+	matched := make(map[int]bool)
+	for _, term := range terms {
+		found := term.occurrences(words)
+		if term.exclude != (found == nil) {
+			return nil
+		}
+		for _, i := range found {
+			matched[i] = true
+		}
+	}
+
+	var rank float64
+	for i := range matched {
+		if words[i].title {
+			rank += titleWeight
+		} else {
+			rank += descriptionWeight
+		}
+	}
+	return &models.SearchHit{
+		Task:               cloneTask(task),
+		Score:              rank / (rank + 1),
+		TitleSnippet:       highlight(task.Title, words, matched, true),
+		DescriptionSnippet: highlight(task.Description, words, matched, false),
+	}
+}
+
+// occurrences returns the positions in words of the words matching the term, or nil if the term does not
+// match. Terms that are not sequences match if each of their words appears anywhere.
+func (t searchTerm) occurrences(words []taskWord) []int {
+	var found []int
+	if t.sequence {
+		for i := range words {
+			if t.matchesAt(words, i) {
+				for j := range t.words {
+					found = append(found, i+j)
+				}
+			}
+		}
+		return found
+	}
+
+	for _, word := range t.words {
+		n := len(found)
+		for i := range words {
+			if words[i].text == word {
+				found = append(found, i)
+			}
+		}
+		if len(found) == n {
+			return nil
+		}
+	}
+	return found
+}
+
+// matchesAt reports whether the words of a sequence term appear in words starting at position i.
+func (t searchTerm) matchesAt(words []taskWord, i int) bool {
+	if i+len(t.words) > len(words) {
+		return false
+	}
+	for j, word := range t.words {
+		text := words[i+j].text
+		if t.prefix && j == len(t.words)-1 {
+			if !strings.HasPrefix(text, word) {
+				return false
+			}
+		} else if text != word {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight returns the title or description of a task with the matched words in <mark> tags.
+func highlight(text string, words []taskWord, matched map[int]bool, title bool) string {
+	var b strings.Builder
+	last := 0
+	for i, word := range words {
+		if word.title != title || !matched[i] {
+			continue
+		}
+		b.WriteString(text[last:word.start])
+		b.WriteString("<mark>")
+		b.WriteString(text[word.start:word.end])
+		b.WriteString("</mark>")
+		last = word.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// searchWords splits a text into its lowercase words.
+func searchWords(text string) []taskWord {
+	var words []taskWord
+	for _, loc := range searchWordPattern.FindAllStringIndex(text, -1) {
+		words = append(words, taskWord{text: strings.ToLower(text[loc[0]:loc[1]]), start: loc[0], end: loc[1]})
+	}
+	return words
+}
+
+func wordTexts(words []taskWord) []string {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.text
+	}
+	return texts
+}
+
+// compareHits orders hits by descending score and then by external ID.
+func compareHits(a, b *models.SearchHit) int {
+	switch {
+	case a.Score > b.Score:
+		return -1
+	case a.Score < b.Score:
+		return 1
+	}
+	return compareStrings(a.Task.ExternalID, b.Task.ExternalID)
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTaskSearcher(t *testing.T) {
+	store := NewStore(zap.NewNop())
+	ctx := context.Background()
+	project, err := store.Projects.CreateProject(context.Background(), &models.Project{Key: "OPS", Name: "Operations"})
+	require.NoError(t, err)
+	for _, task := range []*models.Task{
+		{ExternalID: "a", ProjectID: project.ID, Title: "Fix login page", Description: "The login form crashes."},
+		{ExternalID: "b", ProjectID: project.ID, Title: "Update docs", Description: "Describe how the login works."},
+		{ExternalID: "c", Title: "Login audit", Description: "Check the logs of the page."},
+		{ExternalID: "d", ProjectID: project.ID, Title: "Page login", Description: "Unrelated."},
+	} {
+		_, err := store.Tasks.CreateTask(ctx, task)
+		require.NoError(t, err)
+	}
+
+	search := func(projectID int64, terms ...models.SearchTerm) []string {
+		t.Helper()
+		hits, err := store.Searcher.SearchTasks(ctx, models.TaskSearch{Terms: terms, ProjectID: projectID})
+		require.NoError(t, err)
+		var externalIDs []string
+		for _, hit := range hits {
+			externalIDs = append(externalIDs, hit.Task.ExternalID)
+		}
+		return externalIDs
+	}
+
+	assert.Equal(t, []string{"a", "c", "d", "b"}, search(0, models.SearchTerm{Text: "LOGIN"}), "title matches rank first, ties by external ID")
+	assert.Equal(t, []string{"a", "d"}, search(project.ID, models.SearchTerm{Text: "login"}, models.SearchTerm{Text: "page"}))
+	assert.Equal(t, []string{"a"}, search(0, models.SearchTerm{Text: "login page", Phrase: true}))
+	assert.Equal(t, []string{"c"}, search(0, models.SearchTerm{Text: "the lo", Prefix: true}, models.SearchTerm{Text: "audit"}))
+	assert.Equal(t, []string{"b"}, search(project.ID, models.SearchTerm{Text: "login"}, models.SearchTerm{Text: "page", Exclude: true}))
+	assert.Empty(t, search(0, models.SearchTerm{Text: "!!"}, models.SearchTerm{Text: "audit", Exclude: true}), "there is no word to look for")
+
+	hits, err := store.Searcher.SearchTasks(ctx, models.TaskSearch{Terms: []models.SearchTerm{{Text: "login"}}, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "Fix <mark>login</mark> page", hits[0].TitleSnippet)
+	assert.Equal(t, "The <mark>login</mark> form crashes.", hits[0].DescriptionSnippet)
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+
+	last := hits[1]
+	hits, err = store.Searcher.SearchTasks(ctx, models.TaskSearch{Terms: []models.SearchTerm{{Text: "login"}},
+		After: &models.SearchCursor{Score: last.Score, ExternalID: last.Task.ExternalID}})
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "d", hits[0].Task.ExternalID)
+}
@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+)
+
+// TaskTemplateRepository keeps task templates and all their versions in memory like the Postgres task
+// template repository.
+type TaskTemplateRepository struct {
+	mu        sync.RWMutex
+	nextID    int64
+	templates map[int64]*storedTemplate
+}
+
+// storedTemplate is a template with its versions, the first at index 0. The CreatedAt of a template is
+// when its first version was made and the UpdatedAt of a version when that version was made.
+type storedTemplate struct {
+	projectID int64
+	name      string
+	createdAt time.Time
+	versions  []*models.TaskTemplate
+}
+
+func newTaskTemplateRepository() *TaskTemplateRepository {
+	return &TaskTemplateRepository{templates: make(map[int64]*storedTemplate)}
+}
+
+func (r *TaskTemplateRepository) CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(template.ProjectID, template.Name, 0) {
+		return nil, repository.ErrAlreadyExists
+	}
+
+	now := time.Now()
+	r.nextID++
+	template.ID = r.nextID
+	template.Version = 1
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	r.templates[template.ID] = &storedTemplate{
+		projectID: template.ProjectID,
+		name:      template.Name,
+		createdAt: now,
+		versions:  []*models.TaskTemplate{cloneTaskTemplate(template)},
+	}
+	return template, nil
+}
+
+func (r *TaskTemplateRepository) GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.templates[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if version == 0 {
+		version = len(stored.versions)
+	}
+	if version < 1 || version > len(stored.versions) {
+		return nil, sql.ErrNoRows
+	}
+	return stored.template(version), nil
+}
+
+func (r *TaskTemplateRepository) ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var templates []*models.TaskTemplate
+	for _, stored := range r.templates {
+		if stored.projectID == projectID {
+			templates = append(templates, stored.template(len(stored.versions)))
+		}
+	}
+	slices.SortFunc(templates, func(a, b *models.TaskTemplate) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return templates, nil
+}
+
+func (r *TaskTemplateRepository) UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.templates[template.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if r.nameTaken(stored.projectID, template.Name, template.ID) {
+		return nil, repository.ErrAlreadyExists
+	}
+
+	template.ProjectID = stored.projectID
+	template.Version = len(stored.versions) + 1
+	template.CreatedAt = stored.createdAt
+	template.UpdatedAt = time.Now()
+	stored.name = template.Name
+	stored.versions = append(stored.versions, cloneTaskTemplate(template))
+	return template, nil
+}
+
+func (r *TaskTemplateRepository) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+// nameTaken reports whether a template other than the one with ID except has the name in the project.
+// The caller holds the lock.
+func (r *TaskTemplateRepository) nameTaken(projectID int64, name string, except int64) bool {
+	for id, stored := range r.templates {
+		if id != except && stored.projectID == projectID && stored.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteProject deletes the templates of a deleted project.
+func (r *TaskTemplateRepository) deleteProject(projectID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, stored := range r.templates {
+		if stored.projectID == projectID {
+			delete(r.templates, id)
+		}
+	}
+}
+
+// template returns a version of the template under its current name, as the Postgres repository joins
+// the versions with the template.
+func (t *storedTemplate) template(version int) *models.TaskTemplate {
+	template := cloneTaskTemplate(t.versions[version-1])
+	template.Name = t.name
+	template.CreatedAt = t.createdAt
+	return template
+}
+
+func cloneTaskTemplate(template *models.TaskTemplate) *models.TaskTemplate {
+	clone := *template
+	clone.Variables = slices.Clone(template.Variables)
+	clone.Tasks = cloneBlueprints(template.Tasks)
+	return &clone
+}
+
+func cloneBlueprints(blueprints []models.TaskBlueprint) []models.TaskBlueprint {
+	if blueprints == nil {
+		return nil
+	}
+	clones := make([]models.TaskBlueprint, len(blueprints))
+	for i, blueprint := range blueprints {
+		blueprint.Labels = slices.Clone(blueprint.Labels)
+		if len(blueprint.CustomFields) == 0 {
+			blueprint.CustomFields = nil
+		} else {
+			blueprint.CustomFields = maps.Clone(blueprint.CustomFields)
+		}
+		blueprint.Subtasks = cloneBlueprints(blueprint.Subtasks)
+		blueprint.Relations = slices.Clone(blueprint.Relations)
+		clones[i] = blueprint
+	}
+	return clones
+}
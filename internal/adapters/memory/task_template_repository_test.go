@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskTemplateRepository(t *testing.T) {
+	repo := newTaskTemplateRepository()
+
+	template, err := repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: 1, Name: "Onboarding", Variables: []string{"name"},
+		Tasks: []models.TaskBlueprint{{Title: "Welcome {{name}}", Labels: []string{"hr"}}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, template.Version)
+	_, err = repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: 1, Name: "Onboarding"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+	other, err := repo.CreateTaskTemplate(context.Background(), &models.TaskTemplate{ProjectID: 1, Name: "Audit"})
+	require.NoError(t, err)
+
+	template.Tasks[0].Labels[0] = "changed"
+	updated, err := repo.UpdateTaskTemplate(context.Background(), &models.TaskTemplate{ID: template.ID, ProjectID: 7, Name: "Offboarding",
+		Tasks: []models.TaskBlueprint{{Title: "Goodbye"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, int64(1), updated.ProjectID)
+	assert.Equal(t, template.CreatedAt, updated.CreatedAt)
+	_, err = repo.UpdateTaskTemplate(context.Background(), &models.TaskTemplate{ID: other.ID, Name: "Offboarding"})
+	assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+
+	first, err := repo.GetTaskTemplate(context.Background(), template.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Offboarding", first.Name, "versions carry the current name")
+	assert.Equal(t, []string{"hr"}, first.Tasks[0].Labels, "the repository keeps its own copy")
+	current, err := repo.GetTaskTemplate(context.Background(), template.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.Version)
+	assert.Equal(t, "Goodbye", current.Tasks[0].Title)
+	_, err = repo.GetTaskTemplate(context.Background(), template.ID, 3)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	templates, err := repo.ListTaskTemplates(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "Audit", templates[0].Name)
+	assert.Equal(t, 2, templates[1].Version)
+
+	require.NoError(t, repo.DeleteTaskTemplate(context.Background(), template.ID))
+	assert.ErrorIs(t, repo.DeleteTaskTemplate(context.Background(), template.ID), sql.ErrNoRows)
+	_, err = repo.GetTaskTemplate(context.Background(), template.ID, 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
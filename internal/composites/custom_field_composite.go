@@ -0,0 +1,40 @@
+package composites
+
+import (
+	"errors"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+)
+
+type CustomFieldComposite struct {
+	Repository repository.CustomFieldRepository
+	Service    services.CustomFieldService
+	Handler    pb.CustomFieldManagerServer
+}
+
+func NewCustomFieldComposite(store Store, projectRepository repository.ProjectRepository, logger *zap.Logger) (*CustomFieldComposite, error) {
+	customFieldRepository, err := store.customFieldRepository(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	customFieldService := services.NewCustomFieldService(customFieldRepository, projectRepository, logger)
+	if customFieldService == nil {
+		return nil, errors.New("failed to initialize custom field service")
+	}
+
+	customFieldHandler := grpc.NewCustomFieldHandler(customFieldService, logger)
+	if customFieldHandler == nil {
+		return nil, errors.New("failed to initialize custom field handler")
+	}
+
+	return &CustomFieldComposite{
+		Repository: customFieldRepository,
+		Service:    customFieldService,
+		Handler:    customFieldHandler,
+	}, nil
+}
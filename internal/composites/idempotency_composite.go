@@ -0,0 +1,39 @@
+package composites
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	"go.uber.org/zap"
+	grpclib "google.golang.org/grpc"
+)
+
+type IdempotencyComposite struct {
+	Repository  repository.IdempotencyRepository
+	Service     services.IdempotencyService
+	Purger      *services.IdempotencyPurger
+	Interceptor grpclib.UnaryServerInterceptor
+}
+
+// NewIdempotencyComposite takes over claims of requests that ran past requestTimeout, the deadline of RPCs.
+func NewIdempotencyComposite(store Store, ttl, purgeInterval, requestTimeout time.Duration, logger *zap.Logger) (*IdempotencyComposite, error) {
+	idempotencyRepository, err := store.idempotencyRepository(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyService := services.NewIdempotencyService(idempotencyRepository, ttl, requestTimeout, logger)
+	if idempotencyService == nil {
+		return nil, errors.New("failed to initialize idempotency service")
+	}
+
+	return &IdempotencyComposite{
+		Repository:  idempotencyRepository,
+		Service:     idempotencyService,
+		Purger:      services.NewIdempotencyPurger(idempotencyRepository, purgeInterval, logger),
+		Interceptor: grpc.NewIdempotencyInterceptor(idempotencyService, logger),
+	}, nil
+}
@@ -0,0 +1,40 @@
+package composites
+
+import (
+	"errors"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+)
+
+type ProjectComposite struct {
+	Repository repository.ProjectRepository
+	Service    services.ProjectService
+	Handler    pb.ProjectManagerServer
+}
+
+func NewProjectComposite(store Store, logger *zap.Logger) (*ProjectComposite, error) {
+	projectRepository, err := store.projectRepository(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	projectService := services.NewProjectService(projectRepository, logger)
+	if projectService == nil {
+		return nil, errors.New("failed to initialize project service")
+	}
+
+	projectHandler := grpc.NewProjectHandler(projectService, logger)
+	if projectHandler == nil {
+		return nil, errors.New("failed to initialize project handler")
+	}
+
+	return &ProjectComposite{
+		Repository: projectRepository,
+		Service:    projectService,
+		Handler:    projectHandler,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package composites
+
+import (
+	"errors"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+)
+
+type SavedViewComposite struct {
+	Repository repository.SavedViewRepository
+	Service    services.SavedViewService
+	Handler    pb.SavedViewManagerServer
+}
+
+func NewSavedViewComposite(store Store, projectRepository repository.ProjectRepository,
+	customFieldRepository repository.CustomFieldRepository, taskService services.TaskService, logger *zap.Logger) (*SavedViewComposite, error) {
+	savedViewRepository, err := store.savedViewRepository(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	savedViewService := services.NewSavedViewService(savedViewRepository, projectRepository, customFieldRepository, taskService, logger)
+	if savedViewService == nil {
+		return nil, errors.New("failed to initialize saved view service")
+	}
+
+	savedViewHandler := grpc.NewSavedViewHandler(savedViewService, logger)
+	if savedViewHandler == nil {
+		return nil, errors.New("failed to initialize saved view handler")
+	}
+
+	return &SavedViewComposite{
+		Repository: savedViewRepository,
+		Service:    savedViewService,
+		Handler:    savedViewHandler,
+	}, nil
+}
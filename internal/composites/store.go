@@ -0,0 +1,132 @@
+package composites
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	storage "github.com/Sunf1ower113/grpc-task-manager/internal/adapters/db"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/memory"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// Store selects where the composites keep their data.
+type Store struct {
+	// Driver is "postgres", "sqlite" to keep everything in a single SQLite database, or "memory" to keep
+	// everything in process memory for tests and local development.
+	Driver string
+	// Postgres is the database of the "postgres" driver.
+	Postgres *sql.DB
+	// SQLite is the database of the "sqlite" driver.
+	SQLite *sql.DB
+	// Memory holds the data of the "memory" driver.
+	Memory *memory.Store
+}
+
+// check reports a driver that is unknown or misses its database.
+func (s Store) check() error {
+	switch {
+	case s.Driver == "memory" && s.Memory == nil:
+		return errors.New("the memory storage driver needs a memory store")
+	case s.Driver == "memory":
+		return nil
+	case s.Driver == "sqlite" && s.SQLite == nil:
+		return errors.New("the sqlite storage driver needs an SQLite database")
+	case s.Driver == "sqlite":
+		return nil
+	case s.Driver != "postgres":
+		return fmt.Errorf("unknown storage driver %q", s.Driver)
+	case s.Postgres == nil:
+		return errors.New("the postgres storage driver needs a Postgres database")
+	}
+	return nil
+}
+
+func (s Store) projectRepository(logger *zap.Logger) (repository.ProjectRepository, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	switch s.Driver {
+	case "memory":
+		return s.Memory.Projects, nil
+	case "sqlite":
+		return storage.NewSQLiteProjectRepository(s.SQLite, logger), nil
+	}
+	return storage.NewPostgresProjectRepository(s.Postgres, logger), nil
+}
+
+func (s Store) customFieldRepository(logger *zap.Logger) (repository.CustomFieldRepository, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	switch s.Driver {
+	case "memory":
+		return s.Memory.CustomFields, nil
+	case "sqlite":
+		return storage.NewSQLiteCustomFieldRepository(s.SQLite, logger), nil
+	}
+	return storage.NewPostgresCustomFieldRepository(s.Postgres, logger), nil
+}
+
+func (s Store) savedViewRepository(logger *zap.Logger) (repository.SavedViewRepository, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	switch s.Driver {
+	case "memory":
+		return s.Memory.SavedViews, nil
+	case "sqlite":
+		return storage.NewSQLiteSavedViewRepository(s.SQLite, logger), nil
+	}
+	return storage.NewPostgresSavedViewRepository(s.Postgres, logger), nil
+}
+
+func (s Store) taskTemplateRepository(logger *zap.Logger) (repository.TaskTemplateRepository, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	switch s.Driver {
+	case "memory":
+		return s.Memory.TaskTemplates, nil
+	case "sqlite":
+		return storage.NewSQLiteTaskTemplateRepository(s.SQLite, logger), nil
+	}
+	return storage.NewPostgresTaskTemplateRepository(s.Postgres, logger), nil
+}
+
+func (s Store) idempotencyRepository(logger *zap.Logger) (repository.IdempotencyRepository, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	switch s.Driver {
+	case "memory":
+		return s.Memory.Idempotency, nil
+	case "sqlite":
+		return storage.NewSQLiteIdempotencyRepository(s.SQLite, logger), nil
+	}
+	return storage.NewPostgresIdempotencyRepository(s.Postgres, logger), nil
+}
+
+// taskSearcher returns the searcher of the tasks in the primary database. The Postgres and SQLite
+// searchers first index the tasks in searchLanguage.
+func (s Store) taskSearcher(searchLanguage string, logger *zap.Logger) (repository.TaskSearcher, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	switch s.Driver {
+	case "memory":
+		return s.Memory.Searcher, nil
+	case "sqlite":
+		searcher := storage.NewSQLiteTaskSearcher(s.SQLite, searchLanguage, logger)
+		if err := searcher.ApplyLanguage(); err != nil {
+			return nil, err
+		}
+		return searcher, nil
+	}
+	searcher := storage.NewPostgresTaskSearcher(s.Postgres, searchLanguage, logger)
+	if err := searcher.ApplyLanguage(); err != nil {
+		return nil, err
+	}
+	return searcher, nil
+}
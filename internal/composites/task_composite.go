@@ -3,40 +3,144 @@ package composites
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/cache"
 	storage "github.com/Sunf1ower113/grpc-task-manager/internal/adapters/db"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/ids"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
 	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
 )
 
 type TaskComposite struct {
 	Repository repository.TaskRepository
+	Searcher   repository.TaskSearcher
 	Service    services.TaskService
 	Handler    pb.TaskManagerServer
+	// Transactor runs units of work across the calls of Repository.
+	Transactor repository.Transactor
+	// Cache is the caching layer of Repository, or nil without a cache.
+	Cache *cache.TaskRepository
+	// ChangeListener, if set, invalidates Cache on changes made by other servers and needs to be run.
+	ChangeListener *storage.PostgresTaskChangeListener
+	// Replicas, if set, routes reads to the read replicas and needs to be run to check them.
+	Replicas *storage.ReplicaSet
 }
 
-func NewTaskComposite(db *sql.DB, logger *zap.Logger) (*TaskComposite, error) {
-	taskRepository := storage.NewPostgresTaskRepository(db, logger)
+// TaskReplica is a read replica of the Postgres database, connected like the primary: DB always, and
+// Pool as well when tasks are stored through pgx.
+type TaskReplica struct {
+	DB   *sql.DB
+	Pool *pgxpool.Pool
+}
+
+// TaskStore configures how the task composite accesses the tasks of a Store.
+type TaskStore struct {
+	// Pool, if set, stores tasks in Postgres through pgx rather than through the database/sql connection.
+	Pool *pgxpool.Pool
+	// Cache, if set, caches tasks for GetTask. With the "postgres" driver the cache is kept up to date
+	// with changes made by other servers through the notifications of the database named by ChangesDSN.
+	Cache      cache.Store
+	ChangesDSN string
+	// Replicas serve the task reads and searches of the "postgres" driver as Routing configures.
+	Replicas []TaskReplica
+	Routing  storage.ReplicaRouting
+	// TxAttempts is how many times a unit of work runs at most when it conflicts with concurrent
+	// transactions. Postgres units of work run serializable, so such conflicts are expected under load.
+	TxAttempts int
+}
+
+// NewTaskComposite keeps tasks in store, accessed as taskStore configures. Search uses the primary
+// database of store and the read replicas of taskStore.
+func NewTaskComposite(store Store, taskStore TaskStore, projectRepository repository.ProjectRepository, customFieldRepository repository.CustomFieldRepository,
+	idStrategy, searchLanguage string, batchMaxSize int, calendarTokens *grpc.CalendarTokens, logger *zap.Logger) (*TaskComposite, error) {
+	var taskRepository repository.TaskRepository
+	var transactor repository.Transactor
+	if err := store.check(); err != nil {
+		return nil, err
+	}
+	switch {
+	case store.Driver == "memory":
+		taskRepository, transactor = store.Memory.Tasks, store.Memory.Tasks
+	case store.Driver == "sqlite":
+		taskRepository = storage.NewSQLiteTaskRepository(store.SQLite, projectRepository, logger)
+		transactor = storage.NewSQLTransactor(store.SQLite, nil, taskStore.TxAttempts, logger)
+	case taskStore.Pool != nil:
+		taskRepository = storage.NewPgxTaskRepository(taskStore.Pool, logger)
+		transactor = storage.NewPgxTransactor(taskStore.Pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, taskStore.TxAttempts, logger)
+	default:
+		taskRepository = storage.NewPostgresTaskRepository(store.Postgres, logger)
+		transactor = storage.NewSQLTransactor(store.Postgres, &sql.TxOptions{Isolation: sql.LevelSerializable}, taskStore.TxAttempts, logger)
+	}
 	if taskRepository == nil {
 		return nil, errors.New("failed to initialize task repository")
 	}
 
-	taskService := services.NewTaskService(taskRepository, logger)
+	taskSearcher, err := store.taskSearcher(searchLanguage, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicaSet *storage.ReplicaSet
+	if len(taskStore.Replicas) > 0 {
+		if store.Driver != "postgres" {
+			return nil, fmt.Errorf("read replicas need the postgres storage driver, not %q", store.Driver)
+		}
+		replicaDBs := make([]*sql.DB, len(taskStore.Replicas))
+		replicaRepositories := make([]repository.TaskRepository, len(taskStore.Replicas))
+		replicaSearchers := make([]repository.TaskSearcher, len(taskStore.Replicas))
+		for i, replica := range taskStore.Replicas {
+			replicaDBs[i] = replica.DB
+			if taskStore.Pool != nil {
+				replicaRepositories[i] = storage.NewPgxTaskRepository(replica.Pool, logger)
+			} else {
+				replicaRepositories[i] = storage.NewPostgresTaskRepository(replica.DB, logger)
+			}
+			replicaSearchers[i] = storage.NewPostgresTaskSearcher(replica.DB, searchLanguage, logger)
+		}
+		replicaSet = storage.NewReplicaSet(replicaDBs, taskStore.Routing, logger)
+		taskRepository = storage.NewReplicatedTaskRepository(taskRepository, replicaRepositories, replicaSet)
+		taskSearcher = storage.NewReplicatedTaskSearcher(taskSearcher, replicaSearchers, replicaSet)
+	}
+
+	var taskCache *cache.TaskRepository
+	var changeListener *storage.PostgresTaskChangeListener
+	if taskStore.Cache != nil {
+		taskCache = cache.NewTaskRepository(taskRepository, taskStore.Cache, logger)
+		taskRepository = taskCache
+		if store.Driver == "postgres" {
+			changeListener = storage.NewPostgresTaskChangeListener(taskStore.ChangesDSN, taskCache.Invalidate, logger)
+		}
+	}
+
+	idGenerator, err := ids.NewGenerator(idStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	taskService := services.NewTaskService(taskRepository, projectRepository, customFieldRepository, taskSearcher, transactor, idGenerator, logger)
 
 	if taskService == nil {
 		return nil, errors.New("failed to initialize task service")
 	}
-	taskHandler := grpc.NewTaskHandler(taskService, logger)
+	taskHandler := grpc.NewTaskHandler(taskService, batchMaxSize, calendarTokens, logger)
 	if taskHandler == nil {
 		return nil, errors.New("failed to initialize task handler")
 	}
 
 	return &TaskComposite{
-		Repository: taskRepository,
-		Service:    taskService,
-		Handler:    taskHandler,
+		Repository:     taskRepository,
+		Searcher:       taskSearcher,
+		Service:        taskService,
+		Transactor:     transactor,
+		Handler:        taskHandler,
+		Cache:          taskCache,
+		ChangeListener: changeListener,
+		Replicas:       replicaSet,
 	}, nil
 }
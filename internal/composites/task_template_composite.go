@@ -0,0 +1,41 @@
+package composites
+
+import (
+	"errors"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/services"
+	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"go.uber.org/zap"
+)
+
+type TaskTemplateComposite struct {
+	Repository repository.TaskTemplateRepository
+	Service    services.TaskTemplateService
+	Handler    pb.TaskTemplateManagerServer
+}
+
+func NewTaskTemplateComposite(store Store, projectRepository repository.ProjectRepository, taskService services.TaskService,
+	transactor repository.Transactor, logger *zap.Logger) (*TaskTemplateComposite, error) {
+	taskTemplateRepository, err := store.taskTemplateRepository(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	taskTemplateService := services.NewTaskTemplateService(taskTemplateRepository, projectRepository, taskService, transactor, logger)
+	if taskTemplateService == nil {
+		return nil, errors.New("failed to initialize task template service")
+	}
+
+	taskTemplateHandler := grpc.NewTaskTemplateHandler(taskTemplateService, logger)
+	if taskTemplateHandler == nil {
+		return nil, errors.New("failed to initialize task template handler")
+	}
+
+	return &TaskTemplateComposite{
+		Repository: taskTemplateRepository,
+		Service:    taskTemplateService,
+		Handler:    taskTemplateHandler,
+	}, nil
+}
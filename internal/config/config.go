@@ -3,7 +3,13 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/redis"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/sqlite"
 	"github.com/joho/godotenv"
 )
 
@@ -26,6 +32,80 @@ type AppConfig struct {
 	GeneratedPath   string
 	DebugMode       bool
 	EnableProfiling bool
+	// RankMaxLength is the rank key length above which a board column gets rebalanced.
+	RankMaxLength int
+	// RankRebalanceInterval is how often the rank rebalancer looks for columns to rebalance.
+	RankRebalanceInterval time.Duration
+	// ArchiveRetention is how long closed tasks stay unchanged before they are archived. Zero disables archiving.
+	ArchiveRetention time.Duration
+	// ArchiveInterval is how often the task archiver looks for closed tasks to archive.
+	ArchiveInterval time.Duration
+	// ArchiveBatchSize is the number of tasks the archiver moves per transaction; it must be positive.
+	ArchiveBatchSize int
+	// PartitionInterval is how often the task partitioner creates the partitions of the coming months.
+	PartitionInterval time.Duration
+	// IDStrategy selects the format of task external IDs: "ulid" or "uuidv7".
+	IDStrategy string
+	// SearchLanguage is the Postgres text search configuration used to index and search tasks, e.g. "english" or "simple".
+	// The sqlite storage driver supports these two.
+	SearchLanguage string
+	// BatchMaxSize is the largest number of items a batch request may carry.
+	BatchMaxSize int
+	// IdempotencyTTL is how long the response of a request made with an idempotency key is replayed.
+	IdempotencyTTL time.Duration
+	// IdempotencyPurgeInterval is how often expired idempotency keys are deleted.
+	IdempotencyPurgeInterval time.Duration
+	// RPCTimeout is the deadline of unary RPCs whose client set none or a later one; zero disables it.
+	RPCTimeout time.Duration
+	// CalendarFeedSecret signs the tokens of calendar feed subscriptions. Without it, all feeds are refused.
+	CalendarFeedSecret string
+	// CalendarFeedTokenTTL is how long the calendar feed tokens issued by the calendar-token command are valid.
+	CalendarFeedTokenTTL time.Duration
+	// StorageDriver selects where data is stored: "postgres", "sqlite" for a single database file, or "memory" for process
+	// memory without any database, for tests and local development.
+	StorageDriver string
+	// SQLitePath is the database file of the sqlite storage driver.
+	SQLitePath string
+	// SQLiteBusyTimeout is how long an SQLite write waits for the write lock held by another one.
+	SQLiteBusyTimeout time.Duration
+	// CacheBackend selects where GetTask caches tasks: "none", "lru" for process memory, or "redis".
+	CacheBackend string
+	// CacheSize is the number of tasks the lru cache holds.
+	CacheSize int
+	// CacheTTL is how long a task stays cached; zero keeps tasks until they change or are evicted.
+	CacheTTL time.Duration
+	// CacheStatsInterval is how often the cache hit and miss counts are logged.
+	CacheStatsInterval time.Duration
+	// RedisAddr, RedisPassword and RedisDB select the Redis database of the redis cache.
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	// DBDriver selects the driver of the task repository: "pq" for lib/pq through database/sql, or "pgx" for a pgx pool.
+	DBDriver string
+	// DBSSLMode and DBSSLRootCert configure TLS to the database, see postgres.Config.
+	DBSSLMode     string
+	DBSSLRootCert string
+	// DBMaxConns, DBMinConns, DBMaxConnLifetime, DBHealthCheckPeriod and DBStatementCacheCapacity tune the connection pool.
+	DBMaxConns               int
+	DBMinConns               int
+	DBMaxConnLifetime        time.Duration
+	DBHealthCheckPeriod      time.Duration
+	DBStatementCacheCapacity int
+	// DBConnectAttempts and DBConnectRetryInterval control how long connecting to the database is retried at startup.
+	DBConnectAttempts      int
+	DBConnectRetryInterval time.Duration
+	// DBReplicaDSNs are the connection strings of read replicas that serve task reads and searches.
+	DBReplicaDSNs []string
+	// DBReplicaMaxLag is how far a replica may fall behind before reads go to the primary instead.
+	DBReplicaMaxLag time.Duration
+	// DBReplicaCheckInterval is how often the health and lag of the replicas are checked.
+	DBReplicaCheckInterval time.Duration
+	// DBReadYourWritesWindow is how long after a write the reads of the same caller go to the primary.
+	DBReadYourWritesWindow time.Duration
+	// DBTxMaxAttempts is how many times a unit of work runs at most when it conflicts with concurrent transactions.
+	DBTxMaxAttempts int
+	// DBMigrateOnStart applies pending schema migrations at startup; when false the server only checks the schema.
+	DBMigrateOnStart bool
 }
 
 // InitConfig initializes the application configuration by reading environment variables.
@@ -50,8 +130,125 @@ func InitConfig() (*AppConfig, error) {
 			Level:       os.Getenv("LOG_LEVEL"),
 			OutputPaths: []string{"stdout", os.Getenv("LOG_FILE_PATH")},
 		},
-		GeneratedPath:   os.Getenv("GENERATED_PATH"),
-		DebugMode:       os.Getenv("DEBUG_MODE") == "true",
-		EnableProfiling: os.Getenv("ENABLE_PROFILING") == "true",
+		GeneratedPath:            os.Getenv("GENERATED_PATH"),
+		DebugMode:                os.Getenv("DEBUG_MODE") == "true",
+		EnableProfiling:          os.Getenv("ENABLE_PROFILING") == "true",
+		RankMaxLength:            getEnvInt("RANK_MAX_LENGTH", 32),
+		RankRebalanceInterval:    getEnvDuration("RANK_REBALANCE_INTERVAL", 10*time.Minute),
+		ArchiveRetention:         getEnvDuration("ARCHIVE_RETENTION", 0),
+		ArchiveInterval:          getEnvDuration("ARCHIVE_INTERVAL", time.Hour),
+		ArchiveBatchSize:         getEnvInt("ARCHIVE_BATCH_SIZE", 500),
+		PartitionInterval:        getEnvDuration("PARTITION_INTERVAL", 24*time.Hour),
+		IDStrategy:               getEnv("ID_STRATEGY", "ulid"),
+		SearchLanguage:           getEnv("SEARCH_LANGUAGE", "english"),
+		BatchMaxSize:             getEnvInt("BATCH_MAX_SIZE", 500),
+		IdempotencyTTL:           getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyPurgeInterval: getEnvDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour),
+		RPCTimeout:               getEnvDuration("RPC_TIMEOUT", 30*time.Second),
+		CalendarFeedSecret:       os.Getenv("CALENDAR_FEED_SECRET"),
+		CalendarFeedTokenTTL:     getEnvDuration("CALENDAR_FEED_TOKEN_TTL", 90*24*time.Hour),
+		StorageDriver:            getEnv("STORAGE_DRIVER", "postgres"),
+		SQLitePath:               getEnv("SQLITE_PATH", "data/tasks.db"),
+		SQLiteBusyTimeout:        getEnvDuration("SQLITE_BUSY_TIMEOUT", 5*time.Second),
+		CacheBackend:             getEnv("CACHE_BACKEND", "none"),
+		CacheSize:                getEnvInt("CACHE_SIZE", 10000),
+		CacheTTL:                 getEnvDuration("CACHE_TTL", 5*time.Minute),
+		CacheStatsInterval:       getEnvDuration("CACHE_STATS_INTERVAL", 5*time.Minute),
+		RedisAddr:                getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:            os.Getenv("REDIS_PASSWORD"),
+		RedisDB:                  getEnvInt("REDIS_DB", 0),
+		DBDriver:                 getEnv("DB_DRIVER", "pq"),
+		DBSSLMode:                getEnv("DB_SSLMODE", "disable"),
+		DBSSLRootCert:            os.Getenv("DB_SSLROOTCERT"),
+		DBMaxConns:               getEnvInt("DB_MAX_CONNS", 10),
+		DBMinConns:               getEnvInt("DB_MIN_CONNS", 0),
+		DBMaxConnLifetime:        getEnvDuration("DB_MAX_CONN_LIFETIME", time.Hour),
+		DBHealthCheckPeriod:      getEnvDuration("DB_HEALTH_CHECK_PERIOD", time.Minute),
+		DBStatementCacheCapacity: getEnvInt("DB_STATEMENT_CACHE_CAPACITY", 512),
+		DBConnectAttempts:        getEnvInt("DB_CONNECT_ATTEMPTS", 5),
+		DBConnectRetryInterval:   getEnvDuration("DB_CONNECT_RETRY_INTERVAL", 5*time.Second),
+		DBTxMaxAttempts:          getEnvInt("DB_TX_MAX_ATTEMPTS", 3),
+		DBMigrateOnStart:         getEnvBool("DB_MIGRATE_ON_START", true),
+		DBReplicaDSNs:            getEnvList("DB_REPLICA_DSNS"),
+		DBReplicaMaxLag:          getEnvDuration("DB_REPLICA_MAX_LAG", 5*time.Second),
+		DBReplicaCheckInterval:   getEnvDuration("DB_REPLICA_CHECK_INTERVAL", 5*time.Second),
+		DBReadYourWritesWindow:   getEnvDuration("DB_READ_YOUR_WRITES_WINDOW", 10*time.Second),
 	}, nil
 }
+
+// Postgres returns the settings of the database connection.
+func (c *AppConfig) Postgres() postgres.Config {
+	return postgres.Config{
+		User:                   c.DBUser,
+		Password:               c.DBPassword,
+		DBName:                 c.DBName,
+		Host:                   c.DBHost,
+		Port:                   c.DBPort,
+		SSLMode:                c.DBSSLMode,
+		SSLRootCert:            c.DBSSLRootCert,
+		MaxConns:               c.DBMaxConns,
+		MinConns:               c.DBMinConns,
+		MaxConnLifetime:        c.DBMaxConnLifetime,
+		HealthCheckPeriod:      c.DBHealthCheckPeriod,
+		StatementCacheCapacity: c.DBStatementCacheCapacity,
+		ConnectAttempts:        c.DBConnectAttempts,
+		RetryInterval:          c.DBConnectRetryInterval,
+		MigrateOnStart:         c.DBMigrateOnStart,
+	}
+}
+
+// SQLite returns the settings of the SQLite database of the sqlite storage driver.
+func (c *AppConfig) SQLite() sqlite.Config {
+	return sqlite.Config{Path: c.SQLitePath, BusyTimeout: c.SQLiteBusyTimeout}
+}
+
+// Redis returns the settings of the Redis connection of the redis cache.
+func (c *AppConfig) Redis() redis.Config {
+	return redis.Config{Addr: c.RedisAddr, Password: c.RedisPassword, DB: c.RedisDB}
+}
+
+// getEnv reads a string environment variable, falling back to def when it is unset.
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvList reads a comma-separated list from the environment, leaving out blank items.
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when it is unset or invalid.
+func getEnvInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvDuration reads a duration such as "10m" from the environment, falling back to def when it is unset or invalid.
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvBool reads a boolean such as "true" or "0" from the environment, falling back to def when it is unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
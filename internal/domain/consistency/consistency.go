@@ -0,0 +1,86 @@
+// Package consistency carries through contexts what reads need to see of earlier writes, for data
+// layers that serve reads from replicas which may lag behind the primary database or from caches, and
+// which writes are not committed yet.
+package consistency
+
+import (
+	"context"
+	"sync"
+)
+
+type callerKey struct{}
+
+type latestKey struct{}
+
+type unitOfWorkKey struct{}
+
+// WithCaller returns a context for requests made on behalf of caller, an opaque ID of the client such
+// as a session or connection. Reads of a caller see the writes the same caller made before.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// Caller returns the caller set by WithCaller, or "" for requests of unknown callers, which all count
+// as one caller.
+func Caller(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// WithLatest returns a context whose reads see every committed write, such as reads whose results are
+// kept for later.
+func WithLatest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, latestKey{}, true)
+}
+
+// Latest reports whether reads under ctx must see every committed write, which includes all reads of
+// a unit of work.
+func Latest(ctx context.Context) bool {
+	latest, _ := ctx.Value(latestKey{}).(bool)
+	return latest || InUnitOfWork(ctx)
+}
+
+// UnitOfWork is a transaction spanning several repository calls. Its writes are only seen by others once
+// it commits, so what has to follow them, such as dropping cached copies, waits until then.
+type UnitOfWork struct {
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// WithUnitOfWork returns the context for the repository calls of a new unit of work.
+func WithUnitOfWork(ctx context.Context) (context.Context, *UnitOfWork) {
+	unit := &UnitOfWork{}
+	return context.WithValue(ctx, unitOfWorkKey{}, unit), unit
+}
+
+// InUnitOfWork reports whether ctx belongs to a unit of work that has not committed yet.
+func InUnitOfWork(ctx context.Context) bool {
+	_, ok := ctx.Value(unitOfWorkKey{}).(*UnitOfWork)
+	return ok
+}
+
+// AfterCommit runs fn once the unit of work of ctx commits, or right away outside of a unit of work.
+// fn does not run if the unit of work is rolled back.
+func AfterCommit(ctx context.Context, fn func()) {
+	unit, ok := ctx.Value(unitOfWorkKey{}).(*UnitOfWork)
+	if !ok {
+		fn()
+		return
+	}
+	unit.mu.Lock()
+	defer unit.mu.Unlock()
+	unit.afterCommit = append(unit.afterCommit, fn)
+}
+
+// Committed runs the functions passed to AfterCommit for the unit of work, in the order they were passed.
+// Whoever runs the unit of work calls it once the unit of work committed.
+func (u *UnitOfWork) Committed() {
+	u.mu.Lock()
+	afterCommit := u.afterCommit
+	u.afterCommit = nil
+	u.mu.Unlock()
+
+	for _, fn := range afterCommit {
+		fn()
+	}
+}
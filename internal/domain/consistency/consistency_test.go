@@ -0,0 +1,55 @@
+package consistency
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCaller(t *testing.T) {
+	ctx := context.Background()
+	if caller := Caller(ctx); caller != "" {
+		t.Errorf("Caller() = %q without a caller, want \"\"", caller)
+	}
+	if caller := Caller(WithCaller(ctx, "session-1")); caller != "session-1" {
+		t.Errorf("Caller() = %q, want %q", caller, "session-1")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	ctx := context.Background()
+	if Latest(ctx) {
+		t.Error("Latest() = true without WithLatest")
+	}
+	if !Latest(WithLatest(WithCaller(ctx, "session-1"))) {
+		t.Error("Latest() = false after WithLatest")
+	}
+}
+
+func TestUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	var ran []string
+	AfterCommit(ctx, func() { ran = append(ran, "outside") })
+	if len(ran) != 1 {
+		t.Fatalf("AfterCommit() outside of a unit of work ran %d functions, want 1", len(ran))
+	}
+
+	unitCtx, unit := WithUnitOfWork(ctx)
+	if !InUnitOfWork(unitCtx) || InUnitOfWork(ctx) {
+		t.Error("InUnitOfWork() does not tell the unit of work apart")
+	}
+	if !Latest(unitCtx) {
+		t.Error("Latest() = false in a unit of work")
+	}
+	AfterCommit(unitCtx, func() { ran = append(ran, "first") })
+	AfterCommit(unitCtx, func() { ran = append(ran, "second") })
+	if len(ran) != 1 {
+		t.Fatal("AfterCommit() ran before the commit")
+	}
+
+	unit.Committed()
+	unit.Committed()
+	if want := []string{"outside", "first", "second"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
@@ -0,0 +1,149 @@
+// Package ids generates globally unique, time-sortable identifiers for tasks.
+//
+// Two formats are supported: ULIDs such as 01J9ZQ4X8M3V6T2K5R7W0N1B4C and
+// UUIDv7s such as 0192f1a4-5b3c-7d2e-9f10-1a2b3c4d5e6f. Both start with a
+// millisecond timestamp and sort by creation time as plain strings, so they can
+// back keyset pagination. Identifiers of one generator never repeat and keep
+// increasing even when several are generated within the same millisecond.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StrategyULID   = "ulid"
+	StrategyUUIDv7 = "uuidv7"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs; it sorts in ASCII order.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ErrUnknownStrategy = errors.New("unknown ID strategy")
+
+var (
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	uuidv7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+)
+
+// Generator produces new external IDs.
+type Generator interface {
+	NewID() string
+}
+
+// NewGenerator returns the generator for the given strategy. An empty strategy selects ULIDs.
+func NewGenerator(strategy string) (Generator, error) {
+	switch strings.ToLower(strategy) {
+	case "", StrategyULID:
+		return &generator{encode: encodeULID, now: time.Now}, nil
+	case StrategyUUIDv7:
+		return &generator{encode: encodeUUIDv7, now: time.Now}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, strategy)
+	}
+}
+
+// Normalize returns the canonical spelling of a ULID or UUIDv7 and whether id is one.
+// ULIDs are upper-cased and UUIDs lower-cased, as both are case-insensitive.
+func Normalize(id string) (string, bool) {
+	if upper := strings.ToUpper(id); ulidPattern.MatchString(upper) {
+		return upper, true
+	}
+	if lower := strings.ToLower(id); uuidv7Pattern.MatchString(lower) {
+		return lower, true
+	}
+	return "", false
+}
+
+// generator keeps the last timestamp and random bits so that IDs generated within
+// the same millisecond are made monotonic by incrementing the random part.
+type generator struct {
+	mu     sync.Mutex
+	encode func(ms uint64, entropy [10]byte) string
+	now    func() time.Time
+	lastMs uint64
+	last   [10]byte
+}
+
+func (g *generator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms < g.lastMs {
+		// The clock went backwards; stay on the last timestamp to keep IDs increasing.
+		ms = g.lastMs
+	}
+	if ms > g.lastMs || !increment(&g.last) {
+		if _, err := rand.Read(g.last[:]); err != nil {
+			panic(fmt.Sprintf("ids: reading random bytes: %v", err))
+		}
+		// Keep the top bit clear so the next IDs of this millisecond have room to increment.
+		g.last[0] &= 0x7f
+	}
+	g.lastMs = ms
+
+	return g.encode(ms, g.last)
+}
+
+// increment adds one to the big-endian number in b and reports whether it did not overflow.
+func increment(b *[10]byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID writes the 48-bit timestamp and 80 random bits as 26 Crockford base32 digits.
+func encodeULID(ms uint64, entropy [10]byte) string {
+	var raw [16]byte
+	raw[0] = byte(ms >> 40)
+	raw[1] = byte(ms >> 32)
+	binary.BigEndian.PutUint32(raw[2:6], uint32(ms))
+	copy(raw[6:], entropy[:])
+
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// encodeUUIDv7 lays out the timestamp and random bits as described in RFC 9562.
+// The version and variant fields overwrite six of the random bits.
+func encodeUUIDv7(ms uint64, entropy [10]byte) string {
+	var raw [16]byte
+	raw[0] = byte(ms >> 40)
+	raw[1] = byte(ms >> 32)
+	binary.BigEndian.PutUint32(raw[2:6], uint32(ms))
+	copy(raw[6:], entropy[:])
+	raw[6] = 0x70 | raw[6]&0x0f
+	raw[8] = 0x80 | raw[8]&0x3f
+
+	var out [36]byte
+	hex.Encode(out[0:8], raw[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], raw[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], raw[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], raw[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], raw[10:])
+	return string(out[:])
+}
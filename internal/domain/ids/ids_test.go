@@ -0,0 +1,99 @@
+package ids
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		length   int
+	}{
+		{name: "Default", strategy: "", length: 26},
+		{name: "ULID", strategy: "ulid", length: 26},
+		{name: "UUIDv7", strategy: "UUIDv7", length: 36},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := NewGenerator(tt.strategy)
+			if err != nil {
+				t.Fatalf("NewGenerator() unexpected error: %v", err)
+			}
+			id := gen.NewID()
+			if len(id) != tt.length {
+				t.Errorf("NewID() = %q, want length %d", id, tt.length)
+			}
+			if normalized, ok := Normalize(id); !ok || normalized != id {
+				t.Errorf("Normalize(%q) = %q, %v, want the ID itself", id, normalized, ok)
+			}
+		})
+	}
+
+	if _, err := NewGenerator("serial"); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("NewGenerator() error = %v, want %v", err, ErrUnknownStrategy)
+	}
+}
+
+func TestGenerator_Monotonic(t *testing.T) {
+	for _, strategy := range []string{StrategyULID, StrategyUUIDv7} {
+		t.Run(strategy, func(t *testing.T) {
+			gen, _ := NewGenerator(strategy)
+			fixed := time.UnixMilli(1767225600000)
+			gen.(*generator).now = func() time.Time { return fixed }
+
+			prev := gen.NewID()
+			for i := 0; i < 1000; i++ {
+				if i == 500 {
+					// A clock going backwards must not break the order either.
+					fixed = fixed.Add(-time.Second)
+				}
+				id := gen.NewID()
+				if id <= prev {
+					t.Fatalf("NewID() = %q, want it to sort after %q", id, prev)
+				}
+				prev = id
+			}
+		})
+	}
+}
+
+func TestEncodeTimestamp(t *testing.T) {
+	var entropy [10]byte
+	ms := uint64(1767225600000) // 2026-01-01T00:00:00Z
+
+	if got, want := encodeULID(ms, entropy), "01KDVDNA000000000000000000"; got != want {
+		t.Errorf("encodeULID() = %q, want %q", got, want)
+	}
+	if got, want := encodeUUIDv7(ms, entropy), "019b76da-a800-7000-8000-000000000000"; got != want {
+		t.Errorf("encodeUUIDv7() = %q, want %q", got, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+		ok   bool
+	}{
+		{name: "ULID", id: "01KDVDNA000000000000000000", want: "01KDVDNA000000000000000000", ok: true},
+		{name: "Lower-case ULID", id: "01kdvdna000000000000000000", want: "01KDVDNA000000000000000000", ok: true},
+		{name: "ULID with excluded letter", id: "01KDVDNA00000000000000000I", ok: false},
+		{name: "UUIDv7", id: "019B76DA-A800-7000-8000-000000000000", want: "019b76da-a800-7000-8000-000000000000", ok: true},
+		{name: "UUIDv4", id: "019b76da-a800-4000-8000-000000000000", ok: false},
+		{name: "Numeric ID", id: "42", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Normalize(tt.id)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.id, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Custom field types.
+const (
+	FieldTypeString = "string"
+	FieldTypeNumber = "number"
+	FieldTypeEnum   = "enum"
+	FieldTypeDate   = "date"
+	FieldTypeUser   = "user"
+)
+
+// FieldTypes lists the supported custom field types.
+var FieldTypes = []string{FieldTypeString, FieldTypeNumber, FieldTypeEnum, FieldTypeDate, FieldTypeUser}
+
+// CustomField defines an extra typed field that the tasks of a project can carry.
+// The validation rules that apply depend on Type: Options for enums, Min and Max for numbers
+// and MaxLength for strings. The name cannot change after creation.
+type CustomField struct {
+	ID        int64    `json:"id"`
+	ProjectID int64    `json:"project_id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+	// MaxLength limits the length of string values; zero means no limit.
+	MaxLength int       `json:"max_length"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FieldValue is the value of a custom field on a task. Numbers are held in Number and all other
+// types, dates as YYYY-MM-DD, in String. A zero FieldValue clears the field in task updates.
+type FieldValue struct {
+	Type   string
+	String string
+	Number float64
+}
+
+// Raw returns the value as stored: a float64 for numbers and a string otherwise.
+func (v FieldValue) Raw() any {
+	if v.Type == FieldTypeNumber {
+		return v.Number
+	}
+	return v.String
+}
+
+// FieldCondition restricts ListTasks to tasks whose custom field compares to Value with Operator,
+// one of =, !=, <, <=, > and >=. Tasks without a value for the field never match.
+type FieldCondition struct {
+	Field    string
+	Type     string
+	Operator string
+	Value    FieldValue
+}
+
+// FieldOrder sorts ListTasks by a custom field; tasks without a value come last.
+type FieldOrder struct {
+	Field      string
+	Type       string
+	Descending bool
+}
+
+// FieldValueRef is the value a task of a project holds for a custom field, or nil if it has none.
+type FieldValueRef struct {
+	TaskID  int64
+	TaskKey string
+	Value   *FieldValue
+}
+
+// FieldConflict describes a task whose value does not satisfy a changed custom field definition.
+type FieldConflict struct {
+	TaskID  int64
+	TaskKey string
+	Reason  string
+}
@@ -0,0 +1,33 @@
+package models
+
+// Types of the task fields a filter expression can refer to. Custom fields keep their own FieldType.
+const (
+	FilterTypeString    = "string"
+	FilterTypeInteger   = "integer"
+	FilterTypeTimestamp = "timestamp"
+	FilterTypeList      = "list"
+)
+
+// Logical operators of a FilterExpr.
+const (
+	FilterAnd = "AND"
+	FilterOr  = "OR"
+	FilterNot = "NOT"
+)
+
+// FilterExpr is a node of a checked ListTasks filter expression. A logical node combines its
+// Operands with Logic; any other node compares a task field with a value.
+//
+// Field is a task column such as "status", or the name of a custom field when Custom is set, and Type
+// is the field's filter type or custom field type. Operator is one of =, !=, <, <=, >, >= and ":", which
+// tests whether a text contains the value, a list has it as an element or, with a nil Value, whether the
+// field is set at all. Value is a string, int64 or time.Time, or a FieldValue for custom fields.
+type FilterExpr struct {
+	Logic    string
+	Operands []*FilterExpr
+	Field    string
+	Custom   bool
+	Type     string
+	Operator string
+	Value    any
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers a mutating request made under an idempotency key and, once the request
+// succeeded, its response. RequestHash identifies the request; ResponseType names the type of the
+// encoded Response. Both response fields are empty while the request is in progress.
+type IdempotencyRecord struct {
+	Key          string
+	RequestHash  []byte
+	ResponseType string
+	Response     []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Completed tells whether the request of the record succeeded and its response was stored.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.ResponseType != ""
+}
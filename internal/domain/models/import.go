@@ -0,0 +1,36 @@
+package models
+
+// TaskImport describes an import of tasks into a project.
+// Source names where the tasks come from, such as "github"; together with the ID of a record there it
+// identifies records imported before, which later imports of the same source skip. A dry run validates
+// the records without storing anything.
+type TaskImport struct {
+	ProjectID int64
+	Source    string
+	DryRun    bool
+}
+
+// ImportRow is a record read from an import. Row is its 1-based position among the records and SourceID
+// its ID in the source. Err tells why the record could not be read or turned into a task.
+type ImportRow struct {
+	Row      int
+	SourceID string
+	Task     *Task
+	Err      error
+}
+
+// ImportRowError reports a record that was not imported.
+type ImportRowError struct {
+	Row      int
+	SourceID string
+	Message  string
+}
+
+// ImportSummary counts the records of an import: those created, or valid in a dry run, those skipped
+// because an earlier import created them, and those that failed, the first of which are listed in Errors.
+type ImportSummary struct {
+	Created int
+	Skipped int
+	Failed  int
+	Errors  []ImportRowError
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultWorkflow is the status workflow used by projects that do not define their own
+// and by tasks that do not belong to any project. The first status is assigned to new tasks
+// and tasks in the last status are closed.
+var DefaultWorkflow = []string{"open", "in_progress", "done"}
+
+type Project struct {
+	ID int64 `json:"id"`
+	// Key prefixes the keys of the project's tasks, e.g. OPS for OPS-123. It cannot change after creation.
+	Key             string    `json:"key"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	Workflow        []string  `json:"workflow"`
+	DefaultAssignee string    `json:"default_assignee"`
+	AllowedLabels   []string  `json:"allowed_labels"`
+	Archived        bool      `json:"archived"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// InitialStatus returns the status assigned to new tasks of the project.
+func (p *Project) InitialStatus() string {
+	if len(p.Workflow) == 0 {
+		return DefaultWorkflow[0]
+	}
+	return p.Workflow[0]
+}
+
+// ClosedStatus returns the status of the project's closed tasks, the last of its workflow.
+func (p *Project) ClosedStatus() string {
+	if len(p.Workflow) == 0 {
+		return DefaultWorkflow[len(DefaultWorkflow)-1]
+	}
+	return p.Workflow[len(p.Workflow)-1]
+}
+
+// AllowsStatus reports whether status is part of the project's workflow.
+func (p *Project) AllowsStatus(status string) bool {
+	if len(p.Workflow) == 0 {
+		return slices.Contains(DefaultWorkflow, status)
+	}
+	return slices.Contains(p.Workflow, status)
+}
+
+// AllowsLabel reports whether label may be attached to the project's tasks.
+// Projects without an allowed labels list accept any label.
+func (p *Project) AllowsLabel(label string) bool {
+	return len(p.AllowedLabels) == 0 || slices.Contains(p.AllowedLabels, label)
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Parts of a saved view that can break when the custom fields or labels they refer to go away.
+const (
+	ViewPartFilter  = "filter"
+	ViewPartOrderBy = "order_by"
+	ViewPartColumns = "columns"
+)
+
+// SavedView is a named ListTasks query that UIs and the CLI share: a filter expression, an optional
+// custom field order and the columns to show. A view is visible to its owner only unless it is shared;
+// only the owner can change it. A view with a ProjectID lists the tasks of that project only.
+type SavedView struct {
+	ID        int64       `json:"id"`
+	Owner     string      `json:"owner"`
+	Name      string      `json:"name"`
+	ProjectID int64       `json:"project_id"`
+	Filter    string      `json:"filter"`
+	OrderBy   *FieldOrder `json:"order_by"`
+	Columns   []string    `json:"columns"`
+	Shared    bool        `json:"shared"`
+	// Problems lists the broken parts of the view. It is not stored but determined whenever the view is read.
+	Problems  []ViewProblem `json:"problems"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ViewProblem describes why a part of a saved view, one of the ViewPart constants, no longer works.
+type ViewProblem struct {
+	Part    string
+	Message string
+}
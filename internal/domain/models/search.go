@@ -0,0 +1,37 @@
+package models
+
+// SearchTerm is one part of a search query. A task matches a query when it contains every term
+// that is not excluded and none of the excluded ones.
+type SearchTerm struct {
+	Text string
+	// Phrase requires the words of Text to appear next to each other, in this order.
+	Phrase bool
+	// Prefix matches any word starting with Text.
+	Prefix  bool
+	Exclude bool
+}
+
+// TaskSearch is a full-text search over task titles and descriptions. Query is the text entered by
+// the user, which the service parses into Terms. A zero ProjectID searches all projects.
+type TaskSearch struct {
+	Query     string
+	Terms     []SearchTerm
+	ProjectID int64
+	Limit     int
+	After     *SearchCursor
+}
+
+// SearchCursor is the position of a hit in the search order, which is by descending score and then by external ID.
+type SearchCursor struct {
+	Score      float64
+	ExternalID string
+}
+
+// SearchHit is a task matching a search, with its relevance score and excerpts of its title and
+// description in which the matching words are highlighted.
+type SearchHit struct {
+	Task               *Task
+	Score              float64
+	TitleSnippet       string
+	DescriptionSnippet string
+}
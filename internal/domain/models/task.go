@@ -5,9 +5,161 @@ import (
 )
 
 type Task struct {
+	ID int64 `json:"id"`
+	// ExternalID is a globally unique, time-sortable ULID or UUIDv7 that is safe to share across deployments.
+	ExternalID string `json:"external_id"`
+	ProjectID  int64  `json:"project_id"`
+	// Key is the human-readable identifier such as OPS-123. Tasks outside of any project have no key.
+	Key string `json:"key"`
+	// ParentID is the task this task is a subtask of, or zero for a top-level task. Subtasks are in the
+	// project of their parent.
+	ParentID    int64    `json:"parent_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Assignee    string   `json:"assignee"`
+	Labels      []string `json:"labels"`
+	Rank        string   `json:"rank"`
+	// CustomFields holds the values of the custom fields defined by the task's project, keyed by field name.
+	CustomFields map[string]FieldValue `json:"custom_fields"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	// ArchivedAt is when the task was moved to the archive, or zero for tasks that are not archived.
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// TaskRef identifies a task by its numeric ID, its key or its external ID.
+type TaskRef struct {
+	ID         int64
+	Key        string
+	ExternalID string
+}
+
+// Types of task relations.
+const (
+	RelationBlocks     = "blocks"
+	RelationRelatesTo  = "relates_to"
+	RelationDuplicates = "duplicates"
+)
+
+// RelationTypes lists the valid relation types.
+var RelationTypes = []string{RelationBlocks, RelationRelatesTo, RelationDuplicates}
+
+// TaskRelation links two tasks, such as a task that blocks another one. The relation reads from TaskID
+// to RelatedTaskID: TaskID blocks, relates to or duplicates RelatedTaskID.
+type TaskRelation struct {
+	TaskID        int64  `json:"task_id"`
+	RelatedTaskID int64  `json:"related_task_id"`
+	Type          string `json:"type"`
+}
+
+// ChecklistItem is an item of the checklist of a task. A checklist keeps its items in order.
+type ChecklistItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// TaskComment is a comment on a task. Comments are listed in the order they were made.
+type TaskComment struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TaskAttachment is a file attached to a task. The file itself is kept elsewhere and linked by its URL.
+type TaskAttachment struct {
 	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
+	TaskID      int64     `json:"task_id"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
 	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaskContent selects what a task holds besides its own fields.
+type TaskContent struct {
+	Checklist   bool
+	Comments    bool
+	Attachments bool
+}
+
+// TaskFilter narrows down the tasks returned by ListTasks.
+// A zero ProjectID lists tasks across all projects and an empty Status lists all statuses.
+// A positive Limit returns at most that many tasks, starting after the After cursor when it is set.
+// CustomFields and OrderBy, which refer to custom fields, require a ProjectID.
+// Expression is a filter in AIP-160 syntax that the service checks and parses into Where.
+// IncludeArchived adds the archived tasks to the live ones.
+type TaskFilter struct {
+	ProjectID       int64
+	Status          string
+	CustomFields    []FieldCondition
+	Expression      string
+	Where           *FilterExpr
+	OrderBy         *FieldOrder
+	Limit           int
+	After           *TaskCursor
+	IncludeArchived bool
+}
+
+// TaskExport selects the tasks of an export and the columns to export. Columns are task fields such as
+// "title" or custom fields of the filtered project prefixed with "custom_fields.".
+type TaskExport struct {
+	Filter  TaskFilter
+	Columns []string
+}
+
+// TaskCursor is the position of a task in the ListTasks order, which is by the OrderBy field when set,
+// then by rank and then by external ID. SortValue is the task's raw OrderBy value, or nil if it has none.
+type TaskCursor struct {
+	SortValue  any
+	Rank       string
+	ExternalID string
+}
+
+// Column identifies a board column: the tasks of one project that share a status.
+// Tasks within a column are ordered by their rank.
+type Column struct {
+	ProjectID int64
+	Status    string
+}
+
+// TaskMove describes a drag-and-drop move of a task on the board.
+// The task is placed right after AfterID and right before BeforeID in the column of Status;
+// zero neighbour IDs leave that side open, and without any neighbour the task goes to the end of the column.
+type TaskMove struct {
+	TaskID    int64
+	ProjectID int64
+	Status    string
+	AfterID   int64
+	BeforeID  int64
+}
+
+// TaskClone describes a copy of a task. The copy gets a new ID, key and external ID and always takes
+// the title, description and assignee of the task; everything else is copied on request.
+type TaskClone struct {
+	TaskID int64
+	// ProjectID is the project to create the copy in, or nil for the project of the task.
+	ProjectID *int64
+	// Title replaces the title of the task when set.
+	Title        string
+	Labels       bool
+	CustomFields bool
+	// Subtasks copies the subtasks of the task, and theirs, under the copy. The copied subtasks take the
+	// labels, custom field values and content that are copied of the task, and the relations between the
+	// tasks of the copied tree are copied along.
+	Subtasks    bool
+	Checklist   bool
+	Comments    bool
+	Attachments bool
+}
+
+// TaskTransfer describes the move of a task to another project. A zero ProjectID moves the task out of
+// any project. With Subtree the subtasks of the task, and theirs, move along and stay its subtasks.
+type TaskTransfer struct {
+	TaskID    int64
+	ProjectID int64
+	Subtree   bool
 }
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// TaskTemplate is a reusable set of tasks of a project, such as the steps of an onboarding, that are
+// created together by instantiating it. Blueprints may contain {{variable}} placeholders for the
+// declared Variables, which are filled in on instantiation. Every change of a template creates a new
+// Version; older versions stay available, and tasks created from a template do not change with it.
+type TaskTemplate struct {
+	ID          int64           `json:"id"`
+	ProjectID   int64           `json:"project_id"`
+	Name        string          `json:"name"`
+	Version     int             `json:"version"`
+	Description string          `json:"description"`
+	Variables   []string        `json:"variables"`
+	Tasks       []TaskBlueprint `json:"tasks"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TaskBlueprint describes a task a template creates, along with its subtasks. Empty fields take the
+// project defaults, as in CreateTask. Placeholders may appear in all text, but not in number custom field
+// values, refs or relations.
+type TaskBlueprint struct {
+	// Ref names the blueprint within its template, so that relations of other blueprints can point at it.
+	// It is only required of the blueprints relations point at.
+	Ref          string                `json:"ref"`
+	Title        string                `json:"title"`
+	Description  string                `json:"description"`
+	Status       string                `json:"status"`
+	Assignee     string                `json:"assignee"`
+	Labels       []string              `json:"labels"`
+	CustomFields map[string]FieldValue `json:"custom_fields"`
+	// Subtasks are created as subtasks of the task.
+	Subtasks []TaskBlueprint `json:"subtasks"`
+	// Relations link the task to other tasks of the template.
+	Relations []BlueprintRelation `json:"relations"`
+}
+
+// BlueprintRelation is a relation of the given type from the task of a blueprint to the task of the
+// blueprint named Ref, see TaskRelation.
+type BlueprintRelation struct {
+	Type string `json:"type"`
+	Ref  string `json:"ref"`
+}
@@ -0,0 +1,118 @@
+// Package rank generates lexicographically ordered keys for manual ordering.
+//
+// Keys are fractional base-36 numbers written without the leading "0.", so a key
+// can always be generated between any two existing keys and moving an item only
+// rewrites the item itself. Keys never end in the zero digit, which keeps room
+// for a key before every other key.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrInvalidKey   = errors.New("invalid rank key")
+	ErrInvalidRange = errors.New("lower rank key must sort before upper rank key")
+)
+
+// Between returns a key that sorts strictly between lower and upper.
+// An empty lower means the start of the list and an empty upper means its end.
+func Between(lower, upper string) (string, error) {
+	if err := validate(lower); err != nil {
+		return "", err
+	}
+	if err := validate(upper); err != nil {
+		return "", err
+	}
+	if upper != "" && lower >= upper {
+		return "", ErrInvalidRange
+	}
+	return midpoint(lower, upper), nil
+}
+
+// Spread returns count evenly spaced keys of equal length, for rebalancing a whole list.
+func Spread(count int) []string {
+	if count <= 0 {
+		return nil
+	}
+
+	width, space := 1, len(digits)
+	for space <= count {
+		width++
+		space *= len(digits)
+	}
+	step := space / (count + 1)
+
+	keys := make([]string, count)
+	for i := range keys {
+		keys[i] = strings.TrimRight(encode((i+1)*step, width), digits[:1])
+	}
+	return keys
+}
+
+func midpoint(lower, upper string) string {
+	if upper != "" {
+		n := 0
+		for n < len(upper) && digitAt(lower, n) == upper[n] {
+			n++
+		}
+		if n > 0 {
+			return upper[:n] + midpoint(suffix(lower, n), upper[n:])
+		}
+	}
+
+	digitLower := 0
+	if lower != "" {
+		digitLower = strings.IndexByte(digits, lower[0])
+	}
+	digitUpper := len(digits)
+	if upper != "" {
+		digitUpper = strings.IndexByte(digits, upper[0])
+	}
+
+	if digitUpper-digitLower > 1 {
+		return string(digits[(digitLower+digitUpper+1)/2])
+	}
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+	return string(digits[digitLower]) + midpoint(suffix(lower, 1), "")
+}
+
+func validate(key string) error {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return ErrInvalidKey
+		}
+	}
+	if strings.HasSuffix(key, digits[:1]) {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func suffix(key string, n int) string {
+	if n >= len(key) {
+		return ""
+	}
+	return key[n:]
+}
+
+func encode(value, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[value%len(digits)]
+		value /= len(digits)
+	}
+	return string(buf)
+}
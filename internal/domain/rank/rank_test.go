@@ -0,0 +1,85 @@
+package rank
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name  string
+		lower string
+		upper string
+	}{
+		{name: "Empty list", lower: "", upper: ""},
+		{name: "Before first", lower: "", upper: "i"},
+		{name: "After last", lower: "i", upper: ""},
+		{name: "Between distant keys", lower: "a", upper: "z"},
+		{name: "Between adjacent digits", lower: "a", upper: "b"},
+		{name: "Between key and its extension", lower: "a", upper: "a1"},
+		{name: "Between shared prefix", lower: "abc", upper: "abd"},
+		{name: "Before smallest digit", lower: "", upper: "01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Between(tt.lower, tt.upper)
+			if err != nil {
+				t.Fatalf("Between() unexpected error: %v", err)
+			}
+			if got <= tt.lower || (tt.upper != "" && got >= tt.upper) {
+				t.Errorf("Between(%q, %q) = %q, not strictly between", tt.lower, tt.upper, got)
+			}
+			if validate(got) != nil {
+				t.Errorf("Between(%q, %q) = %q, not a valid key", tt.lower, tt.upper, got)
+			}
+		})
+	}
+}
+
+func TestBetween_Errors(t *testing.T) {
+	if _, err := Between("b", "a"); err != ErrInvalidRange {
+		t.Errorf("Between() error = %v, want %v", err, ErrInvalidRange)
+	}
+	if _, err := Between("a0", ""); err != ErrInvalidKey {
+		t.Errorf("Between() error = %v, want %v", err, ErrInvalidKey)
+	}
+	if _, err := Between("A", ""); err != ErrInvalidKey {
+		t.Errorf("Between() error = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
+func TestBetween_RepeatedInserts(t *testing.T) {
+	lower, upper := "", ""
+	for i := 0; i < 200; i++ {
+		key, err := Between(lower, upper)
+		if err != nil {
+			t.Fatalf("Between() unexpected error at step %d: %v", i, err)
+		}
+		if i%2 == 0 {
+			lower = key
+		} else {
+			upper = key
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, count := range []int{1, 35, 36, 1000} {
+		keys := Spread(count)
+		if len(keys) != count {
+			t.Fatalf("Spread(%d) returned %d keys", count, len(keys))
+		}
+		if !sort.StringsAreSorted(keys) {
+			t.Errorf("Spread(%d) keys are not sorted", count)
+		}
+		for i, key := range keys {
+			if validate(key) != nil || key == "" {
+				t.Errorf("Spread(%d) key %d = %q is not valid", count, i, key)
+			}
+			if i > 0 && keys[i-1] == key {
+				t.Errorf("Spread(%d) key %d duplicates its predecessor", count, i)
+			}
+		}
+	}
+}
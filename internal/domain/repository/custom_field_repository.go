@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+type CustomFieldRepository interface {
+	CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error)
+	ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error)
+	GetCustomField(ctx context.Context, id int64) (*models.CustomField, error)
+	UpdateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error)
+	// DeleteCustomField removes the definition together with the values tasks hold for it.
+	DeleteCustomField(ctx context.Context, id int64) error
+	// ListFieldValues returns, for every task of the project, the value it holds for the named field.
+	ListFieldValues(ctx context.Context, projectID int64, name string) ([]models.FieldValueRef, error)
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "errors"
+
+// ErrAlreadyExists is returned when a write violates a uniqueness constraint, such as a project key that is taken.
+var ErrAlreadyExists = errors.New("record already exists")
+
+// ErrNotEmpty is returned when deleting a record that others still belong to, such as a project that has tasks.
+var ErrNotEmpty = errors.New("record is not empty")
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+type IdempotencyRepository interface {
+	// ClaimKey atomically stores record unless its key is held by another record that has not expired and
+	// is either completed or was claimed after staleBefore, or at all when staleBefore is zero. It returns
+	// nil when the key was claimed, setting the CreatedAt of record as stored, and the record holding the
+	// key otherwise.
+	ClaimKey(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, error)
+	// CompleteKey stores the response of the request that made claim, or returns sql.ErrNoRows if the
+	// claim no longer holds its key.
+	CompleteKey(ctx context.Context, claim *models.IdempotencyRecord, responseType string, response []byte) error
+	// ReleaseKey gives up claim, made by a request that failed, so that it can be retried. It leaves the
+	// key alone once another request claimed it. Completed records are kept.
+	ReleaseKey(ctx context.Context, claim *models.IdempotencyRecord) error
+	// DeleteExpiredKeys removes the records that expired before now and returns how many there were.
+	DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error)
+}
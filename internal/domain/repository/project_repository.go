@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+type ProjectRepository interface {
+	CreateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	ListProjects(ctx context.Context) ([]*models.Project, error)
+	GetProject(ctx context.Context, id int64) (*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	// DeleteProject deletes the project unless it has tasks, in which case it returns ErrNotEmpty. The
+	// check and the deletion are atomic: no task is created in the project between them.
+	DeleteProject(ctx context.Context, id int64) error
+}
@@ -0,0 +1,1041 @@
+// Package repositorytest holds conformance tests that every implementation of the repository
+// interfaces must pass, so that the storage backends stay interchangeable.
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Fixture is a task repository under test together with a way to create projects for its tasks.
+type Fixture struct {
+	Repository repository.TaskRepository
+	// NewProject creates a project the repository can give task keys of, returning its ID and key.
+	// Every call must return a project no other test uses, as the tests may share a database.
+	NewProject func(t *testing.T) (int64, string)
+	// Transactor, if set, runs units of work of Repository, which are then tested as well.
+	Transactor repository.Transactor
+}
+
+// TestTaskRepository runs the conformance tests for task repositories, calling setup once per test.
+// The tests only create tasks in the projects of NewProject and only list tasks of those projects.
+func TestTaskRepository(t *testing.T, setup func(t *testing.T) Fixture) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, f Fixture)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"CreateTasks", testCreateTasks},
+		{"Lookups", testLookups},
+		{"Imports", testImports},
+		{"Transfer", testTransfer},
+		{"Subtasks", testSubtasks},
+		{"Relations", testRelations},
+		{"Content", testContent},
+		{"ListTasks", testListTasks},
+		{"FilterExpressions", testFilterExpressions},
+		{"Pagination", testPagination},
+		{"Ranks", testRanks},
+		{"UnitsOfWork", testUnitsOfWork},
+		{"Archive", testArchive},
+		{"ArchiveRecords", testArchiveRecords},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, setup(t))
+		})
+	}
+}
+
+// newTask returns a task of project whose external ID is unique to the project and sorts by n.
+func newTask(projectKey string, projectID int64, n int) *models.Task {
+	return &models.Task{
+		ExternalID:  fmt.Sprintf("%s-%04d", projectKey, n),
+		ProjectID:   projectID,
+		Title:       fmt.Sprintf("Task %d", n),
+		Description: "Conformance test task",
+		Status:      "open",
+		Labels:      []string{"conformance"},
+		Rank:        fmt.Sprintf("m%04d", n),
+	}
+}
+
+func testCreateAndGet(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	first := newTask(projectKey, projectID, 1)
+	first.Assignee = "alice"
+	first.CustomFields = map[string]models.FieldValue{
+		"points": {Type: models.FieldTypeNumber, Number: 3},
+		"team":   {Type: models.FieldTypeString, String: "core"},
+	}
+	created, err := f.Repository.CreateTask(ctx, first)
+	require.NoError(t, err)
+	assert.NotZero(t, created.ID)
+	assert.Equal(t, projectKey+"-1", created.Key)
+	assert.False(t, created.CreatedAt.IsZero())
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+
+	second := newTask(projectKey, projectID, 2)
+	second.Labels = nil
+	created2, err := f.Repository.CreateTask(ctx, second)
+	require.NoError(t, err)
+	assert.Greater(t, created2.ID, created.ID)
+	assert.Equal(t, projectKey+"-2", created2.Key)
+
+	task, err := f.Repository.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ExternalID, task.ExternalID)
+	assert.Equal(t, projectID, task.ProjectID)
+	assert.Equal(t, projectKey+"-1", task.Key)
+	assert.Equal(t, "Task 1", task.Title)
+	assert.Equal(t, "Conformance test task", task.Description)
+	assert.Equal(t, "open", task.Status)
+	assert.Equal(t, "alice", task.Assignee)
+	assert.Equal(t, []string{"conformance"}, task.Labels)
+	assert.Equal(t, "m0001", task.Rank)
+	assert.Equal(t, first.CustomFields, task.CustomFields)
+	assert.False(t, task.CreatedAt.IsZero())
+
+	task, err = f.Repository.GetTask(ctx, created2.ID)
+	require.NoError(t, err)
+	assert.Empty(t, task.Labels)
+	assert.Empty(t, task.CustomFields)
+
+	// Changing a returned task must not change the stored one.
+	task.Title = "Changed locally"
+	task, err = f.Repository.GetTask(ctx, created2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Task 2", task.Title)
+}
+
+func testUpdate(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	created, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 1))
+	require.NoError(t, err)
+
+	task, err := f.Repository.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	task.Title = "Updated"
+	task.Status = "done"
+	task.Labels = []string{"a", "b"}
+	task.CustomFields = map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 5}}
+	// Updates leave the key and external ID alone.
+	task.Key = "OTHER-1"
+	task.ExternalID = "other"
+	updated, err := f.Repository.UpdateTask(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, projectKey+"-1", updated.Key)
+	assert.Equal(t, created.ExternalID, updated.ExternalID)
+	assert.Equal(t, projectID, updated.ProjectID)
+
+	task, err = f.Repository.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", task.Title)
+	assert.Equal(t, "done", task.Status)
+	assert.Equal(t, []string{"a", "b"}, task.Labels)
+	assert.Equal(t, float64(5), task.CustomFields["points"].Number)
+	assert.Equal(t, projectKey+"-1", task.Key)
+	assert.False(t, task.UpdatedAt.Before(task.CreatedAt))
+
+	missing := *task
+	missing.ID = deletedTaskID(t, f, projectKey, projectID)
+	_, err = f.Repository.UpdateTask(ctx, &missing)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// A batch with a missing task stores none of its updates.
+	task.Title = "Not stored"
+	_, err = f.Repository.UpdateTasks(ctx, []*models.Task{task, &missing})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	task, err = f.Repository.GetTask(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", task.Title)
+}
+
+func testDelete(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	tasks, err := f.Repository.CreateTasks(ctx, []*models.Task{newTask(projectKey, projectID, 1), newTask(projectKey, projectID, 2)})
+	require.NoError(t, err)
+	missingID := deletedTaskID(t, f, projectKey, projectID)
+
+	// A batch with a missing task deletes none of its tasks.
+	assert.ErrorIs(t, f.Repository.DeleteTasks(ctx, []int64{tasks[0].ID, missingID}), sql.ErrNoRows)
+	_, err = f.Repository.GetTask(ctx, tasks[0].ID)
+	assert.NoError(t, err)
+
+	require.NoError(t, f.Repository.DeleteTask(ctx, tasks[0].ID))
+	_, err = f.Repository.GetTask(ctx, tasks[0].ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, f.Repository.DeleteTask(ctx, tasks[0].ID), sql.ErrNoRows)
+
+	require.NoError(t, f.Repository.DeleteTasks(ctx, []int64{tasks[1].ID}))
+	_, err = f.Repository.GetTaskIDByKey(ctx, tasks[1].Key)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testCreateTasks(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	tasks := make([]*models.Task, 3)
+	for i := range tasks {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+	}
+	created, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+	require.Len(t, created, 3)
+	ids := make(map[int64]bool)
+	for i, task := range created {
+		assert.Equal(t, fmt.Sprintf("%s-%d", projectKey, i+1), task.Key)
+		assert.Equal(t, created[0].CreatedAt, task.CreatedAt)
+		ids[task.ID] = true
+	}
+	assert.Len(t, ids, 3)
+
+	// An external ID that is taken fails the whole batch, giving back the keys it allocated.
+	_, err = f.Repository.CreateTasks(ctx, []*models.Task{newTask(projectKey, projectID, 4), newTask(projectKey, projectID, 1)})
+	assert.Error(t, err)
+	_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0004")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	task, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 5))
+	require.NoError(t, err)
+	assert.Equal(t, projectKey+"-4", task.Key)
+}
+
+func testLookups(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	created, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 1))
+	require.NoError(t, err)
+
+	id, err := f.Repository.GetTaskIDByKey(ctx, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, id)
+	_, err = f.Repository.GetTaskIDByKey(ctx, projectKey+"-99")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	id, err = f.Repository.GetTaskIDByExternalID(ctx, created.ExternalID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, id)
+	_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-9999")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = f.Repository.GetTask(ctx, deletedTaskID(t, f, projectKey, projectID))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testImports(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+	source := "conformance-" + projectKey
+
+	imported, err := f.Repository.ImportedSourceIDs(ctx, source, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Empty(t, imported)
+
+	tasks := []*models.Task{newTask(projectKey, projectID, 1), newTask(projectKey, projectID, 2)}
+	created, err := f.Repository.CreateImportedTasks(ctx, source, tasks, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, projectKey+"-2", created[1].Key)
+
+	imported, err = f.Repository.ImportedSourceIDs(ctx, source, []string{"1", "2", "3"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "2"}, imported)
+	imported, err = f.Repository.ImportedSourceIDs(ctx, "other-"+source, []string{"1"})
+	require.NoError(t, err)
+	assert.Empty(t, imported)
+
+	// Importing a record twice fails, creating nothing.
+	_, err = f.Repository.CreateImportedTasks(ctx, source, []*models.Task{newTask(projectKey, projectID, 3)}, []string{"2"})
+	assert.Error(t, err)
+	_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0003")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = f.Repository.CreateImportedTasks(ctx, source, tasks, []string{"1"})
+	assert.Error(t, err)
+
+	// Deleting a task forgets that it was imported.
+	require.NoError(t, f.Repository.DeleteTask(ctx, created[0].ID))
+	imported, err = f.Repository.ImportedSourceIDs(ctx, source, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, imported)
+}
+
+func testTransfer(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	fromID, fromKey := f.NewProject(t)
+	toID, toKey := f.NewProject(t)
+
+	_, err := f.Repository.CreateTask(ctx, newTask(toKey, toID, 1))
+	require.NoError(t, err)
+	task := newTask(fromKey, fromID, 1)
+	task.CustomFields = map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 3}}
+	created, err := f.Repository.CreateTask(ctx, task)
+	require.NoError(t, err)
+
+	fields := map[string]models.FieldValue{"size": {Type: models.FieldTypeString, String: "L"}}
+	transferred, err := f.Repository.TransferTask(ctx, created.ID, toID, "todo", "a", fields)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, transferred.ID)
+	assert.Equal(t, toID, transferred.ProjectID)
+	assert.Equal(t, toKey+"-2", transferred.Key)
+	assert.Equal(t, "todo", transferred.Status)
+	assert.Equal(t, "a", transferred.Rank)
+	assert.Equal(t, fields, transferred.CustomFields)
+	assert.Equal(t, created.ExternalID, transferred.ExternalID)
+
+	// Both the new and the old key resolve to the task.
+	for _, key := range []string{toKey + "-2", fromKey + "-1"} {
+		id, err := f.Repository.GetTaskIDByKey(ctx, key)
+		require.NoError(t, err, key)
+		assert.Equal(t, created.ID, id, key)
+	}
+
+	// The old project does not hand out the transferred key again.
+	next, err := f.Repository.CreateTask(ctx, newTask(fromKey, fromID, 2))
+	require.NoError(t, err)
+	assert.Equal(t, fromKey+"-2", next.Key)
+
+	_, err = f.Repository.TransferTask(ctx, deletedTaskID(t, f, fromKey, fromID), toID, "todo", "b", nil)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testSubtasks(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	parent, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 1))
+	require.NoError(t, err)
+	subtasks := []*models.Task{newTask(projectKey, projectID, 2), newTask(projectKey, projectID, 3)}
+	for _, subtask := range subtasks {
+		subtask.ParentID = parent.ID
+	}
+	subtasks, err = f.Repository.CreateTasks(ctx, subtasks)
+	require.NoError(t, err)
+	other, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 4))
+	require.NoError(t, err)
+
+	task, err := f.Repository.GetTask(ctx, subtasks[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID, task.ParentID)
+
+	// Updates leave the parent alone.
+	task.ParentID = 0
+	task.Title = "Updated"
+	updated, err := f.Repository.UpdateTask(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID, updated.ParentID)
+
+	moved, err := f.Repository.SetTaskParent(ctx, subtasks[1].ID, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, moved.ParentID)
+	listed, err := f.Repository.ListTasks(ctx, models.TaskFilter{
+		ProjectID: projectID,
+		Where:     &models.FilterExpr{Field: "parent_id", Type: models.FilterTypeInteger, Operator: "=", Value: other.ID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, externalIDs(projectKey, []int{3}), taskExternalIDs(listed))
+
+	detached, err := f.Repository.DetachSubtasks(ctx, []int64{parent.ID, other.ID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{subtasks[0].ID, subtasks[1].ID}, detached)
+	task, err = f.Repository.GetTask(ctx, subtasks[1].ID)
+	require.NoError(t, err)
+	assert.Zero(t, task.ParentID)
+	detached, err = f.Repository.DetachSubtasks(ctx, []int64{parent.ID})
+	require.NoError(t, err)
+	assert.Empty(t, detached)
+
+	_, err = f.Repository.SetTaskParent(ctx, deletedTaskID(t, f, projectKey, projectID), parent.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testRelations(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	tasks := make([]*models.Task, 3)
+	for i := range tasks {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+	}
+	tasks, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+	blocks := models.TaskRelation{TaskID: tasks[0].ID, RelatedTaskID: tasks[1].ID, Type: models.RelationBlocks}
+	relates := models.TaskRelation{TaskID: tasks[2].ID, RelatedTaskID: tasks[0].ID, Type: models.RelationRelatesTo}
+
+	require.NoError(t, f.Repository.CreateTaskRelations(ctx, []models.TaskRelation{blocks, relates}))
+	// Relations that exist already are skipped.
+	require.NoError(t, f.Repository.CreateTaskRelations(ctx, []models.TaskRelation{blocks}))
+
+	relations, err := f.Repository.ListTaskRelations(ctx, tasks[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TaskRelation{blocks, relates}, relations)
+	relations, err = f.Repository.ListTaskRelations(ctx, tasks[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TaskRelation{blocks}, relations)
+
+	// A relation to a missing task stores none of the batch.
+	missing := models.TaskRelation{TaskID: tasks[1].ID, RelatedTaskID: deletedTaskID(t, f, projectKey, projectID), Type: models.RelationBlocks}
+	duplicates := models.TaskRelation{TaskID: tasks[1].ID, RelatedTaskID: tasks[2].ID, Type: models.RelationDuplicates}
+	assert.Error(t, f.Repository.CreateTaskRelations(ctx, []models.TaskRelation{duplicates, missing}))
+	relations, err = f.Repository.ListTaskRelations(ctx, tasks[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TaskRelation{relates}, relations)
+
+	require.NoError(t, f.Repository.DeleteTaskRelation(ctx, blocks))
+	assert.ErrorIs(t, f.Repository.DeleteTaskRelation(ctx, blocks), sql.ErrNoRows)
+
+	// Relations end with either of their tasks.
+	require.NoError(t, f.Repository.DeleteTask(ctx, tasks[2].ID))
+	relations, err = f.Repository.ListTaskRelations(ctx, tasks[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, relations)
+}
+
+func testContent(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	tasks := make([]*models.Task, 3)
+	for i := range tasks {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+	}
+	tasks, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+	task, copied, other := tasks[0].ID, tasks[1].ID, tasks[2].ID
+
+	checklist := []models.ChecklistItem{{Text: "Write"}, {Text: "Review", Done: true}, {Text: "Ship"}}
+	require.NoError(t, f.Repository.SetTaskChecklist(ctx, task, checklist[:1]))
+	require.NoError(t, f.Repository.SetTaskChecklist(ctx, task, checklist))
+	items, err := f.Repository.GetTaskChecklist(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, checklist, items)
+	items, err = f.Repository.GetTaskChecklist(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ChecklistItem{}, items)
+
+	first, err := f.Repository.CreateTaskComment(ctx, &models.TaskComment{TaskID: task, Author: "ada", Body: "First"})
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+	assert.WithinDuration(t, time.Now(), first.CreatedAt, time.Minute)
+	second, err := f.Repository.CreateTaskComment(ctx, &models.TaskComment{TaskID: task, Author: "bob", Body: "Second"})
+	require.NoError(t, err)
+	_, err = f.Repository.CreateTaskComment(ctx, &models.TaskComment{TaskID: other, Author: "bob", Body: "Elsewhere"})
+	require.NoError(t, err)
+	comments, err := f.Repository.ListTaskComments(ctx, task)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, []string{"First", "Second"}, []string{comments[0].Body, comments[1].Body})
+	assert.Equal(t, second.ID, comments[1].ID)
+	assert.Equal(t, "ada", comments[0].Author)
+
+	attachment, err := f.Repository.CreateTaskAttachment(ctx, &models.TaskAttachment{
+		TaskID: task, Name: "plan.pdf", URL: "https://files.example.com/plan.pdf", ContentType: "application/pdf", Size: 2048,
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, attachment.ID)
+	attachments, err := f.Repository.ListTaskAttachments(ctx, task)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, attachment.ID, attachments[0].ID)
+	assert.Equal(t, []any{"plan.pdf", "https://files.example.com/plan.pdf", "application/pdf", int64(2048)},
+		[]any{attachments[0].Name, attachments[0].URL, attachments[0].ContentType, attachments[0].Size})
+
+	t.Run("Copy", func(t *testing.T) {
+		require.NoError(t, f.Repository.CopyTaskContent(ctx, task, copied, models.TaskContent{Checklist: true, Comments: true}))
+
+		items, err := f.Repository.GetTaskChecklist(ctx, copied)
+		require.NoError(t, err)
+		assert.Equal(t, checklist, items)
+		copiedComments, err := f.Repository.ListTaskComments(ctx, copied)
+		require.NoError(t, err)
+		require.Len(t, copiedComments, 2)
+		for i, comment := range copiedComments {
+			assert.NotEqual(t, comments[i].ID, comment.ID)
+			assert.Equal(t, copied, comment.TaskID)
+			assert.Equal(t, comments[i].Author, comment.Author)
+			assert.Equal(t, comments[i].Body, comment.Body)
+			assert.True(t, comments[i].CreatedAt.Equal(comment.CreatedAt), "copied comments keep their creation time")
+		}
+		// Attachments were not selected.
+		copiedAttachments, err := f.Repository.ListTaskAttachments(ctx, copied)
+		require.NoError(t, err)
+		assert.Empty(t, copiedAttachments)
+
+		require.NoError(t, f.Repository.CopyTaskContent(ctx, task, other, models.TaskContent{Attachments: true}))
+		copiedAttachments, err = f.Repository.ListTaskAttachments(ctx, other)
+		require.NoError(t, err)
+		require.Len(t, copiedAttachments, 1)
+		assert.Equal(t, "plan.pdf", copiedAttachments[0].Name)
+	})
+
+	// The content ends with its task.
+	require.NoError(t, f.Repository.DeleteTask(ctx, task))
+	items, err = f.Repository.GetTaskChecklist(ctx, task)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	comments, err = f.Repository.ListTaskComments(ctx, task)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+	attachments, err = f.Repository.ListTaskAttachments(ctx, task)
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+
+	_, err = f.Repository.CreateTaskComment(ctx, &models.TaskComment{TaskID: task, Body: "Too late"})
+	assert.Error(t, err)
+}
+
+func testListTasks(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+	otherID, otherKey := f.NewProject(t)
+
+	points := []float64{2, 0, 10, 1}
+	tasks := make([]*models.Task, len(points))
+	for i, value := range points {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+		// Equal ranks leave the order to the external IDs.
+		tasks[i].Rank = "m"
+		if value != 0 {
+			tasks[i].CustomFields = map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: value}}
+		}
+	}
+	tasks[3].Status = "done"
+	_, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+	_, err = f.Repository.CreateTask(ctx, newTask(otherKey, otherID, 1))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter models.TaskFilter
+		want   []int
+	}{
+		{name: "Project", filter: models.TaskFilter{ProjectID: projectID}, want: []int{1, 2, 3, 4}},
+		{name: "Status", filter: models.TaskFilter{ProjectID: projectID, Status: "done"}, want: []int{4}},
+		{name: "Limit", filter: models.TaskFilter{ProjectID: projectID, Limit: 2}, want: []int{1, 2}},
+		{
+			name: "Custom field condition",
+			filter: models.TaskFilter{ProjectID: projectID, CustomFields: []models.FieldCondition{
+				{Field: "points", Type: models.FieldTypeNumber, Operator: ">=", Value: models.FieldValue{Type: models.FieldTypeNumber, Number: 2}},
+			}},
+			want: []int{1, 3},
+		},
+		{
+			name: "Custom field equality",
+			filter: models.TaskFilter{ProjectID: projectID, CustomFields: []models.FieldCondition{
+				{Field: "points", Type: models.FieldTypeNumber, Operator: "=", Value: models.FieldValue{Type: models.FieldTypeNumber, Number: 10}},
+			}},
+			want: []int{3},
+		},
+		{
+			name:   "Order by number ascending",
+			filter: models.TaskFilter{ProjectID: projectID, OrderBy: &models.FieldOrder{Field: "points", Type: models.FieldTypeNumber}},
+			want:   []int{4, 1, 3, 2},
+		},
+		{
+			name:   "Order by number descending",
+			filter: models.TaskFilter{ProjectID: projectID, OrderBy: &models.FieldOrder{Field: "points", Type: models.FieldTypeNumber, Descending: true}},
+			want:   []int{3, 1, 4, 2},
+		},
+		{
+			// Text order is byte-wise, so "10" sorts before "2".
+			name:   "Order by text",
+			filter: models.TaskFilter{ProjectID: projectID, OrderBy: &models.FieldOrder{Field: "points", Type: models.FieldTypeString}},
+			want:   []int{4, 3, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, err := f.Repository.ListTasks(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, externalIDs(projectKey, tt.want), taskExternalIDs(listed))
+
+			var exported []*models.Task
+			err = f.Repository.ExportTasks(ctx, tt.filter, func(task *models.Task) error {
+				exported = append(exported, task)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, taskExternalIDs(listed), taskExternalIDs(exported))
+		})
+	}
+
+	t.Run("Export stops at the first error", func(t *testing.T) {
+		var calls int
+		err := f.Repository.ExportTasks(ctx, models.TaskFilter{ProjectID: projectID}, func(*models.Task) error {
+			calls++
+			return sql.ErrTxDone
+		})
+		assert.ErrorIs(t, err, sql.ErrTxDone)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func testFilterExpressions(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	first := newTask(projectKey, projectID, 1)
+	first.Title = "Fix the login page"
+	first.Assignee = "alice"
+	first.Labels = []string{"bug", "ui"}
+	first.CustomFields = map[string]models.FieldValue{
+		"points": {Type: models.FieldTypeNumber, Number: 3},
+		"due":    {Type: models.FieldTypeDate, String: "2025-03-01"},
+	}
+	second := newTask(projectKey, projectID, 2)
+	second.Title = "Write the docs"
+	second.Status = "done"
+	second.Labels = nil
+	second.CustomFields = map[string]models.FieldValue{"due": {Type: models.FieldTypeDate, String: "2025-01-15"}}
+	third := newTask(projectKey, projectID, 3)
+	third.Title = "Login rate limits"
+	third.Assignee = "bob"
+	third.Labels = []string{"bug"}
+	_, err := f.Repository.CreateTasks(ctx, []*models.Task{first, second, third})
+	require.NoError(t, err)
+
+	field := func(name, fieldType, operator string, value any) *models.FilterExpr {
+		return &models.FilterExpr{Field: name, Type: fieldType, Operator: operator, Value: value}
+	}
+	custom := func(name, fieldType, operator string, value any) *models.FilterExpr {
+		return &models.FilterExpr{Field: name, Custom: true, Type: fieldType, Operator: operator, Value: value}
+	}
+	number := func(n float64) models.FieldValue { return models.FieldValue{Type: models.FieldTypeNumber, Number: n} }
+	date := func(s string) models.FieldValue { return models.FieldValue{Type: models.FieldTypeDate, String: s} }
+	logic := func(op string, operands ...*models.FilterExpr) *models.FilterExpr {
+		return &models.FilterExpr{Logic: op, Operands: operands}
+	}
+
+	tests := []struct {
+		name string
+		expr *models.FilterExpr
+		want []int
+	}{
+		{name: "Equality", expr: field("status", models.FilterTypeString, "=", "done"), want: []int{2}},
+		{name: "Text contains ignoring case", expr: field("title", models.FilterTypeString, ":", "LOGIN"), want: []int{1, 3}},
+		{name: "Text is set", expr: field("assignee", models.FilterTypeString, ":", nil), want: []int{1, 3}},
+		{name: "List has element", expr: field("labels", models.FilterTypeList, ":", "ui"), want: []int{1}},
+		{name: "List is not empty", expr: field("labels", models.FilterTypeList, ":", nil), want: []int{1, 3}},
+		{name: "Not equal", expr: field("assignee", models.FilterTypeString, "!=", "alice"), want: []int{2, 3}},
+		{name: "Comparison", expr: field("title", models.FilterTypeString, ">", "Login"), want: []int{3, 2}},
+		{name: "Project", expr: field("project_id", models.FilterTypeInteger, "=", projectID), want: []int{1, 2, 3}},
+		{name: "Key", expr: field("key", models.FilterTypeString, "=", projectKey+"-2"), want: []int{2}},
+		{name: "Custom field is set", expr: custom("points", models.FieldTypeNumber, ":", nil), want: []int{1}},
+		{name: "Custom field comparison", expr: custom("due", models.FieldTypeDate, "<", date("2025-02-01")), want: []int{2}},
+		{name: "Custom field contains", expr: custom("due", models.FieldTypeDate, ":", date("-03-")), want: []int{1}},
+		{name: "Custom field equality", expr: custom("points", models.FieldTypeNumber, "=", number(3)), want: []int{1}},
+		{
+			name: "And",
+			expr: logic(models.FilterAnd, field("labels", models.FilterTypeList, ":", "bug"), field("assignee", models.FilterTypeString, "=", "bob")),
+			want: []int{3},
+		},
+		{
+			name: "Or",
+			expr: logic(models.FilterOr, field("status", models.FilterTypeString, "=", "done"), field("assignee", models.FilterTypeString, "=", "bob")),
+			want: []int{2, 3},
+		},
+		{
+			// Tasks lacking the field do not match the comparison, so they match its negation.
+			name: "Not with missing custom field",
+			expr: logic(models.FilterNot, custom("points", models.FieldTypeNumber, ">", number(1))),
+			want: []int{2, 3},
+		},
+		{
+			name: "Or with missing custom field",
+			expr: logic(models.FilterOr, custom("points", models.FieldTypeNumber, ">", number(1)), field("status", models.FilterTypeString, "=", "done")),
+			want: []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, err := f.Repository.ListTasks(ctx, models.TaskFilter{ProjectID: projectID, Where: tt.expr})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, externalIDs(projectKey, tt.want), taskExternalIDs(listed))
+		})
+	}
+
+	t.Run("Unsupported field", func(t *testing.T) {
+		_, err := f.Repository.ListTasks(ctx, models.TaskFilter{ProjectID: projectID, Where: field("secret", models.FilterTypeString, "=", "x")})
+		assert.Error(t, err)
+	})
+}
+
+func testPagination(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	points := []float64{2, 0, 1, 2, 0}
+	tasks := make([]*models.Task, len(points))
+	for i, value := range points {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+		tasks[i].Rank = "m"
+		if value != 0 {
+			tasks[i].CustomFields = map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: value}}
+		}
+	}
+	_, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+
+	orders := []struct {
+		name    string
+		orderBy *models.FieldOrder
+		want    []int
+	}{
+		{name: "Rank", want: []int{1, 2, 3, 4, 5}},
+		{name: "Ascending", orderBy: &models.FieldOrder{Field: "points", Type: models.FieldTypeNumber}, want: []int{3, 1, 4, 2, 5}},
+		{name: "Descending", orderBy: &models.FieldOrder{Field: "points", Type: models.FieldTypeNumber, Descending: true}, want: []int{1, 4, 3, 2, 5}},
+	}
+	for _, order := range orders {
+		t.Run(order.name, func(t *testing.T) {
+			var pages [][]string
+			var listed []string
+			filter := models.TaskFilter{ProjectID: projectID, OrderBy: order.orderBy, Limit: 2}
+			for len(pages) < 5 {
+				page, err := f.Repository.ListTasks(ctx, filter)
+				require.NoError(t, err)
+				if len(page) == 0 {
+					break
+				}
+				pages = append(pages, taskExternalIDs(page))
+				listed = append(listed, taskExternalIDs(page)...)
+
+				last := page[len(page)-1]
+				filter.After = &models.TaskCursor{Rank: last.Rank, ExternalID: last.ExternalID}
+				if order.orderBy != nil {
+					if value, ok := last.CustomFields[order.orderBy.Field]; ok {
+						filter.After.SortValue = value.Raw()
+					}
+				}
+			}
+			assert.Len(t, pages, 3)
+			assert.Equal(t, externalIDs(projectKey, order.want), listed)
+		})
+	}
+}
+
+func testRanks(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+	column := models.Column{ProjectID: projectID, Status: "open"}
+
+	ranks := []string{"b", "d", "f"}
+	tasks := make([]*models.Task, len(ranks))
+	for i, rank := range ranks {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+		tasks[i].Rank = rank
+	}
+	_, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+
+	rankTests := []struct {
+		name   string
+		lookup func(context.Context, models.Column, string) (string, error)
+		rank   string
+		want   string
+	}{
+		{name: "Before", lookup: f.Repository.RankBefore, rank: "d", want: "b"},
+		{name: "Before first", lookup: f.Repository.RankBefore, rank: "b", want: ""},
+		{name: "Last", lookup: f.Repository.RankBefore, rank: "", want: "f"},
+		{name: "After", lookup: f.Repository.RankAfter, rank: "c", want: "d"},
+		{name: "After last", lookup: f.Repository.RankAfter, rank: "f", want: ""},
+	}
+	for _, tt := range rankTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rank, err := tt.lookup(ctx, column, tt.rank)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rank)
+		})
+	}
+
+	moved, err := f.Repository.MoveTask(ctx, tasks[0].ID, "open", "eeeeeeeeeeee")
+	require.NoError(t, err)
+	assert.Equal(t, "eeeeeeeeeeee", moved.Rank)
+	assert.Equal(t, tasks[0].Key, moved.Key)
+	_, err = f.Repository.MoveTask(ctx, deletedTaskID(t, f, projectKey, projectID), "open", "a")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	columns, err := f.Repository.ColumnsWithLongRanks(ctx, 10)
+	require.NoError(t, err)
+	assert.Contains(t, columns, column)
+
+	var count int
+	err = f.Repository.RebalanceColumn(ctx, column, func(n int) []string {
+		count = n
+		return []string{"1", "2", "3"}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	listed, err := f.Repository.ListTasks(ctx, models.TaskFilter{ProjectID: projectID, Status: "open"})
+	require.NoError(t, err)
+	assert.Equal(t, externalIDs(projectKey, []int{2, 1, 3}), taskExternalIDs(listed))
+	assert.Equal(t, []string{"1", "2", "3"}, []string{listed[0].Rank, listed[1].Rank, listed[2].Rank})
+
+	columns, err = f.Repository.ColumnsWithLongRanks(ctx, 10)
+	require.NoError(t, err)
+	assert.NotContains(t, columns, column)
+
+	err = f.Repository.RebalanceColumn(ctx, column, func(int) []string { return []string{"1"} })
+	assert.Error(t, err)
+}
+
+func testArchive(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+
+	tasks := make([]*models.Task, 4)
+	for i := range tasks {
+		tasks[i] = newTask(projectKey, projectID, i+1)
+		tasks[i].Status = "done"
+	}
+	tasks[3].Status = "open"
+	tasks, err := f.Repository.CreateTasks(ctx, tasks)
+	require.NoError(t, err)
+	done := models.Column{ProjectID: projectID, Status: "done"}
+
+	// Tasks updated since the given time stay. The times allow for the clock of a database server.
+	ids, err := f.Repository.ArchiveTasks(ctx, done, tasks[0].CreatedAt.Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	ids, err = f.Repository.ArchiveTasks(ctx, done, time.Now().Add(time.Hour), 2)
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+	ids, err = f.Repository.ArchiveTasks(ctx, done, time.Now().Add(time.Hour), 2)
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	_, err = f.Repository.GetTask(ctx, tasks[0].ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = f.Repository.GetTaskIDByKey(ctx, tasks[0].Key)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = f.Repository.GetArchivedTask(ctx, models.TaskRef{ID: tasks[3].ID})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, ref := range []models.TaskRef{{ID: tasks[0].ID}, {Key: tasks[0].Key}, {ExternalID: tasks[0].ExternalID}} {
+		task, err := f.Repository.GetArchivedTask(ctx, ref)
+		require.NoError(t, err)
+		assert.Equal(t, tasks[0].ID, task.ID)
+		assert.Equal(t, tasks[0].Key, task.Key)
+		assert.Equal(t, "Task 1", task.Title)
+		assert.Equal(t, []string{"conformance"}, task.Labels)
+		assert.False(t, task.ArchivedAt.IsZero())
+	}
+
+	listed, err := f.Repository.ListTasks(ctx, models.TaskFilter{ProjectID: projectID})
+	require.NoError(t, err)
+	assert.Equal(t, externalIDs(projectKey, []int{4}), taskExternalIDs(listed))
+
+	listed, err = f.Repository.ListTasks(ctx, models.TaskFilter{ProjectID: projectID, IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Equal(t, externalIDs(projectKey, []int{1, 2, 3, 4}), taskExternalIDs(listed))
+	assert.False(t, listed[0].ArchivedAt.IsZero())
+	assert.True(t, listed[3].ArchivedAt.IsZero())
+
+	listed, err = f.Repository.ListTasks(ctx, models.TaskFilter{
+		ProjectID: projectID, IncludeArchived: true, Limit: 2, After: &models.TaskCursor{Rank: "m0001", ExternalID: projectKey + "-0001"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, externalIDs(projectKey, []int{2, 3}), taskExternalIDs(listed))
+
+	var exported []*models.Task
+	require.NoError(t, f.Repository.ExportTasks(ctx, models.TaskFilter{ProjectID: projectID, Status: "done", IncludeArchived: true},
+		func(task *models.Task) error {
+			exported = append(exported, task)
+			return nil
+		}))
+	assert.Equal(t, externalIDs(projectKey, []int{1, 2, 3}), taskExternalIDs(exported))
+}
+
+func testArchiveRecords(t *testing.T, f Fixture) {
+	ctx := context.Background()
+	fromID, fromKey := f.NewProject(t)
+	toID, toKey := f.NewProject(t)
+	source := "conformance-" + fromKey
+
+	created, err := f.Repository.CreateImportedTasks(ctx, source, []*models.Task{newTask(fromKey, fromID, 1)}, []string{"1"})
+	require.NoError(t, err)
+	_, err = f.Repository.TransferTask(ctx, created[0].ID, toID, "done", "a", nil)
+	require.NoError(t, err)
+	ids, err := f.Repository.ArchiveTasks(ctx, models.Column{ProjectID: toID, Status: "done"}, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{created[0].ID}, ids)
+
+	// The archived task stays imported, so that importing its record again skips it.
+	imported, err := f.Repository.ImportedSourceIDs(ctx, source, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, imported)
+
+	// The key the task had before its transfer still finds it in the archive.
+	_, err = f.Repository.GetTaskIDByKey(ctx, fromKey+"-1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	for _, key := range []string{toKey + "-1", fromKey + "-1"} {
+		task, err := f.Repository.GetArchivedTask(ctx, models.TaskRef{Key: key})
+		require.NoError(t, err, key)
+		assert.Equal(t, created[0].ID, task.ID, key)
+	}
+}
+
+// deletedTaskID returns the ID of a task that existed but was deleted, which no task has.
+func testUnitsOfWork(t *testing.T, f Fixture) {
+	if f.Transactor == nil {
+		t.Skip("no transactor")
+	}
+	ctx := context.Background()
+	projectID, projectKey := f.NewProject(t)
+	errFailed := errors.New("unit of work failed")
+	existing, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 1))
+	require.NoError(t, err)
+
+	t.Run("Commit", func(t *testing.T) {
+		committed := false
+		err := f.Transactor.InTx(ctx, func(ctx context.Context) error {
+			created, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 2))
+			if err != nil {
+				return err
+			}
+			got, err := f.Repository.GetTask(ctx, created.ID)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, "Task 2", got.Title, "the unit of work reads its own writes")
+			consistency.AfterCommit(ctx, func() { committed = true })
+			assert.False(t, committed)
+			_, err = f.Repository.MoveTask(ctx, existing.ID, "done", "n")
+			return err
+		})
+		require.NoError(t, err)
+		assert.True(t, committed)
+
+		got, err := f.Repository.GetTask(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "done", got.Status)
+		_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0002")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		committed := false
+		err := f.Transactor.InTx(ctx, func(ctx context.Context) error {
+			if _, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 3)); err != nil {
+				return err
+			}
+			changed, err := f.Repository.GetTask(ctx, existing.ID)
+			if err != nil {
+				return err
+			}
+			changed.Title = "Rolled back"
+			if _, err := f.Repository.UpdateTask(ctx, changed); err != nil {
+				return err
+			}
+			if _, err := f.Repository.GetTask(ctx, existing.ID); err != nil {
+				return err
+			}
+			consistency.AfterCommit(ctx, func() { committed = true })
+			return errFailed
+		})
+		assert.ErrorIs(t, err, errFailed)
+		assert.False(t, committed)
+
+		got, err := f.Repository.GetTask(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Task 1", got.Title)
+		_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0003")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// The key the rolled back task had is handed out again.
+		created, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 4))
+		require.NoError(t, err)
+		assert.Equal(t, projectKey+"-3", created.Key)
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		deletedID := deletedTaskID(t, f, projectKey, projectID)
+		err := f.Transactor.InTx(ctx, func(ctx context.Context) error {
+			if _, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 5)); err != nil {
+				return err
+			}
+			err := f.Transactor.InTx(ctx, func(ctx context.Context) error {
+				if _, err := f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 6)); err != nil {
+					return err
+				}
+				return errFailed
+			})
+			assert.ErrorIs(t, err, errFailed)
+
+			// A failing repository call only undoes its own changes.
+			changed, err := f.Repository.GetTask(ctx, existing.ID)
+			if err != nil {
+				return err
+			}
+			changed.Title = "Partly updated"
+			_, err = f.Repository.UpdateTasks(ctx, []*models.Task{changed, {ID: deletedID}})
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+
+			_, err = f.Repository.CreateTask(ctx, newTask(projectKey, projectID, 7))
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0005")
+		assert.NoError(t, err)
+		_, err = f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0006")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		id, err := f.Repository.GetTaskIDByExternalID(ctx, projectKey+"-0007")
+		require.NoError(t, err)
+		got, err := f.Repository.GetTask(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, projectKey+"-6", got.Key, "the key of the undone task is handed out again")
+		got, err = f.Repository.GetTask(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Task 1", got.Title)
+	})
+}
+
+func deletedTaskID(t *testing.T, f Fixture, projectKey string, projectID int64) int64 {
+	t.Helper()
+	ctx := context.Background()
+	task := newTask(projectKey, projectID, 9000)
+	task.ExternalID += fmt.Sprintf("-deleted-%s", t.Name())
+	created, err := f.Repository.CreateTask(ctx, task)
+	require.NoError(t, err)
+	require.NoError(t, f.Repository.DeleteTask(ctx, created.ID))
+	return created.ID
+}
+
+func externalIDs(projectKey string, numbers []int) []string {
+	ids := make([]string, len(numbers))
+	for i, n := range numbers {
+		ids[i] = fmt.Sprintf("%s-%04d", projectKey, n)
+	}
+	return ids
+}
+
+func taskExternalIDs(tasks []*models.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ExternalID
+	}
+	return ids
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+type SavedViewRepository interface {
+	CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error)
+	GetSavedView(ctx context.Context, id int64) (*models.SavedView, error)
+	// ListSavedViews returns the views owned by owner and the views shared by others, by name.
+	// A non-zero projectID limits them to the views of that project.
+	ListSavedViews(ctx context.Context, owner string, projectID int64) ([]*models.SavedView, error)
+	UpdateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error)
+	DeleteSavedView(ctx context.Context, id int64) error
+}
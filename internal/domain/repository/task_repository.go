@@ -1,11 +1,97 @@
 package repository
 
-import "github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+import (
+	"context"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
 
 type TaskRepository interface {
-	CreateTask(task *models.Task) (*models.Task, error)
-	ListTasks() ([]*models.Task, error)
-	GetTask(id int64) (*models.Task, error)
-	UpdateTask(task *models.Task) (*models.Task, error)
-	DeleteTask(id int64) error
+	// CreateTask stores a task. Tasks of a project get the next key of that project.
+	CreateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	// ListTasks returns the tasks matching filter ordered by rank and then by external ID.
+	ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error)
+	// ExportTasks passes the tasks matching filter to each in ListTasks order, reading them in chunks
+	// rather than all at once. It stops at the first error each returns and returns that error.
+	ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error
+	GetTask(ctx context.Context, id int64) (*models.Task, error)
+	UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	DeleteTask(ctx context.Context, id int64) error
+	// CreateTasks stores several tasks in one transaction, giving them consecutive keys of their projects
+	// in the order given. Either all tasks are stored or none.
+	CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error)
+	// UpdateTasks applies several updates in one transaction. It fails with sql.ErrNoRows, storing
+	// nothing, if any of the tasks is gone.
+	UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error)
+	// DeleteTasks deletes several tasks in one transaction. It fails with sql.ErrNoRows, deleting
+	// nothing, if any of the tasks is gone.
+	DeleteTasks(ctx context.Context, ids []int64) error
+	// CreateImportedTasks stores tasks like CreateTasks and records the source ID each task was imported
+	// from, in the same transaction.
+	CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error)
+	// ImportedSourceIDs returns those of sourceIDs that were already imported from source.
+	ImportedSourceIDs(ctx context.Context, source string, sourceIDs []string) ([]string, error)
+	// GetTaskIDByKey resolves a task key, including keys the task had before it moved to another project.
+	GetTaskIDByKey(ctx context.Context, key string) (int64, error)
+	// GetTaskIDByExternalID resolves the ULID or UUIDv7 of a task.
+	GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error)
+	// TransferTask moves a task to another project, giving it a key of the new project and keeping
+	// the old key as an alias. A zero projectID moves the task out of any project. customFields
+	// replaces the task's custom field values, as the fields are defined per project.
+	TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error)
+	// SetTaskParent makes a task a subtask of the task with ID parentID, or a top-level task if parentID is zero.
+	SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error)
+	// DetachSubtasks makes the subtasks of the given tasks top-level tasks and returns the IDs of the subtasks.
+	DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error)
+
+	// CreateTaskRelations stores relations between tasks, skipping those that exist already.
+	CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error
+	// ListTaskRelations returns the relations from and to a task, ordered by task ID, related task ID and type.
+	ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error)
+	// DeleteTaskRelation deletes a relation, failing with sql.ErrNoRows if there is none.
+	DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error
+
+	// SetTaskChecklist replaces the checklist of a task with items.
+	SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error
+	// GetTaskChecklist returns the checklist of a task, which is empty for tasks without one.
+	GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error)
+	// CreateTaskComment stores a comment, giving it an ID and its creation time.
+	CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error)
+	// ListTaskComments returns the comments of a task by ID, oldest first.
+	ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error)
+	// CreateTaskAttachment stores an attachment, giving it an ID and its creation time.
+	CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error)
+	// ListTaskAttachments returns the attachments of a task by ID, oldest first.
+	ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error)
+	// CopyTaskContent copies the selected content of task fromID to task toID, which has none of it yet.
+	// Copied comments and attachments keep their author and creation time and get new IDs in their order.
+	// Checklists, comments and attachments end when their task is deleted or archived.
+	CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error
+
+	// MoveTask places a task in the column of status at the given rank, touching only that row.
+	MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error)
+	// RankBefore returns the largest rank in the column below rank, or "" if there is none.
+	// An empty rank stands for the end of the column, so RankBefore(column, "") returns the last rank.
+	RankBefore(ctx context.Context, column models.Column, rank string) (string, error)
+	// RankAfter returns the smallest rank in the column above rank, or "" if there is none.
+	RankAfter(ctx context.Context, column models.Column, rank string) (string, error)
+	// ColumnsWithLongRanks lists the columns holding rank keys longer than maxLength.
+	ColumnsWithLongRanks(ctx context.Context, maxLength int) ([]models.Column, error)
+	// RebalanceColumn atomically rewrites the ranks of all tasks in the column, keeping their order.
+	// ranks is called with the number of tasks and must return that many ascending keys.
+	RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error
+
+	// ArchiveTasks moves up to limit tasks of the column that were last updated before the given time to
+	// the archive, least recently updated first, and returns their IDs. Archived tasks are gone for all
+	// other methods except ImportedSourceIDs, whose records they keep in the archive along with their key
+	// aliases. Their relations end; their subtasks keep them as parent.
+	ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error)
+	// GetArchivedTask returns the archived task ref identifies by its external ID, key or ID, whichever is
+	// set first in that order, or sql.ErrNoRows. Keys include those the task had in earlier projects.
+	// Archived tasks are also listed by ListTasks and ExportTasks when their filter includes archived tasks.
+	GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error)
+	// CreateTaskPartitions prepares the storage of the tasks created from now until the given time, ahead
+	// of their creation. Stores that do not partition tasks by time have nothing to prepare.
+	CreateTaskPartitions(ctx context.Context, until time.Time) error
 }
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// TaskSearcher finds tasks by the words of their title and description. It is separate from
+// TaskRepository so that the search index can live outside the task store.
+type TaskSearcher interface {
+	// SearchTasks returns the tasks matching all terms of the search, best matches first.
+	SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+type TaskTemplateRepository interface {
+	// CreateTaskTemplate stores a template as its first version.
+	CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error)
+	// GetTaskTemplate returns a version of a template, or its current version for version zero.
+	GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error)
+	// ListTaskTemplates returns the current versions of the templates of a project, by name.
+	ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error)
+	// UpdateTaskTemplate stores a template as its next version, keeping the previous ones.
+	UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error)
+	DeleteTaskTemplate(ctx context.Context, id int64) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "context"
+
+// Transactor runs units of work: task repository calls that take effect together or not at all.
+type Transactor interface {
+	// InTx runs fn in a transaction, which it commits if fn returns nil and rolls back otherwise.
+	// The task repository calls made with the context passed to fn are part of the transaction. InTx
+	// called with such a context runs fn in a savepoint of the enclosing transaction instead, so that a
+	// failing nested unit of work is undone without undoing the enclosing one.
+	//
+	// When the database aborts the transaction for a serialization failure or a deadlock, InTx runs fn
+	// again in a new transaction, up to a configured number of attempts. fn must therefore change nothing
+	// but through the repositories, and must return the errors of repository calls, wrapped or not, so
+	// that InTx can tell why the transaction failed.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCustomFieldNotFound   = errors.New("custom field not found")
+	ErrCustomFieldNameTaken  = errors.New("custom field name is already taken")
+	ErrInvalidCustomField    = errors.New("invalid custom field definition")
+	ErrCustomFieldConflicts  = errors.New("existing task values conflict with the custom field definition")
+	ErrUnknownCustomField    = errors.New("unknown custom field")
+	ErrInvalidFieldValue     = errors.New("invalid custom field value")
+	ErrCustomFieldCreateFail = errors.New("failed to create custom field")
+	ErrCustomFieldUpdateFail = errors.New("failed to update custom field")
+	ErrCustomFieldDeleteFail = errors.New("failed to delete custom field")
+)
+
+// dateLayout is the format of date custom field values.
+const dateLayout = "2006-01-02"
+
+type CustomFieldService interface {
+	CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, []models.FieldConflict, error)
+	ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error)
+	UpdateCustomField(ctx context.Context, field *models.CustomField, validateOnly bool) (*models.CustomField, []models.FieldConflict, error)
+	DeleteCustomField(ctx context.Context, projectID, id int64) error
+}
+
+type customFieldService struct {
+	repo     repository.CustomFieldRepository
+	projects repository.ProjectRepository
+	logger   *zap.Logger
+}
+
+func NewCustomFieldService(repo repository.CustomFieldRepository, projects repository.ProjectRepository, logger *zap.Logger) CustomFieldService {
+	return &customFieldService{
+		repo:     repo,
+		projects: projects,
+		logger:   logger,
+	}
+}
+
+// CreateCustomField defines a new field for the tasks of a project. A required field can only be
+// added while every task of the project could satisfy it, that is while the project has no tasks;
+// otherwise the tasks lacking a value are returned as conflicts along with ErrCustomFieldConflicts.
+func (s *customFieldService) CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, []models.FieldConflict, error) {
+	s.logger.Info("Creating custom field", zap.Int64("project_id", field.ProjectID), zap.String("name", field.Name))
+
+	if err := s.writableProject(ctx, field.ProjectID); err != nil {
+		return nil, nil, err
+	}
+	if err := validateCustomField(field); err != nil {
+		return nil, nil, err
+	}
+
+	conflicts, err := s.conflicts(ctx, field)
+	if err != nil {
+		return nil, nil, ErrCustomFieldCreateFail
+	}
+	if len(conflicts) > 0 {
+		s.logger.Warn("Custom field conflicts with existing tasks", zap.String("name", field.Name), zap.Int("conflicts", len(conflicts)))
+		return nil, conflicts, ErrCustomFieldConflicts
+	}
+
+	createdField, err := s.repo.CreateCustomField(ctx, field)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			s.logger.Warn("Custom field name is already taken", zap.String("name", field.Name))
+			return nil, nil, ErrCustomFieldNameTaken
+		}
+		s.logger.Error("Failed to create custom field", zap.Error(err))
+		return nil, nil, ErrCustomFieldCreateFail
+	}
+
+	return createdField, nil, nil
+}
+
+func (s *customFieldService) ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error) {
+	s.logger.Info("Listing custom fields", zap.Int64("project_id", projectID))
+
+	if _, err := s.project(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	fields, err := s.repo.ListCustomFields(ctx, projectID)
+	if err != nil {
+		s.logger.Error("Failed to list custom fields", zap.Error(err))
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// UpdateCustomField replaces the definition of a field; its name cannot change. Existing values are
+// checked against the new definition first. Tasks whose values would no longer be valid are returned
+// as conflicts, with ErrCustomFieldConflicts unless validateOnly is set. With validateOnly nothing is
+// written and the field is returned as it would be after the update.
+func (s *customFieldService) UpdateCustomField(ctx context.Context, field *models.CustomField, validateOnly bool) (*models.CustomField, []models.FieldConflict, error) {
+	s.logger.Info("Updating custom field", zap.Int64("id", field.ID), zap.Bool("validate_only", validateOnly))
+
+	existing, err := s.field(ctx, field.ProjectID, field.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.writableProject(ctx, existing.ProjectID); err != nil {
+		return nil, nil, err
+	}
+	field.Name = existing.Name
+	if err := validateCustomField(field); err != nil {
+		return nil, nil, err
+	}
+
+	conflicts, err := s.conflicts(ctx, field)
+	if err != nil {
+		return nil, nil, ErrCustomFieldUpdateFail
+	}
+	if validateOnly {
+		field.CreatedAt = existing.CreatedAt
+		field.UpdatedAt = existing.UpdatedAt
+		return field, conflicts, nil
+	}
+	if len(conflicts) > 0 {
+		s.logger.Warn("Custom field conflicts with existing tasks", zap.String("name", field.Name), zap.Int("conflicts", len(conflicts)))
+		return nil, conflicts, ErrCustomFieldConflicts
+	}
+
+	updatedField, err := s.repo.UpdateCustomField(ctx, field)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrCustomFieldNotFound
+		}
+		s.logger.Error("Failed to update custom field", zap.Error(err))
+		return nil, nil, ErrCustomFieldUpdateFail
+	}
+
+	return updatedField, nil, nil
+}
+
+// DeleteCustomField removes a field and its values from every task of the project.
+func (s *customFieldService) DeleteCustomField(ctx context.Context, projectID, id int64) error {
+	s.logger.Info("Deleting custom field", zap.Int64("project_id", projectID), zap.Int64("id", id))
+
+	existing, err := s.field(ctx, projectID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.writableProject(ctx, existing.ProjectID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteCustomField(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCustomFieldNotFound
+		}
+		s.logger.Error("Failed to delete custom field", zap.Error(err))
+		return ErrCustomFieldDeleteFail
+	}
+
+	return nil
+}
+
+// field loads a field, treating fields of other projects as missing.
+func (s *customFieldService) field(ctx context.Context, projectID, id int64) (*models.CustomField, error) {
+	field, err := s.repo.GetCustomField(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Custom field not found", zap.Int64("id", id))
+			return nil, ErrCustomFieldNotFound
+		}
+		s.logger.Error("Failed to fetch custom field", zap.Error(err))
+		return nil, err
+	}
+	if field.ProjectID != projectID {
+		s.logger.Warn("Custom field not found in project", zap.Int64("id", id), zap.Int64("project_id", projectID))
+		return nil, ErrCustomFieldNotFound
+	}
+	return field, nil
+}
+
+// conflicts lists the tasks of the field's project whose current value does not satisfy the field.
+func (s *customFieldService) conflicts(ctx context.Context, field *models.CustomField) ([]models.FieldConflict, error) {
+	values, err := s.repo.ListFieldValues(ctx, field.ProjectID, field.Name)
+	if err != nil {
+		s.logger.Error("Failed to list custom field values", zap.Error(err))
+		return nil, err
+	}
+
+	var conflicts []models.FieldConflict
+	for _, ref := range values {
+		var reason string
+		if ref.Value == nil {
+			if field.Required {
+				reason = "value is required"
+			}
+		} else if _, err := validateFieldValue(field, *ref.Value); err != nil {
+			reason = err.Error()
+		}
+		if reason != "" {
+			conflicts = append(conflicts, models.FieldConflict{TaskID: ref.TaskID, TaskKey: ref.TaskKey, Reason: reason})
+		}
+	}
+	return conflicts, nil
+}
+
+func (s *customFieldService) project(ctx context.Context, id int64) (*models.Project, error) {
+	project, err := s.projects.GetProject(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found", zap.Int64("project_id", id))
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *customFieldService) writableProject(ctx context.Context, id int64) error {
+	project, err := s.project(ctx, id)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		s.logger.Warn("Project is archived", zap.Int64("project_id", id))
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+// validateCustomField checks that the rules of a definition fit its type.
+func validateCustomField(field *models.CustomField) error {
+	if !slices.Contains(models.FieldTypes, field.Type) {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidCustomField, field.Type)
+	}
+	if field.Type == models.FieldTypeEnum {
+		if len(field.Options) == 0 {
+			return fmt.Errorf("%w: enum fields need options", ErrInvalidCustomField)
+		}
+	} else if len(field.Options) > 0 {
+		return fmt.Errorf("%w: only enum fields have options", ErrInvalidCustomField)
+	}
+	if field.Type != models.FieldTypeNumber && (field.Min != nil || field.Max != nil) {
+		return fmt.Errorf("%w: only number fields have a minimum and maximum", ErrInvalidCustomField)
+	}
+	if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+		return fmt.Errorf("%w: minimum is greater than maximum", ErrInvalidCustomField)
+	}
+	if field.MaxLength < 0 || field.MaxLength > 0 && field.Type != models.FieldTypeString {
+		return fmt.Errorf("%w: only string fields have a positive maximum length", ErrInvalidCustomField)
+	}
+	return nil
+}
+
+// validateFieldValue checks a value against a field definition and returns it typed as the field.
+// Values may be given as any type of the same kind: numbers for number fields and strings otherwise.
+func validateFieldValue(field *models.CustomField, value models.FieldValue) (models.FieldValue, error) {
+	isNumber := value.Type == models.FieldTypeNumber
+	if isNumber != (field.Type == models.FieldTypeNumber) {
+		return models.FieldValue{}, fmt.Errorf("%w: %s expects a %s value", ErrInvalidFieldValue, field.Name, field.Type)
+	}
+	value.Type = field.Type
+
+	switch field.Type {
+	case models.FieldTypeNumber:
+		if math.IsNaN(value.Number) || math.IsInf(value.Number, 0) {
+			return models.FieldValue{}, fmt.Errorf("%w: %s must be a finite number", ErrInvalidFieldValue, field.Name)
+		}
+		if field.Min != nil && value.Number < *field.Min {
+			return models.FieldValue{}, fmt.Errorf("%w: %s must be at least %g", ErrInvalidFieldValue, field.Name, *field.Min)
+		}
+		if field.Max != nil && value.Number > *field.Max {
+			return models.FieldValue{}, fmt.Errorf("%w: %s must be at most %g", ErrInvalidFieldValue, field.Name, *field.Max)
+		}
+	case models.FieldTypeString:
+		if field.MaxLength > 0 && utf8.RuneCountInString(value.String) > field.MaxLength {
+			return models.FieldValue{}, fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidFieldValue, field.Name, field.MaxLength)
+		}
+	case models.FieldTypeEnum:
+		if !slices.Contains(field.Options, value.String) {
+			return models.FieldValue{}, fmt.Errorf("%w: %q is not an option of %s", ErrInvalidFieldValue, value.String, field.Name)
+		}
+	case models.FieldTypeDate:
+		if _, err := time.Parse(dateLayout, value.String); err != nil {
+			return models.FieldValue{}, fmt.Errorf("%w: %s must be a date in YYYY-MM-DD format", ErrInvalidFieldValue, field.Name)
+		}
+	case models.FieldTypeUser:
+		value.String = strings.TrimSpace(value.String)
+		if value.String == "" {
+			return models.FieldValue{}, fmt.Errorf("%w: %s must name a user", ErrInvalidFieldValue, field.Name)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap/zaptest"
+)
+
+type mockCustomFieldRepository struct {
+	fields map[int64]*models.CustomField
+	values map[string][]models.FieldValueRef
+	err    error
+}
+
+func (m *mockCustomFieldRepository) CreateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, existing := range m.fields {
+		if existing.ProjectID == field.ProjectID && existing.Name == field.Name {
+			return nil, repository.ErrAlreadyExists
+		}
+	}
+	field.ID = int64(len(m.fields) + 1)
+	m.fields[field.ID] = field
+	return field, nil
+}
+
+func (m *mockCustomFieldRepository) ListCustomFields(ctx context.Context, projectID int64) ([]*models.CustomField, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var fieldList []*models.CustomField
+	for _, field := range m.fields {
+		if field.ProjectID == projectID {
+			fieldList = append(fieldList, field)
+		}
+	}
+	return fieldList, nil
+}
+
+func (m *mockCustomFieldRepository) GetCustomField(ctx context.Context, id int64) (*models.CustomField, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	field, exists := m.fields[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return field, nil
+}
+
+func (m *mockCustomFieldRepository) UpdateCustomField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if _, exists := m.fields[field.ID]; !exists {
+		return nil, sql.ErrNoRows
+	}
+	m.fields[field.ID] = field
+	return field, nil
+}
+
+func (m *mockCustomFieldRepository) DeleteCustomField(ctx context.Context, id int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, exists := m.fields[id]; !exists {
+		return sql.ErrNoRows
+	}
+	delete(m.fields, id)
+	return nil
+}
+
+func (m *mockCustomFieldRepository) ListFieldValues(ctx context.Context, projectID int64, name string) ([]models.FieldValueRef, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.values[name], nil
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func newCustomFieldMocks() (*mockCustomFieldRepository, *mockProjectRepository) {
+	mockFields := &mockCustomFieldRepository{
+		fields: map[int64]*models.CustomField{
+			1: {ID: 1, ProjectID: 1, Name: "points", Type: models.FieldTypeNumber, Max: floatPtr(20)},
+			2: {ID: 2, ProjectID: 1, Name: "env", Type: models.FieldTypeEnum, Options: []string{"staging", "prod"}},
+			3: {ID: 3, ProjectID: 2, Name: "points", Type: models.FieldTypeNumber, Max: floatPtr(5)},
+		},
+		values: map[string][]models.FieldValueRef{
+			"points": {
+				{TaskID: 1, TaskKey: "OPS-1", Value: &models.FieldValue{Type: models.FieldTypeNumber, Number: 8}},
+				{TaskID: 2, TaskKey: "OPS-2", Value: &models.FieldValue{Type: models.FieldTypeNumber, Number: 3}},
+				{TaskID: 3, TaskKey: "OPS-3"},
+			},
+		},
+	}
+	mockProjects := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Key: "OPS"},
+			2: {ID: 2, Key: "DEV"},
+			3: {ID: 3, Key: "OLD", Archived: true},
+		},
+	}
+	return mockFields, mockProjects
+}
+
+func Test_customFieldService_CreateCustomField(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name          string
+		field         *models.CustomField
+		wantConflicts int
+		wantErr       error
+	}{
+		{name: "Valid field", field: &models.CustomField{ProjectID: 1, Name: "customer", Type: models.FieldTypeString, MaxLength: 40}},
+		{name: "Unknown type", field: &models.CustomField{ProjectID: 1, Name: "customer", Type: "money"}, wantErr: ErrInvalidCustomField},
+		{name: "Enum without options", field: &models.CustomField{ProjectID: 1, Name: "tier", Type: models.FieldTypeEnum}, wantErr: ErrInvalidCustomField},
+		{name: "Bounds on a string", field: &models.CustomField{ProjectID: 1, Name: "customer", Type: models.FieldTypeString, Min: floatPtr(1)}, wantErr: ErrInvalidCustomField},
+		{name: "Minimum above maximum", field: &models.CustomField{ProjectID: 1, Name: "size", Type: models.FieldTypeNumber, Min: floatPtr(5), Max: floatPtr(1)}, wantErr: ErrInvalidCustomField},
+		{name: "Name taken", field: &models.CustomField{ProjectID: 1, Name: "env", Type: models.FieldTypeString}, wantErr: ErrCustomFieldNameTaken},
+		{name: "Required with tasks lacking a value", field: &models.CustomField{ProjectID: 1, Name: "points", Type: models.FieldTypeNumber, Required: true}, wantConflicts: 1, wantErr: ErrCustomFieldConflicts},
+		{name: "Archived project", field: &models.CustomField{ProjectID: 3, Name: "customer", Type: models.FieldTypeString}, wantErr: ErrProjectArchived},
+		{name: "Unknown project", field: &models.CustomField{ProjectID: 9, Name: "customer", Type: models.FieldTypeString}, wantErr: ErrProjectNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFields, mockProjects := newCustomFieldMocks()
+			svc := NewCustomFieldService(mockFields, mockProjects, logger)
+
+			created, conflicts, err := svc.CreateCustomField(context.Background(), tt.field)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateCustomField() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(conflicts) != tt.wantConflicts {
+				t.Errorf("CreateCustomField() conflicts = %v, want %d", conflicts, tt.wantConflicts)
+			}
+			if err == nil && created.ID == 0 {
+				t.Errorf("CreateCustomField() returned a field without ID")
+			}
+		})
+	}
+}
+
+func Test_customFieldService_UpdateCustomField(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	t.Run("Reports conflicts without writing when validating only", func(t *testing.T) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		svc := NewCustomFieldService(mockFields, mockProjects, logger)
+
+		field, conflicts, err := svc.UpdateCustomField(context.Background(), &models.CustomField{ID: 1, ProjectID: 1, Type: models.FieldTypeNumber, Max: floatPtr(5)}, true)
+		if err != nil {
+			t.Fatalf("UpdateCustomField() unexpected error: %v", err)
+		}
+		if len(conflicts) != 1 || conflicts[0].TaskKey != "OPS-1" {
+			t.Errorf("UpdateCustomField() conflicts = %v, want OPS-1", conflicts)
+		}
+		if field.Name != "points" || *mockFields.fields[1].Max != 20 {
+			t.Errorf("UpdateCustomField() changed the stored field")
+		}
+	})
+
+	t.Run("Rejects conflicting update", func(t *testing.T) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		svc := NewCustomFieldService(mockFields, mockProjects, logger)
+
+		_, conflicts, err := svc.UpdateCustomField(context.Background(), &models.CustomField{ID: 1, ProjectID: 1, Type: models.FieldTypeString}, false)
+		if !errors.Is(err, ErrCustomFieldConflicts) {
+			t.Fatalf("UpdateCustomField() error = %v, want %v", err, ErrCustomFieldConflicts)
+		}
+		if len(conflicts) != 2 {
+			t.Errorf("UpdateCustomField() conflicts = %v, want 2", conflicts)
+		}
+	})
+
+	t.Run("Applies compatible update", func(t *testing.T) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		svc := NewCustomFieldService(mockFields, mockProjects, logger)
+
+		field, _, err := svc.UpdateCustomField(context.Background(), &models.CustomField{ID: 1, ProjectID: 1, Type: models.FieldTypeNumber, Max: floatPtr(8)}, false)
+		if err != nil {
+			t.Fatalf("UpdateCustomField() unexpected error: %v", err)
+		}
+		if *mockFields.fields[1].Max != 8 || field.Name != "points" {
+			t.Errorf("UpdateCustomField() did not store the new definition")
+		}
+	})
+
+	t.Run("Field of another project", func(t *testing.T) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		svc := NewCustomFieldService(mockFields, mockProjects, logger)
+
+		_, _, err := svc.UpdateCustomField(context.Background(), &models.CustomField{ID: 3, ProjectID: 1, Type: models.FieldTypeNumber}, false)
+		if !errors.Is(err, ErrCustomFieldNotFound) {
+			t.Errorf("UpdateCustomField() error = %v, want %v", err, ErrCustomFieldNotFound)
+		}
+	})
+}
+
+func Test_customFieldService_DeleteCustomField(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockFields, mockProjects := newCustomFieldMocks()
+	svc := NewCustomFieldService(mockFields, mockProjects, logger)
+
+	if err := svc.DeleteCustomField(context.Background(), 2, 1); !errors.Is(err, ErrCustomFieldNotFound) {
+		t.Errorf("DeleteCustomField() error = %v, want %v", err, ErrCustomFieldNotFound)
+	}
+	if err := svc.DeleteCustomField(context.Background(), 1, 1); err != nil {
+		t.Fatalf("DeleteCustomField() unexpected error: %v", err)
+	}
+	if _, exists := mockFields.fields[1]; exists {
+		t.Errorf("DeleteCustomField() did not delete the field")
+	}
+}
+
+func Test_validateFieldValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   *models.CustomField
+		value   models.FieldValue
+		want    models.FieldValue
+		wantErr bool
+	}{
+		{
+			name:  "Number within bounds",
+			field: &models.CustomField{Name: "points", Type: models.FieldTypeNumber, Min: floatPtr(0), Max: floatPtr(13)},
+			value: models.FieldValue{Type: models.FieldTypeNumber, Number: 8},
+			want:  models.FieldValue{Type: models.FieldTypeNumber, Number: 8},
+		},
+		{
+			name:    "Number above maximum",
+			field:   &models.CustomField{Name: "points", Type: models.FieldTypeNumber, Max: floatPtr(13)},
+			value:   models.FieldValue{Type: models.FieldTypeNumber, Number: 21},
+			wantErr: true,
+		},
+		{
+			name:    "Infinite number",
+			field:   &models.CustomField{Name: "points", Type: models.FieldTypeNumber},
+			value:   models.FieldValue{Type: models.FieldTypeNumber, Number: math.Inf(1)},
+			wantErr: true,
+		},
+		{
+			name:    "String for a number",
+			field:   &models.CustomField{Name: "points", Type: models.FieldTypeNumber},
+			value:   models.FieldValue{Type: models.FieldTypeString, String: "8"},
+			wantErr: true,
+		},
+		{
+			name:  "String as enum option",
+			field: &models.CustomField{Name: "env", Type: models.FieldTypeEnum, Options: []string{"staging", "prod"}},
+			value: models.FieldValue{Type: models.FieldTypeString, String: "prod"},
+			want:  models.FieldValue{Type: models.FieldTypeEnum, String: "prod"},
+		},
+		{
+			name:    "Unknown enum option",
+			field:   &models.CustomField{Name: "env", Type: models.FieldTypeEnum, Options: []string{"staging", "prod"}},
+			value:   models.FieldValue{Type: models.FieldTypeEnum, String: "dev"},
+			wantErr: true,
+		},
+		{
+			name:    "Malformed date",
+			field:   &models.CustomField{Name: "due", Type: models.FieldTypeDate},
+			value:   models.FieldValue{Type: models.FieldTypeDate, String: "2026-02-30"},
+			wantErr: true,
+		},
+		{
+			name:    "String too long",
+			field:   &models.CustomField{Name: "customer", Type: models.FieldTypeString, MaxLength: 3},
+			value:   models.FieldValue{Type: models.FieldTypeString, String: "ACME"},
+			wantErr: true,
+		},
+		{
+			name:  "User is trimmed",
+			field: &models.CustomField{Name: "reviewer", Type: models.FieldTypeUser},
+			value: models.FieldValue{Type: models.FieldTypeUser, String: " alice "},
+			want:  models.FieldValue{Type: models.FieldTypeUser, String: "alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateFieldValue(tt.field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidFieldValue) {
+					t.Errorf("validateFieldValue() error = %v, want %v", err, ErrInvalidFieldValue)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("validateFieldValue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_taskService_CustomFields(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		mockFields.fields[4] = &models.CustomField{ID: 4, ProjectID: 2, Name: "team", Type: models.FieldTypeString, Required: true}
+		mockRepo := &mockTaskRepository{
+			tasks: map[int64]*models.Task{
+				1: {ID: 1, ProjectID: 1, Key: "OPS-1", Title: "A", Status: "open", Rank: "i", CustomFields: map[string]models.FieldValue{
+					"points": {Type: models.FieldTypeNumber, Number: 8},
+					"env":    {Type: models.FieldTypeString, String: "prod"},
+				}},
+			},
+		}
+		return mockRepo, NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+
+	t.Run("Create validates and types values", func(t *testing.T) {
+		_, svc := newService()
+
+		created, err := svc.CreateTask(context.Background(), &models.Task{ProjectID: 1, Title: "B", CustomFields: map[string]models.FieldValue{
+			"env": {Type: models.FieldTypeString, String: "staging"},
+		}})
+		if err != nil {
+			t.Fatalf("CreateTask() unexpected error: %v", err)
+		}
+		if got := created.CustomFields["env"]; got.Type != models.FieldTypeEnum || got.String != "staging" {
+			t.Errorf("CreateTask() env = %+v, want enum staging", got)
+		}
+	})
+
+	t.Run("Create rejects unknown field", func(t *testing.T) {
+		_, svc := newService()
+
+		_, err := svc.CreateTask(context.Background(), &models.Task{ProjectID: 1, Title: "B", CustomFields: map[string]models.FieldValue{
+			"customer": {Type: models.FieldTypeString, String: "ACME"},
+		}})
+		if !errors.Is(err, ErrUnknownCustomField) {
+			t.Errorf("CreateTask() error = %v, want %v", err, ErrUnknownCustomField)
+		}
+	})
+
+	t.Run("Create requires required fields", func(t *testing.T) {
+		_, svc := newService()
+
+		_, err := svc.CreateTask(context.Background(), &models.Task{ProjectID: 2, Title: "B"})
+		if !errors.Is(err, ErrInvalidFieldValue) {
+			t.Errorf("CreateTask() error = %v, want %v", err, ErrInvalidFieldValue)
+		}
+	})
+
+	t.Run("Update merges and clears values", func(t *testing.T) {
+		_, svc := newService()
+
+		updated, err := svc.UpdateTask(context.Background(), &models.Task{ID: 1, Title: "A", CustomFields: map[string]models.FieldValue{
+			"points": {Type: models.FieldTypeNumber, Number: 13},
+			"env":    {},
+		}})
+		if err != nil {
+			t.Fatalf("UpdateTask() unexpected error: %v", err)
+		}
+		if len(updated.CustomFields) != 1 || updated.CustomFields["points"].Number != 13 {
+			t.Errorf("UpdateTask() custom fields = %+v, want only points 13", updated.CustomFields)
+		}
+	})
+
+	t.Run("Get types values from definitions", func(t *testing.T) {
+		_, svc := newService()
+
+		task, err := svc.GetTask(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("GetTask() unexpected error: %v", err)
+		}
+		if got := task.CustomFields["env"].Type; got != models.FieldTypeEnum {
+			t.Errorf("GetTask() env type = %q, want %q", got, models.FieldTypeEnum)
+		}
+	})
+
+	t.Run("List resolves filter types", func(t *testing.T) {
+		mockRepo, svc := newService()
+
+		_, err := svc.ListTasks(context.Background(), models.TaskFilter{
+			ProjectID:    1,
+			CustomFields: []models.FieldCondition{{Field: "env", Operator: "=", Value: models.FieldValue{Type: models.FieldTypeString, String: "prod"}}},
+			OrderBy:      &models.FieldOrder{Field: "points"},
+		})
+		if err != nil {
+			t.Fatalf("ListTasks() unexpected error: %v", err)
+		}
+		got := mockRepo.lastFilter
+		if got.CustomFields[0].Type != models.FieldTypeEnum || got.CustomFields[0].Value.Type != models.FieldTypeEnum ||
+			got.OrderBy.Type != models.FieldTypeNumber {
+			t.Errorf("ListTasks() filter = %+v, want resolved types", got)
+		}
+	})
+
+	t.Run("List rejects number filter with string value", func(t *testing.T) {
+		_, svc := newService()
+
+		_, err := svc.ListTasks(context.Background(), models.TaskFilter{
+			ProjectID:    1,
+			CustomFields: []models.FieldCondition{{Field: "points", Operator: ">", Value: models.FieldValue{Type: models.FieldTypeString, String: "3"}}},
+		})
+		if !errors.Is(err, ErrInvalidFieldValue) {
+			t.Errorf("ListTasks() error = %v, want %v", err, ErrInvalidFieldValue)
+		}
+	})
+
+	t.Run("List rejects custom fields without project", func(t *testing.T) {
+		_, svc := newService()
+
+		_, err := svc.ListTasks(context.Background(), models.TaskFilter{OrderBy: &models.FieldOrder{Field: "points"}})
+		if !errors.Is(err, ErrUnknownCustomField) {
+			t.Errorf("ListTasks() error = %v, want %v", err, ErrUnknownCustomField)
+		}
+	})
+
+	t.Run("Transfer keeps only values valid in the target", func(t *testing.T) {
+		mockRepo, svc := newService()
+		mockRepo.tasks[1].CustomFields["team"] = models.FieldValue{Type: models.FieldTypeString, String: "core"}
+
+		transferred, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 1, ProjectID: 2})
+		if err != nil {
+			t.Fatalf("TransferTask() unexpected error: %v", err)
+		}
+		want := map[string]models.FieldValue{"team": {Type: models.FieldTypeString, String: "core"}}
+		if len(transferred.CustomFields) != 1 || transferred.CustomFields["team"] != want["team"] {
+			t.Errorf("TransferTask() custom fields = %+v, want %+v", transferred.CustomFields, want)
+		}
+	})
+
+	t.Run("Transfer requires the target's required fields", func(t *testing.T) {
+		_, svc := newService()
+
+		_, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 1, ProjectID: 2})
+		if !errors.Is(err, ErrInvalidFieldValue) {
+			t.Errorf("TransferTask() error = %v, want %v", err, ErrInvalidFieldValue)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with the same idempotency key is still in progress")
+)
+
+// abandonedClaimGrace is how long a request may hold its idempotency key past its deadline, time to
+// notice the deadline and release the key, before the key is considered abandoned, for example by a
+// replica that crashed, and can be claimed again.
+const abandonedClaimGrace = 30 * time.Second
+
+type IdempotencyService interface {
+	// Begin claims key for the request identified by requestHash. It returns the claim, which is not
+	// completed, when the caller should process the request, and the completed record to replay when the
+	// same request already succeeded.
+	Begin(ctx context.Context, key string, requestHash []byte) (*models.IdempotencyRecord, error)
+	// Complete stores the response of a request that claimed its key with Begin.
+	Complete(ctx context.Context, claim *models.IdempotencyRecord, responseType string, response []byte) error
+	// Release gives up the key of a request that failed, so that a retry processes it again.
+	Release(ctx context.Context, claim *models.IdempotencyRecord) error
+}
+
+type idempotencyService struct {
+	repo           repository.IdempotencyRepository
+	ttl            time.Duration
+	requestTimeout time.Duration
+	logger         *zap.Logger
+}
+
+// NewIdempotencyService creates a service that remembers responses for ttl after their request.
+// requestTimeout is the longest a request may run. A claim whose request has run out of time is taken
+// over by the next request with its key; without a timeout, claims are only given up by their request
+// or when they expire.
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl, requestTimeout time.Duration, logger *zap.Logger) IdempotencyService {
+	return &idempotencyService{
+		repo:           repo,
+		ttl:            ttl,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, key string, requestHash []byte) (*models.IdempotencyRecord, error) {
+	now := time.Now()
+	record := &models.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: now, ExpiresAt: now.Add(s.ttl)}
+
+	var staleBefore time.Time
+	if s.requestTimeout > 0 {
+		staleBefore = now.Add(-s.requestTimeout - abandonedClaimGrace)
+	}
+	existing, err := s.repo.ClaimKey(ctx, record, staleBefore)
+	if err != nil {
+		s.logger.Error("Failed to claim idempotency key", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+	if existing == nil {
+		return record, nil
+	}
+	if !bytes.Equal(existing.RequestHash, requestHash) {
+		s.logger.Warn("Idempotency key reused for a different request", zap.String("key", key))
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !existing.Completed() {
+		s.logger.Warn("Request with idempotency key still in progress", zap.String("key", key))
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	s.logger.Info("Replaying response for idempotency key", zap.String("key", key))
+	return existing, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, claim *models.IdempotencyRecord, responseType string, response []byte) error {
+	if err := s.repo.CompleteKey(ctx, claim, responseType, response); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Idempotency key was claimed again before its request completed", zap.String("key", claim.Key))
+			return nil
+		}
+		s.logger.Error("Failed to store response for idempotency key", zap.String("key", claim.Key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *idempotencyService) Release(ctx context.Context, claim *models.IdempotencyRecord) error {
+	if err := s.repo.ReleaseKey(ctx, claim); err != nil {
+		s.logger.Error("Failed to release idempotency key", zap.String("key", claim.Key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// IdempotencyPurger periodically deletes expired idempotency keys. Expired keys can be claimed again
+// even before they are purged; purging only keeps the table small.
+type IdempotencyPurger struct {
+	repo     repository.IdempotencyRepository
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func NewIdempotencyPurger(repo repository.IdempotencyRepository, interval time.Duration, logger *zap.Logger) *IdempotencyPurger {
+	return &IdempotencyPurger{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run purges expired keys every interval until ctx is cancelled.
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	p.logger.Info("Starting idempotency key purger", zap.Duration("interval", p.interval))
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Stopping idempotency key purger")
+			return
+		case <-ticker.C:
+			if err := p.PurgeOnce(ctx); err != nil {
+				p.logger.Error("Purging idempotency keys failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// PurgeOnce deletes the keys that have expired.
+func (p *IdempotencyPurger) PurgeOnce(ctx context.Context) error {
+	deleted, err := p.repo.DeleteExpiredKeys(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		p.logger.Info("Purged expired idempotency keys", zap.Int64("count", deleted))
+	}
+	return nil
+}
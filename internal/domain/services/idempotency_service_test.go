@@ -0,0 +1,201 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+type mockIdempotencyRepository struct {
+	records map[string]*models.IdempotencyRecord
+	err     error
+}
+
+func (m *mockIdempotencyRepository) ClaimKey(ctx context.Context, record *models.IdempotencyRecord,
+	staleBefore time.Time) (*models.IdempotencyRecord, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	existing, exists := m.records[record.Key]
+	if exists && existing.ExpiresAt.After(record.CreatedAt) &&
+		(existing.Completed() || staleBefore.IsZero() || !existing.CreatedAt.Before(staleBefore)) {
+		return existing, nil
+	}
+	claim := *record
+	m.records[record.Key] = &claim
+	return nil, nil
+}
+
+// claimed returns the record of key if claim still holds it.
+func (m *mockIdempotencyRepository) claimed(claim *models.IdempotencyRecord) (*models.IdempotencyRecord, bool) {
+	record, exists := m.records[claim.Key]
+	if !exists || record.Completed() || !record.CreatedAt.Equal(claim.CreatedAt) || !bytes.Equal(record.RequestHash, claim.RequestHash) {
+		return nil, false
+	}
+	return record, true
+}
+
+func (m *mockIdempotencyRepository) CompleteKey(ctx context.Context, claim *models.IdempotencyRecord, responseType string,
+	response []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	record, ok := m.claimed(claim)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	record.ResponseType = responseType
+	record.Response = response
+	return nil
+}
+
+func (m *mockIdempotencyRepository) ReleaseKey(ctx context.Context, claim *models.IdempotencyRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.claimed(claim); ok {
+		delete(m.records, claim.Key)
+	}
+	return nil
+}
+
+func (m *mockIdempotencyRepository) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	var deleted int64
+	for key, record := range m.records {
+		if !record.ExpiresAt.After(now) {
+			delete(m.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func Test_idempotencyService(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	now := time.Now()
+	mockRepo := &mockIdempotencyRepository{records: map[string]*models.IdempotencyRecord{
+		"done":      {Key: "done", RequestHash: []byte("a"), ResponseType: "taskmanager.TaskResponse", Response: []byte("resp"), CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		"running":   {Key: "running", RequestHash: []byte("a"), CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		"abandoned": {Key: "abandoned", RequestHash: []byte("a"), CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)},
+		"expired":   {Key: "expired", RequestHash: []byte("b"), ResponseType: "taskmanager.TaskResponse", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	}}
+	svc := NewIdempotencyService(mockRepo, time.Hour, time.Minute, logger)
+	claims := make(map[string]*models.IdempotencyRecord)
+
+	tests := []struct {
+		name       string
+		key        string
+		hash       string
+		wantReplay bool
+		wantErr    error
+	}{
+		{name: "New key is claimed", key: "new", hash: "a"},
+		{name: "Completed request is replayed", key: "done", hash: "a", wantReplay: true},
+		{name: "Different request is rejected", key: "done", hash: "b", wantErr: ErrIdempotencyKeyReused},
+		{name: "Request in progress", key: "running", hash: "a", wantErr: ErrIdempotencyKeyInProgress},
+		{name: "Abandoned claim is taken over", key: "abandoned", hash: "a"},
+		{name: "Expired key is reused", key: "expired", hash: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := svc.Begin(ctx, tt.key, []byte(tt.hash))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Begin() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if record.Completed() != tt.wantReplay {
+				t.Errorf("Begin() record = %+v, want replay %v", record, tt.wantReplay)
+			}
+			claims[tt.key] = record
+		})
+	}
+
+	if err := svc.Complete(ctx, claims["new"], "taskmanager.TaskResponse", []byte("created")); err != nil {
+		t.Fatalf("Complete() unexpected error: %v", err)
+	}
+	record, err := svc.Begin(ctx, "new", []byte("a"))
+	if err != nil || !record.Completed() || string(record.Response) != "created" {
+		t.Errorf("Begin() after Complete() = %+v, %v, want the stored response", record, err)
+	}
+
+	if err := svc.Release(ctx, claims["abandoned"]); err != nil {
+		t.Fatalf("Release() unexpected error: %v", err)
+	}
+	if _, err := svc.Begin(ctx, "abandoned", []byte("c")); err != nil {
+		t.Errorf("Begin() after Release() error = %v, want the key free", err)
+	}
+}
+
+func Test_idempotencyService_AbandonedClaims(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	now := time.Now()
+	newRepo := func() *mockIdempotencyRepository {
+		return &mockIdempotencyRepository{records: map[string]*models.IdempotencyRecord{
+			"slow": {Key: "slow", RequestHash: []byte("a"), CreatedAt: now.Add(-2 * time.Minute), ExpiresAt: now.Add(time.Hour)},
+		}}
+	}
+
+	t.Run("Claim within the request timeout is kept", func(t *testing.T) {
+		svc := NewIdempotencyService(newRepo(), time.Hour, 5*time.Minute, logger)
+		if _, err := svc.Begin(ctx, "slow", []byte("a")); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+			t.Errorf("Begin() error = %v, want %v", err, ErrIdempotencyKeyInProgress)
+		}
+	})
+
+	t.Run("Claim is kept without a request timeout", func(t *testing.T) {
+		svc := NewIdempotencyService(newRepo(), time.Hour, 0, logger)
+		if _, err := svc.Begin(ctx, "slow", []byte("a")); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+			t.Errorf("Begin() error = %v, want %v", err, ErrIdempotencyKeyInProgress)
+		}
+	})
+
+	t.Run("Taken over claim is not settled by its request", func(t *testing.T) {
+		mockRepo := newRepo()
+		abandoned := *mockRepo.records["slow"]
+		svc := NewIdempotencyService(mockRepo, time.Hour, time.Second, logger)
+
+		claim, err := svc.Begin(ctx, "slow", []byte("a"))
+		if err != nil || claim.Completed() {
+			t.Fatalf("Begin() = %+v, %v, want the abandoned claim taken over", claim, err)
+		}
+		if err := svc.Complete(ctx, &abandoned, "taskmanager.TaskResponse", []byte("late")); err != nil {
+			t.Fatalf("Complete() unexpected error: %v", err)
+		}
+		if err := svc.Release(ctx, &abandoned); err != nil {
+			t.Fatalf("Release() unexpected error: %v", err)
+		}
+		if record := mockRepo.records["slow"]; record == nil || record.Completed() || !record.CreatedAt.Equal(claim.CreatedAt) {
+			t.Errorf("Record after settling the abandoned claim = %+v, want the new claim", record)
+		}
+	})
+}
+
+func TestIdempotencyPurger_PurgeOnce(t *testing.T) {
+	now := time.Now()
+	mockRepo := &mockIdempotencyRepository{records: map[string]*models.IdempotencyRecord{
+		"fresh":   {Key: "fresh", ExpiresAt: now.Add(time.Hour)},
+		"expired": {Key: "expired", ExpiresAt: now.Add(-time.Minute)},
+	}}
+	purger := NewIdempotencyPurger(mockRepo, time.Hour, zaptest.NewLogger(t))
+
+	if err := purger.PurgeOnce(context.Background()); err != nil {
+		t.Fatalf("PurgeOnce() unexpected error: %v", err)
+	}
+	if _, exists := mockRepo.records["expired"]; exists || len(mockRepo.records) != 1 {
+		t.Errorf("PurgeOnce() left %d keys, want only the fresh one", len(mockRepo.records))
+	}
+}
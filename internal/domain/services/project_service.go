@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrProjectNotFound   = errors.New("project not found")
+	ErrProjectArchived   = errors.New("project is archived")
+	ErrProjectNotEmpty   = errors.New("project still has tasks")
+	ErrProjectKeyTaken   = errors.New("project key is already taken")
+	ErrProjectCreateFail = errors.New("failed to create project")
+	ErrProjectUpdateFail = errors.New("failed to update project")
+	ErrProjectDeleteFail = errors.New("failed to delete project")
+)
+
+type ProjectService interface {
+	CreateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	ListProjects(ctx context.Context) ([]*models.Project, error)
+	GetProject(ctx context.Context, id int64) (*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	SetProjectArchived(ctx context.Context, id int64, archived bool) (*models.Project, error)
+	DeleteProject(ctx context.Context, id int64) error
+}
+
+type projectService struct {
+	repo   repository.ProjectRepository
+	logger *zap.Logger
+}
+
+func NewProjectService(repo repository.ProjectRepository, logger *zap.Logger) ProjectService {
+	return &projectService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *projectService) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	s.logger.Info("Creating project", zap.String("name", project.Name))
+
+	if len(project.Workflow) == 0 {
+		project.Workflow = slices.Clone(models.DefaultWorkflow)
+	}
+
+	createdProject, err := s.repo.CreateProject(ctx, project)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			s.logger.Warn("Project key is already taken", zap.String("key", project.Key))
+			return nil, ErrProjectKeyTaken
+		}
+		s.logger.Error("Failed to create project", zap.Error(err))
+		return nil, ErrProjectCreateFail
+	}
+
+	return createdProject, nil
+}
+
+func (s *projectService) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	s.logger.Info("Listing projects")
+
+	projects, err := s.repo.ListProjects(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list projects", zap.Error(err))
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (s *projectService) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	s.logger.Info("Fetching project", zap.Int64("id", id))
+
+	project, err := s.repo.GetProject(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found", zap.Int64("id", id))
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// UpdateProject replaces the project's name, description and settings.
+// Archived projects are read-only until they are unarchived.
+func (s *projectService) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	s.logger.Info("Updating project", zap.Int64("id", project.ID))
+
+	existing, err := s.GetProject(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Archived {
+		s.logger.Warn("Refusing to update archived project", zap.Int64("id", project.ID))
+		return nil, ErrProjectArchived
+	}
+
+	if len(project.Workflow) == 0 {
+		project.Workflow = existing.Workflow
+	}
+	project.Archived = existing.Archived
+
+	updatedProject, err := s.repo.UpdateProject(ctx, project)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found for update", zap.Int64("id", project.ID))
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to update project", zap.Error(err))
+		return nil, ErrProjectUpdateFail
+	}
+
+	return updatedProject, nil
+}
+
+// SetProjectArchived archives or unarchives a project. Tasks of an archived project are read-only.
+func (s *projectService) SetProjectArchived(ctx context.Context, id int64, archived bool) (*models.Project, error) {
+	s.logger.Info("Changing project archive state", zap.Int64("id", id), zap.Bool("archived", archived))
+
+	project, err := s.GetProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived == archived {
+		return project, nil
+	}
+	project.Archived = archived
+
+	updatedProject, err := s.repo.UpdateProject(ctx, project)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to change project archive state", zap.Error(err))
+		return nil, ErrProjectUpdateFail
+	}
+
+	return updatedProject, nil
+}
+
+// DeleteProject removes an empty project. Projects that still own tasks cannot be deleted.
+func (s *projectService) DeleteProject(ctx context.Context, id int64) error {
+	s.logger.Info("Deleting project", zap.Int64("id", id))
+
+	err := s.repo.DeleteProject(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found for deletion", zap.Int64("id", id))
+			return ErrProjectNotFound
+		}
+		if errors.Is(err, repository.ErrNotEmpty) {
+			s.logger.Warn("Project still has tasks", zap.Int64("id", id))
+			return ErrProjectNotEmpty
+		}
+		s.logger.Error("Failed to delete project", zap.Error(err))
+		return ErrProjectDeleteFail
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_projectService_CreateProject(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockProjectRepository{projects: make(map[int64]*models.Project)}
+
+	svc := NewProjectService(mockRepo, logger)
+
+	project, err := svc.CreateProject(context.Background(), &models.Project{Name: "Ops"})
+	if err != nil {
+		t.Fatalf("CreateProject() unexpected error: %v", err)
+	}
+	if len(project.Workflow) != len(models.DefaultWorkflow) {
+		t.Errorf("CreateProject() got workflow %v, want %v", project.Workflow, models.DefaultWorkflow)
+	}
+}
+
+func Test_projectService_UpdateProject(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Name: "Ops", Workflow: []string{"open", "done"}},
+			2: {ID: 2, Name: "Archived", Archived: true},
+		},
+	}
+
+	svc := NewProjectService(mockRepo, logger)
+
+	tests := []struct {
+		name    string
+		project *models.Project
+		wantErr error
+	}{
+		{
+			name:    "Update existing project",
+			project: &models.Project{ID: 1, Name: "Operations"},
+			wantErr: nil,
+		},
+		{
+			name:    "Update archived project",
+			project: &models.Project{ID: 2, Name: "Renamed"},
+			wantErr: ErrProjectArchived,
+		},
+		{
+			name:    "Update non-existing project",
+			project: &models.Project{ID: 3, Name: "Missing"},
+			wantErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdateProject(context.Background(), tt.project)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateProject() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := mockRepo.projects[1].Workflow; len(got) != 2 {
+		t.Errorf("UpdateProject() should keep the workflow when none is given, got %v", got)
+	}
+}
+
+func Test_projectService_SetProjectArchived(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Name: "Ops"},
+		},
+	}
+
+	svc := NewProjectService(mockRepo, logger)
+
+	project, err := svc.SetProjectArchived(context.Background(), 1, true)
+	if err != nil {
+		t.Fatalf("SetProjectArchived() unexpected error: %v", err)
+	}
+	if !project.Archived {
+		t.Errorf("SetProjectArchived() did not archive the project")
+	}
+}
+
+func Test_projectService_DeleteProject(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Name: "Empty"},
+			2: {ID: 2, Name: "Busy"},
+		},
+	}
+	mockRepo.projectsWithTasks = map[int64]bool{2: true}
+
+	svc := NewProjectService(mockRepo, logger)
+
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{
+			name:    "Delete empty project",
+			id:      1,
+			wantErr: nil,
+		},
+		{
+			name:    "Delete project with tasks",
+			id:      2,
+			wantErr: ErrProjectNotEmpty,
+		},
+		{
+			name:    "Delete non-existing project",
+			id:      3,
+			wantErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.DeleteProject(context.Background(), tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteProject() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/rank"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// RankRebalancer periodically rewrites the rank keys of board columns whose keys grew too long.
+// Repeated moves between the same two tasks make keys longer by one digit every few moves;
+// rebalancing spreads the keys of a column evenly again while keeping the task order.
+type RankRebalancer struct {
+	repo      repository.TaskRepository
+	maxLength int
+	interval  time.Duration
+	logger    *zap.Logger
+}
+
+func NewRankRebalancer(repo repository.TaskRepository, maxLength int, interval time.Duration, logger *zap.Logger) *RankRebalancer {
+	return &RankRebalancer{
+		repo:      repo,
+		maxLength: maxLength,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Run rebalances columns every interval until ctx is cancelled.
+func (r *RankRebalancer) Run(ctx context.Context) {
+	r.logger.Info("Starting rank rebalancer", zap.Duration("interval", r.interval), zap.Int("max_length", r.maxLength))
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Stopping rank rebalancer")
+			return
+		case <-ticker.C:
+			if err := r.RebalanceOnce(ctx); err != nil {
+				r.logger.Error("Rank rebalancing failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// RebalanceOnce rewrites the ranks of every column that holds a key longer than the configured maximum.
+func (r *RankRebalancer) RebalanceOnce(ctx context.Context) error {
+	columns, err := r.repo.ColumnsWithLongRanks(ctx, r.maxLength)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		r.logger.Info("Rebalancing column", zap.Int64("project_id", column.ProjectID), zap.String("status", column.Status))
+		if err := r.repo.RebalanceColumn(ctx, column, rank.Spread); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
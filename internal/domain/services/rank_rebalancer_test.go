@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRankRebalancer_RebalanceOnce(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			1: {ID: 1, Status: "open", Rank: "a"},
+			2: {ID: 2, Status: "open", Rank: "azzzzzzzzzzzzzzzzzzi"},
+			3: {ID: 3, Status: "open", Rank: "b"},
+			4: {ID: 4, Status: "done", Rank: "i"},
+		},
+	}
+
+	rebalancer := NewRankRebalancer(mockRepo, 8, time.Minute, logger)
+	if err := rebalancer.RebalanceOnce(context.Background()); err != nil {
+		t.Fatalf("RebalanceOnce() unexpected error: %v", err)
+	}
+
+	if !(mockRepo.tasks[1].Rank < mockRepo.tasks[2].Rank && mockRepo.tasks[2].Rank < mockRepo.tasks[3].Rank) {
+		t.Errorf("RebalanceOnce() changed the task order: %q %q %q",
+			mockRepo.tasks[1].Rank, mockRepo.tasks[2].Rank, mockRepo.tasks[3].Rank)
+	}
+	if len(mockRepo.tasks[2].Rank) > 8 {
+		t.Errorf("RebalanceOnce() left a long rank %q", mockRepo.tasks[2].Rank)
+	}
+	if mockRepo.tasks[4].Rank != "i" {
+		t.Errorf("RebalanceOnce() touched a column with short ranks")
+	}
+}
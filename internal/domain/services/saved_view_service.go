@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSavedViewNotFound   = errors.New("saved view not found")
+	ErrSavedViewNameTaken  = errors.New("saved view name is already taken")
+	ErrSavedViewForbidden  = errors.New("only the owner can change a saved view")
+	ErrInvalidSavedView    = errors.New("invalid saved view")
+	ErrSavedViewBroken     = errors.New("saved view is broken")
+	ErrSavedViewCreateFail = errors.New("failed to create saved view")
+	ErrSavedViewUpdateFail = errors.New("failed to update saved view")
+	ErrSavedViewDeleteFail = errors.New("failed to delete saved view")
+)
+
+// taskColumnNames are the task columns saved views and exports can show besides custom fields.
+var taskColumnNames = []string{"id", "key", "external_id", "project_id", "title", "description", "status", "assignee",
+	"labels", "rank", "created_at", "updated_at"}
+
+type SavedViewService interface {
+	CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error)
+	GetSavedView(ctx context.Context, id int64, user string) (*models.SavedView, error)
+	ListSavedViews(ctx context.Context, user string, projectID int64) ([]*models.SavedView, error)
+	UpdateSavedView(ctx context.Context, view *models.SavedView, user string) (*models.SavedView, error)
+	DeleteSavedView(ctx context.Context, id int64, user string) error
+	// ExecuteView lists a page of the tasks of a view returned by GetSavedView.
+	ExecuteView(ctx context.Context, view *models.SavedView, limit int, after *models.TaskCursor) ([]*models.Task, error)
+}
+
+type savedViewService struct {
+	repo     repository.SavedViewRepository
+	projects repository.ProjectRepository
+	fields   repository.CustomFieldRepository
+	tasks    TaskService
+	logger   *zap.Logger
+}
+
+func NewSavedViewService(repo repository.SavedViewRepository, projects repository.ProjectRepository,
+	fields repository.CustomFieldRepository, tasks TaskService, logger *zap.Logger) SavedViewService {
+	return &savedViewService{
+		repo:     repo,
+		projects: projects,
+		fields:   fields,
+		tasks:    tasks,
+		logger:   logger,
+	}
+}
+
+// CreateSavedView stores a view for its owner. Views must be valid when they are saved: their filter,
+// order and columns may only refer to existing custom fields and allowed labels.
+func (s *savedViewService) CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	s.logger.Info("Creating saved view", zap.String("owner", view.Owner), zap.String("name", view.Name))
+
+	if err := s.validate(ctx, view); err != nil {
+		return nil, err
+	}
+
+	createdView, err := s.repo.CreateSavedView(ctx, view)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			s.logger.Warn("Saved view name is already taken", zap.String("name", view.Name))
+			return nil, ErrSavedViewNameTaken
+		}
+		s.logger.Error("Failed to create saved view", zap.Error(err))
+		return nil, ErrSavedViewCreateFail
+	}
+
+	return createdView, nil
+}
+
+// GetSavedView returns a view owned by user or shared, along with its broken parts.
+// Views of other users that are not shared are reported as not found.
+func (s *savedViewService) GetSavedView(ctx context.Context, id int64, user string) (*models.SavedView, error) {
+	s.logger.Info("Fetching saved view", zap.Int64("id", id), zap.String("user", user))
+
+	view, err := s.view(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if view.Owner != user && !view.Shared {
+		s.logger.Warn("Saved view is private", zap.Int64("id", id), zap.String("user", user))
+		return nil, ErrSavedViewNotFound
+	}
+	if view.Problems, err = s.problems(ctx, view); err != nil {
+		return nil, err
+	}
+
+	return view, nil
+}
+
+func (s *savedViewService) ListSavedViews(ctx context.Context, user string, projectID int64) ([]*models.SavedView, error) {
+	s.logger.Info("Listing saved views", zap.String("user", user), zap.Int64("project_id", projectID))
+
+	views, err := s.repo.ListSavedViews(ctx, user, projectID)
+	if err != nil {
+		s.logger.Error("Failed to list saved views", zap.Error(err))
+		return nil, err
+	}
+	for _, view := range views {
+		if view.Problems, err = s.problems(ctx, view); err != nil {
+			return nil, err
+		}
+	}
+
+	return views, nil
+}
+
+// UpdateSavedView replaces the definition of a view; only its owner can do so and the owner cannot change.
+func (s *savedViewService) UpdateSavedView(ctx context.Context, view *models.SavedView, user string) (*models.SavedView, error) {
+	s.logger.Info("Updating saved view", zap.Int64("id", view.ID), zap.String("user", user))
+
+	if _, err := s.ownedView(ctx, view.ID, user); err != nil {
+		return nil, err
+	}
+	view.Owner = user
+	if err := s.validate(ctx, view); err != nil {
+		return nil, err
+	}
+
+	updatedView, err := s.repo.UpdateSavedView(ctx, view)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSavedViewNotFound
+		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			s.logger.Warn("Saved view name is already taken", zap.String("name", view.Name))
+			return nil, ErrSavedViewNameTaken
+		}
+		s.logger.Error("Failed to update saved view", zap.Error(err))
+		return nil, ErrSavedViewUpdateFail
+	}
+
+	return updatedView, nil
+}
+
+func (s *savedViewService) DeleteSavedView(ctx context.Context, id int64, user string) error {
+	s.logger.Info("Deleting saved view", zap.Int64("id", id), zap.String("user", user))
+
+	if _, err := s.ownedView(ctx, id, user); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSavedView(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSavedViewNotFound
+		}
+		s.logger.Error("Failed to delete saved view", zap.Error(err))
+		return ErrSavedViewDeleteFail
+	}
+
+	return nil
+}
+
+// ExecuteView lists the tasks of a view as ListTasks would. Views whose filter or order is broken
+// cannot run; broken columns do not matter for the tasks returned.
+func (s *savedViewService) ExecuteView(ctx context.Context, view *models.SavedView, limit int, after *models.TaskCursor) ([]*models.Task, error) {
+	s.logger.Info("Executing saved view", zap.Int64("id", view.ID))
+
+	var broken []string
+	for _, problem := range view.Problems {
+		if problem.Part != models.ViewPartColumns {
+			broken = append(broken, problem.Part+": "+problem.Message)
+		}
+	}
+	if len(broken) > 0 {
+		s.logger.Warn("Saved view is broken", zap.Int64("id", view.ID), zap.Strings("problems", broken))
+		return nil, fmt.Errorf("%w: %s", ErrSavedViewBroken, strings.Join(broken, "; "))
+	}
+
+	filter := models.TaskFilter{ProjectID: view.ProjectID, Expression: view.Filter, Limit: limit, After: after}
+	if view.OrderBy != nil {
+		order := *view.OrderBy
+		filter.OrderBy = &order
+	}
+	return s.tasks.ListTasks(ctx, filter)
+}
+
+// validate checks a view about to be saved: its project must exist and no part of it may be broken.
+func (s *savedViewService) validate(ctx context.Context, view *models.SavedView) error {
+	if view.ProjectID != 0 {
+		if _, err := s.project(ctx, view.ProjectID); err != nil {
+			return err
+		}
+	}
+
+	problems, err := s.problems(ctx, view)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		reasons := make([]string, len(problems))
+		for i, problem := range problems {
+			reasons[i] = problem.Part + ": " + problem.Message
+		}
+		s.logger.Warn("Invalid saved view", zap.String("name", view.Name), zap.Strings("problems", reasons))
+		return fmt.Errorf("%w: %s", ErrInvalidSavedView, strings.Join(reasons, "; "))
+	}
+	return nil
+}
+
+// problems checks the parts of a view against the current custom fields and allowed labels of its project.
+func (s *savedViewService) problems(ctx context.Context, view *models.SavedView) ([]models.ViewProblem, error) {
+	var project *models.Project
+	var fields map[string]*models.CustomField
+	if view.ProjectID != 0 {
+		var err error
+		if project, err = s.project(ctx, view.ProjectID); err != nil {
+			return nil, err
+		}
+		if fields, err = s.customFields(ctx, view.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	var problems []models.ViewProblem
+	if view.Filter != "" {
+		node, err := parseFilter(view.Filter)
+		var where *models.FilterExpr
+		if err == nil {
+			where, err = checkFilter(node, fields)
+		}
+		if err != nil {
+			problems = append(problems, models.ViewProblem{Part: models.ViewPartFilter, Message: err.Error()})
+		} else if project != nil && len(project.AllowedLabels) > 0 {
+			for _, label := range filterLabels(where) {
+				if !slices.Contains(project.AllowedLabels, label) {
+					problems = append(problems, models.ViewProblem{Part: models.ViewPartFilter,
+						Message: fmt.Sprintf("label %q is not allowed in the project", label)})
+				}
+			}
+		}
+	}
+	if view.OrderBy != nil {
+		if fields == nil {
+			problems = append(problems, models.ViewProblem{Part: models.ViewPartOrderBy,
+				Message: "custom fields can only be sorted by within a project"})
+		} else if _, ok := fields[view.OrderBy.Field]; !ok {
+			problems = append(problems, models.ViewProblem{Part: models.ViewPartOrderBy,
+				Message: fmt.Sprintf("unknown custom field %q", view.OrderBy.Field)})
+		}
+	}
+	for _, column := range view.Columns {
+		if name, ok := strings.CutPrefix(column, customFieldPrefix); ok {
+			if _, ok := fields[name]; !ok {
+				problems = append(problems, models.ViewProblem{Part: models.ViewPartColumns,
+					Message: fmt.Sprintf("unknown custom field %q", name)})
+			}
+		} else if !slices.Contains(taskColumnNames, column) {
+			problems = append(problems, models.ViewProblem{Part: models.ViewPartColumns,
+				Message: fmt.Sprintf("unknown column %q", column)})
+		}
+	}
+	return problems, nil
+}
+
+// filterLabels returns the labels a filter looks for.
+func filterLabels(expr *models.FilterExpr) []string {
+	if expr.Logic != "" {
+		var labels []string
+		for _, operand := range expr.Operands {
+			labels = append(labels, filterLabels(operand)...)
+		}
+		return labels
+	}
+	if label, ok := expr.Value.(string); ok && expr.Field == "labels" && !expr.Custom {
+		return []string{label}
+	}
+	return nil
+}
+
+func (s *savedViewService) view(ctx context.Context, id int64) (*models.SavedView, error) {
+	view, err := s.repo.GetSavedView(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Saved view not found", zap.Int64("id", id))
+			return nil, ErrSavedViewNotFound
+		}
+		s.logger.Error("Failed to fetch saved view", zap.Error(err))
+		return nil, err
+	}
+	return view, nil
+}
+
+// ownedView loads a view that user may change. Private views of others are reported as not found
+// and shared ones as forbidden.
+func (s *savedViewService) ownedView(ctx context.Context, id int64, user string) (*models.SavedView, error) {
+	view, err := s.view(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if view.Owner != user {
+		if !view.Shared {
+			return nil, ErrSavedViewNotFound
+		}
+		s.logger.Warn("Saved view belongs to another user", zap.Int64("id", id), zap.String("user", user))
+		return nil, ErrSavedViewForbidden
+	}
+	return view, nil
+}
+
+func (s *savedViewService) project(ctx context.Context, id int64) (*models.Project, error) {
+	project, err := s.projects.GetProject(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found", zap.Int64("project_id", id))
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *savedViewService) customFields(ctx context.Context, projectID int64) (map[string]*models.CustomField, error) {
+	fields, err := s.fields.ListCustomFields(ctx, projectID)
+	if err != nil {
+		s.logger.Error("Failed to list custom fields", zap.Int64("project_id", projectID), zap.Error(err))
+		return nil, err
+	}
+	byName := make(map[string]*models.CustomField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	return byName, nil
+}
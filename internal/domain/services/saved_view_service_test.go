@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap/zaptest"
+)
+
+type mockSavedViewRepository struct {
+	views map[int64]*models.SavedView
+	err   error
+}
+
+func (m *mockSavedViewRepository) CreateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, existing := range m.views {
+		if existing.Owner == view.Owner && existing.Name == view.Name {
+			return nil, repository.ErrAlreadyExists
+		}
+	}
+	view.ID = int64(len(m.views) + 1)
+	m.views[view.ID] = view
+	return view, nil
+}
+
+func (m *mockSavedViewRepository) GetSavedView(ctx context.Context, id int64) (*models.SavedView, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	view, ok := m.views[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	stored := *view
+	return &stored, nil
+}
+
+func (m *mockSavedViewRepository) ListSavedViews(ctx context.Context, owner string, projectID int64) ([]*models.SavedView, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var viewList []*models.SavedView
+	for _, view := range m.views {
+		if (view.Owner == owner || view.Shared) && (projectID == 0 || view.ProjectID == projectID) {
+			stored := *view
+			viewList = append(viewList, &stored)
+		}
+	}
+	sort.Slice(viewList, func(i, j int) bool { return viewList[i].ID < viewList[j].ID })
+	return viewList, nil
+}
+
+func (m *mockSavedViewRepository) UpdateSavedView(ctx context.Context, view *models.SavedView) (*models.SavedView, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if _, ok := m.views[view.ID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	m.views[view.ID] = view
+	return view, nil
+}
+
+func (m *mockSavedViewRepository) DeleteSavedView(ctx context.Context, id int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.views[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.views, id)
+	return nil
+}
+
+func Test_savedViewService(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockSavedViewRepository, *mockCustomFieldRepository, *mockTaskRepository, SavedViewService) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		mockProjects.projects[1].AllowedLabels = []string{"bug", "feature"}
+		mockViews := &mockSavedViewRepository{
+			views: map[int64]*models.SavedView{
+				1: {ID: 1, Owner: "alice", Name: "Big bugs", ProjectID: 1, Filter: `labels:bug AND custom_fields.points >= 5`,
+					OrderBy: &models.FieldOrder{Field: "points", Descending: true}, Columns: []string{"key", "custom_fields.env"}},
+				2: {ID: 2, Owner: "bob", Name: "Open", Filter: `status = open`, Shared: true},
+			},
+		}
+		mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{}}
+		tasks := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+		return mockViews, mockFields, mockRepo, NewSavedViewService(mockViews, mockProjects, mockFields, tasks, logger)
+	}
+
+	t.Run("Create rejects broken parts", func(t *testing.T) {
+		tests := []struct {
+			name string
+			view *models.SavedView
+		}{
+			{name: "Invalid filter", view: &models.SavedView{Owner: "alice", Name: "V", Filter: `status = `}},
+			{name: "Custom field outside a project", view: &models.SavedView{Owner: "alice", Name: "V", Filter: `custom_fields.points > 1`}},
+			{name: "Label not allowed", view: &models.SavedView{Owner: "alice", Name: "V", ProjectID: 1, Filter: `labels:chore`}},
+			{name: "Unknown order field", view: &models.SavedView{Owner: "alice", Name: "V", ProjectID: 1, OrderBy: &models.FieldOrder{Field: "size"}}},
+			{name: "Unknown column", view: &models.SavedView{Owner: "alice", Name: "V", Columns: []string{"priority"}}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, _, _, svc := newService()
+				if _, err := svc.CreateSavedView(context.Background(), tt.view); !errors.Is(err, ErrInvalidSavedView) {
+					t.Errorf("CreateSavedView() error = %v, want %v", err, ErrInvalidSavedView)
+				}
+			})
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		_, _, _, svc := newService()
+
+		created, err := svc.CreateSavedView(context.Background(), &models.SavedView{Owner: "carol", Name: "Mine", ProjectID: 1,
+			Filter: `custom_fields.env = prod`, Columns: []string{"title", "custom_fields.points"}})
+		if err != nil {
+			t.Fatalf("CreateSavedView() unexpected error: %v", err)
+		}
+		if created.ID == 0 {
+			t.Errorf("CreateSavedView() returned view without ID")
+		}
+
+		if _, err := svc.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "Big bugs"}); !errors.Is(err, ErrSavedViewNameTaken) {
+			t.Errorf("CreateSavedView() duplicate error = %v, want %v", err, ErrSavedViewNameTaken)
+		}
+		if _, err := svc.CreateSavedView(context.Background(), &models.SavedView{Owner: "alice", Name: "V", ProjectID: 9}); !errors.Is(err, ErrProjectNotFound) {
+			t.Errorf("CreateSavedView() in missing project error = %v, want %v", err, ErrProjectNotFound)
+		}
+	})
+
+	t.Run("Visibility and ownership", func(t *testing.T) {
+		_, _, _, svc := newService()
+
+		if _, err := svc.GetSavedView(context.Background(), 1, "bob"); !errors.Is(err, ErrSavedViewNotFound) {
+			t.Errorf("GetSavedView() of private view error = %v, want %v", err, ErrSavedViewNotFound)
+		}
+		if _, err := svc.GetSavedView(context.Background(), 2, "alice"); err != nil {
+			t.Errorf("GetSavedView() of shared view unexpected error: %v", err)
+		}
+		if _, err := svc.UpdateSavedView(context.Background(), &models.SavedView{ID: 2, Name: "Mine now"}, "alice"); !errors.Is(err, ErrSavedViewForbidden) {
+			t.Errorf("UpdateSavedView() of shared view error = %v, want %v", err, ErrSavedViewForbidden)
+		}
+		if err := svc.DeleteSavedView(context.Background(), 1, "bob"); !errors.Is(err, ErrSavedViewNotFound) {
+			t.Errorf("DeleteSavedView() of private view error = %v, want %v", err, ErrSavedViewNotFound)
+		}
+
+		views, err := svc.ListSavedViews(context.Background(), "alice", 0)
+		if err != nil {
+			t.Fatalf("ListSavedViews() unexpected error: %v", err)
+		}
+		if len(views) != 2 {
+			t.Errorf("ListSavedViews() got %d views, want own and shared", len(views))
+		}
+
+		updated, err := svc.UpdateSavedView(context.Background(), &models.SavedView{ID: 2, Name: "Open tasks", Filter: `status = open`}, "bob")
+		if err != nil {
+			t.Fatalf("UpdateSavedView() unexpected error: %v", err)
+		}
+		if updated.Owner != "bob" || updated.Shared {
+			t.Errorf("UpdateSavedView() = %+v, want bob's unshared view", updated)
+		}
+		if err := svc.DeleteSavedView(context.Background(), 2, "bob"); err != nil {
+			t.Errorf("DeleteSavedView() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Broken parts are reported and block execution", func(t *testing.T) {
+		_, mockFields, _, svc := newService()
+		delete(mockFields.fields, 1) // points
+		delete(mockFields.fields, 2) // env
+
+		view, err := svc.GetSavedView(context.Background(), 1, "alice")
+		if err != nil {
+			t.Fatalf("GetSavedView() unexpected error: %v", err)
+		}
+		var parts []string
+		for _, problem := range view.Problems {
+			parts = append(parts, problem.Part)
+		}
+		want := []string{models.ViewPartFilter, models.ViewPartOrderBy, models.ViewPartColumns}
+		if !reflect.DeepEqual(parts, want) {
+			t.Errorf("GetSavedView() problems = %+v, want parts %v", view.Problems, want)
+		}
+
+		if _, err := svc.ExecuteView(context.Background(), view, 0, nil); !errors.Is(err, ErrSavedViewBroken) {
+			t.Errorf("ExecuteView() error = %v, want %v", err, ErrSavedViewBroken)
+		}
+	})
+
+	t.Run("Execute lists tasks with the view's query", func(t *testing.T) {
+		_, _, mockRepo, svc := newService()
+
+		view, err := svc.GetSavedView(context.Background(), 1, "alice")
+		if err != nil {
+			t.Fatalf("GetSavedView() unexpected error: %v", err)
+		}
+		if len(view.Problems) != 0 {
+			t.Fatalf("GetSavedView() unexpected problems: %+v", view.Problems)
+		}
+		if _, err := svc.ExecuteView(context.Background(), view, 11, nil); err != nil {
+			t.Fatalf("ExecuteView() unexpected error: %v", err)
+		}
+
+		filter := mockRepo.lastFilter
+		if filter.ProjectID != 1 || filter.Limit != 11 || filter.Where == nil || filter.OrderBy == nil || !filter.OrderBy.Descending {
+			t.Errorf("ExecuteView() listed tasks with %+v, want the view's project, filter and order", filter)
+		}
+		if view.OrderBy.Type != "" {
+			t.Errorf("ExecuteView() changed the order of the view")
+		}
+	})
+}
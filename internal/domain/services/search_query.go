@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// MaxSearchTerms limits the number of terms in a search query.
+const MaxSearchTerms = 32
+
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+// parseSearchQuery splits a search query into terms. Words are separated by white space;
+// "quoted text" is a phrase, a trailing * makes a word a prefix and a leading - excludes a word
+// or phrase. An unterminated quote runs to the end of the query.
+func parseSearchQuery(query string) ([]models.SearchTerm, error) {
+	var terms []models.SearchTerm
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var term models.SearchTerm
+		if runes[i] == '-' {
+			term.Exclude = true
+			i++
+		}
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			term.Text = strings.TrimSpace(string(runes[i+1 : end]))
+			term.Phrase = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			term.Text = string(runes[i:end])
+			if strings.HasSuffix(term.Text, "*") {
+				term.Text = strings.TrimRight(term.Text, "*")
+				term.Prefix = true
+			}
+			i = end
+		}
+		if term.Text != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) > MaxSearchTerms {
+		return nil, fmt.Errorf("%w: at most %d terms are allowed", ErrInvalidSearchQuery, MaxSearchTerms)
+	}
+	for _, term := range terms {
+		if !term.Exclude {
+			return terms, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: the query needs at least one word to look for", ErrInvalidSearchQuery)
+}
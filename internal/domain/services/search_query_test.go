@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+type mockTaskSearcher struct {
+	hits       []*models.SearchHit
+	lastSearch models.TaskSearch
+	err        error
+}
+
+func (m *mockTaskSearcher) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	m.lastSearch = search
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.hits, nil
+}
+
+func Test_parseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []models.SearchTerm
+		wantErr error
+	}{
+		{
+			name:  "Words",
+			query: "deploy  api",
+			want:  []models.SearchTerm{{Text: "deploy"}, {Text: "api"}},
+		},
+		{
+			name:  "Phrase, prefix and exclusion",
+			query: `"release notes" migr* -draft -"on hold"`,
+			want: []models.SearchTerm{
+				{Text: "release notes", Phrase: true},
+				{Text: "migr", Prefix: true},
+				{Text: "draft", Exclude: true},
+				{Text: "on hold", Phrase: true, Exclude: true},
+			},
+		},
+		{
+			name:  "Unterminated quote",
+			query: `fix "login page`,
+			want:  []models.SearchTerm{{Text: "fix"}, {Text: "login page", Phrase: true}},
+		},
+		{
+			name:  "Empty terms are dropped",
+			query: `api "" * -`,
+			want:  []models.SearchTerm{{Text: "api"}},
+		},
+		{
+			name:    "Only exclusions",
+			query:   "-draft -wip",
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "No terms",
+			query:   `"" *`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSearchQuery(tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseSearchQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSearchQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_taskService_SearchTasks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockProjects := &mockProjectRepository{projects: map[int64]*models.Project{1: {ID: 1}}}
+	mockSearcher := &mockTaskSearcher{
+		hits: []*models.SearchHit{
+			{Task: &models.Task{ID: 1, ProjectID: 1, Title: "Deploy API"}, Score: 0.5, TitleSnippet: "<mark>Deploy</mark> API"},
+		},
+	}
+
+	svc := NewTaskService(&mockTaskRepository{}, mockProjects, &mockCustomFieldRepository{}, mockSearcher, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	hits, err := svc.SearchTasks(context.Background(), models.TaskSearch{Query: "deploy -draft", ProjectID: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchTasks() unexpected error: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Task.ID != 1 {
+		t.Errorf("SearchTasks() got %+v, want the searcher's hit", hits)
+	}
+	wantTerms := []models.SearchTerm{{Text: "deploy"}, {Text: "draft", Exclude: true}}
+	if !reflect.DeepEqual(mockSearcher.lastSearch.Terms, wantTerms) {
+		t.Errorf("SearchTasks() searched terms %+v, want %+v", mockSearcher.lastSearch.Terms, wantTerms)
+	}
+
+	if _, err := svc.SearchTasks(context.Background(), models.TaskSearch{Query: "deploy", ProjectID: 2}); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("SearchTasks() in missing project error = %v, want %v", err, ErrProjectNotFound)
+	}
+	if _, err := svc.SearchTasks(context.Background(), models.TaskSearch{Query: "-draft"}); !errors.Is(err, ErrInvalidSearchQuery) {
+		t.Errorf("SearchTasks() with only exclusions error = %v, want %v", err, ErrInvalidSearchQuery)
+	}
+}
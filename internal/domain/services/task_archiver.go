@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// TaskArchiver periodically moves closed tasks that have not changed for the retention period to the
+// archive, where they stay readable but no longer weigh on the queries of the live tasks. A task is
+// closed when it is in the last status of its project's workflow.
+type TaskArchiver struct {
+	repo      repository.TaskRepository
+	projects  repository.ProjectRepository
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+	logger    *zap.Logger
+}
+
+// NewTaskArchiver archives up to batchSize tasks per transaction, so that a large backlog of closed tasks
+// does not hold locks for long. batchSize must be positive.
+func NewTaskArchiver(repo repository.TaskRepository, projects repository.ProjectRepository, retention, interval time.Duration,
+	batchSize int, logger *zap.Logger) (*TaskArchiver, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("archive batch size must be positive, got %d", batchSize)
+	}
+	return &TaskArchiver{
+		repo:      repo,
+		projects:  projects,
+		retention: retention,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}, nil
+}
+
+// Run archives tasks every interval until ctx is cancelled.
+func (a *TaskArchiver) Run(ctx context.Context) {
+	a.logger.Info("Starting task archiver", zap.Duration("interval", a.interval), zap.Duration("retention", a.retention))
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.logger.Info("Stopping task archiver")
+			return
+		case <-ticker.C:
+			if err := a.ArchiveOnce(ctx); err != nil {
+				a.logger.Error("Task archiving failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// ArchiveOnce archives the closed tasks of every project, and those outside of any project, that were last
+// updated longer than the retention period ago.
+func (a *TaskArchiver) ArchiveOnce(ctx context.Context) error {
+	projects, err := a.projects.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	columns := []models.Column{{Status: models.DefaultWorkflow[len(models.DefaultWorkflow)-1]}}
+	for _, project := range projects {
+		columns = append(columns, models.Column{ProjectID: project.ID, Status: project.ClosedStatus()})
+	}
+
+	updatedBefore := time.Now().Add(-a.retention)
+	for _, column := range columns {
+		if err := a.archiveColumn(ctx, column, updatedBefore); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveColumn archives the tasks of the column last updated before the given time, a batch at a time.
+func (a *TaskArchiver) archiveColumn(ctx context.Context, column models.Column, updatedBefore time.Time) error {
+	var archived int
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ids, err := a.repo.ArchiveTasks(ctx, column, updatedBefore, a.batchSize)
+		if err != nil {
+			return err
+		}
+		archived += len(ids)
+		if len(ids) == 0 || len(ids) < a.batchSize {
+			break
+		}
+	}
+
+	if archived > 0 {
+		a.logger.Info("Archived tasks", zap.Int64("project_id", column.ProjectID), zap.String("status", column.Status),
+			zap.Int("count", archived))
+	}
+	return nil
+}
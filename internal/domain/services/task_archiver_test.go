@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTaskArchiver_ArchiveOnce(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	old := time.Now().Add(-48 * time.Hour)
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			1: {ID: 1, ProjectID: 1, Status: "released", UpdatedAt: old},
+			2: {ID: 2, ProjectID: 1, Status: "released", UpdatedAt: old},
+			3: {ID: 3, ProjectID: 1, Status: "released", UpdatedAt: time.Now()},
+			4: {ID: 4, ProjectID: 1, Status: "done", UpdatedAt: old},
+			5: {ID: 5, Status: "done", UpdatedAt: old},
+			6: {ID: 6, Status: "open", UpdatedAt: old},
+		},
+	}
+	projects := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Workflow: []string{"open", "done", "released"}},
+		},
+	}
+
+	// A batch size of one makes the archiver go through a column in several batches.
+	archiver, err := NewTaskArchiver(mockRepo, projects, 24*time.Hour, time.Minute, 1, logger)
+	if err != nil {
+		t.Fatalf("NewTaskArchiver() unexpected error: %v", err)
+	}
+	if err := archiver.ArchiveOnce(context.Background()); err != nil {
+		t.Fatalf("ArchiveOnce() unexpected error: %v", err)
+	}
+
+	for _, id := range []int64{1, 2, 5} {
+		if _, ok := mockRepo.archived[id]; !ok {
+			t.Errorf("ArchiveOnce() did not archive task %d", id)
+		}
+	}
+	for _, id := range []int64{3, 4, 6} {
+		if _, ok := mockRepo.tasks[id]; !ok {
+			t.Errorf("ArchiveOnce() archived task %d", id)
+		}
+	}
+}
+
+func TestNewTaskArchiver_BatchSize(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	for _, batchSize := range []int{0, -1} {
+		if _, err := NewTaskArchiver(&mockTaskRepository{}, &mockProjectRepository{}, time.Hour, time.Minute, batchSize, logger); err == nil {
+			t.Errorf("NewTaskArchiver() accepted the batch size %d", batchSize)
+		}
+	}
+}
+
+func TestTaskArchiver_Reimport(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockFields, mockProjects := newCustomFieldMocks()
+	mockRepo := &mockTaskRepository{tasks: make(map[int64]*models.Task)}
+	svc := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	runImport := func() *models.ImportSummary {
+		importer, err := svc.NewTaskImport(context.Background(), models.TaskImport{ProjectID: 1, Source: "jira"})
+		if err != nil {
+			t.Fatalf("NewTaskImport() unexpected error: %v", err)
+		}
+		if err := importer.Add(importRow(1, "A-1", "done", nil)); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+		summary, err := importer.Finish()
+		if err != nil {
+			t.Fatalf("Finish() unexpected error: %v", err)
+		}
+		return summary
+	}
+
+	if summary := runImport(); summary.Created != 1 {
+		t.Fatalf("NewTaskImport() created %d, want 1", summary.Created)
+	}
+	archiver, err := NewTaskArchiver(mockRepo, mockProjects, 0, time.Minute, 10, logger)
+	if err != nil {
+		t.Fatalf("NewTaskArchiver() unexpected error: %v", err)
+	}
+	if err := archiver.ArchiveOnce(context.Background()); err != nil {
+		t.Fatalf("ArchiveOnce() unexpected error: %v", err)
+	}
+	if len(mockRepo.archived) != 1 || len(mockRepo.tasks) != 0 {
+		t.Fatalf("ArchiveOnce() left %d tasks and archived %d, want 0 and 1", len(mockRepo.tasks), len(mockRepo.archived))
+	}
+
+	// The record of the archived task is not imported again.
+	if summary := runImport(); summary.Created != 0 || summary.Skipped != 1 {
+		t.Errorf("NewTaskImport() created %d, skipped %d, want 0, 1", summary.Created, summary.Skipped)
+	}
+	if len(mockRepo.tasks) != 0 {
+		t.Errorf("NewTaskImport() stored %d tasks, want 0", len(mockRepo.tasks))
+	}
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/rank"
+	"go.uber.org/zap"
+)
+
+// BatchResult is the outcome of one item of a best-effort batch: the created or updated task,
+// or the error the item failed with. Deleted items carry neither.
+type BatchResult struct {
+	Task *models.Task
+	Err  error
+}
+
+// BatchItemError names the item that failed an all-or-nothing batch.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchCreateTasks prepares every task like CreateTask and stores the batch in one unit of work. All-or-nothing
+// batches store the tasks in a single repository call; best-effort batches store each valid task in a nested
+// unit of work of its own, so that a task that fails to be stored leaves the others stored.
+func (s *taskService) BatchCreateTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]BatchResult, error) {
+	s.logger.Info("Creating tasks in batch", zap.Int("count", len(tasks)), zap.Bool("best_effort", bestEffort))
+
+	requests := batchRequests(tasks)
+	results := make([]BatchResult, len(tasks))
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// Every attempt starts over from the requests, as prepareCreate fills in the tasks.
+		results = restoreBatch(tasks, requests)
+		batch := s.newBatch(ctx)
+
+		if !bestEffort {
+			for i, task := range tasks {
+				if err := s.prepareCreate(task, batch); err != nil {
+					return &BatchItemError{Index: i, Err: err}
+				}
+			}
+			if _, err := s.repo.CreateTasks(ctx, tasks); err != nil {
+				s.logger.Error("Failed to create tasks", zap.Error(err))
+				return &repositoryError{failure: ErrTaskCreateFail, cause: err}
+			}
+			for i, task := range tasks {
+				results[i].Task = task
+			}
+			return nil
+		}
+
+		for i, task := range tasks {
+			results[i].Err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+				if err := s.prepareCreate(task, batch); err != nil {
+					return err
+				}
+				if _, err := s.repo.CreateTask(ctx, task); err != nil {
+					s.logger.Error("Failed to create task", zap.Error(err))
+					return &repositoryError{failure: ErrTaskCreateFail, cause: err}
+				}
+				return nil
+			})
+			if results[i].Err == nil {
+				results[i].Task = task
+			}
+		}
+		return nil
+	})
+	return s.batchOutcome(results, err, bestEffort, ErrTaskCreateFail)
+}
+
+// BatchUpdateTasks prepares every update like UpdateTask, reading the tasks in the unit of work that stores
+// the batch. All-or-nothing batches are stored in a single repository call; best-effort batches store each
+// valid update in a nested unit of work of its own.
+func (s *taskService) BatchUpdateTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]BatchResult, error) {
+	s.logger.Info("Updating tasks in batch", zap.Int("count", len(tasks)), zap.Bool("best_effort", bestEffort))
+
+	requests := batchRequests(tasks)
+	results := make([]BatchResult, len(tasks))
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// Every attempt starts over from the requests, as prepareUpdate fills in the tasks.
+		results = restoreBatch(tasks, requests)
+		batch := s.newBatch(ctx)
+		seen := make(map[int64]bool, len(tasks))
+
+		if !bestEffort {
+			for i, task := range tasks {
+				if err := s.prepareBatchItem(task, seen, func() error { return s.prepareUpdate(task, batch) }); err != nil {
+					return &BatchItemError{Index: i, Err: err}
+				}
+			}
+			if _, err := s.repo.UpdateTasks(ctx, tasks); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					s.logger.Warn("Task of batch not found for update")
+					return ErrTaskNotFound
+				}
+				s.logger.Error("Failed to update tasks", zap.Error(err))
+				return &repositoryError{failure: ErrTaskUpdateFail, cause: err}
+			}
+			for i, task := range tasks {
+				results[i].Task = task
+			}
+			return nil
+		}
+
+		for i, task := range tasks {
+			results[i].Err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+				if err := s.prepareBatchItem(task, seen, func() error { return s.prepareUpdate(task, batch) }); err != nil {
+					return err
+				}
+				var err error
+				results[i].Task, err = s.repo.UpdateTask(ctx, task)
+				switch {
+				case errors.Is(err, sql.ErrNoRows):
+					return ErrTaskNotFound
+				case err != nil:
+					s.logger.Error("Failed to update task", zap.Int64("id", task.ID), zap.Error(err))
+					return &repositoryError{failure: ErrTaskUpdateFail, cause: err}
+				}
+				return nil
+			})
+			if results[i].Err != nil {
+				results[i].Task = nil
+			}
+		}
+		return nil
+	})
+	return s.batchOutcome(results, err, bestEffort, ErrTaskUpdateFail)
+}
+
+// BatchDeleteTasks checks every deletion like DeleteTask in the unit of work that deletes the batch, which
+// also detaches the subtasks of the deleted tasks.
+// All-or-nothing batches are deleted in a single repository call; best-effort batches delete each task in
+// a nested unit of work of its own.
+func (s *taskService) BatchDeleteTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]BatchResult, error) {
+	s.logger.Info("Deleting tasks in batch", zap.Int("count", len(tasks)), zap.Bool("best_effort", bestEffort))
+
+	results := make([]BatchResult, len(tasks))
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		results = make([]BatchResult, len(tasks))
+		batch := s.newBatch(ctx)
+		seen := make(map[int64]bool, len(tasks))
+
+		if !bestEffort {
+			ids := make([]int64, len(tasks))
+			for i, task := range tasks {
+				if err := s.prepareBatchItem(task, seen, func() error { return s.prepareDelete(task, batch) }); err != nil {
+					return &BatchItemError{Index: i, Err: err}
+				}
+				ids[i] = task.ID
+			}
+			if err := s.repo.DeleteTasks(ctx, ids); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					s.logger.Warn("Task of batch not found for deletion")
+					return ErrTaskNotFound
+				}
+				s.logger.Error("Failed to delete tasks", zap.Error(err))
+				return &repositoryError{failure: ErrTaskDeleteFail, cause: err}
+			}
+			return s.detachSubtasks(ctx, ids, ErrTaskDeleteFail)
+		}
+
+		for i, task := range tasks {
+			results[i].Err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+				if err := s.prepareBatchItem(task, seen, func() error { return s.prepareDelete(task, batch) }); err != nil {
+					return err
+				}
+				err := s.repo.DeleteTask(ctx, task.ID)
+				switch {
+				case errors.Is(err, sql.ErrNoRows):
+					return ErrTaskNotFound
+				case err != nil:
+					s.logger.Error("Failed to delete task", zap.Int64("id", task.ID), zap.Error(err))
+					return &repositoryError{failure: ErrTaskDeleteFail, cause: err}
+				}
+				return s.detachSubtasks(ctx, []int64{task.ID}, ErrTaskDeleteFail)
+			})
+		}
+		return nil
+	})
+	return s.batchOutcome(results, err, bestEffort, ErrTaskDeleteFail)
+}
+
+// prepareBatchItem prepares an update or deletion of a batch with prepare, refusing tasks that appeared
+// earlier in the batch.
+func (s *taskService) prepareBatchItem(task *models.Task, seen map[int64]bool, prepare func() error) error {
+	err := prepare()
+	if err == nil && seen[task.ID] {
+		err = fmt.Errorf("%w: task %d appears more than once", ErrInvalidBatch, task.ID)
+	}
+	seen[task.ID] = true
+	return err
+}
+
+// batchOutcome returns the results of a batch whose unit of work ended with err. A best-effort batch that
+// failed to commit stored none of its items, which all fail with failure then.
+func (s *taskService) batchOutcome(results []BatchResult, err error, bestEffort bool, failure error) ([]BatchResult, error) {
+	switch {
+	case err == nil:
+		return results, nil
+	case !bestEffort:
+		return nil, err
+	}
+	s.logger.Error("Failed to commit batch", zap.Error(err))
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BatchResult{Err: failure}
+		}
+	}
+	return results, nil
+}
+
+// batchRequests copies the tasks of a batch as requested, see restoreBatch.
+func batchRequests(tasks []*models.Task) []models.Task {
+	requests := make([]models.Task, len(tasks))
+	for i, task := range tasks {
+		requests[i] = *task
+	}
+	return requests
+}
+
+// restoreBatch resets the tasks of a batch to the requests, so that an attempt of its unit of work starts
+// over, and returns fresh results for them.
+func restoreBatch(tasks []*models.Task, requests []models.Task) []BatchResult {
+	for i, task := range tasks {
+		*task = requests[i]
+	}
+	return make([]BatchResult, len(tasks))
+}
+
+// prepareCreate fills in the defaults of a new task and checks it against the settings of its project.
+// A subtask must be in the project of its parent.
+func (s *taskService) prepareCreate(task *models.Task, batch *taskBatch) error {
+	project, err := batch.writableProject(task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if task.ParentID != 0 {
+		parent, err := s.GetTask(batch.ctx, task.ParentID)
+		switch {
+		case errors.Is(err, ErrTaskNotFound):
+			return fmt.Errorf("%w: task %d not found", ErrInvalidParent, task.ParentID)
+		case err != nil:
+			return ErrTaskCreateFail
+		case parent.ProjectID != task.ProjectID:
+			return fmt.Errorf("%w: task %d is in another project", ErrInvalidParent, task.ParentID)
+		}
+	}
+	if task.Status == "" {
+		task.Status = project.InitialStatus()
+	}
+	if task.Assignee == "" {
+		task.Assignee = project.DefaultAssignee
+	}
+	if err := validateTaskSettings(task, project, nil); err != nil {
+		s.logger.Warn("Task does not match project settings", zap.Error(err))
+		return err
+	}
+	fields, err := batch.customFields(task.ProjectID)
+	if err != nil {
+		return ErrTaskCreateFail
+	}
+	if task.CustomFields, err = mergeCustomFields(task.CustomFields, fields, nil); err != nil {
+		s.logger.Warn("Task custom fields are invalid", zap.Error(err))
+		return err
+	}
+	task.Rank, err = batch.endOfColumn(models.Column{ProjectID: task.ProjectID, Status: task.Status})
+	if err != nil {
+		return ErrTaskCreateFail
+	}
+	task.ExternalID = s.ids.NewID()
+	return nil
+}
+
+// prepareUpdate completes an update with the current values of the task and checks it against the
+// settings of the task's project.
+func (s *taskService) prepareUpdate(task *models.Task, batch *taskBatch) error {
+	existing, err := s.GetTask(batch.ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if task.ProjectID != 0 && task.ProjectID != existing.ProjectID {
+		s.logger.Warn("Task not found in project", zap.Int64("id", task.ID), zap.Int64("project_id", task.ProjectID))
+		return ErrTaskNotFound
+	}
+
+	project, err := batch.writableProject(existing.ProjectID)
+	if err != nil {
+		return err
+	}
+	task.ProjectID = existing.ProjectID
+	if task.Status == "" {
+		task.Status = existing.Status
+	}
+	if task.Assignee == "" {
+		task.Assignee = existing.Assignee
+	}
+	if task.Labels == nil {
+		task.Labels = existing.Labels
+	}
+	if err := validateTaskSettings(task, project, existing); err != nil {
+		s.logger.Warn("Task does not match project settings", zap.Error(err))
+		return err
+	}
+	fields, err := batch.customFields(task.ProjectID)
+	if err != nil {
+		return ErrTaskUpdateFail
+	}
+	if task.CustomFields, err = mergeCustomFields(task.CustomFields, fields, existing.CustomFields); err != nil {
+		s.logger.Warn("Task custom fields are invalid", zap.Error(err))
+		return err
+	}
+	task.Rank = existing.Rank
+	if task.Status != existing.Status {
+		task.Rank, err = batch.endOfColumn(models.Column{ProjectID: task.ProjectID, Status: task.Status})
+		if err != nil {
+			return ErrTaskUpdateFail
+		}
+	}
+	return nil
+}
+
+// prepareDelete checks that the task exists, in its ProjectID if that is set, and may be deleted.
+func (s *taskService) prepareDelete(task *models.Task, batch *taskBatch) error {
+	existing, err := s.GetTask(batch.ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if task.ProjectID != 0 && task.ProjectID != existing.ProjectID {
+		s.logger.Warn("Task not found in project", zap.Int64("id", task.ID), zap.Int64("project_id", task.ProjectID))
+		return ErrTaskNotFound
+	}
+	_, err = batch.writableProject(existing.ProjectID)
+	return err
+}
+
+// taskBatch caches the projects, custom fields and column ends looked up while preparing tasks, so that
+// a batch loads each of them once. Column ends advance as tasks are placed, so tasks of a batch that go
+// to the same column keep the order of the batch.
+type taskBatch struct {
+	ctx      context.Context
+	service  *taskService
+	projects map[int64]*models.Project
+	fields   map[int64]map[string]*models.CustomField
+	ends     map[models.Column]string
+}
+
+func (s *taskService) newBatch(ctx context.Context) *taskBatch {
+	return &taskBatch{
+		ctx:      ctx,
+		service:  s,
+		projects: make(map[int64]*models.Project),
+		fields:   make(map[int64]map[string]*models.CustomField),
+		ends:     make(map[models.Column]string),
+	}
+}
+
+func (b *taskBatch) writableProject(id int64) (*models.Project, error) {
+	project, ok := b.projects[id]
+	if !ok {
+		var err error
+		if project, err = b.service.project(b.ctx, id); err != nil {
+			return nil, err
+		}
+		b.projects[id] = project
+	}
+	if project.Archived {
+		b.service.logger.Warn("Project is archived", zap.Int64("project_id", id))
+		return nil, ErrProjectArchived
+	}
+	return project, nil
+}
+
+func (b *taskBatch) customFields(projectID int64) (map[string]*models.CustomField, error) {
+	if fields, ok := b.fields[projectID]; ok {
+		return fields, nil
+	}
+	fields, err := b.service.customFields(b.ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	b.fields[projectID] = fields
+	return fields, nil
+}
+
+// endOfColumn returns a rank after every task of the column, including those placed earlier in the batch.
+func (b *taskBatch) endOfColumn(column models.Column) (string, error) {
+	last, ok := b.ends[column]
+	if !ok {
+		var err error
+		if last, err = b.service.repo.RankBefore(b.ctx, column, ""); err != nil {
+			b.service.logger.Error("Failed to fetch last rank of column", zap.Error(err))
+			return "", err
+		}
+	}
+
+	next, err := rank.Between(last, "")
+	if err != nil {
+		b.service.logger.Error("Failed to generate rank", zap.String("last", last), zap.Error(err))
+		return "", err
+	}
+	b.ends[column] = next
+
+	return next, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/consistency"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_taskService_BatchCreateTasks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockProjects := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Workflow: []string{"backlog", "doing"}, AllowedLabels: []string{"bug"}},
+		},
+	}
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{
+			1: {ID: 1, ProjectID: 1, Title: "Existing", Status: "backlog", Rank: "m"},
+		}}
+		return mockRepo, NewTaskService(mockRepo, mockProjects, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+
+	t.Run("All or nothing stores every task in order", func(t *testing.T) {
+		mockRepo, svc := newService()
+		results, err := svc.BatchCreateTasks(context.Background(), []*models.Task{
+			{ProjectID: 1, Title: "First"},
+			{ProjectID: 1, Title: "Second"},
+		}, false)
+		if err != nil {
+			t.Fatalf("BatchCreateTasks() unexpected error: %v", err)
+		}
+		if len(results) != 2 || len(mockRepo.tasks) != 3 {
+			t.Fatalf("BatchCreateTasks() got %d results and %d tasks, want 2 and 3", len(results), len(mockRepo.tasks))
+		}
+		first, second := results[0].Task, results[1].Task
+		if first.Status != "backlog" || first.ExternalID == "" {
+			t.Errorf("BatchCreateTasks() did not apply the defaults: %+v", first)
+		}
+		if !("m" < first.Rank && first.Rank < second.Rank) {
+			t.Errorf("BatchCreateTasks() ranks %q, %q do not follow the column end %q in order", first.Rank, second.Rank, "m")
+		}
+	})
+
+	t.Run("All or nothing fails on the first invalid item", func(t *testing.T) {
+		mockRepo, svc := newService()
+		_, err := svc.BatchCreateTasks(context.Background(), []*models.Task{
+			{ProjectID: 1, Title: "Valid"},
+			{ProjectID: 1, Title: "Invalid", Labels: []string{"feature"}},
+		}, false)
+		var itemErr *BatchItemError
+		if !errors.As(err, &itemErr) || itemErr.Index != 1 || !errors.Is(err, ErrLabelNotAllowed) {
+			t.Fatalf("BatchCreateTasks() error = %v, want item 1 failing with %v", err, ErrLabelNotAllowed)
+		}
+		if len(mockRepo.tasks) != 1 {
+			t.Errorf("BatchCreateTasks() stored %d tasks, want none", len(mockRepo.tasks)-1)
+		}
+	})
+
+	t.Run("Best effort stores the valid items", func(t *testing.T) {
+		mockRepo, svc := newService()
+		results, err := svc.BatchCreateTasks(context.Background(), []*models.Task{
+			{ProjectID: 1, Title: "Valid"},
+			{ProjectID: 2, Title: "Unknown project"},
+		}, true)
+		if err != nil {
+			t.Fatalf("BatchCreateTasks() unexpected error: %v", err)
+		}
+		if results[0].Err != nil || results[0].Task == nil {
+			t.Errorf("BatchCreateTasks() item 0 = %+v, want a created task", results[0])
+		}
+		if !errors.Is(results[1].Err, ErrProjectNotFound) {
+			t.Errorf("BatchCreateTasks() item 1 error = %v, want %v", results[1].Err, ErrProjectNotFound)
+		}
+		if len(mockRepo.tasks) != 2 {
+			t.Errorf("BatchCreateTasks() stored %d tasks, want 1", len(mockRepo.tasks)-1)
+		}
+	})
+}
+
+func Test_taskService_BatchUpdateTasks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{
+			1: {ID: 1, Title: "A", Status: "open", Rank: "a"},
+			2: {ID: 2, Title: "B", Status: "open", Rank: "b"},
+			3: {ID: 3, Title: "C", Status: "done", Rank: "c"},
+		}}
+		return mockRepo, NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+
+	t.Run("All or nothing moves tasks to the end of the column in order", func(t *testing.T) {
+		mockRepo, svc := newService()
+		results, err := svc.BatchUpdateTasks(context.Background(), []*models.Task{
+			{ID: 1, Title: "A", Status: "done"},
+			{ID: 2, Title: "B", Status: "done"},
+		}, false)
+		if err != nil {
+			t.Fatalf("BatchUpdateTasks() unexpected error: %v", err)
+		}
+		if !("c" < results[0].Task.Rank && results[0].Task.Rank < results[1].Task.Rank) {
+			t.Errorf("BatchUpdateTasks() ranks %q, %q do not follow %q in order", results[0].Task.Rank, results[1].Task.Rank, "c")
+		}
+		if mockRepo.tasks[1].Status != "done" {
+			t.Errorf("BatchUpdateTasks() did not store the update")
+		}
+	})
+
+	t.Run("All or nothing rejects duplicates", func(t *testing.T) {
+		mockRepo, svc := newService()
+		_, err := svc.BatchUpdateTasks(context.Background(), []*models.Task{
+			{ID: 1, Title: "Changed"},
+			{ID: 1, Title: "Changed again"},
+		}, false)
+		var itemErr *BatchItemError
+		if !errors.As(err, &itemErr) || itemErr.Index != 1 || !errors.Is(err, ErrInvalidBatch) {
+			t.Fatalf("BatchUpdateTasks() error = %v, want item 1 failing with %v", err, ErrInvalidBatch)
+		}
+		if mockRepo.tasks[1].Title != "A" {
+			t.Errorf("BatchUpdateTasks() stored %q, want no change", mockRepo.tasks[1].Title)
+		}
+	})
+
+	t.Run("Best effort reports missing tasks", func(t *testing.T) {
+		mockRepo, svc := newService()
+		results, err := svc.BatchUpdateTasks(context.Background(), []*models.Task{
+			{ID: 4, Title: "Missing"},
+			{ID: 2, Title: "Changed"},
+		}, true)
+		if err != nil {
+			t.Fatalf("BatchUpdateTasks() unexpected error: %v", err)
+		}
+		if !errors.Is(results[0].Err, ErrTaskNotFound) {
+			t.Errorf("BatchUpdateTasks() item 0 error = %v, want %v", results[0].Err, ErrTaskNotFound)
+		}
+		if results[1].Err != nil || mockRepo.tasks[2].Title != "Changed" {
+			t.Errorf("BatchUpdateTasks() item 1 = %+v, want the update stored", results[1])
+		}
+	})
+}
+
+func Test_taskService_BatchDeleteTasks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{
+			1: {ID: 1, Title: "A"},
+			2: {ID: 2, Title: "B", ProjectID: 1},
+		}}
+		mockProjects := &mockProjectRepository{projects: map[int64]*models.Project{1: {ID: 1}}}
+		return mockRepo, NewTaskService(mockRepo, mockProjects, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+
+	tests := []struct {
+		name       string
+		tasks      []*models.Task
+		bestEffort bool
+		wantErr    error
+		wantItems  []error
+		wantLeft   int
+	}{
+		{
+			name:     "All or nothing deletes every task",
+			tasks:    []*models.Task{{ID: 1}, {ID: 2, ProjectID: 1}},
+			wantLeft: 0,
+		},
+		{
+			name:     "All or nothing keeps every task when one is missing",
+			tasks:    []*models.Task{{ID: 1}, {ID: 3}},
+			wantErr:  ErrTaskNotFound,
+			wantLeft: 2,
+		},
+		{
+			name:       "Best effort deletes what it can",
+			tasks:      []*models.Task{{ID: 1}, {ID: 2, ProjectID: 5}},
+			bestEffort: true,
+			wantItems:  []error{nil, ErrTaskNotFound},
+			wantLeft:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, svc := newService()
+			results, err := svc.BatchDeleteTasks(context.Background(), tt.tasks, tt.bestEffort)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BatchDeleteTasks() error = %v, want %v", err, tt.wantErr)
+			}
+			for i, want := range tt.wantItems {
+				if !errors.Is(results[i].Err, want) {
+					t.Errorf("BatchDeleteTasks() item %d error = %v, want %v", i, results[i].Err, want)
+				}
+			}
+			if len(mockRepo.tasks) != tt.wantLeft {
+				t.Errorf("BatchDeleteTasks() left %d tasks, want %d", len(mockRepo.tasks), tt.wantLeft)
+			}
+		})
+	}
+}
+
+// staleTaskRepository serves the reads that need not see the latest writes from stale copies of the tasks,
+// as the task cache and read replicas may.
+type staleTaskRepository struct {
+	*mockTaskRepository
+	stale map[int64]*models.Task
+}
+
+func (r *staleTaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	if task, ok := r.stale[id]; ok && !consistency.Latest(ctx) {
+		clone := *task
+		return &clone, nil
+	}
+	return r.mockTaskRepository.GetTask(ctx, id)
+}
+
+// unitTransactor runs units of work once, with contexts that mark their calls as part of a unit of work.
+type unitTransactor struct{}
+
+func (unitTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if consistency.InUnitOfWork(ctx) {
+		return fn(ctx)
+	}
+	unitCtx, unit := consistency.WithUnitOfWork(ctx)
+	if err := fn(unitCtx); err != nil {
+		return err
+	}
+	unit.Committed()
+	return nil
+}
+
+func Test_taskService_BatchUpdateTasks_ReadsLatest(t *testing.T) {
+	for _, bestEffort := range []bool{false, true} {
+		repo := &staleTaskRepository{
+			mockTaskRepository: &mockTaskRepository{tasks: map[int64]*models.Task{
+				1: {ID: 1, Title: "A", Status: "done", Assignee: "bob", Rank: "a"},
+			}},
+			stale: map[int64]*models.Task{
+				1: {ID: 1, Title: "A", Status: "open", Assignee: "alice", Rank: "a"},
+			},
+		}
+		svc := NewTaskService(repo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, unitTransactor{},
+			&mockIDGenerator{}, zaptest.NewLogger(t))
+
+		results, err := svc.BatchUpdateTasks(context.Background(), []*models.Task{{ID: 1, Title: "Renamed"}}, bestEffort)
+		if err != nil || results[0].Err != nil {
+			t.Fatalf("BatchUpdateTasks(best effort %v) unexpected error: %v, %+v", bestEffort, err, results)
+		}
+		stored := repo.tasks[1]
+		if stored.Title != "Renamed" || stored.Status != "done" || stored.Assignee != "bob" {
+			t.Errorf("BatchUpdateTasks(best effort %v) stored %+v, want the title changed on the latest status and assignee",
+				bestEffort, stored)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap"
+)
+
+var ErrUnknownColumn = errors.New("unknown column")
+
+// ExportTasks streams the tasks matching the export filter from the repository, typing their custom
+// field values on the way. Errors returned by each end the export and are returned as they are.
+func (s *taskService) ExportTasks(ctx context.Context, export models.TaskExport, each func(*models.Task) error) error {
+	s.logger.Info("Exporting tasks", zap.Int64("project_id", export.Filter.ProjectID), zap.Strings("columns", export.Columns))
+
+	filter := export.Filter
+	if err := s.resolveListFilter(ctx, &filter); err != nil {
+		return err
+	}
+	if err := s.checkExportColumns(ctx, filter.ProjectID, export.Columns); err != nil {
+		s.logger.Warn("Invalid export columns", zap.Error(err))
+		return err
+	}
+
+	byProject := make(map[int64]map[string]*models.CustomField)
+	var exported int
+	err := s.repo.ExportTasks(ctx, filter, func(task *models.Task) error {
+		if err := s.typeTaskCustomFields(ctx, task, byProject); err != nil {
+			return err
+		}
+		exported++
+		return each(task)
+	})
+	if err != nil {
+		s.logger.Error("Failed to export tasks", zap.Int("exported", exported), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Exported tasks", zap.Int("count", exported))
+	return nil
+}
+
+// checkExportColumns checks that every column is a task column or a custom field of the project.
+func (s *taskService) checkExportColumns(ctx context.Context, projectID int64, columns []string) error {
+	var fields map[string]*models.CustomField
+	for _, column := range columns {
+		name, custom := strings.CutPrefix(column, customFieldPrefix)
+		if !custom {
+			if !slices.Contains(taskColumnNames, column) {
+				return fmt.Errorf("%w: %q", ErrUnknownColumn, column)
+			}
+			continue
+		}
+		if projectID == 0 {
+			return fmt.Errorf("%w: custom fields can only be exported within a project", ErrUnknownCustomField)
+		}
+		if fields == nil {
+			var err error
+			if fields, err = s.customFields(ctx, projectID); err != nil {
+				return err
+			}
+		}
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCustomField, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_taskService_ExportTasks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockFields, mockProjects := newCustomFieldMocks()
+	mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{
+		1: {ID: 1, ProjectID: 1, Key: "OPS-1", Title: "Points", CustomFields: map[string]models.FieldValue{
+			"env": {String: "prod"},
+		}},
+		2: {ID: 2, ProjectID: 2, Key: "DEV-1", Title: "Elsewhere"},
+	}}
+	svc := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	tests := []struct {
+		name    string
+		export  models.TaskExport
+		want    int
+		wantErr error
+	}{
+		{name: "Task columns", export: models.TaskExport{Columns: []string{"key", "title", "created_at"}}, want: 2},
+		{name: "Custom field columns", export: models.TaskExport{Filter: models.TaskFilter{ProjectID: 1}, Columns: []string{"key", "custom_fields.env"}}, want: 1},
+		{name: "Unknown column", export: models.TaskExport{Columns: []string{"owner"}}, wantErr: ErrUnknownColumn},
+		{name: "Unknown custom field", export: models.TaskExport{Filter: models.TaskFilter{ProjectID: 1}, Columns: []string{"custom_fields.owner"}}, wantErr: ErrUnknownCustomField},
+		{name: "Custom field without a project", export: models.TaskExport{Columns: []string{"custom_fields.env"}}, wantErr: ErrUnknownCustomField},
+		{name: "Unknown project", export: models.TaskExport{Filter: models.TaskFilter{ProjectID: 9}, Columns: []string{"key"}}, wantErr: ErrProjectNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exported []*models.Task
+			err := svc.ExportTasks(context.Background(), tt.export, func(task *models.Task) error {
+				exported = append(exported, task)
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExportTasks() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(exported) != tt.want {
+				t.Errorf("ExportTasks() exported %d tasks, want %d", len(exported), tt.want)
+			}
+		})
+	}
+
+	t.Run("Custom field values are typed", func(t *testing.T) {
+		err := svc.ExportTasks(context.Background(), models.TaskExport{Filter: models.TaskFilter{ProjectID: 1}}, func(task *models.Task) error {
+			if task.CustomFields["env"].Type != models.FieldTypeEnum {
+				t.Errorf("ExportTasks() env has type %q, want %q", task.CustomFields["env"].Type, models.FieldTypeEnum)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportTasks() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Consumer errors end the export", func(t *testing.T) {
+		stop := errors.New("client went away")
+		var calls int
+		err := svc.ExportTasks(context.Background(), models.TaskExport{}, func(task *models.Task) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("ExportTasks() = %v after %d tasks, want %v after 1", err, calls, stop)
+		}
+	})
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+)
+
+// MaxFilterDepth limits how deeply filter expressions may nest.
+const MaxFilterDepth = 32
+
+// customFieldPrefix introduces a custom field in a filter, as in custom_fields.points > 3.
+const customFieldPrefix = "custom_fields."
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterError reports a problem with a filter expression at a 1-based character position.
+type FilterError struct {
+	Position int
+	Message  string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("%s at position %d: %s", ErrInvalidFilter, e.Position, e.Message)
+}
+
+func (e *FilterError) Is(target error) bool {
+	return target == ErrInvalidFilter
+}
+
+// taskFilterFields are the task fields filters can refer to besides custom fields, with their types.
+var taskFilterFields = map[string]string{
+	"id":          models.FilterTypeInteger,
+	"external_id": models.FilterTypeString,
+	"project_id":  models.FilterTypeInteger,
+	"parent_id":   models.FilterTypeInteger,
+	"key":         models.FilterTypeString,
+	"title":       models.FilterTypeString,
+	"description": models.FilterTypeString,
+	"status":      models.FilterTypeString,
+	"assignee":    models.FilterTypeString,
+	"labels":      models.FilterTypeList,
+	"created_at":  models.FilterTypeTimestamp,
+	"updated_at":  models.FilterTypeTimestamp,
+}
+
+type filterTokenKind int
+
+const (
+	filterEOF filterTokenKind = iota
+	filterText
+	filterString
+	filterComparator
+	filterMinus
+	filterLeftParen
+	filterRightParen
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+// describe names a token in error messages.
+func (t filterToken) describe() string {
+	if t.kind == filterEOF {
+		return "end of filter"
+	}
+	return strconv.Quote(t.text)
+}
+
+// isKeyword reports whether the token is the unquoted keyword AND, OR or NOT.
+func (t filterToken) isKeyword(keyword string) bool {
+	return t.kind == filterText && t.text == keyword
+}
+
+// tokenizeFilter splits a filter into tokens. Unquoted text runs until white space, a parenthesis,
+// a quote or a comparator, so timestamps and other values containing those must be quoted.
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: filterLeftParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: filterRightParen, text: ")", pos: pos})
+			i++
+		case r == '=' || r == ':':
+			tokens = append(tokens, filterToken{kind: filterComparator, text: string(r), pos: pos})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, filterToken{kind: filterComparator, text: string(runes[i : i+2]), pos: pos})
+				i += 2
+			} else if r == '!' {
+				return nil, &FilterError{Position: pos, Message: `unexpected "!", did you mean "!="?`}
+			} else {
+				tokens = append(tokens, filterToken{kind: filterComparator, text: string(r), pos: pos})
+				i++
+			}
+		case r == '"':
+			var text strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &FilterError{Position: pos, Message: "unterminated string"}
+			}
+			tokens = append(tokens, filterToken{kind: filterString, text: text.String(), pos: pos})
+			i++
+		case r == '-' && (i+1 == len(runes) || !unicode.IsDigit(runes[i+1]) && runes[i+1] != '.'):
+			tokens = append(tokens, filterToken{kind: filterMinus, text: "-", pos: pos})
+			i++
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"=:!<>`, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, filterToken{kind: filterText, text: string(runes[i:end]), pos: pos})
+			i = end
+		}
+	}
+	return append(tokens, filterToken{kind: filterEOF, pos: len(runes) + 1}), nil
+}
+
+// filterNode is a node of a parsed but not yet checked filter.
+type filterNode struct {
+	logic    string
+	operands []*filterNode
+	field    filterToken
+	operator filterToken
+	value    filterToken
+}
+
+type filterParser struct {
+	tokens []filterToken
+	next   int
+	depth  int
+}
+
+// parseFilter parses a filter in the syntax of AIP-160. Restrictions such as status = "open" or
+// title:"deploy" combine with AND, OR and NOT, where - is short for NOT, and parentheses group them.
+// As in AIP-160, OR binds tighter than AND and restrictions separated by white space only are ANDed.
+func parseFilter(filter string) (*filterNode, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	node, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != filterEOF {
+		return nil, &FilterError{Position: token.pos, Message: "unexpected " + token.describe()}
+	}
+	return node, nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) take() filterToken {
+	token := p.tokens[p.next]
+	if token.kind != filterEOF {
+		p.next++
+	}
+	return token
+}
+
+// expression := sequence {AND sequence}
+func (p *filterParser) expression() (*filterNode, error) {
+	node, err := p.sequence()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*filterNode{node}
+	for p.peek().isKeyword(models.FilterAnd) {
+		p.take()
+		node, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, node)
+	}
+	return combineFilterNodes(models.FilterAnd, operands), nil
+}
+
+// sequence := factor {factor}
+func (p *filterParser) sequence() (*filterNode, error) {
+	node, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*filterNode{node}
+	for {
+		token := p.peek()
+		if token.kind == filterEOF || token.kind == filterRightParen || token.isKeyword(models.FilterAnd) {
+			break
+		}
+		node, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, node)
+	}
+	return combineFilterNodes(models.FilterAnd, operands), nil
+}
+
+// factor := term {OR term}
+func (p *filterParser) factor() (*filterNode, error) {
+	node, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*filterNode{node}
+	for p.peek().isKeyword(models.FilterOr) {
+		p.take()
+		node, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, node)
+	}
+	return combineFilterNodes(models.FilterOr, operands), nil
+}
+
+// term := [NOT | -] simple
+// simple := restriction | "(" expression ")"
+func (p *filterParser) term() (*filterNode, error) {
+	if token := p.peek(); token.kind == filterMinus || token.isKeyword(models.FilterNot) {
+		p.take()
+		node, err := p.simple()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNode{logic: models.FilterNot, operands: []*filterNode{node}}, nil
+	}
+	return p.simple()
+}
+
+func (p *filterParser) simple() (*filterNode, error) {
+	token := p.peek()
+	if token.kind != filterLeftParen {
+		return p.restriction()
+	}
+
+	p.take()
+	p.depth++
+	if p.depth > MaxFilterDepth {
+		return nil, &FilterError{Position: token.pos, Message: fmt.Sprintf("expressions cannot nest more than %d levels deep", MaxFilterDepth)}
+	}
+	node, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if closing := p.take(); closing.kind != filterRightParen {
+		return nil, &FilterError{Position: closing.pos, Message: "expected \")\" but found " + closing.describe()}
+	}
+	p.depth--
+	return node, nil
+}
+
+// restriction := field comparator value
+func (p *filterParser) restriction() (*filterNode, error) {
+	field := p.take()
+	if field.kind != filterText || field.isKeyword(models.FilterAnd) || field.isKeyword(models.FilterOr) || field.isKeyword(models.FilterNot) {
+		return nil, &FilterError{Position: field.pos, Message: "expected a field name but found " + field.describe()}
+	}
+	operator := p.take()
+	if operator.kind != filterComparator {
+		return nil, &FilterError{Position: operator.pos, Message: fmt.Sprintf("expected a comparator after %s but found %s", field.text, operator.describe())}
+	}
+	value := p.take()
+	if value.kind != filterText && value.kind != filterString {
+		return nil, &FilterError{Position: value.pos, Message: fmt.Sprintf("expected a value after %s but found %s", operator.text, value.describe())}
+	}
+	return &filterNode{field: field, operator: operator, value: value}, nil
+}
+
+// combineFilterNodes joins nodes with a logical operator, leaving a single node as it is.
+func combineFilterNodes(logic string, operands []*filterNode) *filterNode {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	return &filterNode{logic: logic, operands: operands}
+}
+
+// usesCustomFields reports whether any restriction of the filter refers to a custom field.
+func (n *filterNode) usesCustomFields() bool {
+	if n.logic == "" {
+		return strings.HasPrefix(n.field.text, customFieldPrefix)
+	}
+	for _, operand := range n.operands {
+		if operand.usesCustomFields() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFilter validates the fields, comparators and values of a parsed filter against the task fields
+// and the given custom fields, and returns the filter with typed values.
+func checkFilter(node *filterNode, fields map[string]*models.CustomField) (*models.FilterExpr, error) {
+	if node.logic != "" {
+		expr := &models.FilterExpr{Logic: node.logic}
+		for _, operand := range node.operands {
+			checked, err := checkFilter(operand, fields)
+			if err != nil {
+				return nil, err
+			}
+			expr.Operands = append(expr.Operands, checked)
+		}
+		return expr, nil
+	}
+
+	expr := &models.FilterExpr{Field: node.field.text, Operator: node.operator.text}
+	if name, ok := strings.CutPrefix(node.field.text, customFieldPrefix); ok {
+		if fields == nil {
+			return nil, &FilterError{Position: node.field.pos, Message: "custom fields can only be filtered within a project"}
+		}
+		field, ok := fields[name]
+		if !ok {
+			return nil, &FilterError{Position: node.field.pos, Message: fmt.Sprintf("unknown custom field %q", name)}
+		}
+		expr.Field, expr.Custom, expr.Type = name, true, field.Type
+	} else if expr.Type, ok = taskFilterFields[node.field.text]; !ok {
+		return nil, &FilterError{Position: node.field.pos, Message: fmt.Sprintf("unknown field %q", node.field.text)}
+	}
+
+	// field:* tests whether the field is set.
+	if expr.Operator == ":" && node.value.kind == filterText && node.value.text == "*" {
+		return expr, nil
+	}
+
+	value, err := filterValue(expr, node.value.text)
+	if err != nil {
+		return nil, &FilterError{Position: node.value.pos, Message: err.Error()}
+	}
+	if err := checkFilterOperator(expr); err != nil {
+		return nil, &FilterError{Position: node.operator.pos, Message: err.Error()}
+	}
+	expr.Value = value
+	return expr, nil
+}
+
+// filterValue converts the text of a value to the type of the field it is compared with.
+func filterValue(expr *models.FilterExpr, text string) (any, error) {
+	switch expr.Type {
+	case models.FilterTypeInteger:
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects an integer", expr.Field)
+		}
+		return value, nil
+	case models.FilterTypeTimestamp:
+		if value, err := time.Parse(time.RFC3339, text); err == nil {
+			return value.UTC(), nil
+		}
+		if value, err := time.Parse(dateLayout, text); err == nil {
+			return value, nil
+		}
+		return nil, fmt.Errorf("%s expects a date such as \"2026-01-01\" or an RFC 3339 timestamp", expr.Field)
+	case models.FilterTypeString, models.FilterTypeList:
+		return text, nil
+	case models.FieldTypeNumber:
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects a number", expr.Field)
+		}
+		return models.FieldValue{Type: expr.Type, Number: value}, nil
+	default:
+		return models.FieldValue{Type: expr.Type, String: text}, nil
+	}
+}
+
+// checkFilterOperator checks that a comparator applies to the type of its field. Lists only support
+// ":", which tests for an element, and ":" on other fields tests whether a text contains the value.
+func checkFilterOperator(expr *models.FilterExpr) error {
+	switch {
+	case expr.Type == models.FilterTypeList && expr.Operator != ":":
+		return fmt.Errorf("%s is a list and only supports \":\"", expr.Field)
+	case expr.Operator != ":":
+		return nil
+	case expr.Type == models.FilterTypeString, expr.Type == models.FilterTypeList,
+		expr.Type == models.FieldTypeString, expr.Type == models.FieldTypeUser:
+		return nil
+	}
+	return fmt.Errorf("\":\" only applies to text and list fields; compare %s with \"=\" instead", expr.Field)
+}
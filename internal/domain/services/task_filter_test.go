@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func Test_parseFilter(t *testing.T) {
+	fields := map[string]*models.CustomField{
+		"points": {Name: "points", Type: models.FieldTypeNumber},
+		"team":   {Name: "team", Type: models.FieldTypeEnum, Options: []string{"api", "web"}},
+	}
+	status := func(value string) *models.FilterExpr {
+		return &models.FilterExpr{Field: "status", Type: models.FilterTypeString, Operator: "=", Value: value}
+	}
+
+	tests := []struct {
+		name    string
+		filter  string
+		want    *models.FilterExpr
+		wantPos int
+	}{
+		{
+			name:   "Conjunction of restrictions",
+			filter: `status = "open" AND created_at > "2026-01-01" AND title:"deploy"`,
+			want: &models.FilterExpr{Logic: models.FilterAnd, Operands: []*models.FilterExpr{
+				status("open"),
+				{Field: "created_at", Type: models.FilterTypeTimestamp, Operator: ">", Value: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{Field: "title", Type: models.FilterTypeString, Operator: ":", Value: "deploy"},
+			}},
+		},
+		{
+			name:   "OR binds tighter than AND",
+			filter: `status = open AND status = done OR status = "in review"`,
+			want: &models.FilterExpr{Logic: models.FilterAnd, Operands: []*models.FilterExpr{
+				status("open"),
+				{Logic: models.FilterOr, Operands: []*models.FilterExpr{status("done"), status("in review")}},
+			}},
+		},
+		{
+			name:   "Negation, grouping and implicit AND",
+			filter: `-(id = 1 OR id = 2) NOT labels:bug`,
+			want: &models.FilterExpr{Logic: models.FilterAnd, Operands: []*models.FilterExpr{
+				{Logic: models.FilterNot, Operands: []*models.FilterExpr{{Logic: models.FilterOr, Operands: []*models.FilterExpr{
+					{Field: "id", Type: models.FilterTypeInteger, Operator: "=", Value: int64(1)},
+					{Field: "id", Type: models.FilterTypeInteger, Operator: "=", Value: int64(2)},
+				}}}},
+				{Logic: models.FilterNot, Operands: []*models.FilterExpr{{Field: "labels", Type: models.FilterTypeList, Operator: ":", Value: "bug"}}},
+			}},
+		},
+		{
+			name:   "Custom fields and presence",
+			filter: `custom_fields.points >= -1.5 custom_fields.team:* key:*`,
+			want: &models.FilterExpr{Logic: models.FilterAnd, Operands: []*models.FilterExpr{
+				{Field: "points", Custom: true, Type: models.FieldTypeNumber, Operator: ">=", Value: models.FieldValue{Type: models.FieldTypeNumber, Number: -1.5}},
+				{Field: "team", Custom: true, Type: models.FieldTypeEnum, Operator: ":"},
+				{Field: "key", Type: models.FilterTypeString, Operator: ":"},
+			}},
+		},
+		{
+			name:   "Escaped quote",
+			filter: `title = "say \"hi\""`,
+			want:   &models.FilterExpr{Field: "title", Type: models.FilterTypeString, Operator: "=", Value: `say "hi"`},
+		},
+		{name: "Unknown field", filter: `status = open AND titel:"x"`, wantPos: 19},
+		{name: "Unknown custom field", filter: `custom_fields.size = 3`, wantPos: 1},
+		{name: "Missing comparator", filter: `status "open"`, wantPos: 8},
+		{name: "Missing value", filter: `status =`, wantPos: 9},
+		{name: "Unterminated string", filter: `title = "deploy`, wantPos: 9},
+		{name: "Unbalanced parenthesis", filter: `(status = open`, wantPos: 15},
+		{name: "Bang without equals", filter: `status ! open`, wantPos: 8},
+		{name: "Integer expected", filter: `id = abc`, wantPos: 6},
+		{name: "Bad timestamp", filter: `created_at > "yesterday"`, wantPos: 14},
+		{name: "Number expected", filter: `custom_fields.points = "many"`, wantPos: 24},
+		{name: "Contains on integer", filter: `id:1`, wantPos: 3},
+		{name: "Comparison on list", filter: `labels = bug`, wantPos: 8},
+		{name: "Dangling operator", filter: `status = open AND`, wantPos: 18},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *models.FilterExpr
+			node, err := parseFilter(tt.filter)
+			if err == nil {
+				got, err = checkFilter(node, fields)
+			}
+
+			if tt.wantPos != 0 {
+				var filterErr *FilterError
+				if !errors.As(err, &filterErr) || !errors.Is(err, ErrInvalidFilter) {
+					t.Fatalf("parseFilter() error = %v, want a FilterError", err)
+				}
+				if filterErr.Position != tt.wantPos {
+					t.Errorf("parseFilter() error position = %d, want %d (%v)", filterErr.Position, tt.wantPos, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFilter() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_taskService_ListTasks_Filter(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockProjects := &mockProjectRepository{projects: map[int64]*models.Project{1: {ID: 1}}}
+	mockFields := &mockCustomFieldRepository{
+		fields: map[int64]*models.CustomField{1: {ID: 1, ProjectID: 1, Name: "points", Type: models.FieldTypeNumber}},
+	}
+	mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{}}
+
+	svc := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	if _, err := svc.ListTasks(context.Background(), models.TaskFilter{ProjectID: 1, Expression: `custom_fields.points > 3`}); err != nil {
+		t.Fatalf("ListTasks() unexpected error: %v", err)
+	}
+	want := &models.FilterExpr{Field: "points", Custom: true, Type: models.FieldTypeNumber, Operator: ">",
+		Value: models.FieldValue{Type: models.FieldTypeNumber, Number: 3}}
+	if !reflect.DeepEqual(mockRepo.lastFilter.Where, want) {
+		t.Errorf("ListTasks() passed filter %+v, want %+v", mockRepo.lastFilter.Where, want)
+	}
+
+	if _, err := svc.ListTasks(context.Background(), models.TaskFilter{Expression: `custom_fields.points > 3`}); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("ListTasks() with custom field outside a project error = %v, want %v", err, ErrInvalidFilter)
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMissingSourceID   = errors.New("record has no source ID")
+	ErrDuplicateSourceID = errors.New("source ID appears more than once in the import")
+	ErrTaskImportFail    = errors.New("failed to import tasks")
+)
+
+// importBatchSize is the number of records an import stores at a time. Each batch is a transaction of
+// its own, so an import that breaks off keeps the batches stored before and can simply be run again.
+const importBatchSize = 200
+
+// maxImportErrors caps the record errors an import reports; further failures are only counted.
+const maxImportErrors = 1000
+
+// TaskImporter takes the records of an import one at a time, so that imports of any size are processed
+// as they arrive.
+type TaskImporter interface {
+	// Add checks a record and queues it for storage. It only fails when storing fails, which ends the import;
+	// invalid records are reported in the summary instead.
+	Add(row models.ImportRow) error
+	// Finish stores the records still queued and returns the summary of the import.
+	Finish() (*models.ImportSummary, error)
+}
+
+// NewTaskImport starts an import into a project, which must exist and not be archived.
+func (s *taskService) NewTaskImport(ctx context.Context, taskImport models.TaskImport) (TaskImporter, error) {
+	s.logger.Info("Starting task import", zap.Int64("project_id", taskImport.ProjectID), zap.String("source", taskImport.Source),
+		zap.Bool("dry_run", taskImport.DryRun))
+
+	if _, err := s.writableProject(ctx, taskImport.ProjectID); err != nil {
+		return nil, err
+	}
+	return &taskImporter{ctx: ctx, service: s, taskImport: taskImport, seen: make(map[string]bool)}, nil
+}
+
+type taskImporter struct {
+	ctx        context.Context
+	service    *taskService
+	taskImport models.TaskImport
+	pending    []models.ImportRow
+	seen       map[string]bool
+	summary    models.ImportSummary
+}
+
+func (i *taskImporter) Add(row models.ImportRow) error {
+	switch {
+	case row.Err != nil:
+		i.fail(row, row.Err)
+	case row.SourceID == "":
+		i.fail(row, ErrMissingSourceID)
+	case i.seen[row.SourceID]:
+		i.fail(row, ErrDuplicateSourceID)
+	default:
+		i.seen[row.SourceID] = true
+		i.pending = append(i.pending, row)
+		if len(i.pending) >= importBatchSize {
+			return i.flush()
+		}
+	}
+	return nil
+}
+
+func (i *taskImporter) Finish() (*models.ImportSummary, error) {
+	if err := i.flush(); err != nil {
+		return nil, err
+	}
+
+	i.service.logger.Info("Finished task import", zap.String("source", i.taskImport.Source), zap.Bool("dry_run", i.taskImport.DryRun),
+		zap.Int("created", i.summary.Created), zap.Int("skipped", i.summary.Skipped), zap.Int("failed", i.summary.Failed))
+	return &i.summary, nil
+}
+
+// flush skips the queued records imported before, prepares the others like CreateTask and stores the
+// valid ones together, unless the import is a dry run. The records imported before are looked up in the
+// unit of work that stores the others, so that concurrent imports of the same records store them once.
+func (i *taskImporter) flush() error {
+	rows := i.pending
+	i.pending = nil
+	if len(rows) == 0 {
+		return nil
+	}
+	s := i.service
+
+	sourceIDs := make([]string, len(rows))
+	requests := make([]models.Task, len(rows))
+	for j, row := range rows {
+		sourceIDs[j] = row.SourceID
+		requests[j] = *row.Task
+	}
+
+	var skipped, created int
+	var failed []models.ImportRow
+	var failures []error
+	err := s.transactor.InTx(i.ctx, func(ctx context.Context) error {
+		// Every attempt starts over from the records, as prepareImport fills in their tasks.
+		skipped, created, failed, failures = 0, 0, nil, nil
+		for j, row := range rows {
+			*row.Task = requests[j]
+		}
+
+		imported, err := s.repo.ImportedSourceIDs(ctx, i.taskImport.Source, sourceIDs)
+		if err != nil {
+			s.logger.Error("Failed to look up imported tasks", zap.Error(err))
+			return &repositoryError{failure: ErrTaskImportFail, cause: err}
+		}
+		skip := make(map[string]bool, len(imported))
+		for _, sourceID := range imported {
+			skip[sourceID] = true
+		}
+
+		// A fresh batch per flush picks up the column ends stored by the previous one.
+		batch := s.newBatch(ctx)
+		var tasks []*models.Task
+		var taskSourceIDs []string
+		for _, row := range rows {
+			if skip[row.SourceID] {
+				skipped++
+				continue
+			}
+			row.Task.ProjectID = i.taskImport.ProjectID
+			if err := s.prepareImport(row.Task, batch); err != nil {
+				if !isTaskRuleError(err) {
+					return &repositoryError{failure: ErrTaskImportFail, cause: err}
+				}
+				failed = append(failed, row)
+				failures = append(failures, err)
+				continue
+			}
+			tasks = append(tasks, row.Task)
+			taskSourceIDs = append(taskSourceIDs, row.SourceID)
+		}
+
+		if len(tasks) > 0 && !i.taskImport.DryRun {
+			if _, err := s.repo.CreateImportedTasks(ctx, i.taskImport.Source, tasks, taskSourceIDs); err != nil {
+				s.logger.Error("Failed to store imported tasks", zap.Error(err))
+				return &repositoryError{failure: ErrTaskImportFail, cause: err}
+			}
+		}
+		created = len(tasks)
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, ErrTaskImportFail) {
+			s.logger.Error("Failed to store imported tasks", zap.Error(err))
+		}
+		return ErrTaskImportFail
+	}
+
+	i.summary.Skipped += skipped
+	i.summary.Created += created
+	for j, row := range failed {
+		i.fail(row, failures[j])
+	}
+	return nil
+}
+
+func (i *taskImporter) fail(row models.ImportRow, err error) {
+	i.summary.Failed++
+	if len(i.summary.Errors) < maxImportErrors {
+		i.summary.Errors = append(i.summary.Errors, models.ImportRowError{Row: row.Row, SourceID: row.SourceID, Message: err.Error()})
+	}
+}
+
+// prepareImport prepares an imported task like CreateTask. Records carry custom field values as text,
+// so values of number fields are parsed first.
+func (s *taskService) prepareImport(task *models.Task, batch *taskBatch) error {
+	fields, err := batch.customFields(task.ProjectID)
+	if err != nil {
+		return ErrTaskCreateFail
+	}
+	for name, value := range task.CustomFields {
+		field, ok := fields[name]
+		if !ok || field.Type != models.FieldTypeNumber || value.Type == models.FieldTypeNumber {
+			continue
+		}
+		number, err := strconv.ParseFloat(strings.TrimSpace(value.String), 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s expects a number value", ErrInvalidFieldValue, name)
+		}
+		task.CustomFields[name] = models.FieldValue{Type: models.FieldTypeNumber, Number: number}
+	}
+	return s.prepareCreate(task, batch)
+}
+
+// isTaskRuleError tells whether err rejects a task for breaking the rules of its project, rather than
+// reporting a failure to check them.
+func isTaskRuleError(err error) bool {
+	return errors.Is(err, ErrInvalidStatus) || errors.Is(err, ErrLabelNotAllowed) ||
+		errors.Is(err, ErrUnknownCustomField) || errors.Is(err, ErrInvalidFieldValue)
+}
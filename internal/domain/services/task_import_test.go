@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func importRow(row int, sourceID, status string, fields map[string]models.FieldValue) models.ImportRow {
+	return models.ImportRow{Row: row, SourceID: sourceID, Task: &models.Task{
+		Title: fmt.Sprintf("Imported %d", row), Description: "From the old tracker", Status: status, CustomFields: fields,
+	}}
+}
+
+func text(value string) models.FieldValue {
+	return models.FieldValue{Type: models.FieldTypeString, String: value}
+}
+
+func Test_taskService_NewTaskImport(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockFields, mockProjects := newCustomFieldMocks()
+	mockRepo := &mockTaskRepository{tasks: make(map[int64]*models.Task)}
+	svc := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	rows := []models.ImportRow{
+		importRow(1, "A-1", "", map[string]models.FieldValue{"points": text(" 3 "), "env": text("prod")}),
+		importRow(2, "A-2", "closed", nil),
+		importRow(3, "A-3", "", map[string]models.FieldValue{"points": text("many")}),
+		importRow(4, "A-4", "", map[string]models.FieldValue{"owner": text("ada")}),
+		importRow(5, "", "", nil),
+		importRow(6, "A-1", "", nil),
+		{Row: 7, SourceID: "A-7", Err: errors.New("Title cannot be empty")},
+		importRow(8, "A-8", "", nil),
+	}
+	runImport := func(t *testing.T, taskImport models.TaskImport) *models.ImportSummary {
+		importer, err := svc.NewTaskImport(context.Background(), taskImport)
+		if err != nil {
+			t.Fatalf("NewTaskImport() unexpected error: %v", err)
+		}
+		for _, row := range rows {
+			// Imports change their tasks, so every run starts from fresh copies.
+			task := row.Task
+			if task != nil {
+				copied := *task
+				copied.CustomFields = maps.Clone(task.CustomFields)
+				row.Task = &copied
+			}
+			if err := importer.Add(row); err != nil {
+				t.Fatalf("Add() unexpected error: %v", err)
+			}
+		}
+		summary, err := importer.Finish()
+		if err != nil {
+			t.Fatalf("Finish() unexpected error: %v", err)
+		}
+		return summary
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		summary := runImport(t, models.TaskImport{ProjectID: 1, Source: "jira", DryRun: true})
+		if summary.Created != 2 || summary.Skipped != 0 || summary.Failed != 6 {
+			t.Errorf("NewTaskImport() created %d, skipped %d, failed %d, want 2, 0, 6", summary.Created, summary.Skipped, summary.Failed)
+		}
+		if len(mockRepo.tasks) != 0 {
+			t.Errorf("NewTaskImport() stored %d tasks in a dry run", len(mockRepo.tasks))
+		}
+
+		wantErrors := []struct {
+			row      int
+			sourceID string
+			err      error
+		}{
+			{row: 5, err: ErrMissingSourceID},
+			{row: 6, sourceID: "A-1", err: ErrDuplicateSourceID},
+			{row: 2, sourceID: "A-2", err: ErrInvalidStatus},
+			{row: 3, sourceID: "A-3", err: ErrInvalidFieldValue},
+			{row: 4, sourceID: "A-4", err: ErrUnknownCustomField},
+		}
+		// Records are checked for source IDs on arrival and against the project when their batch is stored.
+		got := summary.Errors
+		if len(got) != 6 || got[2].Row != 7 || got[2].Message != "Title cannot be empty" {
+			t.Fatalf("NewTaskImport() errors = %+v", got)
+		}
+		got = append(got[:2], got[3:]...)
+		for i, want := range wantErrors {
+			if got[i].Row != want.row || got[i].SourceID != want.sourceID || !strings.HasPrefix(got[i].Message, want.err.Error()) {
+				t.Errorf("NewTaskImport() error %d = %+v, want row %d of %q: %v", i, got[i], want.row, want.sourceID, want.err)
+			}
+		}
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		summary := runImport(t, models.TaskImport{ProjectID: 1, Source: "jira"})
+		if summary.Created != 2 || summary.Failed != 6 {
+			t.Errorf("NewTaskImport() created %d, failed %d, want 2, 6", summary.Created, summary.Failed)
+		}
+		task := mockRepo.tasks[mockRepo.imported["jira/A-1"]]
+		if task == nil {
+			t.Fatal("NewTaskImport() did not record source ID A-1")
+		}
+		if task.ProjectID != 1 || task.Status != "open" || task.ExternalID == "" {
+			t.Errorf("NewTaskImport() created %+v, want an open task of project 1 with an external ID", task)
+		}
+		if points := task.CustomFields["points"]; points.Type != models.FieldTypeNumber || points.Number != 3 {
+			t.Errorf("NewTaskImport() points = %+v, want number 3", points)
+		}
+	})
+
+	t.Run("Running again skips imported records", func(t *testing.T) {
+		summary := runImport(t, models.TaskImport{ProjectID: 1, Source: "jira"})
+		if summary.Created != 0 || summary.Skipped != 2 || summary.Failed != 6 {
+			t.Errorf("NewTaskImport() created %d, skipped %d, failed %d, want 0, 2, 6", summary.Created, summary.Skipped, summary.Failed)
+		}
+		if len(mockRepo.tasks) != 2 {
+			t.Errorf("NewTaskImport() stored %d tasks, want 2", len(mockRepo.tasks))
+		}
+	})
+
+	t.Run("Other sources are imported again", func(t *testing.T) {
+		summary := runImport(t, models.TaskImport{ProjectID: 1, Source: "github"})
+		if summary.Created != 2 || summary.Skipped != 0 {
+			t.Errorf("NewTaskImport() created %d, skipped %d, want 2, 0", summary.Created, summary.Skipped)
+		}
+	})
+
+	for _, tc := range []struct {
+		name      string
+		projectID int64
+		wantErr   error
+	}{
+		{name: "Unknown project", projectID: 9, wantErr: ErrProjectNotFound},
+		{name: "Archived project", projectID: 3, wantErr: ErrProjectArchived},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := svc.NewTaskImport(context.Background(), models.TaskImport{ProjectID: tc.projectID, Source: "csv"}); !errors.Is(err, tc.wantErr) {
+				t.Errorf("NewTaskImport() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func Test_taskImporter_Batches(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockFields, mockProjects := newCustomFieldMocks()
+	mockRepo := &mockTaskRepository{tasks: make(map[int64]*models.Task)}
+	svc := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	importer, err := svc.NewTaskImport(context.Background(), models.TaskImport{ProjectID: 2, Source: "csv"})
+	if err != nil {
+		t.Fatalf("NewTaskImport() unexpected error: %v", err)
+	}
+	for i := 1; i <= importBatchSize; i++ {
+		if err := importer.Add(importRow(i, fmt.Sprint(i), "", nil)); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+	}
+	if len(mockRepo.tasks) != importBatchSize {
+		t.Errorf("Add() stored %d tasks after a full batch, want %d", len(mockRepo.tasks), importBatchSize)
+	}
+
+	if err := importer.Add(importRow(importBatchSize+1, "last", "", nil)); err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+	mockRepo.err = errors.New("connection reset")
+	if _, err := importer.Finish(); !errors.Is(err, ErrTaskImportFail) {
+		t.Errorf("Finish() error = %v, want %v", err, ErrTaskImportFail)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+// TaskPartitioner keeps the partitions of the coming months ready, so that new tasks land in the
+// partition of the month they were created in rather than in the default partition, from which they
+// could not be detached with their month.
+type TaskPartitioner struct {
+	repo     repository.TaskRepository
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func NewTaskPartitioner(repo repository.TaskRepository, interval time.Duration, logger *zap.Logger) *TaskPartitioner {
+	return &TaskPartitioner{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run creates partitions right away and then every interval until ctx is cancelled.
+func (p *TaskPartitioner) Run(ctx context.Context) {
+	p.logger.Info("Starting task partitioner", zap.Duration("interval", p.interval))
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.PartitionOnce(ctx); err != nil {
+			p.logger.Error("Task partitioning failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Stopping task partitioner")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PartitionOnce creates the partitions of the current and the next month, unless they exist.
+func (p *TaskPartitioner) PartitionOnce(ctx context.Context) error {
+	return p.repo.CreateTaskPartitions(ctx, time.Now().AddDate(0, 1, 0))
+}
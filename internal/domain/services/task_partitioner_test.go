@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTaskPartitioner_PartitionOnce(t *testing.T) {
+	mockRepo := &mockTaskRepository{}
+	partitioner := NewTaskPartitioner(mockRepo, time.Hour, zaptest.NewLogger(t))
+
+	if err := partitioner.PartitionOnce(context.Background()); err != nil {
+		t.Fatalf("PartitionOnce() unexpected error: %v", err)
+	}
+	if ahead := time.Until(mockRepo.partitionedUntil); ahead < 27*24*time.Hour {
+		t.Errorf("PartitionOnce() prepared partitions %v ahead, want the next month", ahead)
+	}
+}
@@ -1,69 +1,211 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/ids"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/rank"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
 	"go.uber.org/zap"
 )
 
 var (
-	ErrTaskNotFound   = errors.New("task not found")
-	ErrTaskCreateFail = errors.New("failed to create task")
-	ErrTaskUpdateFail = errors.New("failed to update task")
-	ErrTaskDeleteFail = errors.New("failed to delete task")
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrTaskCreateFail   = errors.New("failed to create task")
+	ErrTaskUpdateFail   = errors.New("failed to update task")
+	ErrTaskDeleteFail   = errors.New("failed to delete task")
+	ErrInvalidStatus    = errors.New("status is not part of the project workflow")
+	ErrLabelNotAllowed  = errors.New("label is not allowed in the project")
+	ErrInvalidMove      = errors.New("invalid task move")
+	ErrTaskMoveFail     = errors.New("failed to move task")
+	ErrTaskTransferFail = errors.New("failed to transfer task")
+	ErrInvalidBatch     = errors.New("invalid batch")
+	ErrInvalidParent    = errors.New("invalid parent task")
+	ErrInvalidRelation  = errors.New("invalid task relation")
+	ErrRelationNotFound = errors.New("task relation not found")
+	ErrRelationFail     = errors.New("failed to change task relations")
+	ErrTaskContentFail  = errors.New("failed to change the checklist, comments or attachments of task")
 )
 
+// repositoryError reports the failure of an operation, such as ErrTaskUpdateFail, caused by a failing
+// repository call inside a unit of work. It matches both errors: callers see the failure of the operation
+// as always, while the transactor sees the cause, which may call for another attempt.
+type repositoryError struct {
+	failure error
+	cause   error
+}
+
+func (e *repositoryError) Error() string {
+	return e.failure.Error()
+}
+
+func (e *repositoryError) Unwrap() []error {
+	return []error{e.failure, e.cause}
+}
+
 type TaskService interface {
-	CreateTask(task *models.Task) (*models.Task, error)
-	ListTasks() ([]*models.Task, error)
-	GetTask(id int64) (*models.Task, error)
-	UpdateTask(task *models.Task) (*models.Task, error)
-	DeleteTask(id int64) error
+	CreateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error)
+	GetTask(ctx context.Context, id int64) (*models.Task, error)
+	// GetTaskIncludingArchived returns the task ref identifies, looking it up in the archive if it is not a
+	// live task.
+	GetTaskIncludingArchived(ctx context.Context, ref models.TaskRef) (*models.Task, error)
+	UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	DeleteTask(ctx context.Context, id int64) error
+	MoveTask(ctx context.Context, move models.TaskMove) (*models.Task, error)
+	TransferTask(ctx context.Context, transfer models.TaskTransfer) (*models.Task, error)
+	// CloneTask creates a copy of a task in its own or another project, as CreateTask would.
+	CloneTask(ctx context.Context, clone models.TaskClone) (*models.Task, error)
+	// CreateTaskRelations relates tasks, keeping the relations that exist already.
+	CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error
+	// ListTaskRelations returns the relations from and to a task.
+	ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error)
+	DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error
+	// SetTaskChecklist replaces the checklist of a task and returns the new one.
+	SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) ([]models.ChecklistItem, error)
+	GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error)
+	CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error)
+	// ListTaskComments returns the comments of a task, oldest first.
+	ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error)
+	CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error)
+	// ListTaskAttachments returns the attachments of a task, oldest first.
+	ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error)
+	ResolveTask(ctx context.Context, ref models.TaskRef) (int64, error)
+	SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error)
+	// ExportTasks passes the tasks matching the export filter to each in ListTasks order without loading
+	// them all at once. The export columns must name task fields or custom fields of the filtered project.
+	ExportTasks(ctx context.Context, export models.TaskExport, each func(*models.Task) error) error
+	// NewTaskImport starts an import of tasks into a project. Records added to the import are validated
+	// like CreateTask and stored in batches, unless the import is a dry run; records imported from the same
+	// source before are skipped.
+	NewTaskImport(ctx context.Context, taskImport models.TaskImport) (TaskImporter, error)
+	// BatchCreateTasks, BatchUpdateTasks and BatchDeleteTasks apply CreateTask, UpdateTask and DeleteTask
+	// to several tasks. Unless bestEffort is set they are all-or-nothing and fail with a *BatchItemError
+	// naming the first invalid item; otherwise they return the result of every item.
+	BatchCreateTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]BatchResult, error)
+	BatchUpdateTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]BatchResult, error)
+	// BatchDeleteTasks deletes tasks by ID. A non-zero ProjectID restricts the deletion of a task to that project.
+	BatchDeleteTasks(ctx context.Context, tasks []*models.Task, bestEffort bool) ([]BatchResult, error)
 }
 
 type taskService struct {
-	repo   repository.TaskRepository
-	logger *zap.Logger
+	repo       repository.TaskRepository
+	projects   repository.ProjectRepository
+	fields     repository.CustomFieldRepository
+	searcher   repository.TaskSearcher
+	transactor repository.Transactor
+	ids        ids.Generator
+	logger     *zap.Logger
 }
 
-func NewTaskService(repo repository.TaskRepository, logger *zap.Logger) TaskService {
+// NewTaskService runs the operations that read tasks before writing them as units of work of transactor,
+// which must run those of repo.
+func NewTaskService(repo repository.TaskRepository, projects repository.ProjectRepository, fields repository.CustomFieldRepository,
+	searcher repository.TaskSearcher, transactor repository.Transactor, idGenerator ids.Generator, logger *zap.Logger) TaskService {
 	return &taskService{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		projects:   projects,
+		fields:     fields,
+		searcher:   searcher,
+		transactor: transactor,
+		ids:        idGenerator,
+		logger:     logger,
 	}
 }
 
-func (s *taskService) CreateTask(task *models.Task) (*models.Task, error) {
-	s.logger.Info("Creating task", zap.String("title", task.Title))
+// CreateTask stores a new task under a fresh external ID, filling in the status and assignee from the project defaults.
+// The task is placed at the end of its column in the unit of work that stores it.
+func (s *taskService) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	s.logger.Info("Creating task", zap.String("title", task.Title), zap.Int64("project_id", task.ProjectID))
 
-	createdTask, err := s.repo.CreateTask(task)
+	request := *task
+	var createdTask *models.Task
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// Every attempt starts over from the request, as prepareCreate fills in the task.
+		*task = request
+		if err := s.prepareCreate(task, s.newBatch(ctx)); err != nil {
+			return err
+		}
+
+		var err error
+		createdTask, err = s.repo.CreateTask(ctx, task)
+		if err != nil {
+			s.logger.Error("Failed to create task", zap.Error(err))
+			return &repositoryError{failure: ErrTaskCreateFail, cause: err}
+		}
+		return nil
+	})
 	if err != nil {
-		s.logger.Error("Failed to create task", zap.Error(err))
-		return nil, ErrTaskCreateFail
+		return nil, err
 	}
 
 	return createdTask, nil
 }
 
-func (s *taskService) ListTasks() ([]*models.Task, error) {
-	s.logger.Info("Listing tasks")
+// ListTasks returns the tasks matching the filter. Conditions on and ordering by custom fields
+// are resolved against the field definitions of the filter's project.
+func (s *taskService) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
+	s.logger.Info("Listing tasks", zap.Int64("project_id", filter.ProjectID))
+
+	if err := s.resolveListFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
 
-	tasks, err := s.repo.ListTasks()
+	tasks, err := s.repo.ListTasks(ctx, filter)
 	if err != nil {
 		s.logger.Error("Failed to list tasks", zap.Error(err))
 		return nil, err
 	}
+	if err := s.typeCustomFields(ctx, tasks...); err != nil {
+		return nil, err
+	}
 
 	return tasks, nil
 }
 
-func (s *taskService) GetTask(id int64) (*models.Task, error) {
+// SearchTasks finds the tasks whose title or description match the search query, best matches first.
+func (s *taskService) SearchTasks(ctx context.Context, search models.TaskSearch) ([]*models.SearchHit, error) {
+	s.logger.Info("Searching tasks", zap.String("query", search.Query), zap.Int64("project_id", search.ProjectID))
+
+	terms, err := parseSearchQuery(search.Query)
+	if err != nil {
+		s.logger.Warn("Invalid search query", zap.Error(err))
+		return nil, err
+	}
+	search.Terms = terms
+	if search.ProjectID != 0 {
+		if _, err := s.project(ctx, search.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	hits, err := s.searcher.SearchTasks(ctx, search)
+	if err != nil {
+		s.logger.Error("Failed to search tasks", zap.Error(err))
+		return nil, err
+	}
+	tasks := make([]*models.Task, len(hits))
+	for i, hit := range hits {
+		tasks[i] = hit.Task
+	}
+	if err := s.typeCustomFields(ctx, tasks...); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+func (s *taskService) GetTask(ctx context.Context, id int64) (*models.Task, error) {
 	s.logger.Info("Fetching task", zap.Int64("id", id))
 
-	task, err := s.repo.GetTask(id)
+	task, err := s.repo.GetTask(ctx, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			s.logger.Warn("Task not found", zap.Int64("id", id))
@@ -72,38 +214,955 @@ func (s *taskService) GetTask(id int64) (*models.Task, error) {
 		s.logger.Error("Failed to fetch task", zap.Error(err))
 		return nil, err
 	}
+	if err := s.typeCustomFields(ctx, task); err != nil {
+		return nil, err
+	}
 
 	return task, nil
 }
 
-func (s *taskService) UpdateTask(task *models.Task) (*models.Task, error) {
-	s.logger.Info("Updating task", zap.Int64("id", task.ID))
+func (s *taskService) GetTaskIncludingArchived(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	id, err := s.ResolveTask(ctx, ref)
+	if err == nil {
+		var task *models.Task
+		if task, err = s.GetTask(ctx, id); err == nil {
+			return task, nil
+		}
+	}
+	if !errors.Is(err, ErrTaskNotFound) {
+		return nil, err
+	}
 
-	updatedTask, err := s.repo.UpdateTask(task)
+	s.logger.Info("Fetching archived task", zap.Int64("id", ref.ID), zap.String("key", ref.Key), zap.String("external_id", ref.ExternalID))
+	task, err := s.repo.GetArchivedTask(ctx, ref)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			s.logger.Warn("Task not found for update", zap.Int64("id", task.ID))
+			s.logger.Warn("Archived task not found", zap.Int64("id", ref.ID), zap.String("key", ref.Key), zap.String("external_id", ref.ExternalID))
 			return nil, ErrTaskNotFound
 		}
-		s.logger.Error("Failed to update task", zap.Error(err))
-		return nil, ErrTaskUpdateFail
+		s.logger.Error("Failed to fetch archived task", zap.Error(err))
+		return nil, err
+	}
+	if err := s.typeCustomFields(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// UpdateTask replaces the task's title and description. Empty status and assignee and nil labels
+// keep their current values. Custom field values are merged into the current ones, where a zero
+// value clears a field. A non-zero ProjectID restricts the update to tasks of that project.
+// The task is read and written in one unit of work, so that a concurrent update is not lost.
+func (s *taskService) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	s.logger.Info("Updating task", zap.Int64("id", task.ID))
+
+	request := *task
+	var updatedTask *models.Task
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// Every attempt starts over from the request, as prepareUpdate fills in the task.
+		*task = request
+		if err := s.prepareUpdate(task, s.newBatch(ctx)); err != nil {
+			return err
+		}
+
+		var err error
+		updatedTask, err = s.repo.UpdateTask(ctx, task)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.logger.Warn("Task not found for update", zap.Int64("id", task.ID))
+				return ErrTaskNotFound
+			}
+			s.logger.Error("Failed to update task", zap.Error(err))
+			return &repositoryError{failure: ErrTaskUpdateFail, cause: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return updatedTask, nil
 }
 
-func (s *taskService) DeleteTask(id int64) error {
+// DeleteTask deletes a task of a project that is not archived. The project is checked in the unit of
+// work that deletes the task, which also makes the subtasks of the task top-level tasks.
+func (s *taskService) DeleteTask(ctx context.Context, id int64) error {
 	s.logger.Info("Deleting task", zap.Int64("id", id))
 
-	err := s.repo.DeleteTask(id)
+	return s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.prepareDelete(&models.Task{ID: id}, s.newBatch(ctx)); err != nil {
+			return err
+		}
+
+		err := s.repo.DeleteTask(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.logger.Warn("Task not found for deletion", zap.Int64("id", id))
+				return ErrTaskNotFound
+			}
+			s.logger.Error("Failed to delete task", zap.Error(err))
+			return &repositoryError{failure: ErrTaskDeleteFail, cause: err}
+		}
+		return s.detachSubtasks(ctx, []int64{id}, ErrTaskDeleteFail)
+	})
+}
+
+// detachSubtasks makes the subtasks of the given tasks top-level tasks, failing with failure if the
+// repository fails. Subtasks are detached from tasks that are deleted or leave the project.
+func (s *taskService) detachSubtasks(ctx context.Context, ids []int64, failure error) error {
+	if _, err := s.repo.DetachSubtasks(ctx, ids); err != nil {
+		s.logger.Error("Failed to detach subtasks", zap.Int64s("parent_ids", ids), zap.Error(err))
+		return &repositoryError{failure: failure, cause: err}
+	}
+	return nil
+}
+
+// ResolveTask returns the numeric ID of the referenced task. Keys the task had in
+// projects it was transferred out of still resolve to it.
+func (s *taskService) ResolveTask(ctx context.Context, ref models.TaskRef) (int64, error) {
+	var id int64
+	var err error
+	switch {
+	case ref.ExternalID != "":
+		id, err = s.repo.GetTaskIDByExternalID(ctx, ref.ExternalID)
+	case ref.Key != "":
+		id, err = s.repo.GetTaskIDByKey(ctx, ref.Key)
+	default:
+		return ref.ID, nil
+	}
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			s.logger.Warn("Task not found for deletion", zap.Int64("id", id))
-			return ErrTaskNotFound
+			s.logger.Warn("Task reference not found", zap.String("key", ref.Key), zap.String("external_id", ref.ExternalID))
+			return 0, ErrTaskNotFound
+		}
+		s.logger.Error("Failed to resolve task reference", zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// TransferTask moves a task to another project. The task gets the next key of the target project,
+// keeps its status if the target workflow has it and is otherwise reset to the initial status,
+// and goes to the end of its new column. It keeps the custom field values that are valid in the target
+// project and drops the others. As subtasks are in the project of their parent, a transferred subtask
+// becomes a top-level task. The subtasks of the task move along with it when the transfer includes its
+// subtree, in the same way and keeping their parents, and otherwise become top-level tasks.
+func (s *taskService) TransferTask(ctx context.Context, transfer models.TaskTransfer) (*models.Task, error) {
+	s.logger.Info("Transferring task", zap.Int64("id", transfer.TaskID), zap.Int64("project_id", transfer.ProjectID),
+		zap.Bool("subtree", transfer.Subtree))
+
+	var transferredTask *models.Task
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		transferredTask, err = s.transferTask(ctx, transfer)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.typeCustomFields(ctx, transferredTask); err != nil {
+		return nil, err
+	}
+
+	return transferredTask, nil
+}
+
+// transferTask runs TransferTask within its unit of work.
+func (s *taskService) transferTask(ctx context.Context, transfer models.TaskTransfer) (*models.Task, error) {
+	existing, err := s.GetTask(ctx, transfer.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ProjectID == transfer.ProjectID {
+		return existing, nil
+	}
+	if _, err := s.writableProject(ctx, existing.ProjectID); err != nil {
+		return nil, err
+	}
+	batch := s.newBatch(ctx)
+	target, err := batch.writableProject(transfer.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := batch.customFields(transfer.ProjectID)
+	if err != nil {
+		return nil, ErrTaskTransferFail
+	}
+
+	tasks := []*models.Task{existing}
+	if transfer.Subtree {
+		subtasks, err := s.subtree(ctx, existing, ErrTaskTransferFail)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, subtasks...)
+	}
+
+	transferred := make([]*models.Task, len(tasks))
+	for i, task := range tasks {
+		status := task.Status
+		if !target.AllowsStatus(status) {
+			status = target.InitialStatus()
+		}
+		for _, label := range task.Labels {
+			if !target.AllowsLabel(label) {
+				return nil, fmt.Errorf("%w: %q", ErrLabelNotAllowed, label)
+			}
+		}
+		customFields, err := mergeCustomFields(nil, fields, portableCustomFields(task.CustomFields, fields))
+		if err != nil {
+			s.logger.Warn("Task lacks custom fields required by the target project", zap.Int64("id", task.ID), zap.Error(err))
+			return nil, err
+		}
+
+		newRank, err := batch.endOfColumn(models.Column{ProjectID: transfer.ProjectID, Status: status})
+		if err != nil {
+			return nil, &repositoryError{failure: ErrTaskTransferFail, cause: err}
+		}
+
+		transferred[i], err = s.repo.TransferTask(ctx, task.ID, transfer.ProjectID, status, newRank, customFields)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrTaskNotFound
+			}
+			s.logger.Error("Failed to transfer task", zap.Error(err))
+			return nil, &repositoryError{failure: ErrTaskTransferFail, cause: err}
 		}
-		s.logger.Error("Failed to delete task", zap.Error(err))
-		return ErrTaskDeleteFail
 	}
 
+	transferredTask := transferred[0]
+	if transferredTask.ParentID != 0 {
+		if transferredTask, err = s.repo.SetTaskParent(ctx, transferredTask.ID, 0); err != nil {
+			s.logger.Error("Failed to detach transferred task", zap.Error(err))
+			return nil, &repositoryError{failure: ErrTaskTransferFail, cause: err}
+		}
+	}
+	if !transfer.Subtree {
+		if err := s.detachSubtasks(ctx, []int64{transferredTask.ID}, ErrTaskTransferFail); err != nil {
+			return nil, err
+		}
+	}
+
+	return transferredTask, nil
+}
+
+// subtree returns the subtasks of a task and theirs, each after its parent, failing with failure if the
+// repository fails.
+func (s *taskService) subtree(ctx context.Context, task *models.Task, failure error) ([]*models.Task, error) {
+	var subtree []*models.Task
+	for parents := []*models.Task{task}; len(parents) > 0; {
+		var subtasks []*models.Task
+		for _, parent := range parents {
+			children, err := s.repo.ListTasks(ctx, models.TaskFilter{
+				ProjectID: parent.ProjectID,
+				Where:     &models.FilterExpr{Field: "parent_id", Type: models.FilterTypeInteger, Operator: "=", Value: parent.ID},
+			})
+			if err != nil {
+				s.logger.Error("Failed to list subtasks", zap.Int64("parent_id", parent.ID), zap.Error(err))
+				return nil, &repositoryError{failure: failure, cause: err}
+			}
+			subtasks = append(subtasks, children...)
+		}
+		subtree = append(subtree, subtasks...)
+		parents = subtasks
+	}
+	return subtree, nil
+}
+
+// CloneTask creates a copy of a task. Like a transferred task, a copy in another project keeps the status
+// if the target workflow has it and starts in the initial status otherwise, and copied custom field values
+// that are not valid in the target project are dropped. Tasks of archived projects may be cloned into
+// projects that are not archived. A copy that includes the subtasks copies the whole subtree in the same
+// way, along with the relations between its tasks, in the same unit of work.
+func (s *taskService) CloneTask(ctx context.Context, clone models.TaskClone) (*models.Task, error) {
+	s.logger.Info("Cloning task", zap.Int64("id", clone.TaskID), zap.Bool("subtasks", clone.Subtasks))
+
+	var clonedTask *models.Task
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		clonedTask, err = s.cloneTask(ctx, clone)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clonedTask, nil
+}
+
+// cloneTask runs CloneTask within its unit of work.
+func (s *taskService) cloneTask(ctx context.Context, clone models.TaskClone) (*models.Task, error) {
+	existing, err := s.GetTask(ctx, clone.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	projectID := existing.ProjectID
+	if clone.ProjectID != nil {
+		projectID = *clone.ProjectID
+	}
+
+	batch := s.newBatch(ctx)
+	clonedTask, err := s.copyTask(existing, clone, projectID, 0, batch)
+	if err != nil {
+		return nil, err
+	}
+	if !clone.Subtasks {
+		return clonedTask, nil
+	}
+
+	subtasks, err := s.subtree(ctx, existing, ErrTaskCreateFail)
+	if err != nil {
+		return nil, err
+	}
+	// copies maps the IDs of the copied tasks to those of their copies.
+	copies := map[int64]int64{existing.ID: clonedTask.ID}
+	clone.Title = ""
+	for _, subtask := range subtasks {
+		copied, err := s.copyTask(subtask, clone, projectID, copies[subtask.ParentID], batch)
+		if err != nil {
+			return nil, err
+		}
+		copies[subtask.ID] = copied.ID
+	}
+	if err := s.copyRelations(ctx, copies); err != nil {
+		return nil, err
+	}
+
+	return clonedTask, nil
+}
+
+// copyTask creates the copy clone describes of one task in the project with ID projectID, as a subtask of
+// the task with ID parentID if it is not zero, and copies the selected content to it.
+func (s *taskService) copyTask(existing *models.Task, clone models.TaskClone, projectID, parentID int64, batch *taskBatch) (*models.Task, error) {
+	target, err := batch.writableProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	task := &models.Task{
+		ProjectID:   projectID,
+		ParentID:    parentID,
+		Title:       existing.Title,
+		Description: existing.Description,
+		Assignee:    existing.Assignee,
+	}
+	if clone.Title != "" {
+		task.Title = clone.Title
+	}
+	if target.AllowsStatus(existing.Status) {
+		task.Status = existing.Status
+	}
+	if clone.Labels {
+		task.Labels = slices.Clone(existing.Labels)
+	}
+	if clone.CustomFields {
+		fields, err := batch.customFields(projectID)
+		if err != nil {
+			return nil, ErrTaskCreateFail
+		}
+		task.CustomFields = portableCustomFields(existing.CustomFields, fields)
+	}
+
+	if err := s.prepareCreate(task, batch); err != nil {
+		return nil, err
+	}
+	clonedTask, err := s.repo.CreateTask(batch.ctx, task)
+	if err != nil {
+		s.logger.Error("Failed to clone task", zap.Error(err))
+		return nil, &repositoryError{failure: ErrTaskCreateFail, cause: err}
+	}
+
+	content := models.TaskContent{Checklist: clone.Checklist, Comments: clone.Comments, Attachments: clone.Attachments}
+	if content != (models.TaskContent{}) {
+		if err := s.repo.CopyTaskContent(batch.ctx, existing.ID, clonedTask.ID, content); err != nil {
+			s.logger.Error("Failed to copy task content", zap.Error(err))
+			return nil, &repositoryError{failure: ErrTaskCreateFail, cause: err}
+		}
+	}
+
+	return clonedTask, nil
+}
+
+// copyRelations relates the copies of the tasks of a cloned subtree as the tasks are related to each
+// other. Relations to tasks outside of the subtree are not copied.
+func (s *taskService) copyRelations(ctx context.Context, copies map[int64]int64) error {
+	ids := make([]int64, 0, len(copies))
+	for id := range copies {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var relations []models.TaskRelation
+	seen := make(map[models.TaskRelation]bool)
+	for _, id := range ids {
+		existing, err := s.repo.ListTaskRelations(ctx, id)
+		if err != nil {
+			s.logger.Error("Failed to list task relations", zap.Int64("id", id), zap.Error(err))
+			return &repositoryError{failure: ErrTaskCreateFail, cause: err}
+		}
+		for _, relation := range existing {
+			taskID, ok := copies[relation.TaskID]
+			relatedTaskID, relatedOK := copies[relation.RelatedTaskID]
+			if !ok || !relatedOK || seen[relation] {
+				continue
+			}
+			seen[relation] = true
+			relations = append(relations, models.TaskRelation{TaskID: taskID, RelatedTaskID: relatedTaskID, Type: relation.Type})
+		}
+	}
+	if len(relations) == 0 {
+		return nil
+	}
+	if err := s.repo.CreateTaskRelations(ctx, relations); err != nil {
+		s.logger.Error("Failed to copy task relations", zap.Error(err))
+		return &repositoryError{failure: ErrTaskCreateFail, cause: err}
+	}
 	return nil
 }
+
+// CreateTaskRelations checks that the related tasks exist and that the projects of the tasks the relations
+// read from are not archived, and stores the relations in one unit of work.
+func (s *taskService) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	s.logger.Info("Creating task relations", zap.Int("count", len(relations)))
+
+	return s.transactor.InTx(ctx, func(ctx context.Context) error {
+		batch := s.newBatch(ctx)
+		for _, relation := range relations {
+			if err := s.checkRelation(relation, batch); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.CreateTaskRelations(ctx, relations); err != nil {
+			s.logger.Error("Failed to create task relations", zap.Error(err))
+			return &repositoryError{failure: ErrRelationFail, cause: err}
+		}
+		return nil
+	})
+}
+
+func (s *taskService) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	s.logger.Info("Listing task relations", zap.Int64("id", id))
+
+	if _, err := s.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+	relations, err := s.repo.ListTaskRelations(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to list task relations", zap.Error(err))
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+// DeleteTaskRelation deletes a relation of a task whose project is not archived.
+func (s *taskService) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	s.logger.Info("Deleting task relation", zap.Int64("task_id", relation.TaskID), zap.Int64("related_task_id", relation.RelatedTaskID),
+		zap.String("type", relation.Type))
+
+	return s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.checkWritableTask(ctx, relation.TaskID); err != nil {
+			return err
+		}
+
+		err := s.repo.DeleteTaskRelation(ctx, relation)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.logger.Warn("Task relation not found")
+				return ErrRelationNotFound
+			}
+			s.logger.Error("Failed to delete task relation", zap.Error(err))
+			return &repositoryError{failure: ErrRelationFail, cause: err}
+		}
+		return nil
+	})
+}
+
+// SetTaskChecklist replaces the checklist of a task whose project is not archived.
+func (s *taskService) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) ([]models.ChecklistItem, error) {
+	s.logger.Info("Setting task checklist", zap.Int64("id", id), zap.Int("items", len(items)))
+
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.checkWritableTask(ctx, id); err != nil {
+			return err
+		}
+		if err := s.repo.SetTaskChecklist(ctx, id, items); err != nil {
+			s.logger.Error("Failed to set task checklist", zap.Error(err))
+			return &repositoryError{failure: ErrTaskContentFail, cause: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (s *taskService) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	s.logger.Info("Fetching task checklist", zap.Int64("id", id))
+
+	if _, err := s.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.GetTaskChecklist(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to fetch task checklist", zap.Error(err))
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// CreateTaskComment comments on a task whose project is not archived.
+func (s *taskService) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	s.logger.Info("Creating task comment", zap.Int64("task_id", comment.TaskID), zap.String("author", comment.Author))
+
+	var createdComment *models.TaskComment
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.checkWritableTask(ctx, comment.TaskID); err != nil {
+			return err
+		}
+		var err error
+		if createdComment, err = s.repo.CreateTaskComment(ctx, comment); err != nil {
+			s.logger.Error("Failed to create task comment", zap.Error(err))
+			return &repositoryError{failure: ErrTaskContentFail, cause: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return createdComment, nil
+}
+
+func (s *taskService) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	s.logger.Info("Listing task comments", zap.Int64("id", id))
+
+	if _, err := s.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+	comments, err := s.repo.ListTaskComments(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to list task comments", zap.Error(err))
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// CreateTaskAttachment attaches a file to a task whose project is not archived.
+func (s *taskService) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	s.logger.Info("Creating task attachment", zap.Int64("task_id", attachment.TaskID), zap.String("name", attachment.Name))
+
+	var createdAttachment *models.TaskAttachment
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := s.checkWritableTask(ctx, attachment.TaskID); err != nil {
+			return err
+		}
+		var err error
+		if createdAttachment, err = s.repo.CreateTaskAttachment(ctx, attachment); err != nil {
+			s.logger.Error("Failed to create task attachment", zap.Error(err))
+			return &repositoryError{failure: ErrTaskContentFail, cause: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return createdAttachment, nil
+}
+
+func (s *taskService) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	s.logger.Info("Listing task attachments", zap.Int64("id", id))
+
+	if _, err := s.GetTask(ctx, id); err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.ListTaskAttachments(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to list task attachments", zap.Error(err))
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// checkWritableTask checks that a task exists in a project that is not archived.
+func (s *taskService) checkWritableTask(ctx context.Context, id int64) error {
+	task, err := s.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = s.writableProject(ctx, task.ProjectID)
+	return err
+}
+
+// checkRelation checks the type of a relation and that it relates two existing tasks, the first of which
+// is in a project that is not archived.
+func (s *taskService) checkRelation(relation models.TaskRelation, batch *taskBatch) error {
+	if !slices.Contains(models.RelationTypes, relation.Type) {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRelation, relation.Type)
+	}
+	if relation.TaskID == relation.RelatedTaskID {
+		return fmt.Errorf("%w: a task cannot be related to itself", ErrInvalidRelation)
+	}
+	task, err := s.GetTask(batch.ctx, relation.TaskID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.GetTask(batch.ctx, relation.RelatedTaskID); err != nil {
+		return err
+	}
+	_, err = batch.writableProject(task.ProjectID)
+	return err
+}
+
+// MoveTask places a task between two neighbours of a board column, optionally changing its status.
+// Only the moved task is written; the neighbours keep their ranks.
+func (s *taskService) MoveTask(ctx context.Context, move models.TaskMove) (*models.Task, error) {
+	s.logger.Info("Moving task", zap.Int64("id", move.TaskID), zap.String("status", move.Status),
+		zap.Int64("after_id", move.AfterID), zap.Int64("before_id", move.BeforeID))
+
+	var movedTask *models.Task
+	err := s.transactor.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		movedTask, err = s.moveTask(ctx, move)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.typeCustomFields(ctx, movedTask); err != nil {
+		return nil, err
+	}
+
+	return movedTask, nil
+}
+
+// moveTask runs MoveTask within its unit of work.
+func (s *taskService) moveTask(ctx context.Context, move models.TaskMove) (*models.Task, error) {
+	existing, err := s.GetTask(ctx, move.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if move.ProjectID != 0 && move.ProjectID != existing.ProjectID {
+		s.logger.Warn("Task not found in project", zap.Int64("id", move.TaskID), zap.Int64("project_id", move.ProjectID))
+		return nil, ErrTaskNotFound
+	}
+
+	project, err := s.writableProject(ctx, existing.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if move.Status == "" {
+		move.Status = existing.Status
+	}
+	if move.Status != existing.Status && !project.AllowsStatus(move.Status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, move.Status)
+	}
+
+	column := models.Column{ProjectID: existing.ProjectID, Status: move.Status}
+	lower, err := s.neighbourRank(ctx, column, move.AfterID, move.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := s.neighbourRank(ctx, column, move.BeforeID, move.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case move.AfterID != 0 && move.BeforeID == 0:
+		upper, err = s.repo.RankAfter(ctx, column, lower)
+	case move.AfterID == 0:
+		lower, err = s.repo.RankBefore(ctx, column, upper)
+	}
+	if err != nil {
+		s.logger.Error("Failed to look up neighbouring ranks", zap.Error(err))
+		return nil, &repositoryError{failure: ErrTaskMoveFail, cause: err}
+	}
+
+	newRank, err := rank.Between(lower, upper)
+	if err != nil {
+		s.logger.Warn("Cannot place task between neighbours", zap.String("lower", lower), zap.String("upper", upper), zap.Error(err))
+		return nil, fmt.Errorf("%w: the task after which to place it must come before the task before which to place it", ErrInvalidMove)
+	}
+
+	movedTask, err := s.repo.MoveTask(ctx, move.TaskID, move.Status, newRank)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		s.logger.Error("Failed to move task", zap.Error(err))
+		return nil, &repositoryError{failure: ErrTaskMoveFail, cause: err}
+	}
+
+	return movedTask, nil
+}
+
+// neighbourRank returns the rank of a neighbour named in a move, checking that it is in the target column.
+// A zero ID has no rank.
+func (s *taskService) neighbourRank(ctx context.Context, column models.Column, id, movedID int64) (string, error) {
+	if id == 0 {
+		return "", nil
+	}
+	if id == movedID {
+		return "", fmt.Errorf("%w: a task cannot be its own neighbour", ErrInvalidMove)
+	}
+
+	neighbour, err := s.repo.GetTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: neighbour task %d not found", ErrInvalidMove, id)
+		}
+		s.logger.Error("Failed to fetch neighbour task", zap.Error(err))
+		return "", ErrTaskMoveFail
+	}
+	if neighbour.ProjectID != column.ProjectID || neighbour.Status != column.Status {
+		return "", fmt.Errorf("%w: neighbour task %d is not in the target column", ErrInvalidMove, id)
+	}
+
+	return neighbour.Rank, nil
+}
+
+// project loads the project with the given ID. Tasks outside of any project use the zero project,
+// which has the default workflow and accepts any label.
+func (s *taskService) project(ctx context.Context, id int64) (*models.Project, error) {
+	if id == 0 {
+		return &models.Project{}, nil
+	}
+
+	project, err := s.projects.GetProject(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found", zap.Int64("project_id", id))
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// writableProject loads the project like project, but fails with ErrProjectArchived
+// when its tasks are read-only.
+func (s *taskService) writableProject(ctx context.Context, id int64) (*models.Project, error) {
+	project, err := s.project(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived {
+		s.logger.Warn("Project is archived", zap.Int64("project_id", id))
+		return nil, ErrProjectArchived
+	}
+	return project, nil
+}
+
+// validateTaskSettings checks the task's status and labels against the project settings.
+// Values the task already had before an update are accepted even if the settings changed since.
+func validateTaskSettings(task *models.Task, project *models.Project, existing *models.Task) error {
+	if (existing == nil || task.Status != existing.Status) && !project.AllowsStatus(task.Status) {
+		return fmt.Errorf("%w: %q", ErrInvalidStatus, task.Status)
+	}
+	for _, label := range task.Labels {
+		if existing != nil && slices.Contains(existing.Labels, label) {
+			continue
+		}
+		if !project.AllowsLabel(label) {
+			return fmt.Errorf("%w: %q", ErrLabelNotAllowed, label)
+		}
+	}
+	return nil
+}
+
+// customFields loads the custom field definitions of a project, keyed by name.
+// Tasks outside of any project have no custom fields.
+func (s *taskService) customFields(ctx context.Context, projectID int64) (map[string]*models.CustomField, error) {
+	if projectID == 0 {
+		return nil, nil
+	}
+
+	fields, err := s.fields.ListCustomFields(ctx, projectID)
+	if err != nil {
+		s.logger.Error("Failed to list custom fields", zap.Int64("project_id", projectID), zap.Error(err))
+		return nil, err
+	}
+
+	byName := make(map[string]*models.CustomField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	return byName, nil
+}
+
+// typeCustomFields gives the custom field values of tasks the types of their field definitions,
+// as the repository only tells numbers from strings.
+func (s *taskService) typeCustomFields(ctx context.Context, tasks ...*models.Task) error {
+	byProject := make(map[int64]map[string]*models.CustomField)
+	for _, task := range tasks {
+		if err := s.typeTaskCustomFields(ctx, task, byProject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeTaskCustomFields types the custom field values of one task, loading the field definitions of
+// its project into byProject unless they are there already.
+func (s *taskService) typeTaskCustomFields(ctx context.Context, task *models.Task, byProject map[int64]map[string]*models.CustomField) error {
+	if len(task.CustomFields) == 0 {
+		return nil
+	}
+	fields, ok := byProject[task.ProjectID]
+	if !ok {
+		var err error
+		if fields, err = s.customFields(ctx, task.ProjectID); err != nil {
+			return err
+		}
+		byProject[task.ProjectID] = fields
+	}
+	for name, value := range task.CustomFields {
+		if field, ok := fields[name]; ok {
+			value.Type = field.Type
+			task.CustomFields[name] = value
+		}
+	}
+	return nil
+}
+
+// resolveListFilter checks that the filtered project exists and resolves the custom fields and the
+// filter expression the filter refers to.
+func (s *taskService) resolveListFilter(ctx context.Context, filter *models.TaskFilter) error {
+	if filter.ProjectID != 0 {
+		if _, err := s.project(ctx, filter.ProjectID); err != nil {
+			return err
+		}
+	}
+	if err := s.resolveFieldFilter(ctx, filter); err != nil {
+		s.logger.Warn("Invalid custom field filter", zap.Error(err))
+		return err
+	}
+	if err := s.resolveFilterExpression(ctx, filter); err != nil {
+		s.logger.Warn("Invalid filter", zap.String("filter", filter.Expression), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// resolveFieldFilter looks up the types of the custom fields a filter refers to.
+// Values may be given as any type of the same kind as the field, as in task updates.
+func (s *taskService) resolveFieldFilter(ctx context.Context, filter *models.TaskFilter) error {
+	if len(filter.CustomFields) == 0 && filter.OrderBy == nil {
+		return nil
+	}
+	if filter.ProjectID == 0 {
+		return fmt.Errorf("%w: custom fields can only be filtered and sorted by within a project", ErrUnknownCustomField)
+	}
+
+	fields, err := s.customFields(ctx, filter.ProjectID)
+	if err != nil {
+		return err
+	}
+	for i, condition := range filter.CustomFields {
+		field, ok := fields[condition.Field]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCustomField, condition.Field)
+		}
+		if (condition.Value.Type == models.FieldTypeNumber) != (field.Type == models.FieldTypeNumber) {
+			return fmt.Errorf("%w: %s expects a %s value", ErrInvalidFieldValue, field.Name, field.Type)
+		}
+		filter.CustomFields[i].Type = field.Type
+		filter.CustomFields[i].Value.Type = field.Type
+	}
+	if filter.OrderBy != nil {
+		field, ok := fields[filter.OrderBy.Field]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCustomField, filter.OrderBy.Field)
+		}
+		filter.OrderBy.Type = field.Type
+		if filter.After != nil && filter.After.SortValue != nil {
+			_, isNumber := filter.After.SortValue.(float64)
+			if isNumber != (field.Type == models.FieldTypeNumber) {
+				return fmt.Errorf("%w: the page token does not match the order", ErrInvalidFieldValue)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveFilterExpression parses the filter expression and checks it against the task fields and
+// the custom fields of the filtered project.
+func (s *taskService) resolveFilterExpression(ctx context.Context, filter *models.TaskFilter) error {
+	if filter.Expression == "" {
+		return nil
+	}
+
+	node, err := parseFilter(filter.Expression)
+	if err != nil {
+		return err
+	}
+	var fields map[string]*models.CustomField
+	if node.usesCustomFields() {
+		if fields, err = s.customFields(ctx, filter.ProjectID); err != nil {
+			return err
+		}
+	}
+	filter.Where, err = checkFilter(node, fields)
+	return err
+}
+
+// portableCustomFields returns the custom field values that are valid for the given fields, typed as those
+// fields. Values of tasks moving or copied to another project keep only what that project defines.
+func portableCustomFields(values map[string]models.FieldValue, fields map[string]*models.CustomField) map[string]models.FieldValue {
+	portable := make(map[string]models.FieldValue)
+	for name, value := range values {
+		if field, ok := fields[name]; ok {
+			if value, err := validateFieldValue(field, value); err == nil {
+				portable[name] = value
+			}
+		}
+	}
+	return portable
+}
+
+// mergeCustomFields applies updates to the current custom field values of a task and validates the
+// result against the project's fields. A zero update value clears the field. Only updated values are
+// checked against the field rules, but every required field must have a value afterwards.
+func mergeCustomFields(updates map[string]models.FieldValue, fields map[string]*models.CustomField,
+	current map[string]models.FieldValue) (map[string]models.FieldValue, error) {
+	merged := maps.Clone(current)
+	if merged == nil {
+		merged = make(map[string]models.FieldValue, len(updates))
+	}
+	for name, value := range updates {
+		if value == (models.FieldValue{}) {
+			delete(merged, name)
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCustomField, name)
+		}
+		value, err := validateFieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		merged[name] = value
+	}
+	var missing []string
+	for name, field := range fields {
+		if _, ok := merged[name]; field.Required && !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("%w: missing required %s", ErrInvalidFieldValue, strings.Join(missing, ", "))
+	}
+	return merged, nil
+}
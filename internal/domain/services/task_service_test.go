@@ -1,20 +1,40 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
 	"go.uber.org/zap/zaptest"
 )
 
 type mockTaskRepository struct {
-	tasks map[int64]*models.Task
-	err   error
+	tasks    map[int64]*models.Task
+	aliases  map[string]int64
+	imported map[string]int64
+	archived map[int64]*models.Task
+	// relations holds the stored relations in the order they were created.
+	relations   []models.TaskRelation
+	checklists  map[int64][]models.ChecklistItem
+	comments    []*models.TaskComment
+	attachments []*models.TaskAttachment
+	// partitionedUntil is the time CreateTaskPartitions was last called with.
+	partitionedUntil time.Time
+	lastFilter       models.TaskFilter
+	err              error
 }
 
-func (m *mockTaskRepository) CreateTask(task *models.Task) (*models.Task, error) {
+func (m *mockTaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -23,29 +43,51 @@ func (m *mockTaskRepository) CreateTask(task *models.Task) (*models.Task, error)
 	return task, nil
 }
 
-func (m *mockTaskRepository) ListTasks() ([]*models.Task, error) {
+func (m *mockTaskRepository) ListTasks(ctx context.Context, filter models.TaskFilter) ([]*models.Task, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	m.lastFilter = filter
 	var taskList []*models.Task
 	for _, task := range m.tasks {
+		if filter.ProjectID != 0 && task.ProjectID != filter.ProjectID {
+			continue
+		}
+		// Of the filter expressions, only the comparison of parent_id made by subtree is supported.
+		if where := filter.Where; where != nil && where.Field == "parent_id" && task.ParentID != where.Value {
+			continue
+		}
 		taskList = append(taskList, task)
 	}
+	sort.Slice(taskList, func(i, j int) bool { return taskList[i].ID < taskList[j].ID })
 	return taskList, nil
 }
 
-func (m *mockTaskRepository) GetTask(id int64) (*models.Task, error) {
+func (m *mockTaskRepository) ExportTasks(ctx context.Context, filter models.TaskFilter, each func(*models.Task) error) error {
+	tasks, err := m.ListTasks(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := each(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockTaskRepository) GetTask(ctx context.Context, id int64) (*models.Task, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	task, exists := m.tasks[id]
 	if !exists {
-		return nil, nil
+		return nil, sql.ErrNoRows
 	}
 	return task, nil
 }
 
-func (m *mockTaskRepository) UpdateTask(task *models.Task) (*models.Task, error) {
+func (m *mockTaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -57,7 +99,7 @@ func (m *mockTaskRepository) UpdateTask(task *models.Task) (*models.Task, error)
 	return task, nil
 }
 
-func (m *mockTaskRepository) DeleteTask(id int64) error {
+func (m *mockTaskRepository) DeleteTask(ctx context.Context, id int64) error {
 	if m.err != nil {
 		return m.err
 	}
@@ -69,11 +111,520 @@ func (m *mockTaskRepository) DeleteTask(id int64) error {
 	return nil
 }
 
+func (m *mockTaskRepository) CreateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, task := range tasks {
+		task.ID = int64(len(m.tasks) + 1)
+		m.tasks[task.ID] = task
+	}
+	return tasks, nil
+}
+
+func (m *mockTaskRepository) CreateImportedTasks(ctx context.Context, source string, tasks []*models.Task, sourceIDs []string) ([]*models.Task, error) {
+	if _, err := m.CreateTasks(ctx, tasks); err != nil {
+		return nil, err
+	}
+	if m.imported == nil {
+		m.imported = make(map[string]int64)
+	}
+	for i, task := range tasks {
+		m.imported[source+"/"+sourceIDs[i]] = task.ID
+	}
+	return tasks, nil
+}
+
+func (m *mockTaskRepository) ImportedSourceIDs(ctx context.Context, source string, sourceIDs []string) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var imported []string
+	for _, sourceID := range sourceIDs {
+		if _, ok := m.imported[source+"/"+sourceID]; ok {
+			imported = append(imported, sourceID)
+		}
+	}
+	return imported, nil
+}
+
+func (m *mockTaskRepository) UpdateTasks(ctx context.Context, tasks []*models.Task) ([]*models.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, task := range tasks {
+		if _, exists := m.tasks[task.ID]; !exists {
+			return nil, sql.ErrNoRows
+		}
+	}
+	for _, task := range tasks {
+		m.tasks[task.ID] = task
+	}
+	return tasks, nil
+}
+
+func (m *mockTaskRepository) DeleteTasks(ctx context.Context, ids []int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, id := range ids {
+		if _, exists := m.tasks[id]; !exists {
+			return sql.ErrNoRows
+		}
+	}
+	for _, id := range ids {
+		delete(m.tasks, id)
+	}
+	return nil
+}
+
+func (m *mockTaskRepository) GetTaskIDByKey(ctx context.Context, key string) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	for _, task := range m.tasks {
+		if task.Key == key {
+			return task.ID, nil
+		}
+	}
+	for oldKey, id := range m.aliases {
+		if oldKey == key {
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (m *mockTaskRepository) GetTaskIDByExternalID(ctx context.Context, externalID string) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	for _, task := range m.tasks {
+		if task.ExternalID == externalID {
+			return task.ID, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (m *mockTaskRepository) TransferTask(ctx context.Context, id, projectID int64, status, rank string, customFields map[string]models.FieldValue) (*models.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	task, exists := m.tasks[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	if m.aliases == nil {
+		m.aliases = make(map[string]int64)
+	}
+	m.aliases[task.Key] = id
+	transferred := *task
+	task = &transferred
+	m.tasks[id] = task
+	task.Key = fmt.Sprintf("P%d-%d", projectID, id)
+	task.ProjectID = projectID
+	task.Status = status
+	task.Rank = rank
+	task.CustomFields = customFields
+	return task, nil
+}
+
+func (m *mockTaskRepository) SetTaskParent(ctx context.Context, id, parentID int64) (*models.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	task, exists := m.tasks[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	moved := *task
+	moved.ParentID = parentID
+	m.tasks[id] = &moved
+	return &moved, nil
+}
+
+func (m *mockTaskRepository) DetachSubtasks(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var ids []int64
+	for _, task := range m.tasks {
+		if task.ParentID != 0 && slices.Contains(parentIDs, task.ParentID) {
+			detached := *task
+			detached.ParentID = 0
+			m.tasks[task.ID] = &detached
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockTaskRepository) CreateTaskRelations(ctx context.Context, relations []models.TaskRelation) error {
+	if m.err != nil {
+		return m.err
+	}
+	for _, relation := range relations {
+		if !slices.Contains(m.relations, relation) {
+			m.relations = append(m.relations, relation)
+		}
+	}
+	return nil
+}
+
+func (m *mockTaskRepository) ListTaskRelations(ctx context.Context, id int64) ([]models.TaskRelation, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var relations []models.TaskRelation
+	for _, relation := range m.relations {
+		if relation.TaskID == id || relation.RelatedTaskID == id {
+			relations = append(relations, relation)
+		}
+	}
+	return relations, nil
+}
+
+func (m *mockTaskRepository) DeleteTaskRelation(ctx context.Context, relation models.TaskRelation) error {
+	if m.err != nil {
+		return m.err
+	}
+	i := slices.Index(m.relations, relation)
+	if i < 0 {
+		return sql.ErrNoRows
+	}
+	m.relations = slices.Delete(m.relations, i, i+1)
+	return nil
+}
+
+func (m *mockTaskRepository) SetTaskChecklist(ctx context.Context, id int64, items []models.ChecklistItem) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.checklists == nil {
+		m.checklists = make(map[int64][]models.ChecklistItem)
+	}
+	m.checklists[id] = slices.Clone(items)
+	return nil
+}
+
+func (m *mockTaskRepository) GetTaskChecklist(ctx context.Context, id int64) ([]models.ChecklistItem, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return append([]models.ChecklistItem{}, m.checklists[id]...), nil
+}
+
+func (m *mockTaskRepository) CreateTaskComment(ctx context.Context, comment *models.TaskComment) (*models.TaskComment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	comment.ID = int64(len(m.comments) + 1)
+	m.comments = append(m.comments, comment)
+	return comment, nil
+}
+
+func (m *mockTaskRepository) ListTaskComments(ctx context.Context, id int64) ([]*models.TaskComment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var comments []*models.TaskComment
+	for _, comment := range m.comments {
+		if comment.TaskID == id {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (m *mockTaskRepository) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) (*models.TaskAttachment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	attachment.ID = int64(len(m.attachments) + 1)
+	m.attachments = append(m.attachments, attachment)
+	return attachment, nil
+}
+
+func (m *mockTaskRepository) ListTaskAttachments(ctx context.Context, id int64) ([]*models.TaskAttachment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var attachments []*models.TaskAttachment
+	for _, attachment := range m.attachments {
+		if attachment.TaskID == id {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (m *mockTaskRepository) CopyTaskContent(ctx context.Context, fromID, toID int64, content models.TaskContent) error {
+	if m.err != nil {
+		return m.err
+	}
+	if content.Checklist {
+		if err := m.SetTaskChecklist(ctx, toID, m.checklists[fromID]); err != nil {
+			return err
+		}
+	}
+	if content.Comments {
+		comments, _ := m.ListTaskComments(ctx, fromID)
+		for _, comment := range comments {
+			copied := *comment
+			copied.TaskID = toID
+			if _, err := m.CreateTaskComment(ctx, &copied); err != nil {
+				return err
+			}
+		}
+	}
+	if content.Attachments {
+		attachments, _ := m.ListTaskAttachments(ctx, fromID)
+		for _, attachment := range attachments {
+			copied := *attachment
+			copied.TaskID = toID
+			if _, err := m.CreateTaskAttachment(ctx, &copied); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockTaskRepository) MoveTask(ctx context.Context, id int64, status, rank string) (*models.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	task, exists := m.tasks[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	task.Status = status
+	task.Rank = rank
+	return task, nil
+}
+
+func (m *mockTaskRepository) RankBefore(ctx context.Context, column models.Column, rank string) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	before := ""
+	for _, task := range m.columnTasks(column) {
+		if (rank == "" || task.Rank < rank) && task.Rank > before {
+			before = task.Rank
+		}
+	}
+	return before, nil
+}
+
+func (m *mockTaskRepository) RankAfter(ctx context.Context, column models.Column, rank string) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	after := ""
+	for _, task := range m.columnTasks(column) {
+		if task.Rank > rank && (after == "" || task.Rank < after) {
+			after = task.Rank
+		}
+	}
+	return after, nil
+}
+
+func (m *mockTaskRepository) ColumnsWithLongRanks(ctx context.Context, maxLength int) ([]models.Column, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	seen := make(map[models.Column]bool)
+	var columns []models.Column
+	for _, task := range m.tasks {
+		column := models.Column{ProjectID: task.ProjectID, Status: task.Status}
+		if len(task.Rank) > maxLength && !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+func (m *mockTaskRepository) RebalanceColumn(ctx context.Context, column models.Column, ranks func(count int) []string) error {
+	if m.err != nil {
+		return m.err
+	}
+	tasks := m.columnTasks(column)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Rank < tasks[j].Rank })
+	for i, rank := range ranks(len(tasks)) {
+		tasks[i].Rank = rank
+	}
+	return nil
+}
+
+func (m *mockTaskRepository) ArchiveTasks(ctx context.Context, column models.Column, updatedBefore time.Time, limit int) ([]int64, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.archived == nil {
+		m.archived = make(map[int64]*models.Task)
+	}
+	tasks := m.columnTasks(column)
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	var ids []int64
+	for _, task := range tasks {
+		if len(ids) < limit && task.UpdatedAt.Before(updatedBefore) {
+			task.ArchivedAt = time.Now()
+			m.archived[task.ID] = task
+			delete(m.tasks, task.ID)
+			ids = append(ids, task.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockTaskRepository) CreateTaskPartitions(ctx context.Context, until time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.partitionedUntil = until
+	return nil
+}
+
+func (m *mockTaskRepository) GetArchivedTask(ctx context.Context, ref models.TaskRef) (*models.Task, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, task := range m.archived {
+		if task.ID == ref.ID || (ref.Key != "" && task.Key == ref.Key) || (ref.ExternalID != "" && task.ExternalID == ref.ExternalID) {
+			return task, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockTaskRepository) columnTasks(column models.Column) []*models.Task {
+	var tasks []*models.Task
+	for _, task := range m.tasks {
+		if task.ProjectID == column.ProjectID && task.Status == column.Status {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+type mockIDGenerator struct {
+	next int
+}
+
+func (m *mockIDGenerator) NewID() string {
+	m.next++
+	return fmt.Sprintf("01KDVDNA%018d", m.next)
+}
+
+// mockTransactor runs a unit of work once, or once more for every conflict, calling between before each
+// further attempt as a concurrent transaction would change the tasks.
+type mockTransactor struct {
+	conflicts int
+	between   func()
+}
+
+func (m *mockTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	for i := 0; i < m.conflicts; i++ {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		if m.between != nil {
+			m.between()
+		}
+	}
+	return fn(ctx)
+}
+
+// rollbackTransactor runs a unit of work once and undoes its changes to the tasks, relations and content of repo when it fails,
+// or when committing it fails with commitErr.
+type rollbackTransactor struct {
+	repo      *mockTaskRepository
+	commitErr error
+}
+
+func (m *rollbackTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	snapshot, relations := maps.Clone(m.repo.tasks), slices.Clone(m.repo.relations)
+	checklists, comments, attachments := maps.Clone(m.repo.checklists), slices.Clone(m.repo.comments), slices.Clone(m.repo.attachments)
+	err := fn(ctx)
+	if err == nil {
+		err = m.commitErr
+	}
+	if err != nil {
+		m.repo.tasks, m.repo.relations = snapshot, relations
+		m.repo.checklists, m.repo.comments, m.repo.attachments = checklists, comments, attachments
+	}
+	return err
+}
+
+type mockProjectRepository struct {
+	projects          map[int64]*models.Project
+	projectsWithTasks map[int64]bool
+	err               error
+}
+
+func (m *mockProjectRepository) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	project.ID = int64(len(m.projects) + 1)
+	m.projects[project.ID] = project
+	return project, nil
+}
+
+func (m *mockProjectRepository) ListProjects(ctx context.Context) ([]*models.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var projectList []*models.Project
+	for _, project := range m.projects {
+		projectList = append(projectList, project)
+	}
+	return projectList, nil
+}
+
+func (m *mockProjectRepository) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	project, exists := m.projects[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return project, nil
+}
+
+func (m *mockProjectRepository) UpdateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if _, exists := m.projects[project.ID]; !exists {
+		return nil, sql.ErrNoRows
+	}
+	m.projects[project.ID] = project
+	return project, nil
+}
+
+func (m *mockProjectRepository) DeleteProject(ctx context.Context, id int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, exists := m.projects[id]; !exists {
+		return sql.ErrNoRows
+	}
+	if m.projectsWithTasks[id] {
+		return repository.ErrNotEmpty
+	}
+	delete(m.projects, id)
+	return nil
+}
+
 func Test_taskService_CreateTask(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	mockRepo := &mockTaskRepository{tasks: make(map[int64]*models.Task)}
 
-	svc := NewTaskService(mockRepo, logger)
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
 
 	tests := []struct {
 		name    string
@@ -89,11 +640,14 @@ func Test_taskService_CreateTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := svc.CreateTask(tt.task)
+			created, err := svc.CreateTask(context.Background(), tt.task)
 			log.Println(err)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateTask() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err == nil && created.ExternalID == "" {
+				t.Errorf("CreateTask() did not assign an external ID")
+			}
 		})
 	}
 }
@@ -107,9 +661,9 @@ func Test_taskService_ListTasks(t *testing.T) {
 		},
 	}
 
-	svc := NewTaskService(mockRepo, logger)
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
 
-	tasks, err := svc.ListTasks()
+	tasks, err := svc.ListTasks(context.Background(), models.TaskFilter{})
 	if err != nil {
 		t.Errorf("ListTasks() unexpected error: %v", err)
 	}
@@ -127,7 +681,7 @@ func Test_taskService_GetTask(t *testing.T) {
 		},
 	}
 
-	svc := NewTaskService(mockRepo, logger)
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
 
 	tests := []struct {
 		name    string
@@ -143,7 +697,7 @@ func Test_taskService_GetTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			task, err := svc.GetTask(tt.id)
+			task, err := svc.GetTask(context.Background(), tt.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetTask() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -162,7 +716,7 @@ func Test_taskService_UpdateTask(t *testing.T) {
 		},
 	}
 
-	svc := NewTaskService(mockRepo, logger)
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
 
 	tests := []struct {
 		name    string
@@ -183,7 +737,7 @@ func Test_taskService_UpdateTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := svc.UpdateTask(tt.task)
+			_, err := svc.UpdateTask(context.Background(), tt.task)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("UpdateTask() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -191,6 +745,71 @@ func Test_taskService_UpdateTask(t *testing.T) {
 	}
 }
 
+func Test_taskService_UpdateTaskRetried(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			1: {ID: 1, Title: "Task 1", Assignee: "alice"},
+		},
+	}
+	transactor := &mockTransactor{conflicts: 1, between: func() {
+		mockRepo.tasks[1] = &models.Task{ID: 1, Title: "Task 1", Assignee: "bob"}
+	}}
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, transactor, &mockIDGenerator{}, logger)
+
+	updated, err := svc.UpdateTask(context.Background(), &models.Task{ID: 1, Title: "Changed"})
+	if err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	if updated.Title != "Changed" || updated.Assignee != "bob" {
+		t.Errorf("UpdateTask() = %q assigned to %q, want the retry to keep the concurrent assignee", updated.Title, updated.Assignee)
+	}
+}
+
+func Test_taskService_RolledBack(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	commitErr := errors.New("could not serialize access")
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockRepo := &mockTaskRepository{
+			tasks: map[int64]*models.Task{
+				1: {ID: 1, Title: "Task 1", Status: "open", Rank: "i"},
+			},
+		}
+		transactor := &rollbackTransactor{repo: mockRepo, commitErr: commitErr}
+		return mockRepo, NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, transactor, &mockIDGenerator{}, logger)
+	}
+
+	t.Run("Delete", func(t *testing.T) {
+		mockRepo, svc := newService()
+		if err := svc.DeleteTask(context.Background(), 1); !errors.Is(err, commitErr) {
+			t.Fatalf("DeleteTask() error = %v, want %v", err, commitErr)
+		}
+		if _, ok := mockRepo.tasks[1]; !ok {
+			t.Error("DeleteTask() deleted the task outside its unit of work")
+		}
+	})
+
+	t.Run("Clone", func(t *testing.T) {
+		mockRepo, svc := newService()
+		if _, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1}); !errors.Is(err, commitErr) {
+			t.Fatalf("CloneTask() error = %v, want %v", err, commitErr)
+		}
+		if len(mockRepo.tasks) != 1 {
+			t.Errorf("CloneTask() stored the copy outside its unit of work")
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockRepo, svc := newService()
+		if _, err := svc.CreateTask(context.Background(), &models.Task{Title: "Task 2"}); !errors.Is(err, commitErr) {
+			t.Fatalf("CreateTask() error = %v, want %v", err, commitErr)
+		}
+		if len(mockRepo.tasks) != 1 {
+			t.Errorf("CreateTask() stored the task outside its unit of work")
+		}
+	})
+}
+
 func Test_taskService_DeleteTask(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	mockRepo := &mockTaskRepository{
@@ -199,7 +818,7 @@ func Test_taskService_DeleteTask(t *testing.T) {
 		},
 	}
 
-	svc := NewTaskService(mockRepo, logger)
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
 
 	tests := []struct {
 		name    string
@@ -220,10 +839,756 @@ func Test_taskService_DeleteTask(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := svc.DeleteTask(tt.id)
+			err := svc.DeleteTask(context.Background(), tt.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DeleteTask() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_taskService_ProjectSettings(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockProjects := &mockProjectRepository{
+		projects: map[int64]*models.Project{
+			1: {ID: 1, Workflow: []string{"backlog", "doing"}, DefaultAssignee: "alice", AllowedLabels: []string{"bug"}},
+			2: {ID: 2, Archived: true},
+		},
+	}
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			1: {ID: 1, ProjectID: 2, Title: "Archived", Description: "Task in archived project", Status: "open"},
+		},
+	}
+
+	svc := NewTaskService(mockRepo, mockProjects, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	created, err := svc.CreateTask(context.Background(), &models.Task{ProjectID: 1, Title: "Task", Description: "Description"})
+	if err != nil {
+		t.Fatalf("CreateTask() unexpected error: %v", err)
+	}
+	if created.Status != "backlog" || created.Assignee != "alice" {
+		t.Errorf("CreateTask() got status %q assignee %q, want project defaults", created.Status, created.Assignee)
+	}
+
+	tests := []struct {
+		name    string
+		task    *models.Task
+		wantErr error
+	}{
+		{
+			name:    "Status outside workflow",
+			task:    &models.Task{ProjectID: 1, Title: "Task", Description: "Description", Status: "open"},
+			wantErr: ErrInvalidStatus,
+		},
+		{
+			name:    "Label not allowed",
+			task:    &models.Task{ProjectID: 1, Title: "Task", Description: "Description", Labels: []string{"feature"}},
+			wantErr: ErrLabelNotAllowed,
+		},
+		{
+			name:    "Archived project",
+			task:    &models.Task{ProjectID: 2, Title: "Task", Description: "Description"},
+			wantErr: ErrProjectArchived,
+		},
+		{
+			name:    "Unknown project",
+			task:    &models.Task{ProjectID: 3, Title: "Task", Description: "Description"},
+			wantErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateTask(context.Background(), tt.task)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTask() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := svc.UpdateTask(context.Background(), &models.Task{ID: 1, Title: "Changed", Description: "Changed"}); !errors.Is(err, ErrProjectArchived) {
+		t.Errorf("UpdateTask() error = %v, want %v", err, ErrProjectArchived)
+	}
+	if err := svc.DeleteTask(context.Background(), 1); !errors.Is(err, ErrProjectArchived) {
+		t.Errorf("DeleteTask() error = %v, want %v", err, ErrProjectArchived)
+	}
+}
+
+func Test_taskService_MoveTask(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newRepo := func() *mockTaskRepository {
+		return &mockTaskRepository{
+			tasks: map[int64]*models.Task{
+				1: {ID: 1, Title: "A", Status: "open", Rank: "a"},
+				2: {ID: 2, Title: "B", Status: "open", Rank: "b"},
+				3: {ID: 3, Title: "C", Status: "open", Rank: "c"},
+				4: {ID: 4, Title: "D", Status: "done", Rank: "i"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		move      models.TaskMove
+		wantOrder []int64
+		wantErr   error
+	}{
+		{
+			name:      "Between two neighbours",
+			move:      models.TaskMove{TaskID: 3, AfterID: 1, BeforeID: 2},
+			wantOrder: []int64{1, 3, 2},
+		},
+		{
+			name:      "After a neighbour",
+			move:      models.TaskMove{TaskID: 1, AfterID: 2},
+			wantOrder: []int64{2, 1, 3},
+		},
+		{
+			name:      "Before a neighbour",
+			move:      models.TaskMove{TaskID: 3, BeforeID: 1},
+			wantOrder: []int64{3, 1, 2},
+		},
+		{
+			name:      "To the end of another column",
+			move:      models.TaskMove{TaskID: 1, Status: "done"},
+			wantOrder: []int64{4, 1},
+		},
+		{
+			name:    "Neighbours in the wrong order",
+			move:    models.TaskMove{TaskID: 1, AfterID: 3, BeforeID: 2},
+			wantErr: ErrInvalidMove,
+		},
+		{
+			name:    "Neighbour in another column",
+			move:    models.TaskMove{TaskID: 1, AfterID: 4},
+			wantErr: ErrInvalidMove,
+		},
+		{
+			name:    "Status outside workflow",
+			move:    models.TaskMove{TaskID: 1, Status: "archived"},
+			wantErr: ErrInvalidStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := newRepo()
+			svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+			moved, err := svc.MoveTask(context.Background(), tt.move)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MoveTask() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			column := mockRepo.columnTasks(models.Column{Status: moved.Status})
+			sort.Slice(column, func(i, j int) bool { return column[i].Rank < column[j].Rank })
+			var order []int64
+			for _, task := range column {
+				order = append(order, task.ID)
+			}
+			if fmt.Sprint(order) != fmt.Sprint(tt.wantOrder) {
+				t.Errorf("MoveTask() column order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func Test_taskService_TransferTask(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockProjects := &mockProjectRepository{
+			projects: map[int64]*models.Project{
+				1: {ID: 1, Key: "OPS"},
+				2: {ID: 2, Key: "DEV", Workflow: []string{"backlog", "done"}, AllowedLabels: []string{"bug"}},
+				3: {ID: 3, Key: "OLD", Archived: true},
+			},
+		}
+		mockRepo := &mockTaskRepository{
+			tasks: map[int64]*models.Task{
+				1: {ID: 1, ProjectID: 1, Key: "OPS-1", Title: "A", Status: "done", Rank: "i"},
+				2: {ID: 2, ProjectID: 1, Key: "OPS-2", Title: "B", Status: "open", Labels: []string{"infra"}, Rank: "k"},
+				3: {ID: 3, ProjectID: 1, Key: "OPS-3", Title: "C", Status: "open", Rank: "m"},
+			},
+		}
+		return mockRepo, NewTaskService(mockRepo, mockProjects, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+
+	tests := []struct {
+		name       string
+		id         int64
+		projectID  int64
+		wantStatus string
+		wantErr    error
+	}{
+		{name: "Keeps status in target workflow", id: 1, projectID: 2, wantStatus: "done"},
+		{name: "Resets status outside target workflow", id: 3, projectID: 2, wantStatus: "backlog"},
+		{name: "Label not allowed in target", id: 2, projectID: 2, wantErr: ErrLabelNotAllowed},
+		{name: "Archived target", id: 1, projectID: 3, wantErr: ErrProjectArchived},
+		{name: "Unknown target", id: 1, projectID: 4, wantErr: ErrProjectNotFound},
+		{name: "Unknown task", id: 9, projectID: 2, wantErr: ErrTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, svc := newService()
+
+			transferred, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: tt.id, ProjectID: tt.projectID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransferTask() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if transferred.ProjectID != tt.projectID || transferred.Status != tt.wantStatus {
+				t.Errorf("TransferTask() got project %d status %q, want %d %q",
+					transferred.ProjectID, transferred.Status, tt.projectID, tt.wantStatus)
+			}
+		})
+	}
+
+	t.Run("Old key resolves after transfer", func(t *testing.T) {
+		_, svc := newService()
+
+		if _, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 1, ProjectID: 2}); err != nil {
+			t.Fatalf("TransferTask() unexpected error: %v", err)
+		}
+		id, err := svc.ResolveTask(context.Background(), models.TaskRef{Key: "OPS-1"})
+		if err != nil || id != 1 {
+			t.Errorf("ResolveTask() = %d, %v, want 1", id, err)
+		}
+		if _, err := svc.ResolveTask(context.Background(), models.TaskRef{Key: "OPS-9"}); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("ResolveTask() error = %v, want %v", err, ErrTaskNotFound)
+		}
+	})
+}
+
+func Test_taskService_Subtasks(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockTaskRepository, TaskService) {
+		_, mockProjects := newCustomFieldMocks()
+		mockRepo := &mockTaskRepository{
+			tasks: map[int64]*models.Task{
+				1: {ID: 1, ProjectID: 1, Key: "OPS-1", Title: "Parent", Status: "open", Rank: "i"},
+				2: {ID: 2, ProjectID: 1, ParentID: 1, Key: "OPS-2", Title: "Subtask", Status: "open", Rank: "k"},
+				3: {ID: 3, ProjectID: 2, ParentID: 2, Key: "DEV-1", Title: "Other project", Status: "open", Rank: "m"},
+			},
+		}
+		return mockRepo, NewTaskService(mockRepo, mockProjects, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+
+	t.Run("Create checks the parent", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			parentID int64
+			wantErr  error
+		}{
+			{name: "Parent in the project", parentID: 2},
+			{name: "Parent in another project", parentID: 3, wantErr: ErrInvalidParent},
+			{name: "Unknown parent", parentID: 9, wantErr: ErrInvalidParent},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, svc := newService()
+				created, err := svc.CreateTask(context.Background(), &models.Task{ProjectID: 1, ParentID: tt.parentID, Title: "New", Description: "D"})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateTask() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && created.ParentID != tt.parentID {
+					t.Errorf("CreateTask() parent = %d, want %d", created.ParentID, tt.parentID)
+				}
+			})
+		}
+	})
+
+	t.Run("Delete detaches the subtasks", func(t *testing.T) {
+		mockRepo, svc := newService()
+		if err := svc.DeleteTask(context.Background(), 1); err != nil {
+			t.Fatalf("DeleteTask() unexpected error: %v", err)
+		}
+		if parentID := mockRepo.tasks[2].ParentID; parentID != 0 {
+			t.Errorf("DeleteTask() left the subtask with parent %d", parentID)
+		}
+
+		mockRepo, svc = newService()
+		if _, err := svc.BatchDeleteTasks(context.Background(), []*models.Task{{ID: 2}}, false); err != nil {
+			t.Fatalf("BatchDeleteTasks() unexpected error: %v", err)
+		}
+		if parentID := mockRepo.tasks[3].ParentID; parentID != 0 {
+			t.Errorf("BatchDeleteTasks() left the subtask with parent %d", parentID)
+		}
+	})
+
+	t.Run("Transfer detaches the task and its subtasks", func(t *testing.T) {
+		mockRepo, svc := newService()
+		transferred, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 2, ProjectID: 2})
+		if err != nil {
+			t.Fatalf("TransferTask() unexpected error: %v", err)
+		}
+		if transferred.ParentID != 0 || mockRepo.tasks[3].ParentID != 0 {
+			t.Errorf("TransferTask() left parents %d and %d, want none", transferred.ParentID, mockRepo.tasks[3].ParentID)
+		}
+	})
+}
+
+func Test_taskService_TaskRelations(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	_, mockProjects := newCustomFieldMocks()
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			1: {ID: 1, ProjectID: 1, Key: "OPS-1"},
+			2: {ID: 2, ProjectID: 2, Key: "DEV-1"},
+			3: {ID: 3, ProjectID: 3, Key: "OLD-1"},
+		},
+	}
+	svc := NewTaskService(mockRepo, mockProjects, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	tests := []struct {
+		name     string
+		relation models.TaskRelation
+		wantErr  error
+	}{
+		{name: "Across projects", relation: models.TaskRelation{TaskID: 1, RelatedTaskID: 2, Type: models.RelationBlocks}},
+		{name: "To a task of an archived project", relation: models.TaskRelation{TaskID: 2, RelatedTaskID: 3, Type: models.RelationDuplicates}},
+		{name: "From a task of an archived project", relation: models.TaskRelation{TaskID: 3, RelatedTaskID: 1, Type: models.RelationBlocks},
+			wantErr: ErrProjectArchived},
+		{name: "Unknown type", relation: models.TaskRelation{TaskID: 1, RelatedTaskID: 2, Type: "parent_of"}, wantErr: ErrInvalidRelation},
+		{name: "To itself", relation: models.TaskRelation{TaskID: 1, RelatedTaskID: 1, Type: models.RelationRelatesTo}, wantErr: ErrInvalidRelation},
+		{name: "Unknown task", relation: models.TaskRelation{TaskID: 1, RelatedTaskID: 9, Type: models.RelationRelatesTo}, wantErr: ErrTaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.CreateTaskRelations(context.Background(), []models.TaskRelation{tt.relation}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTaskRelations() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	relations, err := svc.ListTaskRelations(context.Background(), 2)
+	if err != nil || len(relations) != 2 {
+		t.Errorf("ListTaskRelations() = %v, %v, want the two relations of task 2", relations, err)
+	}
+	if _, err := svc.ListTaskRelations(context.Background(), 9); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("ListTaskRelations() of an unknown task error = %v, want %v", err, ErrTaskNotFound)
+	}
+
+	blocks := models.TaskRelation{TaskID: 1, RelatedTaskID: 2, Type: models.RelationBlocks}
+	if err := svc.DeleteTaskRelation(context.Background(), blocks); err != nil {
+		t.Errorf("DeleteTaskRelation() unexpected error: %v", err)
+	}
+	if err := svc.DeleteTaskRelation(context.Background(), blocks); !errors.Is(err, ErrRelationNotFound) {
+		t.Errorf("DeleteTaskRelation() of a deleted relation error = %v, want %v", err, ErrRelationNotFound)
+	}
+}
+
+func Test_taskService_CloneTask(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	newService := func() (*mockTaskRepository, TaskService) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		mockProjects.projects[2].Workflow = []string{"backlog", "done"}
+		mockProjects.projects[2].AllowedLabels = []string{"bug"}
+		mockRepo := &mockTaskRepository{
+			tasks: map[int64]*models.Task{
+				1: {ID: 1, ProjectID: 1, Key: "OPS-1", Title: "A", Description: "Deploy", Status: "open", Assignee: "ada", Rank: "i",
+					Labels: []string{"infra"}, CustomFields: map[string]models.FieldValue{
+						"points": {Type: models.FieldTypeNumber, Number: 8},
+						"env":    {Type: models.FieldTypeEnum, String: "prod"},
+					}},
+				2: {ID: 2, ProjectID: 3, Key: "OLD-1", Title: "Old", Description: "From an archived project", Status: "done", Rank: "i"},
+			},
+		}
+		return mockRepo, NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+	}
+	project := func(id int64) *int64 { return &id }
+
+	t.Run("Copy in the same project", func(t *testing.T) {
+		mockRepo, svc := newService()
+
+		cloned, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1, Title: "A again", Labels: true, CustomFields: true})
+		if err != nil {
+			t.Fatalf("CloneTask() unexpected error: %v", err)
+		}
+		if cloned.ID == 1 || cloned.ProjectID != 1 || cloned.Title != "A again" || cloned.Description != "Deploy" ||
+			cloned.Status != "open" || cloned.Assignee != "ada" {
+			t.Errorf("CloneTask() = %+v", cloned)
+		}
+		if !reflect.DeepEqual(cloned.Labels, []string{"infra"}) || len(cloned.CustomFields) != 2 {
+			t.Errorf("CloneTask() labels %v and custom fields %v, want both copied", cloned.Labels, cloned.CustomFields)
+		}
+		if len(mockRepo.tasks) != 3 || mockRepo.tasks[1].Title != "A" {
+			t.Errorf("CloneTask() changed the original task")
+		}
+	})
+
+	t.Run("Labels and custom fields are optional", func(t *testing.T) {
+		_, svc := newService()
+
+		cloned, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1})
+		if err != nil {
+			t.Fatalf("CloneTask() unexpected error: %v", err)
+		}
+		if cloned.Title != "A" || len(cloned.Labels) != 0 || len(cloned.CustomFields) != 0 {
+			t.Errorf("CloneTask() = %+v, want no labels or custom fields", cloned)
+		}
+	})
+
+	t.Run("Copy in another project", func(t *testing.T) {
+		_, svc := newService()
+
+		cloned, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1, ProjectID: project(2), CustomFields: true})
+		if err != nil {
+			t.Fatalf("CloneTask() unexpected error: %v", err)
+		}
+		// Project 2 has no env field and allows at most 5 points.
+		if cloned.ProjectID != 2 || cloned.Status != "backlog" || len(cloned.CustomFields) != 0 {
+			t.Errorf("CloneTask() = %+v, want a backlog task of project 2 without custom fields", cloned)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		clone   models.TaskClone
+		wantErr error
+	}{
+		{name: "Copy out of an archived project", clone: models.TaskClone{TaskID: 2, ProjectID: project(1)}},
+		{name: "Copy outside of any project", clone: models.TaskClone{TaskID: 1, ProjectID: project(0), Labels: true}},
+		{name: "Copy in an archived project", clone: models.TaskClone{TaskID: 2}, wantErr: ErrProjectArchived},
+		{name: "Label not allowed in target", clone: models.TaskClone{TaskID: 1, ProjectID: project(2), Labels: true}, wantErr: ErrLabelNotAllowed},
+		{name: "Unknown target", clone: models.TaskClone{TaskID: 1, ProjectID: project(9)}, wantErr: ErrProjectNotFound},
+		{name: "Unknown task", clone: models.TaskClone{TaskID: 9}, wantErr: ErrTaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, svc := newService()
+			if _, err := svc.CloneTask(context.Background(), tt.clone); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CloneTask() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newSubtreeService returns a service over a task OPS-1 with the subtask OPS-2, which has the subtask OPS-3,
+// and the unrelated task OPS-4. OPS-2 blocks OPS-3 and OPS-1 relates to OPS-4. OPS-1 and OPS-2 have
+// checklists, OPS-1 and OPS-3 comments, and OPS-3 an attachment. Project 2 only allows the label bug.
+func newSubtreeService(t *testing.T) (*mockTaskRepository, TaskService) {
+	mockFields, mockProjects := newCustomFieldMocks()
+	mockProjects.projects[2].AllowedLabels = []string{"bug"}
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			1: {ID: 1, ProjectID: 1, Key: "OPS-1", Title: "Release", Description: "D", Status: "open", Rank: "i"},
+			2: {ID: 2, ProjectID: 1, ParentID: 1, Key: "OPS-2", Title: "Build", Description: "D", Status: "open", Rank: "k"},
+			3: {ID: 3, ProjectID: 1, ParentID: 2, Key: "OPS-3", Title: "Test", Description: "D", Status: "done", Rank: "m",
+				Labels: []string{"bug"}},
+			4: {ID: 4, ProjectID: 1, Key: "OPS-4", Title: "Other", Description: "D", Status: "open", Rank: "o"},
+		},
+		relations: []models.TaskRelation{
+			{TaskID: 2, RelatedTaskID: 3, Type: models.RelationBlocks},
+			{TaskID: 1, RelatedTaskID: 4, Type: models.RelationRelatesTo},
+		},
+		checklists: map[int64][]models.ChecklistItem{
+			1: {{Text: "Tag"}, {Text: "Announce"}},
+			2: {{Text: "Compile", Done: true}},
+		},
+		comments: []*models.TaskComment{
+			{ID: 1, TaskID: 1, Author: "ada", Body: "Friday?"},
+			{ID: 2, TaskID: 3, Author: "bob", Body: "Flaky"},
+		},
+		attachments: []*models.TaskAttachment{
+			{ID: 1, TaskID: 3, Name: "log.txt", URL: "https://files.example.com/log.txt"},
+		},
+	}
+	svc := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, &rollbackTransactor{repo: mockRepo}, &mockIDGenerator{},
+		zaptest.NewLogger(t))
+	return mockRepo, svc
+}
+
+func Test_taskService_CloneTask_Content(t *testing.T) {
+	tests := []struct {
+		name            string
+		clone           models.TaskClone
+		wantChecklist   []models.ChecklistItem
+		wantComments    []string
+		wantAttachments int
+	}{
+		{name: "Nothing", clone: models.TaskClone{TaskID: 1}, wantChecklist: []models.ChecklistItem{}},
+		{name: "Checklist", clone: models.TaskClone{TaskID: 1, Checklist: true},
+			wantChecklist: []models.ChecklistItem{{Text: "Tag"}, {Text: "Announce"}}},
+		{name: "Comments", clone: models.TaskClone{TaskID: 1, Comments: true}, wantChecklist: []models.ChecklistItem{},
+			wantComments: []string{"ada: Friday?"}},
+		{name: "Attachments", clone: models.TaskClone{TaskID: 3, Attachments: true}, wantChecklist: []models.ChecklistItem{},
+			wantAttachments: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, svc := newSubtreeService(t)
+
+			cloned, err := svc.CloneTask(context.Background(), tt.clone)
+			if err != nil {
+				t.Fatalf("CloneTask() unexpected error: %v", err)
+			}
+			checklist, _ := mockRepo.GetTaskChecklist(context.Background(), cloned.ID)
+			if !reflect.DeepEqual(checklist, tt.wantChecklist) {
+				t.Errorf("CloneTask() checklist = %v, want %v", checklist, tt.wantChecklist)
+			}
+			var comments []string
+			listed, _ := mockRepo.ListTaskComments(context.Background(), cloned.ID)
+			for _, comment := range listed {
+				comments = append(comments, comment.Author+": "+comment.Body)
+			}
+			if !reflect.DeepEqual(comments, tt.wantComments) {
+				t.Errorf("CloneTask() comments = %v, want %v", comments, tt.wantComments)
+			}
+			if attachments, _ := mockRepo.ListTaskAttachments(context.Background(), cloned.ID); len(attachments) != tt.wantAttachments {
+				t.Errorf("CloneTask() copied %d attachments, want %d", len(attachments), tt.wantAttachments)
+			}
+			if len(mockRepo.tasks) != 5 {
+				t.Errorf("CloneTask() created %d tasks, want only the copy", len(mockRepo.tasks)-4)
+			}
+		})
+	}
+}
+
+func Test_taskService_CloneTask_Subtasks(t *testing.T) {
+	t.Run("Copies the subtree and its relations", func(t *testing.T) {
+		mockRepo, svc := newSubtreeService(t)
+
+		cloned, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1, Title: "Release 2", Labels: true, Subtasks: true,
+			Checklist: true, Comments: true, Attachments: true})
+		if err != nil {
+			t.Fatalf("CloneTask() unexpected error: %v", err)
+		}
+		if cloned.ID != 5 || cloned.Title != "Release 2" || cloned.ParentID != 0 {
+			t.Fatalf("CloneTask() = %+v, want task 5 named Release 2", cloned)
+		}
+		build, test := mockRepo.tasks[6], mockRepo.tasks[7]
+		if build == nil || build.Title != "Build" || build.ParentID != 5 || test == nil || test.Title != "Test" || test.ParentID != 6 {
+			t.Fatalf("CloneTask() copied the subtasks as %+v and %+v", build, test)
+		}
+		if test.Status != "done" || !reflect.DeepEqual(test.Labels, []string{"bug"}) {
+			t.Errorf("CloneTask() copied OPS-3 as %+v, want its status and labels", test)
+		}
+		want := []models.TaskRelation{{TaskID: 6, RelatedTaskID: 7, Type: models.RelationBlocks}}
+		if relations, _ := mockRepo.ListTaskRelations(context.Background(), 6); !reflect.DeepEqual(relations, want) {
+			t.Errorf("CloneTask() relations of the copies = %v, want %v", relations, want)
+		}
+		if relations, _ := mockRepo.ListTaskRelations(context.Background(), 5); len(relations) != 0 {
+			t.Errorf("CloneTask() copied the relations to tasks outside of the subtree: %v", relations)
+		}
+		if checklist, _ := mockRepo.GetTaskChecklist(context.Background(), 6); len(checklist) != 1 {
+			t.Errorf("CloneTask() copied the checklist of OPS-2 as %v", checklist)
+		}
+		comments, _ := mockRepo.ListTaskComments(context.Background(), 7)
+		attachments, _ := mockRepo.ListTaskAttachments(context.Background(), 7)
+		if len(comments) != 1 || len(attachments) != 1 {
+			t.Errorf("CloneTask() copied %d comments and %d attachments of OPS-3, want 1 each", len(comments), len(attachments))
+		}
+	})
+
+	t.Run("Subtasks are optional", func(t *testing.T) {
+		mockRepo, svc := newSubtreeService(t)
+
+		if _, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1}); err != nil {
+			t.Fatalf("CloneTask() unexpected error: %v", err)
+		}
+		if len(mockRepo.tasks) != 5 || len(mockRepo.relations) != 2 {
+			t.Errorf("CloneTask() left %d tasks and %d relations, want only the copy", len(mockRepo.tasks), len(mockRepo.relations))
+		}
+	})
+
+	t.Run("Copies the whole subtree or nothing", func(t *testing.T) {
+		mockRepo, svc := newSubtreeService(t)
+		project := int64(2)
+		mockRepo.tasks[3].Labels = []string{"infra"}
+
+		_, err := svc.CloneTask(context.Background(), models.TaskClone{TaskID: 1, ProjectID: &project, Labels: true, Subtasks: true, Comments: true})
+		if !errors.Is(err, ErrLabelNotAllowed) {
+			t.Fatalf("CloneTask() error = %v, want %v", err, ErrLabelNotAllowed)
+		}
+		if len(mockRepo.tasks) != 4 || len(mockRepo.comments) != 2 {
+			t.Errorf("CloneTask() left %d tasks and %d comments, want the original 4 and 2", len(mockRepo.tasks), len(mockRepo.comments))
+		}
+	})
+}
+
+func Test_taskService_TransferTask_Subtree(t *testing.T) {
+	t.Run("Moves the subtree", func(t *testing.T) {
+		mockRepo, svc := newSubtreeService(t)
+
+		transferred, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 1, ProjectID: 2, Subtree: true})
+		if err != nil {
+			t.Fatalf("TransferTask() unexpected error: %v", err)
+		}
+		if transferred.ProjectID != 2 {
+			t.Errorf("TransferTask() = %+v, want a task of project 2", transferred)
+		}
+		build, test := mockRepo.tasks[2], mockRepo.tasks[3]
+		if build.ProjectID != 2 || build.ParentID != 1 || test.ProjectID != 2 || test.ParentID != 2 {
+			t.Errorf("TransferTask() left the subtasks as %+v and %+v, want them in project 2 under their parents", build, test)
+		}
+		if test.Status != "done" || mockRepo.tasks[4].ProjectID != 1 {
+			t.Errorf("TransferTask() changed the status of OPS-3 or moved OPS-4")
+		}
+	})
+
+	t.Run("Leaves the subtasks behind without the subtree", func(t *testing.T) {
+		mockRepo, svc := newSubtreeService(t)
+
+		if _, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 1, ProjectID: 2}); err != nil {
+			t.Fatalf("TransferTask() unexpected error: %v", err)
+		}
+		if build := mockRepo.tasks[2]; build.ProjectID != 1 || build.ParentID != 0 {
+			t.Errorf("TransferTask() left OPS-2 as %+v, want a top-level task of project 1", build)
+		}
+	})
+
+	t.Run("Moves the whole subtree or nothing", func(t *testing.T) {
+		mockRepo, svc := newSubtreeService(t)
+		mockRepo.tasks[3].Labels = []string{"infra"}
+
+		_, err := svc.TransferTask(context.Background(), models.TaskTransfer{TaskID: 1, ProjectID: 2, Subtree: true})
+		if !errors.Is(err, ErrLabelNotAllowed) {
+			t.Fatalf("TransferTask() error = %v, want %v", err, ErrLabelNotAllowed)
+		}
+		for id, task := range mockRepo.tasks {
+			if task.ProjectID != 1 {
+				t.Errorf("TransferTask() moved task %d", id)
+			}
+		}
+	})
+}
+
+func Test_taskService_TaskContent(t *testing.T) {
+	mockRepo, svc := newSubtreeService(t)
+	mockRepo.tasks[5] = &models.Task{ID: 5, ProjectID: 3, Key: "OLD-1", Title: "Old", Status: "done"}
+	ctx := context.Background()
+
+	items := []models.ChecklistItem{{Text: "Plan", Done: true}, {Text: "Do"}}
+	if set, err := svc.SetTaskChecklist(ctx, 4, items); err != nil || !reflect.DeepEqual(set, items) {
+		t.Errorf("SetTaskChecklist() = %v, %v, want %v", set, err, items)
+	}
+	if got, err := svc.GetTaskChecklist(ctx, 4); err != nil || !reflect.DeepEqual(got, items) {
+		t.Errorf("GetTaskChecklist() = %v, %v, want %v", got, err, items)
+	}
+
+	comment, err := svc.CreateTaskComment(ctx, &models.TaskComment{TaskID: 4, Author: "ada", Body: "Done soon"})
+	if err != nil || comment.ID == 0 {
+		t.Errorf("CreateTaskComment() = %+v, %v", comment, err)
+	}
+	if comments, err := svc.ListTaskComments(ctx, 4); err != nil || len(comments) != 1 {
+		t.Errorf("ListTaskComments() = %v, %v, want the new comment", comments, err)
+	}
+
+	attachment, err := svc.CreateTaskAttachment(ctx, &models.TaskAttachment{TaskID: 4, Name: "plan.pdf", URL: "https://files.example.com/plan.pdf"})
+	if err != nil || attachment.ID == 0 {
+		t.Errorf("CreateTaskAttachment() = %+v, %v", attachment, err)
+	}
+	if attachments, err := svc.ListTaskAttachments(ctx, 4); err != nil || len(attachments) != 1 {
+		t.Errorf("ListTaskAttachments() = %v, %v, want the new attachment", attachments, err)
+	}
+
+	tests := []struct {
+		name    string
+		id      int64
+		wantErr error
+	}{
+		{name: "Task of an archived project", id: 5, wantErr: ErrProjectArchived},
+		{name: "Unknown task", id: 9, wantErr: ErrTaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.SetTaskChecklist(ctx, tt.id, items); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetTaskChecklist() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := svc.CreateTaskComment(ctx, &models.TaskComment{TaskID: tt.id, Body: "Hi"}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTaskComment() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := svc.CreateTaskAttachment(ctx, &models.TaskAttachment{TaskID: tt.id, Name: "a", URL: "https://a"}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTaskAttachment() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := svc.ListTaskComments(ctx, 9); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("ListTaskComments() of an unknown task error = %v, want %v", err, ErrTaskNotFound)
+	}
+}
+
+func Test_taskService_ResolveTask(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			4: {ID: 4, ExternalID: "01KDVDNA000000000000000004", ProjectID: 1, Key: "OPS-1"},
+		},
+	}
+
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	tests := []struct {
+		name    string
+		ref     models.TaskRef
+		want    int64
+		wantErr error
+	}{
+		{name: "By ID", ref: models.TaskRef{ID: 4}, want: 4},
+		{name: "By key", ref: models.TaskRef{Key: "OPS-1"}, want: 4},
+		{name: "By external ID", ref: models.TaskRef{ExternalID: "01KDVDNA000000000000000004"}, want: 4},
+		{name: "Unknown external ID", ref: models.TaskRef{ExternalID: "01KDVDNA000000000000000009"}, wantErr: ErrTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ResolveTask(context.Background(), tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveTask() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTask() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_taskService_GetTaskIncludingArchived(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	mockRepo := &mockTaskRepository{
+		tasks: map[int64]*models.Task{
+			4: {ID: 4, ExternalID: "01KDVDNA000000000000000004", ProjectID: 1, Key: "OPS-1"},
+		},
+		archived: map[int64]*models.Task{
+			2: {ID: 2, ExternalID: "01KDVDNA000000000000000002", ProjectID: 1, Key: "OPS-2", ArchivedAt: time.Now()},
+		},
+	}
+
+	svc := NewTaskService(mockRepo, &mockProjectRepository{}, &mockCustomFieldRepository{}, &mockTaskSearcher{}, &mockTransactor{}, &mockIDGenerator{}, logger)
+
+	tests := []struct {
+		name    string
+		ref     models.TaskRef
+		want    int64
+		wantErr error
+	}{
+		{name: "Live task", ref: models.TaskRef{Key: "OPS-1"}, want: 4},
+		{name: "Archived task by ID", ref: models.TaskRef{ID: 2}, want: 2},
+		{name: "Archived task by key", ref: models.TaskRef{Key: "OPS-2"}, want: 2},
+		{name: "Archived task by external ID", ref: models.TaskRef{ExternalID: "01KDVDNA000000000000000002"}, want: 2},
+		{name: "Unknown task", ref: models.TaskRef{ID: 9}, wantErr: ErrTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetTaskIncludingArchived(context.Background(), tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetTaskIncludingArchived() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != nil && got.ID != tt.want {
+				t.Errorf("GetTaskIncludingArchived() = %d, want %d", got.ID, tt.want)
+			}
+		})
+	}
+
+	if _, err := svc.GetTask(context.Background(), 2); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("GetTask() of an archived task error = %v, want %v", err, ErrTaskNotFound)
+	}
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTaskTemplateNotFound   = errors.New("task template not found")
+	ErrTaskTemplateNameTaken  = errors.New("task template name is already taken")
+	ErrInvalidTaskTemplate    = errors.New("invalid task template")
+	ErrTemplateVariables      = errors.New("template variables do not match")
+	ErrTaskTemplateCreateFail = errors.New("failed to create task template")
+	ErrTaskTemplateUpdateFail = errors.New("failed to update task template")
+	ErrTaskTemplateDeleteFail = errors.New("failed to delete task template")
+)
+
+// placeholderPattern matches a {{variable}} placeholder; spaces inside the braces are allowed.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\}\}`)
+
+type TaskTemplateService interface {
+	CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error)
+	// GetTaskTemplate returns a version of a template, or its current version for version zero.
+	GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error)
+	ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error)
+	// UpdateTaskTemplate replaces the definition of a template, making it its next version.
+	UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error)
+	DeleteTaskTemplate(ctx context.Context, id int64) error
+	// InstantiateTemplate creates the tasks of a version of a template, or of its current version for
+	// version zero, with its placeholders replaced by values, and the relations between them. Either the
+	// whole tree is created or nothing.
+	InstantiateTemplate(ctx context.Context, id int64, version int, values map[string]string) (*TemplateInstance, error)
+}
+
+// TemplateInstance is what instantiating a template created: the tasks, top-level tasks first and then
+// the subtasks level by level, and the relations between them.
+type TemplateInstance struct {
+	Template  *models.TaskTemplate
+	Tasks     []*models.Task
+	Relations []models.TaskRelation
+}
+
+type taskTemplateService struct {
+	repo       repository.TaskTemplateRepository
+	projects   repository.ProjectRepository
+	tasks      TaskService
+	transactor repository.Transactor
+	logger     *zap.Logger
+}
+
+func NewTaskTemplateService(repo repository.TaskTemplateRepository, projects repository.ProjectRepository, tasks TaskService,
+	transactor repository.Transactor, logger *zap.Logger) TaskTemplateService {
+	return &taskTemplateService{
+		repo:       repo,
+		projects:   projects,
+		tasks:      tasks,
+		transactor: transactor,
+		logger:     logger,
+	}
+}
+
+// CreateTaskTemplate stores a template of a project that is not archived. Its blueprints are checked
+// against the project when the template is instantiated, as projects may change in between.
+func (s *taskTemplateService) CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	s.logger.Info("Creating task template", zap.Int64("project_id", template.ProjectID), zap.String("name", template.Name))
+
+	if err := s.validate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	createdTemplate, err := s.repo.CreateTaskTemplate(ctx, template)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			s.logger.Warn("Task template name is already taken", zap.String("name", template.Name))
+			return nil, ErrTaskTemplateNameTaken
+		}
+		s.logger.Error("Failed to create task template", zap.Error(err))
+		return nil, ErrTaskTemplateCreateFail
+	}
+
+	return createdTemplate, nil
+}
+
+func (s *taskTemplateService) GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error) {
+	s.logger.Info("Fetching task template", zap.Int64("id", id), zap.Int("version", version))
+
+	template, err := s.repo.GetTaskTemplate(ctx, id, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Task template not found", zap.Int64("id", id), zap.Int("version", version))
+			return nil, ErrTaskTemplateNotFound
+		}
+		s.logger.Error("Failed to fetch task template", zap.Error(err))
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *taskTemplateService) ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error) {
+	s.logger.Info("Listing task templates", zap.Int64("project_id", projectID))
+
+	if _, err := s.project(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	templates, err := s.repo.ListTaskTemplates(ctx, projectID)
+	if err != nil {
+		s.logger.Error("Failed to list task templates", zap.Error(err))
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// UpdateTaskTemplate stores a new version of a template. The project of a template cannot change.
+func (s *taskTemplateService) UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	s.logger.Info("Updating task template", zap.Int64("id", template.ID))
+
+	current, err := s.GetTaskTemplate(ctx, template.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	template.ProjectID = current.ProjectID
+	if err := s.validate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	updatedTemplate, err := s.repo.UpdateTaskTemplate(ctx, template)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskTemplateNotFound
+		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			s.logger.Warn("Task template name is already taken", zap.String("name", template.Name))
+			return nil, ErrTaskTemplateNameTaken
+		}
+		s.logger.Error("Failed to update task template", zap.Error(err))
+		return nil, ErrTaskTemplateUpdateFail
+	}
+
+	return updatedTemplate, nil
+}
+
+func (s *taskTemplateService) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	s.logger.Info("Deleting task template", zap.Int64("id", id))
+
+	if err := s.repo.DeleteTaskTemplate(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskTemplateNotFound
+		}
+		s.logger.Error("Failed to delete task template", zap.Error(err))
+		return ErrTaskTemplateDeleteFail
+	}
+
+	return nil
+}
+
+// InstantiateTemplate fills in the placeholders of the blueprints and creates the tasks level by level as
+// all-or-nothing batches, so they get the project defaults and are validated like CreateTask, each level
+// as subtasks of the one before. The relations of the blueprints then link the new tasks. All of it is one
+// unit of work. Every declared variable needs a value and no other values may be given.
+func (s *taskTemplateService) InstantiateTemplate(ctx context.Context, id int64, version int, values map[string]string) (*TemplateInstance, error) {
+	s.logger.Info("Instantiating task template", zap.Int64("id", id), zap.Int("version", version))
+
+	template, err := s.GetTaskTemplate(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range template.Variables {
+		if _, ok := values[variable]; !ok {
+			s.logger.Warn("Template variable has no value", zap.String("variable", variable))
+			return nil, fmt.Errorf("%w: missing a value for %s", ErrTemplateVariables, variable)
+		}
+	}
+	for variable := range values {
+		if !slices.Contains(template.Variables, variable) {
+			s.logger.Warn("Value for unknown template variable", zap.String("variable", variable))
+			return nil, fmt.Errorf("%w: %s is not a variable of the template", ErrTemplateVariables, variable)
+		}
+	}
+
+	instance := &TemplateInstance{Template: template}
+	err = s.transactor.InTx(ctx, func(ctx context.Context) error {
+		// A retried unit of work starts over from the blueprints.
+		instance.Tasks, instance.Relations = nil, nil
+		refs := make(map[string]int64)
+		type pendingRelation struct {
+			taskID   int64
+			relation models.BlueprintRelation
+		}
+		var pending []pendingRelation
+
+		level, parentIDs := template.Tasks, make([]int64, len(template.Tasks))
+		for len(level) > 0 {
+			tasks := make([]*models.Task, len(level))
+			for i, blueprint := range level {
+				tasks[i] = fillBlueprint(blueprint, values)
+				tasks[i].ProjectID = template.ProjectID
+				tasks[i].ParentID = parentIDs[i]
+			}
+			if _, err := s.tasks.BatchCreateTasks(ctx, tasks, false); err != nil {
+				return err
+			}
+			instance.Tasks = append(instance.Tasks, tasks...)
+
+			var subtasks []models.TaskBlueprint
+			parentIDs = nil
+			for i, blueprint := range level {
+				if blueprint.Ref != "" {
+					refs[blueprint.Ref] = tasks[i].ID
+				}
+				for _, relation := range blueprint.Relations {
+					pending = append(pending, pendingRelation{taskID: tasks[i].ID, relation: relation})
+				}
+				for _, subtask := range blueprint.Subtasks {
+					subtasks = append(subtasks, subtask)
+					parentIDs = append(parentIDs, tasks[i].ID)
+				}
+			}
+			level = subtasks
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+		for _, p := range pending {
+			instance.Relations = append(instance.Relations, models.TaskRelation{
+				TaskID: p.taskID, RelatedTaskID: refs[p.relation.Ref], Type: p.relation.Type,
+			})
+		}
+		return s.tasks.CreateTaskRelations(ctx, instance.Relations)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// fillBlueprint returns the task of a blueprint, without its subtasks, with the placeholders replaced by values.
+func fillBlueprint(blueprint models.TaskBlueprint, values map[string]string) *models.Task {
+	fill := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			return values[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+		})
+	}
+	task := &models.Task{
+		Title:       fill(blueprint.Title),
+		Description: fill(blueprint.Description),
+		Status:      fill(blueprint.Status),
+		Assignee:    fill(blueprint.Assignee),
+	}
+	for _, label := range blueprint.Labels {
+		task.Labels = append(task.Labels, fill(label))
+	}
+	if len(blueprint.CustomFields) > 0 {
+		task.CustomFields = make(map[string]models.FieldValue, len(blueprint.CustomFields))
+		for name, value := range blueprint.CustomFields {
+			value.String = fill(value.String)
+			task.CustomFields[name] = value
+		}
+	}
+	return task
+}
+
+// validate checks a template about to be saved: its project must exist and not be archived, its
+// placeholders may only name declared variables, and its relations must be of a known type and point at
+// another blueprint by a ref that is unique in the template.
+func (s *taskTemplateService) validate(ctx context.Context, template *models.TaskTemplate) error {
+	project, err := s.project(ctx, template.ProjectID)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		s.logger.Warn("Project is archived", zap.Int64("project_id", template.ProjectID))
+		return ErrProjectArchived
+	}
+
+	refs := make(map[string]bool)
+	err = walkBlueprints(template.Tasks, "", func(path string, blueprint models.TaskBlueprint) error {
+		if blueprint.Ref != "" {
+			if refs[blueprint.Ref] {
+				return fmt.Errorf("%w: task %s reuses the ref %s", ErrInvalidTaskTemplate, path, blueprint.Ref)
+			}
+			refs[blueprint.Ref] = true
+		}
+
+		texts := []string{blueprint.Title, blueprint.Description, blueprint.Status, blueprint.Assignee}
+		texts = append(texts, blueprint.Labels...)
+		for _, value := range blueprint.CustomFields {
+			texts = append(texts, value.String)
+		}
+		for _, text := range texts {
+			for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+				if !slices.Contains(template.Variables, match[1]) {
+					s.logger.Warn("Undeclared template variable", zap.String("variable", match[1]))
+					return fmt.Errorf("%w: task %s uses %s, which is not a declared variable", ErrInvalidTaskTemplate, path, strings.TrimSpace(match[0]))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return walkBlueprints(template.Tasks, "", func(path string, blueprint models.TaskBlueprint) error {
+		for _, relation := range blueprint.Relations {
+			switch {
+			case !slices.Contains(models.RelationTypes, relation.Type):
+				return fmt.Errorf("%w: task %s has a relation of unknown type %q", ErrInvalidTaskTemplate, path, relation.Type)
+			case !refs[relation.Ref]:
+				return fmt.Errorf("%w: task %s relates to the unknown ref %q", ErrInvalidTaskTemplate, path, relation.Ref)
+			case relation.Ref == blueprint.Ref:
+				return fmt.Errorf("%w: task %s relates to itself", ErrInvalidTaskTemplate, path)
+			}
+		}
+		return nil
+	})
+}
+
+// walkBlueprints calls visit with every blueprint of the tree, parents before their subtasks, and its
+// path, such as 1.0 for the first subtask of the second task, stopping at the first error.
+func walkBlueprints(blueprints []models.TaskBlueprint, parent string, visit func(path string, blueprint models.TaskBlueprint) error) error {
+	for i, blueprint := range blueprints {
+		path := strconv.Itoa(i)
+		if parent != "" {
+			path = parent + "." + path
+		}
+		if err := visit(path, blueprint); err != nil {
+			return err
+		}
+		if err := walkBlueprints(blueprint.Subtasks, path, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *taskTemplateService) project(ctx context.Context, id int64) (*models.Project, error) {
+	project, err := s.projects.GetProject(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Warn("Project not found", zap.Int64("project_id", id))
+			return nil, ErrProjectNotFound
+		}
+		s.logger.Error("Failed to fetch project", zap.Error(err))
+		return nil, err
+	}
+	return project, nil
+}
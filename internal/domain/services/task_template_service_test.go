@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/models"
+	"github.com/Sunf1ower113/grpc-task-manager/internal/domain/repository"
+	"go.uber.org/zap/zaptest"
+)
+
+// mockTaskTemplateRepository keeps every version of every template.
+type mockTaskTemplateRepository struct {
+	versions map[int64][]models.TaskTemplate
+	err      error
+}
+
+func (m *mockTaskTemplateRepository) CreateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, versions := range m.versions {
+		if current := versions[len(versions)-1]; current.ProjectID == template.ProjectID && current.Name == template.Name {
+			return nil, repository.ErrAlreadyExists
+		}
+	}
+	template.ID = int64(len(m.versions) + 1)
+	template.Version = 1
+	m.versions[template.ID] = []models.TaskTemplate{*template}
+	return template, nil
+}
+
+func (m *mockTaskTemplateRepository) GetTaskTemplate(ctx context.Context, id int64, version int) (*models.TaskTemplate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	versions, ok := m.versions[id]
+	if !ok || version > len(versions) {
+		return nil, sql.ErrNoRows
+	}
+	if version == 0 {
+		version = len(versions)
+	}
+	stored := versions[version-1]
+	return &stored, nil
+}
+
+func (m *mockTaskTemplateRepository) ListTaskTemplates(ctx context.Context, projectID int64) ([]*models.TaskTemplate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var templates []*models.TaskTemplate
+	for id := int64(1); id <= int64(len(m.versions)); id++ {
+		if versions, ok := m.versions[id]; ok && versions[0].ProjectID == projectID {
+			stored := versions[len(versions)-1]
+			templates = append(templates, &stored)
+		}
+	}
+	return templates, nil
+}
+
+func (m *mockTaskTemplateRepository) UpdateTaskTemplate(ctx context.Context, template *models.TaskTemplate) (*models.TaskTemplate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	versions, ok := m.versions[template.ID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	template.Version = len(versions) + 1
+	m.versions[template.ID] = append(versions, *template)
+	return template, nil
+}
+
+func (m *mockTaskTemplateRepository) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.versions[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.versions, id)
+	return nil
+}
+
+func Test_taskTemplateService(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	onboarding := func() *models.TaskTemplate {
+		return &models.TaskTemplate{ProjectID: 1, Name: "Onboarding", Variables: []string{"name", "env"},
+			Tasks: []models.TaskBlueprint{
+				{Title: "Create an account for {{name}}", Description: "In {{ env }}", Labels: []string{"onboarding", "{{name}}"},
+					CustomFields: map[string]models.FieldValue{"env": {Type: models.FieldTypeEnum, String: "{{env}}"}}},
+				{Title: "Meet {{name}}", Description: "Say hello",
+					CustomFields: map[string]models.FieldValue{"points": {Type: models.FieldTypeNumber, Number: 1}}},
+			}}
+	}
+	newService := func() (*mockTaskTemplateRepository, *mockTaskRepository, TaskTemplateService) {
+		mockFields, mockProjects := newCustomFieldMocks()
+		mockTemplates := &mockTaskTemplateRepository{versions: map[int64][]models.TaskTemplate{}}
+		mockRepo := &mockTaskRepository{tasks: map[int64]*models.Task{}}
+		transactor := &rollbackTransactor{repo: mockRepo}
+		tasks := NewTaskService(mockRepo, mockProjects, mockFields, &mockTaskSearcher{}, transactor, &mockIDGenerator{}, logger)
+		return mockTemplates, mockRepo, NewTaskTemplateService(mockTemplates, mockProjects, tasks, transactor, logger)
+	}
+	// tree has a task with a subtask of its own that blocks it, and a task related to the subtask.
+	tree := func() *models.TaskTemplate {
+		return &models.TaskTemplate{ProjectID: 1, Name: "Release", Variables: []string{"version"},
+			Tasks: []models.TaskBlueprint{
+				{Ref: "release", Title: "Release {{version}}", Description: "Ship it", Subtasks: []models.TaskBlueprint{
+					{Ref: "notes", Title: "Write the notes of {{version}}", Description: "For users",
+						Relations: []models.BlueprintRelation{{Type: models.RelationBlocks, Ref: "release"}}},
+				}},
+				{Title: "Announce {{version}}", Description: "On the blog",
+					Relations: []models.BlueprintRelation{{Type: models.RelationRelatesTo, Ref: "notes"}}},
+			}}
+	}
+
+	t.Run("Create rejects invalid templates", func(t *testing.T) {
+		undeclared := onboarding()
+		undeclared.Variables = []string{"name"}
+		undeclaredInSubtask := tree()
+		undeclaredInSubtask.Tasks[0].Subtasks[0].Description = "For {{team}}"
+		reusedRef := tree()
+		reusedRef.Tasks[1].Ref = "notes"
+		unknownRef := tree()
+		unknownRef.Tasks[1].Relations[0].Ref = "blog"
+		unknownType := tree()
+		unknownType.Tasks[1].Relations[0].Type = "parent_of"
+		selfRelation := tree()
+		selfRelation.Tasks[0].Relations = []models.BlueprintRelation{{Type: models.RelationDuplicates, Ref: "release"}}
+		tests := []struct {
+			name     string
+			template *models.TaskTemplate
+			wantErr  error
+		}{
+			{name: "Undeclared variable", template: undeclared, wantErr: ErrInvalidTaskTemplate},
+			{name: "Undeclared variable in subtask", template: undeclaredInSubtask, wantErr: ErrInvalidTaskTemplate},
+			{name: "Reused ref", template: reusedRef, wantErr: ErrInvalidTaskTemplate},
+			{name: "Relation to unknown ref", template: unknownRef, wantErr: ErrInvalidTaskTemplate},
+			{name: "Relation of unknown type", template: unknownType, wantErr: ErrInvalidTaskTemplate},
+			{name: "Relation to itself", template: selfRelation, wantErr: ErrInvalidTaskTemplate},
+			{name: "Unknown project", template: &models.TaskTemplate{ProjectID: 9, Name: "T"}, wantErr: ErrProjectNotFound},
+			{name: "Archived project", template: &models.TaskTemplate{ProjectID: 3, Name: "T"}, wantErr: ErrProjectArchived},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, _, svc := newService()
+				if _, err := svc.CreateTaskTemplate(context.Background(), tt.template); !errors.Is(err, tt.wantErr) {
+					t.Errorf("CreateTaskTemplate() error = %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("Updates make new versions", func(t *testing.T) {
+		_, _, svc := newService()
+		created, err := svc.CreateTaskTemplate(context.Background(), onboarding())
+		if err != nil {
+			t.Fatalf("CreateTaskTemplate() unexpected error: %v", err)
+		}
+		if _, err := svc.CreateTaskTemplate(context.Background(), onboarding()); !errors.Is(err, ErrTaskTemplateNameTaken) {
+			t.Errorf("CreateTaskTemplate() duplicate error = %v, want %v", err, ErrTaskTemplateNameTaken)
+		}
+
+		update := onboarding()
+		update.ID = created.ID
+		update.ProjectID = 2
+		update.Tasks = update.Tasks[1:]
+		updated, err := svc.UpdateTaskTemplate(context.Background(), update)
+		if err != nil {
+			t.Fatalf("UpdateTaskTemplate() unexpected error: %v", err)
+		}
+		if updated.Version != 2 || updated.ProjectID != 1 {
+			t.Errorf("UpdateTaskTemplate() = version %d of project %d, want version 2 of project 1", updated.Version, updated.ProjectID)
+		}
+
+		first, err := svc.GetTaskTemplate(context.Background(), created.ID, 1)
+		if err != nil {
+			t.Fatalf("GetTaskTemplate() unexpected error: %v", err)
+		}
+		if len(first.Tasks) != 2 {
+			t.Errorf("GetTaskTemplate() version 1 has %d tasks, want 2", len(first.Tasks))
+		}
+		if _, err := svc.GetTaskTemplate(context.Background(), created.ID, 3); !errors.Is(err, ErrTaskTemplateNotFound) {
+			t.Errorf("GetTaskTemplate() of missing version error = %v, want %v", err, ErrTaskTemplateNotFound)
+		}
+
+		templates, err := svc.ListTaskTemplates(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("ListTaskTemplates() unexpected error: %v", err)
+		}
+		if len(templates) != 1 || templates[0].Version != 2 {
+			t.Errorf("ListTaskTemplates() = %+v, want the current version only", templates)
+		}
+
+		if err := svc.DeleteTaskTemplate(context.Background(), created.ID); err != nil {
+			t.Errorf("DeleteTaskTemplate() unexpected error: %v", err)
+		}
+		if err := svc.DeleteTaskTemplate(context.Background(), created.ID); !errors.Is(err, ErrTaskTemplateNotFound) {
+			t.Errorf("DeleteTaskTemplate() of deleted template error = %v, want %v", err, ErrTaskTemplateNotFound)
+		}
+	})
+
+	t.Run("Instantiate", func(t *testing.T) {
+		_, mockRepo, svc := newService()
+		created, err := svc.CreateTaskTemplate(context.Background(), onboarding())
+		if err != nil {
+			t.Fatalf("CreateTaskTemplate() unexpected error: %v", err)
+		}
+
+		instance, err := svc.InstantiateTemplate(context.Background(), created.ID, 0, map[string]string{"name": "ada", "env": "prod"})
+		if err != nil {
+			t.Fatalf("InstantiateTemplate() unexpected error: %v", err)
+		}
+		template, tasks := instance.Template, instance.Tasks
+		if template.Version != 1 || len(tasks) != 2 || len(mockRepo.tasks) != 2 {
+			t.Fatalf("InstantiateTemplate() created %d tasks from version %d, want 2 from version 1", len(tasks), template.Version)
+		}
+		first := tasks[0]
+		if first.Title != "Create an account for ada" || first.Description != "In prod" || first.Status != "open" || first.ExternalID == "" {
+			t.Errorf("InstantiateTemplate() first task = %+v", first)
+		}
+		if !reflect.DeepEqual(first.Labels, []string{"onboarding", "ada"}) {
+			t.Errorf("InstantiateTemplate() labels = %v, want [onboarding ada]", first.Labels)
+		}
+		if env := first.CustomFields["env"]; env.String != "prod" {
+			t.Errorf("InstantiateTemplate() env = %+v, want prod", env)
+		}
+		stored, _ := svc.GetTaskTemplate(context.Background(), created.ID, 0)
+		if stored.Tasks[0].Title != "Create an account for {{name}}" {
+			t.Errorf("InstantiateTemplate() changed the stored template: %q", stored.Tasks[0].Title)
+		}
+	})
+
+	t.Run("Instantiate is all or nothing", func(t *testing.T) {
+		_, mockRepo, svc := newService()
+		created, err := svc.CreateTaskTemplate(context.Background(), onboarding())
+		if err != nil {
+			t.Fatalf("CreateTaskTemplate() unexpected error: %v", err)
+		}
+
+		_, err = svc.InstantiateTemplate(context.Background(), created.ID, 0, map[string]string{"name": "ada", "env": "qa"})
+		var itemErr *BatchItemError
+		if !errors.As(err, &itemErr) || itemErr.Index != 0 || !errors.Is(err, ErrInvalidFieldValue) {
+			t.Errorf("InstantiateTemplate() error = %v, want %v for task 0", err, ErrInvalidFieldValue)
+		}
+		if len(mockRepo.tasks) != 0 {
+			t.Errorf("InstantiateTemplate() stored %d tasks of a failed instantiation", len(mockRepo.tasks))
+		}
+	})
+
+	t.Run("Instantiate checks the variables", func(t *testing.T) {
+		for _, values := range []map[string]string{
+			{"name": "ada"},
+			{"name": "ada", "env": "prod", "team": "ops"},
+		} {
+			_, mockRepo, svc := newService()
+			created, err := svc.CreateTaskTemplate(context.Background(), onboarding())
+			if err != nil {
+				t.Fatalf("CreateTaskTemplate() unexpected error: %v", err)
+			}
+			if _, err := svc.InstantiateTemplate(context.Background(), created.ID, 0, values); !errors.Is(err, ErrTemplateVariables) {
+				t.Errorf("InstantiateTemplate(%v) error = %v, want %v", values, err, ErrTemplateVariables)
+			}
+			if len(mockRepo.tasks) != 0 {
+				t.Errorf("InstantiateTemplate(%v) stored %d tasks", values, len(mockRepo.tasks))
+			}
+		}
+	})
+
+	t.Run("Instantiate creates the tree", func(t *testing.T) {
+		_, mockRepo, svc := newService()
+		created, err := svc.CreateTaskTemplate(context.Background(), tree())
+		if err != nil {
+			t.Fatalf("CreateTaskTemplate() unexpected error: %v", err)
+		}
+
+		instance, err := svc.InstantiateTemplate(context.Background(), created.ID, 0, map[string]string{"version": "2.0"})
+		if err != nil {
+			t.Fatalf("InstantiateTemplate() unexpected error: %v", err)
+		}
+		var titles []string
+		for _, task := range instance.Tasks {
+			titles = append(titles, task.Title)
+		}
+		if want := []string{"Release 2.0", "Announce 2.0", "Write the notes of 2.0"}; !reflect.DeepEqual(titles, want) {
+			t.Fatalf("InstantiateTemplate() created %v, want %v", titles, want)
+		}
+		release, announce, notes := instance.Tasks[0], instance.Tasks[1], instance.Tasks[2]
+		if notes.ParentID != release.ID || release.ParentID != 0 || announce.ParentID != 0 {
+			t.Errorf("InstantiateTemplate() parents = %d, %d, %d, want the release task as parent of the notes",
+				release.ParentID, announce.ParentID, notes.ParentID)
+		}
+		want := []models.TaskRelation{
+			{TaskID: announce.ID, RelatedTaskID: notes.ID, Type: models.RelationRelatesTo},
+			{TaskID: notes.ID, RelatedTaskID: release.ID, Type: models.RelationBlocks},
+		}
+		if !reflect.DeepEqual(instance.Relations, want) || !reflect.DeepEqual(mockRepo.relations, want) {
+			t.Errorf("InstantiateTemplate() relations = %v, stored %v, want %v", instance.Relations, mockRepo.relations, want)
+		}
+	})
+
+	t.Run("Instantiate creates the tree or nothing", func(t *testing.T) {
+		_, mockRepo, svc := newService()
+		template := tree()
+		template.Tasks[0].Subtasks[0].Status = "later"
+		created, err := svc.CreateTaskTemplate(context.Background(), template)
+		if err != nil {
+			t.Fatalf("CreateTaskTemplate() unexpected error: %v", err)
+		}
+
+		if _, err := svc.InstantiateTemplate(context.Background(), created.ID, 0, map[string]string{"version": "2.0"}); !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("InstantiateTemplate() error = %v, want %v", err, ErrInvalidStatus)
+		}
+		if len(mockRepo.tasks) != 0 || len(mockRepo.relations) != 0 {
+			t.Errorf("InstantiateTemplate() stored %d tasks and %d relations of a failed instantiation", len(mockRepo.tasks), len(mockRepo.relations))
+		}
+	})
+}
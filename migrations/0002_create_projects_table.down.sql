@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS tasks_project_id_idx;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS project_id,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS assignee,
+    DROP COLUMN IF EXISTS labels;
+
+DROP TABLE IF EXISTS projects;
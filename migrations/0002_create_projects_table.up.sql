@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    workflow TEXT[] NOT NULL DEFAULT '{}',
+    default_assignee TEXT NOT NULL DEFAULT '',
+    allowed_labels TEXT[] NOT NULL DEFAULT '{}',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects (id),
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'open',
+    ADD COLUMN IF NOT EXISTS assignee TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON tasks (project_id);
@@ -0,0 +1,12 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C" NOT NULL DEFAULT '';
+
+-- Existing tasks keep their creation order within each board column.
+UPDATE tasks
+SET rank = ranked.rank
+FROM (
+    SELECT id, lpad(to_hex(row_number() OVER (PARTITION BY project_id, status ORDER BY id)), 8, '0') || 'i' AS rank
+    FROM tasks
+) ranked
+WHERE tasks.id = ranked.id AND tasks.rank = '';
+
+CREATE INDEX IF NOT EXISTS tasks_column_rank_idx ON tasks (project_id, status, rank);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS task_key_aliases;
+
+DROP INDEX IF EXISTS tasks_key_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS key;
+
+DROP INDEX IF EXISTS projects_key_idx;
+ALTER TABLE projects
+    DROP COLUMN IF EXISTS key,
+    DROP COLUMN IF EXISTS task_counter;
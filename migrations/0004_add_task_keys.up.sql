@@ -0,0 +1,34 @@
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS key TEXT,
+    ADD COLUMN IF NOT EXISTS task_counter INTEGER NOT NULL DEFAULT 0;
+
+UPDATE projects SET key = 'P' || id WHERE key IS NULL;
+ALTER TABLE projects ALTER COLUMN key SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS projects_key_idx ON projects (key);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS key TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_key_idx ON tasks (key);
+
+-- Existing tasks of a project are numbered in creation order.
+WITH numbered AS (
+    SELECT tasks.id,
+           projects.key || '-' || (projects.task_counter + row_number() OVER (PARTITION BY tasks.project_id ORDER BY tasks.id)) AS key
+    FROM tasks
+    JOIN projects ON projects.id = tasks.project_id
+    WHERE tasks.key IS NULL
+), keyed AS (
+    UPDATE tasks SET key = numbered.key
+    FROM numbered
+    WHERE tasks.id = numbered.id
+    RETURNING tasks.project_id
+)
+UPDATE projects
+SET task_counter = task_counter + counts.keyed
+FROM (SELECT project_id, count(*) AS keyed FROM keyed GROUP BY project_id) counts
+WHERE projects.id = counts.project_id;
+
+-- Keys a task had in projects it was moved out of keep resolving to it.
+CREATE TABLE IF NOT EXISTS task_key_aliases (
+    key TEXT PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE
+);
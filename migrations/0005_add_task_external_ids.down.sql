@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_project_rank_external_id_idx;
+DROP INDEX IF EXISTS tasks_external_id_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,27 @@
+-- External IDs are ULIDs or UUIDv7s generated by the application. They are compared
+-- byte-wise so that their order matches creation time and the Go string order.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS external_id TEXT COLLATE "C";
+
+-- Existing tasks get UUIDv7s built from their creation time, whatever ID_STRATEGY is configured,
+-- so they sort by creation time as well.
+UPDATE tasks
+SET external_id = generated.external_id
+FROM (
+    SELECT id,
+           substr(bits, 1, 8) || '-' || substr(bits, 9, 4) || '-7' || substr(bits, 14, 3) || '-' ||
+           substr('89ab', 1 + ('x' || substr(bits, 17, 1))::bit(4)::int % 4, 1) || substr(bits, 18, 3) || '-' ||
+           substr(bits, 21, 12) AS external_id
+    FROM (
+        SELECT id,
+               lpad(to_hex((extract(epoch FROM COALESCE(created_at, NOW())) * 1000)::bigint), 12, '0') ||
+               md5(random()::text || id::text) AS bits
+        FROM tasks
+        WHERE external_id IS NULL
+    ) random_bits
+) generated
+WHERE tasks.id = generated.id;
+
+ALTER TABLE tasks ALTER COLUMN external_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_external_id_idx ON tasks (external_id);
+-- Serves the keyset pagination of ListTasks.
+CREATE INDEX IF NOT EXISTS tasks_project_rank_external_id_idx ON tasks (project_id, rank, external_id);
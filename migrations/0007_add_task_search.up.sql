@@ -0,0 +1,7 @@
+-- The text search configuration is stored per task so that the generated column stays immutable;
+-- the server sets the column default to its configured language at startup.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_language REGCONFIG NOT NULL DEFAULT 'english';
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector(search_language, title), 'A') || setweight(to_tsvector(search_language, description), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
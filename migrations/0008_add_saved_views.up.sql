@@ -0,0 +1,18 @@
+-- Saved views are deleted with their project. Filters, orders and columns referring to custom fields
+-- or labels that no longer exist are kept and reported as broken parts.
+CREATE TABLE IF NOT EXISTS saved_views (
+    id SERIAL PRIMARY KEY,
+    owner TEXT NOT NULL,
+    name TEXT NOT NULL,
+    project_id INTEGER REFERENCES projects (id) ON DELETE CASCADE,
+    filter TEXT NOT NULL DEFAULT '',
+    order_by TEXT,
+    order_descending BOOLEAN NOT NULL DEFAULT FALSE,
+    columns TEXT[] NOT NULL DEFAULT '{}',
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (owner, name)
+);
+
+CREATE INDEX IF NOT EXISTS saved_views_shared_idx ON saved_views (shared) WHERE shared;
@@ -0,0 +1,12 @@
+-- Idempotency keys remember mutating requests and their responses so that retried requests are
+-- replayed instead of applied twice. Rows without a response belong to requests still in progress.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash BYTEA NOT NULL,
+    response_type TEXT,
+    response BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
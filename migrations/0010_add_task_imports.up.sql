@@ -0,0 +1,11 @@
+-- Task imports remember which task each imported record became, keyed by the source it came from and
+-- its ID there, so that running an import again skips the records imported before.
+CREATE TABLE IF NOT EXISTS task_imports (
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_imports_task_id_idx ON task_imports (task_id);
@@ -0,0 +1,21 @@
+-- Task templates keep every version they had, so that instantiating an older version stays possible and
+-- editing a template never touches the tasks created from it. Templates are deleted with their project.
+CREATE TABLE IF NOT EXISTS task_templates (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_template_versions (
+    template_id INTEGER NOT NULL REFERENCES task_templates (id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    variables TEXT[] NOT NULL DEFAULT '{}',
+    tasks JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (template_id, version)
+);
@@ -0,0 +1,12 @@
+-- Every change to a task row is announced on the task_changes channel with the task ID as payload,
+-- so that servers caching tasks drop their copies whichever server or statement changed them.
+CREATE OR REPLACE FUNCTION notify_task_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('task_changes', OLD.id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_notify_change ON tasks;
+CREATE TRIGGER tasks_notify_change AFTER UPDATE OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION notify_task_change();
@@ -0,0 +1,65 @@
+-- The records cannot return to task_key_aliases and task_imports while their tasks are archived, so
+-- they are dropped, as archiving did before.
+DROP TABLE IF EXISTS task_imports_archive;
+DROP TABLE IF EXISTS task_key_aliases_archive;
+
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_task_id_fkey;
+ALTER TABLE task_imports DROP CONSTRAINT IF EXISTS task_imports_task_id_fkey;
+ALTER SEQUENCE tasks_id_seq OWNED BY NONE;
+ALTER TABLE tasks RENAME TO tasks_partitioned;
+
+CREATE TABLE tasks (
+    id INTEGER PRIMARY KEY DEFAULT nextval('tasks_id_seq'),
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    project_id INTEGER REFERENCES projects (id),
+    status TEXT NOT NULL DEFAULT 'open',
+    assignee TEXT NOT NULL DEFAULT '',
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    rank TEXT COLLATE "C" NOT NULL DEFAULT '',
+    key TEXT,
+    external_id TEXT COLLATE "C" NOT NULL,
+    custom_fields JSONB NOT NULL DEFAULT '{}',
+    search_language REGCONFIG NOT NULL DEFAULT 'english',
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector(search_language, title), 'A') || setweight(to_tsvector(search_language, description), 'B')
+    ) STORED
+);
+
+ALTER SEQUENCE tasks_id_seq OWNED BY tasks.id;
+
+-- Archived tasks return to tasks rather than being dropped with the archive. Their IDs, keys and
+-- external IDs were taken from tasks and are never handed out again, so they cannot collide.
+INSERT INTO tasks (id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, search_language,
+    created_at, updated_at)
+SELECT id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, search_language,
+    created_at, updated_at
+FROM tasks_partitioned;
+
+INSERT INTO tasks (id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, created_at, updated_at)
+SELECT id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, created_at, updated_at
+FROM tasks_archive;
+
+DROP TABLE tasks_partitioned;
+DROP TABLE IF EXISTS tasks_archive;
+DROP TABLE IF EXISTS task_identities;
+DROP FUNCTION IF EXISTS track_task_identity();
+DROP FUNCTION IF EXISTS create_task_partition(TEXT, TIMESTAMPTZ);
+
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON tasks (project_id);
+CREATE INDEX IF NOT EXISTS tasks_column_rank_idx ON tasks (project_id, status, rank);
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_key_idx ON tasks (key);
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_external_id_idx ON tasks (external_id);
+CREATE INDEX IF NOT EXISTS tasks_project_rank_external_id_idx ON tasks (project_id, rank, external_id);
+CREATE INDEX IF NOT EXISTS tasks_custom_fields_idx ON tasks USING GIN (custom_fields jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
+
+CREATE TRIGGER tasks_notify_change AFTER UPDATE OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION notify_task_change();
+
+ALTER TABLE task_key_aliases
+    ADD CONSTRAINT task_key_aliases_task_id_fkey FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE;
+ALTER TABLE task_imports
+    ADD CONSTRAINT task_imports_task_id_fkey FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE;
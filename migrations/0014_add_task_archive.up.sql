@@ -0,0 +1,176 @@
+-- Tasks are partitioned by the month they were created in, in UTC, and so is tasks_archive, to which the
+-- archiver of the server moves closed tasks older than the retention period. Old months can then be
+-- detached, dumped or dropped as a whole. The server creates the partitions of the current and the next
+-- month ahead of time; tasks of a month without a partition go to the default partition.
+
+-- create_task_partition creates the partition of parent, tasks or tasks_archive, holding the tasks created
+-- in the month of created, such as tasks_2026_03, unless it exists. If tasks of that month already went to
+-- the default partition, they stay there and no partition is created, as it would have to take them over.
+CREATE OR REPLACE FUNCTION create_task_partition(parent TEXT, created TIMESTAMPTZ) RETURNS void AS $$
+DECLARE
+    first_day TIMESTAMP := date_trunc('month', created AT TIME ZONE 'UTC');
+    partition_name TEXT := parent || '_' || to_char(first_day, 'YYYY_MM');
+    lower_bound TIMESTAMPTZ := first_day AT TIME ZONE 'UTC';
+    upper_bound TIMESTAMPTZ := (first_day + interval '1 month') AT TIME ZONE 'UTC';
+    defaulted BOOLEAN;
+BEGIN
+    -- Several servers may need the same partition at once.
+    PERFORM pg_advisory_xact_lock(hashtext(parent));
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN;
+    END IF;
+
+    EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE created_at >= %L AND created_at < %L)',
+        parent || '_default', lower_bound, upper_bound) INTO defaulted;
+    IF defaulted THEN
+        RAISE WARNING 'tasks created in % stay in %', to_char(first_day, 'YYYY-MM'), parent || '_default';
+        RETURN;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)', partition_name, parent, lower_bound, upper_bound);
+END;
+$$ LANGUAGE plpgsql;
+
+-- The partition key cannot be NULL. Tasks written before the database set their timestamps may lack one.
+UPDATE tasks SET created_at = COALESCE(updated_at, now()) WHERE created_at IS NULL;
+
+-- The foreign keys into tasks move to task_identities below, and the ID sequence to the new table.
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_task_id_fkey;
+ALTER TABLE task_imports DROP CONSTRAINT IF EXISTS task_imports_task_id_fkey;
+ALTER SEQUENCE tasks_id_seq OWNED BY NONE;
+ALTER TABLE tasks RENAME TO tasks_unpartitioned;
+
+CREATE TABLE tasks (
+    id INTEGER NOT NULL DEFAULT nextval('tasks_id_seq'),
+    external_id TEXT COLLATE "C" NOT NULL,
+    project_id INTEGER REFERENCES projects (id),
+    key TEXT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    assignee TEXT NOT NULL DEFAULT '',
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    rank TEXT COLLATE "C" NOT NULL DEFAULT '',
+    custom_fields JSONB NOT NULL DEFAULT '{}',
+    search_language REGCONFIG NOT NULL DEFAULT 'english',
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector(search_language, title), 'A') || setweight(to_tsvector(search_language, description), 'B')
+    ) STORED,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+ALTER SEQUENCE tasks_id_seq OWNED BY tasks.id;
+CREATE TABLE IF NOT EXISTS tasks_default PARTITION OF tasks DEFAULT;
+
+SELECT create_task_partition('tasks', month)
+FROM (
+    SELECT DISTINCT date_trunc('month', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS month FROM tasks_unpartitioned
+    UNION
+    SELECT now()
+    UNION
+    SELECT now() + interval '1 month'
+) months;
+
+INSERT INTO tasks (id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, search_language,
+    created_at, updated_at)
+SELECT id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, search_language,
+    created_at, updated_at
+FROM tasks_unpartitioned;
+
+DROP TABLE tasks_unpartitioned;
+
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON tasks (project_id);
+CREATE INDEX IF NOT EXISTS tasks_column_rank_idx ON tasks (project_id, status, rank);
+CREATE INDEX IF NOT EXISTS tasks_key_idx ON tasks (key);
+CREATE INDEX IF NOT EXISTS tasks_external_id_idx ON tasks (external_id);
+CREATE INDEX IF NOT EXISTS tasks_project_rank_external_id_idx ON tasks (project_id, rank, external_id);
+CREATE INDEX IF NOT EXISTS tasks_custom_fields_idx ON tasks USING GIN (custom_fields jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
+
+CREATE TRIGGER tasks_notify_change AFTER UPDATE OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION notify_task_change();
+
+-- Unique indexes of a partitioned table only hold within a partition, so task_identities keeps the ID, key
+-- and external ID of every live task and makes them unique across all months. It is what task_key_aliases
+-- and task_imports reference, and its rows come and go with the tasks.
+CREATE TABLE IF NOT EXISTS task_identities (
+    id INTEGER PRIMARY KEY,
+    external_id TEXT COLLATE "C" NOT NULL,
+    key TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS task_identities_external_id_idx ON task_identities (external_id);
+CREATE UNIQUE INDEX IF NOT EXISTS task_identities_key_idx ON task_identities (key);
+
+INSERT INTO task_identities (id, external_id, key)
+SELECT id, external_id, key FROM tasks;
+
+CREATE OR REPLACE FUNCTION track_task_identity() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_identities (id, external_id, key) VALUES (NEW.id, NEW.external_id, NEW.key);
+    ELSIF TG_OP = 'UPDATE' THEN
+        UPDATE task_identities SET external_id = NEW.external_id, key = NEW.key WHERE id = OLD.id;
+    ELSE
+        DELETE FROM task_identities WHERE id = OLD.id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_track_identity AFTER INSERT OR UPDATE OF external_id, key OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION track_task_identity();
+
+ALTER TABLE task_key_aliases
+    ADD CONSTRAINT task_key_aliases_task_id_fkey FOREIGN KEY (task_id) REFERENCES task_identities (id) ON DELETE CASCADE;
+ALTER TABLE task_imports
+    ADD CONSTRAINT task_imports_task_id_fkey FOREIGN KEY (task_id) REFERENCES task_identities (id) ON DELETE CASCADE;
+
+-- Archived tasks keep their project, which therefore cannot be deleted while it has any.
+CREATE TABLE IF NOT EXISTS tasks_archive (
+    id INTEGER NOT NULL,
+    external_id TEXT COLLATE "C" NOT NULL,
+    project_id INTEGER REFERENCES projects (id),
+    key TEXT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL,
+    assignee TEXT NOT NULL,
+    labels TEXT[] NOT NULL,
+    rank TEXT COLLATE "C" NOT NULL,
+    custom_fields JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ,
+    archived_at TIMESTAMPTZ NOT NULL
+) PARTITION BY RANGE (created_at);
+
+-- The archiver creates the partition of a month before moving tasks of that month, so the default
+-- partition only takes tasks of months whose partition could not be created.
+CREATE TABLE IF NOT EXISTS tasks_archive_default PARTITION OF tasks_archive DEFAULT;
+
+CREATE INDEX IF NOT EXISTS tasks_archive_id_idx ON tasks_archive (id);
+CREATE INDEX IF NOT EXISTS tasks_archive_key_idx ON tasks_archive (key);
+CREATE INDEX IF NOT EXISTS tasks_archive_external_id_idx ON tasks_archive (external_id);
+CREATE INDEX IF NOT EXISTS tasks_archive_project_rank_external_id_idx ON tasks_archive (project_id, rank, external_id);
+
+-- The key aliases and import records of archived tasks move to these tables along with the tasks, so that
+-- the old keys of archived tasks keep resolving and imports run again skip the records whose tasks were
+-- archived. Like tasks_archive, they reference no task.
+CREATE TABLE IF NOT EXISTS task_key_aliases_archive (
+    key TEXT PRIMARY KEY,
+    task_id INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS task_key_aliases_archive_task_id_idx ON task_key_aliases_archive (task_id);
+
+CREATE TABLE IF NOT EXISTS task_imports_archive (
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    task_id BIGINT NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (source, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_imports_archive_task_id_idx ON task_imports_archive (task_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS task_relations;
+
+DROP INDEX IF EXISTS tasks_parent_id_idx;
+ALTER TABLE tasks_archive DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,18 @@
+-- A subtask names the task it belongs to in parent_id, in the project of that task. The column references
+-- no task, as unique keys of the partitioned tasks only hold within a month; the server detaches the
+-- subtasks of the tasks it deletes instead. Archived subtasks keep their parent.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id INTEGER;
+ALTER TABLE tasks_archive ADD COLUMN IF NOT EXISTS parent_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS tasks_parent_id_idx ON tasks (parent_id);
+
+-- Relations read from task_id to related_task_id, such as task_id blocks related_task_id. They end when
+-- either task is deleted or archived.
+CREATE TABLE IF NOT EXISTS task_relations (
+    task_id INTEGER NOT NULL REFERENCES task_identities (id) ON DELETE CASCADE,
+    related_task_id INTEGER NOT NULL REFERENCES task_identities (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    PRIMARY KEY (task_id, related_task_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS task_relations_related_task_id_idx ON task_relations (related_task_id);
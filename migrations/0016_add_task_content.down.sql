@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS task_attachments;
+DROP TABLE IF EXISTS task_comments;
+DROP TABLE IF EXISTS task_checklist_items;
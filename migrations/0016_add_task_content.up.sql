@@ -0,0 +1,34 @@
+-- Checklists, comments and attachments of live tasks. Like relations, they end when their task is
+-- deleted or archived.
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    task_id INTEGER NOT NULL REFERENCES task_identities (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT false
+);
+
+-- Not unique, so that a checklist can be replaced by one statement deleting and inserting its items.
+CREATE INDEX IF NOT EXISTS task_checklist_items_task_id_idx ON task_checklist_items (task_id, position);
+
+CREATE TABLE IF NOT EXISTS task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES task_identities (id) ON DELETE CASCADE,
+    author TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_comments_task_id_idx ON task_comments (task_id, id);
+
+-- Attachments link to files kept elsewhere.
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES task_identities (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    content_type TEXT NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_attachments_task_id_idx ON task_attachments (task_id, id);
@@ -0,0 +1,27 @@
+// Package migrations embeds the versioned schema of the task manager. Every version has a
+// NNNN_name.up.sql file applying it and a NNNN_name.down.sql file reverting it. Up migrations
+// guard their statements with IF NOT EXISTS and backfills with WHERE clauses, so that they also
+// apply cleanly to databases created before the schema was versioned.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite returns the migration files of the SQLite task store, which versions a schema of its own.
+func SQLite() fs.FS {
+	sub, err := fs.Sub(sqliteFS, "sqlite")
+	if err != nil {
+		panic(err) // the directory is embedded above
+	}
+	return sub
+}
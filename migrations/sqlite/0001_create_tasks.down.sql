@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS task_imports;
+DROP TABLE IF EXISTS task_key_aliases;
+DROP TABLE IF EXISTS task_counters;
+DROP TABLE IF EXISTS tasks;
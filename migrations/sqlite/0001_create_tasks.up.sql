@@ -0,0 +1,41 @@
+-- Tasks of the SQLite store. Labels and custom fields are JSON, timestamps are Unix nanoseconds,
+-- and AUTOINCREMENT keeps IDs of deleted tasks from being handed out again, as in Postgres.
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    external_id TEXT NOT NULL UNIQUE,
+    project_id INTEGER,
+    key TEXT UNIQUE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT '',
+    assignee TEXT NOT NULL DEFAULT '',
+    labels TEXT NOT NULL DEFAULT '[]',
+    rank TEXT NOT NULL DEFAULT '',
+    custom_fields TEXT NOT NULL DEFAULT '{}',
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tasks_column_rank_idx ON tasks (project_id, status, rank);
+CREATE INDEX IF NOT EXISTS tasks_project_rank_external_id_idx ON tasks (project_id, rank, external_id);
+
+-- Projects live in Postgres, so the last task number of each project is kept here.
+CREATE TABLE IF NOT EXISTS task_counters (
+    project_id INTEGER PRIMARY KEY,
+    counter INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_key_aliases (
+    key TEXT PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS task_imports (
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    imported_at INTEGER NOT NULL,
+    PRIMARY KEY (source, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_imports_task_id_idx ON task_imports (task_id);
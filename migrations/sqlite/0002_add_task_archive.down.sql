@@ -0,0 +1,9 @@
+-- The records cannot return to task_key_aliases and task_imports while their tasks are archived.
+DROP TABLE IF EXISTS task_imports_archive;
+DROP TABLE IF EXISTS task_key_aliases_archive;
+
+INSERT INTO tasks (id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, created_at, updated_at)
+SELECT id, external_id, project_id, key, title, description, status, assignee, labels, rank, custom_fields, created_at, updated_at
+FROM tasks_archive;
+
+DROP TABLE IF EXISTS tasks_archive;
@@ -0,0 +1,41 @@
+-- Closed tasks older than the retention period are moved here by the archiver of the server. SQLite has
+-- no table partitioning, so the archive is a single table; archived_at is in Unix nanoseconds.
+CREATE TABLE IF NOT EXISTS tasks_archive (
+    id INTEGER NOT NULL,
+    external_id TEXT NOT NULL,
+    project_id INTEGER,
+    key TEXT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL,
+    assignee TEXT NOT NULL,
+    labels TEXT NOT NULL,
+    rank TEXT NOT NULL,
+    custom_fields TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    archived_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tasks_archive_id_idx ON tasks_archive (id);
+CREATE INDEX IF NOT EXISTS tasks_archive_key_idx ON tasks_archive (key);
+CREATE INDEX IF NOT EXISTS tasks_archive_external_id_idx ON tasks_archive (external_id);
+CREATE INDEX IF NOT EXISTS tasks_archive_project_rank_external_id_idx ON tasks_archive (project_id, rank, external_id);
+
+-- The key aliases and import records of archived tasks move here along with the tasks, as in Postgres.
+CREATE TABLE IF NOT EXISTS task_key_aliases_archive (
+    key TEXT PRIMARY KEY,
+    task_id INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS task_key_aliases_archive_task_id_idx ON task_key_aliases_archive (task_id);
+
+CREATE TABLE IF NOT EXISTS task_imports_archive (
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    task_id INTEGER NOT NULL,
+    imported_at INTEGER NOT NULL,
+    PRIMARY KEY (source, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS task_imports_archive_task_id_idx ON task_imports_archive (task_id);
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS tasks_fts_update;
+DROP TRIGGER IF EXISTS tasks_fts_delete;
+DROP TRIGGER IF EXISTS tasks_fts_insert;
+DROP TABLE IF EXISTS tasks_fts;
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS task_template_versions;
+DROP TABLE IF EXISTS task_templates;
+DROP TABLE IF EXISTS saved_views;
+DROP TABLE IF EXISTS custom_fields;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,15 @@
+-- Subtasks and task relations, as in Postgres. parent_id references no task either, so that archiving a
+-- parent leaves its subtasks alone.
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER;
+ALTER TABLE tasks_archive ADD COLUMN parent_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS tasks_parent_id_idx ON tasks (parent_id);
+
+CREATE TABLE IF NOT EXISTS task_relations (
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    related_task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    PRIMARY KEY (task_id, related_task_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS task_relations_related_task_id_idx ON task_relations (related_task_id);
@@ -0,0 +1,356 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrSchemaTooNew is returned when the database has migrations applied that this build does not know,
+// typically because a newer release migrated it. Running against such a schema could corrupt data.
+var ErrSchemaTooNew = errors.New("database schema is newer than this build understands")
+
+// migrationLockID is the key of the advisory lock that keeps concurrent replicas from migrating at once.
+const migrationLockID = 73184002
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema, read from its up and down files.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration is applied.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown marks versions applied to the database that this build has no files for.
+	Unknown bool
+}
+
+// Migrator applies and reverts the migrations of a database, recording the applied versions
+// in the schema_migrations table. Every migration runs in a transaction of its own together
+// with its record, so a failed migration leaves neither changes nor a record behind.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *zap.Logger
+}
+
+// NewMigrator reads the migrations in fsys, see LoadMigrations.
+func NewMigrator(db *sql.DB, fsys fs.FS, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// LoadMigrations reads the NNNN_name.up.sql and NNNN_name.down.sql files at the root of fsys and
+// returns the migrations ordered by version. Every version needs both files.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, path := range paths {
+		match := migrationFilePattern.FindStringSubmatch(path)
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named NNNN_name.up.sql or NNNN_name.down.sql", path)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %s has an invalid version", path)
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Latest returns the newest version known to the migrator, or 0 if it has no migrations.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations in version order and returns how many it applied.
+// It fails with ErrSchemaTooNew, applying nothing, if the database is ahead of the migrator.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var count int
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.checkApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, migration, migration.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				migration.Version, migration.Name); err != nil {
+				return err
+			}
+			m.logger.Info("Applied migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the n most recently applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && n > 0; i-- {
+			status := statuses[i]
+			if !status.Applied {
+				continue
+			}
+			if status.Unknown {
+				return fmt.Errorf("%w: cannot revert migration %d", ErrSchemaTooNew, status.Version)
+			}
+			migration := m.migration(status.Version)
+			if err := m.run(ctx, conn, migration, migration.Down, `DELETE FROM schema_migrations WHERE version = $1`,
+				migration.Version); err != nil {
+				return err
+			}
+			m.logger.Info("Reverted migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+			n--
+		}
+		return nil
+	})
+}
+
+// Status lists the known migrations followed by the unknown versions applied to the database.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		statuses, err = m.status(ctx, conn)
+		return err
+	})
+	return statuses, err
+}
+
+// Force records the database as being at version without running any migration: the known migrations
+// up to version count as applied and all later ones as pending. It repairs the records after a schema
+// was changed by hand, and baselines databases created before the schema was versioned.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && m.migration(version).Version == 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version > $1`, version); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING`,
+				migration.Version, migration.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		m.logger.Warn("Forced schema version", zap.Int64("version", version))
+		return nil
+	})
+}
+
+// Check returns the number of pending migrations, failing with ErrSchemaTooNew if the database
+// is ahead of the migrator. It changes nothing but creating the schema_migrations table.
+func (m *Migrator) Check(ctx context.Context) (int, error) {
+	var pending int
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.checkApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok {
+				pending++
+			}
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// locked runs fn on a single connection holding the migration advisory lock, creating the
+// schema_migrations table first. Replicas starting at the same time wait for each other here.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		// The lock belongs to the session, so it is released even if ctx ended meanwhile.
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			m.logger.Error("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// run executes the statements of a migration and the statement recording it in one transaction.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, statements, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied returns the applied versions with the time they were applied.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]MigrationStatus)
+	for rows.Next() {
+		status := MigrationStatus{Applied: true}
+		if err := rows.Scan(&status.Version, &status.Name, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+// checkApplied returns the applied versions like applied, failing with ErrSchemaTooNew if any of
+// them is newer than the latest known migration.
+func (m *Migrator) checkApplied(ctx context.Context, conn *sql.Conn) (map[int64]MigrationStatus, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for version := range applied {
+		if version > m.Latest() {
+			return nil, fmt.Errorf("%w: database is at version %d, this build knows up to %d", ErrSchemaTooNew, version, m.Latest())
+		}
+	}
+	return applied, nil
+}
+
+// status merges the known migrations with the applied versions, ordered by version.
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		record.Unknown = true
+		statuses = append(statuses, record)
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return cmp.Compare(a.Version, b.Version) })
+	return statuses, nil
+}
+
+// migration returns the known migration of version, or a zero Migration.
+func (m *Migrator) migration(version int64) Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return Migration{}
+}
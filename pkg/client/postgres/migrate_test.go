@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Sunf1ower113/grpc-task-manager/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_things.up.sql":   {Data: []byte("CREATE TABLE things (id INT)")},
+	"0001_create_things.down.sql": {Data: []byte("DROP TABLE things")},
+	"0002_add_names.up.sql":       {Data: []byte("ALTER TABLE things ADD COLUMN name TEXT")},
+	"0002_add_names.down.sql":     {Data: []byte("ALTER TABLE things DROP COLUMN name")},
+}
+
+func setupMigrator(t *testing.T) (*sql.DB, sqlmock.Sqlmock, *Migrator) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db, testMigrations, zap.NewNop())
+	require.NoError(t, err)
+	return db, mock, migrator
+}
+
+// expectLocked expects the statements every migrator command starts with, returning the applied versions.
+func expectLocked(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, "migration", time.Now())
+	}
+	mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations").WillReturnRows(rows)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Embedded migrations are complete and consecutive", func(t *testing.T) {
+		loaded, err := LoadMigrations(migrations.FS)
+		require.NoError(t, err)
+		require.NotEmpty(t, loaded)
+		for i, migration := range loaded {
+			assert.Equal(t, int64(i+1), migration.Version)
+		}
+	})
+
+	t.Run("Orders by version", func(t *testing.T) {
+		loaded, err := LoadMigrations(testMigrations)
+		require.NoError(t, err)
+		require.Len(t, loaded, 2)
+		assert.Equal(t, Migration{Version: 2, Name: "add_names", Up: "ALTER TABLE things ADD COLUMN name TEXT",
+			Down: "ALTER TABLE things DROP COLUMN name"}, loaded[1])
+	})
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "Missing down file", files: fstest.MapFS{"0001_create.up.sql": {Data: []byte("SELECT 1")}}},
+		{name: "Invalid name", files: fstest.MapFS{"create.sql": {Data: []byte("SELECT 1")}}},
+		{name: "Conflicting names", files: fstest.MapFS{
+			"0001_create.up.sql":  {Data: []byte("SELECT 1")},
+			"0001_other.down.sql": {Data: []byte("SELECT 1")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, migrator := setupMigrator(t)
+	defer db.Close()
+
+	expectLocked(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE things ADD COLUMN name TEXT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "add_names").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_SchemaTooNew(t *testing.T) {
+	db, mock, migrator := setupMigrator(t)
+	defer db.Close()
+
+	expectLocked(mock, 1, 2, 3)
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err := migrator.Up(context.Background())
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_Failure(t *testing.T) {
+	db, mock, migrator := setupMigrator(t)
+	defer db.Close()
+
+	expectLocked(mock)
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE things").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.Background())
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Zero(t, applied)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, migrator := setupMigrator(t)
+	defer db.Close()
+
+	expectLocked(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE things DROP COLUMN name").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations WHERE version = \\$1").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, migrator.Down(context.Background(), 1))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, migrator := setupMigrator(t)
+	defer db.Close()
+
+	expectLocked(mock, 1, 7)
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.Equal(t, "add_names", statuses[1].Name)
+	assert.True(t, statuses[2].Unknown)
+	assert.Equal(t, int64(7), statuses[2].Version)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Force(t *testing.T) {
+	db, mock, migrator := setupMigrator(t)
+	defer db.Close()
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schema_migrations WHERE version > \\$1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(1, "create_things").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, migrator.Force(context.Background(), 1))
+	assert.Error(t, migrator.Force(context.Background(), 5))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
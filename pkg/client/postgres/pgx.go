@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// NewPool establishes a pgx connection pool as configured in cfg, retrying like ConnectDB.
+// It expects the schema to be migrated already, as NewDB does.
+func NewPool(cfg Config, logger *zap.Logger) (*pgxpool.Pool, error) {
+	poolConfig, err := newPoolConfig(cfg)
+	if err != nil {
+		logger.Error("Invalid database configuration", zap.Error(err))
+		return nil, err
+	}
+
+	var pool *pgxpool.Pool
+	err = connect(cfg, func() error {
+		var err error
+		pool, err = pgxpool.ConnectConfig(context.Background(), poolConfig)
+		if err != nil {
+			return err
+		}
+		if err = pool.Ping(context.Background()); err != nil {
+			pool.Close()
+		}
+		return err
+	})
+	if err != nil {
+		logger.Error("Error creating database connection pool", zap.Error(err))
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// OpenPool creates a pgx connection pool as configured in cfg that connects on first use, like OpenDB.
+func OpenPool(cfg Config, logger *zap.Logger) (*pgxpool.Pool, error) {
+	poolConfig, err := newPoolConfig(cfg)
+	if err != nil {
+		logger.Error("Invalid database configuration", zap.Error(err))
+		return nil, err
+	}
+	poolConfig.LazyConnect = true
+	return pgxpool.ConnectConfig(context.Background(), poolConfig)
+}
+
+// newPoolConfig translates cfg into the settings of a pgx pool.
+func newPoolConfig(cfg Config) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns)
+	}
+	poolConfig.MinConns = int32(cfg.MinConns)
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	poolConfig.ConnConfig.BuildStatementCache = nil
+	if capacity := cfg.StatementCacheCapacity; capacity > 0 {
+		poolConfig.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModePrepare, capacity)
+		}
+	}
+	return poolConfig, nil
+}
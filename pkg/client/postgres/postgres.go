@@ -1,83 +1,166 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/Sunf1ower113/grpc-task-manager/migrations"
 	"go.uber.org/zap"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
-// ConnectDB attempts to establish a connection to the PostgreSQL database.
-// It retries up to 5 times with a 5-second interval between retries in case of failures.
-func ConnectDB(dsn string) (*sql.DB, error) {
-	var db *sql.DB
-	var err error
+// Config describes the database to connect to and how to size the connection pool.
+type Config struct {
+	User     string
+	Password string
+	DBName   string
+	Host     string
+	Port     string
+	// SSLMode is the libpq sslmode, e.g. disable, require or verify-full.
+	SSLMode string
+	// SSLRootCert is the file of the CA certificates the server certificate is verified with.
+	SSLRootCert string
+	// MaxConns and MinConns bound the number of open connections; MinConns is only honoured by pgx.
+	MaxConns int
+	MinConns int
+	// MaxConnLifetime is how long a connection is used before it is replaced.
+	MaxConnLifetime time.Duration
+	// HealthCheckPeriod is how often pgx checks its idle connections.
+	HealthCheckPeriod time.Duration
+	// StatementCacheCapacity is the number of prepared statements pgx keeps per connection; zero disables the cache.
+	StatementCacheCapacity int
+	// ConnectAttempts and RetryInterval control how long connecting is retried, e.g. while the database starts.
+	ConnectAttempts int
+	RetryInterval   time.Duration
+	// MigrateOnStart makes NewDB apply pending migrations.
+	MigrateOnStart bool
 
-	for i := 0; i < 5; i++ {
-		db, err = sql.Open("postgres", dsn)
-		if err == nil {
-			err = db.Ping()
-		}
-		if err == nil {
-			log.Println("Database connection established successfully")
-			return db, nil
-		}
+	// connString, if set by ForReplica, replaces the connection settings above.
+	connString string
+}
 
-		log.Printf("Failed to connect to database: %v. Retrying in 5 seconds...", err)
-		time.Sleep(5 * time.Second)
+// ForReplica returns the settings for connecting to the read replica at dsn, a connection string in
+// either format libpq accepts, with the pool settings of cfg.
+func (cfg Config) ForReplica(dsn string) Config {
+	cfg.connString = dsn
+	cfg.MigrateOnStart = false
+	return cfg
+}
+
+// DSN returns the connection string of cfg in the keyword/value format understood by both lib/pq and pgx,
+// or the connection string of the replica for settings returned by ForReplica.
+func (cfg Config) DSN() string {
+	if cfg.connString != "" {
+		return cfg.connString
+	}
+	params := []string{
+		"user=" + dsnValue(cfg.User),
+		"password=" + dsnValue(cfg.Password),
+		"dbname=" + dsnValue(cfg.DBName),
+		"host=" + dsnValue(cfg.Host),
+		"port=" + dsnValue(cfg.Port),
+		"sslmode=" + dsnValue(cfg.SSLMode),
 	}
+	if cfg.SSLRootCert != "" {
+		params = append(params, "sslrootcert="+dsnValue(cfg.SSLRootCert))
+	}
+	return strings.Join(params, " ")
+}
 
-	return nil, fmt.Errorf("failed to connect to database after 5 attempts: %w", err)
+// dsnValue quotes a connection string value, so values may be empty or contain spaces and quotes.
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
 }
 
-// NewDB initializes a new PostgreSQL database connection with the given parameters.
-// It also ensures the necessary tables are created.
-func NewDB(user, password, dbName, host, port string, logger *zap.Logger) (*sql.DB, error) {
-	connStr := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=disable",
-		user, password, dbName, host, port)
+// connect calls open until it succeeds, at most cfg.ConnectAttempts times with cfg.RetryInterval in between.
+func connect(cfg Config, open func() error) error {
+	attempts := max(cfg.ConnectAttempts, 1)
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = open(); err == nil {
+			log.Println("Database connection established successfully")
+			return nil
+		}
+		if i < attempts-1 {
+			log.Printf("Failed to connect to database: %v. Retrying in %s...", err, cfg.RetryInterval)
+			time.Sleep(cfg.RetryInterval)
+		}
+	}
 
-	db, err := ConnectDB(connStr)
+	return fmt.Errorf("failed to connect to database after %d attempts: %w", attempts, err)
+}
+
+// ConnectDB establishes a database/sql connection pool using lib/pq, retrying as configured in cfg.
+func ConnectDB(cfg Config) (*sql.DB, error) {
+	var db *sql.DB
+	err := connect(cfg, func() error {
+		var err error
+		db, err = OpenDB(cfg)
+		if err != nil {
+			return err
+		}
+		if err = db.Ping(); err != nil {
+			db.Close()
+		}
+		return err
+	})
 	if err != nil {
-		logger.Error("Error creating database connection", zap.Error(err))
 		return nil, err
 	}
+	return db, nil
+}
 
-	err = createTables(db, logger)
+// OpenDB opens a database/sql connection pool using lib/pq without connecting yet, such as for read
+// replicas that must not keep the server from starting while they are unavailable.
+func OpenDB(cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.DSN())
 	if err != nil {
-		logger.Error("Error creating tables", zap.Error(err))
 		return nil, err
 	}
 
+	if cfg.MaxConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxConns)
+		db.SetMaxIdleConns(cfg.MaxConns)
+	}
+	db.SetConnMaxLifetime(cfg.MaxConnLifetime)
+
 	return db, nil
 }
 
-// createTables ensures the required tables exist in the database.
-func createTables(db *sql.DB, logger *zap.Logger) error {
-	var queries []string
-
-	tasks := `
-CREATE TABLE IF NOT EXISTS tasks (
-    id SERIAL PRIMARY KEY,
-    title TEXT NOT NULL,
-    description TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
-)
-`
-	queries = append(queries, tasks)
+// NewDB initializes a new PostgreSQL database connection as configured in cfg. It then applies the
+// pending migrations, or with cfg.MigrateOnStart unset only checks that there are none. Either way it
+// fails with ErrSchemaTooNew on a schema migrated by a newer release.
+func NewDB(cfg Config, logger *zap.Logger) (*sql.DB, error) {
+	db, err := ConnectDB(cfg)
+	if err != nil {
+		logger.Error("Error creating database connection", zap.Error(err))
+		return nil, err
+	}
 
-	for _, query := range queries {
-		_, err := db.Exec(query)
-		if err != nil {
-			logger.Error("Failed to execute query", zap.String("query", query), zap.Error(err))
-			return err
+	migrator, err := NewMigrator(db, migrations.FS, logger)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if cfg.MigrateOnStart {
+		_, err = migrator.Up(context.Background())
+	} else {
+		var pending int
+		pending, err = migrator.Check(context.Background())
+		if pending > 0 {
+			logger.Warn("Database schema has pending migrations", zap.Int("pending", pending))
 		}
 	}
+	if err != nil {
+		logger.Error("Error migrating database schema", zap.Error(err))
+		db.Close()
+		return nil, err
+	}
 
-	logger.Info("Tables created successfully")
-	return nil
+	return db, nil
 }
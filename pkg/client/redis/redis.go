@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Config describes the Redis server to connect to.
+type Config struct {
+	// Addr is the host:port of the server.
+	Addr     string
+	Password string
+	DB       int
+}
+
+// Client is a connection pool to a Redis server offering the plain byte-valued commands the task
+// cache needs. It is safe for concurrent use.
+type Client struct {
+	client *goredis.Client
+}
+
+// NewClient connects to the Redis server of cfg and checks that it answers.
+func NewClient(cfg Config, logger *zap.Logger) (*Client, error) {
+	client := goredis.NewClient(&goredis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Error("Error connecting to Redis", zap.String("addr", cfg.Addr), zap.Error(err))
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", cfg.Addr, err)
+	}
+
+	logger.Info("Redis connection established", zap.String("addr", cfg.Addr))
+	return &Client{client: client}, nil
+}
+
+// Get returns the value of key and whether the key exists.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, expiring it after ttl unless ttl is zero.
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Del removes the keys, ignoring those that do not exist.
+func (c *Client) Del(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// Scan returns a page of the keys matching the glob pattern match, starting at cursor, and the cursor of
+// the next page, which is zero after the last one.
+func (c *Client) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return c.client.Scan(ctx, cursor, match, count).Result()
+}
+
+// Close closes the connections to the server.
+func (c *Client) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
+	"go.uber.org/zap"
+)
+
+// Migrate applies the pending migrations in fsys, named like the Postgres migrations, and returns how many
+// it applied. Each migration runs in a transaction of its own together with its record in schema_migrations,
+// and as transactions take the write lock up front, concurrent processes apply every migration only once.
+// It fails with postgres.ErrSchemaTooNew, applying nothing, if the database is ahead of fsys.
+func Migrate(ctx context.Context, db *sql.DB, fsys fs.FS, logger *zap.Logger) (int, error) {
+	migrations, err := postgres.LoadMigrations(fsys)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var latest int64
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	var applied int64
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&applied); err != nil {
+		return 0, err
+	}
+	if applied > latest {
+		return 0, fmt.Errorf("%w: database is at version %d, this build knows up to %d", postgres.ErrSchemaTooNew, applied, latest)
+	}
+
+	var count int
+	for _, migration := range migrations {
+		ran, err := apply(ctx, db, migration)
+		if err != nil {
+			return count, err
+		}
+		if ran {
+			logger.Info("Applied migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+			count++
+		}
+	}
+	return count, nil
+}
+
+// apply runs a migration unless it is recorded as applied, checking inside the transaction so that
+// a migration applied meanwhile by another process is skipped.
+func apply(ctx context.Context, db *sql.DB, migration postgres.Migration) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.Version).Scan(&exists)
+	if err != nil || exists {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return false, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+		migration.Version, migration.Name, time.Now().UnixNano())
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
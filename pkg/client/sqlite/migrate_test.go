@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/migrations"
+	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	cfg := Config{Path: filepath.Join(t.TempDir(), "nested", "tasks.db"), BusyTimeout: time.Second}
+	db, err := NewDB(cfg, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestNewDB(t *testing.T) {
+	db := openTestDB(t)
+
+	var mode string
+	require.NoError(t, db.QueryRow(`PRAGMA journal_mode`).Scan(&mode))
+	assert.Equal(t, "wal", mode)
+	var timeout int
+	require.NoError(t, db.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout))
+	assert.Equal(t, 1000, timeout)
+
+	var version int64
+	require.NoError(t, db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	loaded, err := postgres.LoadMigrations(migrations.SQLite())
+	require.NoError(t, err)
+	assert.Equal(t, loaded[len(loaded)-1].Version, version)
+}
+
+func TestMigrate(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	files := fstest.MapFS{
+		"0001_create_tasks.up.sql":                 {Data: []byte("SELECT 1")},
+		"0001_create_tasks.down.sql":               {Data: []byte("SELECT 1")},
+		"0002_add_task_archive.up.sql":             {Data: []byte("SELECT 1")},
+		"0002_add_task_archive.down.sql":           {Data: []byte("SELECT 1")},
+		"0003_add_resources.up.sql":                {Data: []byte("SELECT 1")},
+		"0003_add_resources.down.sql":              {Data: []byte("SELECT 1")},
+		"0004_add_subtasks_and_relations.up.sql":   {Data: []byte("SELECT 1")},
+		"0004_add_subtasks_and_relations.down.sql": {Data: []byte("SELECT 1")},
+		"0005_add_task_content.up.sql":             {Data: []byte("SELECT 1")},
+		"0005_add_task_content.down.sql":           {Data: []byte("SELECT 1")},
+		"0006_add_notes.up.sql":                    {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY); CREATE INDEX notes_id_idx ON notes (id)")},
+		"0006_add_notes.down.sql":                  {Data: []byte("DROP TABLE notes")},
+	}
+
+	applied, err := Migrate(ctx, db, files, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	_, err = db.Exec(`INSERT INTO notes (id) VALUES (1)`)
+	assert.NoError(t, err)
+
+	applied, err = Migrate(ctx, db, files, zap.NewNop())
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+
+	delete(files, "0006_add_notes.up.sql")
+	delete(files, "0006_add_notes.down.sql")
+	_, err = Migrate(ctx, db, files, zap.NewNop())
+	assert.ErrorIs(t, err, postgres.ErrSchemaTooNew)
+}
+
+func TestMigrate_Failure(t *testing.T) {
+	db := openTestDB(t)
+	files := fstest.MapFS{
+		"0006_broken.up.sql":   {Data: []byte("CREATE TABLE half (id INTEGER); NOT SQL")},
+		"0006_broken.down.sql": {Data: []byte("SELECT 1")},
+	}
+
+	_, err := Migrate(context.Background(), db, files, zap.NewNop())
+	assert.Error(t, err)
+
+	var tables int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'half'`).Scan(&tables))
+	assert.Zero(t, tables)
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sunf1ower113/grpc-task-manager/migrations"
+	"go.uber.org/zap"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver
+)
+
+// Config describes the SQLite database file tasks are stored in.
+type Config struct {
+	// Path is the database file, created along with its directory if missing.
+	Path string
+	// BusyTimeout is how long a write waits for another connection to finish its write before failing.
+	BusyTimeout time.Duration
+}
+
+// DSN returns the connection string of cfg. Every connection uses write-ahead logging, so readers
+// never block the writer and vice versa, waits up to BusyTimeout for the write lock, and enforces
+// foreign keys. Transactions take the write lock when they begin rather than at their first write,
+// as a transaction upgrading its lock later fails at once instead of waiting out the busy timeout.
+func (cfg Config) DSN() string {
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeout.Milliseconds()))
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Add("_pragma", "foreign_keys(ON)")
+	params.Set("_txlock", "immediate")
+	return "file:" + cfg.Path + "?" + params.Encode()
+}
+
+// NewDB opens the database of cfg and applies its pending migrations.
+func NewDB(cfg Config, logger *zap.Logger) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		logger.Error("Error creating database directory", zap.Error(err))
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", cfg.DSN())
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		logger.Error("Error opening SQLite database", zap.String("path", cfg.Path), zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := Migrate(context.Background(), db, migrations.SQLite(), logger); err != nil {
+		logger.Error("Error migrating SQLite database", zap.Error(err))
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -3,13 +3,16 @@ package grpc_test_test
 import (
 	"context"
 	"database/sql"
+	grpcadapter "github.com/Sunf1ower113/grpc-task-manager/internal/adapters/grpc"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/composites"
 	"github.com/Sunf1ower113/grpc-task-manager/internal/config"
 	"github.com/Sunf1ower113/grpc-task-manager/pkg/client/postgres"
 	pb "github.com/Sunf1ower113/grpc-task-manager/proto"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"log"
 	"net"
@@ -36,28 +39,73 @@ func setupTestEnvironment(t *testing.T) (pb.TaskManagerClient, func()) {
 		t.Fatalf("Failed to initialize logger: %v", err)
 	}
 
-	database, err := postgres.NewDB(
-		appConfig.DBUser,
-		appConfig.DBPassword,
-		appConfig.DBName,
-		appConfig.DBHost,
-		appConfig.DBPort,
-		logger,
-	)
+	database, err := postgres.NewDB(appConfig.Postgres(), logger)
 	if err != nil {
 		t.Fatalf("Failed to initialize database: %v", err)
 	}
 
 	cleanupDatabase(database, t)
 
-	taskComposite, err := composites.NewTaskComposite(database, logger)
+	var pool *pgxpool.Pool
+	switch appConfig.DBDriver {
+	case "pq":
+	case "pgx":
+		pool, err = postgres.NewPool(appConfig.Postgres(), logger)
+		if err != nil {
+			t.Fatalf("Failed to initialize database connection pool: %v", err)
+		}
+	default:
+		t.Fatalf("Unknown database driver %q", appConfig.DBDriver)
+	}
+
+	store := composites.Store{Driver: "postgres", Postgres: database}
+	projectComposite, err := composites.NewProjectComposite(store, logger)
+	if err != nil {
+		t.Fatalf("Failed to initialize project composite: %v", err)
+	}
+
+	customFieldComposite, err := composites.NewCustomFieldComposite(store, projectComposite.Repository, logger)
+	if err != nil {
+		t.Fatalf("Failed to initialize custom field composite: %v", err)
+	}
+
+	taskStore := composites.TaskStore{Pool: pool, TxAttempts: appConfig.DBTxMaxAttempts}
+	taskComposite, err := composites.NewTaskComposite(store, taskStore, projectComposite.Repository, customFieldComposite.Repository,
+		appConfig.IDStrategy, appConfig.SearchLanguage, appConfig.BatchMaxSize, grpcadapter.NewCalendarTokens(appConfig.CalendarFeedSecret, appConfig.CalendarFeedTokenTTL), logger)
 	if err != nil {
 		t.Fatalf("Failed to initialize task composite: %v", err)
 	}
 
-	server := grpc.NewServer()
+	savedViewComposite, err := composites.NewSavedViewComposite(store, projectComposite.Repository, customFieldComposite.Repository,
+		taskComposite.Service, logger)
+	if err != nil {
+		t.Fatalf("Failed to initialize saved view composite: %v", err)
+	}
+
+	taskTemplateComposite, err := composites.NewTaskTemplateComposite(store, projectComposite.Repository, taskComposite.Service,
+		taskComposite.Transactor, logger)
+	if err != nil {
+		t.Fatalf("Failed to initialize task template composite: %v", err)
+	}
+
+	idempotencyComposite, err := composites.NewIdempotencyComposite(store, appConfig.IdempotencyTTL,
+		appConfig.IdempotencyPurgeInterval, appConfig.RPCTimeout, logger)
+	if err != nil {
+		t.Fatalf("Failed to initialize idempotency composite: %v", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcadapter.NewDeadlineInterceptor(appConfig.RPCTimeout, logger), grpcadapter.NewCallerInterceptor(),
+			grpcadapter.NewTimeZoneInterceptor(), idempotencyComposite.Interceptor),
+		grpc.ChainStreamInterceptor(grpcadapter.NewStreamContextInterceptor(logger), grpcadapter.NewStreamCallerInterceptor(),
+			grpcadapter.NewStreamTimeZoneInterceptor()),
+	)
 
 	pb.RegisterTaskManagerServer(server, taskComposite.Handler)
+	pb.RegisterProjectManagerServer(server, projectComposite.Handler)
+	pb.RegisterCustomFieldManagerServer(server, customFieldComposite.Handler)
+	pb.RegisterSavedViewManagerServer(server, savedViewComposite.Handler)
+	pb.RegisterTaskTemplateManagerServer(server, taskTemplateComposite.Handler)
 
 	address := appConfig.GRPCHost + ":" + appConfig.GRPCPort
 	go func() {
@@ -81,6 +129,9 @@ func setupTestEnvironment(t *testing.T) (pb.TaskManagerClient, func()) {
 	cleanup := func() {
 		conn.Close()
 		server.Stop()
+		if pool != nil {
+			pool.Close()
+		}
 		database.Close()
 		logger.Sync()
 	}
@@ -206,6 +257,55 @@ func TestUpdateTask(t *testing.T) {
 	}
 }
 
+func TestGetTask_TimeZone(t *testing.T) {
+	client, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	createResp, err := client.CreateTask(ctx, &pb.CreateTaskRequest{
+		Title:       "Task in Tokyo",
+		Description: "Task description",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	tokyoCtx := metadata.AppendToOutgoingContext(ctx, "time-zone", "Asia/Tokyo")
+	getResp, err := client.GetTask(tokyoCtx, &pb.GetTaskRequest{Id: createResp.Id})
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+
+	for name, tt := range map[string]struct {
+		formatted string
+		instant   time.Time
+	}{
+		"created_at": {getResp.CreatedAt, getResp.CreateTime.AsTime()},
+		"updated_at": {getResp.UpdatedAt, getResp.UpdateTime.AsTime()},
+	} {
+		formatted, err := time.Parse(time.RFC3339, tt.formatted)
+		if err != nil {
+			t.Fatalf("%s %q is not an RFC 3339 timestamp: %v", name, tt.formatted, err)
+		}
+		if _, offset := formatted.Zone(); offset != 9*60*60 {
+			t.Errorf("%s %q is not in the time zone of the time-zone header", name, tt.formatted)
+		}
+		if !formatted.Equal(tt.instant.Truncate(time.Second)) {
+			t.Errorf("%s %q is not the instant %v of the Timestamp field", name, tt.formatted, tt.instant)
+		}
+	}
+	if createResp.CreatedAt == getResp.CreatedAt {
+		t.Errorf("created_at %q is rendered in UTC without the time-zone header and in Tokyo with it alike", getResp.CreatedAt)
+	}
+
+	_, err = client.GetTask(metadata.AppendToOutgoingContext(ctx, "time-zone", "Mars/Olympus_Mons"), &pb.GetTaskRequest{Id: createResp.Id})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unknown time zone, got %v", err)
+	}
+}
+
 func TestCreateTask_EmptyTitle(t *testing.T) {
 	client, cleanup := setupTestEnvironment(t)
 	defer cleanup()